@@ -250,6 +250,53 @@ type BackupStatus struct {
 
 	// Whether the backup was online/hot (`true`) or offline/cold (`false`)
 	Online *bool `json:"online,omitempty"`
+
+	// The most recent restores of this backup, starting from the oldest
+	// one. Only a limited number of restores is retained
+	// +optional
+	RestoreHistory []BackupRestoreRecord `json:"restoreHistory,omitempty"`
+}
+
+// BackupRestoreRecord records the outcome of a restore of a backup
+type BackupRestoreRecord struct {
+	// The name of the cluster restored from the backup
+	ClusterName string `json:"clusterName"`
+
+	// The namespace of the cluster restored from the backup
+	ClusterNamespace string `json:"clusterNamespace"`
+
+	// The ID of the base backup that has been restored, which is an
+	// older one when the restore used a `backupFallback` policy
+	// +optional
+	BackupID string `json:"backupID,omitempty"`
+
+	// When the restore started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// When the restore ended
+	CompletedAt metav1.Time `json:"completedAt"`
+
+	// How long the restore lasted
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// True when the restore succeeded
+	Succeeded bool `json:"succeeded"`
+
+	// The error which made the restore fail
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// The amount of WAL replayed after the end of the base backup,
+	// in bytes
+	// +optional
+	ReplayedBytes int64 `json:"replayedBytes,omitempty"`
+
+	// True when the recovery reached the requested recovery target. Not set
+	// when no recovery target has been requested
+	// +optional
+	TargetReached *bool `json:"targetReached,omitempty"`
 }
 
 // InstanceID contains the information to identify an instance
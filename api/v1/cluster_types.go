@@ -18,6 +18,7 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"slices"
@@ -27,9 +28,11 @@ import (
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 
 	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
@@ -126,6 +129,25 @@ const (
 	// MissingWALDiskSpaceExitCode is the exit code the instance manager
 	// will use to signal that there's no more WAL disk space
 	MissingWALDiskSpaceExitCode = 4
+
+	// RestoreConfigurationErrorExitCode is the exit code the restore job
+	// will use to signal that the restore can't work with the current
+	// configuration, and won't succeed until it is changed
+	RestoreConfigurationErrorExitCode = 10
+
+	// RestoreTransientFailureExitCode is the exit code the restore job
+	// will use to signal a failure that may not happen again when the
+	// restore is retried, such as a network error
+	RestoreTransientFailureExitCode = 11
+
+	// RestoreTimeoutExitCode is the exit code the restore job will use
+	// to signal that a phase of the restore didn't complete within its timeout
+	RestoreTimeoutExitCode = 12
+
+	// RestoreTargetNotReachedExitCode is the exit code the restore job
+	// will use to signal that the recovery ended without reaching the
+	// requested recovery target
+	RestoreTargetNotReachedExitCode = 13
 )
 
 // SnapshotOwnerReference defines the reference type for the owner of the snapshot.
@@ -966,6 +988,344 @@ type ClusterStatus struct {
 	// WAL file, and Time of latest checkpoint
 	// +optional
 	DemotionToken string `json:"demotionToken,omitempty"`
+
+	// ZeroedPages reports the damaged pages that have been zeroed out while
+	// recovering the cluster with `zeroDamagedPages` enabled
+	// +optional
+	ZeroedPages *ZeroedPagesReport `json:"zeroedPages,omitempty"`
+
+	// RecoveryTarget reports the point reached by the recovery, compared
+	// with the recovery target requested while bootstrapping the cluster
+	// +optional
+	RecoveryTarget *RecoveryTargetReport `json:"recoveryTarget,omitempty"`
+
+	// RecoveryLocale reports the locale of the restored databases, compared
+	// with the one of the image used while recovering the cluster
+	// +optional
+	RecoveryLocale *RecoveryLocaleReport `json:"recoveryLocale,omitempty"`
+
+	// CatalogSummary reports the catalog summary of the restored cluster,
+	// compared with the expected one requested while recovering the cluster
+	// +optional
+	CatalogSummary *CatalogSummaryReport `json:"catalogSummary,omitempty"`
+
+	// RestoredBackup reports the base backup restored while recovering
+	// the cluster with a `backupFallback` policy, and the ones that
+	// couldn't be restored before it
+	// +optional
+	RestoredBackup *RestoredBackupReport `json:"restoredBackup,omitempty"`
+
+	// DataSource reports the method chosen to build the data directory
+	// while recovering the cluster with a `dataSourcePolicy`, and the
+	// reason of the choice
+	// +optional
+	DataSource *RecoveryDataSourceReport `json:"dataSource,omitempty"`
+
+	// PreparedTransactions reports the prepared transactions found in the
+	// restored instance, and the action taken on them, while recovering
+	// the cluster with the `preparedTransactions` detection enabled
+	// +optional
+	PreparedTransactions *PreparedTransactionsReport `json:"preparedTransactions,omitempty"`
+
+	// RecoveryProgress reports the progress of the WAL replay while
+	// recovering the cluster from a backup
+	// +optional
+	RecoveryProgress *RecoveryProgressReport `json:"recoveryProgress,omitempty"`
+
+	// RestoreState is the last state entered by the restore of the
+	// cluster from an object store. An interrupted restore is resumed,
+	// when possible, from this state
+	// +optional
+	RestoreState RestoreState `json:"restoreState,omitempty"`
+
+	// RestoreHeartbeat reports when the restore of the cluster from an
+	// object store last made progress, when a `heartbeat` has been
+	// requested while recovering it
+	// +optional
+	RestoreHeartbeat *RestoreHeartbeat `json:"restoreHeartbeat,omitempty"`
+
+	// RestoreResult summarizes the WAL replay done by the recovery of
+	// the cluster from a backup, once it is completed
+	// +optional
+	RestoreResult *RestoreResult `json:"restoreResult,omitempty"`
+
+	// ConnectionRamp reports the connection limits lowered during the
+	// warm-up of the restored cluster, when a `connectionRamp` has been
+	// requested while recovering it
+	// +optional
+	ConnectionRamp *ConnectionRampReport `json:"connectionRamp,omitempty"`
+
+	// WALLevel reports the `wal_level` of the restored cluster, compared
+	// with the one required by the `downstreamUse` requested while
+	// recovering it
+	// +optional
+	WALLevel *WALLevelReport `json:"walLevel,omitempty"`
+}
+
+// ZeroedPagesReport reports the damaged pages that have been zeroed out
+// during the recovery. The data contained in these pages is lost
+type ZeroedPagesReport struct {
+	// The number of pages zeroed out during the recovery
+	Count int `json:"count"`
+
+	// The zeroed pages, grouped by relation. Only the first pages found
+	// are listed, and Truncated is set when the list is incomplete
+	// +optional
+	Relations []ZeroedPagesRelation `json:"relations,omitempty"`
+
+	// Truncated is true when not every zeroed page is listed in Relations
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ZeroedPagesRelation contains the pages of a relation that have been
+// zeroed out during the recovery
+type ZeroedPagesRelation struct {
+	// The path of the relation, relative to the data directory, as
+	// reported by PostgreSQL, i.e. `base/16384/16385`
+	Relation string `json:"relation"`
+
+	// The numbers of the zeroed blocks
+	Blocks []int64 `json:"blocks"`
+}
+
+// PreparedTransactionsReport reports the prepared transactions found in
+// the restored instance once the recovery was completed
+type PreparedTransactionsReport struct {
+	// The action taken on the prepared transactions
+	Action RecoveryPreparedTransactionsAction `json:"action"`
+
+	// The prepared transactions, the oldest first
+	// +optional
+	Transactions []PreparedTransactionReport `json:"transactions,omitempty"`
+}
+
+// PreparedTransactionReport describes a prepared transaction
+// found in the restored instance
+type PreparedTransactionReport struct {
+	// The global identifier of the transaction
+	GID string `json:"gid"`
+
+	// The database the transaction has been prepared in
+	Database string `json:"database"`
+
+	// The role which prepared the transaction
+	Owner string `json:"owner"`
+
+	// When the transaction has been prepared, in RFC 3339 format
+	Prepared string `json:"prepared"`
+}
+
+// RecoveryTargetReport reports the point reached by the recovery,
+// together with the requested recovery target
+type RecoveryTargetReport struct {
+	// The recovery target requested by the user
+	Requested RecoveryTarget `json:"requested"`
+
+	// The LSN of the last WAL record replayed during the recovery
+	// +optional
+	ReachedLSN string `json:"reachedLSN,omitempty"`
+
+	// The commit time of the last transaction replayed during the recovery
+	// +optional
+	ReachedTime string `json:"reachedTime,omitempty"`
+
+	// Reached is true when PostgreSQL stopped the recovery at the requested
+	// target, and false when the recovery ended before reaching it
+	Reached bool `json:"reached"`
+
+	// ShutDown is true when PostgreSQL has been left shut down at the
+	// recovery target, as requested by `shutdownAtTarget`
+	// +optional
+	ShutDown bool `json:"shutDown,omitempty"`
+
+	// The time between the commit of the last replayed transaction and
+	// the requested target time, negative when the transaction committed
+	// after it. Only set when the target time is verified
+	// +optional
+	TimeGap *metav1.Duration `json:"timeGap,omitempty"`
+
+	// TimeVerified is true when the commit time of the last replayed
+	// transaction is consistent with the requested target time, and
+	// false otherwise. Only set when the target time is verified
+	// +optional
+	TimeVerified *bool `json:"timeVerified,omitempty"`
+}
+
+// RecoveryLocaleReport reports the locale of the restored databases,
+// and whether their collations match the ones of the image
+type RecoveryLocaleReport struct {
+	// The collation (`LC_COLLATE`) of the restored `template1` database
+	// +optional
+	SourceCollate string `json:"sourceCollate,omitempty"`
+
+	// The character classification (`LC_CTYPE`) of the restored
+	// `template1` database
+	// +optional
+	SourceCtype string `json:"sourceCtype,omitempty"`
+
+	// The version of the collation recorded in the restored `template1`
+	// database. Available starting from PostgreSQL 15
+	// +optional
+	SourceCollationVersion string `json:"sourceCollationVersion,omitempty"`
+
+	// The default locale of the image
+	// +optional
+	TargetLocale string `json:"targetLocale,omitempty"`
+
+	// The version of the collation of the `template1` database provided
+	// by the image. Available starting from PostgreSQL 15
+	// +optional
+	TargetCollationVersion string `json:"targetCollationVersion,omitempty"`
+
+	// The databases whose collations don't match the ones of the image
+	// +optional
+	MismatchedDatabases []string `json:"mismatchedDatabases,omitempty"`
+
+	// The databases whose collation-dependent indexes have been rebuilt
+	// +optional
+	ReindexedDatabases []string `json:"reindexedDatabases,omitempty"`
+}
+
+// RecoveryDataSourceReport reports how the data directory of the
+// restored instance has been built
+type RecoveryDataSourceReport struct {
+	// The method used to build the data directory
+	Method RecoveryDataSourceMethod `json:"method"`
+
+	// The reason why the method has been chosen
+	Reason string `json:"reason"`
+}
+
+// RestoredBackupReport reports the base backup that has been restored
+// when a fallback to older base backups was allowed
+type RestoredBackupReport struct {
+	// The ID of the restored base backup
+	BackupID string `json:"backupID"`
+
+	// The IDs of the base backups that couldn't be restored, starting
+	// from the one initially selected
+	// +optional
+	FailedBackupIDs []string `json:"failedBackupIDs,omitempty"`
+}
+
+// RecoveryProgressReport reports how much WAL has been replayed by
+// the recovery, updated every time the end of the recovery is checked
+type RecoveryProgressReport struct {
+	// The LSN from which the progress is measured: the end of the
+	// restored base backup when known, otherwise the first LSN
+	// observed as replayed
+	// +optional
+	StartLSN string `json:"startLSN,omitempty"`
+
+	// The LSN of the recovery target, known in advance only when the
+	// recovery target is an LSN
+	// +optional
+	TargetLSN string `json:"targetLSN,omitempty"`
+
+	// The last LSN replayed by the recovery
+	// +optional
+	ReplayedLSN string `json:"replayedLSN,omitempty"`
+
+	// The amount of WAL replayed since `startLSN`, in bytes
+	// +optional
+	ReplayedBytes int64 `json:"replayedBytes,omitempty"`
+
+	// The percentage of the WAL between `startLSN` and `targetLSN`
+	// that has been replayed. Available only when `targetLSN` is known
+	// +optional
+	PercentReplayed *int32 `json:"percentReplayed,omitempty"`
+}
+
+// RestoreState is a state of the restore of a cluster from an object store
+// +kubebuilder:validation:Enum=LoadBackup;RestoreData;WriteConfig;WaitRecovery;Configure;Done
+type RestoreState string
+
+const (
+	// RestoreStateLoadBackup is the state where the backup to be restored
+	// is chosen, and the object store containing it is checked
+	RestoreStateLoadBackup RestoreState = "LoadBackup"
+
+	// RestoreStateRestoreData is the state where the base backup
+	// is restored into PGDATA
+	RestoreStateRestoreData RestoreState = "RestoreData"
+
+	// RestoreStateWriteConfig is the state where the configuration
+	// of the recovery is written into PGDATA
+	RestoreStateWriteConfig RestoreState = "WriteConfig"
+
+	// RestoreStateWaitRecovery is the state where PostgreSQL is started
+	// and the end of the recovery is waited for
+	RestoreStateWaitRecovery RestoreState = "WaitRecovery"
+
+	// RestoreStateConfigure is the state where the recovered instance
+	// is configured
+	RestoreStateConfigure RestoreState = "Configure"
+
+	// RestoreStateDone is the state of a completed restore
+	RestoreStateDone RestoreState = "Done"
+)
+
+// RestoreHeartbeat reports the last progress made by the restore
+type RestoreHeartbeat struct {
+	// The state entered by the restore when the last progress was made
+	// +optional
+	Phase RestoreState `json:"phase,omitempty"`
+
+	// When the restore last made progress, like entering a new state,
+	// downloading more data, or replaying more WAL
+	LastProgressTime metav1.Time `json:"lastProgressTime"`
+}
+
+// IsStale checks if the restore made no progress for
+// longer than the passed threshold
+func (heartbeat *RestoreHeartbeat) IsStale(now time.Time, threshold time.Duration) bool {
+	return heartbeat != nil && now.Sub(heartbeat.LastProgressTime.Time) > threshold
+}
+
+// RestoreResult summarizes the WAL replay done by a recovery, to tell
+// a restore promoted right after the end of the base backup from one
+// replaying a large amount of WAL files
+type RestoreResult struct {
+	// True when any WAL record following the end of the base backup
+	// has been replayed
+	WALReplayed bool `json:"walReplayed"`
+
+	// The number of WAL segments, or parts of them, replayed after
+	// the end of the base backup
+	// +optional
+	ReplayedWALSegments int64 `json:"replayedWALSegments,omitempty"`
+
+	// The amount of WAL replayed after the end of the base backup,
+	// in bytes
+	// +optional
+	ReplayedBytes int64 `json:"replayedBytes,omitempty"`
+
+	// The LSN from which the WAL replay is measured: the end of the
+	// restored base backup when known, otherwise the first LSN observed
+	// as replayed
+	// +optional
+	StartLSN string `json:"startLSN,omitempty"`
+
+	// The last LSN replayed by the recovery
+	// +optional
+	EndLSN string `json:"endLSN,omitempty"`
+
+	// True when the recovery reached the requested recovery target, false
+	// when it ended earlier, for example because the WAL archive doesn't
+	// extend that far. Not set when no recovery target has been requested
+	// +optional
+	TargetReached *bool `json:"targetReached,omitempty"`
+
+	// The amount of WAL, in bytes, between the last LSN replayed and the
+	// requested LSN target, when it has not been reached
+	// +optional
+	TargetShortfallBytes int64 `json:"targetShortfallBytes,omitempty"`
+
+	// The time between the last transaction replayed and the requested
+	// time target, when it has not been reached
+	// +optional
+	TargetShortfallTime *metav1.Duration `json:"targetShortfallTime,omitempty"`
 }
 
 // SwitchReplicaClusterStatus contains all the statuses regarding the switch of a cluster to a replica cluster
@@ -996,6 +1356,27 @@ const (
 	ConditionBackup ClusterConditionType = "LastBackupSucceeded"
 	// ConditionClusterReady represents whether a cluster is Ready
 	ConditionClusterReady ClusterConditionType = "Ready"
+	// ConditionPostRestoreMaintenance represents whether the maintenance
+	// operations requested after a recovery have been completed
+	ConditionPostRestoreMaintenance ClusterConditionType = "PostRestoreMaintenanceCompleted"
+	// ConditionRestoreDownloadTimedOut represents whether the download
+	// of the base backup exceeded its timeout
+	ConditionRestoreDownloadTimedOut ClusterConditionType = "RestoreDownloadTimedOut"
+	// ConditionRestoreRecoveryTimedOut represents whether the recovery
+	// of the restored instance exceeded its timeout
+	ConditionRestoreRecoveryTimedOut ClusterConditionType = "RestoreRecoveryTimedOut"
+	// ConditionRestoreConfigurationTimedOut represents whether the configuration
+	// of the recovered instance exceeded its timeout
+	ConditionRestoreConfigurationTimedOut ClusterConditionType = "RestoreConfigurationTimedOut"
+	// ConditionRecoveryVerification represents whether the read-only
+	// verification of the instance paused at the recovery target passed
+	ConditionRecoveryVerification ClusterConditionType = "RecoveryVerificationPassed"
+	// ConditionRestoreCompleted represents the outcome of the restore job,
+	// and is set once the job terminates
+	ConditionRestoreCompleted ClusterConditionType = "RestoreCompleted"
+	// ConditionRestoreStalled represents whether the restore made no
+	// progress for longer than the stale threshold of its heartbeat
+	ConditionRestoreStalled ClusterConditionType = "RestoreStalled"
 )
 
 // A Condition that can be used to communicate the Backup progress
@@ -1068,12 +1449,80 @@ const (
 	// the WAL archiving is not working correctly
 	ConditionReasonContinuousArchivingFailing ConditionReason = "ContinuousArchivingFailing"
 
+	// ConditionReasonContinuousArchivingDisabled means that the WAL archiving has been
+	// intentionally kept disabled after the recovery of the cluster
+	ConditionReasonContinuousArchivingDisabled ConditionReason = "ContinuousArchivingDisabled"
+
 	// ClusterReady means that the condition changed because the cluster is ready and working properly
 	ClusterReady ConditionReason = "ClusterIsReady"
 
 	// ClusterIsNotReady means that the condition changed because the cluster is not ready
 	ClusterIsNotReady ConditionReason = "ClusterIsNotReady"
 
+	// ClusterPostRestoreMaintenancePending means that the cluster is working, but not ready
+	// because the maintenance operations requested after the recovery are still running
+	ClusterPostRestoreMaintenancePending ConditionReason = "PostRestoreMaintenancePending"
+
+	// ConditionReasonPostRestoreMaintenanceRunning means that the maintenance operations
+	// requested after the recovery have been started
+	ConditionReasonPostRestoreMaintenanceRunning ConditionReason = "PostRestoreMaintenanceRunning"
+
+	// ConditionReasonPostRestoreMaintenanceCompleted means that the maintenance operations
+	// requested after the recovery have been completed successfully
+	ConditionReasonPostRestoreMaintenanceCompleted ConditionReason = "PostRestoreMaintenanceCompleted"
+
+	// ConditionReasonPostRestoreMaintenanceFailed means that the maintenance operations
+	// requested after the recovery have been terminated with an error
+	ConditionReasonPostRestoreMaintenanceFailed ConditionReason = "PostRestoreMaintenanceFailed"
+
+	// ConditionReasonRestorePhaseTimedOut means that a phase of the restore
+	// has been aborted, as it didn't complete within its timeout
+	ConditionReasonRestorePhaseTimedOut ConditionReason = "RestorePhaseTimedOut"
+
+	// ConditionReasonRestorePhaseCompleted means that a phase of the restore
+	// has been completed within its timeout
+	ConditionReasonRestorePhaseCompleted ConditionReason = "RestorePhaseCompleted"
+
+	// ConditionReasonRecoveryVerificationPassed means that the instance paused
+	// at the recovery target passed the verification and is being promoted
+	ConditionReasonRecoveryVerificationPassed ConditionReason = "RecoveryVerificationPassed"
+
+	// ConditionReasonRecoveryVerificationFailed means that the instance paused
+	// at the recovery target didn't pass the verification, and stays paused
+	ConditionReasonRecoveryVerificationFailed ConditionReason = "RecoveryVerificationFailed"
+
+	// ConditionReasonRestoreHeartbeatStale means that the restore made
+	// no progress for longer than the stale threshold of its heartbeat
+	ConditionReasonRestoreHeartbeatStale ConditionReason = "RestoreHeartbeatStale"
+
+	// ConditionReasonRestoreProgressing means that the restore made
+	// progress within the stale threshold of its heartbeat
+	ConditionReasonRestoreProgressing ConditionReason = "RestoreProgressing"
+
+	// ConditionReasonRestoreSucceeded means that the restore job
+	// completed successfully
+	ConditionReasonRestoreSucceeded ConditionReason = "RestoreSucceeded"
+
+	// ConditionReasonRestoreConfigurationError means that the restore job
+	// failed because of the configuration of the restore
+	ConditionReasonRestoreConfigurationError ConditionReason = "RestoreConfigurationError"
+
+	// ConditionReasonRestoreTransientFailure means that the restore job
+	// failed because of an error that may not happen again on retry
+	ConditionReasonRestoreTransientFailure ConditionReason = "RestoreTransientFailure"
+
+	// ConditionReasonRestoreTimedOut means that the restore job failed
+	// because a phase of the restore didn't complete within its timeout
+	ConditionReasonRestoreTimedOut ConditionReason = "RestoreTimedOut"
+
+	// ConditionReasonRestoreTargetNotReached means that the restore job
+	// failed because the recovery target has not been reached
+	ConditionReasonRestoreTargetNotReached ConditionReason = "RestoreTargetNotReached"
+
+	// ConditionReasonRestoreFailed means that the restore job failed
+	// because of an error not falling in any other category
+	ConditionReasonRestoreFailed ConditionReason = "RestoreFailed"
+
 	// DetachedVolume is the reason that is set when we do a rolling upgrade to add a PVC volume to a cluster
 	DetachedVolume ConditionReason = "DetachedVolume"
 )
@@ -1777,100 +2226,1854 @@ type Import struct {
 	// +optional
 	PostImportApplicationSQL []string `json:"postImportApplicationSQL,omitempty"`
 
-	// When set to true, only the `pre-data` and `post-data` sections of
-	// `pg_restore` are invoked, avoiding data import. Default: `false`.
+	// When set to true, only the `pre-data` and `post-data` sections of
+	// `pg_restore` are invoked, avoiding data import. Default: `false`.
+	// +optional
+	SchemaOnly bool `json:"schemaOnly,omitempty"`
+
+	// The number of concurrent jobs used by `pg_restore` to import the
+	// data and create the indexes of each database. Default: `1`.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Jobs *int32 `json:"jobs,omitempty"`
+}
+
+// ImportSource describes the source for the logical snapshot
+type ImportSource struct {
+	// The name of the externalCluster used for import
+	// +optional
+	ExternalCluster string `json:"externalCluster,omitempty"`
+
+	// The volume containing the logical dumps to be imported, taken with
+	// `pg_dump` and, optionally, `pg_dumpall`. It is used in place of an
+	// external cluster
+	// +optional
+	Dump *LogicalDumpSource `json:"dump,omitempty"`
+}
+
+// LogicalDumpSource is a PVC containing the logical dumps of the
+// databases to be imported. Every database is contained in a file named
+// `<database>.dump`, in the custom format of `pg_dump` (`-Fc`). The
+// roles and the other global objects can be contained in a `globals.sql`
+// file, generated with `pg_dumpall --globals-only`
+type LogicalDumpSource struct {
+	// The name of the PVC containing the dumps. It is mounted in
+	// read-only mode in the import job
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// The directory of the volume containing the dumps, relative to
+	// its root (default: the root of the volume)
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// SQLRefs holds references to ConfigMaps or Secrets
+// containing SQL files. The references are processed in a specific order:
+// first, all Secrets are processed, followed by all ConfigMaps.
+// Within each group, the processing order follows the sequence specified
+// in their respective arrays.
+type SQLRefs struct {
+	// SecretRefs holds a list of references to Secrets
+	// +optional
+	SecretRefs []SecretKeySelector `json:"secretRefs,omitempty"`
+
+	// ConfigMapRefs holds a list of references to ConfigMaps
+	// +optional
+	ConfigMapRefs []ConfigMapKeySelector `json:"configMapRefs,omitempty"`
+}
+
+// HasElements returns true if it contains any Reference
+func (s *SQLRefs) HasElements() bool {
+	if s == nil {
+		return false
+	}
+
+	return len(s.ConfigMapRefs) != 0 ||
+		len(s.SecretRefs) != 0
+}
+
+// BootstrapRecovery contains the configuration required to restore
+// from an existing cluster using 3 methodologies: external cluster,
+// volume snapshots or backup objects. Full recovery and Point-In-Time
+// Recovery are supported.
+// The method can be also be used to create clusters in continuous recovery
+// (replica clusters), also supporting cascading replication when `instances` >
+// 1. Once the cluster exits recovery, the password for the superuser
+// will be changed through the provided secret.
+// Refer to the Bootstrap page of the documentation for more information.
+type BootstrapRecovery struct {
+	// The backup object containing the physical base backup from which to
+	// initiate the recovery procedure.
+	// Mutually exclusive with `source` and `volumeSnapshots`.
+	// +optional
+	Backup *BackupSource `json:"backup,omitempty"`
+
+	// The external cluster whose backup we will restore. This is also
+	// used as the name of the folder under which the backup is stored,
+	// so it must be set to the name of the source cluster
+	// Mutually exclusive with `backup`.
+	// +optional
+	Source string `json:"source,omitempty"`
+
+	// The external cluster whose object store contains the WAL files to
+	// be replayed, when they are archived in a different object store
+	// than the base backup. The base backup is restored as usual, while
+	// the `restore_command` fetches the WAL files from this object store.
+	// When not specified, the WAL files are fetched from the same object
+	// store as the base backup.
+	// Not allowed with `volumeSnapshots` and `local`.
+	// +optional
+	WALSource string `json:"walSource,omitempty"`
+
+	// The static PVC data source(s) from which to initiate the
+	// recovery procedure. Currently supporting `VolumeSnapshot`
+	// and `PersistentVolumeClaim` resources that map an existing
+	// PVC group, compatible with CloudNativePG, and taken with
+	// a cold backup copy on a fenced Postgres instance (limitation
+	// which will be removed in the future when online backup
+	// will be implemented).
+	// Mutually exclusive with `backup`.
+	// +optional
+	VolumeSnapshots *DataSource `json:"volumeSnapshots,omitempty"`
+
+	// A PVC containing a copy of the data directory of a base backup and
+	// the WAL files to be replayed, to be restored without accessing
+	// any object store.
+	// Mutually exclusive with `backup`, `source` and `volumeSnapshots`.
+	// +optional
+	Local *LocalBackupSource `json:"local,omitempty"`
+
+	// By default, the recovery process applies all the available
+	// WAL files in the archive (full recovery). However, you can also
+	// end the recovery as soon as a consistent state is reached or
+	// recover to a point-in-time (PITR) by specifying a `RecoveryTarget` object,
+	// as expected by PostgreSQL (i.e., timestamp, transaction Id, LSN, ...).
+	// More info: https://www.postgresql.org/docs/current/runtime-config-wal.html#RUNTIME-CONFIG-WAL-RECOVERY-TARGET
+	// +optional
+	RecoveryTarget *RecoveryTarget `json:"recoveryTarget,omitempty"`
+
+	// Name of the database used by the application. Default: `app`.
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// Name of the owner of the database in the instance to be used
+	// by applications. Defaults to the value of the `database` key.
+	// +optional
+	Owner string `json:"owner,omitempty"`
+
+	// Name of the secret containing the initial credentials for the
+	// owner of the user database. If empty a new secret will be
+	// created from scratch
+	// +optional
+	Secret *LocalObjectReference `json:"secret,omitempty"`
+
+	// The configuration of the command used to decrypt a base backup that
+	// has been encrypted on the client side, with a key barman-cloud
+	// doesn't know about, before being uploaded to the object store
+	// +optional
+	Decryption *RecoveryDecryptionConfiguration `json:"decryption,omitempty"`
+
+	// The configuration of the command used to decrypt the WAL files that
+	// have been encrypted on the client side before being archived. It is
+	// independent of the decryption of the base backup, allowing the WAL
+	// archive to use a different key, and is executed by the
+	// `restore_command` on every WAL file fetched from the object store,
+	// which is passed as the last argument
+	// +optional
+	WALDecryption *RecoveryDecryptionConfiguration `json:"walDecryption,omitempty"`
+
+	// The provider of the credentials for the object store containing
+	// the backup, read right before each barman-cloud command, including
+	// the ones fetching the WAL files in the `restore_command`, so that
+	// short-lived credentials are always fresh. They take precedence over
+	// the ones read from the secrets of the object store configuration,
+	// which are used for the whole restore when this is not set
+	// +optional
+	CredentialsProvider *RecoveryCredentialsProvider `json:"credentialsProvider,omitempty"`
+
+	// When set to true, once the recovery is completed, the content of
+	// every user table, in every database, is removed and every user
+	// sequence is restarted, while the DDL and the grants are preserved.
+	// This produces a structurally-identical but empty copy of the
+	// source cluster. This is a destructive operation and it requires an
+	// explicit opt-in (default: `false`)
+	// +optional
+	SchemaOnly bool `json:"schemaOnly,omitempty"`
+
+	// When set to true, before starting PostgreSQL, the operator checks
+	// that the WAL archive contains every WAL file between the end of the
+	// base backup and the recovery target, failing with the name of the
+	// first missing one. The WAL files are fetched one after another, and
+	// once more during the recovery. This requires the recovery target to
+	// be expressed with `targetLSN` (default: `false`)
+	// +optional
+	VerifyWALArchive bool `json:"verifyWALArchive,omitempty"`
+
+	// When set to true, before restoring the base backup, the operator
+	// computes its recovery window, looking for the last WAL file of the
+	// archive following it, and checks that the recovery target falls
+	// within it. Only the recovery targets expressed with `targetLSN` or
+	// `targetTime` are checked (default: `false`)
+	// +optional
+	VerifyRecoveryWindow bool `json:"verifyRecoveryWindow,omitempty"`
+
+	// When set to true, before restoring the base backup, the operator
+	// fetches its first WAL file into a temporary location, using the
+	// same command PostgreSQL will use during the recovery. This checks
+	// the credentials, the provider settings and the decryption of the WAL
+	// archive, failing fast when the WAL files can't be fetched
+	// (default: `false`)
+	// +optional
+	ProbeWALFetch bool `json:"probeWALFetch,omitempty"`
+
+	// When set to true, once the base backup has been restored, the
+	// restored data directory is verified with `pg_verifybackup` against
+	// the backup manifest included in the backup, failing the restore
+	// when a file is missing, unexpected or doesn't match its checksum.
+	// The WAL files are not verified, as they are fetched from the
+	// archive. The verification is skipped when the backup doesn't
+	// contain a manifest (default: `false`)
+	// +optional
+	VerifyBackupManifest bool `json:"verifyBackupManifest,omitempty"`
+
+	// The absolute path of the directory to be used as HOME by the
+	// barman-cloud commands executed during the recovery, including the
+	// ones fetching the WAL files. It allows each restore to use its
+	// own set of cloud provider configuration and credential files,
+	// which can be mounted via the projected volume template
+	// +optional
+	BarmanHome string `json:"barmanHome,omitempty"`
+
+	// A query to be executed once the recovery is completed, to check
+	// that the restored data looks sane before the cluster is declared
+	// ready
+	// +optional
+	SmokeTest *RecoverySmokeTest `json:"smokeTest,omitempty"`
+
+	// A query which, once the recovery is completed, must return true
+	// before the restore is declared complete, in addition to
+	// `pg_is_in_recovery()` returning false. It allows the readiness to
+	// depend on the restored schema, such as the presence of the required
+	// migrations
+	// +optional
+	ReadinessCheck *RecoveryReadinessCheck `json:"readinessCheck,omitempty"`
+
+	// Once the recovery is completed, collect a summary of the catalog of
+	// the restored cluster, with its databases, roles and tables per schema,
+	// and compare it with the expected one. The summary and the
+	// discrepancies are recorded in the cluster status
+	// +optional
+	CatalogSummary *RecoveryCatalogSummary `json:"catalogSummary,omitempty"`
+
+	// When set to true, the WAL replay is executed with `fsync`,
+	// `full_page_writes` and `synchronous_commit` turned off, which
+	// can make the recovery considerably faster. The durability settings
+	// are restored, and the data directory is flushed to disk, as soon
+	// as the recovery is completed. A crash of the node during
+	// the recovery can corrupt the data directory, which will need to be
+	// restored again (default: `false`)
+	// +optional
+	FastRecovery bool `json:"fastRecovery,omitempty"`
+
+	// The maintenance operations to be executed on the primary instance
+	// once the cluster has been restored, like refreshing the planner
+	// statistics and loading the relations into the shared buffers
+	// +optional
+	PostRestoreMaintenance *PostRestoreMaintenance `json:"postRestoreMaintenance,omitempty"`
+
+	// When set, the WAL replay is paused once the recovery target is
+	// reached, allowing the restored data to be inspected before the
+	// promotion. The instance is promoted when requested via the
+	// `cnpg.io/promoteRecovery` annotation on the cluster, or
+	// automatically when the timeout expires. Requires a recovery target
+	// +optional
+	PauseAtTarget *RecoveryPause `json:"pauseAtTarget,omitempty"`
+
+	// When set to true, PostgreSQL is shut down once the recovery target
+	// is reached (`recovery_target_action = shutdown`) instead of being
+	// promoted, leaving the data directory at the exact recovery target,
+	// for example to take an offline copy of the volume. The restored
+	// instance is not configured, and the password resets are skipped.
+	// Requires a recovery target, and can't be used together with
+	// `pauseAtTarget` (default: `false`)
+	// +optional
+	ShutdownAtTarget bool `json:"shutdownAtTarget,omitempty"`
+
+	// The key of a ConfigMap containing a block of recovery settings, with
+	// the syntax of the PostgreSQL configuration files, to be added to the
+	// generated recovery configuration. Only the recovery-related parameters
+	// are accepted, and the `restore_command` and `recovery_target_action`
+	// parameters, together with the recovery target when defined in the
+	// cluster, are always generated by the operator
+	// +optional
+	RecoverySettings *ConfigMapKeySelector `json:"recoverySettings,omitempty"`
+
+	// The log level of the instance manager while recovering the cluster,
+	// one of the following values: error, warning, info, debug, trace.
+	// It overrides the log level of the cluster for the recovery only, and
+	// defaults to it
+	// +kubebuilder:validation:Enum:=error;warning;info;debug;trace
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// What to do with the temporary data directory used to generate the
+	// configuration files of the restored instance: `Delete` removes it,
+	// `RetainOnFailure` keeps it when the generation fails, and `Retain`
+	// always keeps it. Only the directory of the last run is retained
+	// (default: `Delete`)
+	// +optional
+	TemporaryDirectoryPolicy RecoveryTemporaryDirectoryPolicy `json:"temporaryDirectoryPolicy,omitempty"`
+
+	// The worker processes used by PostgreSQL while the restored instance
+	// is being recovered and configured. The parameters are written only
+	// when supported by the PostgreSQL major version of the backup, and are
+	// set back to the values of the cluster configuration once the
+	// recovery is completed
+	// +optional
+	Workers *RecoveryWorkers `json:"workers,omitempty"`
+
+	// The memory parameters used by PostgreSQL while the restored instance
+	// is being recovered and configured, to be sized on the resources of
+	// the recovery instead of the ones of the source cluster. The
+	// parameters are set back to the values of the cluster configuration
+	// once the recovery is completed
+	// +optional
+	Memory *RecoveryMemory `json:"memory,omitempty"`
+
+	// The checkpoint behavior of the restored instance at the end of the
+	// recovery. The parameters are set back to the values of the cluster
+	// configuration once the recovery is completed
+	// +optional
+	Checkpoint *RecoveryCheckpoint `json:"checkpoint,omitempty"`
+
+	// When set to true, the damaged pages found while recovering the
+	// instance are zeroed out via the `zero_damaged_pages` parameter,
+	// instead of stopping the recovery. The data contained in these pages
+	// is lost, and the zeroed pages are reported in the `zeroedPages`
+	// field of the cluster status. Use it only as a last resort, to
+	// recover a backup affected by page corruption (default: `false`)
+	// +optional
+	ZeroDamagedPages bool `json:"zeroDamagedPages,omitempty"`
+
+	// The key of a ConfigMap containing the manifest written by a previous
+	// restore, to repeat it. The backup and the recovery target reported
+	// in the manifest are used, and the restore fails if the backup found
+	// in the object store doesn't match the one of the manifest. It cannot
+	// be used together with `recoveryTarget`
+	// +optional
+	Manifest *ConfigMapKeySelector `json:"manifest,omitempty"`
+
+	// The roles whose password is replaced once the recovery is completed,
+	// for example when cloning a cluster to a less trusted environment.
+	// Every role must exist in the restored instance. Not supported for
+	// replica clusters
+	// +optional
+	PasswordResets []RecoveryPasswordReset `json:"passwordResets,omitempty"`
+
+	// When true, the recovery fails if PostgreSQL ends it before reaching
+	// the recovery target, for example because some WAL files are missing.
+	// Otherwise, only a warning is raised. In both cases, the requested
+	// target and the reached point are reported in the `recoveryTarget`
+	// field of the cluster status (default: `false`)
+	// +optional
+	StrictRecoveryTarget bool `json:"strictRecoveryTarget,omitempty"`
+
+	// The policy used to retry the operations of the restore that can
+	// fail temporarily, i.e. the download of the base backup, the read
+	// of the ConfigMaps used by the recovery, and the wait for the end
+	// of the recovery. When not specified, the download of the base backup
+	// and the read of the ConfigMaps are not retried, while the end of the
+	// recovery is checked every 5 seconds
+	// +optional
+	RetryPolicy *RestoreRetryPolicy `json:"retryPolicy,omitempty"`
+
+	// The action to be taken when the version of the collations recorded
+	// in the restored databases differs from the one provided by the
+	// operating system of the image, which makes the collation-dependent
+	// indexes unreliable: `reindex`, the default, rebuilds them once the
+	// recovery is completed, `fail` makes the recovery fail, while `warn`
+	// only logs a warning
+	// +kubebuilder:validation:Enum=reindex;fail;warn
+	// +optional
+	OnCollationMismatch CollationMismatchPolicy `json:"onCollationMismatch,omitempty"`
+
+	// The policy used to restore an older base backup, replaying more WAL
+	// files to reach the same recovery target, when the selected one can't
+	// be restored, for example because it is corrupted or incomplete.
+	// Only supported when restoring from the object store of an external
+	// cluster, without choosing the backup ID
+	// +optional
+	BackupFallback *RecoveryBackupFallback `json:"backupFallback,omitempty"`
+
+	// The check of the distance between the end of the selected base
+	// backup and the recovery target, run before starting the recovery.
+	// A long distance means that a lot of WAL files have to be replayed,
+	// and that a newer base backup would make the recovery faster
+	// +optional
+	WALGapCheck *RecoveryWALGapCheck `json:"walGapCheck,omitempty"`
+
+	// The verification, once the recovery is completed, of the commit time
+	// of the last replayed transaction against the requested `targetTime`,
+	// proving that the recovery stopped where intended. Requires a
+	// `targetTime` in `recoveryTarget`
+	// +optional
+	TargetTimeCheck *RecoveryTargetTimeCheck `json:"targetTimeCheck,omitempty"`
+
+	// The restore of the backup over the data directory contained in the
+	// orphan PVCs of a cluster with the same name, for example because it
+	// has been deleted without deleting its PVCs, preserving the identity
+	// of the volume of the primary instance. The PVCs of the replicas are
+	// deleted, and the replicas are cloned again from the restored primary.
+	// Not supported when recovering from volume snapshots
+	// +optional
+	InPlace *RecoveryInPlace `json:"inPlace,omitempty"`
+
+	// The proxy to be used by barman-cloud to reach the object store
+	// during the recovery, both when restoring the base backup and when
+	// fetching the WAL files. When set, these values take precedence over
+	// the proxy variables inherited from the environment of the instance
+	// manager
+	// +optional
+	Proxy *RecoveryProxy `json:"proxy,omitempty"`
+
+	// The extensions to be updated in every restored database once the
+	// recovery is completed, to match the versions provided by the image.
+	// Regardless of this setting, the installed extensions whose version
+	// differs from the default one of the image are reported.
+	// Not supported for replica clusters
+	// +optional
+	Extensions []RecoveryExtension `json:"extensions,omitempty"`
+
+	// The databases that keep accepting connections once the recovery is
+	// completed. When set, every other user database is locked, with
+	// `ALLOW_CONNECTIONS false`, until it's explicitly enabled again.
+	// The `postgres` database and the template databases are never locked.
+	// Not supported for replica clusters
+	// +optional
+	AllowedDatabases []string `json:"allowedDatabases,omitempty"`
+
+	// The locations where the tablespaces of the backup are restored,
+	// instead of the ones they had in the source cluster. Supported only
+	// when recovering from an object store
+	// +optional
+	TablespaceRemap []RecoveryTablespaceRemap `json:"tablespaceRemap,omitempty"`
+
+	// The maximum duration of each phase of the restore. A phase exceeding
+	// its timeout is aborted, and the outcome is reported in a dedicated
+	// condition of the cluster. Supported only when recovering from an
+	// object store
+	// +optional
+	PhaseTimeouts *RecoveryPhaseTimeouts `json:"phaseTimeouts,omitempty"`
+
+	// The limit to the rate of the WAL replay during the recovery, to
+	// reduce its impact on the IO of a shared storage at the cost of a
+	// slower recovery. By default, the WAL replay is not throttled.
+	// Not supported for replica clusters
+	// +optional
+	ReplayThrottle *RecoveryReplayThrottle `json:"replayThrottle,omitempty"`
+
+	// The logical export of the restored data, taken with `pg_dump` or
+	// `pg_dumpall` once the recovery is completed, before the cluster
+	// starts serving the applications, and uploaded to an object store.
+	// Not supported for replica clusters
+	// +optional
+	LogicalExport *RecoveryLogicalExport `json:"logicalExport,omitempty"`
+
+	// The name of a physical replication slot to be created in the restored
+	// instance, even while it is still a standby, so that the downstream
+	// standbys of an external cluster can attach to it as soon as the
+	// instance is promoted. The name can't start with the prefix of the
+	// replication slots used for high availability
+	// +kubebuilder:validation:Pattern=^[0-9a-z_]*$
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	PromotionSlot string `json:"promotionSlot,omitempty"`
+
+	// Controls the replication settings, `primary_conninfo` and
+	// `primary_slot_name`, written by the operator in the `override.conf`
+	// file of the restored instance once the recovery is completed. By
+	// default, they are generated by the operator
+	// +optional
+	ReplicationSettings *RecoveryReplicationSettings `json:"replicationSettings,omitempty"`
+
+	// A volume, usually backed by local storage like an NVMe disk, where
+	// the base backup is restored before being moved to the PGDATA volume.
+	// When not specified, the base backup is restored directly in PGDATA.
+	// Not supported when recovering from volume snapshots or from a
+	// local volume
+	// +optional
+	Staging *RecoveryStaging `json:"staging,omitempty"`
+
+	// Checks, before the base backup is restored, that the volumes
+	// receiving it have enough free inodes for its files. Not supported
+	// when recovering from volume snapshots
+	// +optional
+	InodesCheck *RecoveryInodesCheck `json:"inodesCheck,omitempty"`
+
+	// A volume where the downloaded base backups are kept, so that the
+	// following restores of the same backup don't download it again
+	// from the object store, for example when testing the restore
+	// repeatedly. Supported only when recovering from an object store,
+	// and not with tablespaces
+	// +optional
+	DownloadCache *RecoveryDownloadCache `json:"downloadCache,omitempty"`
+
+	// When set to true, autovacuum is disabled while the restored instance
+	// is configured by the recovery job, so that it doesn't compete for IO
+	// with the post-restore operations, like the rebuild of the indexes
+	// or the logical export. It is enabled again afterward, even if one of
+	// the operations fails (default: `false`)
+	// +optional
+	DisableAutovacuum bool `json:"disableAutovacuum,omitempty"`
+
+	// When set to true, the WAL files included in the `pg_wal` directory
+	// of the restored base backup are kept. By default they are removed,
+	// so that the recovery relies only on the WAL files fetched from the
+	// archive (default: `false`).
+	// Supported only when recovering from an object store
+	// +optional
+	KeepBundledWAL bool `json:"keepBundledWAL,omitempty"`
+
+	// The timeouts used by barman-cloud when reading the base backup
+	// and the WAL files from the object store.
+	// Supported only when recovering from an object store
+	// +optional
+	ObjectStoreTimeouts *RecoveryObjectStoreTimeouts `json:"objectStoreTimeouts,omitempty"`
+
+	// The number of times the `restore_command` retries the fetch of a WAL
+	// file failing with a transient error, like a connectivity error with
+	// the object store, waiting for the initial backoff of the retry policy
+	// between two attempts. A missing WAL file is reported immediately to
+	// PostgreSQL, so that the end of the recovery is detected correctly.
+	// By default, the failed fetches are not retried.
+	// Supported only when recovering from an object store
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	WALFetchRetries int32 `json:"walFetchRetries,omitempty"`
+
+	// The policy choosing, when the restore job starts, between restoring
+	// the base backup from the archive and cloning a live primary with
+	// `pg_basebackup`. By default, the archive is always used.
+	// Not supported when recovering from volume snapshots or from a
+	// local volume
+	// +optional
+	DataSourcePolicy *RecoveryDataSourcePolicy `json:"dataSourcePolicy,omitempty"`
+
+	// The advancement of the sequences of the restored databases, done
+	// with `setval` once the recovery is completed, so that the values
+	// generated by the clone don't collide with the ones generated by
+	// the source afterward.
+	// Not supported for replica clusters
+	// +optional
+	SequenceAdvance *RecoverySequenceAdvance `json:"sequenceAdvance,omitempty"`
+
+	// The reset of the statistics collected by `pg_stat_statements`, done
+	// once the recovery is completed, so that the statistics inherited
+	// from the source don't mislead the monitoring of the restored cluster.
+	// Nothing is reset when the extension is not installed.
+	// Not supported for replica clusters
+	// +optional
+	StatStatementsReset *RecoveryStatStatementsReset `json:"statStatementsReset,omitempty"`
+
+	// A `VACUUM (FREEZE)` of the tables with the oldest `relfrozenxid`,
+	// done once the recovery is completed, so that the restored cluster
+	// doesn't trigger aggressive autovacuum runs to prevent the
+	// transaction ID wraparound right after the promotion.
+	// Not supported for replica clusters
+	// +optional
+	Freeze *RecoveryFreeze `json:"freeze,omitempty"`
+
+	// The detection of the transactions left prepared by the two-phase
+	// commit in the restored backup, done once the recovery is completed.
+	// Orphaned prepared transactions hold their locks and prevent vacuum
+	// from removing dead rows. By default they are not detected, and
+	// when detected they are only reported, unless a different action
+	// is explicitly requested
+	// +optional
+	PreparedTransactions *RecoveryPreparedTransactions `json:"preparedTransactions,omitempty"`
+
+	// The origin the restored backup is expected to have. The restore
+	// fails when the system identifier or the timeline of the backup
+	// differ from the expected ones, preventing the restore of the backup
+	// of the wrong cluster
+	// +optional
+	ExpectedSource *RecoveryExpectedSource `json:"expectedSource,omitempty"`
+
+	// When set to true, a restored data directory whose `pg_control` file
+	// records a state from which the archive recovery can't be safely
+	// started is repaired, by writing the `backup_label` file of the
+	// restored backup, when it is known. When the repair isn't possible
+	// the restore fails. By default, the state of `pg_control` is only
+	// diagnosed and logged.
+	// Not allowed with `volumeSnapshots`.
+	// +optional
+	RepairControlFile bool `json:"repairControlFile,omitempty"`
+
+	// The role the restored instance has once the restore is done. It
+	// defaults to `replica-cluster` when the cluster is a replica cluster,
+	// and to `primary` otherwise
+	// +optional
+	Role RecoveryRole `json:"role,omitempty"`
+
+	// How the promotion of the restored instance is retried when it
+	// fails once the WAL replay is completed, for example because the
+	// new timeline history file can't be written. When not set, the
+	// restore fails as soon as the promotion fails
+	// +optional
+	PromotionRetry *RecoveryPromotionRetry `json:"promotionRetry,omitempty"`
+
+	// When true, the WAL archiving stays disabled after the promotion
+	// of the restored instance, so that the cluster never writes to any
+	// archive, for example during a disaster recovery drill. A primary
+	// without WAL archiving can't be recovered to a point in time, so
+	// this must not be used for a cluster holding real data
+	// +optional
+	KeepArchivingDisabled bool `json:"keepArchivingDisabled,omitempty"`
+
+	// When true, `track_commit_timestamp` is enabled in the configuration
+	// of the restored instance, so that it is active as soon as the
+	// cluster comes up, for example to be the source of a logical
+	// replication requiring the commit timestamps. The transactions
+	// committed before the restore have no commit timestamp
+	// +optional
+	TrackCommitTimestamp bool `json:"trackCommitTimestamp,omitempty"`
+
+	// The reduced connection limit applied to the databases of the
+	// restored cluster during a warm-up window, to protect the cold
+	// instance from a flood of reconnecting clients. The limits are raised
+	// back to their restored values once the warm-up is completed
+	// +optional
+	ConnectionRamp *RecoveryConnectionRamp `json:"connectionRamp,omitempty"`
+
+	// The downstream use intended for the restored cluster. Before the
+	// restore starts, the effective `wal_level` is checked against the one
+	// required by this use, and the mismatch is fixed or makes the
+	// restore fail, as requested
+	// +optional
+	DownstreamUse *RecoveryDownstreamUse `json:"downstreamUse,omitempty"`
+
+	// When true, once every restore step has written its files and before
+	// the instance is started, the ownership and the permissions of every
+	// entry of PGDATA, and of the linked tablespaces, are fixed to be
+	// accepted by the startup checks of PostgreSQL
+	// +optional
+	PermissionSweep bool `json:"permissionSweep,omitempty"`
+
+	// The check of the size of the restored data against the expected
+	// one, to detect a truncated or wrong base backup before the WAL
+	// replay starts
+	// +optional
+	SizeCheck *RecoverySizeCheck `json:"sizeCheck,omitempty"`
+
+	// The action to be taken when the restored instance is going to be
+	// promoted and no recovery target has been set, neither in
+	// `recoveryTarget` nor in the recovery settings: `latest`, the
+	// default, replays every WAL file available in the archive before
+	// the promotion, logging a warning, while `fail` refuses to start the
+	// restore, preventing a full replay when the recovery target has
+	// been forgotten or mistyped
+	// +kubebuilder:validation:Enum=latest;fail
+	// +optional
+	OnMissingRecoveryTarget MissingRecoveryTargetPolicy `json:"onMissingRecoveryTarget,omitempty"`
+
+	// The heartbeat of the restore, periodically reporting in the cluster
+	// status when the restore last made progress, so that a stuck restore
+	// can be told apart from a slow one and reported by the
+	// `RestoreStalled` condition. Supported only when recovering from an
+	// object store
+	// +optional
+	Heartbeat *RecoveryHeartbeat `json:"heartbeat,omitempty"`
+
+	// The directory where `barman-cloud-restore` writes its temporary
+	// files, like the ones used while decompressing the base backup.
+	// By default, they are written in the temporary directory of the
+	// container, whose filesystem may be too small for them. Not
+	// supported when recovering from `VolumeSnapshot` objects or from
+	// a local volume
+	// +optional
+	BarmanTempDirectory *RecoveryBarmanTempDirectory `json:"barmanTempDirectory,omitempty"`
+
+	// The action taken, once the recovery is completed, on the logical
+	// replication subscriptions inherited from the source of the restore:
+	// `keep`, the default, leaves them as they are, so that the enabled
+	// ones connect to their publishers, `disable` disables them, and
+	// `drop` drops them, leaving their replication slots on the
+	// publishers untouched. Disabling them is the safe choice for a
+	// clone, which would otherwise consume the changes meant for the
+	// source. The actions other than `keep` are not supported for
+	// replica clusters
+	// +kubebuilder:validation:Enum=keep;disable;drop
+	// +optional
+	Subscriptions RecoverySubscriptionsPolicy `json:"subscriptions,omitempty"`
+
+	// The probe of the log of PostgreSQL while the WAL files are being
+	// replayed, making the restore fail as soon as a fatal error with a
+	// known cause is logged, like a missing tablespace, a parameter lower
+	// than on the source, or a corrupt WAL file, instead of waiting for
+	// the end of the recovery. By default, the log is not probed
+	// +optional
+	LogProbe *RecoveryLogProbe `json:"logProbe,omitempty"`
+}
+
+// RecoveryStaging is the scratch volume where the base backup is
+// restored before being moved to the PGDATA volume
+type RecoveryStaging struct {
+	// The name of the PVC used as staging area. When it is bound to a local
+	// persistent volume, the node affinity of the volume constrains the node
+	// where the recovery job is scheduled
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// The free space the staging volume must have before the restore of
+	// the base backup is started. Defaults to the size of the PGDATA volume
+	// +optional
+	RequiredSpace *resource.Quantity `json:"requiredSpace,omitempty"`
+}
+
+// RecoveryInodesCheck defines how the number of files contained in the
+// base backup is determined, to check that the volumes receiving it have
+// enough free inodes
+type RecoveryInodesCheck struct {
+	// The number of files the base backup contains. When not specified,
+	// it is estimated from the size of the PGDATA volume and the average
+	// size of the files
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ExpectedFiles *int64 `json:"expectedFiles,omitempty"`
+
+	// The average size of the files of the base backup, used to estimate
+	// their number when `expectedFiles` is not specified. Defaults to 1Mi
+	// +optional
+	BytesPerFile *resource.Quantity `json:"bytesPerFile,omitempty"`
+}
+
+// RecoveryDownloadCache is the volume where the downloaded base backups
+// are kept, to be reused by the following restores of the same backup
+type RecoveryDownloadCache struct {
+	// The name of the PVC containing the cached base backups. It must be
+	// kept across the restores for the cache to be reused
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// The maximum total size of the cached base backups. The least
+	// recently used ones are evicted to stay below it, while the base
+	// backup being restored is always kept. When not specified, the size
+	// of the cache is limited only by the volume
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// The maximum number of cached base backups. The least recently used
+	// ones are evicted to stay below it. Defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxEntries *int32 `json:"maxEntries,omitempty"`
+}
+
+// CollationMismatchPolicy is the action to be taken when the collations
+// of the restored databases don't match the ones of the image
+type CollationMismatchPolicy string
+
+const (
+	// CollationMismatchPolicyReindex rebuilds the collation-dependent
+	// indexes of the restored databases
+	CollationMismatchPolicyReindex CollationMismatchPolicy = "reindex"
+
+	// CollationMismatchPolicyFail makes the recovery fail
+	CollationMismatchPolicyFail CollationMismatchPolicy = "fail"
+
+	// CollationMismatchPolicyWarn makes the recovery proceed,
+	// logging a warning
+	CollationMismatchPolicyWarn CollationMismatchPolicy = "warn"
+)
+
+// RecoveryWorkers configures the worker processes used by PostgreSQL
+// during the recovery
+type RecoveryWorkers struct {
+	// The value of `max_parallel_maintenance_workers` during the recovery,
+	// used by the maintenance commands, such as `CREATE INDEX`, executed
+	// while configuring the restored instance. Requires PostgreSQL 11
+	// or later
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1024
+	// +optional
+	MaintenanceWorkers *int32 `json:"maintenanceWorkers,omitempty"`
+
+	// The value of `maintenance_io_concurrency` during the recovery,
+	// limiting the concurrent I/O requests issued to prefetch the blocks
+	// referenced in the WAL files being replayed. Requires PostgreSQL 13
+	// or later, while the prefetch during the WAL replay is available
+	// starting from PostgreSQL 15
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	IOConcurrency *int32 `json:"ioConcurrency,omitempty"`
+}
+
+// RecoveryCredentialsProvider configures where the credentials used to
+// access the object store during the recovery are read from. Exactly
+// one of `command` and `directory` must be set
+type RecoveryCredentialsProvider struct {
+	// The command, which must be available in the PostgreSQL operand
+	// image, printing the credentials on its standard output, one
+	// environment variable per line with the `NAME=value` syntax, for
+	// example `AWS_SESSION_TOKEN=...`. The values can't contain spaces.
+	// As the command is part of the `restore_command`, its arguments
+	// cannot contain single quotes
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// The directory, inside the projected volume mounted in `/projected`,
+	// containing one file per environment variable, named after it and
+	// holding its value, like a secret written by a Vault agent or by the
+	// secrets store CSI driver. The files are read again before each
+	// barman-cloud command, so that rotated credentials are picked up.
+	// The values can't contain spaces
+	// +optional
+	Directory string `json:"directory,omitempty"`
+}
+
+// RecoveryMemory configures the memory used by PostgreSQL during
+// the recovery
+type RecoveryMemory struct {
+	// The value of `maintenance_work_mem` during the recovery, using the
+	// PostgreSQL units, for example `1GB`. It is used by the maintenance
+	// commands, such as `CREATE INDEX`, executed while configuring the
+	// restored instance
+	// +kubebuilder:validation:Pattern=^[0-9]+(kB|MB|GB|TB)?$
+	// +optional
+	MaintenanceWorkMem string `json:"maintenanceWorkMem,omitempty"`
+
+	// The value of `effective_cache_size` during the recovery, using the
+	// PostgreSQL units, for example `8GB`. It is used by the planner to
+	// estimate the memory available for caching the data
+	// +kubebuilder:validation:Pattern=^[0-9]+(kB|MB|GB|TB)?$
+	// +optional
+	EffectiveCacheSize string `json:"effectiveCacheSize,omitempty"`
+}
+
+// RecoveryCheckpoint configures the checkpoints executed by PostgreSQL
+// during the recovery and before the restored instance is promoted
+type RecoveryCheckpoint struct {
+	// When true, a `CHECKPOINT` is executed as soon as the recovery is
+	// completed, so that the shutdown of the restored instance, which
+	// requires a checkpoint too, is faster. Its duration is logged
+	// (default: `false`)
+	// +optional
+	Explicit bool `json:"explicit,omitempty"`
+
+	// The value of `checkpoint_completion_target` during the recovery,
+	// between 0 and 1
+	// +kubebuilder:validation:Pattern=^(0(\.[0-9]+)?|1(\.0+)?)$
+	// +optional
+	CompletionTarget string `json:"completionTarget,omitempty"`
+
+	// The value of `max_wal_size` during the recovery, using the
+	// PostgreSQL units, for example `4GB`. A larger value reduces the
+	// number of checkpoints requested while replaying the WAL files
+	// +kubebuilder:validation:Pattern=^[0-9]+(kB|MB|GB|TB)?$
+	// +optional
+	MaxWALSize string `json:"maxWALSize,omitempty"`
+}
+
+// RestoreRetryPolicy configures how the operations of the restore
+// that can fail temporarily are retried
+type RestoreRetryPolicy struct {
+	// The maximum number of attempts of the download of the base backup
+	// and of the read of the ConfigMaps used by the recovery (default: `1`,
+	// meaning no retry). The wait for the end of the recovery is never
+	// limited, as the WAL replay can legitimately take a long time
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxAttempts *int32 `json:"maxAttempts,omitempty"`
+
+	// The time to wait before the first retry, doubled at every
+	// following one up to `maxBackoff` (default: `5s`)
+	// +optional
+	InitialBackoff *metav1.Duration `json:"initialBackoff,omitempty"`
+
+	// The maximum time to wait between two attempts. It defaults to
+	// `initialBackoff`, meaning a constant backoff
+	// +optional
+	MaxBackoff *metav1.Duration `json:"maxBackoff,omitempty"`
+
+	// The percentage of random time added to every backoff, to prevent
+	// many restores failing at the same time from retrying in lockstep
+	// (default: `0`)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	JitterPercent *int32 `json:"jitterPercent,omitempty"`
+}
+
+// RecoveryBackupFallback configures the restore of an older base
+// backup when the selected one can't be restored
+type RecoveryBackupFallback struct {
+	// The maximum number of older base backups that are tried after
+	// the selected one (default: `1`)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default:=1
+	// +optional
+	MaxFallbacks int32 `json:"maxFallbacks,omitempty"`
+}
+
+// RecoveryWALGapCheck defines the maximum distance allowed between the
+// end of the base backup and the recovery target
+type RecoveryWALGapCheck struct {
+	// The maximum amount of WAL between the end of the base backup and
+	// the LSN of the recovery target. Only checked when the recovery
+	// target is an LSN
+	// +optional
+	MaxWALSize *resource.Quantity `json:"maxWALSize,omitempty"`
+
+	// The maximum time between the end of the base backup and the time
+	// of the recovery target. Only checked when the recovery target is
+	// a time
+	// +optional
+	MaxTimeGap *metav1.Duration `json:"maxTimeGap,omitempty"`
+
+	// When true, the recovery fails if the distance exceeds one of the
+	// thresholds. Otherwise, only a warning is raised (default: `false`)
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+}
+
+// RecoveryTargetTimeCheck defines the verification of the commit time of
+// the last transaction replayed during a recovery to a point in time
+type RecoveryTargetTimeCheck struct {
+	// The maximum time between the commit of the last replayed transaction
+	// and the recovery target time. A larger gap suggests that the recovery
+	// stopped earlier than intended (default: `1h`)
+	// +optional
+	MaxGap *metav1.Duration `json:"maxGap,omitempty"`
+
+	// When true, the recovery fails if the last replayed transaction
+	// committed after the recovery target time, or too long before it.
+	// Otherwise, only a warning is raised (default: `false`)
+	// +optional
+	Strict bool `json:"strict,omitempty"`
+}
+
+// RecoveryInPlace configures the restore of a backup over the existing
+// data directory of the primary instance
+type RecoveryInPlace struct {
+	// Confirms that the existing data directory can be replaced by the
+	// restored backup. As restoring in place is destructive, the orphan
+	// PVCs are adopted without being touched unless this is true
+	Enabled bool `json:"enabled"`
+
+	// When true, the existing data directory is kept, on the same volume,
+	// in a directory with the current timestamp appended to its name,
+	// allowing a manual rollback. When false, it is removed before the
+	// restore (default: `true`)
+	// +kubebuilder:default:=true
+	// +optional
+	PreserveDataDirectory *bool `json:"preserveDataDirectory,omitempty"`
+}
+
+// RecoveryProxy defines the proxy used to reach the object store
+// during the recovery
+type RecoveryProxy struct {
+	// The URL of the proxy used for HTTPS requests, set as `HTTPS_PROXY`
+	// +optional
+	HTTPSProxy string `json:"httpsProxy,omitempty"`
+
+	// The URL of the proxy used for HTTP requests, set as `HTTP_PROXY`
+	// +optional
+	HTTPProxy string `json:"httpProxy,omitempty"`
+
+	// The hosts, domains and networks that must be reached without
+	// going through the proxy, set as `NO_PROXY`
+	// +optional
+	NoProxy []string `json:"noProxy,omitempty"`
+}
+
+// RecoveryExtension defines the version an extension must have
+// in the restored databases
+type RecoveryExtension struct {
+	// The name of the extension
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// The version the extension must be updated to. When empty, the
+	// default version provided by the image is used
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// RecoveryTablespaceRemap defines the location where a tablespace
+// of the backup is restored
+type RecoveryTablespaceRemap struct {
+	// The name of the tablespace in the backup
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// The absolute path where the tablespace is restored, usually the
+	// location of one of the tablespaces of the cluster
+	// +kubebuilder:validation:MinLength=1
+	Location string `json:"location"`
+}
+
+// RecoveryPhaseTimeouts defines the maximum duration of the phases
+// of the restore. A phase without a timeout can take any time
+type RecoveryPhaseTimeouts struct {
+	// The maximum duration of the download of the base backup, reported
+	// by the `RestoreDownloadTimedOut` condition
+	// +optional
+	Download *metav1.Duration `json:"download,omitempty"`
+
+	// The maximum duration of the WAL replay, up to the end of the
+	// recovery, reported by the `RestoreRecoveryTimedOut` condition
+	// +optional
+	Recovery *metav1.Duration `json:"recovery,omitempty"`
+
+	// The maximum duration of the configuration of the recovered
+	// instance, reported by the `RestoreConfigurationTimedOut` condition
+	// +optional
+	Configuration *metav1.Duration `json:"configuration,omitempty"`
+}
+
+// RecoveryDataSourceMethod is the method used to build the data
+// directory of the restored instance
+type RecoveryDataSourceMethod string
+
+const (
+	// RecoveryDataSourceArchive restores the base backup from the archive,
+	// and replays the archived WAL files
+	RecoveryDataSourceArchive RecoveryDataSourceMethod = "archive"
+
+	// RecoveryDataSourcePrimary clones a live primary with `pg_basebackup`
+	RecoveryDataSourcePrimary RecoveryDataSourceMethod = "primary"
+)
+
+// RecoveryDataSourcePolicy defines how the data directory of the restored
+// instance is built, choosing between the archive and a live primary
+type RecoveryDataSourcePolicy struct {
+	// The name of the external cluster, with the connection parameters of
+	// a live primary, that can be cloned with `pg_basebackup`
+	PrimarySource string `json:"primarySource"`
+
+	// The method preferred to build the data directory: `primary` clones
+	// the live primary when it's reachable, falling back to the archive
+	// otherwise, while `archive` always restores from the archive
+	// (default: `primary`)
+	// +kubebuilder:validation:Enum=archive;primary
+	// +kubebuilder:default:=primary
+	// +optional
+	Preference RecoveryDataSourceMethod `json:"preference,omitempty"`
+
+	// The time waited for the live primary to accept a replication
+	// connection before considering it unreachable (default: `30s`)
+	// +optional
+	AvailabilityTimeout *metav1.Duration `json:"availabilityTimeout,omitempty"`
+}
+
+// GetPreference gets the method preferred to build the data directory
+func (policy *RecoveryDataSourcePolicy) GetPreference() RecoveryDataSourceMethod {
+	if policy.Preference == "" {
+		return RecoveryDataSourcePrimary
+	}
+
+	return policy.Preference
+}
+
+// RecoveryObjectStoreTimeouts defines the timeouts of the requests
+// sent by barman-cloud to the object store during the recovery
+type RecoveryObjectStoreTimeouts struct {
+	// The time barman-cloud waits for data to be read from a connection
+	// to the object store before failing, passed as `--read-timeout`.
+	// It is rounded up to the second. When not set, the barman-cloud
+	// default is used, which is 60 seconds.
+	// Honored only by the S3-compatible object stores
+	// +optional
+	Read *metav1.Duration `json:"read,omitempty"`
+
+	// The maximum time the fetch of a single WAL file can take during
+	// the WAL replay. When it expires, `barman-cloud-wal-restore` is
+	// terminated and the fetch fails. It is rounded up to the second.
+	// When not set, the fetch can take an unlimited time
+	// +optional
+	WALFetch *metav1.Duration `json:"walFetch,omitempty"`
+}
+
+// RecoverySequenceAdvance defines how the sequences of the restored
+// databases are advanced after the recovery
+type RecoverySequenceAdvance struct {
+	// The amount each sequence is advanced by. Sequences with a negative
+	// increment are moved backward by the same amount
+	// +kubebuilder:validation:Minimum=1
+	Offset int64 `json:"offset"`
+
+	// The databases whose sequences are advanced. When empty, every
+	// restored database accepting connections is processed
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// The sequences to be advanced, in the `schema.sequence` format.
+	// When empty, every sequence is advanced, the ones of the system
+	// schemas excluded
+	// +optional
+	Sequences []string `json:"sequences,omitempty"`
+
+	// The sequences, in the `schema.sequence` format, whose values
+	// before and after the advancement are logged
+	// +optional
+	Audit []string `json:"audit,omitempty"`
+}
+
+// RecoveryStatStatementsReset defines how the statistics collected by
+// `pg_stat_statements` are reset once the recovery is completed
+type RecoveryStatStatementsReset struct {
+	// The table, in the `schema.table` format, where the statistics are
+	// saved before being reset, as a baseline to be compared with the
+	// ones collected by the restored cluster. The table is created in the
+	// database where the extension is installed, the `postgres` one being
+	// preferred when there is more than one, and is kept untouched when
+	// it already exists. When empty, no baseline is saved
+	// +optional
+	BaselineTable string `json:"baselineTable,omitempty"`
+}
+
+// RecoveryFreeze defines which tables are frozen once the
+// recovery is completed
+type RecoveryFreeze struct {
+	// The minimum age, in transactions, of the `relfrozenxid` of a table
+	// for it to be frozen (default: `150000000`, the default value of
+	// `vacuum_freeze_table_age`)
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinAge *int64 `json:"minAge,omitempty"`
+
+	// The maximum number of tables frozen in every database, starting
+	// from the oldest ones. When zero or not specified, every table older
+	// than `minAge` is frozen
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxTables int32 `json:"maxTables,omitempty"`
+}
+
+// RecoveryPreparedTransactionsAction is the action taken on the
+// prepared transactions found in the restored instance
+type RecoveryPreparedTransactionsAction string
+
+const (
+	// RecoveryPreparedTransactionsWarn reports the prepared
+	// transactions, without acting on them
+	RecoveryPreparedTransactionsWarn RecoveryPreparedTransactionsAction = "warn"
+
+	// RecoveryPreparedTransactionsRollback rolls back the
+	// prepared transactions
+	RecoveryPreparedTransactionsRollback RecoveryPreparedTransactionsAction = "rollback"
+
+	// RecoveryPreparedTransactionsCommit commits the
+	// prepared transactions
+	RecoveryPreparedTransactionsCommit RecoveryPreparedTransactionsAction = "commit"
+)
+
+// RecoveryPreparedTransactions defines how the prepared transactions
+// found in the restored instance are handled
+type RecoveryPreparedTransactions struct {
+	// The action taken on the prepared transactions: `warn` only reports
+	// them, while `rollback` and `commit` resolve them, which can lose or
+	// apply changes the application didn't expect. The actions other
+	// than `warn` are not supported for replica clusters
+	// (default: `warn`)
+	// +kubebuilder:validation:Enum=warn;rollback;commit
+	// +kubebuilder:default:=warn
+	// +optional
+	Action RecoveryPreparedTransactionsAction `json:"action,omitempty"`
+}
+
+// GetAction gets the action taken on the prepared transactions
+func (in *RecoveryPreparedTransactions) GetAction() RecoveryPreparedTransactionsAction {
+	if in.Action == "" {
+		return RecoveryPreparedTransactionsWarn
+	}
+
+	return in.Action
+}
+
+// RecoveryPromotionRetry defines how the failed promotions of the
+// restored instance are retried
+type RecoveryPromotionRetry struct {
+	// The maximum number of times the promotion is retried
+	// +kubebuilder:validation:Minimum=1
+	MaxRetries int32 `json:"maxRetries"`
+
+	// The time to wait before retrying the promotion, giving the time
+	// to fix the cause of the failure (default: `1m`)
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// RecoveryRole is the role the restored instance has once
+// the restore is done
+// +kubebuilder:validation:Enum=primary;standby;replica-cluster
+type RecoveryRole string
+
+const (
+	// RecoveryRolePrimary replays the WAL files up to the recovery target,
+	// and promotes the instance
+	RecoveryRolePrimary RecoveryRole = "primary"
+
+	// RecoveryRoleStandby makes the instance a continuous standby,
+	// replaying the WAL files archived in the object store containing
+	// the backup
+	RecoveryRoleStandby RecoveryRole = "standby"
+
+	// RecoveryRoleReplicaCluster makes the instance a standby following
+	// the source of the replica cluster
+	RecoveryRoleReplicaCluster RecoveryRole = "replica-cluster"
+)
+
+// RecoveryTemporaryDirectoryPolicy defines what to do with the temporary
+// data directory used to generate the configuration of the restored
+// instance
+// +kubebuilder:validation:Enum=Delete;RetainOnFailure;Retain
+type RecoveryTemporaryDirectoryPolicy string
+
+const (
+	// RecoveryTemporaryDirectoryPolicyDelete always removes the
+	// temporary data directory
+	RecoveryTemporaryDirectoryPolicyDelete RecoveryTemporaryDirectoryPolicy = "Delete"
+
+	// RecoveryTemporaryDirectoryPolicyRetainOnFailure keeps the temporary
+	// data directory when the configuration can't be generated
+	RecoveryTemporaryDirectoryPolicyRetainOnFailure RecoveryTemporaryDirectoryPolicy = "RetainOnFailure"
+
+	// RecoveryTemporaryDirectoryPolicyRetain always keeps the temporary
+	// data directory
+	RecoveryTemporaryDirectoryPolicyRetain RecoveryTemporaryDirectoryPolicy = "Retain"
+)
+
+// RecoveryExpectedSource defines the origin the restored backup
+// must have. Only the specified values are checked
+type RecoveryExpectedSource struct {
+	// The system identifier of the PostgreSQL cluster where the backup
+	// has been taken, as reported by `pg_controldata`
+	// +kubebuilder:validation:Pattern=`^[0-9]+$`
+	// +optional
+	SystemID string `json:"systemID,omitempty"`
+
+	// The timeline on which the backup has been taken
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Timeline *int32 `json:"timeline,omitempty"`
+}
+
+// RecoveryReplayThrottle limits the rate at which the WAL files
+// are replayed during the recovery
+type RecoveryReplayThrottle struct {
+	// The maximum amount of WAL to be replayed per second, e.g. `32Mi`.
+	// After having fetched a WAL file, the `restore_command` pauses for
+	// the time needed not to exceed this rate
+	MaxRate resource.Quantity `json:"maxRate"`
+}
+
+// RecoveryLogicalExport defines the logical export of the restored
+// data, and where it is uploaded
+type RecoveryLogicalExport struct {
+	// The databases to be exported, each one with `pg_dump` in the custom
+	// format. When empty, the whole instance, including the global
+	// objects, is exported with `pg_dumpall`
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// The location of the object store where the dumps are uploaded,
+	// e.g. `s3://bucket/exports`. Every export is stored in a directory
+	// named after the cluster and the time of the export
+	// +kubebuilder:validation:MinLength=1
+	DestinationPath string `json:"destinationPath"`
+
+	// The command uploading a dump, followed by its arguments. The command
+	// must be available in the operand image, and receives the path of the
+	// dump and its destination as the last two arguments. The credentials
+	// of the object store used by the recovery are passed via the
+	// environment
+	// +kubebuilder:validation:MinItems=1
+	UploadCommand []string `json:"uploadCommand"`
+}
+
+// RecoveryPasswordReset defines the new password of a role
+// of the restored instance
+type RecoveryPasswordReset struct {
+	// The name of the role
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// The key of the secret containing the new password of the role.
+	// It cannot be used together with `randomize`
+	// +optional
+	PasswordSecret *SecretKeySelector `json:"passwordSecret,omitempty"`
+
+	// When true, the password of the role is replaced with a random
+	// one, which is not stored anywhere. It cannot be used together
+	// with `passwordSecret`
+	// +optional
+	Randomize bool `json:"randomize,omitempty"`
+
+	// Allow the password of a superuser role to be replaced. Without it,
+	// the recovery fails when the role is a superuser (default: `false`)
+	// +optional
+	AllowSuperuser bool `json:"allowSuperuser,omitempty"`
+}
+
+// RecoveryPause configures the pause of the WAL replay at the
+// recovery target
+type RecoveryPause struct {
+	// The maximum time, in seconds, to wait for a manual promotion once
+	// the recovery target is reached. When it expires, the instance is
+	// promoted automatically (default: 3600)
+	// +kubebuilder:default:=3600
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Timeout int32 `json:"timeout,omitempty"`
+
+	// The read-only verification executed on the instance paused at the
+	// recovery target. When set, the instance is promoted as soon as the
+	// verification passes, while a failed verification leaves the instance
+	// paused, regardless of the timeout, until the promotion is requested
+	// via the `cnpg.io/promoteRecovery` annotation
+	// +optional
+	Verification *RecoveryVerification `json:"verification,omitempty"`
+
+	// The value of `max_standby_archive_delay` during the recovery, using
+	// the PostgreSQL time units, for example `10min`, or `-1` to make the
+	// WAL replay wait for the conflicting queries forever. A longer delay
+	// prevents the read-only queries inspecting the restored data from
+	// being canceled by the replay of the WAL files fetched from the archive
+	// +kubebuilder:validation:Pattern=^(-1|[0-9]+(us|ms|s|min|h|d)?)$
+	// +optional
+	MaxStandbyArchiveDelay string `json:"maxStandbyArchiveDelay,omitempty"`
+
+	// The value of `max_standby_streaming_delay` during the recovery, using
+	// the PostgreSQL time units, for example `10min`, or `-1` to make the
+	// WAL replay wait for the conflicting queries forever. It applies to
+	// the WAL received via streaming replication
+	// +kubebuilder:validation:Pattern=^(-1|[0-9]+(us|ms|s|min|h|d)?)$
+	// +optional
+	MaxStandbyStreamingDelay string `json:"maxStandbyStreamingDelay,omitempty"`
+}
+
+// RecoveryVerification contains the read-only checks executed on the
+// instance paused at the recovery target, before it is promoted
+type RecoveryVerification struct {
+	// The queries to be executed, each returning a single row with a
+	// single numeric column, like a row count, which is checked against
+	// the expected minimum
+	// +optional
+	Queries []RecoverySmokeTest `json:"queries,omitempty"`
+
+	// When set to true, the rows of every table in every database are
+	// checked against the foreign keys referencing other tables
+	// (default: `false`)
+	// +optional
+	ForeignKeys bool `json:"foreignKeys,omitempty"`
+
+	// When set to true, the smoke test of the recovery is executed during
+	// the verification too (default: `false`)
+	// +optional
+	SmokeTest bool `json:"smokeTest,omitempty"`
+}
+
+// PostRestoreMaintenance contains the maintenance operations executed
+// on the primary instance after a recovery. They are executed in the
+// background, while the instance is already accepting connections
+type PostRestoreMaintenance struct {
+	// When set to true, `ANALYZE` is executed on every database, to
+	// refresh the statistics used by the planner (default: `false`)
+	// +optional
+	Analyze bool `json:"analyze,omitempty"`
+
+	// When set to true, the relations of every database are loaded into
+	// the shared buffers using the `pg_prewarm` extension, which is
+	// created if not already available (default: `false`)
+	// +optional
+	Prewarm bool `json:"prewarm,omitempty"`
+
+	// The number of databases processed at the same time, each one using
+	// its own connection. A failure in a database doesn't stop the
+	// maintenance of the other ones (default: `1`)
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=16
+	// +optional
+	Parallelism *int32 `json:"parallelism,omitempty"`
+
+	// When set to true, the `Ready` condition of the cluster stays `False`
+	// until the maintenance operations are completed, allowing clients
+	// to wait for the warm state. Set it to false to have the cluster
+	// reported as ready as soon as it is available (default: `true`)
+	// +kubebuilder:default:=true
+	// +optional
+	ReadinessGate *bool `json:"readinessGate,omitempty"`
+}
+
+// RecoveryConnectionRamp contains the connection limit applied to the
+// databases of the restored cluster while it warms up. The limit is set
+// per database, via `CONNECTION LIMIT`, rather than lowering
+// `max_connections`, as raising the latter requires a restart which
+// would empty the shared buffers loaded during the warm-up
+type RecoveryConnectionRamp struct {
+	// The maximum number of concurrent connections to each database during
+	// the warm-up window. Superusers are not subject to this limit, and a
+	// database whose restored limit is lower keeps it
+	// +kubebuilder:validation:Minimum=1
+	ConnectionLimit int32 `json:"connectionLimit"`
+
+	// The minimum duration of the warm-up window, starting when the
+	// restore is completed. When the post-restore maintenance is requested,
+	// the window lasts until it is terminated too (default: `5m`)
+	// +optional
+	WarmupTime *metav1.Duration `json:"warmupTime,omitempty"`
+}
+
+// RecoveryDownstreamUse contains the downstream use intended for the
+// restored cluster, from which the required `wal_level` is derived
+type RecoveryDownstreamUse struct {
+	// When set to true, the restored cluster is meant to be the source of
+	// a logical replication, like a publication or a change data capture
+	// tool, which requires `wal_level` to be `logical` (default: `false`)
+	// +optional
+	LogicalReplication bool `json:"logicalReplication,omitempty"`
+
+	// The action to be taken when the effective `wal_level` doesn't
+	// support the intended use: `fix`, the default, sets the required
+	// `wal_level` in the configuration of the restored instances, while
+	// `fail` makes the restore fail before it starts
+	// +kubebuilder:validation:Enum=fix;fail
+	// +optional
+	OnWALLevelMismatch WALLevelMismatchPolicy `json:"onWALLevelMismatch,omitempty"`
+}
+
+// WALLevelMismatchPolicy is the action to be taken when the effective
+// `wal_level` doesn't support the intended use of the restored cluster
+type WALLevelMismatchPolicy string
+
+const (
+	// WALLevelMismatchPolicyFix sets the required `wal_level` in the
+	// configuration of the restored instances
+	WALLevelMismatchPolicyFix WALLevelMismatchPolicy = "fix"
+
+	// WALLevelMismatchPolicyFail makes the restore fail
+	WALLevelMismatchPolicyFail WALLevelMismatchPolicy = "fail"
+)
+
+// MissingRecoveryTargetPolicy is the action to be taken when a restored
+// instance is going to be promoted without a recovery target
+type MissingRecoveryTargetPolicy string
+
+const (
+	// MissingRecoveryTargetPolicyLatest replays every WAL file
+	// available in the archive, logging a warning
+	MissingRecoveryTargetPolicyLatest MissingRecoveryTargetPolicy = "latest"
+
+	// MissingRecoveryTargetPolicyFail makes the restore fail
+	MissingRecoveryTargetPolicyFail MissingRecoveryTargetPolicy = "fail"
+)
+
+// RecoverySubscriptionsPolicy is the action taken on the logical
+// replication subscriptions inherited from the source of the restore
+type RecoverySubscriptionsPolicy string
+
+const (
+	// RecoverySubscriptionsPolicyKeep leaves the subscriptions as they are
+	RecoverySubscriptionsPolicyKeep RecoverySubscriptionsPolicy = "keep"
+
+	// RecoverySubscriptionsPolicyDisable disables the subscriptions
+	RecoverySubscriptionsPolicyDisable RecoverySubscriptionsPolicy = "disable"
+
+	// RecoverySubscriptionsPolicyDrop drops the subscriptions, without
+	// dropping their replication slots on the publishers
+	RecoverySubscriptionsPolicyDrop RecoverySubscriptionsPolicy = "drop"
+)
+
+// RecoveryBarmanTempDirectory is the directory where barman-cloud writes
+// its temporary files while restoring the base backup
+type RecoveryBarmanTempDirectory struct {
+	// The absolute path of the directory, set as `TMPDIR` in the
+	// environment of `barman-cloud-restore`. It must exist and be
+	// writable, for example on a volume mounted via the projected
+	// volume template or on the PGDATA volume
+	// +kubebuilder:validation:MinLength=1
+	Path string `json:"path"`
+
+	// The free space the directory must have before the base backup is
+	// restored. By default, the free space is not checked
+	// +optional
+	RequiredSpace *resource.Quantity `json:"requiredSpace,omitempty"`
+}
+
+// RecoveryLogProbe defines the probe of the log of PostgreSQL
+// while the WAL files are being replayed
+type RecoveryLogProbe struct {
+	// The patterns matched in addition to the built-in ones, which
+	// detect the most common causes of a failed recovery
+	// +optional
+	Patterns []RecoveryLogProbePattern `json:"patterns,omitempty"`
+}
+
+// RecoveryLogProbePattern is a fatal error of PostgreSQL
+// detected by the probe of the log
+type RecoveryLogProbePattern struct {
+	// The name of the pattern, reported when it is matched
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// The regular expression, in the RE2 syntax, matched against the
+	// messages logged by PostgreSQL with the `FATAL` or `PANIC` severity
+	// +kubebuilder:validation:MinLength=1
+	Expression string `json:"expression"`
+
+	// How to fix the cause of the error, reported when the
+	// pattern is matched
+	// +optional
+	Hint string `json:"hint,omitempty"`
+}
+
+// RecoveryHeartbeat defines how the progress of the restore
+// is reported, and when a restore is considered stalled
+type RecoveryHeartbeat struct {
+	// How often the time of the last progress of the restore is reported
+	// in the cluster status (default: `30s`). Nothing is reported when the
+	// restore made no progress since the previous report
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// How long the restore can go without making progress before being
+	// reported as stalled (default: `10m`). It must be longer than the
+	// interval
+	// +optional
+	StaleThreshold *metav1.Duration `json:"staleThreshold,omitempty"`
+}
+
+const (
+	// DefaultRecoveryHeartbeatInterval is the default interval between
+	// the reports of the progress of the restore
+	DefaultRecoveryHeartbeatInterval = 30 * time.Second
+
+	// DefaultRecoveryHeartbeatStaleThreshold is the default time after
+	// which a restore that made no progress is considered stalled
+	DefaultRecoveryHeartbeatStaleThreshold = 10 * time.Minute
+)
+
+// GetInterval gets the interval between the reports
+// of the progress of the restore
+func (heartbeat *RecoveryHeartbeat) GetInterval() time.Duration {
+	if heartbeat == nil || heartbeat.Interval == nil {
+		return DefaultRecoveryHeartbeatInterval
+	}
+
+	return heartbeat.Interval.Duration
+}
+
+// GetStaleThreshold gets the time after which a restore
+// that made no progress is considered stalled
+func (heartbeat *RecoveryHeartbeat) GetStaleThreshold() time.Duration {
+	if heartbeat == nil || heartbeat.StaleThreshold == nil {
+		return DefaultRecoveryHeartbeatStaleThreshold
+	}
+
+	return heartbeat.StaleThreshold.Duration
+}
+
+// RecoverySizeCheck defines the expected size of the restored data,
+// made of PGDATA and the tablespaces, and how much it may differ
+type RecoverySizeCheck struct {
+	// The expected size of the restored data, like the size of the data
+	// directory of the origin cluster when the backup was taken
+	ExpectedSize resource.Quantity `json:"expectedSize"`
+
+	// The accepted difference between the restored size and the
+	// expected one, as a percentage of the expected size (default: `10`)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	TolerancePercent *int32 `json:"tolerancePercent,omitempty"`
+
+	// The action to be taken when the restored size is outside the
+	// tolerance: `fail`, the default, makes the restore fail, while
+	// `warn` only logs a warning
+	// +kubebuilder:validation:Enum=fail;warn
+	// +optional
+	OnSizeMismatch SizeMismatchPolicy `json:"onSizeMismatch,omitempty"`
+}
+
+// DefaultSizeCheckTolerancePercent is the default accepted difference
+// between the restored size and the expected one
+const DefaultSizeCheckTolerancePercent = 10
+
+// GetTolerancePercent gets the accepted difference between the restored
+// size and the expected one, as a percentage of the expected size
+func (check *RecoverySizeCheck) GetTolerancePercent() int32 {
+	if check == nil || check.TolerancePercent == nil {
+		return DefaultSizeCheckTolerancePercent
+	}
+
+	return *check.TolerancePercent
+}
+
+// SizeMismatchPolicy is the action to be taken when the size of
+// the restored data is not the expected one
+type SizeMismatchPolicy string
+
+const (
+	// SizeMismatchPolicyFail makes the restore fail
+	SizeMismatchPolicyFail SizeMismatchPolicy = "fail"
+
+	// SizeMismatchPolicyWarn makes the restore proceed,
+	// logging a warning
+	SizeMismatchPolicyWarn SizeMismatchPolicy = "warn"
+)
+
+// LocalBackupSource is a PVC containing a base backup and the WAL
+// files to be replayed, in the format used by the recovery from
+// a local volume
+type LocalBackupSource struct {
+	// The name of the PVC containing the backup. It is mounted in
+	// read-only mode in the recovery job
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// The directory, relative to the root of the volume, containing
+	// the copy of the data directory of the base backup. It can also be
+	// a tar archive of the data directory, either uncompressed or
+	// compressed with the program set in `decompression`
+	// +kubebuilder:default:=data
+	// +optional
+	DataPath string `json:"dataPath,omitempty"`
+
+	// The directory, relative to the root of the volume, containing
+	// the WAL files to be replayed
+	// +kubebuilder:default:=wals
+	// +optional
+	WALPath string `json:"walPath,omitempty"`
+
+	// The program decompressing the tar archive set in `dataPath`,
+	// running in the recovery job. When not specified, the archive
+	// is decompressed by the instance manager, which supports gzip
+	// +optional
+	Decompression *RecoveryDecompression `json:"decompression,omitempty"`
+}
+
+// RecoveryDecompression defines the program used to decompress
+// the archive of a base backup
+type RecoveryDecompression struct {
+	// The command decompressing its standard input to its standard
+	// output, for example `["zstd", "-d", "-T0", "-c"]` or
+	// `["pigz", "-d", "-c"]`. The program must be available in the
+	// operand image
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+}
+
+// SmokeTestFailurePolicy is the action to be taken when the post-recovery
+// smoke test doesn't pass
+type SmokeTestFailurePolicy string
+
+const (
+	// SmokeTestFailurePolicyFail makes the recovery fail when the smoke
+	// test doesn't pass
+	SmokeTestFailurePolicyFail SmokeTestFailurePolicy = "fail"
+
+	// SmokeTestFailurePolicyWarn makes the recovery proceed, logging a
+	// warning, when the smoke test doesn't pass
+	SmokeTestFailurePolicyWarn SmokeTestFailurePolicy = "warn"
+)
+
+// RecoverySmokeTest contains the configuration of the query used to check
+// the restored data
+type RecoverySmokeTest struct {
+	// The query to be executed. It must return a single row with a single
+	// numeric column, for example `SELECT count(*) FROM billing.invoices`
+	// +kubebuilder:validation:MinLength=1
+	Query string `json:"query"`
+
+	// The database where the query is executed. Defaults to the
+	// application database, or to `postgres` if none is defined
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// The minimum value the query is expected to return (default: `1`)
+	// +optional
+	MinValue *int64 `json:"minValue,omitempty"`
+
+	// The action to be taken when the query fails or returns a value
+	// lower than the expected minimum: `fail`, the default, makes the
+	// recovery fail, while `warn` only logs a warning
+	// +kubebuilder:validation:Enum=fail;warn
+	// +kubebuilder:default:=fail
+	// +optional
+	OnFailure SmokeTestFailurePolicy `json:"onFailure,omitempty"`
+}
+
+// RecoveryReadinessCheck defines the query which must pass before
+// the restore is declared complete
+type RecoveryReadinessCheck struct {
+	// The query to be executed. It must return a single row with a single
+	// boolean column, for example
+	// `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = '20240101')`
+	// +kubebuilder:validation:MinLength=1
+	Query string `json:"query"`
+
+	// The database where the query is executed. Defaults to the
+	// application database, or to `postgres` if none is defined
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// The maximum time to wait for the query to return true. The query is
+	// executed again, with the backoff of the restore retry policy, until
+	// it does, and the restore fails when the timeout expires (default: `5m`)
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// RecoveryCatalogSummary contains the configuration of the comparison
+// between the catalog of the restored cluster and the expected one
+type RecoveryCatalogSummary struct {
+	// The catalog summary the restored cluster is expected to have. Only
+	// the sections specified here are compared. When not specified, the
+	// summary is only recorded in the cluster status, for example to be
+	// compared externally
+	// +optional
+	Expected *CatalogSummary `json:"expected,omitempty"`
+
+	// When set to true, the recovery fails when the catalog of the
+	// restored cluster doesn't match the expected summary. Otherwise,
+	// the discrepancies are only reported (default: `false`)
+	// +optional
+	FailOnMismatch bool `json:"failOnMismatch,omitempty"`
+}
+
+// CatalogSummary is a lightweight summary of the catalog of a cluster
+type CatalogSummary struct {
+	// The databases accepting connections, template databases excluded
+	// +optional
+	Databases []string `json:"databases,omitempty"`
+
+	// The roles, the predefined `pg_` ones excluded
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// The number of tables in every schema of every database, the system
+	// schemas excluded
+	// +optional
+	Tables []CatalogSummaryTables `json:"tables,omitempty"`
+}
+
+// CatalogSummaryTables contains the number of tables in a schema
+type CatalogSummaryTables struct {
+	// The name of the database
+	// +kubebuilder:validation:MinLength=1
+	Database string `json:"database"`
+
+	// The name of the schema
+	// +kubebuilder:validation:MinLength=1
+	Schema string `json:"schema"`
+
+	// The number of tables, partitioned tables included
+	// +kubebuilder:validation:Minimum=0
+	Count int `json:"count"`
+}
+
+// CatalogSummaryReport reports the catalog summary of the restored
+// cluster, and how it differs from the expected one
+type CatalogSummaryReport struct {
+	// The catalog summary of the restored cluster
+	Summary CatalogSummary `json:"summary"`
+
+	// Compared is true when the summary has been compared with the
+	// expected one
+	Compared bool `json:"compared"`
+
+	// The differences between the catalog summary of the restored
+	// cluster and the expected one
 	// +optional
-	SchemaOnly bool `json:"schemaOnly,omitempty"`
+	Discrepancies []string `json:"discrepancies,omitempty"`
 }
 
-// ImportSource describes the source for the logical snapshot
-type ImportSource struct {
-	// The name of the externalCluster used for import
-	ExternalCluster string `json:"externalCluster"`
-}
+// ConnectionRampReport reports the connection limits lowered during the
+// warm-up of the restored cluster, and when they have been raised back
+type ConnectionRampReport struct {
+	// When the connection limits have been lowered, at the end of
+	// the restore
+	StartedAt metav1.Time `json:"startedAt"`
 
-// SQLRefs holds references to ConfigMaps or Secrets
-// containing SQL files. The references are processed in a specific order:
-// first, all Secrets are processed, followed by all ConfigMaps.
-// Within each group, the processing order follows the sequence specified
-// in their respective arrays.
-type SQLRefs struct {
-	// SecretRefs holds a list of references to Secrets
+	// The databases whose connection limit has been lowered, with
+	// the restored limit to be set back once the warm-up is completed
 	// +optional
-	SecretRefs []SecretKeySelector `json:"secretRefs,omitempty"`
+	Databases []ConnectionRampDatabase `json:"databases,omitempty"`
 
-	// ConfigMapRefs holds a list of references to ConfigMaps
+	// When the connection limits have been raised back to their
+	// restored values
 	// +optional
-	ConfigMapRefs []ConfigMapKeySelector `json:"configMapRefs,omitempty"`
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
 }
 
-// HasElements returns true if it contains any Reference
-func (s *SQLRefs) HasElements() bool {
-	if s == nil {
-		return false
-	}
+// ConnectionRampDatabase is a database whose connection limit has been
+// lowered during the warm-up of the restored cluster
+type ConnectionRampDatabase struct {
+	// The name of the database
+	Name string `json:"name"`
 
-	return len(s.ConfigMapRefs) != 0 ||
-		len(s.SecretRefs) != 0
+	// The connection limit of the database when it was restored,
+	// where `-1` means no limit
+	ConnectionLimit int32 `json:"connectionLimit"`
 }
 
-// BootstrapRecovery contains the configuration required to restore
-// from an existing cluster using 3 methodologies: external cluster,
-// volume snapshots or backup objects. Full recovery and Point-In-Time
-// Recovery are supported.
-// The method can be also be used to create clusters in continuous recovery
-// (replica clusters), also supporting cascading replication when `instances` >
-// 1. Once the cluster exits recovery, the password for the superuser
-// will be changed through the provided secret.
-// Refer to the Bootstrap page of the documentation for more information.
-type BootstrapRecovery struct {
-	// The backup object containing the physical base backup from which to
-	// initiate the recovery procedure.
-	// Mutually exclusive with `source` and `volumeSnapshots`.
-	// +optional
-	Backup *BackupSource `json:"backup,omitempty"`
+// WALLevelReport reports the `wal_level` of the restored cluster,
+// compared with the one required by its intended downstream use
+type WALLevelReport struct {
+	// The `wal_level` detected in the configuration of the cluster
+	Detected string `json:"detected"`
 
-	// The external cluster whose backup we will restore. This is also
-	// used as the name of the folder under which the backup is stored,
-	// so it must be set to the name of the source cluster
-	// Mutually exclusive with `backup`.
-	// +optional
-	Source string `json:"source,omitempty"`
+	// The `wal_level` required by the intended downstream use
+	Required string `json:"required"`
 
-	// The static PVC data source(s) from which to initiate the
-	// recovery procedure. Currently supporting `VolumeSnapshot`
-	// and `PersistentVolumeClaim` resources that map an existing
-	// PVC group, compatible with CloudNativePG, and taken with
-	// a cold backup copy on a fenced Postgres instance (limitation
-	// which will be removed in the future when online backup
-	// will be implemented).
-	// Mutually exclusive with `backup`.
+	// Adjusted is true when the required `wal_level` has been set in
+	// the configuration of the restored instances
 	// +optional
-	VolumeSnapshots *DataSource `json:"volumeSnapshots,omitempty"`
+	Adjusted bool `json:"adjusted,omitempty"`
+}
 
-	// By default, the recovery process applies all the available
-	// WAL files in the archive (full recovery). However, you can also
-	// end the recovery as soon as a consistent state is reached or
-	// recover to a point-in-time (PITR) by specifying a `RecoveryTarget` object,
-	// as expected by PostgreSQL (i.e., timestamp, transaction Id, LSN, ...).
-	// More info: https://www.postgresql.org/docs/current/runtime-config-wal.html#RUNTIME-CONFIG-WAL-RECOVERY-TARGET
+// RecoveryReplicationSettings controls the replication settings written
+// by the operator once the recovery is completed
+type RecoveryReplicationSettings struct {
+	// When set to true, the operator doesn't write the replication settings
+	// once the recovery is completed, leaving them to be managed by the user.
+	// Mutually exclusive with `primaryConnInfo` and `primarySlotName`
 	// +optional
-	RecoveryTarget *RecoveryTarget `json:"recoveryTarget,omitempty"`
+	Skip bool `json:"skip,omitempty"`
 
-	// Name of the database used by the application. Default: `app`.
+	// The connection string written as `primary_conninfo`, instead of the
+	// one generated by the operator
 	// +optional
-	Database string `json:"database,omitempty"`
+	PrimaryConnInfo string `json:"primaryConnInfo,omitempty"`
 
-	// Name of the owner of the database in the instance to be used
-	// by applications. Defaults to the value of the `database` key.
+	// The name of the replication slot written as `primary_slot_name`,
+	// instead of the one generated by the operator
+	// +kubebuilder:validation:Pattern=^[0-9a-z_]*$
+	// +kubebuilder:validation:MaxLength=63
 	// +optional
-	Owner string `json:"owner,omitempty"`
+	PrimarySlotName string `json:"primarySlotName,omitempty"`
+}
 
-	// Name of the secret containing the initial credentials for the
-	// owner of the user database. If empty a new secret will be
-	// created from scratch
+// RecoveryDecryptionConfiguration contains the configuration of the
+// command used to decrypt a client-side encrypted base backup
+type RecoveryDecryptionConfiguration struct {
+	// The decryption command, followed by its arguments. The command is
+	// executed after `barman-cloud-restore` has downloaded the base backup,
+	// and receives the path of the restored data directory as its last
+	// argument. It is expected to decrypt the content of that directory
+	// in place
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+
+	// The secret containing the decryption key. The content is passed
+	// to the decryption command via the `CNPG_DECRYPTION_KEY` environment
+	// variable and is never logged
 	// +optional
-	Secret *LocalObjectReference `json:"secret,omitempty"`
+	KeySecret *SecretKeySelector `json:"keySecret,omitempty"`
 }
 
 // DataSource contains the configuration required to bootstrap a
@@ -1892,6 +4095,14 @@ type DataSource struct {
 // information that could be needed to correctly restore it.
 type BackupSource struct {
 	LocalObjectReference `json:",inline"`
+
+	// The namespace of the Backup object, defaulting to the one of the
+	// cluster. A Backup in a different namespace can be read only if the
+	// service account of the cluster is allowed to get it, and the secrets
+	// referenced by the Backup must also exist in the namespace of the cluster
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
 	// EndpointCA store the CA bundle of the barman endpoint.
 	// Useful when using self-signed certificates to avoid
 	// errors with certificate issuer and barman-cloud-wal-archive.
@@ -1949,6 +4160,13 @@ type RecoveryTarget struct {
 	// +optional
 	TargetLSN string `json:"targetLSN,omitempty"`
 
+	// The name of the last WAL file to be replayed. It is translated
+	// to the LSN where the WAL file ends, using the WAL segment size
+	// of the restored data directory, and the recovery stops before
+	// any change following it
+	// +optional
+	TargetWAL string `json:"targetWAL,omitempty"`
+
 	// The target time as a timestamp in the RFC3339 standard
 	// +optional
 	TargetTime string `json:"targetTime,omitempty"`
@@ -1957,6 +4175,12 @@ type RecoveryTarget struct {
 	// +optional
 	TargetImmediate *bool `json:"targetImmediate,omitempty"`
 
+	// End recovery exactly at the LSN where the backup ended, as recorded
+	// in the metadata of the backup. It is translated to the corresponding
+	// target LSN before the recovery is started
+	// +optional
+	TargetBackupEnd *bool `json:"targetBackupEnd,omitempty"`
+
 	// Set the target to be exclusive. If omitted, defaults to false, so that
 	// in Postgres, `recovery_target_inclusive` will be true
 	// +optional
@@ -3971,6 +6195,421 @@ func (target *RecoveryTarget) BuildPostgresOptions() string {
 	return result
 }
 
+// GetDataPath gets the directory of the volume containing
+// the copy of the data directory
+func (local *LocalBackupSource) GetDataPath() string {
+	if local.DataPath == "" {
+		return "data"
+	}
+
+	return local.DataPath
+}
+
+// GetWALPath gets the directory of the volume containing
+// the WAL files
+func (local *LocalBackupSource) GetWALPath() string {
+	if local.WALPath == "" {
+		return "wals"
+	}
+
+	return local.WALPath
+}
+
+// DefaultTargetTimeMaxGap is the default maximum time between the commit
+// of the last replayed transaction and the recovery target time
+const DefaultTargetTimeMaxGap = time.Hour
+
+// GetMaxGap gets the maximum time allowed between the commit of the
+// last replayed transaction and the recovery target time
+func (check *RecoveryTargetTimeCheck) GetMaxGap() time.Duration {
+	if check.MaxGap == nil {
+		return DefaultTargetTimeMaxGap
+	}
+
+	return check.MaxGap.Duration
+}
+
+// ErrConflictingRecoveryTargets is raised when more than one of the
+// mutually exclusive recovery targets has been set
+var ErrConflictingRecoveryTargets = errors.New("recovery target options are mutually exclusive")
+
+// Render validates the recovery target and produces the PostgreSQL
+// configuration lines implementing it. The target WAL and the end of the
+// backup, if any, must have been already resolved to an LSN
+func (target *RecoveryTarget) Render() (string, error) {
+	if target == nil {
+		return "", nil
+	}
+
+	if err := target.Validate(); err != nil {
+		return "", err
+	}
+
+	if target.TargetWAL != "" {
+		return "", fmt.Errorf("the target WAL %s needs to be resolved to an LSN", target.TargetWAL)
+	}
+
+	if ptr.Deref(target.TargetBackupEnd, false) {
+		return "", errors.New("the end of the backup needs to be resolved to an LSN")
+	}
+
+	return target.BuildPostgresOptions(), nil
+}
+
+// Validate checks the consistency of the recovery target options. This is
+// the single place where it is enforced, both by the webhook and before
+// the recovery is started, so that PostgreSQL never receives more than one
+// recovery target to choose from
+func (target *RecoveryTarget) Validate() error {
+	if target == nil {
+		return nil
+	}
+
+	if targets := target.getTargets(); len(targets) > 1 {
+		return fmt.Errorf("%w: %s", ErrConflictingRecoveryTargets, strings.Join(targets, ", "))
+	}
+
+	if !target.hasValidTimeline() {
+		return fmt.Errorf("recovery target timeline can be set to 'latest' or a positive integer, got %q",
+			target.TargetTLI)
+	}
+
+	if target.TargetLSN != "" {
+		if _, err := postgres.LSN(target.TargetLSN).Parse(); err != nil {
+			return fmt.Errorf("invalid recovery target LSN: %w", err)
+		}
+	}
+
+	if target.TargetTime != "" {
+		if _, err := utils.ParseTargetTime(nil, target.TargetTime); err != nil {
+			return fmt.Errorf("invalid recovery target time: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// HasTarget checks if a recovery target, and not only the backup
+// or the timeline to be used, has been set
+func (target *RecoveryTarget) HasTarget() bool {
+	return target != nil && target.countTargets() > 0
+}
+
+// countTargets counts how many of the mutually exclusive
+// recovery targets have been set
+func (target *RecoveryTarget) countTargets() int {
+	return len(target.getTargets())
+}
+
+// getTargets gets the names of the mutually exclusive
+// recovery targets that have been set
+func (target *RecoveryTarget) getTargets() []string {
+	var targets []string
+	if target.TargetImmediate != nil {
+		targets = append(targets, "targetImmediate")
+	}
+	if target.TargetLSN != "" {
+		targets = append(targets, "targetLSN")
+	}
+	if target.TargetName != "" {
+		targets = append(targets, "targetName")
+	}
+	if target.TargetXID != "" {
+		targets = append(targets, "targetXID")
+	}
+	if target.TargetTime != "" {
+		targets = append(targets, "targetTime")
+	}
+	if target.TargetWAL != "" {
+		targets = append(targets, "targetWAL")
+	}
+	if target.TargetBackupEnd != nil {
+		targets = append(targets, "targetBackupEnd")
+	}
+
+	return targets
+}
+
+// ResolveTargetWAL returns a copy of the recovery target where the target
+// WAL, if any, is replaced by the LSN where it ends. The recovery is made
+// exclusive, so that every change contained in the target WAL is replayed,
+// and none of the following ones
+func (target *RecoveryTarget) ResolveTargetWAL(walSegmentSize int64) (*RecoveryTarget, error) {
+	if target == nil || target.TargetWAL == "" {
+		return target, nil
+	}
+
+	segment, err := postgres.SegmentFromName(target.TargetWAL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target WAL %q: %w", target.TargetWAL, err)
+	}
+
+	endLSN, err := segment.EndLSN(walSegmentSize)
+	if err != nil {
+		return nil, fmt.Errorf("while computing the LSN of the target WAL: %w", err)
+	}
+
+	result := target.DeepCopy()
+	result.TargetWAL = ""
+	result.TargetLSN = string(endLSN)
+	result.Exclusive = ptr.To(true)
+	return result, nil
+}
+
+// ResolveTargetBackupEnd returns a copy of the recovery target where the
+// end of the backup, if requested, is replaced by the passed LSN, which is
+// the one where the restored backup ended
+func (target *RecoveryTarget) ResolveTargetBackupEnd(backupEndLSN string) (*RecoveryTarget, error) {
+	if target == nil || !ptr.Deref(target.TargetBackupEnd, false) {
+		return target, nil
+	}
+
+	if backupEndLSN == "" {
+		return nil, errors.New("the end LSN of the backup is unknown, and cannot be used as recovery target")
+	}
+
+	if _, err := postgres.LSN(backupEndLSN).Parse(); err != nil {
+		return nil, fmt.Errorf("invalid end LSN of the backup %q: %w", backupEndLSN, err)
+	}
+
+	result := target.DeepCopy()
+	result.TargetBackupEnd = nil
+	result.TargetLSN = backupEndLSN
+	return result, nil
+}
+
+// hasValidTimeline checks if the target timeline is
+// "latest" or a positive integer
+func (target *RecoveryTarget) hasValidTimeline() bool {
+	switch target.TargetTLI {
+	case "", "latest":
+		// Allowed non-numeric values
+		return true
+	default:
+		// Everything else must be a valid positive integer
+		tli, err := strconv.Atoi(target.TargetTLI)
+		return err == nil && tli >= 1
+	}
+}
+
+// GetMinValue gets the minimum value the smoke test query is
+// expected to return
+func (smokeTest *RecoverySmokeTest) GetMinValue() int64 {
+	if smokeTest.MinValue == nil {
+		return 1
+	}
+
+	return *smokeTest.MinValue
+}
+
+// GetMinAge gets the minimum age, in transactions, of the tables
+// to be frozen
+func (freeze *RecoveryFreeze) GetMinAge() int64 {
+	if freeze.MinAge == nil {
+		return 150000000
+	}
+
+	return *freeze.MinAge
+}
+
+// GetBytesPerFile gets the average size of the files of the base backup,
+// used to estimate their number
+func (check *RecoveryInodesCheck) GetBytesPerFile() resource.Quantity {
+	if check.BytesPerFile == nil {
+		return resource.MustParse("1Mi")
+	}
+
+	return *check.BytesPerFile
+}
+
+// GetMaxEntries gets the maximum number of base backups kept in the
+// download cache
+func (cache *RecoveryDownloadCache) GetMaxEntries() int {
+	if cache.MaxEntries == nil {
+		return 1
+	}
+
+	return int(*cache.MaxEntries)
+}
+
+// IsEnabled checks if any maintenance operation has been requested
+func (maintenance *PostRestoreMaintenance) IsEnabled() bool {
+	return maintenance != nil && (maintenance.Analyze || maintenance.Prewarm)
+}
+
+// GetParallelism gets the number of databases processed at the same
+// time by the maintenance operations
+func (maintenance *PostRestoreMaintenance) GetParallelism() int {
+	if maintenance == nil || maintenance.Parallelism == nil || *maintenance.Parallelism < 1 {
+		return 1
+	}
+
+	return int(*maintenance.Parallelism)
+}
+
+// IsReadinessGateEnabled checks if the cluster should be reported as
+// ready only after the maintenance operations are completed
+func (maintenance *PostRestoreMaintenance) IsReadinessGateEnabled() bool {
+	return maintenance.IsEnabled() &&
+		(maintenance.ReadinessGate == nil || *maintenance.ReadinessGate)
+}
+
+// GetPostRestoreMaintenance gets the maintenance operations to be
+// executed after the recovery, if any
+func (cluster *Cluster) GetPostRestoreMaintenance() *PostRestoreMaintenance {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.PostRestoreMaintenance
+}
+
+// DefaultConnectionRampWarmupTime is the default minimum duration of
+// the warm-up window of the restored cluster
+const DefaultConnectionRampWarmupTime = 5 * time.Minute
+
+// GetConnectionRamp gets the connection limit to be applied while
+// the restored cluster warms up, if any
+func (cluster *Cluster) GetConnectionRamp() *RecoveryConnectionRamp {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.ConnectionRamp
+}
+
+// GetWarmupTime gets the minimum duration of the warm-up window
+func (ramp *RecoveryConnectionRamp) GetWarmupTime() time.Duration {
+	if ramp == nil || ramp.WarmupTime == nil {
+		return DefaultConnectionRampWarmupTime
+	}
+
+	return ramp.WarmupTime.Duration
+}
+
+// IsArchivingKeptDisabledAfterRecovery checks if the WAL archiving has
+// to stay disabled after the promotion of the restored instance
+func (cluster *Cluster) IsArchivingKeptDisabledAfterRecovery() bool {
+	return cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.KeepArchivingDisabled
+}
+
+// IsCommitTimestampTrackedAfterRecovery checks if the tracking of the
+// commit timestamps has been requested while recovering the cluster
+func (cluster *Cluster) IsCommitTimestampTrackedAfterRecovery() bool {
+	return cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.TrackCommitTimestamp
+}
+
+// IsWalArchivingDisabled checks if PostgreSQL mustn't archive the WAL
+// files, as requested via annotation or while recovering the cluster
+func (cluster *Cluster) IsWalArchivingDisabled() bool {
+	return utils.IsWalArchivingDisabled(&cluster.ObjectMeta) || cluster.IsArchivingKeptDisabledAfterRecovery()
+}
+
+// GetRecoveryRole gets the role the restored instance has once the
+// restore is done, defaulting to the one implied by the replica
+// cluster configuration
+func (cluster *Cluster) GetRecoveryRole() RecoveryRole {
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.Role != "" {
+		return cluster.Spec.Bootstrap.Recovery.Role
+	}
+
+	if cluster.IsReplica() {
+		return RecoveryRoleReplicaCluster
+	}
+
+	return RecoveryRolePrimary
+}
+
+// IsInPlaceRestoreEnabled checks if the backup has to be restored
+// over the data directory contained in the orphan PVCs of the cluster
+func (cluster *Cluster) IsInPlaceRestoreEnabled() bool {
+	return cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.InPlace != nil && cluster.Spec.Bootstrap.Recovery.InPlace.Enabled
+}
+
+// ShouldPreserveDataDirectory checks if the existing data directory
+// has to be kept when restoring in place
+func (in *RecoveryInPlace) ShouldPreserveDataDirectory() bool {
+	return in.PreserveDataDirectory == nil || *in.PreserveDataDirectory
+}
+
+// GetNamespace gets the namespace of the Backup object to be
+// restored, defaulting to the namespace of the cluster
+func (in *BackupSource) GetNamespace(clusterNamespace string) string {
+	if in.Namespace != "" {
+		return in.Namespace
+	}
+
+	return clusterNamespace
+}
+
+// GetRecoveryLogLevel gets the log level of the instance manager
+// while recovering the cluster
+func (cluster *Cluster) GetRecoveryLogLevel() string {
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.LogLevel != "" {
+		return cluster.Spec.Bootstrap.Recovery.LogLevel
+	}
+
+	return cluster.Spec.LogLevel
+}
+
+// GetRecoveryTemporaryDirectoryPolicy gets what to do with the temporary
+// data directory used to generate the configuration of the restored instance
+func (cluster *Cluster) GetRecoveryTemporaryDirectoryPolicy() RecoveryTemporaryDirectoryPolicy {
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.TemporaryDirectoryPolicy != "" {
+		return cluster.Spec.Bootstrap.Recovery.TemporaryDirectoryPolicy
+	}
+
+	return RecoveryTemporaryDirectoryPolicyDelete
+}
+
+// IsPostRestoreMaintenancePending checks if the cluster must not be
+// reported as ready because the maintenance operations executed after
+// the recovery are not terminated yet. A failure of the maintenance
+// doesn't keep the cluster in the not ready state
+func (cluster *Cluster) IsPostRestoreMaintenancePending() bool {
+	if !cluster.GetPostRestoreMaintenance().IsReadinessGateEnabled() {
+		return false
+	}
+
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, string(ConditionPostRestoreMaintenance))
+	return condition == nil || condition.Reason == string(ConditionReasonPostRestoreMaintenanceRunning)
+}
+
+// GetTimeout gets the maximum time to wait for a manual promotion
+// once the recovery target is reached
+func (pause *RecoveryPause) GetTimeout() time.Duration {
+	if pause.Timeout <= 0 {
+		return time.Hour
+	}
+
+	return time.Duration(pause.Timeout) * time.Second
+}
+
+// GetInterval gets the time to wait before retrying a failed promotion
+func (promotionRetry *RecoveryPromotionRetry) GetInterval() time.Duration {
+	if promotionRetry.Interval == nil || promotionRetry.Interval.Duration <= 0 {
+		return time.Minute
+	}
+
+	return promotionRetry.Interval.Duration
+}
+
+// GetTimeout gets the maximum time to wait for the readiness
+// query to return true
+func (check *RecoveryReadinessCheck) GetTimeout() time.Duration {
+	if check.Timeout == nil || check.Timeout.Duration <= 0 {
+		return 5 * time.Minute
+	}
+
+	return check.Timeout.Duration
+}
+
 func init() {
 	SchemeBuilder.Register(&Cluster{}, &ClusterList{})
 }
@@ -18,6 +18,7 @@ package v1
 
 import (
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -1648,3 +1649,291 @@ var _ = Describe("Cluster Managed Service Enablement", func() {
 		})
 	})
 })
+
+var _ = Describe("RecoveryTarget BuildPostgresOptions", func() {
+	It("renders the target LSN as a quoted string", func() {
+		// PostgreSQL can only parse an unquoted value when it starts
+		// with a letter, which isn't generally true for an LSN
+		target := &RecoveryTarget{TargetLSN: "0/3000060"}
+		Expect(target.BuildPostgresOptions()).To(Equal(
+			"recovery_target_lsn = '0/3000060'\n" +
+				"recovery_target_inclusive = true\n"))
+	})
+
+	It("renders nothing for a nil target", func() {
+		var target *RecoveryTarget
+		Expect(target.BuildPostgresOptions()).To(BeEmpty())
+	})
+})
+
+var _ = Describe("RecoveryTarget Render", func() {
+	It("renders the options of a valid target", func() {
+		target := &RecoveryTarget{TargetTLI: "3", TargetName: "before-migration", Exclusive: ptr.To(true)}
+		Expect(target.Render()).To(Equal(
+			"recovery_target_timeline = '3'\n" +
+				"recovery_target_name = 'before-migration'\n" +
+				"recovery_target_inclusive = false\n"))
+	})
+
+	It("renders nothing for a nil target", func() {
+		var target *RecoveryTarget
+		Expect(target.Render()).To(BeEmpty())
+	})
+
+	DescribeTable("rejects an inconsistent target",
+		func(target *RecoveryTarget) {
+			_, err := target.Render()
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("more than one target", &RecoveryTarget{TargetXID: "1234", TargetLSN: "0/3000060"}),
+		Entry("immediate and time targets",
+			&RecoveryTarget{TargetImmediate: ptr.To(true), TargetTime: "2024-05-21T10:12:33Z"}),
+		Entry("negative timeline", &RecoveryTarget{TargetTLI: "-1"}),
+		Entry("non-numeric timeline", &RecoveryTarget{TargetTLI: "current"}),
+		Entry("invalid LSN", &RecoveryTarget{TargetLSN: "0/XYZ"}),
+		Entry("invalid time", &RecoveryTarget{TargetTime: "yesterday"}),
+	)
+})
+
+var _ = Describe("RecoveryTarget Validate", func() {
+	targets := []struct {
+		name string
+		set  func(target *RecoveryTarget)
+	}{
+		{name: "targetImmediate", set: func(target *RecoveryTarget) { target.TargetImmediate = ptr.To(true) }},
+		{name: "targetLSN", set: func(target *RecoveryTarget) { target.TargetLSN = "0/3000060" }},
+		{name: "targetName", set: func(target *RecoveryTarget) { target.TargetName = "before-migration" }},
+		{name: "targetXID", set: func(target *RecoveryTarget) { target.TargetXID = "1234" }},
+		{name: "targetTime", set: func(target *RecoveryTarget) { target.TargetTime = "2024-05-21T10:12:33Z" }},
+		{name: "targetWAL", set: func(target *RecoveryTarget) { target.TargetWAL = "000000010000000500000002" }},
+		{name: "targetBackupEnd", set: func(target *RecoveryTarget) { target.TargetBackupEnd = ptr.To(true) }},
+	}
+
+	It("accepts every single target", func() {
+		for _, single := range targets {
+			target := &RecoveryTarget{}
+			single.set(target)
+			Expect(target.Validate()).To(Succeed(), single.name)
+		}
+	})
+
+	It("rejects every pair of targets, listing the conflicting ones", func() {
+		for i := range targets {
+			for j := i + 1; j < len(targets); j++ {
+				target := &RecoveryTarget{}
+				targets[i].set(target)
+				targets[j].set(target)
+
+				err := target.Validate()
+				Expect(err).To(MatchError(ErrConflictingRecoveryTargets))
+				Expect(err.Error()).To(HaveSuffix(targets[i].name + ", " + targets[j].name))
+			}
+		}
+	})
+
+	It("lists every conflicting target", func() {
+		target := &RecoveryTarget{TargetName: "before-migration", TargetTime: "2024-05-21T10:12:33Z", TargetXID: "1234"}
+		Expect(target.Validate()).To(MatchError(
+			"recovery target options are mutually exclusive: targetName, targetXID, targetTime"))
+	})
+})
+
+var _ = Describe("RecoveryTarget ResolveTargetWAL", func() {
+	It("replaces the target WAL with the LSN where it ends", func() {
+		target := &RecoveryTarget{BackupID: "20240520T101010", TargetWAL: "0000000100000ABC00000010"}
+		resolved, err := target.ResolveTargetWAL(16 * 1024 * 1024)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved.Render()).To(Equal(
+			"recovery_target_lsn = 'ABC/11000000'\n" +
+				"recovery_target_inclusive = false\n"))
+		Expect(target.TargetWAL).To(Equal("0000000100000ABC00000010"))
+	})
+
+	It("depends on the WAL segment size", func() {
+		target := &RecoveryTarget{TargetWAL: "000000010000000500000002"}
+		resolved, err := target.ResolveTargetWAL(1024 * 1024 * 1024)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved.TargetLSN).To(Equal("5/C0000000"))
+	})
+
+	It("leaves the other targets untouched", func() {
+		target := &RecoveryTarget{TargetLSN: "0/3000060"}
+		Expect(target.ResolveTargetWAL(16 * 1024 * 1024)).To(BeIdenticalTo(target))
+	})
+
+	It("rejects an invalid or unresolved target WAL", func() {
+		_, err := (&RecoveryTarget{TargetWAL: "0000000100000ABC00000010.partial"}).ResolveTargetWAL(16 * 1024 * 1024)
+		Expect(err).To(HaveOccurred())
+
+		_, err = (&RecoveryTarget{TargetWAL: "0000000100000ABC00000010"}).Render()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("RecoveryTarget ResolveTargetBackupEnd", func() {
+	It("replaces the end of the backup with its end LSN", func() {
+		target := &RecoveryTarget{BackupID: "20240520T101010", TargetBackupEnd: ptr.To(true)}
+		resolved, err := target.ResolveTargetBackupEnd("ABC/10000100")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved.Render()).To(Equal(
+			"recovery_target_lsn = 'ABC/10000100'\n" +
+				"recovery_target_inclusive = true\n"))
+		Expect(target.TargetBackupEnd).To(HaveValue(BeTrue()))
+	})
+
+	It("leaves the other targets untouched", func() {
+		target := &RecoveryTarget{TargetLSN: "0/3000060"}
+		Expect(target.ResolveTargetBackupEnd("ABC/10000100")).To(BeIdenticalTo(target))
+
+		target = &RecoveryTarget{TargetBackupEnd: ptr.To(false)}
+		Expect(target.ResolveTargetBackupEnd("ABC/10000100")).To(BeIdenticalTo(target))
+	})
+
+	It("rejects an unknown or invalid end LSN", func() {
+		target := &RecoveryTarget{TargetBackupEnd: ptr.To(true)}
+		_, err := target.ResolveTargetBackupEnd("")
+		Expect(err).To(HaveOccurred())
+
+		_, err = target.ResolveTargetBackupEnd("not-an-lsn")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("refuses to render an unresolved end of the backup", func() {
+		_, err := (&RecoveryTarget{TargetBackupEnd: ptr.To(true)}).Render()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("post-restore maintenance", func() {
+	newCluster := func(maintenance *PostRestoreMaintenance) *Cluster {
+		return &Cluster{
+			Spec: ClusterSpec{
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{Source: "origin", PostRestoreMaintenance: maintenance},
+				},
+			},
+		}
+	}
+
+	setCondition := func(cluster *Cluster, reason ConditionReason) {
+		cluster.Status.Conditions = []metav1.Condition{
+			{
+				Type:   string(ConditionPostRestoreMaintenance),
+				Status: metav1.ConditionFalse,
+				Reason: string(reason),
+			},
+		}
+	}
+
+	It("is not pending when no maintenance operation is requested", func() {
+		Expect(newCluster(nil).IsPostRestoreMaintenancePending()).To(BeFalse())
+		Expect(newCluster(&PostRestoreMaintenance{}).IsPostRestoreMaintenancePending()).To(BeFalse())
+		Expect((&Cluster{}).IsPostRestoreMaintenancePending()).To(BeFalse())
+	})
+
+	It("is pending until the maintenance is terminated", func() {
+		cluster := newCluster(&PostRestoreMaintenance{Analyze: true})
+		Expect(cluster.IsPostRestoreMaintenancePending()).To(BeTrue())
+
+		setCondition(cluster, ConditionReasonPostRestoreMaintenanceRunning)
+		Expect(cluster.IsPostRestoreMaintenancePending()).To(BeTrue())
+
+		setCondition(cluster, ConditionReasonPostRestoreMaintenanceFailed)
+		Expect(cluster.IsPostRestoreMaintenancePending()).To(BeFalse())
+
+		setCondition(cluster, ConditionReasonPostRestoreMaintenanceCompleted)
+		Expect(cluster.IsPostRestoreMaintenancePending()).To(BeFalse())
+	})
+
+	It("is never pending when the readiness gate is disabled", func() {
+		cluster := newCluster(&PostRestoreMaintenance{Prewarm: true, ReadinessGate: ptr.To(false)})
+		Expect(cluster.IsPostRestoreMaintenancePending()).To(BeFalse())
+	})
+
+	It("processes one database at a time by default", func() {
+		var maintenance *PostRestoreMaintenance
+		Expect(maintenance.GetParallelism()).To(Equal(1))
+		Expect((&PostRestoreMaintenance{Analyze: true}).GetParallelism()).To(Equal(1))
+		Expect((&PostRestoreMaintenance{Analyze: true, Parallelism: ptr.To(int32(4))}).GetParallelism()).To(Equal(4))
+	})
+})
+
+var _ = Describe("Recovery role", func() {
+	It("defaults to the role implied by the replica cluster configuration", func() {
+		cluster := &Cluster{}
+		Expect(cluster.GetRecoveryRole()).To(Equal(RecoveryRolePrimary))
+
+		cluster.Spec.Bootstrap = &BootstrapConfiguration{Recovery: &BootstrapRecovery{Source: "origin"}}
+		Expect(cluster.GetRecoveryRole()).To(Equal(RecoveryRolePrimary))
+
+		cluster.Spec.ReplicaCluster = &ReplicaClusterConfiguration{Enabled: ptr.To(true), Source: "origin"}
+		Expect(cluster.GetRecoveryRole()).To(Equal(RecoveryRoleReplicaCluster))
+	})
+
+	It("uses the requested role", func() {
+		cluster := &Cluster{
+			Spec: ClusterSpec{
+				Bootstrap: &BootstrapConfiguration{
+					Recovery: &BootstrapRecovery{Source: "origin", Role: RecoveryRoleStandby},
+				},
+				ReplicaCluster: &ReplicaClusterConfiguration{Enabled: ptr.To(true), Source: "origin"},
+			},
+		}
+		Expect(cluster.GetRecoveryRole()).To(Equal(RecoveryRoleStandby))
+	})
+})
+
+var _ = Describe("WAL archiving kept disabled after the recovery", func() {
+	It("disables the WAL archiving via annotation or while recovering", func() {
+		cluster := &Cluster{}
+		Expect(cluster.IsWalArchivingDisabled()).To(BeFalse())
+		Expect(cluster.IsArchivingKeptDisabledAfterRecovery()).To(BeFalse())
+
+		cluster.Annotations = map[string]string{utils.SkipWalArchiving: "enabled"}
+		Expect(cluster.IsWalArchivingDisabled()).To(BeTrue())
+
+		cluster.Annotations = nil
+		cluster.Spec.Bootstrap = &BootstrapConfiguration{
+			Recovery: &BootstrapRecovery{Source: "origin", KeepArchivingDisabled: true},
+		}
+		Expect(cluster.IsArchivingKeptDisabledAfterRecovery()).To(BeTrue())
+		Expect(cluster.IsWalArchivingDisabled()).To(BeTrue())
+	})
+})
+
+var _ = Describe("temporary directory policy of the recovery", func() {
+	It("defaults to deleting the temporary data directory", func() {
+		cluster := &Cluster{}
+		Expect(cluster.GetRecoveryTemporaryDirectoryPolicy()).To(Equal(RecoveryTemporaryDirectoryPolicyDelete))
+
+		cluster.Spec.Bootstrap = &BootstrapConfiguration{
+			Recovery: &BootstrapRecovery{TemporaryDirectoryPolicy: RecoveryTemporaryDirectoryPolicyRetainOnFailure},
+		}
+		Expect(cluster.GetRecoveryTemporaryDirectoryPolicy()).To(Equal(RecoveryTemporaryDirectoryPolicyRetainOnFailure))
+	})
+})
+
+var _ = Describe("heartbeat of the restore", func() {
+	It("uses the default interval and stale threshold", func() {
+		var heartbeat *RecoveryHeartbeat
+		Expect(heartbeat.GetInterval()).To(Equal(DefaultRecoveryHeartbeatInterval))
+		Expect(heartbeat.GetStaleThreshold()).To(Equal(DefaultRecoveryHeartbeatStaleThreshold))
+
+		heartbeat = &RecoveryHeartbeat{
+			Interval:       &metav1.Duration{Duration: time.Minute},
+			StaleThreshold: &metav1.Duration{Duration: time.Hour},
+		}
+		Expect(heartbeat.GetInterval()).To(Equal(time.Minute))
+		Expect(heartbeat.GetStaleThreshold()).To(Equal(time.Hour))
+	})
+
+	It("detects a stale heartbeat", func() {
+		now := time.Now()
+		heartbeat := &RestoreHeartbeat{LastProgressTime: metav1.NewTime(now.Add(-5 * time.Minute))}
+		Expect(heartbeat.IsStale(now, 10*time.Minute)).To(BeFalse())
+		Expect(heartbeat.IsStale(now, time.Minute)).To(BeTrue())
+
+		heartbeat = nil
+		Expect(heartbeat.IsStale(now, time.Minute)).To(BeFalse())
+	})
+})
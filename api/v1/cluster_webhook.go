@@ -19,6 +19,9 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"path"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -27,6 +30,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -128,7 +132,7 @@ func (r *Cluster) setDefaults(preserveUserSettings bool) {
 			UserSettings:                  r.Spec.PostgresConfiguration.Parameters,
 			IsReplicaCluster:              r.IsReplica(),
 			PreserveFixedSettingsFromUser: preserveUserSettings,
-			IsWalArchivingDisabled:        utils.IsWalArchivingDisabled(&r.ObjectMeta),
+			IsWalArchivingDisabled:        r.IsWalArchivingDisabled(),
 			IsAlterSystemEnabled:          r.Spec.PostgresConfiguration.EnableAlterSystem,
 		}
 		sanitizedParameters := postgres.CreatePostgresqlConfiguration(info).GetConfigurationParameters()
@@ -325,7 +329,67 @@ func (r *Cluster) Validate() (allErrs field.ErrorList) {
 		r.validateBootstrapPgBaseBackupSource,
 		r.validateTablespaceBackupSnapshot,
 		r.validateBootstrapRecoverySource,
+		r.validateBootstrapRecoveryWALSource,
 		r.validateBootstrapRecoveryDataSource,
+		r.validateBootstrapRecoveryDecryption,
+		r.validateBootstrapRecoveryWALDecryption,
+		r.validateBootstrapRecoverySchemaOnly,
+		r.validateBootstrapRecoveryVerifyWALArchive,
+		r.validateBootstrapRecoveryBarmanHome,
+		r.validateBootstrapRecoverySmokeTest,
+		r.validateBootstrapRecoveryReadinessCheck,
+		r.validateBootstrapRecoveryCatalogSummary,
+		r.validateBootstrapRecoveryFastRecovery,
+		r.validateBootstrapRecoveryZeroDamagedPages,
+		r.validateBootstrapRecoveryLocal,
+		r.validateBootstrapRecoveryPostRestoreMaintenance,
+		r.validateBootstrapRecoveryPauseAtTarget,
+		r.validateBootstrapRecoveryShutdownAtTarget,
+		r.validateBootstrapRecoveryVerification,
+		r.validateBootstrapRecoverySettings,
+		r.validateBootstrapRecoveryBackupNamespace,
+		r.validateBootstrapRecoveryManifest,
+		r.validateBootstrapRecoveryPasswordResets,
+		r.validateBootstrapRecoveryRetryPolicy,
+		r.validateBootstrapRecoveryBackupFallback,
+		r.validateBootstrapRecoveryWALGapCheck,
+		r.validateBootstrapRecoveryTargetTimeCheck,
+		r.validateBootstrapRecoveryInPlace,
+		r.validateBootstrapRecoveryProxy,
+		r.validateBootstrapRecoveryExtensions,
+		r.validateBootstrapRecoveryAllowedDatabases,
+		r.validateBootstrapRecoveryTablespaceRemap,
+		r.validateBootstrapRecoveryPhaseTimeouts,
+		r.validateBootstrapRecoveryReplayThrottle,
+		r.validateBootstrapRecoveryLogicalExport,
+		r.validateBootstrapRecoveryVerifyRecoveryWindow,
+		r.validateBootstrapRecoveryPromotionSlot,
+		r.validateBootstrapRecoveryReplicationSettings,
+		r.validateBootstrapRecoveryStaging,
+		r.validateBootstrapRecoveryInodesCheck,
+		r.validateBootstrapRecoveryDownloadCache,
+		r.validateBootstrapRecoveryKeepBundledWAL,
+		r.validateBootstrapRecoveryObjectStoreTimeouts,
+		r.validateBootstrapRecoveryWALFetchRetries,
+		r.validateBootstrapRecoveryDataSourcePolicy,
+		r.validateBootstrapRecoverySequenceAdvance,
+		r.validateBootstrapRecoveryStatStatementsReset,
+		r.validateBootstrapRecoveryFreeze,
+		r.validateBootstrapRecoveryPreparedTransactions,
+		r.validateBootstrapRecoveryExpectedSource,
+		r.validateBootstrapRecoveryRepairControlFile,
+		r.validateBootstrapRecoveryRole,
+		r.validateBootstrapRecoveryPromotionRetry,
+		r.validateBootstrapRecoveryProbeWALFetch,
+		r.validateBootstrapRecoveryCredentialsProvider,
+		r.validateBootstrapRecoveryVerifyBackupManifest,
+		r.validateBootstrapRecoveryTrackCommitTimestamp,
+		r.validateBootstrapRecoveryConnectionRamp,
+		r.validateBootstrapRecoverySizeCheck,
+		r.validateBootstrapRecoveryHeartbeat,
+		r.validateBootstrapRecoveryBarmanTempDirectory,
+		r.validateBootstrapRecoverySubscriptions,
+		r.validateBootstrapRecoveryLogProbe,
 		r.validateExternalClusters,
 		r.validateTolerations,
 		r.validateAntiAffinity,
@@ -545,19 +609,58 @@ func (r *Cluster) validateImport() field.ErrorList {
 		return nil
 	}
 
+	result := importSpec.validateSource()
 	switch importSpec.Type {
 	case MicroserviceSnapshotType:
-		return importSpec.validateMicroservice()
+		return append(result, importSpec.validateMicroservice()...)
 	case MonolithSnapshotType:
-		return importSpec.validateMonolith()
+		return append(result, importSpec.validateMonolith()...)
 	default:
-		return field.ErrorList{
+		return append(result,
 			field.Invalid(
 				field.NewPath("spec", "bootstrap", "initdb", "import", "type"),
 				importSpec.Type,
-				"Unrecognized import type"),
+				"Unrecognized import type"))
+	}
+}
+
+// validateSource is used to ensure that the logical dumps are imported
+// from a valid location, and not together with an external cluster
+func (s Import) validateSource() field.ErrorList {
+	var result field.ErrorList
+	sourcePath := field.NewPath("spec", "bootstrap", "initdb", "import", "source")
+
+	if s.Source.Dump == nil {
+		return nil
+	}
+
+	if s.Source.ExternalCluster != "" {
+		return field.ErrorList{
+			field.Invalid(
+				sourcePath.Child("dump"),
+				s.Source.Dump,
+				"The dumps cannot be imported together with an external cluster"),
 		}
 	}
+
+	if dumpPath := s.Source.Dump.Path; dumpPath != "" &&
+		(!localBackupPathRe.MatchString(dumpPath) || path.Clean(dumpPath) != dumpPath ||
+			strings.HasPrefix(dumpPath, "..")) {
+		result = append(result, field.Invalid(
+			sourcePath.Child("dump", "path"),
+			s.Source.Dump.Path,
+			"The path must be relative to the root of the volume, without any '..' component"))
+	}
+
+	if len(s.Roles) > 0 {
+		result = append(result, field.Invalid(
+			field.NewPath("spec", "bootstrap", "initdb", "import", "roles"),
+			s.Roles,
+			"The roles cannot be selected when importing the dumps, as every role contained "+
+				"in the globals file is imported"))
+	}
+
+	return result
 }
 
 func (s Import) validateMicroservice() field.ErrorList {
@@ -701,209 +804,2660 @@ func (r *Cluster) validateApplicationDatabase(
 		result = append(
 			result,
 			field.Invalid(
-				field.NewPath("spec", "bootstrap", command, "database"),
-				"",
-				"You need to specify the database name"))
+				field.NewPath("spec", "bootstrap", command, "database"),
+				"",
+				"You need to specify the database name"))
+	}
+	return result
+}
+
+// validateCerts validate all the provided certs
+func (r *Cluster) validateCerts() field.ErrorList {
+	var result field.ErrorList
+	certificates := r.Spec.Certificates
+
+	if certificates == nil {
+		return result
+	}
+
+	if certificates.ServerTLSSecret != "" {
+		// Currently names are not validated, maybe add this check in future
+		if len(certificates.ServerAltDNSNames) != 0 {
+			result = append(
+				result,
+				field.Invalid(
+					field.NewPath("spec", "certificates", "serveraltdnsnames"),
+					fmt.Sprintf("%v", certificates.ServerAltDNSNames),
+					"Server alternative DNS names can't be defined when server TLS secret is provided"))
+		}
+
+		// With ServerTLSSecret not empty you must provide the ServerCASecret
+		if certificates.ServerCASecret == "" {
+			result = append(
+				result,
+				field.Invalid(
+					field.NewPath("spec", "certificates", "servercasecret"),
+					"",
+					"Server CA secret can't be empty when server TLS secret is provided"))
+		}
+	}
+
+	// If you provide the ReplicationTLSSecret we must provide the ClientCaSecret
+	if certificates.ReplicationTLSSecret != "" && certificates.ClientCASecret == "" {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "certificates", "clientcasecret"),
+				"",
+				"Client CA secret can't be empty when client replication secret is provided"))
+	}
+
+	return result
+}
+
+// ValidateSuperuserSecret validate super user secret value
+func (r *Cluster) validateSuperuserSecret() field.ErrorList {
+	var result field.ErrorList
+
+	// If empty, we're ok!
+	if r.Spec.SuperuserSecret == nil {
+		return result
+	}
+
+	// We check that we have a valid name and not empty
+	if r.Spec.SuperuserSecret.Name == "" {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "superusersecret", "name"),
+				"",
+				"Super user secret name can't be empty"))
+	}
+
+	return result
+}
+
+// validateBootstrapMethod is used to ensure we have only one
+// bootstrap methods active
+func (r *Cluster) validateBootstrapMethod() field.ErrorList {
+	var result field.ErrorList
+
+	// If it's not configured, everything is ok
+	if r.Spec.Bootstrap == nil {
+		return result
+	}
+
+	bootstrapMethods := 0
+	if r.Spec.Bootstrap.InitDB != nil {
+		bootstrapMethods++
+	}
+	if r.Spec.Bootstrap.Recovery != nil {
+		bootstrapMethods++
+	}
+	if r.Spec.Bootstrap.PgBaseBackup != nil {
+		bootstrapMethods++
+	}
+
+	if bootstrapMethods > 1 {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "bootstrap"),
+				"",
+				"Too many bootstrap types specified"))
+	}
+
+	return result
+}
+
+// validateBootstrapPgBaseBackupSource is used to ensure that the source
+// server is correctly defined
+func (r *Cluster) validateBootstrapPgBaseBackupSource() field.ErrorList {
+	var result field.ErrorList
+
+	// This validation is only applicable for physical backup
+	// based bootstrap
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.PgBaseBackup == nil {
+		return result
+	}
+
+	_, found := r.ExternalCluster(r.Spec.Bootstrap.PgBaseBackup.Source)
+	if !found {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "pg_basebackup", "source"),
+				r.Spec.Bootstrap.PgBaseBackup.Source,
+				fmt.Sprintf("External cluster %v not found", r.Spec.Bootstrap.PgBaseBackup.Source)))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoverySource is used to ensure that the source
+// server is correctly defined
+func (r *Cluster) validateBootstrapRecoverySource() field.ErrorList {
+	var result field.ErrorList
+
+	// This validation is only applicable for recovery based bootstrap
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.Source == "" {
+		return result
+	}
+
+	_, found := r.ExternalCluster(r.Spec.Bootstrap.Recovery.Source)
+	if !found {
+		result = append(
+			result,
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "source"),
+				r.Spec.Bootstrap.Recovery.Source,
+				fmt.Sprintf("External cluster %v not found", r.Spec.Bootstrap.Recovery.Source)))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryWALSource is used to ensure that the source
+// of the WAL files is an external cluster with an object store, and that
+// it is used by a recovery from an object store
+func (r *Cluster) validateBootstrapRecoveryWALSource() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.WALSource == "" {
+		return nil
+	}
+
+	walSourcePath := field.NewPath("spec", "bootstrap", "recovery", "walSource")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	server, found := r.ExternalCluster(recoverySection.WALSource)
+	switch {
+	case !found:
+		result = append(
+			result,
+			field.Invalid(
+				walSourcePath,
+				recoverySection.WALSource,
+				fmt.Sprintf("External cluster %v not found", recoverySection.WALSource)))
+
+	case server.BarmanObjectStore == nil:
+		result = append(
+			result,
+			field.Invalid(
+				walSourcePath,
+				recoverySection.WALSource,
+				fmt.Sprintf("External cluster %v has no object store", recoverySection.WALSource)))
+	}
+
+	if recoverySection.VolumeSnapshots != nil {
+		result = append(
+			result,
+			field.Invalid(
+				walSourcePath,
+				recoverySection.WALSource,
+				"A WAL source is not compatible with the recovery from volume snapshots, "+
+					"use source to define the WAL archive"))
+	}
+
+	if recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				walSourcePath,
+				recoverySection.WALSource,
+				"A WAL source is not compatible with the recovery from a local volume"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryDataSource is used to ensure that the data
+// source is correctly defined
+func (r *Cluster) validateBootstrapRecoveryDataSource() field.ErrorList {
+	// This validation is only applicable for datasource-based recovery based bootstrap
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.VolumeSnapshots == nil {
+		return nil
+	}
+
+	recoveryPath := field.NewPath("spec", "bootstrap", "recovery")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	if recoverySection.Backup != nil {
+		return field.ErrorList{
+			field.Invalid(
+				recoveryPath.Child("backup"),
+				r.Spec.Bootstrap.Recovery.Backup,
+				"Recovery from dataSource is not compatible with other types of recovery"),
+		}
+	}
+
+	if recoverySection.RecoveryTarget != nil && recoverySection.RecoveryTarget.BackupID != "" {
+		return field.ErrorList{
+			field.Invalid(
+				recoveryPath.Child("recoveryTarget", "backupID"),
+				r.Spec.Bootstrap.Recovery.RecoveryTarget.BackupID,
+				"Cannot specify a backupID when recovering using a DataSource"),
+		}
+	}
+
+	result := validateVolumeSnapshotSource(recoverySection.VolumeSnapshots.Storage, recoveryPath.Child("storage"))
+
+	if recoverySection.VolumeSnapshots.WalStorage != nil && r.Spec.WalStorage == nil {
+		walStoragePath := recoveryPath.Child("dataSource", "walStorage")
+		result = append(
+			result,
+			field.Invalid(
+				walStoragePath,
+				r.Spec.Bootstrap.Recovery.VolumeSnapshots.WalStorage,
+				"A WAL storage configuration is required when recovering using a DataSource for WALs"))
+		result = append(
+			result,
+			validateVolumeSnapshotSource(
+				*recoverySection.VolumeSnapshots.WalStorage, walStoragePath)...)
+	}
+
+	if recoverySection.VolumeSnapshots.WalStorage != nil {
+		result = append(
+			result,
+			validateVolumeSnapshotSource(
+				*recoverySection.VolumeSnapshots.WalStorage,
+				recoveryPath.Child("dataSource", "walStorage"))...)
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryDecryption is used to ensure that the decryption
+// of the restored base backup is correctly defined
+func (r *Cluster) validateBootstrapRecoveryDecryption() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.Decryption == nil {
+		return nil
+	}
+
+	decryptionPath := field.NewPath("spec", "bootstrap", "recovery", "decryption")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil {
+		result = append(
+			result,
+			field.Invalid(
+				decryptionPath,
+				recoverySection.Decryption,
+				"Decryption is only supported when recovering from an object store"))
+	}
+
+	return append(result, validateDecryptionConfiguration(decryptionPath, recoverySection.Decryption)...)
+}
+
+// validateBootstrapRecoveryWALDecryption is used to ensure that the
+// decryption of the WAL files fetched from the object store is correctly
+// defined, and can be embedded in the restore_command
+func (r *Cluster) validateBootstrapRecoveryWALDecryption() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.WALDecryption == nil {
+		return nil
+	}
+
+	decryptionPath := field.NewPath("spec", "bootstrap", "recovery", "walDecryption")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				decryptionPath,
+				recoverySection.WALDecryption,
+				"The decryption of the WAL files is only supported when they are fetched from an object store"))
+	}
+
+	for idx, argument := range recoverySection.WALDecryption.Command {
+		if strings.Contains(argument, "'") {
+			result = append(
+				result,
+				field.Invalid(
+					decryptionPath.Child("command").Index(idx),
+					argument,
+					"The WAL decryption command cannot contain single quotes, as it is part of the restore_command"))
+		}
+	}
+
+	return append(result, validateDecryptionConfiguration(decryptionPath, recoverySection.WALDecryption)...)
+}
+
+// validateDecryptionConfiguration is used to ensure that the command and
+// the key of a decryption configuration are defined
+func validateDecryptionConfiguration(
+	decryptionPath *field.Path,
+	decryption *RecoveryDecryptionConfiguration,
+) field.ErrorList {
+	var result field.ErrorList
+
+	if len(decryption.Command) == 0 || decryption.Command[0] == "" {
+		result = append(
+			result,
+			field.Required(decryptionPath.Child("command"), "A decryption command is required"))
+	}
+
+	if keySecret := decryption.KeySecret; keySecret != nil &&
+		(keySecret.Name == "" || keySecret.Key == "") {
+		result = append(
+			result,
+			field.Invalid(
+				decryptionPath.Child("keySecret"),
+				keySecret,
+				"Both the name and the key of the decryption key secret are required"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoverySchemaOnly is used to ensure that a schema-only
+// recovery is requested only when the operator can remove the user data
+func (r *Cluster) validateBootstrapRecoverySchemaOnly() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || !r.Spec.Bootstrap.Recovery.SchemaOnly {
+		return nil
+	}
+
+	schemaOnlyPath := field.NewPath("spec", "bootstrap", "recovery", "schemaOnly")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				schemaOnlyPath,
+				recoverySection.SchemaOnly,
+				"Schema-only recovery is not supported for replica clusters"))
+	}
+
+	if recoverySection.VolumeSnapshots != nil && recoverySection.Source == "" {
+		result = append(
+			result,
+			field.Invalid(
+				schemaOnlyPath,
+				recoverySection.SchemaOnly,
+				"Schema-only recovery from volume snapshots requires a WAL archive source"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryVerifyWALArchive is used to ensure that the
+// verification of the WAL archive has a well-defined range of WAL files
+// to be checked
+func (r *Cluster) validateBootstrapRecoveryVerifyWALArchive() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || !r.Spec.Bootstrap.Recovery.VerifyWALArchive {
+		return nil
+	}
+
+	verifyPath := field.NewPath("spec", "bootstrap", "recovery", "verifyWALArchive")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil {
+		result = append(
+			result,
+			field.Invalid(
+				verifyPath,
+				recoverySection.VerifyWALArchive,
+				"The verification of the WAL archive is only supported when recovering from an object store"))
+	}
+
+	if recoverySection.RecoveryTarget == nil || recoverySection.RecoveryTarget.TargetLSN == "" {
+		result = append(
+			result,
+			field.Invalid(
+				verifyPath,
+				recoverySection.VerifyWALArchive,
+				"The verification of the WAL archive requires a targetLSN recovery target"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryBarmanHome is used to ensure that the HOME
+// directory for barman-cloud is an absolute path
+func (r *Cluster) validateBootstrapRecoveryBarmanHome() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.BarmanHome == "" {
+		return nil
+	}
+
+	barmanHome := r.Spec.Bootstrap.Recovery.BarmanHome
+	if !path.IsAbs(barmanHome) || path.Clean(barmanHome) == "/" {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "barmanHome"),
+				barmanHome,
+				"The barman HOME must be an absolute path, different from the root directory"),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoverySmokeTest is used to ensure that the
+// post-recovery smoke test will be executed
+func (r *Cluster) validateBootstrapRecoverySmokeTest() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.SmokeTest == nil {
+		return nil
+	}
+
+	smokeTestPath := field.NewPath("spec", "bootstrap", "recovery", "smokeTest")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if strings.TrimSpace(recoverySection.SmokeTest.Query) == "" {
+		result = append(
+			result,
+			field.Required(smokeTestPath.Child("query"), "A smoke test query is required"))
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				smokeTestPath,
+				recoverySection.SmokeTest,
+				"The post-recovery smoke test is not supported for replica clusters"))
+	}
+
+	if recoverySection.VolumeSnapshots != nil && recoverySection.Source == "" {
+		result = append(
+			result,
+			field.Invalid(
+				smokeTestPath,
+				recoverySection.SmokeTest,
+				"The post-recovery smoke test from volume snapshots requires a WAL archive source"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryReadinessCheck is used to ensure that the
+// readiness query will be executed, and that its timeout is positive
+func (r *Cluster) validateBootstrapRecoveryReadinessCheck() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.ReadinessCheck == nil {
+		return nil
+	}
+
+	checkPath := field.NewPath("spec", "bootstrap", "recovery", "readinessCheck")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	check := recoverySection.ReadinessCheck
+	var result field.ErrorList
+
+	if strings.TrimSpace(check.Query) == "" {
+		result = append(
+			result,
+			field.Required(checkPath.Child("query"), "A readiness query is required"))
+	}
+
+	if check.Timeout != nil && check.Timeout.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				checkPath.Child("timeout"),
+				check.Timeout.String(),
+				"The timeout of the readiness query must be positive"))
+	}
+
+	if recoverySection.VolumeSnapshots != nil && recoverySection.Source == "" {
+		result = append(
+			result,
+			field.Invalid(
+				checkPath,
+				check,
+				"The readiness query from volume snapshots requires a WAL archive source"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryCatalogSummary is used to ensure that the
+// catalog summary is requested only where the restored instance can
+// be checked, and that every schema is expected only once
+func (r *Cluster) validateBootstrapRecoveryCatalogSummary() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.CatalogSummary == nil {
+		return nil
+	}
+
+	summaryPath := field.NewPath("spec", "bootstrap", "recovery", "catalogSummary")
+	summary := r.Spec.Bootstrap.Recovery.CatalogSummary
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				summaryPath,
+				summary,
+				"The catalog summary is not supported for replica clusters"))
+	}
+
+	if summary.Expected == nil {
+		return result
+	}
+
+	schemas := make(map[string]bool, len(summary.Expected.Tables))
+	for idx, tables := range summary.Expected.Tables {
+		key := tables.Database + "/" + tables.Schema
+		if schemas[key] {
+			result = append(
+				result,
+				field.Duplicate(
+					summaryPath.Child("expected", "tables").Index(idx),
+					fmt.Sprintf("%s.%s", tables.Database, tables.Schema)))
+		}
+		schemas[key] = true
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryFastRecovery is used to ensure that the
+// fast recovery mode is requested only where it can be applied
+func (r *Cluster) validateBootstrapRecoveryFastRecovery() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || !r.Spec.Bootstrap.Recovery.FastRecovery {
+		return nil
+	}
+
+	if r.IsReplica() {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "fastRecovery"),
+				r.Spec.Bootstrap.Recovery.FastRecovery,
+				"The fast recovery mode is not supported for replica clusters"),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryZeroDamagedPages is used to ensure that the
+// damaged pages are zeroed out only where the outcome can be reported
+func (r *Cluster) validateBootstrapRecoveryZeroDamagedPages() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || !r.Spec.Bootstrap.Recovery.ZeroDamagedPages {
+		return nil
+	}
+
+	if r.IsReplica() {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "zeroDamagedPages"),
+				r.Spec.Bootstrap.Recovery.ZeroDamagedPages,
+				"Zeroing the damaged pages is not supported for replica clusters"),
+		}
+	}
+
+	return nil
+}
+
+// localBackupPathRe matches the paths that can be used inside the
+// volume containing a local backup or the logical dumps
+var localBackupPathRe = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+
+// validateBootstrapRecoveryLocal is used to ensure that the recovery
+// from a local volume is correctly defined
+func (r *Cluster) validateBootstrapRecoveryLocal() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.Local == nil {
+		return nil
+	}
+
+	localPath := field.NewPath("spec", "bootstrap", "recovery", "local")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	local := recoverySection.Local
+	var result field.ErrorList
+
+	if recoverySection.Backup != nil || recoverySection.Source != "" || recoverySection.VolumeSnapshots != nil {
+		result = append(
+			result,
+			field.Invalid(
+				localPath,
+				local,
+				"Recovery from a local volume is not compatible with other types of recovery"))
+	}
+
+	if local.ClaimName == "" {
+		result = append(
+			result,
+			field.Required(localPath.Child("claimName"), "The PVC containing the local backup is required"))
+	}
+
+	localBackupPaths := []struct{ name, value string }{
+		{name: "dataPath", value: local.GetDataPath()},
+		{name: "walPath", value: local.GetWALPath()},
+	}
+	for _, localBackupPath := range localBackupPaths {
+		value := localBackupPath.value
+		if !localBackupPathRe.MatchString(value) || path.Clean(value) != value || strings.HasPrefix(value, "..") {
+			result = append(
+				result,
+				field.Invalid(
+					localPath.Child(localBackupPath.name),
+					value,
+					"A relative path inside the volume, made of letters, digits, '.', '_', '-' and '/', is required"))
+		}
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				localPath,
+				local,
+				"Recovery from a local volume is not supported for replica clusters"))
+	}
+
+	if recoverySection.VerifyWALArchive || recoverySection.BarmanHome != "" || recoverySection.Proxy != nil {
+		result = append(
+			result,
+			field.Invalid(
+				localPath,
+				local,
+				"Recovery from a local volume doesn't use barman, verifyWALArchive, barmanHome and proxy "+
+					"are not supported"))
+	}
+
+	if local.Decompression != nil &&
+		(len(local.Decompression.Command) == 0 || strings.TrimSpace(local.Decompression.Command[0]) == "") {
+		result = append(
+			result,
+			field.Required(
+				localPath.Child("decompression", "command"),
+				"The decompression program is required"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryPostRestoreMaintenance is used to ensure that
+// the post-restore maintenance is requested only when it can be executed
+func (r *Cluster) validateBootstrapRecoveryPostRestoreMaintenance() field.ErrorList {
+	maintenance := r.GetPostRestoreMaintenance()
+	if !maintenance.IsEnabled() {
+		return nil
+	}
+
+	if r.IsReplica() {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "postRestoreMaintenance"),
+				maintenance,
+				"The post-restore maintenance is not supported for replica clusters, "+
+					"as the primary instance is in continuous recovery"),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryPauseAtTarget is used to ensure that the pause
+// at the recovery target is requested only when there's a target to reach
+func (r *Cluster) validateBootstrapRecoveryPauseAtTarget() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.PauseAtTarget == nil {
+		return nil
+	}
+
+	pausePath := field.NewPath("spec", "bootstrap", "recovery", "pauseAtTarget")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.RecoveryTarget == nil || recoverySection.RecoveryTarget.countTargets() == 0 {
+		result = append(
+			result,
+			field.Invalid(
+				pausePath,
+				recoverySection.PauseAtTarget,
+				"Pausing at the recovery target requires a recovery target to be specified"))
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				pausePath,
+				recoverySection.PauseAtTarget,
+				"Pausing at the recovery target is not supported for replica clusters"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryShutdownAtTarget is used to ensure that the
+// shutdown at the recovery target is requested only when there's a target
+// to reach, and that it doesn't conflict with the pause at the target
+func (r *Cluster) validateBootstrapRecoveryShutdownAtTarget() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		!r.Spec.Bootstrap.Recovery.ShutdownAtTarget {
+		return nil
+	}
+
+	shutdownPath := field.NewPath("spec", "bootstrap", "recovery", "shutdownAtTarget")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.RecoveryTarget == nil || recoverySection.RecoveryTarget.countTargets() == 0 {
+		result = append(
+			result,
+			field.Invalid(
+				shutdownPath,
+				recoverySection.ShutdownAtTarget,
+				"Shutting down at the recovery target requires a recovery target to be specified"))
+	}
+
+	if recoverySection.PauseAtTarget != nil {
+		result = append(
+			result,
+			field.Invalid(
+				shutdownPath,
+				recoverySection.ShutdownAtTarget,
+				"Shutting down at the recovery target can't be used together with pauseAtTarget"))
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				shutdownPath,
+				recoverySection.ShutdownAtTarget,
+				"Shutting down at the recovery target is not supported for replica clusters"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryVerification is used to ensure that the
+// verification of the instance paused at the recovery target contains
+// at least a check, and that every check can be executed
+func (r *Cluster) validateBootstrapRecoveryVerification() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.PauseAtTarget == nil ||
+		r.Spec.Bootstrap.Recovery.PauseAtTarget.Verification == nil {
+		return nil
+	}
+
+	verificationPath := field.NewPath("spec", "bootstrap", "recovery", "pauseAtTarget", "verification")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	verification := recoverySection.PauseAtTarget.Verification
+	var result field.ErrorList
+
+	if len(verification.Queries) == 0 && !verification.ForeignKeys && !verification.SmokeTest {
+		result = append(
+			result,
+			field.Invalid(
+				verificationPath,
+				verification,
+				"The verification of the recovery requires at least a query, "+
+					"the check of the foreign keys or the smoke test"))
+	}
+
+	for idx, query := range verification.Queries {
+		if strings.TrimSpace(query.Query) == "" {
+			result = append(
+				result,
+				field.Required(verificationPath.Child("queries").Index(idx).Child("query"),
+					"A verification query is required"))
+		}
+	}
+
+	if verification.SmokeTest && recoverySection.SmokeTest == nil {
+		result = append(
+			result,
+			field.Invalid(
+				verificationPath.Child("smokeTest"),
+				verification.SmokeTest,
+				"The smoke test can be executed during the verification only when it is defined"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoverySettings is used to ensure that the ConfigMap
+// containing the recovery settings is correctly referenced
+func (r *Cluster) validateBootstrapRecoverySettings() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.RecoverySettings == nil {
+		return nil
+	}
+
+	settingsPath := field.NewPath("spec", "bootstrap", "recovery", "recoverySettings")
+	recoverySettings := r.Spec.Bootstrap.Recovery.RecoverySettings
+	var result field.ErrorList
+
+	if recoverySettings.Name == "" {
+		result = append(
+			result,
+			field.Required(settingsPath.Child("name"), "The name of the ConfigMap is required"))
+	}
+
+	if recoverySettings.Key == "" {
+		result = append(
+			result,
+			field.Required(settingsPath.Child("key"), "The key of the ConfigMap is required"))
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				settingsPath,
+				recoverySettings,
+				"Recovery settings are not supported for replica clusters"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryBackupNamespace validates the namespace
+// of the Backup object to be restored
+func (r *Cluster) validateBootstrapRecoveryBackupNamespace() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Backup == nil || r.Spec.Bootstrap.Recovery.Backup.Namespace == "" {
+		return nil
+	}
+
+	namespacePath := field.NewPath("spec", "bootstrap", "recovery", "backup", "namespace")
+	namespace := r.Spec.Bootstrap.Recovery.Backup.Namespace
+	var result field.ErrorList
+	for _, message := range validationutil.IsDNS1123Label(namespace) {
+		result = append(result, field.Invalid(namespacePath, namespace, message))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryManifest is used to ensure that the manifest
+// of a previous restore is correctly referenced, and doesn't conflict
+// with the recovery target
+func (r *Cluster) validateBootstrapRecoveryManifest() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Manifest == nil {
+		return nil
+	}
+
+	manifestPath := field.NewPath("spec", "bootstrap", "recovery", "manifest")
+	manifest := r.Spec.Bootstrap.Recovery.Manifest
+	var result field.ErrorList
+
+	if manifest.Name == "" {
+		result = append(
+			result,
+			field.Required(manifestPath.Child("name"), "The name of the ConfigMap is required"))
+	}
+
+	if manifest.Key == "" {
+		result = append(
+			result,
+			field.Required(manifestPath.Child("key"), "The key of the ConfigMap is required"))
+	}
+
+	if r.Spec.Bootstrap.Recovery.RecoveryTarget != nil {
+		result = append(
+			result,
+			field.Invalid(
+				manifestPath,
+				manifest,
+				"The manifest of a previous restore cannot be used together with a recovery target"))
+	}
+
+	if r.Spec.Bootstrap.Recovery.VolumeSnapshots != nil || r.Spec.Bootstrap.Recovery.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				manifestPath,
+				manifest,
+				"The manifest of a previous restore can be used only when recovering from an object store"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryPasswordResets is used to ensure that every
+// role whose password is reset after the recovery has exactly one new
+// password, and that the superuser is not reset unintentionally
+func (r *Cluster) validateBootstrapRecoveryPasswordResets() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		len(r.Spec.Bootstrap.Recovery.PasswordResets) == 0 {
+		return nil
+	}
+
+	resetsPath := field.NewPath("spec", "bootstrap", "recovery", "passwordResets")
+	resets := r.Spec.Bootstrap.Recovery.PasswordResets
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				resetsPath,
+				resets,
+				"Resetting the passwords of the roles is not supported for replica clusters"))
+	}
+
+	names := stringset.New()
+	for idx, reset := range resets {
+		resetPath := resetsPath.Index(idx)
+
+		if reset.Name == "" {
+			result = append(
+				result,
+				field.Required(resetPath.Child("name"), "The name of the role is required"))
+		}
+
+		if names.Has(reset.Name) {
+			result = append(
+				result,
+				field.Duplicate(resetPath.Child("name"), reset.Name))
+		}
+		names.Put(reset.Name)
+
+		if (reset.PasswordSecret != nil) == reset.Randomize {
+			result = append(
+				result,
+				field.Invalid(
+					resetPath,
+					reset.Name,
+					"Exactly one of passwordSecret and randomize must be specified"))
+		}
+
+		if reset.PasswordSecret != nil && (reset.PasswordSecret.Name == "" || reset.PasswordSecret.Key == "") {
+			result = append(
+				result,
+				field.Required(
+					resetPath.Child("passwordSecret"),
+					"The name and the key of the secret are required"))
+		}
+
+		switch {
+		case reset.Name == "postgres" && !reset.AllowSuperuser:
+			result = append(
+				result,
+				field.Invalid(
+					resetPath.Child("name"),
+					reset.Name,
+					"The password of the superuser can be reset only when allowSuperuser is set"))
+
+		case reset.Name != "postgres" && postgres.IsRoleReserved(reset.Name):
+			result = append(
+				result,
+				field.Invalid(
+					resetPath.Child("name"),
+					reset.Name,
+					"This role is reserved for operator use"))
+		}
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryRetryPolicy is used to ensure that the
+// backoffs of the restore retry policy are consistent
+func (r *Cluster) validateBootstrapRecoveryRetryPolicy() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.RetryPolicy == nil {
+		return nil
+	}
+
+	policyPath := field.NewPath("spec", "bootstrap", "recovery", "retryPolicy")
+	policy := r.Spec.Bootstrap.Recovery.RetryPolicy
+	var result field.ErrorList
+
+	if policy.InitialBackoff != nil && policy.InitialBackoff.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				policyPath.Child("initialBackoff"),
+				policy.InitialBackoff.String(),
+				"The initial backoff must be positive"))
+	}
+
+	if policy.MaxBackoff != nil {
+		initialBackoff := policy.InitialBackoff
+		switch {
+		case policy.MaxBackoff.Duration <= 0:
+			result = append(
+				result,
+				field.Invalid(
+					policyPath.Child("maxBackoff"),
+					policy.MaxBackoff.String(),
+					"The maximum backoff must be positive"))
+
+		case initialBackoff != nil && policy.MaxBackoff.Duration < initialBackoff.Duration:
+			result = append(
+				result,
+				field.Invalid(
+					policyPath.Child("maxBackoff"),
+					policy.MaxBackoff.String(),
+					"The maximum backoff cannot be lower than the initial backoff"))
+		}
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryBackupFallback is used to ensure that the
+// fallback to older base backups is requested only when the backup to
+// be restored is chosen by the operator from an object store
+func (r *Cluster) validateBootstrapRecoveryBackupFallback() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.BackupFallback == nil {
+		return nil
+	}
+
+	recovery := r.Spec.Bootstrap.Recovery
+	fallbackPath := field.NewPath("spec", "bootstrap", "recovery", "backupFallback")
+	var result field.ErrorList
+
+	if recovery.Backup != nil || recovery.VolumeSnapshots != nil || recovery.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				fallbackPath,
+				recovery.BackupFallback,
+				"The fallback to older base backups is supported only when recovering "+
+					"from the object store of an external cluster"))
+	}
+
+	if recovery.Manifest != nil {
+		result = append(
+			result,
+			field.Invalid(
+				fallbackPath,
+				recovery.BackupFallback,
+				"The fallback to older base backups cannot be used together with "+
+					"the manifest of a previous restore"))
+	}
+
+	if recovery.RecoveryTarget != nil && recovery.RecoveryTarget.BackupID != "" {
+		result = append(
+			result,
+			field.Invalid(
+				fallbackPath,
+				recovery.BackupFallback,
+				"The fallback to older base backups cannot be used when the backup ID is chosen"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryWALGapCheck is used to ensure that the
+// thresholds of the distance between the base backup and the recovery
+// target are positive
+func (r *Cluster) validateBootstrapRecoveryWALGapCheck() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.WALGapCheck == nil {
+		return nil
+	}
+
+	checkPath := field.NewPath("spec", "bootstrap", "recovery", "walGapCheck")
+	check := r.Spec.Bootstrap.Recovery.WALGapCheck
+	var result field.ErrorList
+
+	if check.MaxWALSize != nil && check.MaxWALSize.Sign() <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				checkPath.Child("maxWALSize"),
+				check.MaxWALSize.String(),
+				"The maximum WAL size must be positive"))
+	}
+
+	if check.MaxTimeGap != nil && check.MaxTimeGap.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				checkPath.Child("maxTimeGap"),
+				check.MaxTimeGap.String(),
+				"The maximum time gap must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryTargetTimeCheck is used to ensure that the
+// verification of the recovery target time is requested only for a
+// recovery to a point in time, with a positive maximum gap
+func (r *Cluster) validateBootstrapRecoveryTargetTimeCheck() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.TargetTimeCheck == nil {
+		return nil
+	}
+
+	checkPath := field.NewPath("spec", "bootstrap", "recovery", "targetTimeCheck")
+	recovery := r.Spec.Bootstrap.Recovery
+	check := recovery.TargetTimeCheck
+	var result field.ErrorList
+
+	if recovery.RecoveryTarget == nil || recovery.RecoveryTarget.TargetTime == "" {
+		result = append(
+			result,
+			field.Invalid(
+				checkPath,
+				check,
+				"The verification of the recovery target time requires a targetTime in recoveryTarget"))
+	}
+
+	if check.MaxGap != nil && check.MaxGap.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				checkPath.Child("maxGap"),
+				check.MaxGap.String(),
+				"The maximum gap must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryInPlace is used to ensure that the restore
+// in place isn't requested when recovering from volume snapshots, as
+// the PVCs would need to be created from them
+func (r *Cluster) validateBootstrapRecoveryInPlace() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.InPlace == nil {
+		return nil
+	}
+
+	if r.Spec.Bootstrap.Recovery.VolumeSnapshots != nil {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "inPlace"),
+				r.Spec.Bootstrap.Recovery.InPlace,
+				"The restore in place is not supported when recovering from volume snapshots"),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryProxy is used to ensure that the proxy
+// used to reach the object store during the recovery is well-formed
+func (r *Cluster) validateBootstrapRecoveryProxy() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Proxy == nil {
+		return nil
+	}
+
+	var result field.ErrorList
+	proxy := r.Spec.Bootstrap.Recovery.Proxy
+	proxyPath := field.NewPath("spec", "bootstrap", "recovery", "proxy")
+
+	if proxy.HTTPSProxy == "" && proxy.HTTPProxy == "" {
+		result = append(
+			result,
+			field.Invalid(
+				proxyPath,
+				proxy,
+				"At least one of httpsProxy and httpProxy must be specified"))
+	}
+
+	if proxy.HTTPSProxy != "" {
+		if err := validateProxyURL(proxy.HTTPSProxy); err != nil {
+			result = append(result, field.Invalid(proxyPath.Child("httpsProxy"), proxy.HTTPSProxy, err.Error()))
+		}
+	}
+
+	if proxy.HTTPProxy != "" {
+		if err := validateProxyURL(proxy.HTTPProxy); err != nil {
+			result = append(result, field.Invalid(proxyPath.Child("httpProxy"), proxy.HTTPProxy, err.Error()))
+		}
+	}
+
+	for idx, host := range proxy.NoProxy {
+		if host == "" || strings.ContainsAny(host, ", \t") {
+			result = append(
+				result,
+				field.Invalid(
+					proxyPath.Child("noProxy").Index(idx),
+					host,
+					"The entries of noProxy must be non-empty and can't contain commas or spaces"))
+		}
+	}
+
+	return result
+}
+
+// validateProxyURL checks that the URL of a proxy has a supported
+// scheme and a host
+func validateProxyURL(value string) error {
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("invalid proxy URL: %v", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return fmt.Errorf("unsupported proxy scheme %q, must be one of http, https, socks5 and socks5h",
+			proxyURL.Scheme)
+	}
+
+	if proxyURL.Hostname() == "" {
+		return fmt.Errorf("the proxy URL %s doesn't contain a host", value)
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryExtensions is used to ensure that the
+// extensions to be updated after the recovery are listed only once, and
+// that they can be updated
+func (r *Cluster) validateBootstrapRecoveryExtensions() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		len(r.Spec.Bootstrap.Recovery.Extensions) == 0 {
+		return nil
+	}
+
+	extensionsPath := field.NewPath("spec", "bootstrap", "recovery", "extensions")
+	extensions := r.Spec.Bootstrap.Recovery.Extensions
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				extensionsPath,
+				extensions,
+				"Updating the extensions is not supported for replica clusters"))
+	}
+
+	names := stringset.New()
+	for idx, extension := range extensions {
+		if names.Has(extension.Name) {
+			result = append(
+				result,
+				field.Duplicate(extensionsPath.Index(idx).Child("name"), extension.Name))
+		}
+		names.Put(extension.Name)
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryAllowedDatabases is used to ensure that the
+// databases left connectable after the recovery include the application
+// database, and that they can be locked
+func (r *Cluster) validateBootstrapRecoveryAllowedDatabases() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		len(r.Spec.Bootstrap.Recovery.AllowedDatabases) == 0 {
+		return nil
+	}
+
+	allowedPath := field.NewPath("spec", "bootstrap", "recovery", "allowedDatabases")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				allowedPath,
+				recoverySection.AllowedDatabases,
+				"Locking the databases is not supported for replica clusters"))
+	}
+
+	for idx, name := range recoverySection.AllowedDatabases {
+		if name == "" {
+			result = append(
+				result,
+				field.Required(allowedPath.Index(idx), "The name of the database is required"))
+		}
+	}
+
+	if recoverySection.Database != "" && !slices.Contains(recoverySection.AllowedDatabases, recoverySection.Database) {
+		result = append(
+			result,
+			field.Invalid(
+				allowedPath,
+				recoverySection.AllowedDatabases,
+				fmt.Sprintf("The application database %s must be allowed", recoverySection.Database)))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryTablespaceRemap is used to ensure that every
+// tablespace of the backup is remapped only once, to an absolute path,
+// and only when barman-cloud-restore is used
+func (r *Cluster) validateBootstrapRecoveryTablespaceRemap() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		len(r.Spec.Bootstrap.Recovery.TablespaceRemap) == 0 {
+		return nil
+	}
+
+	remapPath := field.NewPath("spec", "bootstrap", "recovery", "tablespaceRemap")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				remapPath,
+				recoverySection.TablespaceRemap,
+				"The tablespaces can be remapped only when recovering from an object store"))
+	}
+
+	names := stringset.New()
+	for idx, remap := range recoverySection.TablespaceRemap {
+		if names.Has(remap.Name) {
+			result = append(
+				result,
+				field.Duplicate(remapPath.Index(idx).Child("name"), remap.Name))
+		}
+		names.Put(remap.Name)
+
+		if !path.IsAbs(remap.Location) || path.Clean(remap.Location) == "/" {
+			result = append(
+				result,
+				field.Invalid(
+					remapPath.Index(idx).Child("location"),
+					remap.Location,
+					"The location of a tablespace must be an absolute path, different from the root directory"))
+		}
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryPhaseTimeouts is used to ensure that the
+// timeouts of the phases of the restore are positive, and are used
+// only when barman-cloud-restore is used
+func (r *Cluster) validateBootstrapRecoveryPhaseTimeouts() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.PhaseTimeouts == nil {
+		return nil
+	}
+
+	timeoutsPath := field.NewPath("spec", "bootstrap", "recovery", "phaseTimeouts")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	timeouts := recoverySection.PhaseTimeouts
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				timeoutsPath,
+				timeouts,
+				"The timeouts of the restore phases are supported only when recovering from an object store"))
+	}
+
+	phases := []struct {
+		name    string
+		timeout *metav1.Duration
+	}{
+		{name: "download", timeout: timeouts.Download},
+		{name: "recovery", timeout: timeouts.Recovery},
+		{name: "configuration", timeout: timeouts.Configuration},
+	}
+	for _, phase := range phases {
+		if phase.timeout != nil && phase.timeout.Duration <= 0 {
+			result = append(
+				result,
+				field.Invalid(
+					timeoutsPath.Child(phase.name),
+					phase.timeout.String(),
+					fmt.Sprintf("The timeout of the %s phase must be positive", phase.name)))
+		}
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryReplayThrottle is used to ensure that the rate
+// of the WAL replay is positive, and is throttled only when the WAL files
+// are fetched by the restore_command of the recovery
+func (r *Cluster) validateBootstrapRecoveryReplayThrottle() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.ReplayThrottle == nil {
+		return nil
+	}
+
+	throttlePath := field.NewPath("spec", "bootstrap", "recovery", "replayThrottle")
+	throttle := r.Spec.Bootstrap.Recovery.ReplayThrottle
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				throttlePath,
+				throttle,
+				"The WAL replay cannot be throttled for replica clusters"))
+	}
+
+	if throttle.MaxRate.Sign() <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				throttlePath.Child("maxRate"),
+				throttle.MaxRate.String(),
+				"The maximum rate of the WAL replay must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryLogicalExport is used to ensure that the logical
+// export of the restored data has a destination and an upload command, and
+// that every database is exported only once
+func (r *Cluster) validateBootstrapRecoveryLogicalExport() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.LogicalExport == nil {
+		return nil
+	}
+
+	exportPath := field.NewPath("spec", "bootstrap", "recovery", "logicalExport")
+	export := r.Spec.Bootstrap.Recovery.LogicalExport
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				exportPath,
+				export,
+				"The logical export is not supported for replica clusters"))
+	}
+
+	if export.DestinationPath == "" {
+		result = append(
+			result,
+			field.Required(exportPath.Child("destinationPath"), "The destination of the export is required"))
+	}
+
+	if len(export.UploadCommand) == 0 || export.UploadCommand[0] == "" {
+		result = append(
+			result,
+			field.Required(exportPath.Child("uploadCommand"), "An upload command is required"))
+	}
+
+	databases := stringset.New()
+	for idx, name := range export.Databases {
+		switch {
+		case name == "":
+			result = append(
+				result,
+				field.Required(exportPath.Child("databases").Index(idx), "The name of the database is required"))
+
+		case databases.Has(name):
+			result = append(
+				result,
+				field.Duplicate(exportPath.Child("databases").Index(idx), name))
+		}
+		databases.Put(name)
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryVerifyRecoveryWindow is used to ensure that
+// the recovery window is checked only when the base backup is restored
+// from an object store
+func (r *Cluster) validateBootstrapRecoveryVerifyRecoveryWindow() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		!r.Spec.Bootstrap.Recovery.VerifyRecoveryWindow {
+		return nil
+	}
+
+	recoverySection := r.Spec.Bootstrap.Recovery
+	if recoverySection.VolumeSnapshots == nil && recoverySection.Local == nil {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "verifyRecoveryWindow"),
+			recoverySection.VerifyRecoveryWindow,
+			"The recovery window can be verified only when recovering from an object store"),
+	}
+}
+
+// validateBootstrapRecoveryPromotionSlot is used to ensure that the name
+// of the replication slot to be created in the restored instance is valid
+// and doesn't collide with the ones managed by the operator
+func (r *Cluster) validateBootstrapRecoveryPromotionSlot() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.PromotionSlot == "" {
+		return nil
+	}
+
+	slotName := r.Spec.Bootstrap.Recovery.PromotionSlot
+	fieldPath := field.NewPath("spec", "bootstrap", "recovery", "promotionSlot")
+
+	if len(slotName) > 63 || slotNameNegativeRegex.MatchString(slotName) {
+		return field.ErrorList{
+			field.Invalid(fieldPath, slotName,
+				"The name of a replication slot can only contain lower case letters, "+
+					"numbers and the underscore character, and can't be longer than 63 characters"),
+		}
+	}
+
+	var haConfiguration *ReplicationSlotsHAConfiguration
+	if r.Spec.ReplicationSlots != nil {
+		haConfiguration = r.Spec.ReplicationSlots.HighAvailability
+	}
+	if prefix := haConfiguration.GetSlotPrefix(); strings.HasPrefix(slotName, prefix) {
+		return field.ErrorList{
+			field.Invalid(fieldPath, slotName,
+				fmt.Sprintf("The name of the replication slot can't start with %q, "+
+					"which is reserved to the high availability replication slots", prefix)),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryReplicationSettings is used to ensure that
+// the replication settings are either skipped or customized, and that
+// the name of the replication slot is valid
+func (r *Cluster) validateBootstrapRecoveryReplicationSettings() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.ReplicationSettings == nil {
+		return nil
+	}
+
+	settingsPath := field.NewPath("spec", "bootstrap", "recovery", "replicationSettings")
+	settings := r.Spec.Bootstrap.Recovery.ReplicationSettings
+	var result field.ErrorList
+
+	if settings.Skip && (settings.PrimaryConnInfo != "" || settings.PrimarySlotName != "") {
+		result = append(
+			result,
+			field.Invalid(
+				settingsPath.Child("skip"),
+				settings.Skip,
+				"The replication settings can't be customized when they are skipped"))
+	}
+
+	if strings.ContainsAny(settings.PrimaryConnInfo, "\r\n") {
+		result = append(
+			result,
+			field.Invalid(
+				settingsPath.Child("primaryConnInfo"),
+				settings.PrimaryConnInfo,
+				"The connection string can't contain line breaks"))
+	}
+
+	slotName := settings.PrimarySlotName
+	if len(slotName) > 63 || slotNameNegativeRegex.MatchString(slotName) {
+		result = append(
+			result,
+			field.Invalid(
+				settingsPath.Child("primarySlotName"),
+				slotName,
+				"The name of a replication slot can only contain lower case letters, "+
+					"numbers and the underscore character, and can't be longer than 63 characters"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryStaging validates the volume where the base
+// backup is restored before being moved to PGDATA
+func (r *Cluster) validateBootstrapRecoveryStaging() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.Staging == nil {
+		return nil
+	}
+
+	stagingPath := field.NewPath("spec", "bootstrap", "recovery", "staging")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	staging := recoverySection.Staging
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				stagingPath,
+				staging,
+				"The staging volume is supported only when recovering from an object store"))
+	}
+
+	if staging.ClaimName == "" {
+		result = append(
+			result,
+			field.Required(stagingPath.Child("claimName"), "The PVC used as staging area is required"))
+	}
+
+	if staging.RequiredSpace != nil && staging.RequiredSpace.Sign() < 0 {
+		result = append(
+			result,
+			field.Invalid(
+				stagingPath.Child("requiredSpace"),
+				staging.RequiredSpace.String(),
+				"The required space can't be negative"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryInodesCheck validates the check of the free
+// inodes of the volumes receiving the base backup
+func (r *Cluster) validateBootstrapRecoveryInodesCheck() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.InodesCheck == nil {
+		return nil
+	}
+
+	inodesCheckPath := field.NewPath("spec", "bootstrap", "recovery", "inodesCheck")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	inodesCheck := recoverySection.InodesCheck
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil {
+		result = append(
+			result,
+			field.Invalid(
+				inodesCheckPath,
+				inodesCheck,
+				"The check of the free inodes is not supported when recovering from volume snapshots"))
+	}
+
+	if inodesCheck.BytesPerFile != nil && inodesCheck.BytesPerFile.Sign() <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				inodesCheckPath.Child("bytesPerFile"),
+				inodesCheck.BytesPerFile.String(),
+				"The average size of the files must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryDownloadCache validates the volume where the
+// downloaded base backups are kept
+func (r *Cluster) validateBootstrapRecoveryDownloadCache() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.DownloadCache == nil {
+		return nil
+	}
+
+	downloadCachePath := field.NewPath("spec", "bootstrap", "recovery", "downloadCache")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	downloadCache := recoverySection.DownloadCache
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				downloadCachePath,
+				downloadCache,
+				"The download cache is supported only when recovering from an object store"))
+	}
+
+	if len(r.Spec.Tablespaces) > 0 || len(recoverySection.TablespaceRemap) > 0 {
+		result = append(
+			result,
+			field.Invalid(
+				downloadCachePath,
+				downloadCache,
+				"The download cache is not supported with tablespaces"))
+	}
+
+	if downloadCache.ClaimName == "" {
+		result = append(
+			result,
+			field.Required(downloadCachePath.Child("claimName"), "The PVC containing the cache is required"))
+	}
+
+	if downloadCache.MaxSize != nil && downloadCache.MaxSize.Sign() <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				downloadCachePath.Child("maxSize"),
+				downloadCache.MaxSize.String(),
+				"The maximum size of the cache must be positive"))
+	}
+
+	if downloadCache.MaxEntries != nil && *downloadCache.MaxEntries < 1 {
+		result = append(
+			result,
+			field.Invalid(
+				downloadCachePath.Child("maxEntries"),
+				*downloadCache.MaxEntries,
+				"The cache must be able to keep at least one base backup"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryKeepBundledWAL validates the request to keep
+// the WAL files included in the restored base backup
+func (r *Cluster) validateBootstrapRecoveryKeepBundledWAL() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || !r.Spec.Bootstrap.Recovery.KeepBundledWAL {
+		return nil
+	}
+
+	recoverySection := r.Spec.Bootstrap.Recovery
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "keepBundledWAL"),
+				recoverySection.KeepBundledWAL,
+				"Keeping the WAL files of the base backup is supported only when recovering from an object store"),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryObjectStoreTimeouts is used to ensure that the
+// timeouts of the requests to the object store are positive, and are set
+// only when the recovery reads from an object store
+func (r *Cluster) validateBootstrapRecoveryObjectStoreTimeouts() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.ObjectStoreTimeouts == nil {
+		return nil
+	}
+
+	timeoutsPath := field.NewPath("spec", "bootstrap", "recovery", "objectStoreTimeouts")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	timeouts := recoverySection.ObjectStoreTimeouts
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				timeoutsPath,
+				timeouts,
+				"The timeouts of the object store are supported only when recovering from an object store"))
+	}
+
+	if timeouts.Read != nil && timeouts.Read.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				timeoutsPath.Child("read"),
+				timeouts.Read.String(),
+				"The read timeout must be positive"))
+	}
+
+	if timeouts.WALFetch != nil && timeouts.WALFetch.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				timeoutsPath.Child("walFetch"),
+				timeouts.WALFetch.String(),
+				"The timeout of the fetch of the WAL files must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryWALFetchRetries is used to ensure that the
+// fetches of the WAL files are retried only when recovering from an
+// object store
+func (r *Cluster) validateBootstrapRecoveryWALFetchRetries() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.WALFetchRetries == 0 {
+		return nil
+	}
+
+	retriesPath := field.NewPath("spec", "bootstrap", "recovery", "walFetchRetries")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.WALFetchRetries < 0 {
+		result = append(
+			result,
+			field.Invalid(
+				retriesPath,
+				recoverySection.WALFetchRetries,
+				"The number of retries of the fetch of the WAL files can't be negative"))
+	}
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				retriesPath,
+				recoverySection.WALFetchRetries,
+				"The retries of the fetch of the WAL files are supported only when recovering from an object store"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryDataSourcePolicy is used to ensure that the
+// live primary which can be cloned is correctly defined
+func (r *Cluster) validateBootstrapRecoveryDataSourcePolicy() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.DataSourcePolicy == nil {
+		return nil
+	}
+
+	policyPath := field.NewPath("spec", "bootstrap", "recovery", "dataSourcePolicy")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	policy := recoverySection.DataSourcePolicy
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				policyPath,
+				policy,
+				"The data source policy is not supported when recovering from "+
+					"volume snapshots or from a local volume"))
+	}
+
+	if policy.GetPreference() == RecoveryDataSourcePrimary && recoverySection.RecoveryTarget != nil {
+		result = append(
+			result,
+			field.Invalid(
+				policyPath.Child("preference"),
+				policy.Preference,
+				"A recovery target can't be reached when cloning a live primary"))
+	}
+
+	server, found := r.ExternalCluster(policy.PrimarySource)
+	switch {
+	case !found:
+		result = append(
+			result,
+			field.Invalid(
+				policyPath.Child("primarySource"),
+				policy.PrimarySource,
+				fmt.Sprintf("External cluster %v not found", policy.PrimarySource)))
+	case len(server.ConnectionParameters) == 0:
+		result = append(
+			result,
+			field.Invalid(
+				policyPath.Child("primarySource"),
+				policy.PrimarySource,
+				fmt.Sprintf("External cluster %v has no connection parameters", policy.PrimarySource)))
+	}
+
+	switch policy.Preference {
+	case "", RecoveryDataSourceArchive, RecoveryDataSourcePrimary:
+	default:
+		result = append(
+			result,
+			field.NotSupported(
+				policyPath.Child("preference"),
+				policy.Preference,
+				[]string{string(RecoveryDataSourceArchive), string(RecoveryDataSourcePrimary)}))
+	}
+
+	if policy.AvailabilityTimeout != nil && policy.AvailabilityTimeout.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				policyPath.Child("availabilityTimeout"),
+				policy.AvailabilityTimeout.String(),
+				"The availability timeout must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoverySequenceAdvance is used to ensure that the
+// sequences are advanced by a positive amount, that they are referenced
+// with their schema, and that they are not advanced in a replica cluster
+func (r *Cluster) validateBootstrapRecoverySequenceAdvance() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.SequenceAdvance == nil {
+		return nil
+	}
+
+	advancePath := field.NewPath("spec", "bootstrap", "recovery", "sequenceAdvance")
+	advance := r.Spec.Bootstrap.Recovery.SequenceAdvance
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				advancePath,
+				advance,
+				"Advancing the sequences is not supported for replica clusters"))
+	}
+
+	if advance.Offset <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				advancePath.Child("offset"),
+				advance.Offset,
+				"The offset of the sequences must be positive"))
+	}
+
+	for idx, name := range advance.Databases {
+		if name == "" {
+			result = append(
+				result,
+				field.Required(advancePath.Child("databases").Index(idx), "The name of the database is required"))
+		}
+	}
+
+	lists := []struct {
+		name      string
+		sequences []string
+	}{
+		{name: "sequences", sequences: advance.Sequences},
+		{name: "audit", sequences: advance.Audit},
+	}
+	for _, list := range lists {
+		for idx, name := range list.sequences {
+			schema, sequence, found := strings.Cut(name, ".")
+			if !found || schema == "" || sequence == "" {
+				result = append(
+					result,
+					field.Invalid(
+						advancePath.Child(list.name).Index(idx),
+						name,
+						"The sequence must be in the schema.sequence format"))
+			}
+		}
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryStatStatementsReset is used to ensure that the
+// statistics of pg_stat_statements are not reset in a replica cluster, and
+// that the baseline table is referenced with its schema
+func (r *Cluster) validateBootstrapRecoveryStatStatementsReset() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.StatStatementsReset == nil {
+		return nil
+	}
+
+	resetPath := field.NewPath("spec", "bootstrap", "recovery", "statStatementsReset")
+	reset := r.Spec.Bootstrap.Recovery.StatStatementsReset
+	var result field.ErrorList
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				resetPath,
+				reset,
+				"Resetting the statistics of pg_stat_statements is not supported for replica clusters"))
+	}
+
+	if reset.BaselineTable != "" {
+		schema, table, found := strings.Cut(reset.BaselineTable, ".")
+		if !found || schema == "" || table == "" {
+			result = append(
+				result,
+				field.Invalid(
+					resetPath.Child("baselineTable"),
+					reset.BaselineTable,
+					"The baseline table must be in the schema.table format"))
+		}
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryRepairControlFile is used to ensure that the
+// repair of pg_control is requested only where the data directory is
+// restored by the recovery job
+func (r *Cluster) validateBootstrapRecoveryRepairControlFile() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		!r.Spec.Bootstrap.Recovery.RepairControlFile {
+		return nil
+	}
+
+	if r.Spec.Bootstrap.Recovery.VolumeSnapshots != nil {
+		return field.ErrorList{
+			field.Invalid(
+				field.NewPath("spec", "bootstrap", "recovery", "repairControlFile"),
+				r.Spec.Bootstrap.Recovery.RepairControlFile,
+				"The repair of pg_control is not supported for the recovery from volume snapshots"),
+		}
+	}
+
+	return nil
+}
+
+// validateBootstrapRecoveryFreeze is used to ensure that the tables
+// are frozen only where the restored instance accepts writes
+func (r *Cluster) validateBootstrapRecoveryFreeze() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Freeze == nil || !r.IsReplica() {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "freeze"),
+			r.Spec.Bootstrap.Recovery.Freeze,
+			"Freezing the restored tables is not supported for replica clusters"),
+	}
+}
+
+// validateBootstrapRecoveryPreparedTransactions is used to ensure that
+// the prepared transactions are resolved only when the restored
+// instance can be written
+func (r *Cluster) validateBootstrapRecoveryPreparedTransactions() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.PreparedTransactions == nil {
+		return nil
+	}
+
+	actionPath := field.NewPath("spec", "bootstrap", "recovery", "preparedTransactions", "action")
+	action := r.Spec.Bootstrap.Recovery.PreparedTransactions.GetAction()
+	switch action {
+	case RecoveryPreparedTransactionsWarn:
+		return nil
+	case RecoveryPreparedTransactionsRollback, RecoveryPreparedTransactionsCommit:
+	default:
+		return field.ErrorList{
+			field.NotSupported(
+				actionPath,
+				action,
+				[]string{
+					string(RecoveryPreparedTransactionsWarn),
+					string(RecoveryPreparedTransactionsRollback),
+					string(RecoveryPreparedTransactionsCommit),
+				}),
+		}
+	}
+
+	if !r.IsReplica() {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(
+			actionPath,
+			action,
+			"Resolving the prepared transactions is not supported for replica clusters"),
+	}
+}
+
+// validateBootstrapRecoveryExpectedSource is used to ensure that the
+// expected origin of the backup specifies something to be checked, with
+// a numeric system identifier and a positive timeline
+func (r *Cluster) validateBootstrapRecoveryExpectedSource() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.ExpectedSource == nil {
+		return nil
+	}
+
+	sourcePath := field.NewPath("spec", "bootstrap", "recovery", "expectedSource")
+	expected := r.Spec.Bootstrap.Recovery.ExpectedSource
+	var result field.ErrorList
+
+	if expected.SystemID == "" && expected.Timeline == nil {
+		result = append(
+			result,
+			field.Invalid(
+				sourcePath,
+				expected,
+				"At least one between the system identifier and the timeline must be specified"))
+	}
+
+	if expected.SystemID != "" {
+		if _, err := strconv.ParseUint(expected.SystemID, 10, 64); err != nil {
+			result = append(
+				result,
+				field.Invalid(
+					sourcePath.Child("systemID"),
+					expected.SystemID,
+					"The system identifier must be an unsigned 64-bit integer"))
+		}
+	}
+
+	if expected.Timeline != nil && *expected.Timeline < 1 {
+		result = append(
+			result,
+			field.Invalid(
+				sourcePath.Child("timeline"),
+				*expected.Timeline,
+				"The timeline must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryRole is used to ensure that the role of
+// the restored instance is consistent with the replica cluster
+// configuration and with the rest of the recovery options
+func (r *Cluster) validateBootstrapRecoveryRole() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Role == "" {
+		return nil
+	}
+
+	recoveryPath := field.NewPath("spec", "bootstrap", "recovery")
+	recovery := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recovery.Role == RecoveryRolePrimary {
+		if r.IsReplica() {
+			result = append(
+				result,
+				field.Invalid(
+					recoveryPath.Child("role"),
+					recovery.Role,
+					"A replica cluster can't be restored as a primary"))
+		}
+		return result
+	}
+
+	if !r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				recoveryPath.Child("role"),
+				recovery.Role,
+				"Only a replica cluster can be restored as a standby"))
+	}
+
+	if recovery.RecoveryTarget != nil {
+		result = append(
+			result,
+			field.Invalid(
+				recoveryPath.Child("recoveryTarget"),
+				recovery.RecoveryTarget,
+				fmt.Sprintf("A recovery target can't be used with the %s role", recovery.Role)))
+	}
+
+	if recovery.PauseAtTarget != nil {
+		result = append(
+			result,
+			field.Invalid(
+				recoveryPath.Child("pauseAtTarget"),
+				recovery.PauseAtTarget,
+				fmt.Sprintf("The pause at the recovery target can't be used with the %s role", recovery.Role)))
+	}
+
+	if recovery.Role == RecoveryRoleStandby && (recovery.VolumeSnapshots != nil || recovery.Local != nil) {
+		result = append(
+			result,
+			field.Invalid(
+				recoveryPath.Child("role"),
+				recovery.Role,
+				"The standby role is supported only when recovering from an object store"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryPromotionRetry is used to ensure that the
+// promotion is retried only when the restored instance is promoted,
+// with a positive number of retries and interval
+func (r *Cluster) validateBootstrapRecoveryPromotionRetry() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.PromotionRetry == nil {
+		return nil
+	}
+
+	retryPath := field.NewPath("spec", "bootstrap", "recovery", "promotionRetry")
+	promotionRetry := r.Spec.Bootstrap.Recovery.PromotionRetry
+	var result field.ErrorList
+
+	if r.GetRecoveryRole() != RecoveryRolePrimary {
+		result = append(
+			result,
+			field.Invalid(
+				retryPath,
+				promotionRetry,
+				"The promotion can be retried only when the restored instance is a primary"))
+	}
+
+	if promotionRetry.MaxRetries < 1 {
+		result = append(
+			result,
+			field.Invalid(
+				retryPath.Child("maxRetries"),
+				promotionRetry.MaxRetries,
+				"The number of retries must be positive"))
+	}
+
+	if promotionRetry.Interval != nil && promotionRetry.Interval.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				retryPath.Child("interval"),
+				promotionRetry.Interval.String(),
+				"The interval between the retries must be positive"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryProbeWALFetch is used to ensure that the
+// fetch of the WAL files is probed only when they are fetched from an
+// object store
+func (r *Cluster) validateBootstrapRecoveryProbeWALFetch() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		!r.Spec.Bootstrap.Recovery.ProbeWALFetch {
+		return nil
+	}
+
+	recoverySection := r.Spec.Bootstrap.Recovery
+	if recoverySection.VolumeSnapshots == nil && recoverySection.Local == nil {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "probeWALFetch"),
+			recoverySection.ProbeWALFetch,
+			"The fetch of the WAL files can be probed only when recovering from an object store"),
+	}
+}
+
+// validateBootstrapRecoveryCredentialsProvider is used to ensure that
+// the command minting the credentials of the object store can be
+// embedded in the restore_command
+func (r *Cluster) validateBootstrapRecoveryCredentialsProvider() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.CredentialsProvider == nil {
+		return nil
+	}
+
+	providerPath := field.NewPath("spec", "bootstrap", "recovery", "credentialsProvider")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	var result field.ErrorList
+
+	if recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				providerPath,
+				recoverySection.CredentialsProvider,
+				"The credentials provider is only supported when recovering from an object store"))
 	}
-	return result
-}
 
-// validateCerts validate all the provided certs
-func (r *Cluster) validateCerts() field.ErrorList {
-	var result field.ErrorList
-	certificates := r.Spec.Certificates
+	provider := recoverySection.CredentialsProvider
+	switch {
+	case len(provider.Command) == 0 && provider.Directory == "":
+		result = append(
+			result,
+			field.Required(
+				providerPath,
+				"Either the command minting the credentials or their directory is required"))
 
-	if certificates == nil {
-		return result
+	case len(provider.Command) > 0 && provider.Directory != "":
+		result = append(
+			result,
+			field.Invalid(
+				providerPath,
+				provider,
+				"The command minting the credentials and their directory are mutually exclusive"))
 	}
 
-	if certificates.ServerTLSSecret != "" {
-		// Currently names are not validated, maybe add this check in future
-		if len(certificates.ServerAltDNSNames) != 0 {
+	if provider.Directory != "" {
+		result = append(result, validateCredentialsDirectory(providerPath.Child("directory"), provider.Directory)...)
+		if !r.ShouldCreateProjectedVolume() {
 			result = append(
 				result,
 				field.Invalid(
-					field.NewPath("spec", "certificates", "serveraltdnsnames"),
-					fmt.Sprintf("%v", certificates.ServerAltDNSNames),
-					"Server alternative DNS names can't be defined when server TLS secret is provided"))
+					providerPath.Child("directory"),
+					provider.Directory,
+					"The directory of the credentials requires the projected volume, "+
+						"defined in .spec.projectedVolumeTemplate"))
 		}
+	}
 
-		// With ServerTLSSecret not empty you must provide the ServerCASecret
-		if certificates.ServerCASecret == "" {
+	for idx, argument := range recoverySection.CredentialsProvider.Command {
+		if strings.Contains(argument, "'") {
 			result = append(
 				result,
 				field.Invalid(
-					field.NewPath("spec", "certificates", "servercasecret"),
-					"",
-					"Server CA secret can't be empty when server TLS secret is provided"))
+					providerPath.Child("command").Index(idx),
+					argument,
+					"The credentials provider command cannot contain single quotes, "+
+						"as it is part of the restore_command"))
 		}
 	}
 
-	// If you provide the ReplicationTLSSecret we must provide the ClientCaSecret
-	if certificates.ReplicationTLSSecret != "" && certificates.ClientCASecret == "" {
-		result = append(
-			result,
-			field.Invalid(
-				field.NewPath("spec", "certificates", "clientcasecret"),
-				"",
-				"Client CA secret can't be empty when client replication secret is provided"))
-	}
-
 	return result
 }
 
-// ValidateSuperuserSecret validate super user secret value
-func (r *Cluster) validateSuperuserSecret() field.ErrorList {
+// credentialsDirectoryPattern matches the directories of the credentials
+// that can be embedded in the restore_command without quoting
+var credentialsDirectoryPattern = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// validateCredentialsDirectory is used to ensure that the directory of the
+// credentials of the object store is inside the projected volume, and
+// can be embedded in the restore_command
+func validateCredentialsDirectory(directoryPath *field.Path, directory string) field.ErrorList {
 	var result field.ErrorList
 
-	// If empty, we're ok!
-	if r.Spec.SuperuserSecret == nil {
-		return result
+	if !credentialsDirectoryPattern.MatchString(directory) {
+		result = append(
+			result,
+			field.Invalid(
+				directoryPath,
+				directory,
+				"The directory of the credentials can only contain letters, digits, "+
+					"and the '_', '.', '/' and '-' characters, as it is part of the restore_command"))
 	}
 
-	// We check that we have a valid name and not empty
-	if r.Spec.SuperuserSecret.Name == "" {
+	if path.Clean(directory) != directory ||
+		!strings.HasPrefix(directory, postgres.ProjectedVolumeDirectory+"/") {
 		result = append(
 			result,
 			field.Invalid(
-				field.NewPath("spec", "superusersecret", "name"),
-				"",
-				"Super user secret name can't be empty"))
+				directoryPath,
+				directory,
+				fmt.Sprintf("The directory of the credentials must be a clean path inside %s",
+					postgres.ProjectedVolumeDirectory)))
 	}
 
 	return result
 }
 
-// validateBootstrapMethod is used to ensure we have only one
-// bootstrap methods active
-func (r *Cluster) validateBootstrapMethod() field.ErrorList {
-	var result field.ErrorList
+// validateBootstrapRecoveryVerifyBackupManifest is used to ensure that
+// the restored data directory is verified against the backup manifest
+// only when the base backup is restored from an object store
+func (r *Cluster) validateBootstrapRecoveryVerifyBackupManifest() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		!r.Spec.Bootstrap.Recovery.VerifyBackupManifest {
+		return nil
+	}
 
-	// If it's not configured, everything is ok
-	if r.Spec.Bootstrap == nil {
-		return result
+	recoverySection := r.Spec.Bootstrap.Recovery
+	if recoverySection.VolumeSnapshots == nil && recoverySection.Local == nil {
+		return nil
 	}
 
-	bootstrapMethods := 0
-	if r.Spec.Bootstrap.InitDB != nil {
-		bootstrapMethods++
+	return field.ErrorList{
+		field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "verifyBackupManifest"),
+			recoverySection.VerifyBackupManifest,
+			"The backup manifest can be verified only when recovering from an object store"),
 	}
-	if r.Spec.Bootstrap.Recovery != nil {
-		bootstrapMethods++
+}
+
+// trackCommitTimestampMinVersion is the first PostgreSQL version
+// supporting the `track_commit_timestamp` parameter
+const trackCommitTimestampMinVersion = 90500
+
+// validateBootstrapRecoveryTrackCommitTimestamp is used to ensure that the
+// tracking of the commit timestamps is supported by the PostgreSQL version,
+// and isn't disabled in the parameters of the cluster
+func (r *Cluster) validateBootstrapRecoveryTrackCommitTimestamp() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		!r.Spec.Bootstrap.Recovery.TrackCommitTimestamp {
+		return nil
 	}
-	if r.Spec.Bootstrap.PgBaseBackup != nil {
-		bootstrapMethods++
+
+	optionPath := field.NewPath("spec", "bootstrap", "recovery", "trackCommitTimestamp")
+	var result field.ErrorList
+
+	// The errors of the image name are raised by validateImageName
+	if pgVersion, err := r.GetPostgresqlVersion(); err == nil && pgVersion < trackCommitTimestampMinVersion {
+		result = append(
+			result,
+			field.Invalid(
+				optionPath,
+				true,
+				"The tracking of the commit timestamps requires PostgreSQL 9.5 or newer"))
 	}
 
-	if bootstrapMethods > 1 {
+	if value, ok := r.Spec.PostgresConfiguration.Parameters["track_commit_timestamp"]; ok &&
+		!slices.Contains([]string{"on", "true", "yes", "1"}, strings.ToLower(value)) {
 		result = append(
 			result,
 			field.Invalid(
-				field.NewPath("spec", "bootstrap"),
-				"",
-				"Too many bootstrap types specified"))
+				optionPath,
+				true,
+				"The tracking of the commit timestamps is disabled in the parameters of the cluster"))
 	}
 
 	return result
 }
 
-// validateBootstrapPgBaseBackupSource is used to ensure that the source
-// server is correctly defined
-func (r *Cluster) validateBootstrapPgBaseBackupSource() field.ErrorList {
+// validateBootstrapRecoveryConnectionRamp is used to ensure that the
+// connection ramp of the restored cluster has a valid limit and warm-up
+// time, and isn't requested for a replica cluster, which is read-only
+func (r *Cluster) validateBootstrapRecoveryConnectionRamp() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.ConnectionRamp == nil {
+		return nil
+	}
+
+	rampPath := field.NewPath("spec", "bootstrap", "recovery", "connectionRamp")
+	ramp := r.Spec.Bootstrap.Recovery.ConnectionRamp
 	var result field.ErrorList
 
-	// This validation is only applicable for physical backup
-	// based bootstrap
-	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.PgBaseBackup == nil {
-		return result
+	if ramp.ConnectionLimit < 1 {
+		result = append(
+			result,
+			field.Invalid(
+				rampPath.Child("connectionLimit"),
+				ramp.ConnectionLimit,
+				"The connection limit must be positive"))
 	}
 
-	_, found := r.ExternalCluster(r.Spec.Bootstrap.PgBaseBackup.Source)
-	if !found {
+	if ramp.WarmupTime != nil && ramp.WarmupTime.Duration <= 0 {
 		result = append(
 			result,
 			field.Invalid(
-				field.NewPath("spec", "bootstrap", "pg_basebackup", "source"),
-				r.Spec.Bootstrap.PgBaseBackup.Source,
-				fmt.Sprintf("External cluster %v not found", r.Spec.Bootstrap.PgBaseBackup.Source)))
+				rampPath.Child("warmupTime"),
+				ramp.WarmupTime.String(),
+				"The warm-up time must be positive"))
+	}
+
+	if r.IsReplica() {
+		result = append(
+			result,
+			field.Invalid(
+				rampPath,
+				ramp,
+				"The connection ramp is not supported for replica clusters"))
 	}
 
 	return result
 }
 
-// validateBootstrapRecoverySource is used to ensure that the source
-// server is correctly defined
-func (r *Cluster) validateBootstrapRecoverySource() field.ErrorList {
+// validateBootstrapRecoverySizeCheck validates the check of the size of
+// the restored data, which is available only when recovering from an
+// object store
+func (r *Cluster) validateBootstrapRecoverySizeCheck() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.SizeCheck == nil {
+		return nil
+	}
+
+	sizeCheckPath := field.NewPath("spec", "bootstrap", "recovery", "sizeCheck")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	sizeCheck := recoverySection.SizeCheck
 	var result field.ErrorList
 
-	// This validation is only applicable for recovery based bootstrap
-	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.Source == "" {
-		return result
+	if sizeCheck.ExpectedSize.Sign() <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				sizeCheckPath.Child("expectedSize"),
+				sizeCheck.ExpectedSize.String(),
+				"The expected size must be positive"))
 	}
 
-	_, found := r.ExternalCluster(r.Spec.Bootstrap.Recovery.Source)
-	if !found {
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
 		result = append(
 			result,
 			field.Invalid(
-				field.NewPath("spec", "bootstrap", "recovery", "source"),
-				r.Spec.Bootstrap.Recovery.Source,
-				fmt.Sprintf("External cluster %v not found", r.Spec.Bootstrap.Recovery.Source)))
+				sizeCheckPath,
+				sizeCheck,
+				"The size of the restored data can be checked only when recovering from an object store"))
 	}
 
 	return result
 }
 
-// validateBootstrapRecoveryDataSource is used to ensure that the data
-// source is correctly defined
-func (r *Cluster) validateBootstrapRecoveryDataSource() field.ErrorList {
-	// This validation is only applicable for datasource-based recovery based bootstrap
-	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil || r.Spec.Bootstrap.Recovery.VolumeSnapshots == nil {
+// validateBootstrapRecoveryHeartbeat is used to ensure that the interval
+// and the stale threshold of the heartbeat of the restore are positive,
+// and that a restore can't be considered stalled between two reports
+func (r *Cluster) validateBootstrapRecoveryHeartbeat() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Heartbeat == nil {
 		return nil
 	}
 
-	recoveryPath := field.NewPath("spec", "bootstrap", "recovery")
+	heartbeatPath := field.NewPath("spec", "bootstrap", "recovery", "heartbeat")
 	recoverySection := r.Spec.Bootstrap.Recovery
-	if recoverySection.Backup != nil {
-		return field.ErrorList{
+	heartbeat := recoverySection.Heartbeat
+	var result field.ErrorList
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
 			field.Invalid(
-				recoveryPath.Child("backup"),
-				r.Spec.Bootstrap.Recovery.Backup,
-				"Recovery from dataSource is not compatible with other types of recovery"),
-		}
+				heartbeatPath,
+				heartbeat,
+				"The heartbeat of the restore is supported only when recovering from an object store"))
 	}
 
-	if recoverySection.RecoveryTarget != nil && recoverySection.RecoveryTarget.BackupID != "" {
-		return field.ErrorList{
+	if heartbeat.Interval != nil && heartbeat.Interval.Duration <= 0 {
+		result = append(
+			result,
 			field.Invalid(
-				recoveryPath.Child("recoveryTarget", "backupID"),
-				r.Spec.Bootstrap.Recovery.RecoveryTarget.BackupID,
-				"Cannot specify a backupID when recovering using a DataSource"),
-		}
+				heartbeatPath.Child("interval"),
+				heartbeat.Interval.String(),
+				"The interval of the heartbeat must be positive"))
 	}
 
-	result := validateVolumeSnapshotSource(recoverySection.VolumeSnapshots.Storage, recoveryPath.Child("storage"))
+	if heartbeat.StaleThreshold != nil && heartbeat.StaleThreshold.Duration <= 0 {
+		result = append(
+			result,
+			field.Invalid(
+				heartbeatPath.Child("staleThreshold"),
+				heartbeat.StaleThreshold.String(),
+				"The stale threshold of the heartbeat must be positive"))
+	}
 
-	if recoverySection.VolumeSnapshots.WalStorage != nil && r.Spec.WalStorage == nil {
-		walStoragePath := recoveryPath.Child("dataSource", "walStorage")
+	if len(result) == 0 && heartbeat.GetStaleThreshold() <= heartbeat.GetInterval() {
 		result = append(
 			result,
 			field.Invalid(
-				walStoragePath,
-				r.Spec.Bootstrap.Recovery.VolumeSnapshots.WalStorage,
-				"A WAL storage configuration is required when recovering using a DataSource for WALs"))
+				heartbeatPath.Child("staleThreshold"),
+				heartbeat.GetStaleThreshold().String(),
+				fmt.Sprintf("The stale threshold of the heartbeat must be longer than its interval, %s",
+					heartbeat.GetInterval())))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoveryBarmanTempDirectory is used to ensure that the
+// temporary directory of barman-cloud is an absolute path, and that it is
+// used only when the base backup is restored by barman-cloud
+func (r *Cluster) validateBootstrapRecoveryBarmanTempDirectory() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.BarmanTempDirectory == nil {
+		return nil
+	}
+
+	tempDirectoryPath := field.NewPath("spec", "bootstrap", "recovery", "barmanTempDirectory")
+	recoverySection := r.Spec.Bootstrap.Recovery
+	tempDirectory := recoverySection.BarmanTempDirectory
+	var result field.ErrorList
+
+	if !path.IsAbs(tempDirectory.Path) || path.Clean(tempDirectory.Path) == "/" {
 		result = append(
 			result,
-			validateVolumeSnapshotSource(
-				*recoverySection.VolumeSnapshots.WalStorage, walStoragePath)...)
+			field.Invalid(
+				tempDirectoryPath.Child("path"),
+				tempDirectory.Path,
+				"The temporary directory of barman must be an absolute path, different from the root directory"))
 	}
 
-	if recoverySection.VolumeSnapshots.WalStorage != nil {
+	if tempDirectory.RequiredSpace != nil && tempDirectory.RequiredSpace.Sign() <= 0 {
 		result = append(
 			result,
-			validateVolumeSnapshotSource(
-				*recoverySection.VolumeSnapshots.WalStorage,
-				recoveryPath.Child("dataSource", "walStorage"))...)
+			field.Invalid(
+				tempDirectoryPath.Child("requiredSpace"),
+				tempDirectory.RequiredSpace.String(),
+				"The required space must be positive"))
+	}
+
+	if recoverySection.VolumeSnapshots != nil || recoverySection.Local != nil {
+		result = append(
+			result,
+			field.Invalid(
+				tempDirectoryPath,
+				tempDirectory,
+				"The temporary directory of barman is supported only when recovering from an object store"))
+	}
+
+	return result
+}
+
+// validateBootstrapRecoverySubscriptions is used to ensure that the
+// inherited subscriptions are changed only when the restored instance
+// can be written
+func (r *Cluster) validateBootstrapRecoverySubscriptions() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.Subscriptions == "" {
+		return nil
+	}
+
+	subscriptionsPath := field.NewPath("spec", "bootstrap", "recovery", "subscriptions")
+	policy := r.Spec.Bootstrap.Recovery.Subscriptions
+	switch policy {
+	case RecoverySubscriptionsPolicyKeep:
+		return nil
+	case RecoverySubscriptionsPolicyDisable, RecoverySubscriptionsPolicyDrop:
+	default:
+		return field.ErrorList{
+			field.NotSupported(
+				subscriptionsPath,
+				policy,
+				[]string{
+					string(RecoverySubscriptionsPolicyKeep),
+					string(RecoverySubscriptionsPolicyDisable),
+					string(RecoverySubscriptionsPolicyDrop),
+				}),
+		}
+	}
+
+	if !r.IsReplica() {
+		return nil
+	}
+
+	return field.ErrorList{
+		field.Invalid(
+			subscriptionsPath,
+			policy,
+			"Changing the inherited subscriptions is not supported for replica clusters"),
+	}
+}
+
+// validateBootstrapRecoveryLogProbe is used to ensure that the patterns
+// of the probe of the log have a unique name and a valid expression
+func (r *Cluster) validateBootstrapRecoveryLogProbe() field.ErrorList {
+	if r.Spec.Bootstrap == nil || r.Spec.Bootstrap.Recovery == nil ||
+		r.Spec.Bootstrap.Recovery.LogProbe == nil {
+		return nil
+	}
+
+	patternsPath := field.NewPath("spec", "bootstrap", "recovery", "logProbe", "patterns")
+	var result field.ErrorList
+
+	names := stringset.New()
+	for idx, pattern := range r.Spec.Bootstrap.Recovery.LogProbe.Patterns {
+		if pattern.Name == "" {
+			result = append(
+				result,
+				field.Required(patternsPath.Index(idx).Child("name"), "The name of the pattern is required"))
+		} else if names.Has(pattern.Name) {
+			result = append(
+				result,
+				field.Duplicate(patternsPath.Index(idx).Child("name"), pattern.Name))
+		}
+		names.Put(pattern.Name)
+
+		if _, err := regexp.Compile(pattern.Expression); err != nil || pattern.Expression == "" {
+			result = append(
+				result,
+				field.Invalid(
+					patternsPath.Index(idx).Child("expression"),
+					pattern.Expression,
+					"The expression must be a non-empty regular expression in the RE2 syntax"))
+		}
 	}
 
 	return result
@@ -1084,7 +3638,7 @@ func (r *Cluster) validateConfiguration() field.ErrorList {
 		MajorVersion:           pgVersion,
 		UserSettings:           r.Spec.PostgresConfiguration.Parameters,
 		IsReplicaCluster:       r.IsReplica(),
-		IsWalArchivingDisabled: utils.IsWalArchivingDisabled(&r.ObjectMeta),
+		IsWalArchivingDisabled: r.IsWalArchivingDisabled(),
 		IsAlterSystemEnabled:   r.Spec.PostgresConfiguration.EnableAlterSystem,
 	}
 	sanitizedParameters := postgres.CreatePostgresqlConfiguration(info).GetConfigurationParameters()
@@ -1422,64 +3976,63 @@ func (r *Cluster) validateRecoveryTarget() field.ErrorList {
 		}
 	}
 
+	// validate TargetWAL
+	if recoveryTarget.TargetWAL != "" && !postgres.IsWALFile(recoveryTarget.TargetWAL) {
+		result = append(result, field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "recoveryTarget", "targetWAL"),
+			recoveryTarget.TargetWAL,
+			"Invalid TargetWAL, a WAL file name is expected"))
+	}
+
+	recoveryFromSnapshot := r.Spec.Bootstrap.Recovery.VolumeSnapshots != nil
+	recoveryFromLocal := r.Spec.Bootstrap.Recovery.Local != nil
+
+	// validate TargetBackupEnd, as the end LSN is known only for
+	// the backups restored from an object store
+	if recoveryTarget.TargetBackupEnd != nil && (recoveryFromSnapshot || recoveryFromLocal) {
+		result = append(result, field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "recoveryTarget", "targetBackupEnd"),
+			*recoveryTarget.TargetBackupEnd,
+			"TargetBackupEnd is not supported when recovering from volume snapshots or from a local backup"))
+	}
+
 	// When using a backup catalog, we can identify the backup to be restored
 	// only if the PITR is time-based. If the PITR is not time-based, the user
 	// need to specify a backup ID.
 	// If we use a dataSource, the operator will directly access the backup
 	// and a backupID is not needed.
 
-	// validate BackupID is defined when TargetName or TargetXID or TargetImmediate are set
+	// validate BackupID is defined when TargetName, TargetXID, TargetWAL,
+	// TargetImmediate or TargetBackupEnd are set
 	labelBasedPITR := recoveryTarget.TargetName != "" ||
 		recoveryTarget.TargetXID != "" ||
-		recoveryTarget.TargetImmediate != nil
-	recoveryFromSnapshot := r.Spec.Bootstrap.Recovery.VolumeSnapshots != nil
-	if labelBasedPITR && !recoveryFromSnapshot && recoveryTarget.BackupID == "" {
+		recoveryTarget.TargetWAL != "" ||
+		recoveryTarget.TargetImmediate != nil ||
+		recoveryTarget.TargetBackupEnd != nil
+	if labelBasedPITR && !recoveryFromSnapshot && !recoveryFromLocal && recoveryTarget.BackupID == "" {
 		result = append(result, field.Required(
 			field.NewPath("spec", "bootstrap", "recovery", "recoveryTarget"),
 			"BackupID is missing"))
 	}
 
-	switch recoveryTarget.TargetTLI {
-	case "", "latest":
-		// Allowed non-numeric values
-	default:
-		// Everything else must be a valid positive integer
-		if tli, err := strconv.Atoi(recoveryTarget.TargetTLI); err != nil || tli < 1 {
-			result = append(result, field.Invalid(
-				field.NewPath("spec", "bootstrap", "recovery", "recoveryTarget", "targetTLI"),
-				recoveryTarget,
-				"recovery target timeline can be set to 'latest' or a positive integer"))
-		}
+	if !recoveryTarget.hasValidTimeline() {
+		result = append(result, field.Invalid(
+			field.NewPath("spec", "bootstrap", "recovery", "recoveryTarget", "targetTLI"),
+			recoveryTarget,
+			"recovery target timeline can be set to 'latest' or a positive integer"))
 	}
 
 	return result
 }
 
 func validateTargetExclusiveness(recoveryTarget *RecoveryTarget) field.ErrorList {
-	targets := 0
-	if recoveryTarget.TargetImmediate != nil {
-		targets++
-	}
-	if recoveryTarget.TargetLSN != "" {
-		targets++
-	}
-	if recoveryTarget.TargetName != "" {
-		targets++
-	}
-	if recoveryTarget.TargetXID != "" {
-		targets++
-	}
-	if recoveryTarget.TargetTime != "" {
-		targets++
-	}
-
 	var result field.ErrorList
 
-	if targets > 1 {
+	if targets := recoveryTarget.getTargets(); len(targets) > 1 {
 		result = append(result, field.Invalid(
 			field.NewPath("spec", "bootstrap", "recovery", "recoveryTarget"),
 			recoveryTarget,
-			"Recovery target options are mutually exclusive"))
+			fmt.Sprintf("Recovery target options are mutually exclusive, found %s", strings.Join(targets, ", "))))
 	}
 	return result
 }
@@ -2612,7 +5165,8 @@ func (r *Cluster) validatePgFailoverSlots() field.ErrorList {
 }
 
 func (r *Cluster) getAdmissionWarnings() admission.Warnings {
-	return r.getMaintenanceWindowsAdmissionWarnings()
+	result := r.getMaintenanceWindowsAdmissionWarnings()
+	return append(result, r.getRecoveryArchivingAdmissionWarnings()...)
 }
 
 func (r *Cluster) getMaintenanceWindowsAdmissionWarnings() admission.Warnings {
@@ -2626,6 +5180,18 @@ func (r *Cluster) getMaintenanceWindowsAdmissionWarnings() admission.Warnings {
 	return result
 }
 
+func (r *Cluster) getRecoveryArchivingAdmissionWarnings() admission.Warnings {
+	var result admission.Warnings
+
+	if r.IsArchivingKeptDisabledAfterRecovery() {
+		result = append(
+			result,
+			"The WAL archiving stays disabled after the recovery: the cluster can't be recovered "+
+				"to a point in time, and must not be used for real data")
+	}
+	return result
+}
+
 // validate whether the hibernation configuration is valid
 func (r *Cluster) validateHibernationAnnotation() field.ErrorList {
 	value, ok := r.Annotations[utils.HibernationAnnotationName]
@@ -234,6 +234,36 @@ func (in *BackupPluginConfiguration) DeepCopy() *BackupPluginConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRestoreRecord) DeepCopyInto(out *BackupRestoreRecord) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TargetReached != nil {
+		in, out := &in.TargetReached, &out.TargetReached
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRestoreRecord.
+func (in *BackupRestoreRecord) DeepCopy() *BackupRestoreRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRestoreRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupSnapshotElementStatus) DeepCopyInto(out *BackupSnapshotElementStatus) {
 	*out = *in
@@ -359,6 +389,13 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.RestoreHistory != nil {
+		in, out := &in.RestoreHistory, &out.RestoreHistory
+		*out = make([]BackupRestoreRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
@@ -574,6 +611,11 @@ func (in *BootstrapRecovery) DeepCopyInto(out *BootstrapRecovery) {
 		*out = new(DataSource)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Local != nil {
+		in, out := &in.Local, &out.Local
+		*out = new(LocalBackupSource)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.RecoveryTarget != nil {
 		in, out := &in.RecoveryTarget, &out.RecoveryTarget
 		*out = new(RecoveryTarget)
@@ -584,6 +626,228 @@ func (in *BootstrapRecovery) DeepCopyInto(out *BootstrapRecovery) {
 		*out = new(LocalObjectReference)
 		**out = **in
 	}
+	if in.Decryption != nil {
+		in, out := &in.Decryption, &out.Decryption
+		*out = new(RecoveryDecryptionConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WALDecryption != nil {
+		in, out := &in.WALDecryption, &out.WALDecryption
+		*out = new(RecoveryDecryptionConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CredentialsProvider != nil {
+		in, out := &in.CredentialsProvider, &out.CredentialsProvider
+		*out = new(RecoveryCredentialsProvider)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SmokeTest != nil {
+		in, out := &in.SmokeTest, &out.SmokeTest
+		*out = new(RecoverySmokeTest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessCheck != nil {
+		in, out := &in.ReadinessCheck, &out.ReadinessCheck
+		*out = new(RecoveryReadinessCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CatalogSummary != nil {
+		in, out := &in.CatalogSummary, &out.CatalogSummary
+		*out = new(RecoveryCatalogSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PostRestoreMaintenance != nil {
+		in, out := &in.PostRestoreMaintenance, &out.PostRestoreMaintenance
+		*out = new(PostRestoreMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PauseAtTarget != nil {
+		in, out := &in.PauseAtTarget, &out.PauseAtTarget
+		*out = new(RecoveryPause)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoverySettings != nil {
+		in, out := &in.RecoverySettings, &out.RecoverySettings
+		*out = new(ConfigMapKeySelector)
+		**out = **in
+	}
+	if in.Workers != nil {
+		in, out := &in.Workers, &out.Workers
+		*out = new(RecoveryWorkers)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Memory != nil {
+		in, out := &in.Memory, &out.Memory
+		*out = new(RecoveryMemory)
+		**out = **in
+	}
+	if in.Checkpoint != nil {
+		in, out := &in.Checkpoint, &out.Checkpoint
+		*out = new(RecoveryCheckpoint)
+		**out = **in
+	}
+	if in.Manifest != nil {
+		in, out := &in.Manifest, &out.Manifest
+		*out = new(ConfigMapKeySelector)
+		**out = **in
+	}
+	if in.PasswordResets != nil {
+		in, out := &in.PasswordResets, &out.PasswordResets
+		*out = make([]RecoveryPasswordReset, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RetryPolicy != nil {
+		in, out := &in.RetryPolicy, &out.RetryPolicy
+		*out = new(RestoreRetryPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BackupFallback != nil {
+		in, out := &in.BackupFallback, &out.BackupFallback
+		*out = new(RecoveryBackupFallback)
+		**out = **in
+	}
+	if in.WALGapCheck != nil {
+		in, out := &in.WALGapCheck, &out.WALGapCheck
+		*out = new(RecoveryWALGapCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TargetTimeCheck != nil {
+		in, out := &in.TargetTimeCheck, &out.TargetTimeCheck
+		*out = new(RecoveryTargetTimeCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InPlace != nil {
+		in, out := &in.InPlace, &out.InPlace
+		*out = new(RecoveryInPlace)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Proxy != nil {
+		in, out := &in.Proxy, &out.Proxy
+		*out = new(RecoveryProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Extensions != nil {
+		in, out := &in.Extensions, &out.Extensions
+		*out = make([]RecoveryExtension, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedDatabases != nil {
+		in, out := &in.AllowedDatabases, &out.AllowedDatabases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TablespaceRemap != nil {
+		in, out := &in.TablespaceRemap, &out.TablespaceRemap
+		*out = make([]RecoveryTablespaceRemap, len(*in))
+		copy(*out, *in)
+	}
+	if in.PhaseTimeouts != nil {
+		in, out := &in.PhaseTimeouts, &out.PhaseTimeouts
+		*out = new(RecoveryPhaseTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplayThrottle != nil {
+		in, out := &in.ReplayThrottle, &out.ReplayThrottle
+		*out = new(RecoveryReplayThrottle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogicalExport != nil {
+		in, out := &in.LogicalExport, &out.LogicalExport
+		*out = new(RecoveryLogicalExport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicationSettings != nil {
+		in, out := &in.ReplicationSettings, &out.ReplicationSettings
+		*out = new(RecoveryReplicationSettings)
+		**out = **in
+	}
+	if in.Staging != nil {
+		in, out := &in.Staging, &out.Staging
+		*out = new(RecoveryStaging)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InodesCheck != nil {
+		in, out := &in.InodesCheck, &out.InodesCheck
+		*out = new(RecoveryInodesCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DownloadCache != nil {
+		in, out := &in.DownloadCache, &out.DownloadCache
+		*out = new(RecoveryDownloadCache)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectStoreTimeouts != nil {
+		in, out := &in.ObjectStoreTimeouts, &out.ObjectStoreTimeouts
+		*out = new(RecoveryObjectStoreTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataSourcePolicy != nil {
+		in, out := &in.DataSourcePolicy, &out.DataSourcePolicy
+		*out = new(RecoveryDataSourcePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SequenceAdvance != nil {
+		in, out := &in.SequenceAdvance, &out.SequenceAdvance
+		*out = new(RecoverySequenceAdvance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StatStatementsReset != nil {
+		in, out := &in.StatStatementsReset, &out.StatStatementsReset
+		*out = new(RecoveryStatStatementsReset)
+		**out = **in
+	}
+	if in.Freeze != nil {
+		in, out := &in.Freeze, &out.Freeze
+		*out = new(RecoveryFreeze)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreparedTransactions != nil {
+		in, out := &in.PreparedTransactions, &out.PreparedTransactions
+		*out = new(RecoveryPreparedTransactions)
+		**out = **in
+	}
+	if in.ExpectedSource != nil {
+		in, out := &in.ExpectedSource, &out.ExpectedSource
+		*out = new(RecoveryExpectedSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PromotionRetry != nil {
+		in, out := &in.PromotionRetry, &out.PromotionRetry
+		*out = new(RecoveryPromotionRetry)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionRamp != nil {
+		in, out := &in.ConnectionRamp, &out.ConnectionRamp
+		*out = new(RecoveryConnectionRamp)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DownstreamUse != nil {
+		in, out := &in.DownstreamUse, &out.DownstreamUse
+		*out = new(RecoveryDownstreamUse)
+		**out = **in
+	}
+	if in.SizeCheck != nil {
+		in, out := &in.SizeCheck, &out.SizeCheck
+		*out = new(RecoverySizeCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Heartbeat != nil {
+		in, out := &in.Heartbeat, &out.Heartbeat
+		*out = new(RecoveryHeartbeat)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BarmanTempDirectory != nil {
+		in, out := &in.BarmanTempDirectory, &out.BarmanTempDirectory
+		*out = new(RecoveryBarmanTempDirectory)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LogProbe != nil {
+		in, out := &in.LogProbe, &out.LogProbe
+		*out = new(RecoveryLogProbe)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootstrapRecovery.
@@ -611,6 +875,72 @@ func (in *CatalogImage) DeepCopy() *CatalogImage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSummary) DeepCopyInto(out *CatalogSummary) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tables != nil {
+		in, out := &in.Tables, &out.Tables
+		*out = make([]CatalogSummaryTables, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSummary.
+func (in *CatalogSummary) DeepCopy() *CatalogSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSummaryReport) DeepCopyInto(out *CatalogSummaryReport) {
+	*out = *in
+	in.Summary.DeepCopyInto(&out.Summary)
+	if in.Discrepancies != nil {
+		in, out := &in.Discrepancies, &out.Discrepancies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSummaryReport.
+func (in *CatalogSummaryReport) DeepCopy() *CatalogSummaryReport {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSummaryReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSummaryTables) DeepCopyInto(out *CatalogSummaryTables) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSummaryTables.
+func (in *CatalogSummaryTables) DeepCopy() *CatalogSummaryTables {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSummaryTables)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificatesConfiguration) DeepCopyInto(out *CertificatesConfiguration) {
 	*out = *in
@@ -1062,6 +1392,66 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		}
 	}
 	out.SwitchReplicaClusterStatus = in.SwitchReplicaClusterStatus
+	if in.ZeroedPages != nil {
+		in, out := &in.ZeroedPages, &out.ZeroedPages
+		*out = new(ZeroedPagesReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoveryTarget != nil {
+		in, out := &in.RecoveryTarget, &out.RecoveryTarget
+		*out = new(RecoveryTargetReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoveryLocale != nil {
+		in, out := &in.RecoveryLocale, &out.RecoveryLocale
+		*out = new(RecoveryLocaleReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CatalogSummary != nil {
+		in, out := &in.CatalogSummary, &out.CatalogSummary
+		*out = new(CatalogSummaryReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoredBackup != nil {
+		in, out := &in.RestoredBackup, &out.RestoredBackup
+		*out = new(RestoredBackupReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DataSource != nil {
+		in, out := &in.DataSource, &out.DataSource
+		*out = new(RecoveryDataSourceReport)
+		**out = **in
+	}
+	if in.PreparedTransactions != nil {
+		in, out := &in.PreparedTransactions, &out.PreparedTransactions
+		*out = new(PreparedTransactionsReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecoveryProgress != nil {
+		in, out := &in.RecoveryProgress, &out.RecoveryProgress
+		*out = new(RecoveryProgressReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreHeartbeat != nil {
+		in, out := &in.RestoreHeartbeat, &out.RestoreHeartbeat
+		*out = new(RestoreHeartbeat)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RestoreResult != nil {
+		in, out := &in.RestoreResult, &out.RestoreResult
+		*out = new(RestoreResult)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionRamp != nil {
+		in, out := &in.ConnectionRamp, &out.ConnectionRamp
+		*out = new(ConnectionRampReport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.WALLevel != nil {
+		in, out := &in.WALLevel, &out.WALLevel
+		*out = new(WALLevelReport)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterStatus.
@@ -1112,6 +1502,46 @@ func (in *ConfigMapResourceVersion) DeepCopy() *ConfigMapResourceVersion {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionRampDatabase) DeepCopyInto(out *ConnectionRampDatabase) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionRampDatabase.
+func (in *ConnectionRampDatabase) DeepCopy() *ConnectionRampDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionRampDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionRampReport) DeepCopyInto(out *ConnectionRampReport) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]ConnectionRampDatabase, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionRampReport.
+func (in *ConnectionRampReport) DeepCopy() *ConnectionRampReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionRampReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataBackupConfiguration) DeepCopyInto(out *DataBackupConfiguration) {
 	*out = *in
@@ -1398,7 +1828,7 @@ func (in *ImageCatalogSpec) DeepCopy() *ImageCatalogSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Import) DeepCopyInto(out *Import) {
 	*out = *in
-	out.Source = in.Source
+	in.Source.DeepCopyInto(&out.Source)
 	if in.Databases != nil {
 		in, out := &in.Databases, &out.Databases
 		*out = make([]string, len(*in))
@@ -1414,6 +1844,11 @@ func (in *Import) DeepCopyInto(out *Import) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Jobs != nil {
+		in, out := &in.Jobs, &out.Jobs
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Import.
@@ -1429,6 +1864,11 @@ func (in *Import) DeepCopy() *Import {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImportSource) DeepCopyInto(out *ImportSource) {
 	*out = *in
+	if in.Dump != nil {
+		in, out := &in.Dump, &out.Dump
+		*out = new(LogicalDumpSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImportSource.
@@ -1531,6 +1971,26 @@ func (in *LDAPConfig) DeepCopy() *LDAPConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalBackupSource) DeepCopyInto(out *LocalBackupSource) {
+	*out = *in
+	if in.Decompression != nil {
+		in, out := &in.Decompression, &out.Decompression
+		*out = new(RecoveryDecompression)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalBackupSource.
+func (in *LocalBackupSource) DeepCopy() *LocalBackupSource {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalBackupSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalObjectReference) DeepCopyInto(out *LocalObjectReference) {
 	*out = *in
@@ -1546,6 +2006,21 @@ func (in *LocalObjectReference) DeepCopy() *LocalObjectReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogicalDumpSource) DeepCopyInto(out *LogicalDumpSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicalDumpSource.
+func (in *LogicalDumpSource) DeepCopy() *LogicalDumpSource {
+	if in == nil {
+		return nil
+	}
+	out := new(LogicalDumpSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ManagedConfiguration) DeepCopyInto(out *ManagedConfiguration) {
 	*out = *in
@@ -2191,49 +2666,906 @@ func (in *PoolerStatus) DeepCopy() *PoolerStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PostgresConfiguration) DeepCopyInto(out *PostgresConfiguration) {
+func (in *PostRestoreMaintenance) DeepCopyInto(out *PostRestoreMaintenance) {
 	*out = *in
-	if in.Parameters != nil {
-		in, out := &in.Parameters, &out.Parameters
-		*out = make(map[string]string, len(*in))
+	if in.Parallelism != nil {
+		in, out := &in.Parallelism, &out.Parallelism
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReadinessGate != nil {
+		in, out := &in.ReadinessGate, &out.ReadinessGate
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostRestoreMaintenance.
+func (in *PostRestoreMaintenance) DeepCopy() *PostRestoreMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(PostRestoreMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostgresConfiguration) DeepCopyInto(out *PostgresConfiguration) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
 	}
-	if in.Synchronous != nil {
-		in, out := &in.Synchronous, &out.Synchronous
-		*out = new(SynchronousReplicaConfiguration)
-		(*in).DeepCopyInto(*out)
+	if in.Synchronous != nil {
+		in, out := &in.Synchronous, &out.Synchronous
+		*out = new(SynchronousReplicaConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PgHBA != nil {
+		in, out := &in.PgHBA, &out.PgHBA
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PgIdent != nil {
+		in, out := &in.PgIdent, &out.PgIdent
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.SyncReplicaElectionConstraint.DeepCopyInto(&out.SyncReplicaElectionConstraint)
+	if in.AdditionalLibraries != nil {
+		in, out := &in.AdditionalLibraries, &out.AdditionalLibraries
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LDAP != nil {
+		in, out := &in.LDAP, &out.LDAP
+		*out = new(LDAPConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresConfiguration.
+func (in *PostgresConfiguration) DeepCopy() *PostgresConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PostgresConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreparedTransactionReport) DeepCopyInto(out *PreparedTransactionReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreparedTransactionReport.
+func (in *PreparedTransactionReport) DeepCopy() *PreparedTransactionReport {
+	if in == nil {
+		return nil
+	}
+	out := new(PreparedTransactionReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreparedTransactionsReport) DeepCopyInto(out *PreparedTransactionsReport) {
+	*out = *in
+	if in.Transactions != nil {
+		in, out := &in.Transactions, &out.Transactions
+		*out = make([]PreparedTransactionReport, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreparedTransactionsReport.
+func (in *PreparedTransactionsReport) DeepCopy() *PreparedTransactionsReport {
+	if in == nil {
+		return nil
+	}
+	out := new(PreparedTransactionsReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryBackupFallback) DeepCopyInto(out *RecoveryBackupFallback) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryBackupFallback.
+func (in *RecoveryBackupFallback) DeepCopy() *RecoveryBackupFallback {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryBackupFallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryBarmanTempDirectory) DeepCopyInto(out *RecoveryBarmanTempDirectory) {
+	*out = *in
+	if in.RequiredSpace != nil {
+		in, out := &in.RequiredSpace, &out.RequiredSpace
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryBarmanTempDirectory.
+func (in *RecoveryBarmanTempDirectory) DeepCopy() *RecoveryBarmanTempDirectory {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryBarmanTempDirectory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryCatalogSummary) DeepCopyInto(out *RecoveryCatalogSummary) {
+	*out = *in
+	if in.Expected != nil {
+		in, out := &in.Expected, &out.Expected
+		*out = new(CatalogSummary)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryCatalogSummary.
+func (in *RecoveryCatalogSummary) DeepCopy() *RecoveryCatalogSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryCatalogSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryCheckpoint) DeepCopyInto(out *RecoveryCheckpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryCheckpoint.
+func (in *RecoveryCheckpoint) DeepCopy() *RecoveryCheckpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryCheckpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryConnectionRamp) DeepCopyInto(out *RecoveryConnectionRamp) {
+	*out = *in
+	if in.WarmupTime != nil {
+		in, out := &in.WarmupTime, &out.WarmupTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryConnectionRamp.
+func (in *RecoveryConnectionRamp) DeepCopy() *RecoveryConnectionRamp {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryConnectionRamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryCredentialsProvider) DeepCopyInto(out *RecoveryCredentialsProvider) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryCredentialsProvider.
+func (in *RecoveryCredentialsProvider) DeepCopy() *RecoveryCredentialsProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryCredentialsProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryDataSourcePolicy) DeepCopyInto(out *RecoveryDataSourcePolicy) {
+	*out = *in
+	if in.AvailabilityTimeout != nil {
+		in, out := &in.AvailabilityTimeout, &out.AvailabilityTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryDataSourcePolicy.
+func (in *RecoveryDataSourcePolicy) DeepCopy() *RecoveryDataSourcePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryDataSourcePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryDataSourceReport) DeepCopyInto(out *RecoveryDataSourceReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryDataSourceReport.
+func (in *RecoveryDataSourceReport) DeepCopy() *RecoveryDataSourceReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryDataSourceReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryDecompression) DeepCopyInto(out *RecoveryDecompression) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryDecompression.
+func (in *RecoveryDecompression) DeepCopy() *RecoveryDecompression {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryDecompression)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryDecryptionConfiguration) DeepCopyInto(out *RecoveryDecryptionConfiguration) {
+	*out = *in
+	if in.Command != nil {
+		in, out := &in.Command, &out.Command
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeySecret != nil {
+		in, out := &in.KeySecret, &out.KeySecret
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryDecryptionConfiguration.
+func (in *RecoveryDecryptionConfiguration) DeepCopy() *RecoveryDecryptionConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryDecryptionConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryDownloadCache) DeepCopyInto(out *RecoveryDownloadCache) {
+	*out = *in
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxEntries != nil {
+		in, out := &in.MaxEntries, &out.MaxEntries
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryDownloadCache.
+func (in *RecoveryDownloadCache) DeepCopy() *RecoveryDownloadCache {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryDownloadCache)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryDownstreamUse) DeepCopyInto(out *RecoveryDownstreamUse) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryDownstreamUse.
+func (in *RecoveryDownstreamUse) DeepCopy() *RecoveryDownstreamUse {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryDownstreamUse)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryExpectedSource) DeepCopyInto(out *RecoveryExpectedSource) {
+	*out = *in
+	if in.Timeline != nil {
+		in, out := &in.Timeline, &out.Timeline
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryExpectedSource.
+func (in *RecoveryExpectedSource) DeepCopy() *RecoveryExpectedSource {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryExpectedSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryExtension) DeepCopyInto(out *RecoveryExtension) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryExtension.
+func (in *RecoveryExtension) DeepCopy() *RecoveryExtension {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryExtension)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryFreeze) DeepCopyInto(out *RecoveryFreeze) {
+	*out = *in
+	if in.MinAge != nil {
+		in, out := &in.MinAge, &out.MinAge
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryFreeze.
+func (in *RecoveryFreeze) DeepCopy() *RecoveryFreeze {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryFreeze)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryHeartbeat) DeepCopyInto(out *RecoveryHeartbeat) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.StaleThreshold != nil {
+		in, out := &in.StaleThreshold, &out.StaleThreshold
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryHeartbeat.
+func (in *RecoveryHeartbeat) DeepCopy() *RecoveryHeartbeat {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryHeartbeat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryInPlace) DeepCopyInto(out *RecoveryInPlace) {
+	*out = *in
+	if in.PreserveDataDirectory != nil {
+		in, out := &in.PreserveDataDirectory, &out.PreserveDataDirectory
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryInPlace.
+func (in *RecoveryInPlace) DeepCopy() *RecoveryInPlace {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryInPlace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryInodesCheck) DeepCopyInto(out *RecoveryInodesCheck) {
+	*out = *in
+	if in.ExpectedFiles != nil {
+		in, out := &in.ExpectedFiles, &out.ExpectedFiles
+		*out = new(int64)
+		**out = **in
+	}
+	if in.BytesPerFile != nil {
+		in, out := &in.BytesPerFile, &out.BytesPerFile
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryInodesCheck.
+func (in *RecoveryInodesCheck) DeepCopy() *RecoveryInodesCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryInodesCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryLocaleReport) DeepCopyInto(out *RecoveryLocaleReport) {
+	*out = *in
+	if in.MismatchedDatabases != nil {
+		in, out := &in.MismatchedDatabases, &out.MismatchedDatabases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReindexedDatabases != nil {
+		in, out := &in.ReindexedDatabases, &out.ReindexedDatabases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryLocaleReport.
+func (in *RecoveryLocaleReport) DeepCopy() *RecoveryLocaleReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryLocaleReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryLogProbe) DeepCopyInto(out *RecoveryLogProbe) {
+	*out = *in
+	if in.Patterns != nil {
+		in, out := &in.Patterns, &out.Patterns
+		*out = make([]RecoveryLogProbePattern, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryLogProbe.
+func (in *RecoveryLogProbe) DeepCopy() *RecoveryLogProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryLogProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryLogProbePattern) DeepCopyInto(out *RecoveryLogProbePattern) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryLogProbePattern.
+func (in *RecoveryLogProbePattern) DeepCopy() *RecoveryLogProbePattern {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryLogProbePattern)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryLogicalExport) DeepCopyInto(out *RecoveryLogicalExport) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.UploadCommand != nil {
+		in, out := &in.UploadCommand, &out.UploadCommand
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryLogicalExport.
+func (in *RecoveryLogicalExport) DeepCopy() *RecoveryLogicalExport {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryLogicalExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryMemory) DeepCopyInto(out *RecoveryMemory) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryMemory.
+func (in *RecoveryMemory) DeepCopy() *RecoveryMemory {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryMemory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryObjectStoreTimeouts) DeepCopyInto(out *RecoveryObjectStoreTimeouts) {
+	*out = *in
+	if in.Read != nil {
+		in, out := &in.Read, &out.Read
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.WALFetch != nil {
+		in, out := &in.WALFetch, &out.WALFetch
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryObjectStoreTimeouts.
+func (in *RecoveryObjectStoreTimeouts) DeepCopy() *RecoveryObjectStoreTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryObjectStoreTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryPasswordReset) DeepCopyInto(out *RecoveryPasswordReset) {
+	*out = *in
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(SecretKeySelector)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryPasswordReset.
+func (in *RecoveryPasswordReset) DeepCopy() *RecoveryPasswordReset {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryPasswordReset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryPause) DeepCopyInto(out *RecoveryPause) {
+	*out = *in
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(RecoveryVerification)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryPause.
+func (in *RecoveryPause) DeepCopy() *RecoveryPause {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryPause)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryPhaseTimeouts) DeepCopyInto(out *RecoveryPhaseTimeouts) {
+	*out = *in
+	if in.Download != nil {
+		in, out := &in.Download, &out.Download
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Recovery != nil {
+		in, out := &in.Recovery, &out.Recovery
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.Configuration != nil {
+		in, out := &in.Configuration, &out.Configuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryPhaseTimeouts.
+func (in *RecoveryPhaseTimeouts) DeepCopy() *RecoveryPhaseTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryPhaseTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryPreparedTransactions) DeepCopyInto(out *RecoveryPreparedTransactions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryPreparedTransactions.
+func (in *RecoveryPreparedTransactions) DeepCopy() *RecoveryPreparedTransactions {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryPreparedTransactions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryProgressReport) DeepCopyInto(out *RecoveryProgressReport) {
+	*out = *in
+	if in.PercentReplayed != nil {
+		in, out := &in.PercentReplayed, &out.PercentReplayed
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryProgressReport.
+func (in *RecoveryProgressReport) DeepCopy() *RecoveryProgressReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryProgressReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryPromotionRetry) DeepCopyInto(out *RecoveryPromotionRetry) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryPromotionRetry.
+func (in *RecoveryPromotionRetry) DeepCopy() *RecoveryPromotionRetry {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryPromotionRetry)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryProxy) DeepCopyInto(out *RecoveryProxy) {
+	*out = *in
+	if in.NoProxy != nil {
+		in, out := &in.NoProxy, &out.NoProxy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryProxy.
+func (in *RecoveryProxy) DeepCopy() *RecoveryProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryReadinessCheck) DeepCopyInto(out *RecoveryReadinessCheck) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryReadinessCheck.
+func (in *RecoveryReadinessCheck) DeepCopy() *RecoveryReadinessCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryReadinessCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryReplayThrottle) DeepCopyInto(out *RecoveryReplayThrottle) {
+	*out = *in
+	out.MaxRate = in.MaxRate.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryReplayThrottle.
+func (in *RecoveryReplayThrottle) DeepCopy() *RecoveryReplayThrottle {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryReplayThrottle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryReplicationSettings) DeepCopyInto(out *RecoveryReplicationSettings) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryReplicationSettings.
+func (in *RecoveryReplicationSettings) DeepCopy() *RecoveryReplicationSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryReplicationSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoverySequenceAdvance) DeepCopyInto(out *RecoverySequenceAdvance) {
+	*out = *in
+	if in.Databases != nil {
+		in, out := &in.Databases, &out.Databases
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sequences != nil {
+		in, out := &in.Sequences, &out.Sequences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Audit != nil {
+		in, out := &in.Audit, &out.Audit
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoverySequenceAdvance.
+func (in *RecoverySequenceAdvance) DeepCopy() *RecoverySequenceAdvance {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoverySequenceAdvance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoverySizeCheck) DeepCopyInto(out *RecoverySizeCheck) {
+	*out = *in
+	out.ExpectedSize = in.ExpectedSize.DeepCopy()
+	if in.TolerancePercent != nil {
+		in, out := &in.TolerancePercent, &out.TolerancePercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoverySizeCheck.
+func (in *RecoverySizeCheck) DeepCopy() *RecoverySizeCheck {
+	if in == nil {
+		return nil
 	}
-	if in.PgHBA != nil {
-		in, out := &in.PgHBA, &out.PgHBA
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out := new(RecoverySizeCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoverySmokeTest) DeepCopyInto(out *RecoverySmokeTest) {
+	*out = *in
+	if in.MinValue != nil {
+		in, out := &in.MinValue, &out.MinValue
+		*out = new(int64)
+		**out = **in
 	}
-	if in.PgIdent != nil {
-		in, out := &in.PgIdent, &out.PgIdent
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoverySmokeTest.
+func (in *RecoverySmokeTest) DeepCopy() *RecoverySmokeTest {
+	if in == nil {
+		return nil
 	}
-	in.SyncReplicaElectionConstraint.DeepCopyInto(&out.SyncReplicaElectionConstraint)
-	if in.AdditionalLibraries != nil {
-		in, out := &in.AdditionalLibraries, &out.AdditionalLibraries
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out := new(RecoverySmokeTest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryStaging) DeepCopyInto(out *RecoveryStaging) {
+	*out = *in
+	if in.RequiredSpace != nil {
+		in, out := &in.RequiredSpace, &out.RequiredSpace
+		x := (*in).DeepCopy()
+		*out = &x
 	}
-	if in.LDAP != nil {
-		in, out := &in.LDAP, &out.LDAP
-		*out = new(LDAPConfig)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryStaging.
+func (in *RecoveryStaging) DeepCopy() *RecoveryStaging {
+	if in == nil {
+		return nil
 	}
+	out := new(RecoveryStaging)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostgresConfiguration.
-func (in *PostgresConfiguration) DeepCopy() *PostgresConfiguration {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryStatStatementsReset) DeepCopyInto(out *RecoveryStatStatementsReset) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryStatStatementsReset.
+func (in *RecoveryStatStatementsReset) DeepCopy() *RecoveryStatStatementsReset {
 	if in == nil {
 		return nil
 	}
-	out := new(PostgresConfiguration)
+	out := new(RecoveryStatStatementsReset)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryTablespaceRemap) DeepCopyInto(out *RecoveryTablespaceRemap) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryTablespaceRemap.
+func (in *RecoveryTablespaceRemap) DeepCopy() *RecoveryTablespaceRemap {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryTablespaceRemap)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -2246,6 +3578,11 @@ func (in *RecoveryTarget) DeepCopyInto(out *RecoveryTarget) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.TargetBackupEnd != nil {
+		in, out := &in.TargetBackupEnd, &out.TargetBackupEnd
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Exclusive != nil {
 		in, out := &in.Exclusive, &out.Exclusive
 		*out = new(bool)
@@ -2263,6 +3600,124 @@ func (in *RecoveryTarget) DeepCopy() *RecoveryTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryTargetReport) DeepCopyInto(out *RecoveryTargetReport) {
+	*out = *in
+	in.Requested.DeepCopyInto(&out.Requested)
+	if in.TimeGap != nil {
+		in, out := &in.TimeGap, &out.TimeGap
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TimeVerified != nil {
+		in, out := &in.TimeVerified, &out.TimeVerified
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryTargetReport.
+func (in *RecoveryTargetReport) DeepCopy() *RecoveryTargetReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryTargetReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryTargetTimeCheck) DeepCopyInto(out *RecoveryTargetTimeCheck) {
+	*out = *in
+	if in.MaxGap != nil {
+		in, out := &in.MaxGap, &out.MaxGap
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryTargetTimeCheck.
+func (in *RecoveryTargetTimeCheck) DeepCopy() *RecoveryTargetTimeCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryTargetTimeCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryVerification) DeepCopyInto(out *RecoveryVerification) {
+	*out = *in
+	if in.Queries != nil {
+		in, out := &in.Queries, &out.Queries
+		*out = make([]RecoverySmokeTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryVerification.
+func (in *RecoveryVerification) DeepCopy() *RecoveryVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryWALGapCheck) DeepCopyInto(out *RecoveryWALGapCheck) {
+	*out = *in
+	if in.MaxWALSize != nil {
+		in, out := &in.MaxWALSize, &out.MaxWALSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxTimeGap != nil {
+		in, out := &in.MaxTimeGap, &out.MaxTimeGap
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryWALGapCheck.
+func (in *RecoveryWALGapCheck) DeepCopy() *RecoveryWALGapCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryWALGapCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryWorkers) DeepCopyInto(out *RecoveryWorkers) {
+	*out = *in
+	if in.MaintenanceWorkers != nil {
+		in, out := &in.MaintenanceWorkers, &out.MaintenanceWorkers
+		*out = new(int32)
+		**out = **in
+	}
+	if in.IOConcurrency != nil {
+		in, out := &in.IOConcurrency, &out.IOConcurrency
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryWorkers.
+func (in *RecoveryWorkers) DeepCopy() *RecoveryWorkers {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryWorkers)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicaClusterConfiguration) DeepCopyInto(out *ReplicaClusterConfiguration) {
 	*out = *in
@@ -2333,6 +3788,102 @@ func (in *ReplicationSlotsHAConfiguration) DeepCopy() *ReplicationSlotsHAConfigu
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreHeartbeat) DeepCopyInto(out *RestoreHeartbeat) {
+	*out = *in
+	in.LastProgressTime.DeepCopyInto(&out.LastProgressTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreHeartbeat.
+func (in *RestoreHeartbeat) DeepCopy() *RestoreHeartbeat {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreHeartbeat)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreResult) DeepCopyInto(out *RestoreResult) {
+	*out = *in
+	if in.TargetReached != nil {
+		in, out := &in.TargetReached, &out.TargetReached
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TargetShortfallTime != nil {
+		in, out := &in.TargetShortfallTime, &out.TargetShortfallTime
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreResult.
+func (in *RestoreResult) DeepCopy() *RestoreResult {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreResult)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreRetryPolicy) DeepCopyInto(out *RestoreRetryPolicy) {
+	*out = *in
+	if in.MaxAttempts != nil {
+		in, out := &in.MaxAttempts, &out.MaxAttempts
+		*out = new(int32)
+		**out = **in
+	}
+	if in.InitialBackoff != nil {
+		in, out := &in.InitialBackoff, &out.InitialBackoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.MaxBackoff != nil {
+		in, out := &in.MaxBackoff, &out.MaxBackoff
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.JitterPercent != nil {
+		in, out := &in.JitterPercent, &out.JitterPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreRetryPolicy.
+func (in *RestoreRetryPolicy) DeepCopy() *RestoreRetryPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreRetryPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoredBackupReport) DeepCopyInto(out *RestoredBackupReport) {
+	*out = *in
+	if in.FailedBackupIDs != nil {
+		in, out := &in.FailedBackupIDs, &out.FailedBackupIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoredBackupReport.
+func (in *RestoredBackupReport) DeepCopy() *RestoredBackupReport {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoredBackupReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoleConfiguration) DeepCopyInto(out *RoleConfiguration) {
 	*out = *in
@@ -2898,6 +4449,21 @@ func (in *VolumeSnapshotConfiguration) DeepCopy() *VolumeSnapshotConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WALLevelReport) DeepCopyInto(out *WALLevelReport) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WALLevelReport.
+func (in *WALLevelReport) DeepCopy() *WALLevelReport {
+	if in == nil {
+		return nil
+	}
+	out := new(WALLevelReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WalBackupConfiguration) DeepCopyInto(out *WalBackupConfiguration) {
 	*out = *in
@@ -2922,3 +4488,45 @@ func (in *WalBackupConfiguration) DeepCopy() *WalBackupConfiguration {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZeroedPagesRelation) DeepCopyInto(out *ZeroedPagesRelation) {
+	*out = *in
+	if in.Blocks != nil {
+		in, out := &in.Blocks, &out.Blocks
+		*out = make([]int64, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZeroedPagesRelation.
+func (in *ZeroedPagesRelation) DeepCopy() *ZeroedPagesRelation {
+	if in == nil {
+		return nil
+	}
+	out := new(ZeroedPagesRelation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ZeroedPagesReport) DeepCopyInto(out *ZeroedPagesReport) {
+	*out = *in
+	if in.Relations != nil {
+		in, out := &in.Relations, &out.Relations
+		*out = make([]ZeroedPagesRelation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZeroedPagesReport.
+func (in *ZeroedPagesReport) DeepCopy() *ZeroedPagesReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ZeroedPagesReport)
+	in.DeepCopyInto(out)
+	return out
+}
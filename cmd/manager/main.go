@@ -20,10 +20,12 @@ The manager command is the main entrypoint of CloudNativePG operator.
 package main
 
 import (
+	"errors"
 	"os"
 
 	"github.com/spf13/cobra"
 
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/manager"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/manager/backup"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/manager/bootstrap"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/manager/controller"
@@ -64,6 +66,10 @@ func main() {
 	cmd.AddCommand(debug.NewCmd())
 
 	if err := cmd.Execute(); err != nil {
+		var exitCodeErr *manager.ExitCodeError
+		if errors.As(err, &exitCodeErr) {
+			os.Exit(exitCodeErr.ExitCode)
+		}
 		os.Exit(1)
 	}
 }
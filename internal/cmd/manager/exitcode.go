@@ -0,0 +1,38 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package manager contains the helpers shared by the subcommands of the manager
+package manager
+
+// ExitCodeError is an error making the manager exit with a specific
+// exit code, once every hook of the failed command has been run
+type ExitCodeError struct {
+	// The error raised by the command
+	Err error
+
+	// The exit code of the manager
+	ExitCode int
+}
+
+// Error implements the error interface
+func (e *ExitCodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error raised by the command
+func (e *ExitCodeError) Unwrap() error {
+	return e.Err
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/spf13/cobra"
 	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/manager"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/management/istio"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/management/linkerd"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
@@ -32,6 +33,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/webserver"
 )
 
 // NewCmd creates the "restore" subcommand
@@ -40,6 +42,9 @@ func NewCmd() *cobra.Command {
 	var namespace string
 	var pgData string
 	var pgWal string
+	var restoreConcurrencyLimit int
+	var restoreConcurrencyNamespace string
+	var restoreErr error
 
 	cmd := &cobra.Command{
 		Use:           "restore [flags]",
@@ -54,20 +59,49 @@ func NewCmd() *cobra.Command {
 			ctx := cmd.Context()
 
 			info := postgres.InitInfo{
-				ClusterName: clusterName,
-				Namespace:   namespace,
-				PgData:      pgData,
-				PgWal:       pgWal,
+				ClusterName:   clusterName,
+				Namespace:     namespace,
+				PgData:        pgData,
+				PgWal:         pgWal,
+				RestoreStatus: postgres.NewRestoreStatusTracker(),
+
+				RestoreConcurrencyLimit:     restoreConcurrencyLimit,
+				RestoreConcurrencyNamespace: restoreConcurrencyNamespace,
 			}
 
-			return restoreSubCommand(ctx, info)
+			statusCtx, stopStatusServer := context.WithCancel(ctx)
+			defer stopStatusServer()
+			go serveRestoreStatus(statusCtx, info.RestoreStatus)
+
+			// The error is returned once the sidecars have been shut
+			// down, as cobra skips PostRunE when RunE fails
+			restoreErr = restoreSubCommand(ctx, info)
+			if restoreErr == nil {
+				return nil
+			}
+
+			// The exit code of the job tells the kind of failure apart,
+			// except for the errors not falling in any category
+			outcome := postgres.GetRestoreOutcome(restoreErr)
+			if outcome.ExitCode != 1 {
+				log.Info("Exiting with the exit code of the restore outcome",
+					"reason", outcome.Reason,
+					"exitCode", outcome.ExitCode)
+				restoreErr = &manager.ExitCodeError{Err: restoreErr, ExitCode: outcome.ExitCode}
+			}
+
+			return nil
 		},
 		PostRunE: func(cmd *cobra.Command, _ []string) error {
 			if err := istio.TryInvokeQuitEndpoint(cmd.Context()); err != nil {
-				return err
+				return errors.Join(restoreErr, err)
+			}
+
+			if err := linkerd.TryInvokeShutdownEndpoint(cmd.Context()); err != nil {
+				return errors.Join(restoreErr, err)
 			}
 
-			return linkerd.TryInvokeShutdownEndpoint(cmd.Context())
+			return restoreErr
 		},
 	}
 
@@ -77,16 +111,32 @@ func NewCmd() *cobra.Command {
 		"the cluster and the Pod in k8s")
 	cmd.Flags().StringVar(&pgData, "pg-data", os.Getenv("PGDATA"), "The PGDATA to be restored")
 	cmd.Flags().StringVar(&pgWal, "pg-wal", "", "The PGWAL to be restored")
+	cmd.Flags().IntVar(&restoreConcurrencyLimit, "restore-concurrency-limit", 0, "The maximum number "+
+		"of restores running at the same time across every cluster, zero meaning unlimited")
+	cmd.Flags().StringVar(&restoreConcurrencyNamespace, "restore-concurrency-namespace", "", "The namespace "+
+		"containing the leases used to limit the concurrent restores")
 
 	return cmd
 }
 
 func restoreSubCommand(ctx context.Context, info postgres.InitInfo) error {
-	err := info.CheckTargetDataDirectory(ctx)
+	// An interrupted restore will be resumed without touching
+	// the data directory
+	resumable, err := info.IsRestoreResumable(ctx)
 	if err != nil {
 		return err
 	}
 
+	if !resumable {
+		if err := info.PrepareInPlaceRestore(ctx); err != nil {
+			return err
+		}
+
+		if err := info.CheckTargetDataDirectory(ctx); err != nil {
+			return err
+		}
+	}
+
 	err = info.Restore(ctx)
 	if err != nil {
 		log.Error(err, "Error while restoring a backup")
@@ -97,6 +147,15 @@ func restoreSubCommand(ctx context.Context, info postgres.InitInfo) error {
 	return nil
 }
 
+// serveRestoreStatus serves the status of the restore over HTTP, to
+// localhost only, until the context is cancelled. The restore doesn't
+// depend on it, and continues even if the webserver can't be started
+func serveRestoreStatus(ctx context.Context, status *postgres.RestoreStatusTracker) {
+	if err := webserver.NewRestoreWebServer(status).Start(ctx); err != nil {
+		log.Warning("The status of the restore won't be served", "error", err.Error())
+	}
+}
+
 func cleanupDataDirectoryIfNeeded(restoreError error, dataDirectory string) {
 	var barmanError *barman.CloudRestoreError
 	if !errors.As(restoreError, &barmanError) {
@@ -106,6 +106,16 @@ type Data struct {
 	// CreateAnyService is true when the user wants the operator to create
 	// the <cluster-name>-any service. Defaults to false.
 	CreateAnyService bool `json:"createAnyService" env:"CREATE_ANY_SERVICE"`
+
+	// RestoreConcurrencyLimit is the maximum number of restores from a
+	// backup running at the same time across every cluster managed by the
+	// operator. Zero, the default, means unlimited
+	RestoreConcurrencyLimit int `json:"restoreConcurrencyLimit" env:"RESTORE_CONCURRENCY_LIMIT"`
+
+	// RestoreConcurrencyNamespace is the namespace containing the leases
+	// used to limit the concurrent restores. Defaults to the namespace
+	// where the operator is installed
+	RestoreConcurrencyNamespace string `json:"restoreConcurrencyNamespace" env:"RESTORE_CONCURRENCY_NAMESPACE"`
 }
 
 // Current is the configuration used by the operator
@@ -149,6 +159,16 @@ func (config *Data) IsLabelInherited(name string) bool {
 	return evaluateGlobPatterns(config.InheritedLabels, name)
 }
 
+// GetRestoreConcurrencyNamespace gets the namespace containing the
+// leases used to limit the concurrent restores
+func (config *Data) GetRestoreConcurrencyNamespace() string {
+	if config.RestoreConcurrencyNamespace != "" {
+		return config.RestoreConcurrencyNamespace
+	}
+
+	return config.OperatorNamespace
+}
+
 // WatchedNamespaces get the list of additional watched namespaces.
 // The result is a list of namespaces specified in the WATCHED_NAMESPACE where
 // each namespace is separated by comma
@@ -103,6 +103,14 @@ var _ = Describe("Annotation and label inheritance", func() {
 		})
 	})
 
+	It("stores the restore leases in the operator namespace by default", func() {
+		config := Data{OperatorNamespace: "cnpg-system"}
+		Expect(config.GetRestoreConcurrencyNamespace()).To(Equal("cnpg-system"))
+
+		config.RestoreConcurrencyNamespace = "dr-coordination"
+		Expect(config.GetRestoreConcurrencyNamespace()).To(Equal("dr-coordination"))
+	})
+
 	When("multiple namespaces are specified", func() {
 		It("sets the watched namespaces to the correct list", func() {
 			config := Data{
@@ -102,6 +102,7 @@ var ErrNextLoop = utils.ErrNextLoop
 // Alphabetical order to not repeat or miss permissions
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;patch
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;patch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
 // +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;delete;patch;create;watch
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=podmonitors,verbs=get;create;list;watch;delete;patch
@@ -608,6 +609,9 @@ func (r *ClusterReconciler) reconcileResources(
 	// Act on Pods and PVCs only if there is nothing that is currently being created or deleted
 	if runningJobs := resources.countRunningJobs(); runningJobs > 0 {
 		contextLogger.Debug("A job is currently running. Waiting", "count", runningJobs)
+		if err := r.reconcileRestoreHeartbeat(ctx, cluster); err != nil {
+			contextLogger.Warning("Cannot report if the restore is stalled", "error", err.Error())
+		}
 		return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 	}
 
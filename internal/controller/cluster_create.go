@@ -25,6 +25,7 @@ import (
 
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/sethvargo/go-password/password"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
@@ -1142,18 +1143,31 @@ func (r *ClusterReconciler) createPrimaryInstance(
 		job = specs.CreatePrimaryJobViaInitdb(*cluster, nodeSerial)
 	}
 
+	return r.createPrimaryJob(ctx, cluster, job, nodeSerial)
+}
+
+// createPrimaryJob creates the job initializing the PVCs of the
+// primary instance, which is registered in the cluster status
+func (r *ClusterReconciler) createPrimaryJob(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	job *batchv1.Job,
+	nodeSerial int,
+) (ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
 	if err := ctrl.SetControllerReference(cluster, job, r.Scheme); err != nil {
 		contextLogger.Error(err, "Unable to set the owner reference for instance")
 		return ctrl.Result{}, err
 	}
 
 	podName := fmt.Sprintf("%v-%v", cluster.Name, nodeSerial)
-	if err = r.setPrimaryInstance(ctx, cluster, podName); err != nil {
+	if err := r.setPrimaryInstance(ctx, cluster, podName); err != nil {
 		contextLogger.Error(err, "Unable to set the primary instance name")
 		return ctrl.Result{}, err
 	}
 
-	err = r.RegisterPhase(ctx, cluster, apiv1.PhaseFirstPrimary,
+	err := r.RegisterPhase(ctx, cluster, apiv1.PhaseFirstPrimary,
 		fmt.Sprintf("Creating primary instance %v", podName))
 	if err != nil {
 		return ctrl.Result{}, err
@@ -1196,9 +1210,24 @@ func (r *ClusterReconciler) getOriginBackup(ctx context.Context, cluster *apiv1.
 
 	var backup apiv1.Backup
 	backupObjectKey := client.ObjectKey{
-		Namespace: cluster.Namespace,
+		Namespace: cluster.Spec.Bootstrap.Recovery.Backup.GetNamespace(cluster.Namespace),
 		Name:      cluster.Spec.Bootstrap.Recovery.Backup.Name,
 	}
+
+	if backupObjectKey.Namespace != cluster.Namespace {
+		allowed, err := r.canClusterReadBackup(ctx, cluster, backupObjectKey)
+		if err != nil {
+			return nil, fmt.Errorf("while checking the access to the backup object: %w", err)
+		}
+		if !allowed {
+			r.Recorder.Eventf(cluster, "Warning", "ErrorBackupForbidden",
+				"The service account %q is not allowed to get the Backup object \"%v/%v\"",
+				cluster.Name, backupObjectKey.Namespace, backupObjectKey.Name)
+
+			return nil, nil
+		}
+	}
+
 	err := r.Get(ctx, backupObjectKey, &backup)
 	if err != nil {
 		if apierrs.IsNotFound(err) {
@@ -1209,12 +1238,51 @@ func (r *ClusterReconciler) getOriginBackup(ctx context.Context, cluster *apiv1.
 			return nil, nil
 		}
 
+		if apierrs.IsForbidden(err) {
+			r.Recorder.Eventf(cluster, "Warning", "ErrorBackupForbidden",
+				"The operator is not allowed to get the Backup object \"%v/%v\"",
+				backupObjectKey.Namespace, backupObjectKey.Name)
+
+			return nil, nil
+		}
+
 		return nil, fmt.Errorf("cannot get the backup object: %w", err)
 	}
 
 	return &backup, nil
 }
 
+// canClusterReadBackup checks if the service account of the cluster, which
+// is used by the instance manager restoring the backup, is allowed to get
+// a Backup object living in a different namespace
+func (r *ClusterReconciler) canClusterReadBackup(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backupObjectKey client.ObjectKey,
+) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User: fmt.Sprintf("system:serviceaccount:%s:%s", cluster.Namespace, cluster.Name),
+			Groups: []string{
+				"system:serviceaccounts",
+				fmt.Sprintf("system:serviceaccounts:%s", cluster.Namespace),
+			},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: backupObjectKey.Namespace,
+				Verb:      "get",
+				Group:     apiv1.GroupVersion.Group,
+				Resource:  "backups",
+				Name:      backupObjectKey.Name,
+			},
+		},
+	}
+	if err := r.Create(ctx, review); err != nil {
+		return false, err
+	}
+
+	return review.Status.Allowed, nil
+}
+
 func (r *ClusterReconciler) joinReplicaInstance(
 	ctx context.Context,
 	nodeSerial int,
@@ -21,6 +21,7 @@ import (
 
 	volumesnapshot "github.com/kubernetes-csi/external-snapshotter/client/v8/apis/volumesnapshot/v1"
 	v1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1 "k8s.io/api/policy/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/utils/ptr"
 	k8client "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
@@ -473,6 +475,107 @@ var _ = Describe("check if bootstrap recovery can proceed", func() {
 	)
 })
 
+var _ = Describe("getting the origin backup from another namespace", func() {
+	const (
+		clusterNamespace = "dev"
+		backupNamespace  = "prod"
+	)
+
+	var backup *apiv1.Backup
+
+	BeforeEach(func() {
+		backup = &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: backupNamespace},
+		}
+	})
+
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: clusterNamespace},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Backup: &apiv1.BackupSource{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "nightly"},
+							Namespace:            backupNamespace,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// newReconciler builds a reconciler whose access reviews of the
+	// cluster service account have the passed outcome
+	newReconciler := func(allowed bool, reviews *[]authorizationv1.SubjectAccessReview) *ClusterReconciler {
+		k8sClient := fake.NewClientBuilder().
+			WithScheme(schemeBuilder.BuildWithAllKnownScheme()).
+			WithObjects(backup).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Create: func(
+					ctx context.Context,
+					client k8client.WithWatch,
+					obj k8client.Object,
+					opts ...k8client.CreateOption,
+				) error {
+					review, ok := obj.(*authorizationv1.SubjectAccessReview)
+					if !ok {
+						return client.Create(ctx, obj, opts...)
+					}
+					*reviews = append(*reviews, *review)
+					review.Status.Allowed = allowed
+					return nil
+				},
+			}).
+			Build()
+
+		return &ClusterReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+	}
+
+	It("reads the backup when the cluster service account is allowed to", func(ctx SpecContext) {
+		var reviews []authorizationv1.SubjectAccessReview
+		reconciler := newReconciler(true, &reviews)
+
+		result, err := reconciler.getOriginBackup(ctx, newCluster())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).ToNot(BeNil())
+		Expect(result.Namespace).To(Equal(backupNamespace))
+
+		Expect(reviews).To(HaveLen(1))
+		Expect(reviews[0].Spec.User).To(Equal("system:serviceaccount:dev:clone"))
+		Expect(reviews[0].Spec.ResourceAttributes.Namespace).To(Equal(backupNamespace))
+		Expect(reviews[0].Spec.ResourceAttributes.Resource).To(Equal("backups"))
+		Expect(reviews[0].Spec.ResourceAttributes.Name).To(Equal("nightly"))
+	})
+
+	It("doesn't use the backup when the cluster service account can't read it", func(ctx SpecContext) {
+		var reviews []authorizationv1.SubjectAccessReview
+		reconciler := newReconciler(false, &reviews)
+
+		result, err := reconciler.getOriginBackup(ctx, newCluster())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(BeNil())
+		Expect(reconciler.Recorder.(*record.FakeRecorder).Events).To(Receive(ContainSubstring("ErrorBackupForbidden")))
+	})
+
+	It("doesn't review the access to a backup in the namespace of the cluster", func(ctx SpecContext) {
+		var reviews []authorizationv1.SubjectAccessReview
+		backup.Namespace = clusterNamespace
+		reconciler := newReconciler(false, &reviews)
+
+		cluster := newCluster()
+		cluster.Spec.Bootstrap.Recovery.Backup.Namespace = ""
+		result, err := reconciler.getOriginBackup(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).ToNot(BeNil())
+		Expect(reviews).To(BeEmpty())
+	})
+})
+
 var _ = Describe("check if bootstrap recovery can proceed from volume snapshot", func() {
 	var env *testingEnvironment
 	var namespace, clusterName string
@@ -18,14 +18,19 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/conditions"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/reconciler/persistentvolumeclaim"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
@@ -42,6 +47,9 @@ func (r *ClusterReconciler) reconcileRestoredCluster(
 
 	// No need to check this on a cluster which has been already deployed
 	if cluster.Status.LatestGeneratedNode != 0 {
+		if cluster.IsInPlaceRestoreEnabled() && cluster.Status.ReadyInstances == 0 {
+			return nil, deleteInPlaceRestoreReplicaPVCs(ctx, r.Client, cluster)
+		}
 		return nil, nil
 	}
 
@@ -64,6 +72,10 @@ func (r *ClusterReconciler) reconcileRestoredCluster(
 		return nil, fmt.Errorf("encountered an error while deleting an orphan pod: %w", err)
 	}
 
+	if cluster.IsInPlaceRestoreEnabled() {
+		return r.reconcileInPlaceRestore(ctx, cluster, pvcs)
+	}
+
 	highestSerial, primarySerial, err := getNodeSerialsFromPVCs(pvcs)
 	if err != nil {
 		return nil, err
@@ -85,7 +97,184 @@ func (r *ClusterReconciler) reconcileRestoredCluster(
 	}
 
 	contextLogger.Debug("restored the cluster status, proceeding to restore the orphan PVCS")
-	return nil, restoreOrphanPVCs(ctx, r.Client, cluster, pvcs)
+	return nil, restoreOrphanPVCs(ctx, r.Client, cluster, pvcs, persistentvolumeclaim.StatusReady)
+}
+
+// reconcileInPlaceRestore restores the backup over the data directory
+// contained in the orphan PVCs of the primary instance, once every orphan
+// Pod has been deleted. The PVCs of the other instances are deleted, as
+// they can't follow the restored primary and will be cloned again from it.
+// The PVCs of the primary are adopted only after the restore job has been
+// created, so that an error doesn't leave them without a job initializing
+// them, and the PVCs of the other instances are deleted only once the
+// status of the cluster records the restore
+func (r *ClusterReconciler) reconcileInPlaceRestore(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	pvcs []corev1.PersistentVolumeClaim,
+) (*ctrl.Result, error) {
+	contextLogger := log.FromContext(ctx)
+
+	orphanPodsExist, err := hasOrphanPods(ctx, r.Client, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if orphanPodsExist {
+		contextLogger.Info("Waiting for the orphan pods to be deleted before restoring in place")
+		return &ctrl.Result{RequeueAfter: time.Second}, nil
+	}
+
+	backup, err := r.getOriginBackup(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.checkReadyForRecovery(ctx, backup, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if !res.IsZero() {
+		return &res, nil
+	}
+
+	highestSerial, primarySerial, err := getNodeSerialsFromPVCs(pvcs)
+	if err != nil {
+		return nil, err
+	}
+	if primarySerial == 0 {
+		contextLogger.Info("no primary serial found, assigning the highest serial as the primary")
+		primarySerial = highestSerial
+	}
+
+	primaryPVCs := make([]corev1.PersistentVolumeClaim, 0, len(pvcs))
+	primaryPVCNames := make([]string, 0, len(pvcs))
+	replicaPVCs := make([]corev1.PersistentVolumeClaim, 0, len(pvcs))
+	for i := range pvcs {
+		serial, err := specs.GetNodeSerial(pvcs[i].ObjectMeta)
+		if err != nil {
+			return nil, err
+		}
+		if serial == primarySerial {
+			primaryPVCs = append(primaryPVCs, pvcs[i])
+			primaryPVCNames = append(primaryPVCNames, pvcs[i].Name)
+			continue
+		}
+		replicaPVCs = append(replicaPVCs, pvcs[i])
+	}
+
+	if err := ensureClusterIsNotFenced(ctx, r.Client, cluster); err != nil {
+		return nil, err
+	}
+
+	contextLogger.Warning("Restoring the backup in place, over the data directory of the primary instance",
+		"serial", primarySerial,
+		"pvcNames", primaryPVCNames)
+	r.Recorder.Event(cluster, "Normal", "CreatingInstance", "Primary instance (restore in place)")
+	job := specs.CreatePrimaryJobViaRecovery(*cluster, primarySerial, backup)
+	if _, err := r.createPrimaryJob(ctx, cluster, job, primarySerial); err != nil && !errors.Is(err, ErrNextLoop) {
+		return nil, err
+	}
+
+	if err := restoreOrphanPVCs(
+		ctx, r.Client, cluster, primaryPVCs, persistentvolumeclaim.StatusInitializing); err != nil {
+		return nil, err
+	}
+
+	if err := restoreClusterStatus(ctx, r.Client, cluster, highestSerial, primarySerial); err != nil {
+		return nil, err
+	}
+
+	if err := deleteReplicaPVCs(ctx, r.Client, replicaPVCs); err != nil {
+		return nil, err
+	}
+
+	return &ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// deleteInPlaceRestoreReplicaPVCs deletes the orphan PVCs left after the
+// status of the cluster recorded an in-place restore. The PVCs of the
+// primary have already been adopted, so these belong to the other
+// instances, whose deletion failed in a previous reconciliation loop
+func deleteInPlaceRestoreReplicaPVCs(ctx context.Context, c client.Client, cluster *apiv1.Cluster) error {
+	pvcs, err := getOrphanPVCs(ctx, c, cluster)
+	if err != nil {
+		return err
+	}
+
+	return deleteReplicaPVCs(ctx, c, pvcs)
+}
+
+// deleteReplicaPVCs deletes the PVCs of the instances which can't follow
+// the primary restored in place
+func deleteReplicaPVCs(ctx context.Context, c client.Client, pvcs []corev1.PersistentVolumeClaim) error {
+	contextLogger := log.FromContext(ctx)
+
+	for i := range pvcs {
+		contextLogger.Warning("Restoring in place, deleting the PVC of a replica", "pvcName", pvcs[i].Name)
+		if err := c.Delete(ctx, &pvcs[i]); err != nil && !apierrs.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileRestoreHeartbeat reports, with the RestoreStalled condition,
+// if the running restore made no progress for longer than the stale
+// threshold of its heartbeat, so that a stuck restore job can be detected
+// and removed without waiting for its deadline. The condition is patched
+// only when it changes, and the restore is flagged with a warning event
+// once it gets stalled
+func (r *ClusterReconciler) reconcileRestoreHeartbeat(ctx context.Context, cluster *apiv1.Cluster) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Heartbeat == nil {
+		return nil
+	}
+
+	heartbeat := cluster.Status.RestoreHeartbeat
+	if heartbeat == nil || heartbeat.Phase == apiv1.RestoreStateDone {
+		return nil
+	}
+
+	condition := getRestoreStalledCondition(
+		heartbeat, time.Now(), cluster.Spec.Bootstrap.Recovery.Heartbeat.GetStaleThreshold())
+	if meta.IsStatusConditionPresentAndEqual(cluster.Status.Conditions, condition.Type, condition.Status) {
+		return nil
+	}
+
+	if condition.Status == metav1.ConditionTrue {
+		log.FromContext(ctx).Warning("The restore is stalled",
+			"phase", heartbeat.Phase,
+			"lastProgressTime", heartbeat.LastProgressTime)
+		r.Recorder.Event(cluster, "Warning", string(apiv1.ConditionRestoreStalled), condition.Message)
+	}
+
+	return conditions.Patch(ctx, r.Client, cluster, condition)
+}
+
+// getRestoreStalledCondition builds the condition reporting if the
+// restore made no progress for longer than the stale threshold
+func getRestoreStalledCondition(
+	heartbeat *apiv1.RestoreHeartbeat,
+	now time.Time,
+	staleThreshold time.Duration,
+) *metav1.Condition {
+	if heartbeat.IsStale(now, staleThreshold) {
+		return &metav1.Condition{
+			Type:   string(apiv1.ConditionRestoreStalled),
+			Status: metav1.ConditionTrue,
+			Reason: string(apiv1.ConditionReasonRestoreHeartbeatStale),
+			Message: fmt.Sprintf("The restore made no progress in the %s state since %s, "+
+				"longer than the stale threshold of %s",
+				heartbeat.Phase, heartbeat.LastProgressTime.UTC().Format(time.RFC3339), staleThreshold),
+		}
+	}
+
+	return &metav1.Condition{
+		Type:    string(apiv1.ConditionRestoreStalled),
+		Status:  metav1.ConditionFalse,
+		Reason:  string(apiv1.ConditionReasonRestoreProgressing),
+		Message: "The restore is making progress",
+	}
 }
 
 // ensureClusterRestoreCanStart is a function where the plugins can inject their custom logic to tell the
@@ -160,6 +349,28 @@ func getOrphanPVCs(
 	return orphanPVCs, nil
 }
 
+// hasOrphanPods checks if any Pod belonging to the cluster, but not
+// owned by it, still exists
+func hasOrphanPods(ctx context.Context, c client.Client, cluster *apiv1.Cluster) (bool, error) {
+	var podList corev1.PodList
+	if err := c.List(
+		ctx,
+		&podList,
+		client.InNamespace(cluster.Namespace),
+		client.MatchingLabels{utils.ClusterLabelName: cluster.Name},
+	); err != nil {
+		return false, err
+	}
+
+	for idx := range podList.Items {
+		if len(podList.Items[idx].OwnerReferences) == 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func ensureOrphanPodsAreDeleted(ctx context.Context, c client.Client, cluster *apiv1.Cluster) error {
 	contextLogger := log.FromContext(ctx).WithValues("orphan_pod_cleaner")
 
@@ -227,12 +438,13 @@ func getNodeSerialsFromPVCs(
 	return highestSerial, primarySerial, nil
 }
 
-// restoreOrphanPVCs sets the owner metadata and re-actives the orphan pvcs
+// restoreOrphanPVCs sets the owner metadata and the passed status to the orphan pvcs
 func restoreOrphanPVCs(
 	ctx context.Context,
 	c client.Client,
 	cluster *apiv1.Cluster,
 	pvcs []corev1.PersistentVolumeClaim,
+	status persistentvolumeclaim.PVCStatus,
 ) error {
 	for i := range pvcs {
 		pvc := &pvcs[i]
@@ -242,7 +454,7 @@ func restoreOrphanPVCs(
 
 		pvcOrig := pvc.DeepCopy()
 		cluster.SetInheritedDataAndOwnership(&pvc.ObjectMeta)
-		pvc.Annotations[utils.PVCStatusAnnotationName] = persistentvolumeclaim.StatusReady
+		pvc.Annotations[utils.PVCStatusAnnotationName] = status
 		// we clean hibernation metadata if it exists
 		delete(pvc.Annotations, utils.HibernateClusterManifestAnnotationName)
 		delete(pvc.Annotations, utils.HibernatePgControlDataAnnotationName)
@@ -18,11 +18,17 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	k8client "sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	k8scheme "github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
@@ -290,7 +296,7 @@ var _ = Describe("getOrphanPVCs", func() {
 	})
 
 	It("should correctly restore the orphan pvcs", func() {
-		err := restoreOrphanPVCs(ctx, mockCli, cluster, goodPvcs)
+		err := restoreOrphanPVCs(ctx, mockCli, cluster, goodPvcs, persistentvolumeclaim.StatusReady)
 		Expect(err).ToNot(HaveOccurred())
 
 		for _, pvc := range goodPvcs {
@@ -299,3 +305,245 @@ var _ = Describe("getOrphanPVCs", func() {
 		}
 	})
 })
+
+var _ = Describe("reconcileInPlaceRestore", func() {
+	newPVC := func(serial, role string) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-" + serial,
+				Namespace: "default",
+				Annotations: map[string]string{
+					utils.ClusterSerialAnnotationName: serial,
+					utils.PVCStatusAnnotationName:     persistentvolumeclaim.StatusReady,
+				},
+				Labels: map[string]string{
+					utils.ClusterLabelName:             "test",
+					utils.ClusterInstanceRoleLabelName: role,
+				},
+			},
+		}
+	}
+
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       apiv1.ClusterKind,
+				APIVersion: apiv1.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:  "origin",
+						InPlace: &apiv1.RecoveryInPlace{Enabled: true},
+					},
+				},
+			},
+		}
+	}
+
+	It("restores the backup over the PVCs of the primary and deletes the other ones", func(ctx SpecContext) {
+		cluster := newCluster()
+		primaryPVC := newPVC("2", specs.ClusterRoleLabelPrimary)
+		replicaPVC := newPVC("3", specs.ClusterRoleLabelReplica)
+		mockCli := fake.NewClientBuilder().
+			WithScheme(k8scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster, primaryPVC, replicaPVC).
+			WithStatusSubresource(cluster).
+			Build()
+		r := &ClusterReconciler{
+			Client:   mockCli,
+			Scheme:   mockCli.Scheme(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+
+		res, err := r.reconcileInPlaceRestore(ctx, cluster,
+			[]corev1.PersistentVolumeClaim{*primaryPVC, *replicaPVC})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).ToNot(BeNil())
+
+		var pvc corev1.PersistentVolumeClaim
+		err = mockCli.Get(ctx, k8client.ObjectKeyFromObject(replicaPVC), &pvc)
+		Expect(apierrs.IsNotFound(err)).To(BeTrue())
+
+		Expect(mockCli.Get(ctx, k8client.ObjectKeyFromObject(primaryPVC), &pvc)).To(Succeed())
+		Expect(pvc.OwnerReferences).ToNot(BeEmpty())
+		Expect(pvc.Annotations[utils.PVCStatusAnnotationName]).To(Equal(persistentvolumeclaim.StatusInitializing))
+
+		var jobs batchv1.JobList
+		Expect(mockCli.List(ctx, &jobs, k8client.InNamespace("default"))).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+		Expect(jobs.Items[0].Name).To(Equal("test-2-full-recovery"))
+
+		var updatedCluster apiv1.Cluster
+		Expect(mockCli.Get(ctx, k8client.ObjectKeyFromObject(cluster), &updatedCluster)).To(Succeed())
+		Expect(updatedCluster.Status.LatestGeneratedNode).To(Equal(3))
+		Expect(updatedCluster.Status.TargetPrimary).To(Equal("test-2"))
+	})
+
+	It("keeps the PVCs of the other instances when the restore can't be recorded", func(ctx SpecContext) {
+		cluster := newCluster()
+		primaryPVC := newPVC("2", specs.ClusterRoleLabelPrimary)
+		replicaPVC := newPVC("3", specs.ClusterRoleLabelReplica)
+		mockCli := fake.NewClientBuilder().
+			WithScheme(k8scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster, primaryPVC, replicaPVC).
+			WithStatusSubresource(cluster).
+			WithInterceptorFuncs(interceptor.Funcs{
+				SubResourcePatch: func(
+					context.Context,
+					k8client.Client,
+					string,
+					k8client.Object,
+					k8client.Patch,
+					...k8client.SubResourcePatchOption,
+				) error {
+					return errors.New("status not patched")
+				},
+			}).
+			Build()
+		r := &ClusterReconciler{
+			Client:   mockCli,
+			Scheme:   mockCli.Scheme(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+
+		_, err := r.reconcileInPlaceRestore(ctx, cluster,
+			[]corev1.PersistentVolumeClaim{*primaryPVC, *replicaPVC})
+		Expect(err).To(HaveOccurred())
+
+		var pvc corev1.PersistentVolumeClaim
+		Expect(mockCli.Get(ctx, k8client.ObjectKeyFromObject(replicaPVC), &pvc)).To(Succeed())
+	})
+
+	It("deletes the PVCs of the other instances left by a previous loop", func(ctx SpecContext) {
+		cluster := newCluster()
+		cluster.Status.LatestGeneratedNode = 3
+		cluster.Status.TargetPrimary = "test-2"
+		primaryPVC := newPVC("2", specs.ClusterRoleLabelPrimary)
+		primaryPVC.OwnerReferences = []metav1.OwnerReference{
+			{APIVersion: apiv1.GroupVersion.String(), Kind: apiv1.ClusterKind, Name: "test"},
+		}
+		replicaPVC := newPVC("3", specs.ClusterRoleLabelReplica)
+		mockCli := fake.NewClientBuilder().
+			WithScheme(k8scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster, primaryPVC, replicaPVC).
+			Build()
+		r := &ClusterReconciler{Client: mockCli, Scheme: mockCli.Scheme()}
+
+		res, err := r.reconcileRestoredCluster(ctx, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res).To(BeNil())
+
+		var pvc corev1.PersistentVolumeClaim
+		err = mockCli.Get(ctx, k8client.ObjectKeyFromObject(replicaPVC), &pvc)
+		Expect(apierrs.IsNotFound(err)).To(BeTrue())
+		Expect(mockCli.Get(ctx, k8client.ObjectKeyFromObject(primaryPVC), &pvc)).To(Succeed())
+	})
+
+	It("waits for the orphan pods to be deleted", func(ctx SpecContext) {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-2",
+				Namespace: "default",
+				Labels:    map[string]string{utils.ClusterLabelName: "test"},
+			},
+		}
+		mockCli := fake.NewClientBuilder().
+			WithScheme(k8scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster, pod).
+			Build()
+		r := &ClusterReconciler{Client: mockCli, Scheme: mockCli.Scheme()}
+
+		res, err := r.reconcileInPlaceRestore(ctx, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(res.RequeueAfter).To(Equal(time.Second))
+	})
+})
+
+var _ = Describe("reconcileRestoreHeartbeat", func() {
+	var (
+		cluster *apiv1.Cluster
+		r       *ClusterReconciler
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source: "origin",
+						Heartbeat: &apiv1.RecoveryHeartbeat{
+							StaleThreshold: &metav1.Duration{Duration: 10 * time.Minute},
+						},
+					},
+				},
+			},
+			Status: apiv1.ClusterStatus{
+				RestoreHeartbeat: &apiv1.RestoreHeartbeat{
+					Phase:            apiv1.RestoreStateRestoreData,
+					LastProgressTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+				},
+			},
+		}
+		mockCli := fake.NewClientBuilder().
+			WithScheme(k8scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		r = &ClusterReconciler{
+			Client:   mockCli,
+			Scheme:   mockCli.Scheme(),
+			Recorder: record.NewFakeRecorder(10),
+		}
+	})
+
+	getCondition := func(ctx context.Context) *metav1.Condition {
+		var updatedCluster apiv1.Cluster
+		Expect(r.Client.Get(ctx, k8client.ObjectKeyFromObject(cluster), &updatedCluster)).To(Succeed())
+		for idx := range updatedCluster.Status.Conditions {
+			if updatedCluster.Status.Conditions[idx].Type == string(apiv1.ConditionRestoreStalled) {
+				return &updatedCluster.Status.Conditions[idx]
+			}
+		}
+		return nil
+	}
+
+	It("flags a restore whose heartbeat is stale", func(ctx SpecContext) {
+		Expect(r.reconcileRestoreHeartbeat(ctx, cluster)).To(Succeed())
+
+		condition := getCondition(ctx)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonRestoreHeartbeatStale)))
+		Expect(r.Recorder.(*record.FakeRecorder).Events).To(HaveLen(1))
+
+		// A stalled restore is flagged only once
+		Expect(r.reconcileRestoreHeartbeat(ctx, cluster)).To(Succeed())
+		Expect(r.Recorder.(*record.FakeRecorder).Events).To(HaveLen(1))
+	})
+
+	It("reports a restore making progress", func(ctx SpecContext) {
+		cluster.Status.RestoreHeartbeat.LastProgressTime = metav1.Now()
+		Expect(r.reconcileRestoreHeartbeat(ctx, cluster)).To(Succeed())
+
+		condition := getCondition(ctx)
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonRestoreProgressing)))
+		Expect(r.Recorder.(*record.FakeRecorder).Events).To(BeEmpty())
+	})
+
+	It("ignores a completed restore or a restore without a heartbeat", func(ctx SpecContext) {
+		cluster.Status.RestoreHeartbeat.Phase = apiv1.RestoreStateDone
+		Expect(r.reconcileRestoreHeartbeat(ctx, cluster)).To(Succeed())
+		Expect(getCondition(ctx)).To(BeNil())
+
+		cluster.Status.RestoreHeartbeat.Phase = apiv1.RestoreStateWaitRecovery
+		cluster.Spec.Bootstrap.Recovery.Heartbeat = nil
+		Expect(r.reconcileRestoreHeartbeat(ctx, cluster)).To(Succeed())
+		Expect(getCondition(ctx)).To(BeNil())
+	})
+})
@@ -21,6 +21,8 @@ import (
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
 
@@ -133,6 +135,37 @@ var _ = Describe("cluster_status unit tests", func() {
 		})
 	})
 
+	It("keeps the cluster not ready until the post-restore maintenance is completed", func() {
+		ctx := context.Background()
+		namespace := newFakeNamespace(env.client)
+		cluster := newFakeCNPGCluster(env.client, namespace, func(cluster *v1.Cluster) {
+			cluster.Spec.Bootstrap = &v1.BootstrapConfiguration{
+				Recovery: &v1.BootstrapRecovery{
+					Source:                 "origin",
+					PostRestoreMaintenance: &v1.PostRestoreMaintenance{Analyze: true},
+				},
+			}
+		})
+
+		By("registering the healthy phase while the maintenance is running", func() {
+			Expect(env.clusterReconciler.RegisterPhase(ctx, cluster, v1.PhaseHealthy, "")).To(Succeed())
+			condition := meta.FindStatusCondition(cluster.Status.Conditions, string(v1.ConditionClusterReady))
+			Expect(condition).ToNot(BeNil())
+			Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+			Expect(condition.Reason).To(Equal(string(v1.ClusterPostRestoreMaintenancePending)))
+		})
+
+		By("registering the healthy phase once the maintenance is completed", func() {
+			meta.SetStatusCondition(&cluster.Status.Conditions, metav1.Condition{
+				Type:   string(v1.ConditionPostRestoreMaintenance),
+				Status: metav1.ConditionTrue,
+				Reason: string(v1.ConditionReasonPostRestoreMaintenanceCompleted),
+			})
+			Expect(env.clusterReconciler.RegisterPhase(ctx, cluster, v1.PhaseHealthy, "")).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(cluster.Status.Conditions, string(v1.ConditionClusterReady))).To(BeTrue())
+		})
+	})
+
 	It("makes sure that getManagedResources works correctly", func() {
 		ctx := context.Background()
 		crReconciler := &ClusterReconciler{
@@ -269,6 +269,10 @@ func (r *InstanceReconciler) Reconcile(
 		return reconcile.Result{}, fmt.Errorf("cannot reconcile database configurations: %w", err)
 	}
 
+	r.reconcilePostRestoreMaintenance(ctx, cluster)
+	r.reconcileArchivingKeptDisabled(ctx, cluster)
+	connectionRampRequeue := r.reconcileConnectionRamp(ctx, cluster)
+
 	// Reconcile postgresql.auto.conf file permissions (< PG 17)
 	// IMPORTANT: this needs a database connection to determine
 	// the PostgreSQL major version
@@ -293,6 +297,12 @@ func (r *InstanceReconciler) Reconcile(
 		return reconcile.Result{RequeueAfter: 30 * time.Second}, nil
 	}
 
+	// The connection limits lowered for the warm-up of a restored
+	// cluster need to be raised even if the cluster doesn't change
+	if connectionRampRequeue > 0 {
+		return reconcile.Result{RequeueAfter: connectionRampRequeue}, nil
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -0,0 +1,400 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/conditions"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+const (
+	// postRestorePrewarmExtension is the extension used to load
+	// the relations into the shared buffers
+	postRestorePrewarmExtension = "CREATE EXTENSION IF NOT EXISTS pg_prewarm"
+
+	// postRestorePrewarmQuery loads every permanent table, materialized
+	// view and index of the current database into the shared buffers
+	postRestorePrewarmQuery = "SELECT count(pg_catalog.pg_prewarm(c.oid)) " +
+		"FROM pg_catalog.pg_class c " +
+		"WHERE c.relkind IN ('r', 'm', 'i') AND c.relpersistence = 'p'"
+
+	// connectionRampCheckInterval is the time between two checks of the
+	// post-restore maintenance while the connection ramp waits for it
+	connectionRampCheckInterval = 30 * time.Second
+
+	// invalidCatalogNameErrorCode is the SQLSTATE code raised when
+	// altering a database that doesn't exist
+	invalidCatalogNameErrorCode = "3D000"
+)
+
+// reconcilePostRestoreMaintenance starts, in the background, the maintenance
+// operations requested after a recovery. This is done by the primary
+// instance only, and only once: a maintenance interrupted by a restart of
+// the instance manager is started again
+func (r *InstanceReconciler) reconcilePostRestoreMaintenance(ctx context.Context, cluster *apiv1.Cluster) {
+	if cluster.Status.CurrentPrimary != r.instance.PodName {
+		return
+	}
+
+	maintenance := cluster.GetPostRestoreMaintenance()
+	if !maintenance.IsEnabled() {
+		return
+	}
+
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, string(apiv1.ConditionPostRestoreMaintenance))
+	if condition != nil && condition.Reason != string(apiv1.ConditionReasonPostRestoreMaintenanceRunning) {
+		return
+	}
+
+	if !r.postRestoreMaintenanceStarted.CompareAndSwap(false, true) {
+		return
+	}
+
+	go r.runPostRestoreMaintenance(ctx, maintenance.DeepCopy())
+}
+
+// reconcileArchivingKeptDisabled reports, via the cluster conditions, that
+// the WAL archiving has been intentionally kept disabled after the recovery.
+// This is done by the primary instance only, and the warning is logged
+// every time the condition is set
+func (r *InstanceReconciler) reconcileArchivingKeptDisabled(ctx context.Context, cluster *apiv1.Cluster) {
+	if cluster.Status.CurrentPrimary != r.instance.PodName || !cluster.IsArchivingKeptDisabledAfterRecovery() {
+		return
+	}
+
+	condition := meta.FindStatusCondition(cluster.Status.Conditions, string(apiv1.ConditionContinuousArchiving))
+	if condition != nil && condition.Reason == string(apiv1.ConditionReasonContinuousArchivingDisabled) {
+		return
+	}
+
+	log.FromContext(ctx).Warning("WAL archiving has been kept disabled after the recovery, "+
+		"the cluster can't be recovered to a point in time and must not be used for real data",
+		"option", "keepArchivingDisabled")
+	if err := conditions.Patch(ctx, r.client, cluster, &metav1.Condition{
+		Type:    string(apiv1.ConditionContinuousArchiving),
+		Status:  metav1.ConditionFalse,
+		Reason:  string(apiv1.ConditionReasonContinuousArchivingDisabled),
+		Message: "WAL archiving has been intentionally kept disabled after the recovery",
+	}); err != nil {
+		log.FromContext(ctx).Error(err, "Error changing the continuous archiving condition",
+			"reason", apiv1.ConditionReasonContinuousArchivingDisabled)
+	}
+}
+
+// reconcileConnectionRamp raises the connection limits, lowered by the
+// restore for the warm-up of the cluster, back to their restored values
+// once the warm-up window has elapsed and the post-restore maintenance,
+// if requested, is terminated. This is done by the primary instance only,
+// and the time to wait before checking again is returned
+func (r *InstanceReconciler) reconcileConnectionRamp(ctx context.Context, cluster *apiv1.Cluster) time.Duration {
+	if cluster.Status.CurrentPrimary != r.instance.PodName {
+		return 0
+	}
+
+	report := cluster.Status.ConnectionRamp
+	if report == nil || report.CompletedAt != nil {
+		return 0
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	if remaining := cluster.GetConnectionRamp().GetWarmupTime() - time.Since(report.StartedAt.Time); remaining > 0 {
+		return remaining
+	}
+
+	if cluster.GetPostRestoreMaintenance().IsEnabled() {
+		condition := meta.FindStatusCondition(cluster.Status.Conditions,
+			string(apiv1.ConditionPostRestoreMaintenance))
+		if condition == nil || condition.Reason == string(apiv1.ConditionReasonPostRestoreMaintenanceRunning) {
+			contextLogger.Debug("Waiting for the post-restore maintenance before raising the connection limits")
+			return connectionRampCheckInterval
+		}
+	}
+
+	superUserDB, err := r.instance.GetSuperUserDB()
+	if err != nil {
+		contextLogger.Error(err, "Error getting the superuser connection pool to raise the connection limits")
+		return connectionRampCheckInterval
+	}
+
+	if err := raiseConnectionLimits(ctx, superUserDB, report.Databases); err != nil {
+		contextLogger.Error(err, "Error raising the connection limits after the warm-up")
+		return connectionRampCheckInterval
+	}
+
+	completedAt := metav1.Now()
+	oldCluster := cluster.DeepCopy()
+	cluster.Status.ConnectionRamp.CompletedAt = &completedAt
+	if err := r.client.Status().Patch(ctx, cluster, client.MergeFrom(oldCluster)); err != nil {
+		contextLogger.Error(err, "Error reporting the completion of the connection ramp")
+		return connectionRampCheckInterval
+	}
+
+	contextLogger.Info("Completed the connection ramp of the restored cluster",
+		"warmupDuration", time.Since(report.StartedAt.Time).Round(time.Second).String(),
+		"databases", len(report.Databases))
+	return 0
+}
+
+// raiseConnectionLimits sets the connection limit of the passed databases
+// back to the restored one. A database dropped during the warm-up
+// is skipped
+func raiseConnectionLimits(ctx context.Context, db *sql.DB, databases []apiv1.ConnectionRampDatabase) error {
+	contextLogger := log.FromContext(ctx)
+
+	for _, database := range databases {
+		_, err := db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT %d",
+			pgx.Identifier{database.Name}.Sanitize(), database.ConnectionLimit))
+		var errPGX *pgconn.PgError
+		if errors.As(err, &errPGX) && errPGX.Code == invalidCatalogNameErrorCode {
+			contextLogger.Info("Database dropped during the warm-up, its connection limit is not raised",
+				"database", database.Name)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("while raising the connection limit of database %s: %w", database.Name, err)
+		}
+
+		contextLogger.Info("Raised the connection limit of the restored database after the warm-up",
+			"database", database.Name,
+			"connectionLimit", database.ConnectionLimit)
+	}
+
+	return nil
+}
+
+// runPostRestoreMaintenance executes the maintenance operations on every
+// database, and reports the outcome via the cluster conditions
+func (r *InstanceReconciler) runPostRestoreMaintenance(
+	ctx context.Context,
+	maintenance *apiv1.PostRestoreMaintenance,
+) {
+	contextLogger := log.FromContext(ctx)
+
+	r.patchPostRestoreMaintenanceCondition(ctx, &metav1.Condition{
+		Type:    string(apiv1.ConditionPostRestoreMaintenance),
+		Status:  metav1.ConditionFalse,
+		Reason:  string(apiv1.ConditionReasonPostRestoreMaintenanceRunning),
+		Message: "Post-restore maintenance is running",
+	})
+
+	contextLogger.Info("Starting the post-restore maintenance",
+		"analyze", maintenance.Analyze,
+		"prewarm", maintenance.Prewarm)
+
+	if err := r.executePostRestoreMaintenance(ctx, maintenance); err != nil {
+		contextLogger.Error(err, "Post-restore maintenance failed")
+		r.patchPostRestoreMaintenanceCondition(ctx, &metav1.Condition{
+			Type:    string(apiv1.ConditionPostRestoreMaintenance),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(apiv1.ConditionReasonPostRestoreMaintenanceFailed),
+			Message: err.Error(),
+		})
+		return
+	}
+
+	contextLogger.Info("Post-restore maintenance completed")
+	r.patchPostRestoreMaintenanceCondition(ctx, &metav1.Condition{
+		Type:    string(apiv1.ConditionPostRestoreMaintenance),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonPostRestoreMaintenanceCompleted),
+		Message: "Post-restore maintenance has been completed",
+	})
+}
+
+// executePostRestoreMaintenance executes the maintenance operations on
+// every database accepting connections, template databases excluded. The
+// databases are processed in parallel, and a failure in one of them
+// doesn't stop the maintenance of the other ones: the errors are
+// reported together at the end
+func (r *InstanceReconciler) executePostRestoreMaintenance(
+	ctx context.Context,
+	maintenance *apiv1.PostRestoreMaintenance,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	superUserDB, err := r.instance.GetSuperUserDB()
+	if err != nil {
+		return fmt.Errorf("while getting the superuser connection pool: %w", err)
+	}
+
+	tx, err := superUserDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("while starting a transaction: %w", err)
+	}
+	databases, errors := postgresutils.GetAllAccessibleDatabases(tx, "datallowconn AND NOT datistemplate")
+	if err := tx.Commit(); err != nil {
+		errors = append(errors, err)
+	}
+	if errors != nil {
+		return fmt.Errorf("while listing the databases: %v", errors)
+	}
+
+	// The connection pool is not safe for concurrent use, and the
+	// connections are taken before starting the workers
+	targets := make([]postRestoreMaintenanceTarget, 0, len(databases))
+	var failures []string
+	for _, databaseName := range databases {
+		db, err := r.instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("could not connect to database %s: %v", databaseName, err))
+			continue
+		}
+		targets = append(targets, postRestoreMaintenanceTarget{databaseName: databaseName, db: db})
+	}
+
+	results := runPostRestoreMaintenanceOnDatabases(ctx, targets, maintenance, maintenance.GetParallelism())
+	durations := make(map[string]string, len(results))
+	for _, result := range results {
+		durations[result.databaseName] = result.duration.String()
+		if result.err != nil {
+			failures = append(failures, result.err.Error())
+		}
+	}
+	contextLogger.Info("Post-restore maintenance durations",
+		"parallelism", maintenance.GetParallelism(),
+		"durations", durations)
+
+	if len(failures) > 0 {
+		return fmt.Errorf("post-restore maintenance failed on %d of %d databases: %s",
+			len(failures), len(databases), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// postRestoreMaintenanceTarget is a database on which the maintenance
+// operations are executed
+type postRestoreMaintenanceTarget struct {
+	databaseName string
+	db           *sql.DB
+}
+
+// postRestoreMaintenanceResult is the outcome of the maintenance
+// operations on a database
+type postRestoreMaintenanceResult struct {
+	databaseName string
+	duration     time.Duration
+	err          error
+}
+
+// runPostRestoreMaintenanceOnDatabases executes the maintenance operations
+// on the passed databases, processing at most parallelism of them at the
+// same time to protect the just restored instance. The results are in
+// the same order of the databases
+func runPostRestoreMaintenanceOnDatabases(
+	ctx context.Context,
+	targets []postRestoreMaintenanceTarget,
+	maintenance *apiv1.PostRestoreMaintenance,
+	parallelism int,
+) []postRestoreMaintenanceResult {
+	results := make([]postRestoreMaintenanceResult, len(targets))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(parallelism, len(targets)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexes {
+				start := time.Now()
+				err := runPostRestoreMaintenanceOnDatabase(ctx, targets[idx].db, targets[idx].databaseName, maintenance)
+				results[idx] = postRestoreMaintenanceResult{
+					databaseName: targets[idx].databaseName,
+					duration:     time.Since(start),
+					err:          err,
+				}
+			}
+		}()
+	}
+
+	for idx := range targets {
+		indexes <- idx
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// runPostRestoreMaintenanceOnDatabase executes the maintenance operations
+// on a single database
+func runPostRestoreMaintenanceOnDatabase(
+	ctx context.Context,
+	db *sql.DB,
+	databaseName string,
+	maintenance *apiv1.PostRestoreMaintenance,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("database", databaseName)
+
+	if maintenance.Analyze {
+		contextLogger.Info("Running ANALYZE")
+		if _, err := db.ExecContext(ctx, "ANALYZE"); err != nil {
+			return fmt.Errorf("while analyzing database %s: %w", databaseName, err)
+		}
+	}
+
+	if maintenance.Prewarm {
+		if _, err := db.ExecContext(ctx, postRestorePrewarmExtension); err != nil {
+			return fmt.Errorf("while creating the pg_prewarm extension in database %s: %w", databaseName, err)
+		}
+
+		var relations int64
+		if err := db.QueryRowContext(ctx, postRestorePrewarmQuery).Scan(&relations); err != nil {
+			return fmt.Errorf("while prewarming database %s: %w", databaseName, err)
+		}
+		contextLogger.Info("Relations loaded in the shared buffers", "relations", relations)
+	}
+
+	return nil
+}
+
+// patchPostRestoreMaintenanceCondition updates the condition reporting
+// the status of the post-restore maintenance. The cluster is fetched
+// again, as the maintenance can take a long time
+func (r *InstanceReconciler) patchPostRestoreMaintenanceCondition(
+	ctx context.Context,
+	condition *metav1.Condition,
+) {
+	cluster, err := r.GetCluster(ctx)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Error getting the cluster to update the post-restore maintenance condition",
+			"reason", condition.Reason)
+		return
+	}
+
+	if err := conditions.Patch(ctx, r.client, cluster, condition); err != nil {
+		log.FromContext(ctx).Error(err, "Error changing the post-restore maintenance condition",
+			"reason", condition.Reason)
+	}
+}
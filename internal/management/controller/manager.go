@@ -46,6 +46,8 @@ type InstanceReconciler struct {
 	systemInitialization  *concurrency.Executed
 	firstReconcileDone    atomic.Bool
 	metricsServerExporter *metricserver.Exporter
+
+	postRestoreMaintenanceStarted atomic.Bool
 }
 
 // NewInstanceReconciler creates a new instance reconciler
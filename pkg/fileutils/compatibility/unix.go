@@ -47,3 +47,38 @@ func AddInstanceRunCommands(cmd *exec.Cmd) {
 func Umask(mask int) int {
 	return unix.Umask(mask)
 }
+
+// GetOwnership returns the UID and GID of the owner of a file
+func GetOwnership(fileName string) (uid, gid int, err error) {
+	var stat unix.Stat_t
+	if err := unix.Stat(fileName, &stat); err != nil {
+		return 0, 0, err
+	}
+	return int(stat.Uid), int(stat.Gid), nil
+}
+
+// IsWritable checks if the current process can write into a file or directory
+func IsWritable(fileName string) bool {
+	return unix.Access(fileName, unix.W_OK) == nil
+}
+
+// GetAvailableSpace returns the number of bytes available to unprivileged
+// users in the filesystem containing a file or directory
+func GetAvailableSpace(fileName string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(fileName, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil // nolint:gosec
+}
+
+// GetAvailableInodes returns the number of free inodes and the total number
+// of inodes in the filesystem containing a file or directory. The total is
+// zero for the filesystems not limiting the number of inodes
+func GetAvailableInodes(fileName string) (free uint64, total uint64, err error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(fileName, &stat); err != nil {
+		return 0, 0, err
+	}
+	return stat.Ffree, stat.Files, nil
+}
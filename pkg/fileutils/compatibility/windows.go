@@ -39,3 +39,23 @@ func AddInstanceRunCommands(cmd *exec.Cmd) {
 func Umask(mask int) int {
 	return mask
 }
+
+// GetOwnership fakes function for cross-compiling compatibility
+func GetOwnership(fileName string) (uid, gid int, err error) {
+	panic(fmt.Sprintf("function GetOwnership() should not be used in Windows"))
+}
+
+// IsWritable fakes function for cross-compiling compatibility
+func IsWritable(fileName string) bool {
+	panic(fmt.Sprintf("function IsWritable() should not be used in Windows"))
+}
+
+// GetAvailableSpace fakes function for cross-compiling compatibility
+func GetAvailableSpace(fileName string) (uint64, error) {
+	panic(fmt.Sprintf("function GetAvailableSpace() should not be used in Windows"))
+}
+
+// GetAvailableInodes fakes function for cross-compiling compatibility
+func GetAvailableInodes(fileName string) (uint64, uint64, error) {
+	panic(fmt.Sprintf("function GetAvailableInodes() should not be used in Windows"))
+}
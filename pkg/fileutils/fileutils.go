@@ -88,6 +88,25 @@ func AppendStringToFile(targetFile string, content string) (err error) {
 	return err
 }
 
+// SyncFile flushes to disk the content of a file or, when it is passed
+// a directory, its entries, so that the files created, renamed or
+// removed inside it survive a crash
+func SyncFile(fileName string) (err error) {
+	var stream *os.File
+	stream, err = os.Open(fileName) // #nosec
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeError := stream.Close()
+		if err == nil && closeError != nil {
+			err = closeError
+		}
+	}()
+
+	return stream.Sync()
+}
+
 // FileExists check if a file exists, and return an error otherwise
 func FileExists(fileName string) (bool, error) {
 	if _, err := os.Stat(fileName); err != nil {
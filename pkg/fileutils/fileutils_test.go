@@ -60,6 +60,21 @@ var _ = Describe("File writing functions", func() {
 	})
 })
 
+var _ = Describe("SyncFile", func() {
+	It("flushes files and directories", func() {
+		tempDir := GinkgoT().TempDir()
+		fileName := filepath.Join(tempDir, "test.txt")
+		Expect(os.WriteFile(fileName, []byte("content"), 0o600)).To(Succeed())
+
+		Expect(SyncFile(fileName)).To(Succeed())
+		Expect(SyncFile(tempDir)).To(Succeed())
+	})
+
+	It("fails when the file doesn't exist", func() {
+		Expect(SyncFile(filepath.Join(GinkgoT().TempDir(), "missing"))).ToNot(Succeed())
+	})
+})
+
 var _ = Describe("File copying functions", func() {
 	It("copy files", func() {
 		changed, err := WriteStringToFile(path.Join(tempDir2, "test.txt"), "this is a test")
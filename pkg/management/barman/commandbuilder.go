@@ -17,13 +17,35 @@ limitations under the License.
 package barman
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
 
 	v1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	barmanCapabilities "github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/capabilities"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 )
 
+const (
+	// cloudProviderAWS is the barman cloud provider of AWS S3 and
+	// of the S3-compatible object stores
+	cloudProviderAWS = "aws-s3"
+
+	// cloudProviderAzure is the barman cloud provider of Azure Blob Storage
+	cloudProviderAzure = "azure-blob-storage"
+
+	// cloudProviderGoogle is the barman cloud provider of Google Cloud Storage
+	cloudProviderGoogle = "google-cloud-storage"
+
+	// azureBlobStorageDomain is the domain of the Azure Blob Storage accounts
+	azureBlobStorageDomain = ".blob.core.windows.net"
+)
+
+// ErrUnknownCloudProvider is raised when the cloud provider hosting an
+// object store can't be inferred from its destination path
+var ErrUnknownCloudProvider = errors.New("cannot infer the cloud provider from the destination path")
+
 // CloudWalRestoreOptions returns the options needed to execute the barman command successfully
 func CloudWalRestoreOptions(
 	configuration *v1.BarmanObjectStoreConfiguration,
@@ -59,7 +81,8 @@ func AppendCloudProviderOptionsFromConfiguration(
 	options []string,
 	barmanConfiguration *v1.BarmanObjectStoreConfiguration,
 ) ([]string, error) {
-	return appendCloudProviderOptions(options, barmanConfiguration.BarmanCredentials)
+	return appendCloudProviderOptions(
+		options, barmanConfiguration.BarmanCredentials, barmanConfiguration.DestinationPath)
 }
 
 // AppendCloudProviderOptionsFromBackup takes an options array and adds the cloud provider specified
@@ -68,25 +91,91 @@ func AppendCloudProviderOptionsFromBackup(
 	options []string,
 	backup *v1.Backup,
 ) ([]string, error) {
-	return appendCloudProviderOptions(options, backup.Status.BarmanCredentials)
+	return appendCloudProviderOptions(options, backup.Status.BarmanCredentials, backup.Status.DestinationPath)
 }
 
-// appendCloudProviderOptions takes an options array and adds the cloud provider specified as arguments
-func appendCloudProviderOptions(options []string, credentials v1.BarmanCredentials) ([]string, error) {
-	capabilities, err := barmanCapabilities.CurrentCapabilities()
+// InferCloudProvider infers the cloud provider hosting an object store
+// from its destination path: `s3://` is used by AWS S3 and by the
+// S3-compatible object stores, reached via the endpoint URL, `gs://` by
+// Google Cloud Storage, and `https://<account>.blob.core.windows.net` by
+// Azure Blob Storage
+func InferCloudProvider(destinationPath string) (string, error) {
+	destination, err := url.Parse(destinationPath)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("%w: %v", ErrUnknownCloudProvider, err)
+	}
+
+	switch {
+	case destination.Scheme == "s3":
+		return cloudProviderAWS, nil
+	case destination.Scheme == "gs":
+		return cloudProviderGoogle, nil
+	case (destination.Scheme == "https" || destination.Scheme == "http") &&
+		strings.HasSuffix(destination.Hostname(), azureBlobStorageDomain):
+		return cloudProviderAzure, nil
 	}
 
+	return "", fmt.Errorf("%w: %q", ErrUnknownCloudProvider, destinationPath)
+}
+
+// getCloudProvider gets the cloud provider hosting an object store. The
+// one matching the credentials takes precedence and, when no credentials
+// are available, the provider is inferred from the destination path
+func getCloudProvider(credentials v1.BarmanCredentials, destinationPath string) (string, error) {
+	var provider string
 	switch {
 	case credentials.AWS != nil:
+		provider = cloudProviderAWS
+	case credentials.Azure != nil:
+		provider = cloudProviderAzure
+	case credentials.Google != nil:
+		provider = cloudProviderGoogle
+	}
+
+	inferredProvider, err := InferCloudProvider(destinationPath)
+	if provider == "" {
+		return inferredProvider, err
+	}
+
+	// The destination path of an Azure emulator can't be recognized, so
+	// a disagreement is not an error
+	if err == nil && inferredProvider != provider {
+		log.Warning("The credentials and the destination path refer to different cloud providers, "+
+			"using the provider of the credentials",
+			"provider", provider,
+			"destinationPathProvider", inferredProvider,
+			"destinationPath", destinationPath)
+	}
+
+	return provider, nil
+}
+
+// appendCloudProviderOptions takes an options array and adds the cloud provider
+// matching the credentials or, without them, the destination path
+func appendCloudProviderOptions(
+	options []string,
+	credentials v1.BarmanCredentials,
+	destinationPath string,
+) ([]string, error) {
+	capabilities, err := barmanCapabilities.CurrentCapabilities()
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := getCloudProvider(credentials, destinationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider {
+	case cloudProviderAWS:
 		if capabilities.HasS3 {
 			options = append(
 				options,
 				"--cloud-provider",
-				"aws-s3")
+				cloudProviderAWS)
 		}
-	case credentials.Azure != nil:
+	case cloudProviderAzure:
 		if !capabilities.HasAzure {
 			err := fmt.Errorf(
 				"barman >= 2.13 is required to use Azure object storage, current: %v",
@@ -98,9 +187,9 @@ func appendCloudProviderOptions(options []string, credentials v1.BarmanCredentia
 		options = append(
 			options,
 			"--cloud-provider",
-			"azure-blob-storage")
+			cloudProviderAzure)
 
-		if !credentials.Azure.InheritFromAzureAD {
+		if credentials.Azure == nil || !credentials.Azure.InheritFromAzureAD {
 			break
 		}
 
@@ -116,7 +205,7 @@ func appendCloudProviderOptions(options []string, credentials v1.BarmanCredentia
 			options,
 			"--credential",
 			"managed-identity")
-	case credentials.Google != nil:
+	case cloudProviderGoogle:
 		if !capabilities.HasGoogle {
 			err := fmt.Errorf(
 				"barman >= 2.19 is required to use Google Cloud Storage, current: %v",
@@ -127,7 +216,7 @@ func appendCloudProviderOptions(options []string, credentials v1.BarmanCredentia
 		options = append(
 			options,
 			"--cloud-provider",
-			"google-cloud-storage")
+			cloudProviderGoogle)
 	}
 
 	return options, nil
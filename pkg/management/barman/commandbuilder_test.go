@@ -68,3 +68,46 @@ var _ = Describe("barmanCloudWalRestoreOptions", func() {
 				))
 	})
 })
+
+var _ = Describe("cloud provider of an object store", func() {
+	DescribeTable("infers the cloud provider from the destination path",
+		func(destinationPath string, expectedProvider string) {
+			provider, err := InferCloudProvider(destinationPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(provider).To(Equal(expectedProvider))
+		},
+		Entry("AWS S3", "s3://bucket-name/path", cloudProviderAWS),
+		Entry("Google Cloud Storage", "gs://bucket-name/path", cloudProviderGoogle),
+		Entry("Azure Blob Storage", "https://account.blob.core.windows.net/container/path", cloudProviderAzure),
+	)
+
+	It("fails with an unrecognized destination path", func() {
+		_, err := InferCloudProvider("https://minio:9000/bucket-name")
+		Expect(err).To(MatchError(ErrUnknownCloudProvider))
+		_, err = InferCloudProvider("/local/path")
+		Expect(err).To(MatchError(ErrUnknownCloudProvider))
+	})
+
+	It("uses the provider of the credentials when available", func() {
+		provider, err := getCloudProvider(apiv1.BarmanCredentials{
+			Azure: &apiv1.AzureCredentials{ConnectionString: &apiv1.SecretKeySelector{}},
+		}, "http://azurite:10000/account/container")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider).To(Equal(cloudProviderAzure))
+
+		provider, err = getCloudProvider(apiv1.BarmanCredentials{
+			Google: &apiv1.GoogleCredentials{GKEEnvironment: true},
+		}, "s3://bucket-name/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider).To(Equal(cloudProviderGoogle))
+	})
+
+	It("infers the provider when there are no credentials", func() {
+		provider, err := getCloudProvider(apiv1.BarmanCredentials{}, "gs://bucket-name/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(provider).To(Equal(cloudProviderGoogle))
+
+		_, err = getCloudProvider(apiv1.BarmanCredentials{}, "ftp://server/")
+		Expect(err).To(MatchError(ErrUnknownCloudProvider))
+	})
+})
@@ -17,7 +17,9 @@ limitations under the License.
 package barman
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 
 	barmanCapabilities "github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/capabilities"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
@@ -49,6 +51,19 @@ var errorDescriptions = map[int]string{
 	generalErrorCode:   "General error",
 }
 
+// ErrBackupMissingInStorage is raised when the base backup to be restored
+// is not available in the object storage anymore
+var ErrBackupMissingInStorage = errors.New("backup not found in the object storage")
+
+// backupMissingPatterns are the messages written by barman-cloud-restore
+// on its standard error when the requested backup can't be found
+var backupMissingPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)backup \S+ for server \S+ does not exists?`),
+	regexp.MustCompile(`(?i)unknown backup`),
+	regexp.MustCompile(`(?i)backup \S* ?not found`),
+	regexp.MustCompile(`(?i)no backups? (found|available)`),
+}
+
 // CloudRestoreError is raised when barman-cloud-restore fails
 type CloudRestoreError struct {
 	// The exit code returned by Barman
@@ -56,6 +71,9 @@ type CloudRestoreError struct {
 
 	// This is true when Barman can return significant error codes
 	HasRestoreErrorCodes bool
+
+	// The standard error of barman-cloud-restore
+	Stderr string
 }
 
 // Error implements the error interface
@@ -65,18 +83,83 @@ func (err *CloudRestoreError) Error() string {
 		msg = "Generic failure"
 	}
 
+	if err.IsBackupMissing() {
+		return fmt.Sprintf("%s: %s (exit code %v)", msg, ErrBackupMissingInStorage.Error(), err.ExitCode)
+	}
+
 	return fmt.Sprintf("%s (exit code %v)", msg, err.ExitCode)
 }
 
+// Unwrap allows the missing backup condition to be detected
+// via errors.Is(err, ErrBackupMissingInStorage)
+func (err *CloudRestoreError) Unwrap() error {
+	if err.IsBackupMissing() {
+		return ErrBackupMissingInStorage
+	}
+
+	return nil
+}
+
+// IsBackupMissing returns true when barman-cloud-restore reported that
+// the requested backup is not available in the object storage
+func (err *CloudRestoreError) IsBackupMissing() bool {
+	for _, pattern := range backupMissingPatterns {
+		if pattern.MatchString(err.Stderr) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // IsRetriable returns true whether the error is temporary, and
 // it could be a good idea to retry the restore later
 func (err *CloudRestoreError) IsRetriable() bool {
+	if err.IsBackupMissing() {
+		return false
+	}
+
 	return (err.ExitCode == networkErrorCode || err.ExitCode == generalErrorCode) && err.HasRestoreErrorCodes
 }
 
+// CloudRestoreStartError is raised when barman-cloud-restore can't even
+// be started, for example because it is not installed in the image or
+// it can't be executed. This is a problem of the environment, and
+// retrying the restore is pointless
+type CloudRestoreStartError struct {
+	// The error raised while starting barman-cloud-restore
+	Err error
+}
+
+// Error implements the error interface
+func (err *CloudRestoreStartError) Error() string {
+	return fmt.Sprintf("cannot start %s: %v", barmanCapabilities.BarmanCloudRestore, err.Err)
+}
+
+// Unwrap allows the cause of the failure, such as exec.ErrNotFound
+// or fs.ErrPermission, to be detected
+func (err *CloudRestoreStartError) Unwrap() error {
+	return err.Err
+}
+
+// IsRetriable always returns false, as a command that can't be
+// started won't start at the next attempt either
+func (err *CloudRestoreStartError) IsRetriable() bool {
+	return false
+}
+
+// IsRetriableRestoreError checks if a failure of barman-cloud-restore
+// can be retried. Only the failures happened before the command could
+// be started are considered permanent
+func IsRetriableRestoreError(err error) bool {
+	var startError *CloudRestoreStartError
+	return !errors.As(err, &startError)
+}
+
 // UnmarshalBarmanCloudRestoreExitCode returns the correct error
-// for a certain barman-cloud-restore exit code
-func UnmarshalBarmanCloudRestoreExitCode(exitCode int) error {
+// for a certain barman-cloud-restore exit code, given the standard
+// error of the command
+func UnmarshalBarmanCloudRestoreExitCode(exitCode int, stderr string) error {
 	if exitCode == 0 {
 		return nil
 	}
@@ -91,11 +174,13 @@ func UnmarshalBarmanCloudRestoreExitCode(exitCode int) error {
 		return &CloudRestoreError{
 			ExitCode:             exitCode,
 			HasRestoreErrorCodes: false,
+			Stderr:               stderr,
 		}
 	}
 
 	return &CloudRestoreError{
 		ExitCode:             exitCode,
 		HasRestoreErrorCodes: currentCapabilities.HasErrorCodesForRestore,
+		Stderr:               stderr,
 	}
 }
@@ -0,0 +1,81 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package barman
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CloudRestoreError", func() {
+	DescribeTable("detects a backup missing from the object storage",
+		func(stderr string, missing bool) {
+			err := &CloudRestoreError{ExitCode: operationErrorCode, HasRestoreErrorCodes: true, Stderr: stderr}
+			Expect(err.IsBackupMissing()).To(Equal(missing))
+			Expect(errors.Is(err, ErrBackupMissingInStorage)).To(Equal(missing))
+			if missing {
+				Expect(err.IsRetriable()).To(BeFalse())
+				Expect(err.Error()).To(ContainSubstring(ErrBackupMissingInStorage.Error()))
+			}
+		},
+		Entry("backup id that does not exist",
+			"2024-05-21 10:12:33,142 [1234] ERROR: Backup 20240520T101010 for server cluster-example does not exists",
+			true),
+		Entry("unknown backup",
+			"ERROR: Barman cloud restore exception: Unknown backup '20240520T101010' for server 'cluster-example'",
+			true),
+		Entry("no backup found",
+			"2024-05-21 10:12:33,142 [1234] ERROR: No backups found",
+			true),
+		Entry("network error",
+			"ERROR: Barman cloud restore exception: Could not connect to the endpoint URL: \"https://s3.amazonaws.com\"",
+			false),
+		Entry("empty stderr", "", false),
+	)
+
+	It("keeps the raw stderr and the retriability of the exit code", func() {
+		err := &CloudRestoreError{
+			ExitCode:             networkErrorCode,
+			HasRestoreErrorCodes: true,
+			Stderr:               "ERROR: Connection timed out",
+		}
+		Expect(err.IsRetriable()).To(BeTrue())
+		Expect(err.Stderr).To(Equal("ERROR: Connection timed out"))
+		Expect(err.Error()).To(Equal("Network error (exit code 2)"))
+	})
+})
+
+var _ = Describe("CloudRestoreStartError", func() {
+	It("is not retriable and keeps the cause of the failure", func() {
+		err := &CloudRestoreStartError{Err: exec.ErrNotFound}
+		Expect(err.IsRetriable()).To(BeFalse())
+		Expect(errors.Is(err, exec.ErrNotFound)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("cannot start barman-cloud-restore"))
+	})
+
+	It("distinguishes the failures to start from the runtime ones", func() {
+		Expect(IsRetriableRestoreError(fmt.Errorf("while restoring: %w",
+			&CloudRestoreStartError{Err: fs.ErrPermission}))).To(BeFalse())
+		Expect(IsRetriableRestoreError(&CloudRestoreError{ExitCode: operationErrorCode})).To(BeTrue())
+		Expect(IsRetriableRestoreError(errors.New("generic failure"))).To(BeTrue())
+	})
+})
@@ -77,6 +77,39 @@ func (catalog *Catalog) LatestBackupInfo() *BarmanBackup {
 	return nil
 }
 
+// PreviousBackupInfo gets the information about the latest successful
+// backup taken before the one with the passed ID, on the timeline requested
+// by the recovery target, if any. Being older, it can be used to reach any
+// target reachable starting from the passed backup, replaying more WAL files
+func (catalog *Catalog) PreviousBackupInfo(backupID string, recoveryTarget *v1.RecoveryTarget) *BarmanBackup {
+	// the code below assumes the catalog to be sorted, therefore, we enforce it first
+	sort.Sort(catalog)
+
+	var targetTLI string
+	if recoveryTarget != nil {
+		targetTLI = recoveryTarget.TargetTLI
+	}
+
+	found := false
+	for i := len(catalog.List) - 1; i >= 0; i-- {
+		barmanBackup := catalog.List[i]
+		if barmanBackup.ID == backupID {
+			found = true
+			continue
+		}
+		if !found || !barmanBackup.isBackupDone() {
+			continue
+		}
+		if strconv.Itoa(barmanBackup.TimeLine) == targetTLI ||
+			// if targetTLI is not an integer, it will be ignored actually
+			currentTLIRegex.MatchString(targetTLI) {
+			return &catalog.List[i]
+		}
+	}
+
+	return nil
+}
+
 // FirstRecoverabilityPoint gets the start time of the first backup in
 // the catalog
 func (catalog *Catalog) FirstRecoverabilityPoint() *time.Time {
@@ -148,13 +181,25 @@ func (catalog *Catalog) findClosestBackupFromTargetLSN(
 		if (strconv.Itoa(barmanBackup.TimeLine) == targetTLI ||
 			// if targetTLI is not an integer, it will be ignored actually
 			currentTLIRegex.MatchString(targetTLI)) &&
-			postgres.LSN(barmanBackup.BeginLSN).Less(targetLSN) {
+			barmanBackup.canReachLSN(targetLSN) {
 			return &catalog.List[i], nil
 		}
 	}
 	return nil, nil
 }
 
+// canReachLSN checks if a recovery starting from this backup can reach
+// the passed LSN. The target needs to be at or after the consistency
+// point of the backup, which is its end LSN. When the end LSN is
+// not known, the begin LSN is used
+func (b *BarmanBackup) canReachLSN(targetLSN postgres.LSN) bool {
+	if b.EndLSN != "" {
+		return !targetLSN.Less(postgres.LSN(b.EndLSN))
+	}
+
+	return postgres.LSN(b.BeginLSN).Less(targetLSN)
+}
+
 func (catalog *Catalog) findClosestBackupFromTargetTime(
 	targetTimeString string,
 	targetTLI string,
@@ -96,6 +96,62 @@ var _ = Describe("Backup catalog", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(BackupInfo.ID).To(Equal("202101011200"))
 	})
+
+	It("can get the backup info preceding a given backup", func() {
+		Expect(catalog.PreviousBackupInfo("202101031200", nil).ID).To(Equal("202101021200"))
+		Expect(catalog.PreviousBackupInfo("202101021200", &v1.RecoveryTarget{}).ID).To(Equal("202101011200"))
+		Expect(catalog.PreviousBackupInfo("202101011200", nil)).To(BeNil())
+		Expect(catalog.PreviousBackupInfo("202101031200", &v1.RecoveryTarget{TargetTLI: "2"})).To(BeNil())
+		Expect(catalog.PreviousBackupInfo("unknown", nil)).To(BeNil())
+	})
+})
+
+var _ = Describe("Backup catalog LSN based research", func() {
+	catalog := NewCatalog([]BarmanBackup{
+		{
+			ID:        "202101011200",
+			BeginTime: time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2021, 1, 1, 12, 30, 0, 0, time.UTC),
+			BeginLSN:  "0/2000028",
+			EndLSN:    "0/3000100",
+			TimeLine:  1,
+		},
+		{
+			ID:        "202101021200",
+			BeginTime: time.Date(2021, 1, 2, 12, 0, 0, 0, time.UTC),
+			EndTime:   time.Date(2021, 1, 2, 12, 30, 0, 0, time.UTC),
+			BeginLSN:  "0/5000028",
+			EndLSN:    "0/6000100",
+			TimeLine:  1,
+		},
+	})
+
+	It("picks the latest backup whose consistency point precedes the target", func() {
+		closestBackupInfo, err := catalog.FindBackupInfo(&v1.RecoveryTarget{TargetLSN: "0/7000000"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(closestBackupInfo.ID).To(Equal("202101021200"))
+
+		closestBackupInfo, err = catalog.FindBackupInfo(&v1.RecoveryTarget{TargetLSN: "0/6000100"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(closestBackupInfo.ID).To(Equal("202101021200"))
+	})
+
+	It("skips the backups that were still running at the target LSN", func() {
+		closestBackupInfo, err := catalog.FindBackupInfo(&v1.RecoveryTarget{TargetLSN: "0/5800000"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(closestBackupInfo.ID).To(Equal("202101011200"))
+	})
+
+	It("will return an empty result when no backup can reach the target", func() {
+		closestBackupInfo, err := catalog.FindBackupInfo(&v1.RecoveryTarget{TargetLSN: "0/2800000"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(closestBackupInfo).To(BeNil())
+	})
+
+	It("rejects invalid LSNs", func() {
+		_, err := catalog.FindBackupInfo(&v1.RecoveryTarget{TargetLSN: "14:32"})
+		Expect(err).To(HaveOccurred())
+	})
 })
 
 var _ = Describe("barman-cloud-backup-list parsing", func() {
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"time"
 
+	coordinationv1 "k8s.io/api/coordination/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -79,7 +80,7 @@ func NewControllerRuntimeClient() (client.WithWatch, error) {
 		// custom resources
 		&apiv1.Cluster{}, &apiv1.Backup{}, &apiv1.Pooler{}, &apiv1.ImageCatalog{}, &apiv1.ClusterImageCatalog{},
 		// k8s resources needed for the typedClient to work properly
-		&v1.ConfigMap{}, &v1.Secret{},
+		&v1.ConfigMap{}, &v1.Secret{}, &coordinationv1.Lease{},
 	}
 
 	// we register the resources
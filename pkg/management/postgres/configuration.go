@@ -19,6 +19,7 @@ package postgres
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
@@ -33,9 +34,12 @@ import (
 	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres/replication"
-	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 )
 
+// trackCommitTimestampParameter is the parameter enabling the tracking
+// of the commit timestamps
+const trackCommitTimestampParameter = "track_commit_timestamp"
+
 // InstallPgDataFileContent installs a file in PgData, returning true/false if
 // the file has been changed and an error state
 func InstallPgDataFileContent(pgdata, contents, destinationFile string) (bool, error) {
@@ -206,6 +210,7 @@ func (instance *Instance) generatePostgresqlIdent(additionalLines []string) (str
 	return postgres.CreateIdentRules(
 		additionalLines,
 		getCurrentUserOrDefaultToInsecureMapping(),
+		postgresName,
 	)
 }
 
@@ -429,11 +434,11 @@ func createPostgresqlConfiguration(cluster *apiv1.Cluster, preserveUserSettings
 	info := postgres.ConfigurationInfo{
 		Settings:                         postgres.CnpgConfigurationSettings,
 		MajorVersion:                     fromVersion,
-		UserSettings:                     cluster.Spec.PostgresConfiguration.Parameters,
+		UserSettings:                     getUserSettings(cluster),
 		IncludingSharedPreloadLibraries:  true,
 		AdditionalSharedPreloadLibraries: cluster.Spec.PostgresConfiguration.AdditionalLibraries,
 		IsReplicaCluster:                 cluster.IsReplica(),
-		IsWalArchivingDisabled:           utils.IsWalArchivingDisabled(&cluster.ObjectMeta),
+		IsWalArchivingDisabled:           cluster.IsWalArchivingDisabled(),
 		IsAlterSystemEnabled:             cluster.Spec.PostgresConfiguration.EnableAlterSystem,
 		SynchronousStandbyNames:          replication.GetSynchronousStandbyNames(cluster),
 	}
@@ -464,6 +469,33 @@ func createPostgresqlConfiguration(cluster *apiv1.Cluster, preserveUserSettings
 	return conf, sha256, nil
 }
 
+// getUserSettings gets the parameters set by the user, including the ones
+// requested while recovering the cluster, so that they are active as soon
+// as the restored instance starts: the tracking of the commit timestamps,
+// unless explicitly set by the user, and the wal_level required by the
+// intended downstream use of the cluster
+func getUserSettings(cluster *apiv1.Cluster) map[string]string {
+	parameters := cluster.Spec.PostgresConfiguration.Parameters
+
+	overrides := make(map[string]string)
+	if cluster.IsCommitTimestampTrackedAfterRecovery() {
+		if _, ok := parameters[trackCommitTimestampParameter]; !ok {
+			overrides[trackCommitTimestampParameter] = "on"
+		}
+	}
+	if walLevel := getAdjustedWALLevel(cluster); walLevel != "" {
+		overrides[postgres.ParameterWalLevel] = string(walLevel)
+	}
+	if len(overrides) == 0 {
+		return parameters
+	}
+
+	result := make(map[string]string, len(parameters)+len(overrides))
+	maps.Copy(result, parameters)
+	maps.Copy(result, overrides)
+	return result
+}
+
 // configurePostgresForImport configures Postgres to be optimized for the firt import
 // process, by writing dedicated options the override.conf file just for this phase
 func configurePostgresForImport(ctx context.Context, pgData string) (changed bool, err error) {
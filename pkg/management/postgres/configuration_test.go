@@ -18,6 +18,9 @@ package postgres
 
 import (
 	"fmt"
+	"os"
+	"os/user"
+	"path"
 	"strings"
 	"time"
 
@@ -26,6 +29,7 @@ import (
 	"k8s.io/utils/ptr"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -185,6 +189,46 @@ var _ = Describe("Test building of the list of temporary tablespaces", func() {
 	})
 })
 
+var _ = Describe("tracking of the commit timestamps requested while recovering", func() {
+	newCluster := func(parameters map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "configurationTest",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", TrackCommitTimestamp: true},
+				},
+				PostgresConfiguration: apiv1.PostgresConfiguration{Parameters: parameters},
+			},
+		}
+	}
+
+	It("enables track_commit_timestamp", func() {
+		config, _, err := createPostgresqlConfiguration(newCluster(nil), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config).To(ContainSubstring("track_commit_timestamp = 'on'"))
+	})
+
+	It("doesn't override the value set by the user", func() {
+		parameters := map[string]string{"track_commit_timestamp": "true"}
+		config, _, err := createPostgresqlConfiguration(newCluster(parameters), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config).To(ContainSubstring("track_commit_timestamp = 'true'"))
+		Expect(parameters).To(HaveLen(1))
+	})
+
+	It("doesn't enable track_commit_timestamp when not requested", func() {
+		cluster := newCluster(map[string]string{"work_mem": "8MB"})
+		cluster.Spec.Bootstrap.Recovery.TrackCommitTimestamp = false
+		config, _, err := createPostgresqlConfiguration(cluster, true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config).ToNot(ContainSubstring("track_commit_timestamp"))
+		Expect(getUserSettings(cluster)).To(Equal(cluster.Spec.PostgresConfiguration.Parameters))
+	})
+})
+
 var _ = Describe("recovery_min_apply_delay", func() {
 	primaryCluster := apiv1.Cluster{
 		ObjectMeta: metav1.ObjectMeta{
@@ -255,3 +299,89 @@ var _ = Describe("recovery_min_apply_delay", func() {
 		Expect(config).ToNot(ContainSubstring("recovery_min_apply_delay"))
 	})
 })
+
+var _ = Describe("operating system user of the local ident map", func() {
+	noEnvironment := func(string) string { return "" }
+
+	It("uses the current user, even when it is not postgres", func() {
+		lookupUser := func() (*user.User, error) {
+			return &user.User{Username: "enterprisedb"}, nil
+		}
+		Expect(getSystemUsername(lookupUser, noEnvironment)).To(Equal("enterprisedb"))
+	})
+
+	It("uses the environment when the current user can't be looked up", func() {
+		lookupUser := func() (*user.User, error) {
+			return nil, user.UnknownUserIdError(26)
+		}
+		getenv := func(name string) string {
+			if name == systemUserEnvironmentVariable {
+				return "enterprisedb"
+			}
+			return ""
+		}
+		Expect(getSystemUsername(lookupUser, getenv)).To(Equal("enterprisedb"))
+		Expect(getSystemUsername(lookupUser, noEnvironment)).To(Equal("/"))
+	})
+
+	It("maps the current user to the superuser in the restore configuration", func() {
+		pgData, err := os.MkdirTemp("", "ident")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() { _ = os.RemoveAll(pgData) })
+
+		info := InitInfo{PgData: pgData}
+		Expect(info.WriteRestoreHbaConf()).To(Succeed())
+
+		identContent, err := os.ReadFile(path.Join(pgData, constants.PostgresqlIdentFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(identContent)).To(ContainSubstring(
+			fmt.Sprintf("\nlocal %s postgres\n", getCurrentUserOrDefaultToInsecureMapping())))
+	})
+})
+
+var _ = Describe("wal_level required by the downstream use of a restored cluster", func() {
+	newCluster := func(parameters map[string]string, policy apiv1.WALLevelMismatchPolicy) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "configurationTest",
+				Namespace: "default",
+			},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source: "origin",
+						DownstreamUse: &apiv1.RecoveryDownstreamUse{
+							LogicalReplication: true,
+							OnWALLevelMismatch: policy,
+						},
+					},
+				},
+				PostgresConfiguration: apiv1.PostgresConfiguration{Parameters: parameters},
+			},
+		}
+	}
+
+	It("sets the required wal_level", func() {
+		parameters := map[string]string{"wal_level": "replica"}
+		config, _, err := createPostgresqlConfiguration(newCluster(parameters, ""), true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config).To(ContainSubstring("wal_level = 'logical'"))
+		Expect(parameters).To(HaveKeyWithValue("wal_level", "replica"))
+	})
+
+	It("doesn't change the wal_level when the mismatch must fail the restore", func() {
+		cluster := newCluster(map[string]string{"wal_level": "replica"}, apiv1.WALLevelMismatchPolicyFail)
+		Expect(getUserSettings(cluster)).To(Equal(cluster.Spec.PostgresConfiguration.Parameters))
+	})
+
+	It("doesn't change a minimal wal_level", func() {
+		cluster := newCluster(map[string]string{"wal_level": "minimal", "max_wal_senders": "0"}, "")
+		Expect(getUserSettings(cluster)).To(Equal(cluster.Spec.PostgresConfiguration.Parameters))
+	})
+
+	It("doesn't change a wal_level supporting the downstream use", func() {
+		cluster := newCluster(nil, "")
+		Expect(getConfiguredWALLevel(cluster)).To(BeEquivalentTo("logical"))
+		Expect(getUserSettings(cluster)).To(BeNil())
+	})
+})
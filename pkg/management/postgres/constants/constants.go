@@ -51,4 +51,12 @@ const (
 
 	// Startup is the name of a file that is created once during the first reconcile of an instance
 	Startup = "cnpg_initialized"
+
+	// RestoreMarker is the name of a file that is created in PGDATA once the base backup
+	// has been restored and the recovery configured, and removed when the recovery is completed
+	RestoreMarker = "cnpg_restore_in_progress"
+
+	// InPlaceRestoreMarker is the name of a file that is created next to PGDATA once the
+	// directories replaced by a restore in place have been preserved or removed
+	InPlaceRestoreMarker = "cnpg_in_place_restore_prepared"
 )
@@ -17,22 +17,45 @@ limitations under the License.
 package postgres
 
 import (
+	"os"
 	"os/user"
 
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
 )
 
+// systemUserEnvironmentVariable is the environment variable used to get
+// the name of the operating system user running PostgreSQL when it can't
+// be looked up, i.e. when the UID isn't in the user database of the image
+const systemUserEnvironmentVariable = "USER"
+
 // getCurrentUserOrDefaultToInsecureMapping retrieves the current system user's username.
 // If the retrieval fails, it falls back to an insecure mapping using the root ("/") as the default username.
 //
 // Returns:
 // - string: The current system user's username or the default insecure mapping if retrieval fails.
 func getCurrentUserOrDefaultToInsecureMapping() string {
-	currentUser, err := user.Current()
-	if err != nil {
-		log.Info("Unable to identify the current user. Falling back to insecure mapping.")
-		return "/"
+	return getSystemUsername(user.Current, os.Getenv)
+}
+
+// getSystemUsername gets the name of the operating system user running
+// PostgreSQL, which is not necessarily 'postgres', using the passed user
+// lookup function and, when it fails, the environment. When the user can't
+// be identified, the insecure mapping ("/") is returned
+func getSystemUsername(
+	lookupUser func() (*user.User, error),
+	getenv func(string) string,
+) string {
+	currentUser, err := lookupUser()
+	if err == nil && currentUser.Username != "" {
+		return currentUser.Username
+	}
+
+	if username := getenv(systemUserEnvironmentVariable); username != "" {
+		log.Info("Unable to look up the current user, using the environment",
+			"username", username)
+		return username
 	}
 
-	return currentUser.Username
+	log.Info("Unable to identify the current user. Falling back to insecure mapping.")
+	return "/"
 }
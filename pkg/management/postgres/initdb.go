@@ -45,6 +45,7 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logicalimport"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/pool"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/system"
 )
 
@@ -111,6 +112,35 @@ type InitInfo struct {
 
 	// TablespaceMapFile holds the content returned by pg_stop_backup. Needed for a hot backup restore
 	TablespaceMapFile []byte
+
+	// BackupEndLSN is the LSN where the restored base backup ends, used to
+	// estimate the progress of the WAL replay. It is set by the restore
+	BackupEndLSN string
+
+	// TablespaceRemap contains the locations where the tablespaces of the
+	// backup are restored by barman-cloud-restore. It is set by the restore
+	TablespaceRemap []apiv1.RecoveryTablespaceRemap
+
+	// BarmanRunner executes the barman-cloud commands during the restore.
+	// When not set, the barman-cloud binaries are executed
+	BarmanRunner BarmanRunner
+
+	// CredentialsProvider gets the credentials of the object store right
+	// before each barman-cloud command executed during the restore. When
+	// not set, the one requested in the cluster is used
+	CredentialsProvider CredentialsProvider
+
+	// RestoreStatus keeps track of the phase and the progress of the
+	// restore, to be served over HTTP. When not set, nothing is tracked
+	RestoreStatus *RestoreStatusTracker
+
+	// RestoreConcurrencyLimit is the maximum number of restores running
+	// at the same time across every cluster. Zero means unlimited
+	RestoreConcurrencyLimit int
+
+	// RestoreConcurrencyNamespace is the namespace containing the
+	// leases used to limit the concurrent restores
+	RestoreConcurrencyNamespace string
 }
 
 // CheckTargetDataDirectory ensures that the target data directory does not exist.
@@ -489,6 +519,10 @@ func executeLogicalImport(
 	destinationPool := instance.ConnectionPool()
 	defer destinationPool.ShutdownConnections()
 
+	if dump := cluster.Spec.Bootstrap.InitDB.Import.Source.Dump; dump != nil {
+		return executeLogicalImportFromDump(ctx, destinationPool, cluster, dump)
+	}
+
 	originPool, err := getConnectionPoolerForExternalCluster(ctx, cluster, client, cluster.Namespace)
 	if err != nil {
 		return err
@@ -506,6 +540,27 @@ func executeLogicalImport(
 	}
 }
 
+// executeLogicalImportFromDump imports the logical dumps contained in
+// the volume mounted in the import job
+func executeLogicalImportFromDump(
+	ctx context.Context,
+	destinationPool *pool.ConnectionPool,
+	cluster *apiv1.Cluster,
+	dump *apiv1.LogicalDumpSource,
+) error {
+	directory := path.Join(postgres.LogicalDumpDirectory, dump.Path)
+
+	cloneType := cluster.Spec.Bootstrap.InitDB.Import.Type
+	switch cloneType {
+	case apiv1.MicroserviceSnapshotType:
+		return logicalimport.MicroserviceFromDump(ctx, cluster, destinationPool, directory)
+	case apiv1.MonolithSnapshotType:
+		return logicalimport.MonolithFromDump(ctx, cluster, destinationPool, directory)
+	default:
+		return fmt.Errorf("unrecognized clone type %s", cloneType)
+	}
+}
+
 func getConnectionPoolerForExternalCluster(
 	ctx context.Context,
 	cluster *apiv1.Cluster,
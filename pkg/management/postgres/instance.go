@@ -134,6 +134,11 @@ type Instance struct {
 	// '-c' option of pg_ctl for an useful example
 	StartupOptions []string
 
+	// When set, the PostgreSQL log records collected while the instance
+	// is active via WithActiveInstance are written here instead of the
+	// instance manager logger
+	LogRecordWriter logpipe.RecordWriter
+
 	// Pool of DB connections pointing to every used database
 	pool *pool.ConnectionPool
 
@@ -663,6 +668,9 @@ func (instance *Instance) WithActiveInstance(inner func() error) error {
 	// Start the CSV logpipe to redirect log to stdout
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	csvPipe := logpipe.NewLogPipe()
+	if instance.LogRecordWriter != nil {
+		csvPipe = logpipe.NewLogPipeWithRecordWriter(instance.LogRecordWriter)
+	}
 
 	go func() {
 		if err := csvPipe.Start(ctx); err != nil {
@@ -721,23 +729,28 @@ func (instance *Instance) GetPgVersion() (semver.Version, error) {
 
 // ConnectionPool gets or initializes the connection pool for this instance
 func (instance *Instance) ConnectionPool() *pool.ConnectionPool {
-	const applicationName = "cnpg-instance-manager"
 	if instance.pool == nil {
-		socketDir := GetSocketDir()
-		dsn := fmt.Sprintf(
-			"host=%s port=%v user=%v sslmode=disable application_name=%v",
-			socketDir,
-			GetServerPort(),
-			"postgres",
-			applicationName,
-		)
-
-		instance.pool = pool.NewPostgresqlConnectionPool(dsn)
+		instance.pool = newSuperUserConnectionPool(GetSocketDir())
 	}
 
 	return instance.pool
 }
 
+// newSuperUserConnectionPool creates the pool of the superuser connections
+// of the instance manager, going through the passed socket directory
+func newSuperUserConnectionPool(socketDir string) *pool.ConnectionPool {
+	const applicationName = "cnpg-instance-manager"
+	dsn := fmt.Sprintf(
+		"host=%s port=%v user=%v sslmode=disable application_name=%v",
+		socketDir,
+		GetServerPort(),
+		"postgres",
+		applicationName,
+	)
+
+	return pool.NewPostgresqlConnectionPool(dsn)
+}
+
 // PrimaryConnectionPool gets or initializes the primary connection pool for this instance
 func (instance *Instance) PrimaryConnectionPool() *pool.ConnectionPool {
 	if instance.primaryPool == nil {
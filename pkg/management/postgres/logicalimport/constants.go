@@ -28,10 +28,11 @@ type (
 )
 
 const (
-	pgDump           executable = "pg_dump"
-	pgRestore        executable = "pg_restore"
-	postgresDatabase            = "postgres"
-	dumpDirectory               = specs.PgDataPath + "/dumps"
+	pgDump            executable = "pg_dump"
+	pgRestore         executable = "pg_restore"
+	postgresDatabase             = "postgres"
+	dumpDirectory                = specs.PgDataPath + "/dumps"
+	dumpFileExtension            = ".dump"
 )
 
 func createDumpsDirectory() error {
@@ -39,7 +40,7 @@ func createDumpsDirectory() error {
 }
 
 func generateFileNameForDatabase(database string) string {
-	return fmt.Sprintf("%s/%s.dump", dumpDirectory, database)
+	return fmt.Sprintf("%s/%s%s", dumpDirectory, database, dumpFileExtension)
 }
 
 func cleanDumpDirectory() error {
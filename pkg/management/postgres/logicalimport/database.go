@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"os/exec"
+	"path"
+	"strconv"
 
 	"github.com/jackc/pgx/v5"
 	"k8s.io/utils/strings/slices"
@@ -32,6 +34,31 @@ import (
 
 type databaseSnapshotter struct {
 	cluster *apiv1.Cluster
+
+	// the directory containing the dumps, when they are not
+	// generated during the import
+	dumpDirectory string
+}
+
+// getDumpFileName gets the name of the file containing the
+// dump of a database
+func (ds *databaseSnapshotter) getDumpFileName(database string) string {
+	if ds.dumpDirectory != "" {
+		return path.Join(ds.dumpDirectory, database+dumpFileExtension)
+	}
+
+	return generateFileNameForDatabase(database)
+}
+
+// getRestoreJobsOptions gets the options setting the number of
+// concurrent jobs of pg_restore
+func (ds *databaseSnapshotter) getRestoreJobsOptions() []string {
+	jobs := ds.cluster.Spec.Bootstrap.InitDB.Import.Jobs
+	if jobs == nil || *jobs <= 1 {
+		return nil
+	}
+
+	return []string{"--jobs", strconv.Itoa(int(*jobs))}
 }
 
 func (ds *databaseSnapshotter) getDatabaseList(ctx context.Context, target pool.Pooler) ([]string, error) {
@@ -101,7 +128,7 @@ func (ds *databaseSnapshotter) exportDatabases(
 		dsn := target.GetDsn(database)
 		options := []string{
 			"-Fc",
-			"-f", generateFileNameForDatabase(database),
+			"-f", ds.getDumpFileName(database),
 			"-d", dsn,
 			"-v",
 		}
@@ -153,10 +180,11 @@ func (ds *databaseSnapshotter) importDatabases(
 				"-U", "postgres",
 				"-d", targetDatabase,
 				"--section", section,
-				generateFileNameForDatabase(database),
 			}
 
 			options = append(options, alwaysPresentOptions...)
+			options = append(options, ds.getRestoreJobsOptions()...)
+			options = append(options, ds.getDumpFileName(database))
 
 			contextLogger.Info("Running pg_restore",
 				"cmd", pgRestore,
@@ -211,8 +239,9 @@ func (ds *databaseSnapshotter) importDatabaseContent(
 			fmt.Sprintf("--role=%s", owner),
 			"-d", targetDatabase,
 			"--section", section,
-			generateFileNameForDatabase(database),
 		}
+		options = append(options, ds.getRestoreJobsOptions()...)
+		options = append(options, ds.getDumpFileName(database))
 
 		contextLogger.Info("Running pg_restore",
 			"cmd", pgRestore,
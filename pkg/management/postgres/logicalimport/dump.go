@@ -0,0 +1,186 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logicalimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strings"
+
+	"k8s.io/utils/strings/slices"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/execlog"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/pool"
+)
+
+const (
+	psql executable = "psql"
+
+	// globalsFileName is the name of the file containing the global
+	// objects, as generated by `pg_dumpall --globals-only`
+	globalsFileName = "globals.sql"
+)
+
+// MicroserviceFromDump executes the microservice clone type, importing
+// the database from a dump stored in the passed directory
+func MicroserviceFromDump(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	destination pool.Pooler,
+	directory string,
+) error {
+	contextLogger := log.FromContext(ctx)
+	ds := databaseSnapshotter{cluster: cluster, dumpDirectory: directory}
+	databases := cluster.Spec.Bootstrap.InitDB.Import.Databases
+	contextLogger.Info("starting microservice import from the logical dumps", "directory", directory)
+
+	if err := ds.checkDumpsExist(databases); err != nil {
+		return err
+	}
+
+	if err := ds.dropExtensionsFromDatabase(ctx, destination, cluster.Spec.Bootstrap.InitDB.Database); err != nil {
+		return err
+	}
+
+	if err := ds.importDatabaseContent(
+		ctx,
+		destination,
+		databases[0],
+		cluster.Spec.Bootstrap.InitDB.Database,
+		cluster.Spec.Bootstrap.InitDB.Owner,
+	); err != nil {
+		return err
+	}
+
+	if err := ds.executePostImportQueries(ctx, destination, cluster.Spec.Bootstrap.InitDB.Database); err != nil {
+		return err
+	}
+
+	return ds.analyze(ctx, destination, []string{cluster.Spec.Bootstrap.InitDB.Database})
+}
+
+// MonolithFromDump executes the monolith clone type, importing the
+// global objects and the databases from the dumps stored in the
+// passed directory
+func MonolithFromDump(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	destination pool.Pooler,
+	directory string,
+) error {
+	contextLogger := log.FromContext(ctx)
+	contextLogger.Info("starting monolith import from the logical dumps", "directory", directory)
+
+	ds := databaseSnapshotter{cluster: cluster, dumpDirectory: directory}
+	databases, err := ds.getDumpedDatabaseList(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := ds.checkDumpsExist(databases); err != nil {
+		return err
+	}
+
+	if err := ds.importGlobals(ctx, destination); err != nil {
+		return err
+	}
+
+	if err := ds.importDatabases(ctx, destination, databases); err != nil {
+		return err
+	}
+
+	return ds.analyze(ctx, destination, databases)
+}
+
+// getDumpedDatabaseList gets the list of the databases to be imported.
+// When a wildcard is used, every database having a dump is imported
+func (ds *databaseSnapshotter) getDumpedDatabaseList(ctx context.Context) ([]string, error) {
+	passedDatabases := ds.cluster.Spec.Bootstrap.InitDB.Import.Databases
+	if !slices.Contains(passedDatabases, "*") {
+		return passedDatabases, nil
+	}
+
+	entries, err := os.ReadDir(ds.dumpDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("while listing the logical dumps: %w", err)
+	}
+
+	var databases []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), dumpFileExtension) {
+			continue
+		}
+		databases = append(databases, strings.TrimSuffix(entry.Name(), dumpFileExtension))
+	}
+	sort.Strings(databases)
+
+	log.FromContext(ctx).Info("found the logical dumps of the databases",
+		"directory", ds.dumpDirectory,
+		"databases", databases)
+	return databases, nil
+}
+
+// checkDumpsExist checks that a dump exists for each of the
+// passed databases
+func (ds *databaseSnapshotter) checkDumpsExist(databases []string) error {
+	if len(databases) == 0 {
+		return fmt.Errorf("no logical dump found in %s", ds.dumpDirectory)
+	}
+
+	for _, database := range databases {
+		if _, err := os.Stat(ds.getDumpFileName(database)); err != nil {
+			return fmt.Errorf("missing the logical dump of database %s: %w", database, err)
+		}
+	}
+
+	return nil
+}
+
+// importGlobals restores the global objects, such as the roles,
+// contained in the globals file, if present. The errors raised by
+// the objects already existing, such as the superuser, are ignored
+func (ds *databaseSnapshotter) importGlobals(ctx context.Context, target pool.Pooler) error {
+	contextLogger := log.FromContext(ctx)
+
+	globalsFile := path.Join(ds.dumpDirectory, globalsFileName)
+	if _, err := os.Stat(globalsFile); os.IsNotExist(err) {
+		contextLogger.Info("no global objects to import", "fileName", globalsFile)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	options := []string{
+		"-U", "postgres",
+		"-d", target.GetDsn(postgresDatabase),
+		"-f", globalsFile,
+	}
+
+	contextLogger.Info("Running psql", "cmd", psql, "options", options)
+	psqlCommand := exec.Command(psql, options...) // #nosec
+	if err := execlog.RunStreaming(psqlCommand, psql); err != nil {
+		return fmt.Errorf("error while importing the global objects: %w", err)
+	}
+
+	return nil
+}
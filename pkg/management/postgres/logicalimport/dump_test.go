@@ -0,0 +1,89 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logicalimport
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("importing the logical dumps", func() {
+	var (
+		directory string
+		ds        databaseSnapshotter
+	)
+
+	BeforeEach(func() {
+		directory = GinkgoT().TempDir()
+		ds = databaseSnapshotter{
+			cluster: &apiv1.Cluster{
+				Spec: apiv1.ClusterSpec{
+					Bootstrap: &apiv1.BootstrapConfiguration{
+						InitDB: &apiv1.BootstrapInitDB{
+							Import: &apiv1.Import{
+								Type:      apiv1.MonolithSnapshotType,
+								Databases: []string{"*"},
+							},
+						},
+					},
+				},
+			},
+			dumpDirectory: directory,
+		}
+
+		for _, name := range []string{"orders.dump", "app.dump", globalsFileName} {
+			Expect(os.WriteFile(path.Join(directory, name), nil, 0o600)).To(Succeed())
+		}
+		Expect(os.Mkdir(path.Join(directory, "old.dump"), 0o700)).To(Succeed())
+	})
+
+	It("reads the dumps from the passed directory", func() {
+		Expect(ds.getDumpFileName("app")).To(Equal(path.Join(directory, "app.dump")))
+		Expect((&databaseSnapshotter{}).getDumpFileName("app")).To(Equal(generateFileNameForDatabase("app")))
+	})
+
+	It("lists the dumped databases when a wildcard is used", func() {
+		Expect(ds.getDumpedDatabaseList(context.TODO())).To(Equal([]string{"app", "orders"}))
+
+		ds.cluster.Spec.Bootstrap.InitDB.Import.Databases = []string{"orders"}
+		Expect(ds.getDumpedDatabaseList(context.TODO())).To(Equal([]string{"orders"}))
+	})
+
+	It("checks that every database has a dump", func() {
+		Expect(ds.checkDumpsExist([]string{"app", "orders"})).To(Succeed())
+		Expect(ds.checkDumpsExist([]string{"app", "missing"})).ToNot(Succeed())
+		Expect(ds.checkDumpsExist(nil)).ToNot(Succeed())
+	})
+
+	It("runs pg_restore with the requested number of jobs", func() {
+		Expect(ds.getRestoreJobsOptions()).To(BeEmpty())
+
+		ds.cluster.Spec.Bootstrap.InitDB.Import.Jobs = ptr.To(int32(1))
+		Expect(ds.getRestoreJobsOptions()).To(BeEmpty())
+
+		ds.cluster.Spec.Bootstrap.InitDB.Import.Jobs = ptr.To(int32(4))
+		Expect(ds.getRestoreJobsOptions()).To(Equal([]string{"--jobs", "4"}))
+	})
+})
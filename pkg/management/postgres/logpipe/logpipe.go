@@ -41,6 +41,7 @@ type LogPipe struct {
 	fileName        string
 	record          CSVRecordParser
 	fieldsValidator FieldsValidator
+	writer          RecordWriter
 
 	initialized *concurrency.Executed
 	exited      *concurrency.Executed
@@ -58,12 +59,21 @@ func NewLogPipe() *LogPipe {
 		fileName:        filepath.Join(postgres.LogPath, postgres.LogFileName+".csv"),
 		record:          NewPgAuditLoggingDecorator(),
 		fieldsValidator: LogFieldValidator,
+		writer:          &LogRecordWriter{},
 
 		initialized: concurrency.NewExecuted(),
 		exited:      concurrency.NewExecuted(),
 	}
 }
 
+// NewLogPipeWithRecordWriter returns a new LogPipe writing the
+// collected records to the passed RecordWriter
+func NewLogPipeWithRecordWriter(writer RecordWriter) *LogPipe {
+	p := NewLogPipe()
+	p.writer = writer
+	return p
+}
+
 // GetInitializedCondition returns the condition that can be checked in order to
 // be sure initialization has been done
 func (p *LogPipe) GetInitializedCondition() *concurrency.Executed {
@@ -147,7 +157,7 @@ func (p *LogPipe) collectLogsFromFile(ctx context.Context) error {
 	// the cancellation signal happened
 	go func() {
 		defer close(errChan)
-		errChan <- p.streamLogFromCSVFile(ctx, f, &LogRecordWriter{})
+		errChan <- p.streamLogFromCSVFile(ctx, f, p.writer)
 	}()
 	select {
 	case <-ctx.Done():
@@ -22,6 +22,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"os/exec"
@@ -31,9 +32,11 @@ import (
 	"strings"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
@@ -45,7 +48,6 @@ import (
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/archiver"
 	barmanCapabilities "github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/capabilities"
 	barmanCredentials "github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/credentials"
-	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/restorer"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/execlog"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/external"
@@ -61,8 +63,15 @@ var (
 	// ErrInstanceInRecovery is raised while PostgreSQL is still in recovery mode
 	ErrInstanceInRecovery = fmt.Errorf("instance in recovery")
 
+	// ErrInstanceReadOnly is raised while PostgreSQL, despite having exited
+	// recovery mode, is not accepting write transactions yet
+	ErrInstanceReadOnly = fmt.Errorf("instance not accepting writes")
+
 	// RetryUntilRecoveryDone is the default retry configuration that is used
 	// to wait for a restored cluster to promote itself
+	//
+	// Deprecated: the end of the recovery is waited for following the retry
+	// policy of the restore, see the retryPolicy option of the recovery
 	RetryUntilRecoveryDone = wait.Backoff{
 		Duration: 5 * time.Second,
 		// Steps is declared as an "int", so we are capping
@@ -70,6 +79,13 @@ var (
 		Steps: math.MaxInt32,
 	}
 
+	// RetryUntilWritesAccepted is the retry configuration that is used
+	// to wait for a promoted instance to accept write transactions
+	RetryUntilWritesAccepted = wait.Backoff{
+		Duration: 1 * time.Second,
+		Steps:    30,
+	}
+
 	pgControldataSettingsToParamsMap = map[string]string{
 		"max_connections setting":      "max_connections",
 		"max_wal_senders setting":      "max_wal_senders",
@@ -98,6 +114,14 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client, imm
 		return fmt.Errorf("error while cleaning up the recovered PGDATA: %w", err)
 	}
 
+	if err := info.checkTablespaceLinks(ctx); err != nil {
+		return err
+	}
+
+	if err := info.checkRestoredLocale(ctx); err != nil {
+		return err
+	}
+
 	// We're creating a new replica of an existing cluster, and the PVCs
 	// have been initialized by a set of VolumeSnapshots.
 	if immediate {
@@ -116,6 +140,10 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client, imm
 		return fmt.Errorf("missing snapshot recovery stanza in cluster .spec.bootstrap")
 	}
 
+	if err := info.checkRestoredSource(ctx, cluster); err != nil {
+		return err
+	}
+
 	// We've no WAL archive, so we can't proceed with a PITR
 	if cluster.Spec.Bootstrap.Recovery.Source == "" {
 		return nil
@@ -124,6 +152,21 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client, imm
 	contextLogger.Info("Recovering from volume snapshot",
 		"sourceName", cluster.Spec.Bootstrap.Recovery.Source)
 
+	recoveryTargetSource, err := applyRecoveryTargetAnnotation(cluster)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRecoveryTarget(cluster); err != nil {
+		return err
+	}
+	recordRecoveryTargetSource(ctx, cluster, recoveryTargetSource)
+
+	recoverySettings, err := loadRecoverySettings(ctx, cli, cluster)
+	if err != nil {
+		return err
+	}
+
 	if len(info.BackupLabelFile) > 0 {
 		filePath := filepath.Join(info.PgData, constants.BackupLabelFile)
 		if _, err := fileutils.WriteFileAtomic(filePath, info.BackupLabelFile, 0o666); err != nil {
@@ -143,6 +186,18 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client, imm
 		return err
 	}
 
+	if env, err = withBarmanHome(cluster, backup, env); err != nil {
+		return err
+	}
+	env = withRecoveryProxy(cluster, env)
+	if env, err = info.withWALDecryptionKey(ctx, cli, cluster, env); err != nil {
+		return err
+	}
+
+	if err := checkArchiveDestinationIsNotRecoverySource(ctx, cluster, backup); err != nil {
+		return err
+	}
+
 	if _, err := info.restoreCustomWalDir(ctx); err != nil {
 		return err
 	}
@@ -151,7 +206,7 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client, imm
 		return err
 	}
 
-	if cluster.IsReplica() {
+	if cluster.GetRecoveryRole() == apiv1.RecoveryRoleReplicaCluster {
 		server, ok := cluster.ExternalCluster(cluster.Spec.ReplicaCluster.Source)
 		if !ok {
 			return fmt.Errorf("missing external cluster: %v", cluster.Spec.ReplicaCluster.Source)
@@ -172,11 +227,43 @@ func (info InitInfo) RestoreSnapshot(ctx context.Context, cli client.Client, imm
 		return err
 	}
 
-	if err := info.writeRestoreWalConfig(backup, cluster); err != nil {
+	if err := info.writeRestoreWalConfig(backup, cluster, recoverySettings); err != nil {
 		return err
 	}
 
-	return info.ConfigureInstanceAfterRestore(ctx, cluster, env)
+	if err := info.writeFastRecoveryConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryWorkersConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryMemoryConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryHugePagesConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryCheckpointConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryStandbyDelayConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeZeroDamagedPagesConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoverySignal(cluster); err != nil {
+		return err
+	}
+
+	return info.ConfigureInstanceAfterRestore(ctx, cli, cluster, env)
 }
 
 // createBackupObjectForSnapshotRestore creates a fake Backup object that can be used during the
@@ -200,12 +287,7 @@ func (info InitInfo) createBackupObjectForSnapshotRestore(
 	}
 	serverName := server.GetServerName()
 
-	env, err := barmanCredentials.EnvSetRestoreCloudCredentials(
-		ctx,
-		typedClient,
-		cluster.Namespace,
-		server.BarmanObjectStore,
-		os.Environ())
+	env, err := objectStoreEnvironment(ctx, typedClient, cluster, server.BarmanObjectStore)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -227,13 +309,21 @@ func (info InitInfo) createBackupObjectForSnapshotRestore(
 	}, env, nil
 }
 
-// Restore restores a PostgreSQL cluster from a backup into the object storage
+// Restore restores a PostgreSQL cluster from a backup into the object storage.
+// The outcome is reported in the RestoreCompleted condition of the cluster
 func (info InitInfo) Restore(ctx context.Context) error {
 	typedClient, err := management.NewControllerRuntimeClient()
 	if err != nil {
 		return err
 	}
 
+	err = info.restore(ctx, typedClient)
+	info.reportRestoreOutcome(ctx, typedClient, err)
+	return err
+}
+
+// restore restores a PostgreSQL cluster from a backup
+func (info InitInfo) restore(ctx context.Context, typedClient client.Client) error {
 	cluster, err := info.loadCluster(ctx, typedClient)
 	if err != nil {
 		return err
@@ -244,11 +334,42 @@ func (info InitInfo) Restore(ctx context.Context) error {
 		return err
 	}
 
+	recoveryTargetSource, err := applyRecoveryTargetAnnotation(cluster)
+	if err != nil {
+		return err
+	}
+
+	if err := validateRecoveryTarget(cluster); err != nil {
+		return err
+	}
+	recordRecoveryTargetSource(ctx, cluster, recoveryTargetSource)
+
 	if cluster.ShouldRecoveryCreateApplicationDatabase() {
 		info.ApplicationUser = cluster.GetApplicationDatabaseOwner()
 		info.ApplicationDatabase = cluster.GetApplicationDatabaseName()
 	}
 
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil {
+		info.TablespaceRemap = cluster.Spec.Bootstrap.Recovery.TablespaceRemap
+	}
+
+	if cluster.IsArchivingKeptDisabledAfterRecovery() {
+		log.FromContext(ctx).Warning("WAL archiving will stay disabled after the promotion, "+
+			"the cluster won't write to any archive and can't be recovered to a point in time",
+			"option", "keepArchivingDisabled")
+	}
+
+	if cluster.IsCommitTimestampTrackedAfterRecovery() {
+		log.FromContext(ctx).Info("The commit timestamps will be tracked from the start of the restored "+
+			"instance. They can't be enabled retroactively: the transactions committed before the "+
+			"restore have no commit timestamp",
+			"option", "trackCommitTimestamp")
+	}
+
+	if err := info.checkRecoveryWALLevel(ctx, typedClient, cluster); err != nil {
+		return err
+	}
+
 	// Before starting the restore we check if the archive destination is safe to use
 	// otherwise, we stop creating the cluster
 	err = info.checkBackupDestination(ctx, typedClient, cluster)
@@ -256,60 +377,82 @@ func (info InitInfo) Restore(ctx context.Context) error {
 		return err
 	}
 
-	// If we need to download data from a backup, we do it
-	backup, env, err := info.loadBackup(ctx, typedClient, cluster)
+	recoverySettings, err := loadRecoverySettings(ctx, typedClient, cluster)
 	if err != nil {
 		return err
 	}
 
-	if err := info.ensureArchiveContainsLastCheckpointRedoWAL(ctx, cluster, env, backup); err != nil {
+	if err := checkMissingRecoveryTarget(ctx, cluster, recoverySettings); err != nil {
 		return err
 	}
 
-	if err := info.restoreDataDir(backup, env); err != nil {
-		return err
+	if isLocalRecovery(cluster) {
+		return info.restoreFromLocalBackup(ctx, typedClient, cluster, recoverySettings)
 	}
 
-	if _, err := info.restoreCustomWalDir(ctx); err != nil {
-		return err
+	if policy := getRecoveryDataSourcePolicy(cluster); policy != nil {
+		cloned, err := info.restoreFromRecoveryDataSource(ctx, typedClient, cluster, policy)
+		if err != nil || cloned {
+			return err
+		}
 	}
 
-	if err := info.WriteInitialPostgresqlConf(cluster); err != nil {
-		return err
+	machine := &restoreMachine{
+		info:             info,
+		typedClient:      typedClient,
+		cluster:          cluster,
+		recoverySettings: recoverySettings,
 	}
-	// we need a migration here, otherwise the server will not start up if
-	// we recover from a base which has postgresql.auto.conf
-	// the override.conf and include statement is present, what we need to do is to
-	// migrate the content
-	if _, err := info.GetInstance().migratePostgresAutoConfFile(ctx); err != nil {
-		return err
+	return machine.run(ctx)
+}
+
+// validateRecoveryTarget rejects, before starting the restore, an
+// inconsistent recovery target, such as one setting more than one of the
+// mutually exclusive targets, instead of writing an ambiguous configuration
+func validateRecoveryTarget(cluster *apiv1.Cluster) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
 	}
-	if cluster.IsReplica() {
-		server, ok := cluster.ExternalCluster(cluster.Spec.ReplicaCluster.Source)
-		if !ok {
-			return fmt.Errorf("missing external cluster: %v", cluster.Spec.ReplicaCluster.Source)
-		}
 
-		connectionString, err := external.ConfigureConnectionToServer(
-			ctx, typedClient, info.Namespace, &server)
-		if err != nil {
-			return err
-		}
+	if err := cluster.Spec.Bootstrap.Recovery.RecoveryTarget.Validate(); err != nil {
+		return fmt.Errorf("invalid recovery target: %w", err)
+	}
 
-		// TODO: Using a replication slot on replica cluster is not supported (yet?)
-		_, err = UpdateReplicaConfiguration(info.PgData, connectionString, "")
-		return err
+	return nil
+}
+
+// validateRecoveryTargetLSN rejects, before starting the restore, a target
+// LSN that cannot be reached starting from the chosen backup, as it
+// precedes its consistency point
+func validateRecoveryTargetLSN(cluster *apiv1.Cluster, backup *apiv1.Backup) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget == nil ||
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget.TargetLSN == "" {
+		return nil
 	}
 
-	if err := info.WriteRestoreHbaConf(); err != nil {
-		return err
+	targetLSN := postgresSpec.LSN(cluster.Spec.Bootstrap.Recovery.RecoveryTarget.TargetLSN)
+	if _, err := targetLSN.Parse(); err != nil {
+		return fmt.Errorf("invalid recovery target LSN: %w", err)
 	}
 
-	if err := info.writeRestoreWalConfig(backup, cluster); err != nil {
-		return err
+	// We can't say anything without knowing where the backup ended
+	if backup.Status.EndLSN == "" {
+		return nil
+	}
+
+	backupEndLSN := postgresSpec.LSN(backup.Status.EndLSN)
+	if _, err := backupEndLSN.Parse(); err != nil {
+		return nil
 	}
 
-	return info.ConfigureInstanceAfterRestore(ctx, cluster, env)
+	if targetLSN.Less(backupEndLSN) {
+		return fmt.Errorf(
+			"recovery target LSN %s precedes the consistency point %s of backup %s, and cannot be reached",
+			targetLSN, backupEndLSN, backup.Status.BackupID)
+	}
+
+	return nil
 }
 
 func (info InitInfo) ensureArchiveContainsLastCheckpointRedoWAL(
@@ -332,12 +475,27 @@ func (info InitInfo) ensureArchiveContainsLastCheckpointRedoWAL(
 		return err
 	}
 
-	rest, err := restorer.New(ctx, cluster, env, walarchive.SpoolDirectory)
+	opts, err := backupWalRestoreOptions(cluster, backup)
 	if err != nil {
 		return err
 	}
 
-	opts, err := barman.CloudWalRestoreOptions(&apiv1.BarmanObjectStoreConfiguration{
+	if err := info.barmanRunner(cluster).WALRestore(
+		ctx, cluster, env, backup.Status.BeginWal, testWALPath, opts); err != nil {
+		return fmt.Errorf("encountered an error while checking the presence of first needed WAL in the archive: %w", err)
+	}
+
+	return nil
+}
+
+// backupWalRestoreOptions builds the barman-cloud-wal-restore options
+// needed to fetch WAL files from the object store containing the backup
+func backupWalRestoreOptions(cluster *apiv1.Cluster, backup *apiv1.Backup) ([]string, error) {
+	if err := validateServerName(backup.Status.ServerName); err != nil {
+		return nil, err
+	}
+
+	options, err := barman.CloudWalRestoreOptions(&apiv1.BarmanObjectStoreConfiguration{
 		BarmanCredentials: backup.Status.BarmanCredentials,
 		EndpointCA:        backup.Status.EndpointCA,
 		EndpointURL:       backup.Status.EndpointURL,
@@ -345,14 +503,10 @@ func (info InitInfo) ensureArchiveContainsLastCheckpointRedoWAL(
 		ServerName:        backup.Status.ServerName,
 	}, cluster.Name)
 	if err != nil {
-		return err
-	}
-
-	if err := rest.Restore(backup.Status.BeginWal, testWALPath, opts); err != nil {
-		return fmt.Errorf("encountered an error while checking the presence of first needed WAL in the archive: %w", err)
+		return nil, err
 	}
 
-	return nil
+	return append(objectStoreTimeoutOptions(cluster), options...), nil
 }
 
 // restoreCustomWalDir moves the current pg_wal data to the specified custom wal dir and applies the symlink
@@ -391,13 +545,46 @@ func (info InitInfo) restoreCustomWalDir(ctx context.Context) (bool, error) {
 	return true, os.Symlink(info.PgWal, pgDataWal)
 }
 
-// restoreDataDir restores PGDATA from an existing backup
-func (info InitInfo) restoreDataDir(backup *apiv1.Backup, env []string) error {
+// restoreDataDir restores PGDATA from an existing backup, going through
+// the download cache when the cluster defines one. The temporary files
+// of barman-cloud are written in the directory requested by the user
+func (info InitInfo) restoreDataDir(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+	policy restoreRetryPolicy,
+) error {
+	env, err := withBarmanTempDirectory(ctx, cluster, env)
+	if err != nil {
+		return err
+	}
+
+	return info.restoreDataDirCached(
+		ctx, cluster, backup, env, policy, postgresSpec.RecoveryDownloadCacheDirectory)
+}
+
+// downloadDataDir downloads an existing backup into PGDATA. The main data
+// directory and the tablespaces are downloaded sequentially, as
+// barman-cloud-restore doesn't support parallelizing the download.
+// A failed download is retried as requested by the retry policy
+func (info InitInfo) downloadDataDir(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+	policy restoreRetryPolicy,
+) error {
+	if err := validateServerName(backup.Status.ServerName); err != nil {
+		return err
+	}
+
 	var options []string
 
 	if backup.Status.EndpointURL != "" {
 		options = append(options, "--endpoint-url", backup.Status.EndpointURL)
 	}
+	options = append(options, objectStoreTimeoutOptions(cluster)...)
 	options = append(options, backup.Status.DestinationPath)
 	options = append(options, backup.Status.ServerName)
 	options = append(options, backup.Status.BackupID)
@@ -407,27 +594,103 @@ func (info InitInfo) restoreDataDir(backup *apiv1.Backup, env []string) error {
 		return err
 	}
 
+	for _, remap := range info.TablespaceRemap {
+		options = append(options, "--tablespace", fmt.Sprintf("%s:%s", remap.Name, remap.Location))
+	}
+
 	options = append(options, info.PgData)
 
 	log.Info("Starting barman-cloud-restore",
 		"options", options)
 
-	cmd := exec.Command(barmanCapabilities.BarmanCloudRestore, options...) // #nosec G204
-	cmd.Env = env
-	err = execlog.RunStreaming(cmd, barmanCapabilities.BarmanCloudRestore)
-	if err != nil {
-		var exitError *exec.ExitError
-		if errors.As(err, &exitError) {
-			err = barman.UnmarshalBarmanCloudRestoreExitCode(exitError.ExitCode())
+	attempt := 0
+	startTime := time.Now()
+	if err := policy.retry(ctx, "restore the base backup", barman.IsRetriableRestoreError, func() error {
+		attempt++
+		if attempt > 1 {
+			// Start again from an empty data directory, as the failed
+			// attempt may have left some files behind
+			if err := fileutils.RemoveDirectoryContent(info.PgData); err != nil {
+				return fmt.Errorf("while cleaning up the data directory before retrying the restore: %w", err)
+			}
+			log.Info("Retrying barman-cloud-restore", "attempt", attempt)
+			startTime = time.Now()
 		}
 
-		log.Error(err, "Can't restore backup")
+		err := info.barmanRunner(cluster).Restore(ctx, options, env)
+		if err != nil {
+			log.Error(err, "Can't restore backup", "attempt", attempt)
+			info.RestoreStatus.recordError(err)
+		}
+		return err
+	}); err != nil {
 		return err
 	}
-	log.Info("Restore completed")
+	info.logRestoreThroughput(ctx, time.Since(startTime))
 	return nil
 }
 
+// stderrCollectorMaxLines is the number of lines of the standard error
+// of barman-cloud-restore that are kept to detect the cause of a failure
+const stderrCollectorMaxLines = 100
+
+// stderrCollector keeps the last lines written to the standard
+// error of a command
+type stderrCollector struct {
+	lines []string
+}
+
+// Write implements the io.Writer interface. It is invoked once per line
+func (c *stderrCollector) Write(p []byte) (int, error) {
+	c.lines = append(c.lines, string(p))
+	if len(c.lines) > stderrCollectorMaxLines {
+		c.lines = c.lines[len(c.lines)-stderrCollectorMaxLines:]
+	}
+
+	return len(p), nil
+}
+
+// String returns the collected lines
+func (c *stderrCollector) String() string {
+	return strings.Join(c.lines, "\n")
+}
+
+// commandStartError is raised when a command can't be started, as
+// opposed to the failures of a command that has been started
+type commandStartError struct {
+	err error
+}
+
+// Error implements the error interface
+func (err *commandStartError) Error() string {
+	return err.err.Error()
+}
+
+// Unwrap returns the error raised while starting the command
+func (err *commandStartError) Unwrap() error {
+	return err.err
+}
+
+// runStreamingCollectingStderr executes the command redirecting its stdout
+// and stderr to the logger, like execlog.RunStreaming, and also copies
+// its stderr into the passed collector. The errors raised while starting
+// the command are wrapped in a commandStartError
+func runStreamingCollectingStderr(cmd *exec.Cmd, cmdName string, stderr *stderrCollector) error {
+	logger := log.WithName(cmdName)
+	stdoutWriter := &execlog.LogWriter{Logger: logger.WithValues(execlog.PipeKey, execlog.StdOut)}
+	stderrWriter := io.MultiWriter(
+		&execlog.LogWriter{Logger: logger.WithValues(execlog.PipeKey, execlog.StdErr)},
+		stderr,
+	)
+
+	streamingCmd, err := execlog.RunStreamingNoWaitWithWriter(cmd, cmdName, stdoutWriter, stderrWriter)
+	if err != nil {
+		return &commandStartError{err: err}
+	}
+
+	return streamingCmd.Wait()
+}
+
 // loadCluster loads the cluster definition from the API server
 func (info InitInfo) loadCluster(ctx context.Context, typedClient client.Client) (*apiv1.Cluster, error) {
 	var cluster apiv1.Cluster
@@ -475,17 +738,12 @@ func (info InitInfo) loadBackupObjectFromExternalCluster(
 	}
 	serverName := server.GetServerName()
 
-	env, err := barmanCredentials.EnvSetRestoreCloudCredentials(
-		ctx,
-		typedClient,
-		cluster.Namespace,
-		server.BarmanObjectStore,
-		os.Environ())
+	env, err := objectStoreEnvironment(ctx, typedClient, cluster, server.BarmanObjectStore)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	backupCatalog, err := barman.GetBackupList(ctx, server.BarmanObjectStore, serverName, env)
+	backupCatalog, err := info.barmanRunner(cluster).ListBackups(ctx, server.BarmanObjectStore, serverName, env)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -507,6 +765,14 @@ func (info InitInfo) loadBackupObjectFromExternalCluster(
 
 	log.Info("Target backup found", "backup", targetBackup)
 
+	return newBackupFromBarmanBackup(server, targetBackup), env, nil
+}
+
+// newBackupFromBarmanBackup generates an in-memory Backup structure given
+// the information about a backup stored in the object store of an
+// external cluster
+func newBackupFromBarmanBackup(server apiv1.ExternalCluster, backupInfo *catalog.BarmanBackup) *apiv1.Backup {
+	serverName := server.GetServerName()
 	return &apiv1.Backup{
 		Spec: apiv1.BackupSpec{
 			Cluster: apiv1.LocalObjectReference{
@@ -519,19 +785,19 @@ func (info InitInfo) loadBackupObjectFromExternalCluster(
 			EndpointURL:       server.BarmanObjectStore.EndpointURL,
 			DestinationPath:   server.BarmanObjectStore.DestinationPath,
 			ServerName:        serverName,
-			BackupID:          targetBackup.ID,
+			BackupID:          backupInfo.ID,
 			Phase:             apiv1.BackupPhaseCompleted,
-			StartedAt:         &metav1.Time{Time: targetBackup.BeginTime},
-			StoppedAt:         &metav1.Time{Time: targetBackup.EndTime},
-			BeginWal:          targetBackup.BeginWal,
-			EndWal:            targetBackup.EndWal,
-			BeginLSN:          targetBackup.BeginLSN,
-			EndLSN:            targetBackup.EndLSN,
-			Error:             targetBackup.Error,
+			StartedAt:         &metav1.Time{Time: backupInfo.BeginTime},
+			StoppedAt:         &metav1.Time{Time: backupInfo.EndTime},
+			BeginWal:          backupInfo.BeginWal,
+			EndWal:            backupInfo.EndWal,
+			BeginLSN:          backupInfo.BeginLSN,
+			EndLSN:            backupInfo.EndLSN,
+			Error:             backupInfo.Error,
 			CommandOutput:     "",
 			CommandError:      "",
 		},
-	}, env, nil
+	}
 }
 
 // loadBackupFromReference loads a backup object and the required credentials given the backup object resource
@@ -541,26 +807,28 @@ func (info InitInfo) loadBackupFromReference(
 	cluster *apiv1.Cluster,
 ) (*apiv1.Backup, []string, error) {
 	var backup apiv1.Backup
-	err := typedClient.Get(
-		ctx,
-		client.ObjectKey{Namespace: info.Namespace, Name: cluster.Spec.Bootstrap.Recovery.Backup.Name},
-		&backup)
+	backupObjectKey := client.ObjectKey{
+		Namespace: cluster.Spec.Bootstrap.Recovery.Backup.GetNamespace(info.Namespace),
+		Name:      cluster.Spec.Bootstrap.Recovery.Backup.Name,
+	}
+	err := typedClient.Get(ctx, backupObjectKey, &backup)
+	if apierrors.IsForbidden(err) && backupObjectKey.Namespace != info.Namespace {
+		return nil, nil, fmt.Errorf(
+			"the service account %s is not allowed to get the Backup %s in namespace %s, "+
+				"a Role and a RoleBinding granting it are needed in that namespace: %w",
+			info.ClusterName, backupObjectKey.Name, backupObjectKey.Namespace, err)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
 
-	env, err := barmanCredentials.EnvSetRestoreCloudCredentials(
-		ctx,
-		typedClient,
-		cluster.Namespace,
-		&apiv1.BarmanObjectStoreConfiguration{
-			BarmanCredentials: backup.Status.BarmanCredentials,
-			EndpointCA:        backup.Status.EndpointCA,
-			EndpointURL:       backup.Status.EndpointURL,
-			DestinationPath:   backup.Status.DestinationPath,
-			ServerName:        backup.Status.ServerName,
-		},
-		os.Environ())
+	env, err := objectStoreEnvironment(ctx, typedClient, cluster, &apiv1.BarmanObjectStoreConfiguration{
+		BarmanCredentials: backup.Status.BarmanCredentials,
+		EndpointCA:        backup.Status.EndpointCA,
+		EndpointURL:       backup.Status.EndpointURL,
+		DestinationPath:   backup.Status.DestinationPath,
+		ServerName:        backup.Status.ServerName,
+	})
 	if err != nil {
 		return nil, nil, err
 	}
@@ -571,32 +839,131 @@ func (info InitInfo) loadBackupFromReference(
 
 // writeRestoreWalConfig writes a `custom.conf` allowing PostgreSQL
 // to complete the WAL recovery from the object storage and then start
-// as a new primary
-func (info InitInfo) writeRestoreWalConfig(backup *apiv1.Backup, cluster *apiv1.Cluster) error {
-	var err error
+// as a new primary, or to keep replaying the archived WAL files as a
+// continuous standby. The recovery settings supplied by the user are
+// appended, unless they conflict with the generated ones
+func (info InitInfo) writeRestoreWalConfig(
+	backup *apiv1.Backup,
+	cluster *apiv1.Cluster,
+	recoverySettings map[string]string,
+) error {
+	if err := checkWALRestoreBinary(barmanCapabilities.BarmanCloudWalRestore); err != nil {
+		return err
+	}
+
+	cmd, err := walFetchCommand(backup, cluster)
+	if err != nil {
+		return err
+	}
+	if cmd, err = info.withReplayThrottle(cmd, cluster); err != nil {
+		return err
+	}
+
+	if cluster.GetRecoveryRole() == apiv1.RecoveryRoleStandby {
+		// A continuous standby has no recovery target, and the ones
+		// coming from the recovery settings are ignored
+		recoveryFileContents := fmt.Sprintf(
+			"restore_command = '%s'\n%s",
+			cmd.render(),
+			renderRecoverySettings(recoverySettings, true))
+		return info.writeRecoveryConfiguration(cluster, recoveryFileContents)
+	}
+
+	recoveryTargetOptions, err := info.renderRecoveryTarget(cluster)
+	if err != nil {
+		return err
+	}
+
+	recoveryFileContents := fmt.Sprintf(
+		"recovery_target_action = %s\n"+
+			"restore_command = '%s'\n"+
+			"%s%s",
+		recoveryTargetAction(cluster),
+		cmd.render(),
+		recoveryTargetOptions,
+		renderRecoverySettings(recoverySettings, recoveryTargetOptions != ""))
+
+	return info.writeRecoveryConfiguration(cluster, recoveryFileContents)
+}
+
+// walFetchCommand builds the command fetching a WAL file from the object
+// store containing the backup, decrypting it and obtaining fresh credentials
+// when requested. It contains the `%f` and `%p` placeholders of the
+// restore_command
+func walFetchCommand(backup *apiv1.Backup, cluster *apiv1.Cluster) (restoreCommand, error) {
+	if err := validateServerName(backup.Status.ServerName); err != nil {
+		return restoreCommand{}, err
+	}
 
 	cmd := []string{barmanCapabilities.BarmanCloudWalRestore}
 	if backup.Status.EndpointURL != "" {
 		cmd = append(cmd, "--endpoint-url", backup.Status.EndpointURL)
 	}
+	cmd = append(cmd, objectStoreTimeoutOptions(cluster)...)
 	cmd = append(cmd, backup.Status.DestinationPath)
 	cmd = append(cmd, backup.Status.ServerName)
 
-	cmd, err = barman.AppendCloudProviderOptionsFromBackup(cmd, backup)
+	cmd, err := barman.AppendCloudProviderOptionsFromBackup(cmd, backup)
 	if err != nil {
-		return err
+		return restoreCommand{}, err
+	}
+
+	return restoreCommand{fetch: cmd, arguments: walFetchArguments}.
+		withWALFetchTimeout(cluster).
+		withWALFetchRetries(cluster).
+		withWALDecryption(cluster).
+		withCredentialsProvider(cluster), nil
+}
+
+// renderRecoveryTarget generates the configuration implementing the
+// recovery target requested by the user, if any
+func (info InitInfo) renderRecoveryTarget(cluster *apiv1.Cluster) (string, error) {
+	recoveryTarget := cluster.Spec.Bootstrap.Recovery.RecoveryTarget
+	if recoveryTarget != nil && recoveryTarget.TargetWAL != "" {
+		walSegmentSize, err := info.getWALSegmentSize()
+		if err != nil {
+			return "", err
+		}
+
+		if recoveryTarget, err = recoveryTarget.ResolveTargetWAL(walSegmentSize); err != nil {
+			return "", err
+		}
+	}
+
+	if recoveryTarget != nil && ptr.Deref(recoveryTarget.TargetBackupEnd, false) {
+		resolved, err := recoveryTarget.ResolveTargetBackupEnd(info.BackupEndLSN)
+		if err != nil {
+			return "", err
+		}
+		recoveryTarget = resolved
+
+		log.Info("The recovery will stop at the end of the backup",
+			"backupID", recoveryTarget.BackupID,
+			"targetLSN", recoveryTarget.TargetLSN)
 	}
 
-	cmd = append(cmd, "%f", "%p")
+	return recoveryTarget.Render()
+}
 
-	recoveryFileContents := fmt.Sprintf(
-		"recovery_target_action = promote\n"+
-			"restore_command = '%s'\n"+
-			"%s",
-		strings.Join(cmd, " "),
-		cluster.Spec.Bootstrap.Recovery.RecoveryTarget.BuildPostgresOptions())
+const (
+	// recoveryConfFile is both the configuration and the
+	// signal of the recovery, before PostgreSQL 12
+	recoveryConfFile = "recovery.conf"
 
-	return info.writeRecoveryConfiguration(cluster, recoveryFileContents)
+	// stagedRecoveryConfFile is the recovery.conf being prepared,
+	// before every recovery setting has been written
+	stagedRecoveryConfFile = "recovery.conf.staged"
+)
+
+// recoverySignalFile gets the signal file making PostgreSQL recover
+// the restored backup: a continuous standby is started in standby mode,
+// while otherwise a targeted recovery is executed
+func recoverySignalFile(cluster *apiv1.Cluster) string {
+	if cluster.GetRecoveryRole() == apiv1.RecoveryRoleStandby {
+		return "standby.signal"
+	}
+
+	return "recovery.signal"
 }
 
 func (info InitInfo) writeRecoveryConfiguration(cluster *apiv1.Cluster, recoveryFileContents string) error {
@@ -662,39 +1029,106 @@ func (info InitInfo) writeRecoveryConfiguration(cluster *apiv1.Cluster, recovery
 	}
 
 	if major >= 12 {
-		// Append restore_command to the end of the
-		// custom configs file
-		err = fileutils.AppendStringToFile(
-			path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile),
-			recoveryFileContents)
-		if err != nil {
-			return fmt.Errorf("cannot write recovery config: %w", err)
-		}
-
-		err = os.WriteFile(
-			path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile),
-			[]byte(""),
-			0o600)
-		if err != nil {
-			return fmt.Errorf("cannot erase auto config: %w", err)
-		}
+		return persistRecoveryConfiguration(info.PgData, recoverySignalFile(cluster), recoveryFileContents)
+	}
 
-		// Create recovery signal file
-		return os.WriteFile(
-			path.Join(info.PgData, "recovery.signal"),
-			[]byte(""),
-			0o600)
+	if cluster.GetRecoveryRole() == apiv1.RecoveryRoleStandby {
+		recoveryFileContents = "standby_mode = 'on'\n" + recoveryFileContents
 	}
 
-	// We need to generate a recovery.conf
-	return os.WriteFile(
-		path.Join(info.PgData, "recovery.conf"),
+	// We need to generate a recovery.conf, which is both the signal and
+	// the configuration of the recovery. It is staged, and installed by
+	// writeRecoverySignal once every recovery setting has been written
+	if err := fileutils.RemoveFile(path.Join(info.PgData, recoveryConfFile)); err != nil {
+		return fmt.Errorf("cannot remove the stale %s: %w", recoveryConfFile, err)
+	}
+	if _, err := fileutils.WriteFileAtomic(
+		path.Join(info.PgData, stagedRecoveryConfFile),
 		[]byte(recoveryFileContents),
-		0o600)
+		0o600); err != nil {
+		return fmt.Errorf("cannot write recovery config: %w", err)
+	}
+
+	return fileutils.SyncFile(info.PgData)
 }
 
-// LoadEnforcedParametersFromPgControldata will parse the output of pg_controldata in order to get
-// the values of all the hot standby sensible parameters
+// persistRecoveryConfiguration writes the configuration making PostgreSQL
+// 12 or later recover the restored backup with the passed settings. A stale
+// signal file is removed first, then the settings are appended to the custom
+// configuration and postgresql.auto.conf is emptied. The signal file is
+// created by writeRecoverySignal, once every recovery setting is written
+func persistRecoveryConfiguration(pgData, signalFile, recoveryFileContents string) error {
+	signalPath := path.Join(pgData, signalFile)
+	customConfPath := path.Join(pgData, constants.PostgresqlCustomConfigurationFile)
+	autoConfPath := path.Join(pgData, constants.PostgresqlOverrideConfigurationFile)
+
+	if err := fileutils.RemoveFile(signalPath); err != nil {
+		return fmt.Errorf("cannot remove the stale %s: %w", signalFile, err)
+	}
+	if err := fileutils.SyncFile(pgData); err != nil {
+		return fmt.Errorf("cannot flush the data directory: %w", err)
+	}
+
+	// Append restore_command to the end of the
+	// custom configs file
+	if err := fileutils.AppendStringToFile(customConfPath, recoveryFileContents); err != nil {
+		return fmt.Errorf("cannot write recovery config: %w", err)
+	}
+
+	if err := os.WriteFile(autoConfPath, []byte(""), 0o600); err != nil {
+		return fmt.Errorf("cannot erase auto config: %w", err)
+	}
+
+	return nil
+}
+
+// writeRecoverySignal makes PostgreSQL recover the restored backup once it
+// is started. This is the last step writing the configuration of the
+// recovery, so that PostgreSQL never finds the signal without every recovery
+// setting: the configuration files and the entries of PGDATA are flushed to
+// disk, then the signal file is created and flushed too. Before PostgreSQL
+// 12, the staged recovery.conf, being both the signal and the configuration,
+// is renamed into place
+func (info InitInfo) writeRecoverySignal(cluster *apiv1.Cluster) error {
+	major, err := postgresutils.GetMajorVersion(info.PgData)
+	if err != nil {
+		return fmt.Errorf("cannot detect major version: %w", err)
+	}
+
+	if major < 12 {
+		if err := os.Rename(
+			path.Join(info.PgData, stagedRecoveryConfFile),
+			path.Join(info.PgData, recoveryConfFile)); err != nil {
+			return fmt.Errorf("cannot install %s: %w", recoveryConfFile, err)
+		}
+
+		return fileutils.SyncFile(info.PgData)
+	}
+
+	for _, name := range []string{
+		path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile),
+		path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile),
+		info.PgData,
+	} {
+		if err := fileutils.SyncFile(name); err != nil {
+			return fmt.Errorf("cannot flush the recovery config: %w", err)
+		}
+	}
+
+	signalFile := recoverySignalFile(cluster)
+	signalPath := path.Join(info.PgData, signalFile)
+	if err := os.WriteFile(signalPath, []byte(""), 0o600); err != nil {
+		return fmt.Errorf("cannot create %s: %w", signalFile, err)
+	}
+	if err := fileutils.SyncFile(signalPath); err != nil {
+		return fmt.Errorf("cannot flush %s: %w", signalFile, err)
+	}
+
+	return fileutils.SyncFile(info.PgData)
+}
+
+// LoadEnforcedParametersFromPgControldata will parse the output of pg_controldata in order to get
+// the values of all the hot standby sensible parameters
 func LoadEnforcedParametersFromPgControldata(pgData string) (map[string]int, error) {
 	var stdoutBuffer bytes.Buffer
 	var stderrBuffer bytes.Buffer
@@ -756,127 +1190,475 @@ func LoadEnforcedParametersFromCluster(
 }
 
 // WriteInitialPostgresqlConf resets the postgresql.conf that there is in the instance using
-// a new bootstrapped instance as reference
+// a new bootstrapped instance as reference. The configuration is generated from
+// the Cluster spec only, so no other object needs to exist in the API server
 func (info InitInfo) WriteInitialPostgresqlConf(cluster *apiv1.Cluster) error {
-	if err := fileutils.EnsureDirectoryExists(postgresSpec.RecoveryTemporaryDirectory); err != nil {
+	conf, err := info.prepareInitialPostgresqlConf(cluster)
+	if err != nil {
+		return err
+	}
+
+	return conf.install(info.PgData)
+}
+
+// WriteRestoreHbaConf writes basic pg_hba.conf and pg_ident.conf allowing access without password from localhost.
+// This is needed to set the PostgreSQL password after the postgres server is started and active
+func (info InitInfo) WriteRestoreHbaConf() error {
+	// We allow every access from localhost, and this is needed to correctly restore
+	// the database
+	_, err := fileutils.WriteStringToFile(
+		path.Join(info.PgData, constants.PostgresqlHBARulesFile),
+		"local all all peer map=local\n")
+	if err != nil {
 		return err
 	}
 
-	tempDataDir, err := os.MkdirTemp(postgresSpec.RecoveryTemporaryDirectory, "datadir_")
+	// Create only the local map referred in the HBA configuration
+	_, err = info.GetInstance().RefreshPGIdent(nil)
+	return err
+}
+
+// ConfigureInstanceAfterRestore changes the superuser password
+// of the instance to be coherent with the one specified in the
+// cluster. This function also ensures that we can really connect
+// to this cluster using the password in the secrets
+func (info InitInfo) ConfigureInstanceAfterRestore(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	env []string,
+) error {
+	if err := info.sweepRestoredPgData(ctx, cluster); err != nil {
+		return err
+	}
+
+	shutDown, err := info.waitForRestoredInstanceRecovery(ctx, cluster, env)
 	if err != nil {
-		return fmt.Errorf("while creating a temporary data directory: %w", err)
+		return err
 	}
-	defer func() {
-		err = os.RemoveAll(tempDataDir)
-		if err != nil {
-			log.Error(
-				err,
-				"skipping error while deleting temporary data directory")
+
+	if shutDown {
+		if err := info.configureInstanceShutDownAtTarget(ctx, cluster); err != nil {
+			return err
 		}
-	}()
+	} else if err := info.configureRestoredInstance(ctx, cluster, env); err != nil {
+		return err
+	}
 
-	temporaryInitInfo := InitInfo{
-		PgData:    tempDataDir,
-		Temporary: true,
+	if err := info.finalizeRestoreHbaConf(ctx, typedClient, cluster); err != nil {
+		return err
 	}
 
-	if err = temporaryInitInfo.CreateDataDirectory(); err != nil {
-		return fmt.Errorf("while creating a temporary data directory: %w", err)
+	if err := info.removeRestoreMarker(); err != nil {
+		return fmt.Errorf("while removing the restore marker: %w", err)
 	}
 
-	temporaryInstance := temporaryInitInfo.GetInstance()
-	temporaryInstance.Namespace = info.Namespace
-	temporaryInstance.ClusterName = info.ClusterName
+	return nil
+}
+
+// waitForRestoredInstanceRecovery starts the restored instance and waits
+// for the end of the recovery, checking how the recovery went. The returned
+// value tells if PostgreSQL has been left shut down at the recovery target,
+// as requested by the user, instead of being promoted
+func (info InitInfo) waitForRestoredInstanceRecovery(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	env []string,
+) (bool, error) {
+	contextLogger := log.FromContext(ctx)
+
+	instance := info.GetInstance()
+	instance.Env = env
 
-	_, err = temporaryInstance.RefreshPGHBA(cluster, "")
-	if err != nil {
-		return fmt.Errorf("while generating pg_hba.conf: %w", err)
+	if err := instance.VerifyPgDataCoherence(ctx); err != nil {
+		contextLogger.Error(err, "while ensuring pgData coherence")
+		return false, err
 	}
-	_, err = temporaryInstance.RefreshPGIdent(cluster.Spec.PostgresConfiguration.PgIdent)
-	if err != nil {
-		return fmt.Errorf("while generating pg_ident.conf: %w", err)
+
+	if err := info.relaxSynchronousReplication(ctx, cluster); err != nil {
+		return false, err
 	}
-	_, err = temporaryInstance.RefreshConfigurationFilesFromCluster(cluster, false)
-	if err != nil {
-		return fmt.Errorf("while generating Postgres configuration: %w", err)
+
+	if err := info.validatePostgresConfiguration(ctx, env); err != nil {
+		return false, err
 	}
 
-	err = fileutils.CopyFile(
-		path.Join(temporaryInitInfo.PgData, "postgresql.conf"),
-		path.Join(info.PgData, "postgresql.conf"))
-	if err != nil {
-		return fmt.Errorf("while installing postgresql.conf: %w", err)
+	var zeroedPages *zeroedPagesCollector
+	if isZeroDamagedPagesRecovery(cluster) {
+		zeroedPages = newZeroedPagesCollector()
+		instance.LogRecordWriter = zeroedPages
 	}
 
-	err = fileutils.CopyFile(
-		path.Join(temporaryInitInfo.PgData, constants.PostgresqlCustomConfigurationFile),
-		path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
-	if err != nil {
-		return fmt.Errorf("while installing %v: %w", constants.PostgresqlCustomConfigurationFile, err)
+	var recoveryTarget *recoveryTargetCollector
+	var reachedLSN, reachedTime string
+	var restoreResult *apiv1.RestoreResult
+	if getRequestedRecoveryTarget(cluster) != nil {
+		recoveryTarget = newRecoveryTargetCollector(instance.LogRecordWriter)
+		instance.LogRecordWriter = recoveryTarget
 	}
 
-	err = fileutils.CopyFile(
-		path.Join(temporaryInitInfo.PgData, constants.PostgresqlOverrideConfigurationFile),
-		path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile))
+	var shutdown *recoveryShutdownCollector
+	if isShutdownAtTarget(cluster) {
+		shutdown = newRecoveryShutdownCollector(instance.LogRecordWriter)
+		instance.LogRecordWriter = shutdown
+	}
+
+	logProbe, err := newRecoveryLogProbe(instance.LogRecordWriter, getRecoveryLogProbe(cluster))
 	if err != nil {
-		return fmt.Errorf("while installing %v: %w", constants.PostgresqlOverrideConfigurationFile, err)
+		return false, err
+	}
+	if logProbe != nil {
+		instance.LogRecordWriter = logProbe
 	}
 
-	// Disable SSL as we still don't have the required certificates
-	err = fileutils.AppendStringToFile(
-		path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile),
-		"ssl = 'off'\n")
+	progress, err := info.newRecoveryReplayProgress(cluster)
 	if err != nil {
-		return fmt.Errorf("cannot write recovery config: %w", err)
+		return false, err
 	}
 
-	return err
-}
+	promotion := newPromotionFailureCollector(instance.LogRecordWriter)
+	instance.LogRecordWriter = promotion
 
-// WriteRestoreHbaConf writes basic pg_hba.conf and pg_ident.conf allowing access without password from localhost.
-// This is needed to set the PostgreSQL password after the postgres server is started and active
-func (info InitInfo) WriteRestoreHbaConf() error {
-	// We allow every access from localhost, and this is needed to correctly restore
-	// the database
-	_, err := fileutils.WriteStringToFile(
-		path.Join(info.PgData, constants.PostgresqlHBARulesFile),
-		"local all all peer map=local\n")
+	replayWAL := func() error {
+		db, err := instance.GetSuperUserDB()
+		if err != nil {
+			return err
+		}
+
+		if pause := getRecoveryPause(cluster); pause != nil {
+			isPromotionRequested, err := info.recoveryPromotionChecker()
+			if err != nil {
+				return err
+			}
+			verify, err := info.recoveryVerifier(cluster, instance)
+			if err != nil {
+				return err
+			}
+			if err := waitForRecoveryPromotion(
+				ctx, db, pause.GetTimeout(), isPromotionRequested, verify, recoveryPausePollInterval); err != nil {
+				return fmt.Errorf("while waiting for the promotion of the paused recovery: %w", err)
+			}
+		}
+
+		// Wait until we exit from recovery mode
+		err = waitUntilRecoveryFinishes(
+			ctx, db, getRestoreRetryPolicy(cluster), progress, promotion, shutdown, logProbe)
+		if errors.Is(err, errShutdownAtRecoveryTarget) {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("while waiting for PostgreSQL to stop recovery mode: %w", err)
+		}
+		logProbe.stop()
+
+		if progress != nil {
+			if restoreResult, err = progress.complete(ctx, db); err != nil {
+				return err
+			}
+		}
+
+		if recoveryTarget != nil {
+			if reachedLSN, reachedTime, err = getRecoveryReachedPoint(ctx, db); err != nil {
+				return err
+			}
+		}
+
+		if err := info.waitUntilRestoreReady(ctx, getRecoveryReadinessCheck(cluster),
+			getRestoreRetryPolicy(cluster), instance.ConnectionPool().Connection); err != nil {
+			return err
+		}
+
+		if isExplicitRecoveryCheckpoint(cluster) {
+			return executeRecoveryCheckpoint(ctx, db)
+		}
+
+		return nil
+	}
+
+	// This will start the recovery of WALs taken during the backup
+	// and, after that, the server will start in a new timeline
+	err = retryFailedPromotion(ctx, getRecoveryPromotionRetry(cluster), promotion, func() error {
+		return instance.WithActiveInstance(replayWAL)
+	})
+	// PostgreSQL stops once it logs a fatal error, so the error raised
+	// while starting it or waiting for it is usually a connection error
+	if probeErr := logProbe.probeError(); probeErr != nil && err != nil && !errors.Is(err, ErrRecoveryLogProbe) {
+		err = fmt.Errorf("%w (%v)", probeErr, err)
+	}
+	// PostgreSQL may also shut down at the recovery target before
+	// the start of the instance is completed
+	shutDown := errors.Is(err, errShutdownAtRecoveryTarget) || (err != nil && shutdown.hasShutDown())
+	if shutDown {
+		contextLogger.Info("PostgreSQL has been shut down at the recovery target, as requested")
+		err = nil
+	}
 	if err != nil {
-		return err
+		if recoveryTarget != nil && recoveryTarget.hasEndedBeforeTarget() {
+			return false, fmt.Errorf("%w: %v", ErrRecoveryTargetNotReached, err)
+		}
+		return false, err
 	}
+	instance.LogRecordWriter = nil
 
-	// Create only the local map referred in the HBA configuration
-	_, err = info.GetInstance().RefreshPGIdent(nil)
-	return err
+	if zeroedPages != nil {
+		if err := info.completeZeroDamagedPagesRecovery(ctx, zeroedPages); err != nil {
+			return false, err
+		}
+	}
+
+	var targetReport *apiv1.RecoveryTargetReport
+	if recoveryTarget != nil {
+		targetReport = newRecoveryTargetReport(
+			getRequestedRecoveryTarget(cluster), recoveryTarget, reachedLSN, reachedTime)
+		targetReport.ShutDown = shutDown
+		if restoreResult != nil {
+			setRestoreResultTarget(restoreResult, targetReport)
+		}
+	}
+
+	if restoreResult != nil {
+		if err := info.completeRestoreResult(ctx, restoreResult); err != nil {
+			return false, err
+		}
+	}
+
+	if targetReport != nil {
+		if err := info.completeRecoveryTargetCheck(ctx, cluster, targetReport); err != nil {
+			return false, err
+		}
+	}
+
+	return shutDown, nil
 }
 
-// ConfigureInstanceAfterRestore changes the superuser password
-// of the instance to be coherent with the one specified in the
-// cluster. This function also ensures that we can really connect
-// to this cluster using the password in the secrets
-func (info InitInfo) ConfigureInstanceAfterRestore(ctx context.Context, cluster *apiv1.Cluster, env []string) error {
+// configureRestoredInstance configures the instance once the recovery is
+// completed, creating the application database and user, and executing
+// the operations requested by the user on the restored data
+func (info InitInfo) configureRestoredInstance(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	env []string,
+) (err error) {
 	contextLogger := log.FromContext(ctx)
 
 	instance := info.GetInstance()
 	instance.Env = env
 
-	if err := instance.VerifyPgDataCoherence(ctx); err != nil {
-		contextLogger.Error(err, "while ensuring pgData coherence")
+	if err := info.configureRestoredReplication(ctx, cluster); err != nil {
 		return err
 	}
 
-	// This will start the recovery of WALs taken during the backup
-	// and, after that, the server will start in a new timeline
+	if err := info.restoreDurabilityAfterFastRecovery(ctx, cluster); err != nil {
+		return err
+	}
+
+	if isSchemaOnlyRecovery(cluster) && !cluster.IsReplica() {
+		contextLogger.Info("Schema-only recovery requested, removing the content of the user tables")
+		if err := instance.WithActiveInstance(func() error {
+			if err := useRestoredSocketDirectory(ctx, instance); err != nil {
+				return err
+			}
+
+			db, err := instance.GetSuperUserDB()
+			if err != nil {
+				return err
+			}
+
+			if err := waitUntilInstanceAcceptsWrites(db); err != nil {
+				return fmt.Errorf("while waiting for PostgreSQL to accept writes: %w", err)
+			}
+
+			return removeUserData(ctx, instance.ConnectionPool().Connection)
+		}); err != nil {
+			return fmt.Errorf("while removing user data for schema-only recovery: %w", err)
+		}
+	}
+
+	configureNewInstance := info.ApplicationUser != "" && info.ApplicationDatabase != ""
+	if !configureNewInstance {
+		log.Debug("configure new instance not ran, cluster is running in replica mode or missing user or database")
+	}
+
+	passwordResets, err := info.loadPasswordResets(ctx, cluster)
+	if err != nil {
+		return err
+	}
+
+	// A replica cluster is read-only, and neither its indexes can be
+	// rebuilt nor its extensions updated
+	checkCollations := !cluster.IsReplica()
+	checkExtensions := !cluster.IsReplica()
+
+	smokeTest := getRecoverySmokeTest(cluster)
+	allowedDatabases := getRecoveryAllowedDatabases(cluster)
+	logicalExport := getRecoveryLogicalExport(cluster)
+	promotionSlot := getRecoveryPromotionSlot(cluster)
+	sequenceAdvance := getRecoverySequenceAdvance(cluster)
+	statStatementsReset := getRecoveryStatStatementsReset(cluster)
+	catalogSummary := getRecoveryCatalogSummary(cluster)
+	freeze := getRecoveryFreeze(cluster)
+	preparedTransactions := getRecoveryPreparedTransactions(cluster)
+	connectionRamp := cluster.GetConnectionRamp()
+	subscriptionsPolicy := apiv1.RecoverySubscriptionsPolicyKeep
+	if !cluster.IsReplica() {
+		subscriptionsPolicy = getRecoverySubscriptionsPolicy(cluster)
+	}
+	needsWrites := checkCollations || checkExtensions || configureNewInstance || len(passwordResets) > 0 ||
+		smokeTest != nil || len(allowedDatabases) > 0 || logicalExport != nil || sequenceAdvance != nil ||
+		statStatementsReset != nil || catalogSummary != nil || freeze != nil || preparedTransactions != nil ||
+		connectionRamp != nil || subscriptionsPolicy != apiv1.RecoverySubscriptionsPolicyKeep
+	if !needsWrites && promotionSlot == "" {
+		return info.restoreParametersAfterRecovery(ctx, cluster)
+	}
+
+	// Autovacuum is enabled again even when one of the
+	// post-restore operations fails
+	autovacuumDisabled, err := info.disableRestoreAutovacuum(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if autovacuumDisabled {
+		disabledSince := time.Now()
+		defer func() {
+			if restoreErr := info.restoreAutovacuumAfterRestore(ctx, cluster, disabledSince); restoreErr != nil {
+				if err == nil {
+					err = restoreErr
+				} else {
+					contextLogger.Error(restoreErr, "Cannot restore the autovacuum configuration")
+				}
+			}
+		}()
+	}
+
+	// Create the promotion replication slot, disable or drop the inherited
+	// subscriptions, summarize the restored catalog, detect the prepared
+	// transactions, check the collations and the extensions of the
+	// restored databases, configure the application database information
+	// for restored instance, reset the passwords requested by the user,
+	// advance the sequences, check the restored data, export it, freeze the
+	// oldest tables, reset the statistics of pg_stat_statements, lock the
+	// databases not allowed by the user and lower the connection limits
+	// for the warm-up
 	if err := instance.WithActiveInstance(func() error {
+		// The connections go through the socket directory of the running
+		// instance, which may not be the default one
+		if err := useRestoredSocketDirectory(ctx, instance); err != nil {
+			return err
+		}
+
 		db, err := instance.GetSuperUserDB()
 		if err != nil {
 			return err
 		}
 
-		// Wait until we exit from recovery mode
-		err = waitUntilRecoveryFinishes(db)
-		if err != nil {
-			return fmt.Errorf("while waiting for PostgreSQL to stop recovery mode: %w", err)
+		// The slot is created first, as this doesn't require the
+		// instance to be promoted
+		if err := createPromotionSlot(ctx, db, promotionSlot); err != nil {
+			return err
+		}
+
+		if !needsWrites {
+			return nil
+		}
+
+		if err := waitUntilInstanceAcceptsWrites(db); err != nil {
+			return fmt.Errorf("while waiting for PostgreSQL to accept writes: %w", err)
+		}
+
+		// The subscriptions are handled as soon as possible, as their
+		// workers connect to the publishers once the instance is promoted
+		if err := handleRestoredSubscriptions(
+			ctx, subscriptionsPolicy, db, instance.ConnectionPool().Connection); err != nil {
+			return err
+		}
+
+		// The catalog is summarized before the other operations,
+		// which may create databases and roles
+		if err := info.checkRestoredCatalogSummary(
+			ctx, catalogSummary, instance.ConnectionPool().Connection); err != nil {
+			return err
+		}
+
+		// The prepared transactions hold locks which can block
+		// the following operations
+		if err := info.handleRestoredPreparedTransactions(
+			ctx, cluster, preparedTransactions, db, instance.ConnectionPool().Connection); err != nil {
+			return err
+		}
+
+		if checkCollations {
+			if err := info.checkRestoredCollations(ctx, cluster, instance); err != nil {
+				return err
+			}
+		}
+
+		if checkExtensions {
+			if err := info.reconcileRestoredExtensions(ctx, cluster, instance); err != nil {
+				return err
+			}
+		}
+
+		// Even after the promotion, the instance can briefly refuse
+		// the writes, which are retried
+		if configureNewInstance {
+			if err := retryPostRecoveryWrite(ctx, "configureNewInstance", func() error {
+				return info.ConfigureNewInstance(instance)
+			}); err != nil {
+				return fmt.Errorf("while configuring restored instance: %w", err)
+			}
+		}
+
+		if err := retryPostRecoveryWrite(ctx, "passwordResets", func() error {
+			return applyPasswordResets(ctx, db, passwordResets)
+		}); err != nil {
+			return err
+		}
+
+		if !cluster.IsReplica() {
+			if err := retryPostRecoveryWrite(ctx, "sequenceAdvance", func() error {
+				return advanceRestoredSequences(ctx, sequenceAdvance, instance)
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := info.runRecoverySmokeTest(ctx, smokeTest, instance.ConnectionPool().Connection); err != nil {
+			return err
+		}
+
+		if !cluster.IsReplica() {
+			if err := info.exportRestoredData(ctx, logicalExport, instance.ConnectionPool().GetDsn, env); err != nil {
+				return err
+			}
+
+			if err := freezeRestoredTables(ctx, freeze, instance); err != nil {
+				return err
+			}
+
+			// The statistics are reset once the other operations
+			// are completed, as they execute statements too
+			if err := resetRestoredStatStatements(ctx, statStatementsReset, instance); err != nil {
+				return err
+			}
+		}
+
+		// The databases are locked as the last step, as the previous
+		// ones may need to connect to them
+		if len(allowedDatabases) > 0 && !cluster.IsReplica() {
+			if err := retryPostRecoveryWrite(ctx, "allowedDatabases", func() error {
+				_, err := lockRestoredDatabases(ctx, db, allowedDatabases)
+				return err
+			}); err != nil {
+				return err
+			}
+		}
+
+		// The connection limits are lowered once the databases are
+		// locked, so that only the connectable ones are changed
+		if connectionRamp != nil && !cluster.IsReplica() {
+			if err := info.startConnectionRamp(ctx, db, connectionRamp); err != nil {
+				return err
+			}
 		}
 
 		return nil
@@ -884,25 +1666,29 @@ func (info InitInfo) ConfigureInstanceAfterRestore(ctx context.Context, cluster
 		return err
 	}
 
-	primaryConnInfo := info.GetPrimaryConnInfo()
-	slotName := cluster.GetSlotNameFromInstanceName(info.PodName)
-	if _, err := configurePostgresOverrideConfFile(info.PgData, primaryConnInfo, slotName); err != nil {
-		return fmt.Errorf("while configuring replica: %w", err)
+	return info.restoreParametersAfterRecovery(ctx, cluster)
+}
+
+// restoreParametersAfterRecovery sets the parameters tuned for the
+// recovery back to the values of the cluster configuration
+func (info InitInfo) restoreParametersAfterRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	if err := info.restoreWorkersAfterRecovery(ctx, cluster); err != nil {
+		return err
 	}
 
-	if info.ApplicationUser == "" || info.ApplicationDatabase == "" {
-		log.Debug("configure new instance not ran, cluster is running in replica mode or missing user or database")
-		return nil
+	if err := info.restoreMemoryAfterRecovery(ctx, cluster); err != nil {
+		return err
 	}
 
-	// Configure the application database information for restored instance
-	return instance.WithActiveInstance(func() error {
-		if err := info.ConfigureNewInstance(instance); err != nil {
-			return fmt.Errorf("while configuring restored instance: %w", err)
-		}
+	if err := info.restoreHugePagesAfterRecovery(ctx, cluster); err != nil {
+		return err
+	}
 
-		return nil
-	})
+	if err := info.restoreCheckpointAfterRecovery(ctx, cluster); err != nil {
+		return err
+	}
+
+	return info.restoreStandbyDelayAfterRecovery(ctx, cluster)
 }
 
 // GetPrimaryConnInfo returns the DSN to reach the primary
@@ -953,29 +1739,96 @@ func (info *InitInfo) checkBackupDestination(
 	return nil
 }
 
-// waitUntilRecoveryFinishes periodically checks the underlying
-// PostgreSQL connection and returns only when the recovery
-// mode is finished
-func waitUntilRecoveryFinishes(db *sql.DB) error {
+// waitUntilRecoveryFinishes waits for PostgreSQL to exit recovery mode
+// and to be ready to accept write transactions. The end of the recovery
+// is checked with the backoff of the retry policy, without limiting
+// the number of attempts. When passed, the progress of the WAL replay
+// is updated at every check. When passed, the promotion failures reported
+// by PostgreSQL stop the wait, distinguishing an instance that couldn't be
+// promoted from one still replaying the WAL files. When the shutdown at the
+// recovery target is expected, the connection lost because PostgreSQL shut
+// down at the target is not an error, and errShutdownAtRecoveryTarget is
+// returned
+func waitUntilRecoveryFinishes(
+	ctx context.Context,
+	db *sql.DB,
+	policy restoreRetryPolicy,
+	progress *replayProgress,
+	promotion *promotionFailureCollector,
+	shutdown *recoveryShutdownCollector,
+	logProbe *recoveryLogProbe,
+) error {
 	errorIsRetriable := func(err error) bool {
 		return err == ErrInstanceInRecovery
 	}
 
-	return retry.OnError(RetryUntilRecoveryDone, errorIsRetriable, func() error {
+	err := policy.withUnlimitedAttempts().retry(ctx, "wait for the end of the recovery", errorIsRetriable, func() error {
+		if err := promotion.promotionError(); err != nil {
+			return err
+		}
+		if err := logProbe.probeError(); err != nil {
+			return err
+		}
+		if shutdown.hasShutDown() {
+			return errShutdownAtRecoveryTarget
+		}
+
 		row := db.QueryRow("SELECT pg_is_in_recovery()")
 
 		var status bool
 		if err := row.Scan(&status); err != nil {
+			if shutdown.waitForShutdown(ctx, recoveryShutdownWaitTimeout) {
+				return errShutdownAtRecoveryTarget
+			}
 			return fmt.Errorf("error while reading results of pg_is_in_recovery: %w", err)
 		}
 
 		log.Info("Checking if the server is still in recovery",
-			"recovery", status)
+			"recovery", status,
+			"walReplayCompleted", promotion.isRedoDone())
 
 		if status {
+			if progress != nil {
+				progress.update(ctx, db)
+			}
 			return ErrInstanceInRecovery
 		}
 
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return waitUntilInstanceAcceptsWrites(db)
+}
+
+// waitUntilInstanceAcceptsWrites waits, for a limited amount of time, for
+// PostgreSQL to accept write transactions. Even if `pg_is_in_recovery()`
+// already returned false, there may be a brief window after the promotion
+// where new transactions are still read-only
+func waitUntilInstanceAcceptsWrites(db *sql.DB) error {
+	errorIsRetriable := func(err error) bool {
+		return err == ErrInstanceReadOnly
+	}
+
+	return retry.OnError(RetryUntilWritesAccepted, errorIsRetriable, func() error {
+		row := db.QueryRow("SELECT pg_is_in_recovery(), current_setting('transaction_read_only')")
+
+		var inRecovery bool
+		var readOnly string
+		if err := row.Scan(&inRecovery, &readOnly); err != nil {
+			return fmt.Errorf("error while checking if the instance accepts writes: %w", err)
+		}
+
+		log.Info("Checking if the server is accepting writes",
+			"recovery", inRecovery,
+			"transactionReadOnly", readOnly)
+
+		if inRecovery || readOnly != "off" {
+			return ErrInstanceReadOnly
+		}
+
 		return nil
 	})
 }
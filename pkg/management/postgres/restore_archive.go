@@ -0,0 +1,91 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrArchiveDestinationIsRecoverySource is raised when the restored cluster
+// would archive its WAL files where the backup has been read from, overwriting
+// the WAL archive of the source cluster
+var ErrArchiveDestinationIsRecoverySource = errors.New(
+	"the WAL archive destination is the same as the recovery source")
+
+// checkArchiveDestinationIsNotRecoverySource ensures that, once promoted, the
+// restored cluster will archive its WAL files in its own destination and not
+// in the one the backup has been read from. This can be explicitly allowed
+// via an annotation
+func checkArchiveDestinationIsNotRecoverySource(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	if !cluster.Spec.Backup.IsBarmanBackupConfigured() {
+		return nil
+	}
+
+	archive := cluster.Spec.Backup.BarmanObjectStore
+	archiveServerName := cluster.Name
+	if archive.ServerName != "" {
+		archiveServerName = archive.ServerName
+	}
+
+	if !isSameObjectStoreLocation(
+		archive.EndpointURL, archive.DestinationPath, archiveServerName,
+		backup.Status.EndpointURL, backup.Status.DestinationPath, backup.Status.ServerName,
+	) {
+		contextLogger.Info("WAL archiving will start on the recovered cluster after the promotion",
+			"destinationPath", archive.DestinationPath,
+			"serverName", archiveServerName)
+		return nil
+	}
+
+	if utils.IsArchivingToRecoverySourceAllowed(&cluster.ObjectMeta) {
+		contextLogger.Warning("The recovered cluster will archive its WAL files where the backup is read from",
+			"destinationPath", archive.DestinationPath,
+			"serverName", archiveServerName)
+		return nil
+	}
+
+	return fmt.Errorf("%w: destination path %s, server name %s, use a different server name for the archive",
+		ErrArchiveDestinationIsRecoverySource, archive.DestinationPath, archiveServerName)
+}
+
+// isSameObjectStoreLocation checks if two endpoints, destination paths
+// and server names refer to the same location inside an object store
+func isSameObjectStoreLocation(
+	endpointURL, destinationPath, serverName string,
+	otherEndpointURL, otherDestinationPath, otherServerName string,
+) bool {
+	normalize := func(location string) string {
+		return strings.TrimRight(location, "/")
+	}
+
+	return normalize(endpointURL) == normalize(otherEndpointURL) &&
+		normalize(destinationPath) == normalize(otherDestinationPath) &&
+		serverName == otherServerName
+}
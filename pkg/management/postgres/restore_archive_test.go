@@ -0,0 +1,84 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("archive destination of a recovered cluster", func() {
+	backup := &apiv1.Backup{
+		Status: apiv1.BackupStatus{
+			EndpointURL:     "https://s3.example.com",
+			DestinationPath: "s3://backups/",
+			ServerName:      "source",
+		},
+	}
+
+	newCluster := func(name, serverName string, annotations map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+			Spec: apiv1.ClusterSpec{
+				Backup: &apiv1.BackupConfiguration{
+					BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+						EndpointURL:     "https://s3.example.com",
+						DestinationPath: "s3://backups",
+						ServerName:      serverName,
+						BarmanCredentials: apiv1.BarmanCredentials{
+							AWS: &apiv1.S3Credentials{InheritFromIAMRole: true},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("accepts a cluster archiving with its own server name", func() {
+		Expect(checkArchiveDestinationIsNotRecoverySource(
+			context.TODO(), newCluster("recovered", "", nil), backup)).To(Succeed())
+		Expect(checkArchiveDestinationIsNotRecoverySource(
+			context.TODO(), newCluster("source", "recovered", nil), backup)).To(Succeed())
+	})
+
+	It("accepts a cluster without WAL archiving", func() {
+		Expect(checkArchiveDestinationIsNotRecoverySource(
+			context.TODO(), &apiv1.Cluster{}, backup)).To(Succeed())
+	})
+
+	It("refuses to archive where the backup is read from", func() {
+		Expect(checkArchiveDestinationIsNotRecoverySource(
+			context.TODO(), newCluster("source", "", nil), backup)).
+			To(MatchError(ErrArchiveDestinationIsRecoverySource))
+		Expect(checkArchiveDestinationIsNotRecoverySource(
+			context.TODO(), newCluster("recovered", "source", nil), backup)).
+			To(MatchError(ErrArchiveDestinationIsRecoverySource))
+	})
+
+	It("archives where the backup is read from when explicitly allowed", func() {
+		cluster := newCluster("source", "", map[string]string{
+			"cnpg.io/allowArchivingToRecoverySource": "enabled",
+		})
+		Expect(checkArchiveDestinationIsNotRecoverySource(context.TODO(), cluster, backup)).To(Succeed())
+	})
+})
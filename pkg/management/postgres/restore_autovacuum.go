@@ -0,0 +1,85 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// autovacuumParameter is the parameter enabling autovacuum
+const autovacuumParameter = "autovacuum"
+
+// isRestoreAutovacuumDisabled checks if the user requested autovacuum to
+// be disabled while the restored instance is configured
+func isRestoreAutovacuumDisabled(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.DisableAutovacuum
+}
+
+// disableRestoreAutovacuum disables autovacuum, in the custom.conf file,
+// for the post-restore operations, when the user requested it. The
+// returned value tells if autovacuum has been disabled
+func (info InitInfo) disableRestoreAutovacuum(ctx context.Context, cluster *apiv1.Cluster) (bool, error) {
+	if !isRestoreAutovacuumDisabled(cluster) {
+		return false, nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile, map[string]string{autovacuumParameter: "off"}); err != nil {
+		return false, fmt.Errorf("while disabling autovacuum for the post-restore operations: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Autovacuum disabled for the post-restore operations")
+
+	return true, nil
+}
+
+// restoreAutovacuumAfterRestore sets autovacuum back to the value of the
+// cluster configuration, removing it if the cluster doesn't define it,
+// and logs how long it stayed disabled. The instance needs to be stopped
+func (info InitInfo) restoreAutovacuumAfterRestore(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	disabledSince time.Time,
+) error {
+	options := make(map[string]string)
+	if value, ok := cluster.Spec.PostgresConfiguration.Parameters[autovacuumParameter]; ok {
+		options[autovacuumParameter] = value
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile, options, autovacuumParameter); err != nil {
+		return fmt.Errorf("while restoring the autovacuum configuration: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Restored the autovacuum configuration after the post-restore operations",
+		"options", options,
+		"disabledFor", time.Since(disabledSince).String())
+
+	return nil
+}
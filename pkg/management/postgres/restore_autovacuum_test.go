@@ -0,0 +1,87 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("autovacuum during the post-restore operations", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+		Expect(os.WriteFile(
+			path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("shared_buffers = '128MB'\n"),
+			0o600)).To(Succeed())
+	})
+
+	readCustomConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	newCluster := func(disableAutovacuum bool, parameters map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{Parameters: parameters},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{DisableAutovacuum: disableAutovacuum},
+				},
+			},
+		}
+	}
+
+	It("disables autovacuum and removes the setting afterward", func() {
+		cluster := newCluster(true, nil)
+
+		disabled, err := info.disableRestoreAutovacuum(context.TODO(), cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(disabled).To(BeTrue())
+		Expect(readCustomConf()).To(Equal("shared_buffers = '128MB'\nautovacuum = 'off'\n"))
+
+		Expect(info.restoreAutovacuumAfterRestore(context.TODO(), cluster, time.Now())).To(Succeed())
+		Expect(readCustomConf()).To(Equal("shared_buffers = '128MB'\n"))
+	})
+
+	It("restores the value of the cluster configuration", func() {
+		cluster := newCluster(true, map[string]string{"autovacuum": "on"})
+
+		_, err := info.disableRestoreAutovacuum(context.TODO(), cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.restoreAutovacuumAfterRestore(context.TODO(), cluster, time.Now())).To(Succeed())
+		Expect(readCustomConf()).To(Equal("shared_buffers = '128MB'\nautovacuum = 'on'\n"))
+	})
+
+	It("leaves autovacuum untouched by default", func() {
+		disabled, err := info.disableRestoreAutovacuum(context.TODO(), newCluster(false, nil))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(disabled).To(BeFalse())
+		Expect(readCustomConf()).To(Equal("shared_buffers = '128MB'\n"))
+	})
+})
@@ -0,0 +1,129 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// pgVerifyBackupName is the name of the command verifying a base
+	// backup against its manifest
+	pgVerifyBackupName = "pg_verifybackup"
+
+	// backupManifestFile is the name of the manifest of a base backup,
+	// written by pg_basebackup in the root of the data directory
+	backupManifestFile = "backup_manifest"
+
+	// backupManifestMaxReportedFiles is the maximum number of files not
+	// matching the manifest that are reported in the error
+	backupManifestMaxReportedFiles = 10
+)
+
+// ErrBackupManifestMismatch is raised when the restored data directory
+// doesn't match the manifest of the backup
+var ErrBackupManifestMismatch = errors.New("the restored data directory doesn't match the backup manifest")
+
+// verifyBackupErrorFilePattern matches the errors reported by
+// pg_verifybackup about a file, whose name is quoted
+var verifyBackupErrorFilePattern = regexp.MustCompile(`"([^"]+)"`)
+
+// verifyBackupManifest checks, when requested by the user, the restored
+// data directory against the manifest included in the backup, if any.
+// The WAL files are not parsed, as they come from the archive
+func (info InitInfo) verifyBackupManifest(ctx context.Context, cluster *apiv1.Cluster) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		!cluster.Spec.Bootstrap.Recovery.VerifyBackupManifest {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	manifestPath := path.Join(info.PgData, backupManifestFile)
+	if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
+		contextLogger.Info("The backup doesn't contain a manifest, skipping its verification")
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("while checking the presence of the backup manifest: %w", err)
+	}
+
+	contextLogger.Info("Verifying the restored data directory against the backup manifest")
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, pgVerifyBackupName, "--no-parse-wal", "--quiet", info.PgData) // #nosec G204
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		files := parseVerifyBackupErrors(stderr.String())
+		contextLogger.Error(err, "The restored data directory doesn't match the backup manifest",
+			"files", files,
+			"output", stderr.String())
+		return fmt.Errorf("%w: %s", ErrBackupManifestMismatch, describeBackupManifestMismatch(files))
+	}
+	if err != nil {
+		return fmt.Errorf("while executing %s: %w", pgVerifyBackupName, err)
+	}
+
+	contextLogger.Info("The restored data directory matches the backup manifest")
+	return nil
+}
+
+// parseVerifyBackupErrors gets the files reported by pg_verifybackup
+// as not matching the manifest, together with the problem found
+func parseVerifyBackupErrors(output string) []string {
+	var result []string
+	for _, line := range strings.Split(output, "\n") {
+		_, message, found := strings.Cut(line, "error: ")
+		if !found {
+			continue
+		}
+
+		if verifyBackupErrorFilePattern.MatchString(message) {
+			result = append(result, strings.TrimSpace(message))
+		}
+	}
+
+	return result
+}
+
+// describeBackupManifestMismatch generates the description of the files
+// not matching the manifest, limiting the number of reported ones
+func describeBackupManifestMismatch(files []string) string {
+	if len(files) == 0 {
+		return "see the logs for the output of " + pgVerifyBackupName
+	}
+
+	if len(files) <= backupManifestMaxReportedFiles {
+		return strings.Join(files, "; ")
+	}
+
+	return fmt.Sprintf("%s; and %d more",
+		strings.Join(files[:backupManifestMaxReportedFiles], "; "),
+		len(files)-backupManifestMaxReportedFiles)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("verification of the backup manifest", func() {
+	newCluster := func(verify bool) *apiv1.Cluster {
+		return &apiv1.Cluster{Spec: apiv1.ClusterSpec{Bootstrap: &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{Source: "origin", VerifyBackupManifest: verify},
+		}}}
+	}
+
+	It("skips the verification when not requested or when the backup has no manifest", func() {
+		info := InitInfo{PgData: GinkgoT().TempDir()}
+		Expect(info.verifyBackupManifest(context.TODO(), newCluster(false))).To(Succeed())
+		Expect(info.verifyBackupManifest(context.TODO(), newCluster(true))).To(Succeed())
+	})
+
+	It("reports the files not matching the manifest", func() {
+		output := `pg_verifybackup: error: checksum mismatch for file "base/5/16384"
+pg_verifybackup: error: "global/pg_filenode.map" is present in the manifest but not on disk
+pg_verifybackup: error: "base/5/extra" is present on disk but not in the manifest
+pg_verifybackup: hint: Try "pg_verifybackup --help" for more information.
+`
+		Expect(parseVerifyBackupErrors(output)).To(Equal([]string{
+			`checksum mismatch for file "base/5/16384"`,
+			`"global/pg_filenode.map" is present in the manifest but not on disk`,
+			`"base/5/extra" is present on disk but not in the manifest`,
+		}))
+		Expect(parseVerifyBackupErrors("pg_verifybackup: error: could not parse backup manifest: bad\n")).To(BeEmpty())
+	})
+
+	It("limits the number of reported files", func() {
+		var files []string
+		for i := 0; i < backupManifestMaxReportedFiles+3; i++ {
+			files = append(files, fmt.Sprintf(`checksum mismatch for file "base/5/%d"`, i))
+		}
+		Expect(describeBackupManifestMismatch(files)).To(HaveSuffix("; and 3 more"))
+		Expect(describeBackupManifestMismatch(files[:1])).To(Equal(`checksum mismatch for file "base/5/0"`))
+		Expect(describeBackupManifestMismatch(nil)).To(ContainSubstring(pgVerifyBackupName))
+	})
+})
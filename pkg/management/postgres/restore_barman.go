@@ -0,0 +1,125 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/cmd/manager/walarchive"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman"
+	barmanCapabilities "github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/capabilities"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/restorer"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
+)
+
+// BarmanRunner executes the barman-cloud commands needed to restore
+// a backup stored in an object store
+type BarmanRunner interface {
+	// Restore runs barman-cloud-restore with the passed options
+	Restore(ctx context.Context, options []string, env []string) error
+
+	// WALRestore fetches a single WAL file from the object store
+	// into the destination path
+	WALRestore(
+		ctx context.Context,
+		cluster *apiv1.Cluster,
+		env []string,
+		walName string,
+		destinationPath string,
+		options []string,
+	) error
+
+	// ListBackups gets the catalog of the backups stored in the object store
+	ListBackups(
+		ctx context.Context,
+		configuration *apiv1.BarmanObjectStoreConfiguration,
+		serverName string,
+		env []string,
+	) (*catalog.Catalog, error)
+}
+
+// execBarmanRunner is the BarmanRunner executing the barman-cloud binaries
+type execBarmanRunner struct{}
+
+// Restore implements the BarmanRunner interface
+func (execBarmanRunner) Restore(ctx context.Context, options []string, env []string) error {
+	// The context is used to abort a download exceeding its timeout
+	cmd := exec.CommandContext(ctx, barmanCapabilities.BarmanCloudRestore, options...) // #nosec G204
+	cmd.Env = env
+	var stderr stderrCollector
+	err := runStreamingCollectingStderr(cmd, barmanCapabilities.BarmanCloudRestore, &stderr)
+	if err != nil {
+		var startError *commandStartError
+		if errors.As(err, &startError) {
+			return &barman.CloudRestoreStartError{Err: startError.err}
+		}
+
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			err = barman.UnmarshalBarmanCloudRestoreExitCode(exitError.ExitCode(), stderr.String())
+		}
+	}
+
+	return err
+}
+
+// WALRestore implements the BarmanRunner interface
+func (execBarmanRunner) WALRestore(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	env []string,
+	walName string,
+	destinationPath string,
+	options []string,
+) error {
+	rest, err := restorer.New(ctx, cluster, env, walarchive.SpoolDirectory)
+	if err != nil {
+		return err
+	}
+
+	return rest.Restore(walName, destinationPath, options)
+}
+
+// ListBackups implements the BarmanRunner interface
+func (execBarmanRunner) ListBackups(
+	ctx context.Context,
+	configuration *apiv1.BarmanObjectStoreConfiguration,
+	serverName string,
+	env []string,
+) (*catalog.Catalog, error) {
+	return barman.GetBackupList(ctx, configuration, serverName, env)
+}
+
+// barmanRunner gets the BarmanRunner to be used during the restore of
+// the passed cluster. Unless the static credentials are used, they are
+// obtained from the CredentialsProvider right before each command
+func (info InitInfo) barmanRunner(cluster *apiv1.Cluster) BarmanRunner {
+	var runner BarmanRunner = execBarmanRunner{}
+	if info.BarmanRunner != nil {
+		runner = info.BarmanRunner
+	}
+
+	provider := info.credentialsProvider(cluster)
+	if _, static := provider.(staticCredentialsProvider); static {
+		return runner
+	}
+
+	return credentialsBarmanRunner{runner: runner, provider: provider}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/restorer"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeBarmanRunner is a BarmanRunner recording the requested
+// operations instead of executing the barman-cloud binaries
+type fakeBarmanRunner struct {
+	restoreOptions  []string
+	restoreEnv      []string
+	restoreErr      error
+	restoreFailures int
+	restoreAttempts int
+	restoredWALs    []string
+	walRestoreErr   error
+	archivedWALs    []string
+	listedServer    string
+	backupCatalog   *catalog.Catalog
+	listErr         error
+	// restoredFiles are written into the data directory, which is
+	// the last option, when the restore succeeds
+	restoredFiles map[string]string
+}
+
+func (f *fakeBarmanRunner) Restore(_ context.Context, options []string, env []string) error {
+	f.restoreOptions = options
+	f.restoreEnv = env
+	f.restoreAttempts++
+	if f.restoreAttempts <= f.restoreFailures {
+		return errors.New("temporary failure")
+	}
+	if f.restoreErr != nil {
+		return f.restoreErr
+	}
+	for name, content := range f.restoredFiles {
+		fileName := path.Join(options[len(options)-1], name)
+		if err := os.MkdirAll(path.Dir(fileName), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fileName, []byte(content), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeBarmanRunner) WALRestore(
+	_ context.Context,
+	_ *apiv1.Cluster,
+	_ []string,
+	walName string,
+	_ string,
+	_ []string,
+) error {
+	f.restoredWALs = append(f.restoredWALs, walName)
+	if f.archivedWALs != nil && !slices.Contains(f.archivedWALs, walName) {
+		return restorer.ErrWALNotFound
+	}
+	return f.walRestoreErr
+}
+
+func (f *fakeBarmanRunner) ListBackups(
+	_ context.Context,
+	_ *apiv1.BarmanObjectStoreConfiguration,
+	serverName string,
+	_ []string,
+) (*catalog.Catalog, error) {
+	f.listedServer = serverName
+	return f.backupCatalog, f.listErr
+}
+
+var _ = Describe("restoring through a BarmanRunner", func() {
+	backup := &apiv1.Backup{
+		Status: apiv1.BackupStatus{
+			EndpointURL:     "https://s3.example.com",
+			DestinationPath: "s3://backups/",
+			ServerName:      "source",
+			BackupID:        "20240101T000000",
+		},
+	}
+
+	It("runs barman-cloud-restore with the options of the backup", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{PgData: "/var/lib/postgresql/data/pgdata", BarmanRunner: runner}
+
+		Expect(info.restoreDataDir(
+			context.TODO(), &apiv1.Cluster{}, backup, nil, getRestoreRetryPolicy(&apiv1.Cluster{}))).To(Succeed())
+		Expect(runner.restoreOptions).To(Equal([]string{
+			"--endpoint-url", "https://s3.example.com",
+			"s3://backups/",
+			"source",
+			"20240101T000000",
+			"/var/lib/postgresql/data/pgdata",
+		}))
+	})
+
+	It("passes the read timeout of the object store to barman-cloud-restore", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{PgData: "/var/lib/postgresql/data/pgdata", BarmanRunner: runner}
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source: "origin",
+						ObjectStoreTimeouts: &apiv1.RecoveryObjectStoreTimeouts{
+							Read: &metav1.Duration{Duration: 2 * time.Minute},
+						},
+					},
+				},
+			},
+		}
+
+		Expect(info.restoreDataDir(context.TODO(), cluster, backup, nil, getRestoreRetryPolicy(cluster))).
+			To(Succeed())
+		Expect(runner.restoreOptions).To(Equal([]string{
+			"--endpoint-url", "https://s3.example.com",
+			"--read-timeout", "120",
+			"s3://backups/",
+			"source",
+			"20240101T000000",
+			"/var/lib/postgresql/data/pgdata",
+		}))
+	})
+
+	It("reports the errors raised by barman-cloud-restore", func() {
+		runner := &fakeBarmanRunner{restoreErr: errors.New("restore failed")}
+		info := InitInfo{PgData: "/var/lib/postgresql/data/pgdata", BarmanRunner: runner}
+
+		Expect(info.restoreDataDir(
+			context.TODO(), &apiv1.Cluster{}, backup, nil, getRestoreRetryPolicy(&apiv1.Cluster{}))).
+			To(MatchError("restore failed"))
+	})
+
+	It("selects the latest backup of an external cluster", func() {
+		beginTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		runner := &fakeBarmanRunner{
+			backupCatalog: catalog.NewCatalog([]catalog.BarmanBackup{
+				{ID: "first", BeginTime: beginTime, EndTime: beginTime.Add(time.Hour)},
+				{ID: "second", BeginTime: beginTime.Add(24 * time.Hour), EndTime: beginTime.Add(25 * time.Hour)},
+				{ID: "failed", BeginTime: beginTime.Add(48 * time.Hour), Error: "failed"},
+			}),
+		}
+		info := InitInfo{BarmanRunner: runner}
+		cluster := &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "origin",
+						BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+							DestinationPath: "s3://backups/",
+							BarmanCredentials: apiv1.BarmanCredentials{
+								AWS: &apiv1.S3Credentials{InheritFromIAMRole: true},
+							},
+						},
+					},
+				},
+			},
+		}
+		typedClient := fake.NewClientBuilder().WithScheme(scheme.BuildWithAllKnownScheme()).Build()
+
+		result, _, err := info.loadBackupObjectFromExternalCluster(context.TODO(), typedClient, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runner.listedServer).To(Equal("origin"))
+		Expect(result.Status.BackupID).To(Equal("second"))
+		Expect(result.Status.DestinationPath).To(Equal("s3://backups/"))
+	})
+
+	It("reports the errors raised while listing the backups", func() {
+		runner := &fakeBarmanRunner{listErr: errors.New("cannot list")}
+		info := InitInfo{BarmanRunner: runner}
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "origin",
+						BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+							BarmanCredentials: apiv1.BarmanCredentials{
+								AWS: &apiv1.S3Credentials{InheritFromIAMRole: true},
+							},
+						},
+					},
+				},
+			},
+		}
+		typedClient := fake.NewClientBuilder().WithScheme(scheme.BuildWithAllKnownScheme()).Build()
+
+		_, _, err := info.loadBackupObjectFromExternalCluster(context.TODO(), typedClient, cluster)
+		Expect(err).To(MatchError("cannot list"))
+	})
+})
@@ -0,0 +1,103 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// withBarmanHome sets, in the environment used by the barman-cloud
+// commands of the restore, the HOME directory requested by the user,
+// together with the location of the configuration files of the cloud
+// providers. This way they can't be inherited from the instance manager
+// environment
+func withBarmanHome(cluster *apiv1.Cluster, backup *apiv1.Backup, env []string) ([]string, error) {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.BarmanHome == "" {
+		return env, nil
+	}
+
+	home := cluster.Spec.Bootstrap.Recovery.BarmanHome
+	if err := validateBarmanHome(home, backup.Status.BarmanCredentials); err != nil {
+		return nil, err
+	}
+
+	log.Info("Using a dedicated HOME directory for barman-cloud", "home", home)
+
+	result := setEnvValue(env, "HOME", home)
+	result = setEnvValue(result, "AWS_CONFIG_FILE", path.Join(home, ".aws", "config"))
+	result = setEnvValue(result, "AWS_SHARED_CREDENTIALS_FILE", path.Join(home, ".aws", "credentials"))
+	result = setEnvValue(result, "AZURE_CONFIG_DIR", path.Join(home, ".azure"))
+	result = setEnvValue(result, "CLOUDSDK_CONFIG", path.Join(home, ".config", "gcloud"))
+	return result, nil
+}
+
+// validateBarmanHome checks that the HOME directory for barman-cloud
+// exists and, when the credentials are inherited from the environment,
+// that it contains the configuration of the cloud provider in use
+func validateBarmanHome(home string, credentials apiv1.BarmanCredentials) error {
+	info, err := os.Stat(home)
+	if err != nil {
+		return fmt.Errorf("while checking the barman HOME directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("the barman HOME %s is not a directory", home)
+	}
+
+	var expected []string
+	switch {
+	case credentials.AWS != nil && credentials.AWS.InheritFromIAMRole:
+		expected = []string{path.Join(home, ".aws", "credentials"), path.Join(home, ".aws", "config")}
+	case credentials.Azure != nil && credentials.Azure.InheritFromAzureAD:
+		expected = []string{path.Join(home, ".azure")}
+	case credentials.Google != nil && credentials.Google.GKEEnvironment &&
+		credentials.Google.ApplicationCredentials == nil:
+		expected = []string{path.Join(home, ".config", "gcloud")}
+	default:
+		// The credentials are passed via the environment
+		return nil
+	}
+
+	for _, name := range expected {
+		if _, err := os.Stat(name); err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("the barman HOME %s doesn't contain any of the expected credential files: %s",
+		home, strings.Join(expected, ", "))
+}
+
+// setEnvValue sets the value of a variable in an environment,
+// replacing any existing definition
+func setEnvValue(env []string, name, value string) []string {
+	prefix := name + "="
+	result := make([]string, 0, len(env)+1)
+	for _, item := range env {
+		if !strings.HasPrefix(item, prefix) {
+			result = append(result, item)
+		}
+	}
+
+	return append(result, prefix+value)
+}
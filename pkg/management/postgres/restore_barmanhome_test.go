@@ -0,0 +1,87 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("barman HOME directory", func() {
+	var home string
+
+	BeforeEach(func() {
+		home = GinkgoT().TempDir()
+	})
+
+	newCluster := func(barmanHome string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{BarmanHome: barmanHome},
+				},
+			},
+		}
+	}
+
+	It("leaves the environment untouched when not requested", func() {
+		env := []string{"HOME=/var/lib/postgresql"}
+		Expect(withBarmanHome(newCluster(""), &apiv1.Backup{}, env)).To(Equal(env))
+	})
+
+	It("replaces HOME and the cloud provider configuration locations", func() {
+		env := []string{
+			"HOME=/var/lib/postgresql",
+			"AWS_SHARED_CREDENTIALS_FILE=/tenant-b/credentials",
+			"AWS_ACCESS_KEY_ID=key",
+		}
+		result, err := withBarmanHome(newCluster(home), &apiv1.Backup{}, env)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(ContainElements(
+			"AWS_ACCESS_KEY_ID=key",
+			"HOME="+home,
+			"AWS_SHARED_CREDENTIALS_FILE="+path.Join(home, ".aws", "credentials"),
+			"AWS_CONFIG_FILE="+path.Join(home, ".aws", "config"),
+		))
+		Expect(result).ToNot(ContainElement("HOME=/var/lib/postgresql"))
+		Expect(result).ToNot(ContainElement("AWS_SHARED_CREDENTIALS_FILE=/tenant-b/credentials"))
+	})
+
+	It("fails when the directory doesn't exist", func() {
+		_, err := withBarmanHome(newCluster(path.Join(home, "missing")), &apiv1.Backup{}, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("requires the credential files when inheriting the credentials", func() {
+		credentials := apiv1.BarmanCredentials{AWS: &apiv1.S3Credentials{InheritFromIAMRole: true}}
+		Expect(validateBarmanHome(home, credentials)).ToNot(Succeed())
+
+		Expect(fileutils.EnsureDirectoryExists(path.Join(home, ".aws"))).To(Succeed())
+		Expect(fileutils.CreateEmptyFile(path.Join(home, ".aws", "config"))).To(Succeed())
+		Expect(validateBarmanHome(home, credentials)).To(Succeed())
+	})
+
+	It("doesn't require credential files when the credentials are passed via secrets", func() {
+		credentials := apiv1.BarmanCredentials{AWS: &apiv1.S3Credentials{}}
+		Expect(validateBarmanHome(home, credentials)).To(Succeed())
+	})
+})
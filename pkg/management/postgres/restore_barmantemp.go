@@ -0,0 +1,84 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils/compatibility"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrInsufficientBarmanTempSpace is raised when the temporary directory
+// of barman-cloud doesn't have the required free space
+var ErrInsufficientBarmanTempSpace = errors.New("not enough free space in the temporary directory of barman")
+
+// withBarmanTempDirectory sets, in the environment used by
+// barman-cloud-restore, the temporary directory requested by the user,
+// once checked that it can hold the temporary files. This way they are
+// not written in the temporary directory of the container
+func withBarmanTempDirectory(ctx context.Context, cluster *apiv1.Cluster, env []string) ([]string, error) {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.BarmanTempDirectory == nil {
+		return env, nil
+	}
+
+	tempDirectory := cluster.Spec.Bootstrap.Recovery.BarmanTempDirectory
+	available, err := checkBarmanTempDirectory(tempDirectory.Path, tempDirectory.RequiredSpace)
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Info("Using a dedicated temporary directory for barman-cloud",
+		"directory", tempDirectory.Path,
+		"availableBytes", available)
+	return setEnvValue(env, "TMPDIR", tempDirectory.Path), nil
+}
+
+// checkBarmanTempDirectory checks that the temporary directory of
+// barman-cloud exists, is writable and has at least the required free
+// space, when known, returning the available space
+func checkBarmanTempDirectory(directory string, required *resource.Quantity) (uint64, error) {
+	info, err := os.Stat(directory)
+	if err != nil {
+		return 0, fmt.Errorf("while checking the temporary directory of barman: %w", err)
+	}
+	if !info.IsDir() {
+		return 0, fmt.Errorf("the temporary directory of barman %s is not a directory", directory)
+	}
+	if !compatibility.IsWritable(directory) {
+		return 0, fmt.Errorf("the temporary directory of barman %s is not writable", directory)
+	}
+
+	available, err := compatibility.GetAvailableSpace(directory)
+	if err != nil {
+		return 0, fmt.Errorf("while checking the free space of the temporary directory of barman: %w", err)
+	}
+
+	if required != nil && available < uint64(required.Value()) {
+		return 0, fmt.Errorf("%w: %d bytes available in %s, %s (%d bytes) required",
+			ErrInsufficientBarmanTempSpace, available, directory, required.String(), required.Value())
+	}
+
+	return available, nil
+}
@@ -0,0 +1,77 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("temporary directory of barman", func() {
+	newCluster := func(tempDirectory *apiv1.RecoveryBarmanTempDirectory) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{BarmanTempDirectory: tempDirectory},
+				},
+			},
+		}
+	}
+
+	It("keeps the environment when no temporary directory is requested", func() {
+		env := []string{"TMPDIR=/tmp"}
+		Expect(withBarmanTempDirectory(context.TODO(), newCluster(nil), env)).To(Equal(env))
+	})
+
+	It("sets the temporary directory in the environment", func() {
+		directory := GinkgoT().TempDir()
+		result, err := withBarmanTempDirectory(context.TODO(), newCluster(&apiv1.RecoveryBarmanTempDirectory{
+			Path:          directory,
+			RequiredSpace: ptr.To(resource.MustParse("1Ki")),
+		}), []string{"PATH=/bin", "TMPDIR=/tmp"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(ConsistOf("PATH=/bin", "TMPDIR="+directory))
+	})
+
+	It("fails when the directory doesn't exist", func() {
+		_, err := withBarmanTempDirectory(context.TODO(), newCluster(&apiv1.RecoveryBarmanTempDirectory{
+			Path: path.Join(GinkgoT().TempDir(), "missing"),
+		}), nil)
+		Expect(err).To(MatchError(ContainSubstring("while checking the temporary directory of barman")))
+	})
+
+	It("fails when the directory is a file", func() {
+		fileName := path.Join(GinkgoT().TempDir(), "file")
+		Expect(os.WriteFile(fileName, nil, 0o600)).To(Succeed())
+		_, err := checkBarmanTempDirectory(fileName, nil)
+		Expect(err).To(MatchError(ContainSubstring("is not a directory")))
+	})
+
+	It("fails when the directory doesn't have the required free space", func() {
+		_, err := checkBarmanTempDirectory(GinkgoT().TempDir(), ptr.To(resource.MustParse("1Ei")))
+		Expect(err).To(MatchError(ErrInsufficientBarmanTempSpace))
+	})
+})
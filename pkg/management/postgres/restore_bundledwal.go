@@ -0,0 +1,103 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// walArchiveStatusDirectory is the directory of pg_wal containing the
+// archive status of the WAL files, which PostgreSQL expects to exist
+const walArchiveStatusDirectory = "archive_status"
+
+// isBundledWALKept checks if the user requested to keep the WAL files
+// included in the restored base backup
+func isBundledWALKept(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.KeepBundledWAL
+}
+
+// removeBundledWAL removes the WAL files included in the base backup,
+// so that the recovery relies on the ones fetched by the restore_command.
+// The pg_wal and archive_status directories are kept, together with the
+// other directories PostgreSQL stores in pg_wal
+func (info InitInfo) removeBundledWAL(ctx context.Context, cluster *apiv1.Cluster) error {
+	contextLogger := log.FromContext(ctx)
+	walDirectory := path.Join(info.PgData, pgWalDirectory)
+
+	if isBundledWALKept(cluster) {
+		contextLogger.Info("Keeping the WAL files included in the base backup", "directory", walDirectory)
+		return nil
+	}
+
+	removed, err := removeDirectoryFiles(walDirectory)
+	if err != nil {
+		return fmt.Errorf("while removing the WAL files included in the base backup: %w", err)
+	}
+
+	archiveStatusDirectory := path.Join(walDirectory, walArchiveStatusDirectory)
+	removedStatus, err := removeDirectoryFiles(archiveStatusDirectory)
+	if err != nil {
+		return fmt.Errorf("while removing the archive status of the WAL files included in the base backup: %w", err)
+	}
+
+	if err := os.MkdirAll(archiveStatusDirectory, 0o700); err != nil {
+		return fmt.Errorf("while creating the %s directory: %w", archiveStatusDirectory, err)
+	}
+
+	if removed > 0 || removedStatus > 0 {
+		contextLogger.Info("Removed the WAL files included in the base backup",
+			"directory", walDirectory,
+			"files", removed,
+			"archiveStatusFiles", removedStatus)
+	}
+
+	return nil
+}
+
+// removeDirectoryFiles removes the files contained in a directory,
+// leaving its subdirectories untouched, and returns how many
+// files have been removed. A missing directory is ignored
+func removeDirectoryFiles(directory string) (int, error) {
+	entries, err := os.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var removed int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Remove(path.Join(directory, entry.Name())); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAL files included in the base backup", func() {
+	var info InitInfo
+	var walDirectory string
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+		walDirectory = path.Join(info.PgData, "pg_wal")
+		Expect(os.MkdirAll(path.Join(walDirectory, "archive_status"), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(walDirectory, "summaries"), 0o700)).To(Succeed())
+		for _, name := range []string{"000000010000000000000003", "00000002.history"} {
+			Expect(os.WriteFile(path.Join(walDirectory, name), []byte("wal"), 0o600)).To(Succeed())
+		}
+		Expect(os.WriteFile(
+			path.Join(walDirectory, "archive_status", "000000010000000000000003.done"), nil, 0o600)).To(Succeed())
+		Expect(os.WriteFile(
+			path.Join(walDirectory, "summaries", "0000000100000000010000280000000001000100.summary"),
+			nil, 0o600)).To(Succeed())
+	})
+
+	newCluster := func(keepBundledWAL bool) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", KeepBundledWAL: keepBundledWAL},
+				},
+			},
+		}
+	}
+
+	It("removes the WAL files, keeping the directories", func() {
+		Expect(info.removeBundledWAL(context.TODO(), newCluster(false))).To(Succeed())
+
+		entries, err := os.ReadDir(walDirectory)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(HaveLen(2))
+		Expect(path.Join(walDirectory, "archive_status")).To(BeADirectory())
+		Expect(os.ReadDir(path.Join(walDirectory, "archive_status"))).To(BeEmpty())
+		Expect(path.Join(walDirectory, "summaries", "0000000100000000010000280000000001000100.summary")).
+			To(BeAnExistingFile())
+	})
+
+	It("keeps the WAL files when requested", func() {
+		Expect(info.removeBundledWAL(context.TODO(), newCluster(true))).To(Succeed())
+
+		Expect(path.Join(walDirectory, "000000010000000000000003")).To(BeAnExistingFile())
+		Expect(path.Join(walDirectory, "00000002.history")).To(BeAnExistingFile())
+		Expect(path.Join(walDirectory, "archive_status", "000000010000000000000003.done")).To(BeAnExistingFile())
+	})
+
+	It("creates the directories when the base backup doesn't include pg_wal", func() {
+		Expect(os.RemoveAll(walDirectory)).To(Succeed())
+
+		Expect(info.removeBundledWAL(context.TODO(), newCluster(false))).To(Succeed())
+		Expect(path.Join(walDirectory, "archive_status")).To(BeADirectory())
+	})
+})
@@ -0,0 +1,294 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+)
+
+const (
+	// catalogSummaryDatabasesQuery lists the databases accepting
+	// connections, template databases excluded
+	catalogSummaryDatabasesQuery = "SELECT datname FROM pg_catalog.pg_database " +
+		"WHERE datallowconn AND NOT datistemplate ORDER BY datname"
+
+	// catalogSummaryRolesQuery lists the roles, the predefined ones excluded
+	catalogSummaryRolesQuery = "SELECT rolname FROM pg_catalog.pg_roles " +
+		"WHERE rolname !~ '^pg_' ORDER BY rolname"
+
+	// catalogSummaryTablesQuery counts the tables, partitioned tables
+	// included, in every schema of the current database, the system
+	// schemas excluded
+	catalogSummaryTablesQuery = "SELECT n.nspname, count(*) FROM pg_catalog.pg_class c " +
+		"JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE c.relkind IN ('r', 'p') " +
+		"AND n.nspname NOT IN ('pg_catalog', 'information_schema') " +
+		"AND n.nspname !~ '^pg_(toast|temp_)' " +
+		"GROUP BY n.nspname ORDER BY n.nspname"
+)
+
+// ErrCatalogSummaryMismatch is raised when the catalog of the restored
+// cluster doesn't match the expected summary, and the user asked the
+// recovery to fail in this case
+var ErrCatalogSummaryMismatch = errors.New("the catalog of the restored cluster doesn't match the expected summary")
+
+// getRecoveryCatalogSummary gets the catalog summary requested
+// by the user, if any
+func getRecoveryCatalogSummary(cluster *apiv1.Cluster) *apiv1.RecoveryCatalogSummary {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.CatalogSummary
+}
+
+// checkRestoredCatalogSummary collects the catalog summary of the restored
+// cluster, compares it with the expected one, if any, and records both the
+// summary and the discrepancies in the cluster status. The recovery fails
+// on a discrepancy only when requested by the user
+func (info InitInfo) checkRestoredCatalogSummary(
+	ctx context.Context,
+	catalogSummary *apiv1.RecoveryCatalogSummary,
+	connect func(databaseName string) (*sql.DB, error),
+) error {
+	if catalogSummary == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	summary, err := collectCatalogSummary(ctx, connect)
+	if err != nil {
+		return fmt.Errorf("while collecting the catalog summary: %w", err)
+	}
+
+	report := &apiv1.CatalogSummaryReport{Summary: *summary}
+	if catalogSummary.Expected != nil {
+		report.Compared = true
+		report.Discrepancies = compareCatalogSummary(catalogSummary.Expected, summary)
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+	if err := info.reportCatalogSummary(ctx, typedClient, report); err != nil {
+		return err
+	}
+
+	switch {
+	case !report.Compared:
+		contextLogger.Info("Recorded the catalog summary of the restored cluster",
+			"databases", len(summary.Databases),
+			"roles", len(summary.Roles))
+
+	case len(report.Discrepancies) == 0:
+		contextLogger.Info("The catalog of the restored cluster matches the expected summary")
+
+	case catalogSummary.FailOnMismatch:
+		return fmt.Errorf("%w: %v", ErrCatalogSummaryMismatch, report.Discrepancies)
+
+	default:
+		contextLogger.Warning("The catalog of the restored cluster doesn't match the expected summary",
+			"discrepancies", report.Discrepancies)
+	}
+
+	return nil
+}
+
+// collectCatalogSummary collects the databases, the roles and the
+// number of tables in every schema of the restored cluster
+func collectCatalogSummary(
+	ctx context.Context,
+	connect func(databaseName string) (*sql.DB, error),
+) (*apiv1.CatalogSummary, error) {
+	db, err := connect("postgres")
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to database postgres: %w", err)
+	}
+
+	databases, err := queryCatalogNames(ctx, db, catalogSummaryDatabasesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("while listing the databases: %w", err)
+	}
+
+	roles, err := queryCatalogNames(ctx, db, catalogSummaryRolesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("while listing the roles: %w", err)
+	}
+
+	summary := &apiv1.CatalogSummary{Databases: databases, Roles: roles}
+	for _, databaseName := range databases {
+		db, err := connect(databaseName)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to database %s: %w", databaseName, err)
+		}
+
+		tables, err := countCatalogTables(ctx, db, databaseName)
+		if err != nil {
+			return nil, fmt.Errorf("while counting the tables of database %s: %w", databaseName, err)
+		}
+		summary.Tables = append(summary.Tables, tables...)
+	}
+
+	return summary, nil
+}
+
+// queryCatalogNames executes a query returning a list of names
+func queryCatalogNames(ctx context.Context, db *sql.DB, query string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+
+	return result, rows.Err()
+}
+
+// countCatalogTables counts the tables in every schema of a database
+func countCatalogTables(ctx context.Context, db *sql.DB, databaseName string) ([]apiv1.CatalogSummaryTables, error) {
+	rows, err := db.QueryContext(ctx, catalogSummaryTablesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []apiv1.CatalogSummaryTables
+	for rows.Next() {
+		tables := apiv1.CatalogSummaryTables{Database: databaseName}
+		if err := rows.Scan(&tables.Schema, &tables.Count); err != nil {
+			return nil, err
+		}
+		result = append(result, tables)
+	}
+
+	return result, rows.Err()
+}
+
+// compareCatalogSummary gets the differences between the catalog summary
+// of the restored cluster and the expected one. Only the sections
+// specified in the expected summary are compared
+func compareCatalogSummary(expected, actual *apiv1.CatalogSummary) []string {
+	var result []string
+
+	if expected.Databases != nil {
+		result = append(result, compareCatalogNames("database", expected.Databases, actual.Databases)...)
+	}
+
+	if expected.Roles != nil {
+		result = append(result, compareCatalogNames("role", expected.Roles, actual.Roles)...)
+	}
+
+	if expected.Tables != nil {
+		actualTables := make(map[string]int, len(actual.Tables))
+		for _, tables := range actual.Tables {
+			actualTables[tables.Database+"."+tables.Schema] = tables.Count
+		}
+
+		expectedSchemas := stringset.New()
+		for _, tables := range expected.Tables {
+			key := tables.Database + "." + tables.Schema
+			expectedSchemas.Put(key)
+			if count := actualTables[key]; count != tables.Count {
+				result = append(result, fmt.Sprintf("database %s, schema %s: expected %d tables, found %d",
+					tables.Database, tables.Schema, tables.Count, count))
+			}
+		}
+
+		for _, tables := range actual.Tables {
+			if !expectedSchemas.Has(tables.Database + "." + tables.Schema) {
+				result = append(result, fmt.Sprintf("database %s, schema %s: unexpected schema, found %d tables",
+					tables.Database, tables.Schema, tables.Count))
+			}
+		}
+	}
+
+	return result
+}
+
+// compareCatalogNames gets the expected names which are missing and
+// the ones which are not expected
+func compareCatalogNames(kind string, expected, actual []string) []string {
+	expectedNames := stringset.From(expected)
+	actualNames := stringset.From(actual)
+
+	var result []string
+	for _, name := range expectedNames.ToSortedList() {
+		if !actualNames.Has(name) {
+			result = append(result, fmt.Sprintf("missing %s %s", kind, name))
+		}
+	}
+	for _, name := range actualNames.ToSortedList() {
+		if !expectedNames.Has(name) {
+			result = append(result, fmt.Sprintf("unexpected %s %s", kind, name))
+		}
+	}
+
+	return result
+}
+
+// reportCatalogSummary writes the catalog summary of the restored
+// cluster in the cluster status
+func (info InitInfo) reportCatalogSummary(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.CatalogSummaryReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.CatalogSummary = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the catalog summary in the cluster status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("catalog summary of the restored cluster", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("collects the databases, the roles and the tables per schema", func() {
+		mock.ExpectQuery(regexp.QuoteMeta(catalogSummaryDatabasesQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"datname"}).AddRow("app").AddRow("postgres"))
+		mock.ExpectQuery(regexp.QuoteMeta(catalogSummaryRolesQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"rolname"}).AddRow("app").AddRow("postgres"))
+		mock.ExpectQuery(regexp.QuoteMeta(catalogSummaryTablesQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"nspname", "count"}).AddRow("audit", 2).AddRow("public", 10))
+		mock.ExpectQuery(regexp.QuoteMeta(catalogSummaryTablesQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"nspname", "count"}))
+
+		var databases []string
+		summary, err := collectCatalogSummary(context.TODO(), func(databaseName string) (*sql.DB, error) {
+			databases = append(databases, databaseName)
+			return db, nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(databases).To(Equal([]string{"postgres", "app", "postgres"}))
+		Expect(summary).To(Equal(&apiv1.CatalogSummary{
+			Databases: []string{"app", "postgres"},
+			Roles:     []string{"app", "postgres"},
+			Tables: []apiv1.CatalogSummaryTables{
+				{Database: "app", Schema: "audit", Count: 2},
+				{Database: "app", Schema: "public", Count: 10},
+			},
+		}))
+	})
+
+	It("reports the differences with the expected summary", func() {
+		actual := &apiv1.CatalogSummary{
+			Databases: []string{"app", "postgres"},
+			Roles:     []string{"app", "postgres", "reporting"},
+			Tables: []apiv1.CatalogSummaryTables{
+				{Database: "app", Schema: "public", Count: 8},
+				{Database: "app", Schema: "staging", Count: 1},
+			},
+		}
+
+		Expect(compareCatalogSummary(&apiv1.CatalogSummary{
+			Databases: []string{"app", "inventory", "postgres"},
+			Roles:     []string{"app", "postgres"},
+			Tables: []apiv1.CatalogSummaryTables{
+				{Database: "app", Schema: "public", Count: 10},
+				{Database: "app", Schema: "audit", Count: 2},
+			},
+		}, actual)).To(Equal([]string{
+			"missing database inventory",
+			"unexpected role reporting",
+			"database app, schema public: expected 10 tables, found 8",
+			"database app, schema audit: expected 2 tables, found 0",
+			"database app, schema staging: unexpected schema, found 1 tables",
+		}))
+	})
+
+	It("compares only the sections of the expected summary", func() {
+		actual := &apiv1.CatalogSummary{
+			Databases: []string{"app", "postgres"},
+			Roles:     []string{"app", "postgres"},
+		}
+		Expect(compareCatalogSummary(&apiv1.CatalogSummary{}, actual)).To(BeEmpty())
+		Expect(compareCatalogSummary(&apiv1.CatalogSummary{Databases: []string{"postgres", "app"}}, actual)).
+			To(BeEmpty())
+	})
+
+	It("reports the catalog summary in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		report := &apiv1.CatalogSummaryReport{
+			Summary:       apiv1.CatalogSummary{Databases: []string{"app"}},
+			Compared:      true,
+			Discrepancies: []string{"missing database inventory"},
+		}
+
+		Expect(info.reportCatalogSummary(context.TODO(), typedClient, report)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.CatalogSummary).To(Equal(report))
+	})
+
+	It("does nothing when no catalog summary is requested", func() {
+		Expect(getRecoveryCatalogSummary(&apiv1.Cluster{})).To(BeNil())
+		Expect(InitInfo{}.checkRestoredCatalogSummary(context.TODO(), nil, nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,138 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// recoveryCheckpointParameters are the parameters that can be tuned
+// for the checkpoints executed during the recovery
+var recoveryCheckpointParameters = []string{
+	"checkpoint_completion_target",
+	"max_wal_size",
+}
+
+// getRecoveryCheckpoint gets the checkpoint behavior requested by the
+// user for the recovery, if any
+func getRecoveryCheckpoint(cluster *apiv1.Cluster) *apiv1.RecoveryCheckpoint {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Checkpoint
+}
+
+// isExplicitRecoveryCheckpoint checks if a checkpoint has to be executed
+// as soon as the recovery is completed
+func isExplicitRecoveryCheckpoint(cluster *apiv1.Cluster) bool {
+	checkpoint := getRecoveryCheckpoint(cluster)
+	return checkpoint != nil && checkpoint.Explicit
+}
+
+// renderRecoveryCheckpointOptions generates the parameters tuning the
+// checkpoints for the recovery
+func renderRecoveryCheckpointOptions(checkpoint *apiv1.RecoveryCheckpoint) map[string]string {
+	options := make(map[string]string)
+	if checkpoint.CompletionTarget != "" {
+		options["checkpoint_completion_target"] = checkpoint.CompletionTarget
+	}
+	if checkpoint.MaxWALSize != "" {
+		options["max_wal_size"] = checkpoint.MaxWALSize
+	}
+
+	return options
+}
+
+// writeRecoveryCheckpointConfiguration writes, in the custom.conf file,
+// the parameters tuning the checkpoints for the recovery phase. They will
+// be set back to the values of the cluster configuration once the restored
+// instance is configured
+func (info InitInfo) writeRecoveryCheckpointConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	checkpoint := getRecoveryCheckpoint(cluster)
+	if checkpoint == nil {
+		return nil
+	}
+
+	options := renderRecoveryCheckpointOptions(checkpoint)
+	if len(options) == 0 {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, options); err != nil {
+		return fmt.Errorf("while configuring the checkpoints for the recovery: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Configured the checkpoints for the recovery", "options", options)
+
+	return nil
+}
+
+// restoreCheckpointAfterRecovery sets the parameters tuning the
+// checkpoints back to the values of the cluster configuration, removing
+// the ones the cluster doesn't define. The instance needs to be stopped
+func (info InitInfo) restoreCheckpointAfterRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	if getRecoveryCheckpoint(cluster) == nil {
+		return nil
+	}
+
+	options := make(map[string]string)
+	for _, name := range recoveryCheckpointParameters {
+		if value, ok := cluster.Spec.PostgresConfiguration.Parameters[name]; ok {
+			options[name] = value
+		}
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	changed, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile, options, recoveryCheckpointParameters...)
+	if err != nil {
+		return fmt.Errorf("while restoring the checkpoint configuration: %w", err)
+	}
+
+	if changed {
+		log.FromContext(ctx).Info("Restored the checkpoint configuration after the recovery",
+			"options", options)
+	}
+
+	return nil
+}
+
+// executeRecoveryCheckpoint executes a checkpoint once the recovery is
+// completed, logging its duration
+func executeRecoveryCheckpoint(ctx context.Context, db *sql.DB) error {
+	contextLogger := log.FromContext(ctx)
+
+	contextLogger.Info("Executing a checkpoint after the recovery")
+	start := time.Now()
+	if _, err := db.ExecContext(ctx, "CHECKPOINT"); err != nil {
+		return fmt.Errorf("while executing a checkpoint after the recovery: %w", err)
+	}
+	contextLogger.Info("Checkpoint after the recovery completed", "duration", time.Since(start).String())
+
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery checkpoint", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+		Expect(os.WriteFile(
+			path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("max_wal_size = '1GB'\nshared_buffers = '128MB'\n"),
+			0o600)).To(Succeed())
+	})
+
+	readCustomConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	newCluster := func(parameters map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					Parameters: parameters,
+				},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Checkpoint: &apiv1.RecoveryCheckpoint{
+							Explicit:         true,
+							CompletionTarget: "0.5",
+							MaxWALSize:       "16GB",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("tunes the checkpoints during the recovery and restores the cluster configuration", func() {
+		cluster := newCluster(map[string]string{"max_wal_size": "1GB"})
+		Expect(isExplicitRecoveryCheckpoint(cluster)).To(BeTrue())
+
+		Expect(info.writeRecoveryCheckpointConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal(
+			"max_wal_size = '16GB'\nshared_buffers = '128MB'\ncheckpoint_completion_target = '0.5'\n"))
+
+		Expect(info.restoreCheckpointAfterRecovery(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal("max_wal_size = '1GB'\nshared_buffers = '128MB'\n"))
+	})
+
+	It("leaves the configuration untouched when nothing is requested", func() {
+		Expect(isExplicitRecoveryCheckpoint(&apiv1.Cluster{})).To(BeFalse())
+		Expect(info.writeRecoveryCheckpointConfiguration(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(info.restoreCheckpointAfterRecovery(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(readCustomConf()).To(Equal("max_wal_size = '1GB'\nshared_buffers = '128MB'\n"))
+	})
+
+	It("executes the checkpoint after the recovery", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+
+		mock.ExpectExec("CHECKPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+		Expect(executeRecoveryCheckpoint(context.TODO(), db)).To(Succeed())
+
+		mock.ExpectExec("CHECKPOINT").WillReturnError(errors.New("disk full"))
+		Expect(executeRecoveryCheckpoint(context.TODO(), db)).To(MatchError(ContainSubstring("disk full")))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
@@ -0,0 +1,294 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+const (
+	// collationReportDatabase is the database whose locale is
+	// reported in the cluster status
+	collationReportDatabase = "template1"
+
+	// collationDependentIndexesQuery lists the indexes using a collation
+	// whose ordering depends on the library providing it, i.e. every
+	// collation apart from the C and POSIX ones
+	collationDependentIndexesQuery = `
+SELECT DISTINCT pg_catalog.format('%I.%I', n.nspname, ic.relname)
+FROM pg_catalog.pg_index i
+JOIN pg_catalog.pg_class ic ON ic.oid = i.indexrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = ic.relnamespace
+JOIN pg_catalog.pg_collation c ON c.oid = ANY(i.indcollation)
+WHERE c.collname NOT IN ('C', 'POSIX', 'ucs_basic')
+  AND NOT (c.collname = 'default' AND
+    (SELECT datcollate FROM pg_catalog.pg_database WHERE datname = pg_catalog.current_database())
+    IN ('C', 'POSIX'))
+ORDER BY 1`
+
+	// mismatchedCollationsQuery lists the collations whose recorded version
+	// differs from the one provided by the library
+	mismatchedCollationsQuery = `
+SELECT pg_catalog.format('%I.%I', n.nspname, c.collname)
+FROM pg_catalog.pg_collation c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.collnamespace
+WHERE c.collversion IS NOT NULL
+  AND c.collversion IS DISTINCT FROM pg_catalog.pg_collation_actual_version(c.oid)
+ORDER BY 1`
+)
+
+// ErrCollationMismatch is raised when the collations of the restored
+// databases don't match the ones of the image, and the user asked to fail
+var ErrCollationMismatch = errors.New("the collations of the restored databases don't match the ones of the image")
+
+// databaseCollation is the locale of a restored database,
+// together with its collations not matching the image
+type databaseCollation struct {
+	collate       string
+	ctype         string
+	version       string
+	actualVersion string
+	// the collations whose recorded version differs from the actual one
+	mismatchedCollations []string
+}
+
+// hasMismatch checks if the collations of the database
+// don't match the ones of the image
+func (collation databaseCollation) hasMismatch() bool {
+	return (collation.version != "" && collation.actualVersion != "" &&
+		collation.version != collation.actualVersion) ||
+		len(collation.mismatchedCollations) > 0
+}
+
+// getCollationMismatchPolicy gets the action to be taken when the
+// collations don't match, defaulting to the rebuild of the indexes
+func getCollationMismatchPolicy(cluster *apiv1.Cluster) apiv1.CollationMismatchPolicy {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.OnCollationMismatch == "" {
+		return apiv1.CollationMismatchPolicyReindex
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.OnCollationMismatch
+}
+
+// getTargetLocale gets the default locale of the image,
+// following the precedence used by the C library
+func getTargetLocale() string {
+	for _, name := range []string{"LC_ALL", "LC_COLLATE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+
+	return "C"
+}
+
+// getDatabaseCollation gets the locale of the database of the passed
+// connection. The version of the collation of a database is recorded
+// starting from PostgreSQL 15
+func getDatabaseCollation(ctx context.Context, db *sql.DB, majorVersion int) (databaseCollation, error) {
+	var result databaseCollation
+
+	if majorVersion >= 15 {
+		var version, actualVersion sql.NullString
+		if err := db.QueryRowContext(
+			ctx,
+			"SELECT datcollate, datctype, datcollversion, pg_catalog.pg_database_collation_actual_version(oid) "+
+				"FROM pg_catalog.pg_database WHERE datname = pg_catalog.current_database()",
+		).Scan(&result.collate, &result.ctype, &version, &actualVersion); err != nil {
+			return result, fmt.Errorf("while getting the collation of the database: %w", err)
+		}
+		result.version = version.String
+		result.actualVersion = actualVersion.String
+	} else {
+		if err := db.QueryRowContext(
+			ctx,
+			"SELECT datcollate, datctype "+
+				"FROM pg_catalog.pg_database WHERE datname = pg_catalog.current_database()",
+		).Scan(&result.collate, &result.ctype); err != nil {
+			return result, fmt.Errorf("while getting the collation of the database: %w", err)
+		}
+	}
+
+	collations, err := queryNames(db, mismatchedCollationsQuery)
+	if err != nil {
+		return result, fmt.Errorf("while checking the versions of the collations: %w", err)
+	}
+	result.mismatchedCollations = collations
+
+	return result, nil
+}
+
+// rebuildCollationDependentIndexes rebuilds the indexes depending on the
+// collations of a database, and then records the actual version of the
+// collations, so that PostgreSQL stops warning about the mismatch
+func rebuildCollationDependentIndexes(
+	ctx context.Context,
+	db *sql.DB,
+	databaseName string,
+	majorVersion int,
+	collation databaseCollation,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("database", databaseName)
+
+	indexes, err := queryNames(db, collationDependentIndexesQuery)
+	if err != nil {
+		return fmt.Errorf("while listing the collation-dependent indexes of database %s: %w", databaseName, err)
+	}
+
+	contextLogger.Info("Rebuilding the collation-dependent indexes", "indexes", len(indexes))
+	for _, index := range indexes {
+		// The index name has been already quoted by the query
+		if _, err := db.ExecContext(ctx, "REINDEX INDEX "+index); err != nil {
+			return fmt.Errorf("while rebuilding index %s of database %s: %w", index, databaseName, err)
+		}
+	}
+
+	for _, name := range collation.mismatchedCollations {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER COLLATION %s REFRESH VERSION", name)); err != nil {
+			return fmt.Errorf("while refreshing the version of collation %s of database %s: %w",
+				name, databaseName, err)
+		}
+	}
+
+	if majorVersion >= 15 && collation.version != collation.actualVersion {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s REFRESH COLLATION VERSION",
+			pgx.Identifier{databaseName}.Sanitize())); err != nil {
+			return fmt.Errorf("while refreshing the collation version of database %s: %w", databaseName, err)
+		}
+	}
+
+	return nil
+}
+
+// checkRestoredCollations compares the collations of every restored
+// database with the ones of the image and, when they don't match, takes
+// the action requested by the user. The outcome is reported in the
+// cluster status
+func (info InitInfo) checkRestoredCollations(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	instance *Instance,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	majorVersion, err := postgresutils.GetMajorVersion(info.PgData)
+	if err != nil {
+		return fmt.Errorf("while reading the PostgreSQL major version: %w", err)
+	}
+
+	databases, err := listRestoredDatabases(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	policy := getCollationMismatchPolicy(cluster)
+	report := &apiv1.RecoveryLocaleReport{TargetLocale: getTargetLocale()}
+	for _, databaseName := range databases {
+		db, err := instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			return fmt.Errorf("could not connect to database %s: %w", databaseName, err)
+		}
+
+		collation, err := getDatabaseCollation(ctx, db, majorVersion)
+		if err != nil {
+			return fmt.Errorf("in database %s: %w", databaseName, err)
+		}
+		if databaseName == collationReportDatabase {
+			report.SourceCollate = collation.collate
+			report.SourceCtype = collation.ctype
+			report.SourceCollationVersion = collation.version
+			report.TargetCollationVersion = collation.actualVersion
+		}
+		if !collation.hasMismatch() {
+			continue
+		}
+
+		report.MismatchedDatabases = append(report.MismatchedDatabases, databaseName)
+		contextLogger.Warning("COLLATION MISMATCH: the collations of the restored database don't match "+
+			"the ones of the image, and the collation-dependent indexes may return wrong results",
+			"database", databaseName,
+			"collate", collation.collate,
+			"collationVersion", collation.version,
+			"imageCollationVersion", collation.actualVersion,
+			"mismatchedCollations", collation.mismatchedCollations,
+			"onCollationMismatch", policy)
+		if policy != apiv1.CollationMismatchPolicyReindex {
+			continue
+		}
+
+		if err := rebuildCollationDependentIndexes(ctx, db, databaseName, majorVersion, collation); err != nil {
+			return err
+		}
+		report.ReindexedDatabases = append(report.ReindexedDatabases, databaseName)
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+	if err := info.reportRecoveryLocale(ctx, typedClient, report); err != nil {
+		return err
+	}
+
+	if len(report.MismatchedDatabases) > 0 && policy == apiv1.CollationMismatchPolicyFail {
+		return fmt.Errorf("%w: %v", ErrCollationMismatch, report.MismatchedDatabases)
+	}
+
+	return nil
+}
+
+// reportRecoveryLocale writes the locale of the restored databases
+// in the cluster status
+func (info InitInfo) reportRecoveryLocale(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.RecoveryLocaleReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RecoveryLocale = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the locale of the restored databases in the cluster status: %w", err)
+	}
+
+	return nil
+}
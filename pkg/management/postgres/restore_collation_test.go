@@ -0,0 +1,138 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("collations of the restored databases", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("defaults to rebuilding the indexes", func() {
+		Expect(getCollationMismatchPolicy(&apiv1.Cluster{})).To(Equal(apiv1.CollationMismatchPolicyReindex))
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{OnCollationMismatch: apiv1.CollationMismatchPolicyFail},
+				},
+			},
+		}
+		Expect(getCollationMismatchPolicy(cluster)).To(Equal(apiv1.CollationMismatchPolicyFail))
+	})
+
+	It("detects a different version of the database collation", func() {
+		mock.ExpectQuery("pg_database_collation_actual_version").
+			WillReturnRows(sqlmock.NewRows([]string{"datcollate", "datctype", "datcollversion", "actual"}).
+				AddRow("en_US.UTF-8", "en_US.UTF-8", "2.28", "2.36"))
+		mock.ExpectQuery("pg_collation_actual_version").
+			WillReturnRows(sqlmock.NewRows([]string{"format"}))
+
+		collation, err := getDatabaseCollation(context.TODO(), db, 16)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(collation.collate).To(Equal("en_US.UTF-8"))
+		Expect(collation.version).To(Equal("2.28"))
+		Expect(collation.actualVersion).To(Equal("2.36"))
+		Expect(collation.hasMismatch()).To(BeTrue())
+	})
+
+	It("detects the collations with a different version before PostgreSQL 15", func() {
+		mock.ExpectQuery("SELECT datcollate, datctype FROM").
+			WillReturnRows(sqlmock.NewRows([]string{"datcollate", "datctype"}).AddRow("C", "C"))
+		mock.ExpectQuery("pg_collation_actual_version").
+			WillReturnRows(sqlmock.NewRows([]string{"format"}).AddRow(`public."de-x-icu"`))
+
+		collation, err := getDatabaseCollation(context.TODO(), db, 14)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(collation.mismatchedCollations).To(Equal([]string{`public."de-x-icu"`}))
+		Expect(collation.hasMismatch()).To(BeTrue())
+
+		Expect(databaseCollation{collate: "C", version: "2.36", actualVersion: "2.36"}.hasMismatch()).To(BeFalse())
+	})
+
+	It("rebuilds the collation-dependent indexes and refreshes the versions", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_index").
+			WillReturnRows(sqlmock.NewRows([]string{"format"}).
+				AddRow("public.customers_name_idx").
+				AddRow(`sales."Orders_code_idx"`))
+		mock.ExpectExec(regexp.QuoteMeta("REINDEX INDEX public.customers_name_idx")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`REINDEX INDEX sales."Orders_code_idx"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER COLLATION public."de-x-icu" REFRESH VERSION`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "app" REFRESH COLLATION VERSION`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(rebuildCollationDependentIndexes(context.TODO(), db, "app", 16, databaseCollation{
+			version:              "2.28",
+			actualVersion:        "2.36",
+			mismatchedCollations: []string{`public."de-x-icu"`},
+		})).To(Succeed())
+	})
+
+	It("reports the locale in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		report := &apiv1.RecoveryLocaleReport{
+			SourceCollate:          "en_US.UTF-8",
+			SourceCollationVersion: "2.28",
+			TargetLocale:           "C.UTF-8",
+			TargetCollationVersion: "2.36",
+			MismatchedDatabases:    []string{"app"},
+			ReindexedDatabases:     []string{"app"},
+		}
+
+		Expect(info.reportRecoveryLocale(context.TODO(), typedClient, report)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.RecoveryLocale).To(Equal(report))
+	})
+})
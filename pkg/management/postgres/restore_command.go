@@ -0,0 +1,78 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"strings"
+	"time"
+)
+
+// walFetchArguments are the arguments of the command fetching a WAL file
+// from the object store: the placeholders of the restore_command
+var walFetchArguments = []string{"%f", "%p"}
+
+// restoreCommand is the restore_command used by PostgreSQL to fetch the
+// WAL files. It is made of the command fetching a single WAL file, and of
+// the options requested by the user wrapping it, which are composed by
+// render in a fixed order, regardless of the order they are set in
+type restoreCommand struct {
+	// fetch is the command fetching a WAL file, without its arguments
+	fetch []string
+
+	// arguments are the arguments of the fetch, containing the
+	// placeholders of the restore_command
+	arguments []string
+
+	// fetchTimeout is the time a single fetch can take, zero
+	// meaning unlimited
+	fetchTimeout time.Duration
+
+	// fetchTimedOutExitCode is the exit code of a timed out fetch
+	fetchTimedOutExitCode string
+
+	// fetchRetries is the number of times a fetch failing with a
+	// transient error is retried
+	fetchRetries int32
+
+	// fetchRetryDelay is the time waited between two attempts
+	fetchRetryDelay time.Duration
+
+	// decryption is the command decrypting, in place, the fetched
+	// WAL file, if any
+	decryption []string
+
+	// credentials is the shell command printing the credentials to be
+	// used to fetch the WAL file, if any
+	credentials string
+
+	// throttleDelay is the pause made after having fetched a WAL file
+	throttleDelay time.Duration
+}
+
+// render builds the restore_command. The credentials are obtained first,
+// then the WAL file is fetched, within its timeout and its retries, and
+// finally decrypted, before the pause limiting the rate of the replay.
+// The timeout and the retries are grouped with the fetch they wrap, so
+// that the exit code of the group is the one of the fetch
+func (cmd restoreCommand) render() string {
+	result := cmd.renderWALFetch()
+	result = cmd.renderWALFetchRetries(result)
+	result = cmd.renderWALDecryption(result)
+	result = cmd.renderReplayThrottle(result)
+	result = cmd.renderCredentials(result)
+	return strings.Join(result, " ")
+}
@@ -0,0 +1,113 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore_command", func() {
+	var (
+		tempDir string
+		walPath string
+		fetch   string
+	)
+
+	BeforeEach(func() {
+		tempDir = GinkgoT().TempDir()
+		walPath = path.Join(tempDir, "RECOVERYXLOG")
+
+		// fetch is a stub of barman-cloud-wal-restore, writing the WAL
+		// file only when it has been given the expected credentials
+		fetch = path.Join(tempDir, "fetch")
+		Expect(os.WriteFile(fetch,
+			[]byte("#!/bin/sh\ntest \"$TOKEN\" = fresh && echo encrypted > \"$2\"\n"), 0o700)).To(Succeed()) // #nosec G306
+	})
+
+	// newCommand builds a restore_command with every option enabled,
+	// getting the credentials with the passed command
+	newCommand := func(credentials ...string) restoreCommand {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:          "origin",
+						WALFetchRetries: 2,
+						RetryPolicy: &apiv1.RestoreRetryPolicy{
+							InitialBackoff: &metav1.Duration{Duration: 10 * time.Millisecond},
+						},
+						ObjectStoreTimeouts: &apiv1.RecoveryObjectStoreTimeouts{
+							WALFetch: &metav1.Duration{Duration: 5 * time.Second},
+						},
+						WALDecryption: &apiv1.RecoveryDecryptionConfiguration{
+							Command: []string{"sed", "-i", "s/encrypted/decrypted/"},
+						},
+						CredentialsProvider: &apiv1.RecoveryCredentialsProvider{Command: credentials},
+					},
+				},
+			},
+		}
+
+		cmd := restoreCommand{fetch: []string{fetch}, arguments: walFetchArguments}.
+			withWALFetchTimeout(cluster).
+			withWALFetchRetries(cluster).
+			withWALDecryption(cluster).
+			withCredentialsProvider(cluster)
+		cmd.throttleDelay = 10 * time.Millisecond
+		return cmd
+	}
+
+	run := func(cmd restoreCommand) error {
+		command := renderRestoreCommand(cmd.render(), "000000010000000000000001", walPath)
+		return exec.Command("sh", "-c", command).Run() // #nosec G204
+	}
+
+	It("is a valid shell command with every option enabled", func() {
+		rendered := newCommand("echo", "TOKEN=fresh").render()
+		Expect(rendered).To(ContainSubstring("timeout"))
+		Expect(rendered).To(ContainSubstring("attempt"))
+		Expect(rendered).To(ContainSubstring("sed"))
+		Expect(rendered).To(ContainSubstring("credentials"))
+		Expect(rendered).To(ContainSubstring("sleep"))
+		Expect(exec.Command("sh", "-n", "-c", rendered).Run()).To(Succeed()) // #nosec G204
+	})
+
+	It("fetches and decrypts a WAL file with every option enabled", func() {
+		Expect(run(newCommand("echo", "TOKEN=fresh"))).To(Succeed())
+		Expect(os.ReadFile(walPath)).To(BeEquivalentTo("decrypted\n"))
+	})
+
+	It("doesn't fetch a WAL file when the credentials can't be obtained", func() {
+		Expect(run(newCommand("false"))).ToNot(Succeed())
+		Expect(walPath).ToNot(BeAnExistingFile())
+	})
+
+	It("doesn't decrypt a WAL file whose fetch failed", func() {
+		Expect(run(newCommand("echo", "TOKEN=stale"))).ToNot(Succeed())
+		Expect(walPath).ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,284 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// restoreSlotLeasePrefix is the prefix of the names of the leases
+	// representing the slots of the concurrent restores
+	restoreSlotLeasePrefix = "cnpg-restore-slot-"
+
+	// restoreSlotLeaseDuration is the time after which a slot whose lease
+	// hasn't been renewed can be taken by another restore
+	restoreSlotLeaseDuration = 60 * time.Second
+
+	// restoreSlotRenewInterval is the interval between two renewals
+	// of the lease of the slot held by the restore
+	restoreSlotRenewInterval = 20 * time.Second
+
+	// restoreSlotPollInterval is the interval between two attempts
+	// to acquire a slot, when all of them are taken
+	restoreSlotPollInterval = 10 * time.Second
+)
+
+// restoreSlotLimiter limits the number of restores running at the same
+// time across every cluster. Every restore holds one of a fixed number
+// of leases, stored in a shared namespace, while it runs
+type restoreSlotLimiter struct {
+	typedClient client.Client
+	namespace   string
+	limit       int
+
+	// the identity written in the lease of the slot held by the restore
+	holder string
+
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	pollInterval  time.Duration
+}
+
+// newRestoreSlotLimiter creates the limiter of the concurrent restores,
+// or nil when the number of concurrent restores is unlimited
+func (info InitInfo) newRestoreSlotLimiter(typedClient client.Client) *restoreSlotLimiter {
+	if info.RestoreConcurrencyLimit <= 0 {
+		return nil
+	}
+
+	return &restoreSlotLimiter{
+		typedClient:   typedClient,
+		namespace:     info.RestoreConcurrencyNamespace,
+		limit:         info.RestoreConcurrencyLimit,
+		holder:        info.Namespace + "/" + info.ClusterName,
+		leaseDuration: restoreSlotLeaseDuration,
+		renewInterval: restoreSlotRenewInterval,
+		pollInterval:  restoreSlotPollInterval,
+	}
+}
+
+// acquireRestoreSlot waits for a free slot, when the number of concurrent
+// restores is limited. The returned function releases the slot, and
+// must be called when the restore ends
+func (info InitInfo) acquireRestoreSlot(ctx context.Context, typedClient client.Client) (func(), error) {
+	limiter := info.newRestoreSlotLimiter(typedClient)
+	if limiter == nil {
+		return func() {}, nil
+	}
+
+	return limiter.acquire(ctx)
+}
+
+// restoreSlotLeaseName gets the name of the lease of a slot
+func restoreSlotLeaseName(index int) string {
+	return fmt.Sprintf("%s%d", restoreSlotLeasePrefix, index)
+}
+
+// acquire waits until one of the slots is free and takes it, renewing its
+// lease in the background until the returned function is called
+func (l *restoreSlotLimiter) acquire(ctx context.Context) (func(), error) {
+	contextLogger := log.FromContext(ctx)
+
+	ticker := time.NewTicker(l.pollInterval)
+	defer ticker.Stop()
+
+	waitingSince := time.Now()
+	for {
+		for index := 0; index < l.limit; index++ {
+			name := restoreSlotLeaseName(index)
+			acquired, err := l.tryAcquire(ctx, name)
+			if apierrors.IsForbidden(err) {
+				return nil, fmt.Errorf(
+					"the service account is not allowed to manage the lease %s in namespace %s, "+
+						"a Role and a RoleBinding granting it are needed in that namespace: %w",
+					name, l.namespace, err)
+			}
+			if err != nil {
+				contextLogger.Warning("Cannot acquire a restore slot, will retry",
+					"lease", name,
+					"error", err.Error())
+				continue
+			}
+			if !acquired {
+				continue
+			}
+
+			contextLogger.Info("Acquired a restore slot",
+				"lease", name,
+				"namespace", l.namespace,
+				"limit", l.limit,
+				"waited", time.Since(waitingSince).String())
+			return l.startRenewal(ctx, name), nil
+		}
+
+		contextLogger.Info("Every restore slot is taken, waiting for one to be released",
+			"namespace", l.namespace,
+			"limit", l.limit,
+			"waitingFor", time.Since(waitingSince).String())
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// tryAcquire takes a slot if it is free, if its holder didn't renew the
+// lease in time, or if it is already held by this restore, as it happens
+// when it is resumed
+func (l *restoreSlotLimiter) tryAcquire(ctx context.Context, name string) (bool, error) {
+	now := metav1.NowMicro()
+
+	var lease coordinationv1.Lease
+	err := l.typedClient.Get(ctx, client.ObjectKey{Namespace: l.namespace, Name: name}, &lease)
+	if apierrors.IsNotFound(err) {
+		lease = coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Namespace: l.namespace, Name: name},
+			Spec:       l.newLeaseSpec(now),
+		}
+		err = l.typedClient.Create(ctx, &lease)
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	holder := ptr.Deref(lease.Spec.HolderIdentity, "")
+	if holder != "" && holder != l.holder && !isLeaseExpired(&lease, now.Time) {
+		return false, nil
+	}
+
+	lease.Spec = l.newLeaseSpec(now)
+	err = l.typedClient.Update(ctx, &lease)
+	if apierrors.IsConflict(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// newLeaseSpec creates the content of the lease of a slot taken now
+func (l *restoreSlotLimiter) newLeaseSpec(now metav1.MicroTime) coordinationv1.LeaseSpec {
+	return coordinationv1.LeaseSpec{
+		HolderIdentity:       ptr.To(l.holder),
+		LeaseDurationSeconds: ptr.To(int32(l.leaseDuration.Seconds())),
+		AcquireTime:          &now,
+		RenewTime:            &now,
+	}
+}
+
+// isLeaseExpired checks if the holder of a lease didn't renew it in time
+func isLeaseExpired(lease *coordinationv1.Lease, now time.Time) bool {
+	if lease.Spec.RenewTime == nil || lease.Spec.LeaseDurationSeconds == nil {
+		return true
+	}
+
+	duration := time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	return lease.Spec.RenewTime.Add(duration).Before(now)
+}
+
+// startRenewal renews the lease of the slot in the background. The
+// returned function stops the renewal and releases the slot
+func (l *restoreSlotLimiter) startRenewal(ctx context.Context, name string) func() {
+	renewalCtx, stopRenewal := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(l.renewInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-renewalCtx.Done():
+				return
+			case <-ticker.C:
+				if err := l.renew(renewalCtx, name); err != nil {
+					log.FromContext(ctx).Warning("Cannot renew the lease of the restore slot",
+						"lease", name,
+						"error", err.Error())
+				}
+			}
+		}
+	}()
+
+	return func() {
+		stopRenewal()
+		<-done
+
+		// The slot is released even when the restore context
+		// has been cancelled
+		releaseCtx, cancel := context.WithTimeout(context.Background(), l.renewInterval)
+		defer cancel()
+		l.release(releaseCtx, name)
+	}
+}
+
+// renew extends the lease of the slot held by the restore
+func (l *restoreSlotLimiter) renew(ctx context.Context, name string) error {
+	var lease coordinationv1.Lease
+	if err := l.typedClient.Get(ctx, client.ObjectKey{Namespace: l.namespace, Name: name}, &lease); err != nil {
+		return err
+	}
+
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != l.holder {
+		return fmt.Errorf("the slot has been taken by %s", ptr.Deref(lease.Spec.HolderIdentity, "nobody"))
+	}
+
+	lease.Spec.RenewTime = ptr.To(metav1.NowMicro())
+	return l.typedClient.Update(ctx, &lease)
+}
+
+// release frees the slot held by the restore, unless it has already
+// been taken by another restore. Errors are only logged, as the lease
+// will expire anyway
+func (l *restoreSlotLimiter) release(ctx context.Context, name string) {
+	contextLogger := log.FromContext(ctx)
+
+	var lease coordinationv1.Lease
+	if err := l.typedClient.Get(ctx, client.ObjectKey{Namespace: l.namespace, Name: name}, &lease); err != nil {
+		contextLogger.Warning("Cannot release the restore slot", "lease", name, "error", err.Error())
+		return
+	}
+
+	if ptr.Deref(lease.Spec.HolderIdentity, "") != l.holder {
+		return
+	}
+
+	lease.Spec.HolderIdentity = nil
+	if err := l.typedClient.Update(ctx, &lease); err != nil {
+		contextLogger.Warning("Cannot release the restore slot", "lease", name, "error", err.Error())
+		return
+	}
+
+	contextLogger.Info("Released the restore slot", "lease", name, "namespace", l.namespace)
+}
@@ -0,0 +1,130 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Restore concurrency limit", func() {
+	newLimiter := func(typedClient client.Client, clusterName string) *restoreSlotLimiter {
+		return InitInfo{
+			Namespace:                   "default",
+			ClusterName:                 clusterName,
+			RestoreConcurrencyLimit:     1,
+			RestoreConcurrencyNamespace: "cnpg-system",
+		}.newRestoreSlotLimiter(typedClient)
+	}
+
+	getLease := func(typedClient client.Client) *coordinationv1.Lease {
+		var lease coordinationv1.Lease
+		Expect(typedClient.Get(context.TODO(),
+			client.ObjectKey{Namespace: "cnpg-system", Name: "cnpg-restore-slot-0"}, &lease)).To(Succeed())
+		return &lease
+	}
+
+	It("doesn't limit the restores by default", func() {
+		Expect(InitInfo{}.newRestoreSlotLimiter(fake.NewClientBuilder().Build())).To(BeNil())
+
+		release, err := InitInfo{}.acquireRestoreSlot(context.TODO(), fake.NewClientBuilder().Build())
+		Expect(err).ToNot(HaveOccurred())
+		release()
+	})
+
+	It("acquires and releases a slot", func() {
+		typedClient := fake.NewClientBuilder().WithScheme(scheme.BuildWithAllKnownScheme()).Build()
+
+		release, err := newLimiter(typedClient, "first").acquire(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getLease(typedClient).Spec.HolderIdentity).To(HaveValue(Equal("default/first")))
+
+		release()
+		Expect(getLease(typedClient).Spec.HolderIdentity).To(BeNil())
+
+		release, err = newLimiter(typedClient, "second").acquire(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getLease(typedClient).Spec.HolderIdentity).To(HaveValue(Equal("default/second")))
+		release()
+	})
+
+	It("waits for a slot until the context is cancelled", func() {
+		typedClient := fake.NewClientBuilder().WithScheme(scheme.BuildWithAllKnownScheme()).Build()
+
+		release, err := newLimiter(typedClient, "first").acquire(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		defer release()
+
+		limiter := newLimiter(typedClient, "second")
+		limiter.pollInterval = 10 * time.Millisecond
+		ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+		defer cancel()
+
+		_, err = limiter.acquire(ctx)
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		Expect(getLease(typedClient).Spec.HolderIdentity).To(HaveValue(Equal("default/first")))
+	})
+
+	It("takes a slot whose lease has expired", func() {
+		renewTime := metav1.NewMicroTime(time.Now().Add(-2 * restoreSlotLeaseDuration))
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(&coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "cnpg-system", Name: "cnpg-restore-slot-0"},
+				Spec: coordinationv1.LeaseSpec{
+					HolderIdentity:       ptr.To("default/crashed"),
+					LeaseDurationSeconds: ptr.To(int32(60)),
+					RenewTime:            &renewTime,
+				},
+			}).
+			Build()
+
+		release, err := newLimiter(typedClient, "first").acquire(context.TODO())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(getLease(typedClient).Spec.HolderIdentity).To(HaveValue(Equal("default/first")))
+		release()
+	})
+
+	It("fails when the service account can't manage the leases", func() {
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+					return apierrors.NewForbidden(
+						schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, "cnpg-restore-slot-0", nil)
+				},
+			}).
+			Build()
+
+		_, err := newLimiter(typedClient, "first").acquire(context.TODO())
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,98 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+)
+
+// ErrInvalidPostgresConfiguration is raised when the configuration
+// generated for the restored instance is rejected by PostgreSQL
+var ErrInvalidPostgresConfiguration = errors.New("the configuration of the restored instance is not valid")
+
+// rejectedParameterRe extracts the name of the parameter from the
+// messages raised by PostgreSQL while parsing the configuration files
+var rejectedParameterRe = regexp.MustCompile(`parameter "([^"]+)"`)
+
+// validatePostgresConfiguration checks that the configuration files
+// generated in the data directory are accepted by the PostgreSQL build
+// of the image, before the instance is started for the recovery. This is
+// done by asking PostgreSQL to report the value of a parameter, which
+// requires every configuration file to be parsed
+func (info InitInfo) validatePostgresConfiguration(ctx context.Context, env []string) error {
+	return checkPostgresConfiguration(ctx, postgresName, info.PgData, env)
+}
+
+// checkPostgresConfiguration uses the passed postgres executable
+// to check the configuration contained in a data directory
+func checkPostgresConfiguration(ctx context.Context, postgresExecutable, pgData string, env []string) error {
+	contextLogger := log.FromContext(ctx)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, postgresExecutable, "-D", pgData, "-C", "data_directory") // #nosec G204
+	cmd.Env = env
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err == nil {
+		contextLogger.Info("The configuration of the restored instance is valid")
+		return nil
+	}
+
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) {
+		return fmt.Errorf("while checking the configuration of the restored instance: %w", err)
+	}
+
+	messages, parameters := parseConfigurationErrors(stderr.String())
+	contextLogger.Error(err, "The configuration of the restored instance has been rejected by PostgreSQL",
+		"rejectedParameters", parameters,
+		"messages", messages)
+	return fmt.Errorf("%w: rejected parameters %v: %s",
+		ErrInvalidPostgresConfiguration, parameters, strings.Join(messages, "; "))
+}
+
+// parseConfigurationErrors extracts, from the output of PostgreSQL, the
+// messages describing the errors, and the names of the parameters they
+// refer to
+func parseConfigurationErrors(output string) ([]string, []string) {
+	var messages []string
+	parameters := stringset.New()
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		messages = append(messages, line)
+		if matches := rejectedParameterRe.FindStringSubmatch(line); matches != nil {
+			parameters.Put(matches[1])
+		}
+	}
+
+	return messages, parameters.ToSortedList()
+}
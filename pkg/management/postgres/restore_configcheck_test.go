@@ -0,0 +1,59 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("validating the configuration of the restored instance", func() {
+	writeFakePostgres := func(script string) string {
+		executable := path.Join(GinkgoT().TempDir(), "postgres")
+		Expect(os.WriteFile(executable, []byte("#!/bin/sh\n"+script), 0o700)).To(Succeed()) // #nosec G306
+		return executable
+	}
+
+	It("extracts the rejected parameters from the messages of PostgreSQL", func() {
+		messages, parameters := parseConfigurationErrors(
+			`LOG:  unrecognized configuration parameter "shared_bufers" in file "custom.conf" line 3
+LOG:  invalid value for parameter "wal_level": "verbose"
+FATAL:  configuration file "custom.conf" contains errors
+`)
+		Expect(messages).To(HaveLen(3))
+		Expect(parameters).To(Equal([]string{"shared_bufers", "wal_level"}))
+	})
+
+	It("accepts a configuration parsed without errors", func() {
+		executable := writeFakePostgres("echo /var/lib/postgresql/data/pgdata\n")
+		Expect(checkPostgresConfiguration(context.TODO(), executable, GinkgoT().TempDir(), nil)).To(Succeed())
+	})
+
+	It("lists the settings rejected by PostgreSQL", func() {
+		executable := writeFakePostgres(`echo 'LOG:  unrecognized configuration parameter "shared_bufers"' >&2
+echo 'FATAL:  configuration file "custom.conf" contains errors' >&2
+exit 1
+`)
+		err := checkPostgresConfiguration(context.TODO(), executable, GinkgoT().TempDir(), nil)
+		Expect(err).To(MatchError(ErrInvalidPostgresConfiguration))
+		Expect(err.Error()).To(ContainSubstring("shared_bufers"))
+	})
+})
@@ -0,0 +1,167 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// connectionLimitsQuery lists the connection limit of every
+// database currently accepting connections
+const connectionLimitsQuery = `
+SELECT datname, datconnlimit
+FROM pg_catalog.pg_database
+WHERE datallowconn AND NOT datistemplate
+ORDER BY 1`
+
+// startConnectionRamp lowers the connection limit of the restored
+// databases for the warm-up of the cluster, and reports the restored
+// limits in the cluster status, so that the instance manager of the
+// primary can raise them back once the warm-up is completed
+func (info InitInfo) startConnectionRamp(
+	ctx context.Context,
+	db *sql.DB,
+	ramp *apiv1.RecoveryConnectionRamp,
+) error {
+	var lowered []apiv1.ConnectionRampDatabase
+	if err := retryPostRecoveryWrite(ctx, "connectionRamp", func() (err error) {
+		lowered, err = lowerConnectionLimits(ctx, db, ramp.ConnectionLimit)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Starting the connection ramp of the restored cluster",
+		"connectionLimit", ramp.ConnectionLimit,
+		"warmupTime", ramp.GetWarmupTime().String(),
+		"databases", len(lowered))
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+
+	return info.reportConnectionRamp(ctx, typedClient, &apiv1.ConnectionRampReport{
+		StartedAt: metav1.Now(),
+		Databases: lowered,
+	})
+}
+
+// lowerConnectionLimits sets the passed connection limit on every database
+// accepting connections whose limit is higher, returning the databases
+// changed with their previous limit. The limits are changed in a single
+// transaction, so that a retry never mistakes a lowered limit for the
+// restored one
+func lowerConnectionLimits(
+	ctx context.Context,
+	db *sql.DB,
+	connectionLimit int32,
+) (result []apiv1.ConnectionRampDatabase, err error) {
+	contextLogger := log.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("while starting a transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, connectionLimitsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("while listing the connection limits: %w", err)
+	}
+
+	var databases []apiv1.ConnectionRampDatabase
+	for rows.Next() {
+		var database apiv1.ConnectionRampDatabase
+		if err := rows.Scan(&database.Name, &database.ConnectionLimit); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		databases = append(databases, database)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	for _, database := range databases {
+		if database.ConnectionLimit >= 0 && database.ConnectionLimit <= connectionLimit {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s CONNECTION LIMIT %d",
+			pgx.Identifier{database.Name}.Sanitize(), connectionLimit)); err != nil {
+			return nil, fmt.Errorf("while lowering the connection limit of database %s: %w", database.Name, err)
+		}
+		contextLogger.Info("Lowered the connection limit of the restored database for the warm-up",
+			"database", database.Name,
+			"restoredConnectionLimit", database.ConnectionLimit,
+			"connectionLimit", connectionLimit)
+		result = append(result, database)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("while committing the connection limits: %w", err)
+	}
+
+	return result, nil
+}
+
+// reportConnectionRamp writes the connection ramp of the restored
+// cluster in the cluster status
+func (info InitInfo) reportConnectionRamp(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.ConnectionRampReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.ConnectionRamp = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the connection ramp in the cluster status: %w", err)
+	}
+
+	return nil
+}
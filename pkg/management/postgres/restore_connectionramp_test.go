@@ -0,0 +1,123 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("connection ramp of the restored cluster", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("lowers the connection limits higher than the warm-up one", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("WHERE datallowconn AND NOT datistemplate").
+			WillReturnRows(sqlmock.NewRows([]string{"datname", "datconnlimit"}).
+				AddRow("Billing", 100).
+				AddRow("app", -1).
+				AddRow("hr", 5).
+				AddRow("postgres", -1))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "Billing" CONNECTION LIMIT 10`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "app" CONNECTION LIMIT 10`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "postgres" CONNECTION LIMIT 10`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		lowered, err := lowerConnectionLimits(context.TODO(), db, 10)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(lowered).To(Equal([]apiv1.ConnectionRampDatabase{
+			{Name: "Billing", ConnectionLimit: 100},
+			{Name: "app", ConnectionLimit: -1},
+			{Name: "postgres", ConnectionLimit: -1},
+		}))
+	})
+
+	It("rolls back the connection limits when one of them can't be lowered", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("WHERE datallowconn AND NOT datistemplate").
+			WillReturnRows(sqlmock.NewRows([]string{"datname", "datconnlimit"}).
+				AddRow("app", -1).
+				AddRow("hr", -1))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "app" CONNECTION LIMIT 10`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "hr" CONNECTION LIMIT 10`)).
+			WillReturnError(errors.New("permission denied"))
+		mock.ExpectRollback()
+
+		_, err := lowerConnectionLimits(context.TODO(), db, 10)
+		Expect(err).To(MatchError(ContainSubstring("while lowering the connection limit of database hr")))
+	})
+
+	It("reports the connection ramp in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		report := &apiv1.ConnectionRampReport{
+			StartedAt: metav1.NewTime(time.Now().Truncate(time.Second)),
+			Databases: []apiv1.ConnectionRampDatabase{{Name: "app", ConnectionLimit: -1}},
+		}
+
+		Expect(info.reportConnectionRamp(context.TODO(), typedClient, report)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.ConnectionRamp.Databases).To(Equal(report.Databases))
+		Expect(result.Status.ConnectionRamp.StartedAt.Equal(&report.StartedAt)).To(BeTrue())
+		Expect(result.Status.ConnectionRamp.CompletedAt).To(BeNil())
+	})
+
+	It("uses the default warm-up time", func() {
+		Expect((&apiv1.Cluster{}).GetConnectionRamp().GetWarmupTime()).To(Equal(apiv1.DefaultConnectionRampWarmupTime))
+		Expect((&apiv1.RecoveryConnectionRamp{
+			WarmupTime: &metav1.Duration{Duration: time.Minute},
+		}).GetWarmupTime()).To(Equal(time.Minute))
+	})
+})
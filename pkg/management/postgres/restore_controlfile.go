@@ -0,0 +1,195 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// backupLabelStartLSNPrefix is the line of the backup_label file
+// reporting the LSN where the backup started
+const backupLabelStartLSNPrefix = "START WAL LOCATION:"
+
+// ErrControlFileRepairUnsafe is raised when the repair of pg_control has
+// been requested, but can't be executed safely
+var ErrControlFileRepairUnsafe = errors.New("the state of pg_control can't be safely repaired")
+
+// cleanControlFileStates are the states of pg_control from which the
+// archive recovery can be started without a backup_label file, as the
+// data directory has been shut down cleanly or was already in archive
+// recovery
+var cleanControlFileStates = stringset.From([]string{
+	"shut down",
+	"shut down in recovery",
+	"in archive recovery",
+})
+
+// controlFileDiagnosis is the state of a restored data directory, as
+// recorded in pg_control
+type controlFileDiagnosis struct {
+	state                  string
+	checkpointLocation     string
+	redoLocation           string
+	minRecoveryEndLocation string
+	hasBackupLabel         bool
+
+	// the reason why the archive recovery can't be safely started,
+	// empty when it can
+	problem string
+}
+
+// diagnoseControlFile checks if the archive recovery can be safely started
+// from the state recorded in pg_control. Without a backup_label file,
+// PostgreSQL starts the recovery from the latest checkpoint, which is only
+// safe when the data directory has been shut down cleanly or was already
+// in archive recovery
+func diagnoseControlFile(controlData map[string]string, hasBackupLabel bool) controlFileDiagnosis {
+	diagnosis := controlFileDiagnosis{
+		state:                  controlData["Database cluster state"],
+		checkpointLocation:     controlData["Latest checkpoint location"],
+		redoLocation:           controlData[string(utils.PgControlDataKeyLatestCheckpointREDOLocation)],
+		minRecoveryEndLocation: controlData["Minimum recovery ending location"],
+		hasBackupLabel:         hasBackupLabel,
+	}
+
+	switch {
+	case diagnosis.state == "":
+		diagnosis.problem = "no 'Database cluster state' section in the pg_controldata output"
+
+	case !hasBackupLabel && !cleanControlFileStates.Has(diagnosis.state):
+		diagnosis.problem = fmt.Sprintf("the data directory is %q without a backup_label file, "+
+			"and the recovery would start from the latest checkpoint", diagnosis.state)
+	}
+
+	return diagnosis
+}
+
+// checkControlFile diagnoses, without changing anything, the state
+// recorded in the pg_control file of the restored data directory. When
+// requested by the user, and only when the archive recovery can't be
+// safely started, the backup_label file of the restored backup is written
+func (info InitInfo) checkControlFile(ctx context.Context, cluster *apiv1.Cluster, backup *apiv1.Backup) error {
+	contextLogger := log.FromContext(ctx)
+	repair := cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.RepairControlFile
+
+	output, err := info.GetInstance().GetPgControldata()
+	if err != nil {
+		if repair {
+			return fmt.Errorf("while running pg_controldata to diagnose the restored data directory: %w", err)
+		}
+		contextLogger.Warning("Cannot run pg_controldata to diagnose the restored data directory",
+			"error", err.Error())
+		return nil
+	}
+
+	hasBackupLabel, err := fileutils.FileExists(path.Join(info.PgData, constants.BackupLabelFile))
+	if err != nil {
+		return fmt.Errorf("while checking the backup_label file: %w", err)
+	}
+
+	diagnosis := diagnoseControlFile(utils.ParsePgControldataOutput(output), hasBackupLabel)
+	contextLogger = contextLogger.WithValues(
+		"state", diagnosis.state,
+		"checkpointLocation", diagnosis.checkpointLocation,
+		"redoLocation", diagnosis.redoLocation,
+		"minRecoveryEndLocation", diagnosis.minRecoveryEndLocation,
+		"backupLabel", diagnosis.hasBackupLabel)
+
+	if diagnosis.problem == "" {
+		contextLogger.Info("The state of pg_control allows the archive recovery")
+		return nil
+	}
+
+	if !repair {
+		contextLogger.Warning("The state of pg_control may prevent a correct recovery, "+
+			"set repairControlFile to repair it",
+			"problem", diagnosis.problem)
+		return nil
+	}
+
+	contextLogger.Warning("The state of pg_control may prevent a correct recovery, repairing it",
+		"problem", diagnosis.problem)
+	return info.repairControlFile(ctx, backup)
+}
+
+// repairControlFile writes the backup_label file of the restored backup,
+// making PostgreSQL start the recovery from the beginning of the backup.
+// The content comes from the backup itself, or from the backup_label.old
+// file left by a previous attempt, only when it refers to the same backup.
+// pg_control is never changed, as discarding its state can't be done
+// without the risk of losing data
+func (info InitInfo) repairControlFile(ctx context.Context, backup *apiv1.Backup) error {
+	labelPath := path.Join(info.PgData, constants.BackupLabelFile)
+	content := info.BackupLabelFile
+	origin := "backup"
+
+	if len(content) == 0 {
+		oldContent, err := os.ReadFile(labelPath + ".old")
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("while reading the backup_label.old file: %w", err)
+		}
+
+		startLSN := getBackupLabelStartLSN(oldContent)
+		if backup == nil || startLSN == "" || startLSN != backup.Status.BeginLSN {
+			return fmt.Errorf("%w: the backup_label file of the restored backup is not available",
+				ErrControlFileRepairUnsafe)
+		}
+		content = oldContent
+		origin = "backup_label.old"
+	}
+
+	if _, err := fileutils.WriteFileAtomic(labelPath, content, 0o600); err != nil {
+		return fmt.Errorf("while writing the backup_label file: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Repaired the restored data directory by writing the backup_label file",
+		"origin", origin,
+		"startLSN", getBackupLabelStartLSN(content))
+	return nil
+}
+
+// getBackupLabelStartLSN gets the LSN where the backup started from
+// the content of a backup_label file, if available
+func getBackupLabelStartLSN(backupLabel []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(backupLabel))
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), backupLabelStartLSNPrefix)
+		if !found {
+			continue
+		}
+
+		lsn, _, _ := strings.Cut(strings.TrimSpace(value), " ")
+		return lsn
+	}
+
+	return ""
+}
@@ -0,0 +1,94 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("state of pg_control in the restored data directory", func() {
+	const backupLabel = "START WAL LOCATION: 0/2000028 (file 000000010000000000000002)\n" +
+		"CHECKPOINT LOCATION: 0/2000060\n" +
+		"START TIMELINE: 1\n"
+
+	controlData := func(state string) map[string]string {
+		return map[string]string{
+			"Database cluster state":            state,
+			"Latest checkpoint location":        "0/3000060",
+			"Latest checkpoint's REDO location": "0/3000028",
+			"Minimum recovery ending location":  "0/0",
+		}
+	}
+
+	It("accepts the states from which the archive recovery can be started", func() {
+		Expect(diagnoseControlFile(controlData("in production"), true).problem).To(BeEmpty())
+		Expect(diagnoseControlFile(controlData("shut down"), false).problem).To(BeEmpty())
+		Expect(diagnoseControlFile(controlData("in archive recovery"), false).problem).To(BeEmpty())
+
+		diagnosis := diagnoseControlFile(controlData("shut down in recovery"), false)
+		Expect(diagnosis.problem).To(BeEmpty())
+		Expect(diagnosis.checkpointLocation).To(Equal("0/3000060"))
+		Expect(diagnosis.redoLocation).To(Equal("0/3000028"))
+	})
+
+	It("detects a data directory not shut down cleanly without a backup_label file", func() {
+		Expect(diagnoseControlFile(controlData("in production"), false).problem).
+			To(ContainSubstring(`"in production" without a backup_label file`))
+		Expect(diagnoseControlFile(controlData("in crash recovery"), false).problem).ToNot(BeEmpty())
+		Expect(diagnoseControlFile(map[string]string{}, true).problem).ToNot(BeEmpty())
+	})
+
+	It("reads the start LSN of the backup_label file", func() {
+		Expect(getBackupLabelStartLSN([]byte(backupLabel))).To(Equal("0/2000028"))
+		Expect(getBackupLabelStartLSN(nil)).To(BeEmpty())
+	})
+
+	It("repairs the data directory with the backup_label file of the backup", func() {
+		info := InitInfo{PgData: GinkgoT().TempDir(), BackupLabelFile: []byte(backupLabel)}
+		Expect(info.repairControlFile(context.TODO(), nil)).To(Succeed())
+		Expect(path.Join(info.PgData, constants.BackupLabelFile)).To(BeARegularFile())
+	})
+
+	It("repairs the data directory with a backup_label.old file of the same backup", func() {
+		info := InitInfo{PgData: GinkgoT().TempDir()}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{BeginLSN: "0/2000028"}}
+		Expect(os.WriteFile(path.Join(info.PgData, "backup_label.old"), []byte(backupLabel), 0o600)).To(Succeed())
+
+		Expect(info.repairControlFile(context.TODO(), backup)).To(Succeed())
+		content, err := os.ReadFile(path.Join(info.PgData, constants.BackupLabelFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal(backupLabel))
+	})
+
+	It("refuses to repair the data directory without the backup_label file of the backup", func() {
+		info := InitInfo{PgData: GinkgoT().TempDir()}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{BeginLSN: "0/5000028"}}
+		Expect(info.repairControlFile(context.TODO(), backup)).To(MatchError(ErrControlFileRepairUnsafe))
+
+		Expect(os.WriteFile(path.Join(info.PgData, "backup_label.old"), []byte(backupLabel), 0o600)).To(Succeed())
+		Expect(info.repairControlFile(context.TODO(), backup)).To(MatchError(ErrControlFileRepairUnsafe))
+		Expect(path.Join(info.PgData, constants.BackupLabelFile)).ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,361 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	barmanCredentials "github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/credentials"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// credentialsVariablePattern matches the names of the environment
+// variables that can be set by a credentials provider command
+var credentialsVariablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CredentialsProvider gets the credentials used by the barman-cloud
+// commands to access the object store. The credentials read from the
+// Kubernetes secrets of the object store configuration are the base
+// environment of every command, while the provider requested in the
+// cluster, or the one set in InitInfo to plug in a different secret
+// backend, is invoked right before each of them is executed, so that
+// short-lived credentials are always fresh
+type CredentialsProvider interface {
+	// Credentials gets the environment of a barman-cloud command,
+	// adding the credentials to the passed one
+	Credentials(ctx context.Context, env []string) ([]string, error)
+}
+
+// staticCredentialsProvider is the CredentialsProvider using the
+// credentials read from the object store configuration when the
+// restore starts, which are already part of the environment
+type staticCredentialsProvider struct{}
+
+// Credentials implements the CredentialsProvider interface
+func (staticCredentialsProvider) Credentials(_ context.Context, env []string) ([]string, error) {
+	return env, nil
+}
+
+// secretCredentialsProvider is the CredentialsProvider reading the
+// credentials from the Kubernetes secrets referenced by the
+// configuration of an object store
+type secretCredentialsProvider struct {
+	client        client.Client
+	namespace     string
+	configuration *apiv1.BarmanObjectStoreConfiguration
+}
+
+// Credentials implements the CredentialsProvider interface
+func (p secretCredentialsProvider) Credentials(ctx context.Context, env []string) ([]string, error) {
+	return barmanCredentials.EnvSetRestoreCloudCredentials(ctx, p.client, p.namespace, p.configuration, env)
+}
+
+// objectStoreEnvironment gets the base environment of the barman-cloud
+// commands accessing the passed object store, containing the credentials
+// read from its Kubernetes secrets
+func objectStoreEnvironment(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	configuration *apiv1.BarmanObjectStoreConfiguration,
+) ([]string, error) {
+	provider := secretCredentialsProvider{
+		client:        typedClient,
+		namespace:     cluster.Namespace,
+		configuration: configuration,
+	}
+	return provider.Credentials(ctx, os.Environ())
+}
+
+// fileCredentialsProvider is the CredentialsProvider reading the
+// credentials from a directory containing one file per variable,
+// named after it, like a mounted secret
+type fileCredentialsProvider struct {
+	directory string
+}
+
+// Credentials implements the CredentialsProvider interface
+func (p fileCredentialsProvider) Credentials(ctx context.Context, env []string) ([]string, error) {
+	entries, err := os.ReadDir(p.directory)
+	if err != nil {
+		return nil, fmt.Errorf("while listing the credentials directory %s: %w", p.directory, err)
+	}
+
+	var credentials []string
+	for _, entry := range entries {
+		// The hidden entries are the internal ones of the projected
+		// volumes, like ..data
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		fileName := filepath.Join(p.directory, entry.Name())
+		stat, err := os.Stat(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("while checking the credentials file %s: %w", fileName, err)
+		}
+		if !stat.Mode().IsRegular() {
+			continue
+		}
+
+		value, err := fileutils.ReadFile(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("while reading the credentials file %s: %w", fileName, err)
+		}
+		value = bytes.TrimRight(value, "\r\n")
+		if bytes.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("the credentials file %s contains a line break", fileName)
+		}
+		credentials = append(credentials, entry.Name()+"="+string(value))
+	}
+
+	credentials, err = parseCredentials(strings.Join(credentials, "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("in the credentials directory %s: %w", p.directory, err)
+	}
+
+	log.FromContext(ctx).Debug("Read the credentials for the object store",
+		"directory", p.directory,
+		"variables", credentialNames(credentials))
+
+	return append(append([]string{}, env...), credentials...), nil
+}
+
+// commandCredentialsProvider is the CredentialsProvider executing a
+// command that prints the credentials on its standard output
+type commandCredentialsProvider struct {
+	command []string
+}
+
+// Credentials implements the CredentialsProvider interface
+func (p commandCredentialsProvider) Credentials(ctx context.Context, env []string) ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...) // #nosec G204
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("while executing the credentials provider command %s: %w: %s",
+			p.command[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	credentials, err := parseCredentials(stdout.String())
+	if err != nil {
+		return nil, err
+	}
+
+	log.FromContext(ctx).Debug("Obtained fresh credentials for the object store",
+		"variables", credentialNames(credentials))
+
+	return append(append([]string{}, env...), credentials...), nil
+}
+
+// credentialNames gets the names of the passed credentials,
+// which can be logged, as opposed to their values
+func credentialNames(credentials []string) []string {
+	names := make([]string, 0, len(credentials))
+	for _, credential := range credentials {
+		name, _, _ := strings.Cut(credential, "=")
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// parseCredentials parses the credentials obtained by a provider,
+// made of `NAME=value` lines
+func parseCredentials(output string) ([]string, error) {
+	var result []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if !found || !credentialsVariablePattern.MatchString(name) {
+			return nil, fmt.Errorf("the credentials provider returned an invalid line, "+
+				"NAME=value expected for variable %q", name)
+		}
+		if strings.ContainsAny(value, " \t") {
+			return nil, fmt.Errorf("the value of the credentials variable %s contains spaces", name)
+		}
+
+		result = append(result, line)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("the credentials provider didn't return any credential")
+	}
+
+	return result, nil
+}
+
+// getRecoveryCredentialsProvider gets the provider of the
+// credentials of the object store requested by the user, if any
+func getRecoveryCredentialsProvider(cluster *apiv1.Cluster) *apiv1.RecoveryCredentialsProvider {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.CredentialsProvider
+}
+
+// credentialsProvider gets the CredentialsProvider to be used during
+// the restore of the passed cluster
+func (info InitInfo) credentialsProvider(cluster *apiv1.Cluster) CredentialsProvider {
+	if info.CredentialsProvider != nil {
+		return info.CredentialsProvider
+	}
+
+	provider := getRecoveryCredentialsProvider(cluster)
+	switch {
+	case provider == nil:
+		return staticCredentialsProvider{}
+	case len(provider.Command) > 0:
+		return commandCredentialsProvider{command: provider.Command}
+	case provider.Directory != "":
+		return fileCredentialsProvider{directory: provider.Directory}
+	default:
+		return staticCredentialsProvider{}
+	}
+}
+
+// credentialsShellCommand gets the shell command printing the credentials
+// of the provider requested by the user, one `NAME=value` per line, or
+// an empty string when there's no provider
+func credentialsShellCommand(provider *apiv1.RecoveryCredentialsProvider) string {
+	switch {
+	case provider == nil:
+		return ""
+	case len(provider.Command) > 0:
+		return strings.Join(provider.Command, " ")
+	case provider.Directory != "":
+		// The files are read as fileCredentialsProvider does: the hidden
+		// entries and the ones not being regular files are skipped, the
+		// trailing line breaks are removed, and the names not being valid
+		// variable names and the values containing blanks or line breaks
+		// are rejected, as well as a directory without credentials
+		return "n=0; for f in \"" + provider.Directory + "\"/*; do " +
+			"[ -f \"$f\" ] || continue; " +
+			"case \"${f##*/}\" in [!A-Za-z_]*|*[!A-Za-z0-9_]*) exit 1;; esac; " +
+			"v=$(tr \"\\r\" \"\\n\" < \"$f\"); " +
+			"case \"$v\" in *[[:space:]]*) exit 1;; esac; " +
+			"echo \"${f##*/}=$v\"; n=1; done; [ $n = 1 ]"
+	default:
+		return ""
+	}
+}
+
+// withCredentialsProvider makes the restore_command obtain fresh
+// credentials, from the credentials provider, before fetching every WAL file
+func (cmd restoreCommand) withCredentialsProvider(cluster *apiv1.Cluster) restoreCommand {
+	cmd.credentials = credentialsShellCommand(getRecoveryCredentialsProvider(cluster))
+	return cmd
+}
+
+// renderCredentials prepends to the passed fetch of a WAL file the
+// command obtaining its credentials, if any. The credentials are
+// exported one line at a time, quoted, so that their values are
+// not subject to pathname expansion. The fetch is executed in the
+// same pipeline stage reading them, as it can be a subshell
+func (cmd restoreCommand) renderCredentials(fetch []string) []string {
+	if cmd.credentials == "" {
+		return fetch
+	}
+
+	result := []string{
+		"credentials=$(" + cmd.credentials + ")", "&&",
+		"printf", `"%%s"`, `"$credentials"`, "|", "{",
+		"while", "IFS=", "read", "-r", "line", "||", "[", "-n", `"$line"`, "];", "do",
+		"[", "-z", `"$line"`, "]", "||", "export", `"$line";`, "done;",
+	}
+	result = append(result, fetch...)
+	return append(result, "; }")
+}
+
+// credentialsBarmanRunner is a BarmanRunner obtaining the credentials
+// from a CredentialsProvider right before each barman-cloud command
+type credentialsBarmanRunner struct {
+	runner   BarmanRunner
+	provider CredentialsProvider
+}
+
+// credentials gets the environment of a barman-cloud command
+// from the CredentialsProvider
+func (r credentialsBarmanRunner) credentials(ctx context.Context, env []string) ([]string, error) {
+	env, err := r.provider.Credentials(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("while getting the credentials of the object store: %w", err)
+	}
+
+	return env, nil
+}
+
+// Restore implements the BarmanRunner interface
+func (r credentialsBarmanRunner) Restore(ctx context.Context, options []string, env []string) error {
+	env, err := r.credentials(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	return r.runner.Restore(ctx, options, env)
+}
+
+// WALRestore implements the BarmanRunner interface
+func (r credentialsBarmanRunner) WALRestore(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	env []string,
+	walName string,
+	destinationPath string,
+	options []string,
+) error {
+	env, err := r.credentials(ctx, env)
+	if err != nil {
+		return err
+	}
+
+	return r.runner.WALRestore(ctx, cluster, env, walName, destinationPath, options)
+}
+
+// ListBackups implements the BarmanRunner interface
+func (r credentialsBarmanRunner) ListBackups(
+	ctx context.Context,
+	configuration *apiv1.BarmanObjectStoreConfiguration,
+	serverName string,
+	env []string,
+) (*catalog.Catalog, error) {
+	env, err := r.credentials(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.runner.ListBackups(ctx, configuration, serverName, env)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("credentials provider", func() {
+	newCluster := func(command ...string) *apiv1.Cluster {
+		return &apiv1.Cluster{Spec: apiv1.ClusterSpec{Bootstrap: &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{
+				Source:              "origin",
+				CredentialsProvider: &apiv1.RecoveryCredentialsProvider{Command: command},
+			},
+		}}}
+	}
+
+	It("parses the credentials printed by the command", func() {
+		Expect(parseCredentials("AWS_ACCESS_KEY_ID=key\n\nAWS_SESSION_TOKEN=a/b+c==\n")).
+			To(Equal([]string{"AWS_ACCESS_KEY_ID=key", "AWS_SESSION_TOKEN=a/b+c=="}))
+
+		_, err := parseCredentials("not a credential\n")
+		Expect(err).To(HaveOccurred())
+		_, err = parseCredentials("TOKEN=with spaces\n")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).ToNot(ContainSubstring("with spaces"))
+		_, err = parseCredentials("")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("uses the static credentials when no provider is requested", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{BarmanRunner: runner}
+		Expect(info.barmanRunner(&apiv1.Cluster{})).To(BeIdenticalTo(runner))
+	})
+
+	It("obtains fresh credentials before each barman-cloud command", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{BarmanRunner: runner}
+		cluster := newCluster("sh", "-c", "echo AWS_SESSION_TOKEN=fresh")
+
+		Expect(info.barmanRunner(cluster).Restore(context.TODO(), nil, []string{"HOME=/tmp"})).To(Succeed())
+		Expect(runner.restoreEnv).To(Equal([]string{"HOME=/tmp", "AWS_SESSION_TOKEN=fresh"}))
+	})
+
+	It("doesn't run the barman-cloud command when the credentials can't be obtained", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{BarmanRunner: runner}
+		cluster := newCluster("sh", "-c", "exit 1")
+
+		Expect(info.barmanRunner(cluster).Restore(context.TODO(), nil, nil)).
+			To(MatchError(ContainSubstring("while getting the credentials of the object store")))
+		Expect(runner.restoreAttempts).To(BeZero())
+	})
+
+	It("makes the restore_command obtain fresh credentials", func() {
+		cluster := newCluster("echo", "TOKEN=fresh")
+		cmd := restoreCommand{fetch: []string{"test", `"$TOKEN"`, "=", "fresh"}}.withCredentialsProvider(cluster)
+		Expect(exec.Command("sh", "-c", renderRestoreCommand(cmd.render(), "", "")).Run()).To(Succeed()) // #nosec G204
+
+		cluster = newCluster("false")
+		cmd = restoreCommand{fetch: []string{"true"}}.withCredentialsProvider(cluster)
+		Expect(exec.Command("sh", "-c", renderRestoreCommand(cmd.render(), "", "")).Run()).ToNot(Succeed()) // #nosec G204
+
+		Expect(restoreCommand{fetch: []string{"true"}}.withCredentialsProvider(&apiv1.Cluster{}).render()).
+			To(Equal("true"))
+	})
+
+	It("reads the credentials from the files of a directory", func() {
+		directory := GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(directory, "AWS_SESSION_TOKEN"), []byte("fresh\n"), 0o600)).To(Succeed())
+		Expect(os.Mkdir(path.Join(directory, "..data"), 0o700)).To(Succeed())
+
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{BarmanRunner: runner}
+		cluster := newCluster()
+		cluster.Spec.Bootstrap.Recovery.CredentialsProvider.Directory = directory
+
+		Expect(info.barmanRunner(cluster).Restore(context.TODO(), nil, []string{"HOME=/tmp"})).To(Succeed())
+		Expect(runner.restoreEnv).To(Equal([]string{"HOME=/tmp", "AWS_SESSION_TOKEN=fresh"}))
+	})
+
+	It("rejects the files of a directory not containing valid credentials", func() {
+		directory := GinkgoT().TempDir()
+		provider := fileCredentialsProvider{directory: directory}
+
+		_, err := provider.Credentials(context.TODO(), nil)
+		Expect(err).To(HaveOccurred())
+
+		Expect(os.WriteFile(path.Join(directory, "TOKEN"), []byte("with spaces"), 0o600)).To(Succeed())
+		_, err = provider.Credentials(context.TODO(), nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).ToNot(ContainSubstring("with spaces"))
+
+		_, err = fileCredentialsProvider{directory: path.Join(directory, "missing")}.Credentials(context.TODO(), nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("makes the restore_command read the credentials from a directory", func() {
+		directory := GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(directory, "TOKEN"), []byte("fresh\n"), 0o600)).To(Succeed())
+		cluster := newCluster()
+		cluster.Spec.Bootstrap.Recovery.CredentialsProvider.Directory = directory
+
+		cmd := restoreCommand{fetch: []string{"test", `"$TOKEN"`, "=", "fresh"}}.withCredentialsProvider(cluster)
+		Expect(exec.Command("sh", "-c", renderRestoreCommand(cmd.render(), "", "")).Run()).To(Succeed()) // #nosec G204
+	})
+
+	It("doesn't expand the pathname patterns in the credentials", func() {
+		directory := GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(directory, "TOKEN=fresh-and-stale"), nil, 0o600)).To(Succeed())
+		cluster := newCluster("echo", `"TOKEN=fresh*"`)
+
+		cmd := restoreCommand{fetch: []string{"test", `"$TOKEN"`, "=", `"fresh*"`}}.withCredentialsProvider(cluster)
+		shell := exec.Command("sh", "-c", renderRestoreCommand(cmd.render(), "", "")) // #nosec G204
+		shell.Dir = directory
+		Expect(shell.Run()).To(Succeed())
+	})
+
+	DescribeTable("reads a directory in the restore_command as the instance manager does",
+		func(files map[string]string, expectedCredentials []string) {
+			directory := GinkgoT().TempDir()
+			for name, content := range files {
+				Expect(os.WriteFile(path.Join(directory, name), []byte(content), 0o600)).To(Succeed())
+			}
+			Expect(os.Mkdir(path.Join(directory, "..data"), 0o700)).To(Succeed())
+			Expect(os.Mkdir(path.Join(directory, "subdirectory"), 0o700)).To(Succeed())
+
+			provider := &apiv1.RecoveryCredentialsProvider{Directory: directory}
+			output, shellErr := exec.Command("sh", "-c", credentialsShellCommand(provider)).Output() // #nosec G204
+			credentials, err := fileCredentialsProvider{directory: directory}.Credentials(context.TODO(), nil)
+
+			if expectedCredentials == nil {
+				Expect(shellErr).To(HaveOccurred())
+				Expect(err).To(HaveOccurred())
+				return
+			}
+
+			Expect(shellErr).ToNot(HaveOccurred())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(credentials).To(Equal(expectedCredentials))
+			Expect(parseCredentials(string(output))).To(Equal(expectedCredentials))
+		},
+		Entry("with valid credentials",
+			map[string]string{"AWS_ACCESS_KEY_ID": "key\n", "AWS_SESSION_TOKEN": "a/b+c==\r\n"},
+			[]string{"AWS_ACCESS_KEY_ID=key", "AWS_SESSION_TOKEN=a/b+c=="}),
+		Entry("with hidden files", map[string]string{".TOKEN": "hidden", "TOKEN": "visible"},
+			[]string{"TOKEN=visible"}),
+		Entry("without credentials", map[string]string{}, nil),
+		Entry("with a value containing spaces", map[string]string{"TOKEN": "with spaces"}, nil),
+		Entry("with a value containing a line break", map[string]string{"TOKEN": "first\nSECOND=line"}, nil),
+		Entry("with an invalid variable name", map[string]string{"INVALID-NAME": "value"}, nil),
+	)
+})
@@ -0,0 +1,275 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path"
+	"time"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/external"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/pool"
+)
+
+// defaultPrimaryAvailabilityTimeout is the default time waited for the
+// live primary to accept a replication connection
+const defaultPrimaryAvailabilityTimeout = 30 * time.Second
+
+// getRecoveryDataSourcePolicy gets the policy choosing how the data
+// directory of the restored instance is built, if any
+func getRecoveryDataSourcePolicy(cluster *apiv1.Cluster) *apiv1.RecoveryDataSourcePolicy {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.DataSourcePolicy
+}
+
+// getPrimaryAvailabilityTimeout gets the time waited for the live
+// primary to accept a replication connection
+func getPrimaryAvailabilityTimeout(policy *apiv1.RecoveryDataSourcePolicy) time.Duration {
+	if policy.AvailabilityTimeout == nil || policy.AvailabilityTimeout.Duration <= 0 {
+		return defaultPrimaryAvailabilityTimeout
+	}
+
+	return policy.AvailabilityTimeout.Duration
+}
+
+// chooseRecoveryDataSource chooses how the data directory is built, given
+// the preference of the user and the outcome of the availability check of
+// the live primary, nil meaning that it's reachable. The archive is used
+// when the live primary is unreachable
+func chooseRecoveryDataSource(
+	policy *apiv1.RecoveryDataSourcePolicy,
+	primaryErr error,
+) *apiv1.RecoveryDataSourceReport {
+	if policy.GetPreference() == apiv1.RecoveryDataSourceArchive {
+		return &apiv1.RecoveryDataSourceReport{
+			Method: apiv1.RecoveryDataSourceArchive,
+			Reason: "the archive is the preferred data source",
+		}
+	}
+
+	if primaryErr != nil {
+		return &apiv1.RecoveryDataSourceReport{
+			Method: apiv1.RecoveryDataSourceArchive,
+			Reason: fmt.Sprintf("the live primary %s is unreachable, falling back to the archive: %v",
+				policy.PrimarySource, primaryErr),
+		}
+	}
+
+	return &apiv1.RecoveryDataSourceReport{
+		Method: apiv1.RecoveryDataSourcePrimary,
+		Reason: fmt.Sprintf("the live primary %s is reachable and preferred", policy.PrimarySource),
+	}
+}
+
+// restoreFromRecoveryDataSource chooses, following the data source policy
+// of the cluster, between the archive and the live primary, and clones the
+// live primary when chosen. It returns whether the live primary has been
+// cloned, as the restore from the archive is left to the caller. An
+// interrupted restore from the archive is always resumed from the archive
+func (info InitInfo) restoreFromRecoveryDataSource(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	policy *apiv1.RecoveryDataSourcePolicy,
+) (bool, error) {
+	contextLogger := log.FromContext(ctx)
+
+	markerExists, err := fileutils.FileExists(path.Join(info.PgData, constants.RestoreMarker))
+	if err != nil {
+		return false, err
+	}
+	if markerExists {
+		contextLogger.Info("Resuming an interrupted restore from the archive, " +
+			"skipping the choice of the data source")
+		return false, nil
+	}
+
+	var connectionString string
+	var primaryErr error
+	if policy.GetPreference() == apiv1.RecoveryDataSourcePrimary {
+		if connectionString, err = info.primarySourceConnectionString(ctx, typedClient, cluster, policy); err != nil {
+			return false, err
+		}
+		primaryErr = checkPrimaryAvailable(ctx, connectionString, getPrimaryAvailabilityTimeout(policy))
+	}
+
+	report := chooseRecoveryDataSource(policy, primaryErr)
+	contextLogger.Info("Chose the data source of the restore",
+		"method", report.Method,
+		"reason", report.Reason,
+		"primarySource", policy.PrimarySource,
+		"preference", policy.GetPreference())
+	if err := info.reportRecoveryDataSource(ctx, typedClient, report); err != nil {
+		return false, err
+	}
+
+	if report.Method != apiv1.RecoveryDataSourcePrimary {
+		return false, nil
+	}
+
+	return true, info.restoreFromPrimary(ctx, typedClient, cluster, connectionString)
+}
+
+// primarySourceConnectionString gets the connection string of the live
+// primary, disabling wal_sender_timeout as done when joining a cluster
+func (info InitInfo) primarySourceConnectionString(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	policy *apiv1.RecoveryDataSourcePolicy,
+) (string, error) {
+	server, ok := cluster.ExternalCluster(policy.PrimarySource)
+	if !ok {
+		return "", fmt.Errorf("missing external cluster: %v", policy.PrimarySource)
+	}
+
+	connectionString, err := external.ConfigureConnectionToServer(ctx, typedClient, info.Namespace, &server)
+	if err != nil {
+		return "", err
+	}
+
+	if pgVersion, err := cluster.GetPostgresqlVersion(); err == nil && pgVersion >= 120000 {
+		connectionString += " options='-c wal_sender_timeout=0s'"
+	}
+
+	return connectionString, nil
+}
+
+// checkPrimaryAvailable checks that the live primary accepts a
+// replication connection within the passed timeout
+func checkPrimaryAvailable(ctx context.Context, connectionString string, timeout time.Duration) error {
+	db, err := pool.NewDBConnection(connectionString, pool.ConnectionProfilePostgresqlPhysicalReplication)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return identifySystem(checkCtx, db)
+}
+
+// identifySystem executes IDENTIFY_SYSTEM over a replication connection,
+// which succeeds only when the server can stream its data
+func identifySystem(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, "IDENTIFY_SYSTEM")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	return rows.Err()
+}
+
+// restoreFromPrimary builds the data directory cloning the live primary
+// with pg_basebackup, and configures the instance for its recovery role,
+// like the pg_basebackup bootstrap does
+func (info InitInfo) restoreFromPrimary(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	connectionString string,
+) error {
+	log.FromContext(ctx).Info("Cloning the live primary with pg_basebackup",
+		"primarySource", cluster.Spec.Bootstrap.Recovery.DataSourcePolicy.PrimarySource,
+		"pgdata", info.PgData)
+	if err := ClonePgData(connectionString, info.PgData, info.PgWal); err != nil {
+		return err
+	}
+
+	if err := info.WriteInitialPostgresqlConf(cluster); err != nil {
+		return err
+	}
+
+	if _, err := info.GetInstance().migratePostgresAutoConfFile(ctx); err != nil {
+		return err
+	}
+
+	switch cluster.GetRecoveryRole() {
+	case apiv1.RecoveryRoleReplicaCluster:
+		server, ok := cluster.ExternalCluster(cluster.Spec.ReplicaCluster.Source)
+		if !ok {
+			return fmt.Errorf("missing external cluster: %v", cluster.Spec.ReplicaCluster.Source)
+		}
+
+		replicaConnectionString, err := external.ConfigureConnectionToServer(
+			ctx, typedClient, info.Namespace, &server)
+		if err != nil {
+			return err
+		}
+
+		_, err = UpdateReplicaConfiguration(info.PgData, replicaConnectionString, "")
+		return err
+
+	case apiv1.RecoveryRoleStandby:
+		// The standby keeps streaming from the live primary it
+		// has been cloned from, and is started by the instance manager
+		_, err := UpdateReplicaConfiguration(info.PgData, connectionString, "")
+		return err
+	}
+
+	if err := info.WriteRestoreHbaConf(); err != nil {
+		return err
+	}
+
+	return info.ConfigureInstanceAfterRestore(ctx, typedClient, cluster, nil)
+}
+
+// reportRecoveryDataSource writes the data source chosen to build
+// the data directory in the cluster status
+func (info InitInfo) reportRecoveryDataSource(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.RecoveryDataSourceReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.DataSource = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the data source in the cluster status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery data source", func() {
+	policy := &apiv1.RecoveryDataSourcePolicy{PrimarySource: "live-primary"}
+
+	It("clones the live primary when it's reachable and preferred", func() {
+		report := chooseRecoveryDataSource(policy, nil)
+		Expect(report.Method).To(Equal(apiv1.RecoveryDataSourcePrimary))
+		Expect(report.Reason).To(ContainSubstring("live-primary"))
+	})
+
+	It("falls back to the archive when the live primary is unreachable", func() {
+		report := chooseRecoveryDataSource(policy, errors.New("connection refused"))
+		Expect(report.Method).To(Equal(apiv1.RecoveryDataSourceArchive))
+		Expect(report.Reason).To(ContainSubstring("connection refused"))
+	})
+
+	It("uses the archive when preferred", func() {
+		archivePolicy := &apiv1.RecoveryDataSourcePolicy{
+			PrimarySource: "live-primary",
+			Preference:    apiv1.RecoveryDataSourceArchive,
+		}
+		Expect(chooseRecoveryDataSource(archivePolicy, nil).Method).To(Equal(apiv1.RecoveryDataSourceArchive))
+	})
+
+	It("defaults the availability timeout", func() {
+		Expect(getPrimaryAvailabilityTimeout(policy)).To(Equal(defaultPrimaryAvailabilityTimeout))
+		Expect(getPrimaryAvailabilityTimeout(&apiv1.RecoveryDataSourcePolicy{
+			AvailabilityTimeout: &metav1.Duration{Duration: time.Minute},
+		})).To(Equal(time.Minute))
+	})
+
+	It("checks the live primary with IDENTIFY_SYSTEM", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			_ = db.Close()
+		}()
+
+		mock.ExpectQuery("IDENTIFY_SYSTEM").WillReturnRows(
+			sqlmock.NewRows([]string{"systemid", "timeline", "xlogpos", "dbname"}).
+				AddRow("7000000000000000001", 1, "0/3000060", nil))
+		Expect(identifySystem(context.TODO(), db)).To(Succeed())
+
+		mock.ExpectQuery("IDENTIFY_SYSTEM").WillReturnError(errors.New("connection refused"))
+		Expect(identifySystem(context.TODO(), db)).ToNot(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	Context("choosing the data source of the restore", func() {
+		var cluster *apiv1.Cluster
+		var typedClient client.Client
+		var info InitInfo
+
+		BeforeEach(func() {
+			cluster = &apiv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+				Spec: apiv1.ClusterSpec{
+					Bootstrap: &apiv1.BootstrapConfiguration{
+						Recovery: &apiv1.BootstrapRecovery{
+							Source: "origin",
+							DataSourcePolicy: &apiv1.RecoveryDataSourcePolicy{
+								PrimarySource: "live-primary",
+								Preference:    apiv1.RecoveryDataSourceArchive,
+							},
+						},
+					},
+				},
+			}
+			typedClient = fake.NewClientBuilder().
+				WithScheme(scheme.BuildWithAllKnownScheme()).
+				WithObjects(cluster).
+				WithStatusSubresource(cluster).
+				Build()
+			info = InitInfo{ClusterName: "clone", Namespace: "dev", PgData: GinkgoT().TempDir()}
+		})
+
+		It("reports the choice of the archive in the cluster status", func() {
+			cloned, err := info.restoreFromRecoveryDataSource(
+				context.TODO(), typedClient, cluster, cluster.Spec.Bootstrap.Recovery.DataSourcePolicy)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloned).To(BeFalse())
+
+			var result apiv1.Cluster
+			Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+			Expect(result.Status.DataSource).ToNot(BeNil())
+			Expect(result.Status.DataSource.Method).To(Equal(apiv1.RecoveryDataSourceArchive))
+		})
+
+		It("resumes an interrupted restore from the archive", func() {
+			Expect(os.WriteFile(path.Join(info.PgData, constants.RestoreMarker), []byte("backup"), 0o600)).
+				To(Succeed())
+
+			cloned, err := info.restoreFromRecoveryDataSource(
+				context.TODO(), typedClient, cluster, cluster.Spec.Bootstrap.Recovery.DataSourcePolicy)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloned).To(BeFalse())
+
+			var result apiv1.Cluster
+			Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+			Expect(result.Status.DataSource).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,267 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// builtinDecompressor is the name of the decompressor reported in the
+// logs when the archive is decompressed by the instance manager
+const builtinDecompressor = "builtin"
+
+var (
+	// gzipMagic is the header of a gzip-compressed stream
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	// zstdMagic is the header of a zstd-compressed stream
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ErrDecompressorNotFound is raised when the program requested to
+// decompress the archive of the base backup is not available
+var ErrDecompressorNotFound = errors.New("the decompression program is not available")
+
+// isLocalBackupArchive checks if the local backup is a tar archive
+// of the data directory rather than a copy of it
+func isLocalBackupArchive(dataPath string) bool {
+	stat, err := os.Stat(dataPath)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// checkDecompressionCommand checks that the decompression program, if
+// requested, is available before starting the restore
+func checkDecompressionCommand(decompression *apiv1.RecoveryDecompression) error {
+	if decompression == nil || len(decompression.Command) == 0 {
+		return nil
+	}
+
+	if _, err := exec.LookPath(decompression.Command[0]); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDecompressorNotFound, decompression.Command[0], err)
+	}
+
+	return nil
+}
+
+// countingReader is a reader counting the bytes read from
+// the underlying reader
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+// Read implements the io.Reader interface
+func (counter *countingReader) Read(p []byte) (int, error) {
+	n, err := counter.reader.Read(p)
+	counter.count += int64(n)
+	return n, err
+}
+
+// extractLocalBackupArchive extracts the tar archive of a data directory
+// into the destination directory, decompressing it with the requested
+// program or, when not specified, with the builtin gzip decompressor.
+// The throughput of the decompression is logged once it is completed
+func extractLocalBackupArchive(
+	ctx context.Context,
+	decompression *apiv1.RecoveryDecompression,
+	archivePath string,
+	destination string,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	file, err := os.Open(archivePath) // nolint:gosec
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	compressed := &countingReader{reader: file}
+	startTime := time.Now()
+
+	decompressor := builtinDecompressor
+	var stream io.Reader
+	var wait func() error
+	if decompression != nil && len(decompression.Command) > 0 {
+		decompressor = decompression.Command[0]
+		stream, wait, err = startDecompressionCommand(ctx, decompression.Command, compressed)
+	} else {
+		stream, err = newBuiltinDecompressor(compressed)
+	}
+	if err != nil {
+		return err
+	}
+
+	decompressed := &countingReader{reader: stream}
+	extractErr := extractTarArchive(decompressed, destination)
+	if wait != nil {
+		// The trailing padding of the archive needs to be consumed,
+		// otherwise the decompressor may block writing it
+		_, _ = io.Copy(io.Discard, stream)
+		if err := wait(); err != nil && extractErr == nil {
+			extractErr = err
+		}
+	}
+	if extractErr != nil {
+		return extractErr
+	}
+
+	elapsed := time.Since(startTime)
+	contextLogger.Info("Extracted the local backup archive",
+		"decompressor", decompressor,
+		"compressedBytes", compressed.count,
+		"decompressedBytes", decompressed.count,
+		"duration", elapsed.String(),
+		"throughputMBps", fmt.Sprintf("%.2f", float64(decompressed.count)/1024/1024/elapsed.Seconds()))
+
+	return nil
+}
+
+// newBuiltinDecompressor detects the compression of the archive, returning
+// a reader decompressing a gzip-compressed archive or reading an
+// uncompressed one as is
+func newBuiltinDecompressor(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	header, err := buffered.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return gzip.NewReader(buffered)
+
+	case bytes.HasPrefix(header, zstdMagic):
+		return nil, errors.New("the archive is compressed with zstd, a decompression program is required")
+
+	default:
+		return buffered, nil
+	}
+}
+
+// startDecompressionCommand starts the decompression program, feeding it
+// with the compressed archive. It returns the decompressed stream and the
+// function to be called, once the stream has been consumed, to wait for
+// the program to terminate
+func startDecompressionCommand(
+	ctx context.Context,
+	command []string,
+	reader io.Reader,
+) (io.Reader, func() error, error) {
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...) // #nosec G204
+	cmd.Stdin = reader
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, fmt.Errorf("while starting %s: %w", command[0], err)
+	}
+
+	wait := func() error {
+		if err := cmd.Wait(); err != nil {
+			return fmt.Errorf("%s failed: %w: %s", command[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return nil
+	}
+
+	return stdout, wait, nil
+}
+
+// extractTarArchive extracts the directories, the regular files and the
+// symbolic links contained in a tar archive into the destination directory.
+// The directories are created with the permissions required by PostgreSQL
+func extractTarArchive(reader io.Reader, destination string) error {
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("while reading the archive: %w", err)
+		}
+
+		name := filepath.Clean(header.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("the archive contains an entry outside the data directory: %s", header.Name)
+		}
+		target := filepath.Join(destination, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+
+		case tar.TypeSymlink:
+			if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+
+		case tar.TypeReg:
+			if err := extractTarFile(archive, target, header.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+
+		default:
+			// Sockets, pipes and devices aren't part of a data directory
+		}
+	}
+}
+
+// extractTarFile writes the current entry of a tar archive into a file
+func extractTarFile(archive *tar.Reader, target string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm|0o600) // nolint:gosec
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(file, archive); err != nil { // #nosec G110
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,169 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery from a local backup archive", func() {
+	var archivePath string
+
+	writeArchive := func(compress bool, entries ...*tar.Header) {
+		file, err := os.Create(archivePath)
+		Expect(err).ToNot(HaveOccurred())
+		defer func() {
+			Expect(file.Close()).To(Succeed())
+		}()
+
+		var writer io.Writer = file
+		if compress {
+			gzipWriter := gzip.NewWriter(file)
+			defer func() {
+				Expect(gzipWriter.Close()).To(Succeed())
+			}()
+			writer = gzipWriter
+		}
+
+		tarWriter := tar.NewWriter(writer)
+		for _, entry := range entries {
+			content := []byte(entry.Linkname)
+			if entry.Typeflag == tar.TypeReg {
+				content = []byte(entry.Name)
+				if entry.Name == "PG_VERSION" {
+					content = []byte("16\n")
+				}
+				entry.Size = int64(len(content))
+			}
+			Expect(tarWriter.WriteHeader(entry)).To(Succeed())
+			if entry.Typeflag == tar.TypeReg {
+				_, err := tarWriter.Write(content)
+				Expect(err).ToNot(HaveOccurred())
+			}
+		}
+		Expect(tarWriter.Close()).To(Succeed())
+	}
+
+	dataDirectory := []*tar.Header{
+		{Name: "global/", Typeflag: tar.TypeDir, Mode: 0o700},
+		{Name: "PG_VERSION", Typeflag: tar.TypeReg, Mode: 0o600},
+		{Name: "global/pg_control", Typeflag: tar.TypeReg, Mode: 0o600},
+		{Name: "base/1/1259", Typeflag: tar.TypeReg, Mode: 0o600},
+		{Name: "tbs", Typeflag: tar.TypeSymlink, Linkname: "/var/lib/postgresql/tablespaces/tbs"},
+	}
+
+	BeforeEach(func() {
+		archivePath = path.Join(GinkgoT().TempDir(), "base.tar.gz")
+	})
+
+	checkExtracted := func(destination string) {
+		Expect(checkDataDirStructure(destination)).To(Succeed())
+
+		content, err := os.ReadFile(path.Join(destination, "base", "1", "1259"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("base/1/1259"))
+
+		link, err := os.Readlink(path.Join(destination, "tbs"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(link).To(Equal("/var/lib/postgresql/tablespaces/tbs"))
+
+		stat, err := os.Stat(path.Join(destination, "base"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+	}
+
+	It("recognizes an archive of the data directory", func() {
+		writeArchive(true, dataDirectory...)
+		Expect(isLocalBackupArchive(archivePath)).To(BeTrue())
+		Expect(isLocalBackupArchive(path.Dir(archivePath))).To(BeFalse())
+		Expect(isLocalBackupArchive(path.Join(path.Dir(archivePath), "missing"))).To(BeFalse())
+	})
+
+	It("extracts a gzip-compressed archive with the builtin decompressor", func(ctx SpecContext) {
+		writeArchive(true, dataDirectory...)
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(extractLocalBackupArchive(ctx, nil, archivePath, destination)).To(Succeed())
+		checkExtracted(destination)
+	})
+
+	It("extracts an uncompressed archive with the builtin decompressor", func(ctx SpecContext) {
+		writeArchive(false, dataDirectory...)
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(extractLocalBackupArchive(ctx, nil, archivePath, destination)).To(Succeed())
+		checkExtracted(destination)
+	})
+
+	It("extracts an archive with the requested decompression program", func(ctx SpecContext) {
+		if _, err := exec.LookPath("gzip"); err != nil {
+			Skip("gzip is not available")
+		}
+
+		writeArchive(true, dataDirectory...)
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		decompression := &apiv1.RecoveryDecompression{Command: []string{"gzip", "-d", "-c"}}
+		Expect(checkDecompressionCommand(decompression)).To(Succeed())
+		Expect(extractLocalBackupArchive(ctx, decompression, archivePath, destination)).To(Succeed())
+		checkExtracted(destination)
+	})
+
+	It("fails when the decompression program fails", func(ctx SpecContext) {
+		if _, err := exec.LookPath("gzip"); err != nil {
+			Skip("gzip is not available")
+		}
+
+		writeArchive(false, dataDirectory...)
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		decompression := &apiv1.RecoveryDecompression{Command: []string{"gzip", "-d", "-c"}}
+		Expect(extractLocalBackupArchive(ctx, decompression, archivePath, destination)).ToNot(Succeed())
+	})
+
+	It("rejects a decompression program which is not available", func() {
+		decompression := &apiv1.RecoveryDecompression{Command: []string{"cnpg-missing-decompressor", "-d"}}
+		Expect(checkDecompressionCommand(decompression)).To(MatchError(ErrDecompressorNotFound))
+		Expect(checkDecompressionCommand(nil)).To(Succeed())
+	})
+
+	It("rejects the entries outside the data directory", func(ctx SpecContext) {
+		writeArchive(true, &tar.Header{Name: "../escaped", Typeflag: tar.TypeReg, Mode: 0o600})
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(extractLocalBackupArchive(ctx, nil, archivePath, destination)).ToNot(Succeed())
+		Expect(path.Join(destination, "..", "escaped")).ToNot(BeAnExistingFile())
+	})
+
+	It("requires a decompression program for a zstd-compressed archive", func() {
+		_, err := newBuiltinDecompressor(bytes.NewReader([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}))
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("accepts an archive in place of the data directory", func() {
+		writeArchive(true, dataDirectory...)
+		walPath := path.Join(path.Dir(archivePath), "wals")
+		Expect(os.Mkdir(walPath, 0o700)).To(Succeed())
+		Expect(validateLocalBackup(archivePath, walPath)).To(Succeed())
+	})
+})
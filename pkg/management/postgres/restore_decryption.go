@@ -0,0 +1,215 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/execlog"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+const (
+	// decryptionKeyEnvVar is the environment variable used to pass the
+	// decryption key to the decryption command
+	decryptionKeyEnvVar = "CNPG_DECRYPTION_KEY"
+
+	// walDecryptionKeyEnvVar is the environment variable used to pass the
+	// decryption key of the WAL files to the WAL decryption command
+	walDecryptionKeyEnvVar = "CNPG_WAL_DECRYPTION_KEY"
+)
+
+// ErrInvalidDecryptedData is raised when the data directory doesn't look
+// like a PostgreSQL data directory after having been decrypted
+var ErrInvalidDecryptedData = errors.New("the decrypted data directory is not a valid PostgreSQL data directory")
+
+// decryptDataDir runs the decryption command configured in the cluster,
+// if any, on the restored PGDATA and checks that the result is a valid
+// PostgreSQL data directory
+func (info InitInfo) decryptDataDir(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	env []string,
+) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Decryption == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	decryption := cluster.Spec.Bootstrap.Recovery.Decryption
+	if len(decryption.Command) == 0 {
+		return fmt.Errorf("missing decryption command")
+	}
+
+	cmdEnv := append([]string{}, env...)
+	if decryption.KeySecret != nil {
+		key, err := info.loadDecryptionKey(ctx, typedClient, decryption.KeySecret)
+		if err != nil {
+			return err
+		}
+		cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", decryptionKeyEnvVar, key))
+	}
+
+	options := append(append([]string{}, decryption.Command[1:]...), info.PgData)
+
+	// The options are safe to be logged, as the key is only passed
+	// to the decryption command via its environment
+	contextLogger.Info("Starting the decryption of the restored data directory",
+		"command", decryption.Command[0],
+		"options", options)
+
+	cmd := exec.Command(decryption.Command[0], options...) // #nosec G204
+	cmd.Env = cmdEnv
+	if err := execlog.RunStreaming(cmd, path.Base(decryption.Command[0])); err != nil {
+		contextLogger.Error(err, "Can't decrypt the restored data directory")
+		return fmt.Errorf("while decrypting the restored data directory: %w", err)
+	}
+
+	if err := validateDecryptedDataDir(info.PgData); err != nil {
+		contextLogger.Error(err, "Decryption produced an invalid data directory")
+		return err
+	}
+
+	contextLogger.Info("Decryption completed")
+	return nil
+}
+
+// getWALDecryption gets the configuration used to decrypt the
+// WAL files fetched from the object store, if any
+func getWALDecryption(cluster *apiv1.Cluster) *apiv1.RecoveryDecryptionConfiguration {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.WALDecryption
+}
+
+// withWALDecryption makes the restore_command decrypt, in place, every
+// WAL file it fetched
+func (cmd restoreCommand) withWALDecryption(cluster *apiv1.Cluster) restoreCommand {
+	decryption := getWALDecryption(cluster)
+	if decryption == nil || len(decryption.Command) == 0 {
+		return cmd
+	}
+
+	cmd.decryption = decryption.Command
+	return cmd
+}
+
+// renderWALDecryption appends the decryption to the passed fetch of a WAL
+// file, if any. The decryption is skipped when the WAL file can't be
+// fetched, as PostgreSQL needs the original exit code
+func (cmd restoreCommand) renderWALDecryption(fetch []string) []string {
+	if len(cmd.decryption) == 0 {
+		return fetch
+	}
+
+	result := append(append([]string{}, fetch...), "&&")
+	result = append(result, cmd.decryption...)
+	return append(result, "%p")
+}
+
+// withWALDecryptionKey adds to the environment of PostgreSQL the key used
+// by the restore_command to decrypt the WAL files, which is independent of
+// the one of the base backup
+func (info InitInfo) withWALDecryptionKey(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	env []string,
+) ([]string, error) {
+	decryption := getWALDecryption(cluster)
+	if decryption == nil || decryption.KeySecret == nil {
+		return env, nil
+	}
+
+	key, err := info.loadDecryptionKey(ctx, typedClient, decryption.KeySecret)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]string{}, env...), fmt.Sprintf("%s=%s", walDecryptionKeyEnvVar, key)), nil
+}
+
+// loadDecryptionKey reads the decryption key from the referenced secret
+func (info InitInfo) loadDecryptionKey(
+	ctx context.Context,
+	typedClient client.Client,
+	selector *apiv1.SecretKeySelector,
+) ([]byte, error) {
+	var secret corev1.Secret
+	if err := typedClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: info.Namespace, Name: selector.Name},
+		&secret,
+	); err != nil {
+		return nil, fmt.Errorf("while getting decryption key secret %s: %w", selector.Name, err)
+	}
+
+	key, ok := secret.Data[selector.Key]
+	if !ok {
+		return nil, fmt.Errorf("missing key %s, inside decryption key secret %s", selector.Key, selector.Name)
+	}
+
+	return key, nil
+}
+
+// validateDecryptedDataDir checks that the decryption command produced
+// a data directory PostgreSQL can start from
+func validateDecryptedDataDir(pgData string) error {
+	if err := checkDataDirStructure(pgData); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDecryptedData, err)
+	}
+
+	return nil
+}
+
+// checkDataDirStructure checks that a directory contains the files
+// that every PostgreSQL data directory has
+func checkDataDirStructure(pgData string) error {
+	if _, err := postgresutils.GetMajorVersion(pgData); err != nil {
+		return fmt.Errorf("cannot read PG_VERSION: %w", err)
+	}
+
+	controlFile := path.Join(pgData, "global", "pg_control")
+	exists, err := fileutils.FileExists(controlFile)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("missing %s", controlFile)
+	}
+
+	if _, err := os.Stat(path.Join(pgData, "base")); err != nil {
+		return fmt.Errorf("cannot access the base directory: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("decrypting a restored data directory", func() {
+	var (
+		pgData  string
+		cluster *apiv1.Cluster
+	)
+
+	BeforeEach(func() {
+		tempDir, err := os.MkdirTemp("", "decrypt")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = os.RemoveAll(tempDir)
+		})
+		pgData = path.Join(tempDir, "pgdata")
+		Expect(fileutils.EnsureDirectoryExists(pgData)).To(Succeed())
+
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Decryption: &apiv1.RecoveryDecryptionConfiguration{
+							Command: []string{
+								"sh", "-c",
+								`mkdir -p "$0/global" "$0/base" && ` +
+									`echo 16 > "$0/PG_VERSION" && ` +
+									`printf '%s' "$CNPG_DECRYPTION_KEY" > "$0/global/pg_control"`,
+							},
+							KeySecret: &apiv1.SecretKeySelector{
+								LocalObjectReference: apiv1.LocalObjectReference{Name: "decryption-key"},
+								Key:                  "key",
+							},
+						},
+					},
+				},
+			},
+		}
+	})
+
+	newClient := func(objects ...client.Object) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(objects...).
+			Build()
+	}
+
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "decryption-key", Namespace: "default"},
+		Data:       map[string][]byte{"key": []byte("secret-key")},
+	}
+
+	It("does nothing when decryption is not configured", func() {
+		cluster.Spec.Bootstrap.Recovery.Decryption = nil
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		Expect(info.decryptDataDir(context.TODO(), newClient(), cluster, nil)).To(Succeed())
+	})
+
+	It("runs the command passing the key via the environment", func() {
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		Expect(info.decryptDataDir(context.TODO(), newClient(keySecret), cluster, os.Environ())).To(Succeed())
+
+		content, err := os.ReadFile(path.Join(pgData, "global", "pg_control")) // #nosec
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("secret-key"))
+	})
+
+	It("fails when the key secret doesn't exist", func() {
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		err := info.decryptDataDir(context.TODO(), newClient(), cluster, os.Environ())
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("decryption-key"))
+	})
+
+	It("fails when the command fails", func() {
+		cluster.Spec.Bootstrap.Recovery.Decryption.Command = []string{"false"}
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		Expect(info.decryptDataDir(context.TODO(), newClient(keySecret), cluster, os.Environ())).ToNot(Succeed())
+	})
+
+	It("fails when the command produces an invalid data directory", func() {
+		cluster.Spec.Bootstrap.Recovery.Decryption.Command = []string{"true"}
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		err := info.decryptDataDir(context.TODO(), newClient(keySecret), cluster, os.Environ())
+		Expect(err).To(MatchError(ErrInvalidDecryptedData))
+	})
+
+	It("doesn't decrypt the WAL files when their decryption is not configured", func() {
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		env, err := info.withWALDecryptionKey(context.TODO(), newClient(keySecret), cluster, []string{"PATH=/bin"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(env).To(Equal([]string{"PATH=/bin"}))
+		cmd := restoreCommand{fetch: []string{"barman-cloud-wal-restore"}, arguments: walFetchArguments}
+		Expect(cmd.withWALDecryption(cluster).render()).To(Equal("barman-cloud-wal-restore %f %p"))
+	})
+
+	It("decrypts the WAL files with the same key of the base backup", func() {
+		cluster.Spec.Bootstrap.Recovery.WALDecryption = &apiv1.RecoveryDecryptionConfiguration{
+			Command:   []string{"/usr/local/bin/decrypt-wal", "--in-place"},
+			KeySecret: cluster.Spec.Bootstrap.Recovery.Decryption.KeySecret,
+		}
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		Expect(info.decryptDataDir(context.TODO(), newClient(keySecret), cluster, os.Environ())).To(Succeed())
+
+		env, err := info.withWALDecryptionKey(context.TODO(), newClient(keySecret), cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(env).To(Equal([]string{"CNPG_WAL_DECRYPTION_KEY=secret-key"}))
+		cmd := restoreCommand{fetch: []string{"barman-cloud-wal-restore"}, arguments: walFetchArguments}
+		Expect(cmd.withWALDecryption(cluster).render()).
+			To(Equal("barman-cloud-wal-restore %f %p && /usr/local/bin/decrypt-wal --in-place %p"))
+	})
+
+	It("decrypts the WAL files with a key different from the one of the base backup", func() {
+		walKeySecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "wal-decryption-key", Namespace: "default"},
+			Data:       map[string][]byte{"key": []byte("wal-secret-key")},
+		}
+		cluster.Spec.Bootstrap.Recovery.WALDecryption = &apiv1.RecoveryDecryptionConfiguration{
+			Command: []string{"/usr/local/bin/decrypt-wal"},
+			KeySecret: &apiv1.SecretKeySelector{
+				LocalObjectReference: apiv1.LocalObjectReference{Name: "wal-decryption-key"},
+				Key:                  "key",
+			},
+		}
+		info := InitInfo{PgData: pgData, Namespace: "default"}
+		typedClient := newClient(keySecret, walKeySecret)
+
+		Expect(info.decryptDataDir(context.TODO(), typedClient, cluster, os.Environ())).To(Succeed())
+		content, err := os.ReadFile(path.Join(pgData, "global", "pg_control")) // #nosec
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("secret-key"))
+
+		env, err := info.withWALDecryptionKey(context.TODO(), typedClient, cluster, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(env).To(Equal([]string{"CNPG_WAL_DECRYPTION_KEY=wal-secret-key"}))
+
+		_, err = info.withWALDecryptionKey(context.TODO(), newClient(keySecret), cluster, nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("wal-decryption-key"))
+	})
+})
@@ -0,0 +1,454 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// downloadCacheDataDirectory is the directory, inside a cache entry,
+	// containing the downloaded data directory
+	downloadCacheDataDirectory = "pgdata"
+
+	// downloadCacheManifestFile is the file, inside a cache entry,
+	// describing the downloaded files. It is written once the download
+	// is completed, so an entry without it is incomplete
+	downloadCacheManifestFile = "manifest.json"
+)
+
+// errModifiedCachedBackup is raised when the cached copy of a base backup
+// doesn't match the files recorded when it was downloaded
+var errModifiedCachedBackup = errors.New("the cached base backup changed since its download")
+
+// downloadCacheManifest describes a base backup kept in the download cache
+type downloadCacheManifest struct {
+	// BackupID is the ID of the cached base backup
+	BackupID string `json:"backupID"`
+
+	// Size is the total size of the cached files
+	Size int64 `json:"size"`
+
+	// Files are the cached files, indexed by their path relative
+	// to the data directory
+	Files map[string]downloadCacheFile `json:"files"`
+}
+
+// downloadCacheFile describes a file of a cached base backup
+type downloadCacheFile struct {
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+}
+
+// getRecoveryDownloadCache gets the volume where the downloaded base
+// backups are kept, if any
+func getRecoveryDownloadCache(cluster *apiv1.Cluster) *apiv1.RecoveryDownloadCache {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.DownloadCache
+}
+
+// downloadCacheKey gets the name of the cache entry of a base backup.
+// It is made of the backup ID and of a checksum of the location of the
+// backup, so that backups having the same ID in different object stores
+// don't share the same entry
+func downloadCacheKey(backup *apiv1.Backup) string {
+	hash := sha256.New()
+	for _, value := range []string{
+		backup.Status.EndpointURL,
+		backup.Status.DestinationPath,
+		backup.Status.ServerName,
+		backup.Status.BackupID,
+		backup.Status.BeginWal,
+		backup.Status.EndWal,
+	} {
+		_, _ = hash.Write([]byte(value))
+		_, _ = hash.Write([]byte{0})
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))[:16]
+
+	backupID := backup.Status.BackupID
+	if backupID == "" || filepath.Base(backupID) != backupID || backupID == "." || backupID == ".." {
+		return checksum
+	}
+
+	return fmt.Sprintf("%s-%s", backupID, checksum)
+}
+
+// restoreDataDirCached restores PGDATA from an existing backup. When the
+// cluster defines a download cache, the base backup is downloaded in the
+// cache, unless a copy that didn't change since its download is already
+// there, and PGDATA is then assembled from the cached copy
+func (info InitInfo) restoreDataDirCached(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+	policy restoreRetryPolicy,
+	cacheDirectory string,
+) error {
+	cache := getRecoveryDownloadCache(cluster)
+	if cache == nil {
+		return info.downloadDataDir(ctx, cluster, backup, env, policy)
+	}
+
+	key := downloadCacheKey(backup)
+	entryDirectory := path.Join(cacheDirectory, key)
+	cachedPgData := path.Join(entryDirectory, downloadCacheDataDirectory)
+	contextLogger := log.FromContext(ctx).WithValues(
+		"backupID", backup.Status.BackupID,
+		"cacheEntry", entryDirectory)
+
+	manifest, err := loadDownloadCacheManifest(entryDirectory)
+	if err == nil {
+		err = manifest.verify(cachedPgData)
+	}
+
+	switch {
+	case err == nil:
+		contextLogger.Info("Reusing the cached base backup, skipping the download",
+			"size", manifest.Size)
+
+	default:
+		if !errors.Is(err, os.ErrNotExist) {
+			contextLogger.Warning("Discarding the cached base backup", "reason", err.Error())
+		}
+
+		manifest, err = info.downloadDataDirToCache(ctx, cluster, backup, env, policy, entryDirectory)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The modification time of the manifest records when the entry
+	// has been used for the last time
+	now := time.Now()
+	if err := os.Chtimes(path.Join(entryDirectory, downloadCacheManifestFile), now, now); err != nil {
+		return fmt.Errorf("while updating the cached base backup: %w", err)
+	}
+
+	contextLogger.Info("Assembling the data directory from the cached base backup",
+		"pgdata", info.PgData)
+	assembleStart := time.Now()
+	if err := fileutils.RemoveDirectoryContent(info.PgData); err != nil {
+		return fmt.Errorf("while cleaning up the data directory: %w", err)
+	}
+	if err := copyDataDirectory(cachedPgData, info.PgData); err != nil {
+		return fmt.Errorf("while assembling the data directory from the cached base backup: %w", err)
+	}
+	contextLogger.Info("Data directory assembled from the cached base backup",
+		"duration", time.Since(assembleStart).String())
+
+	evictDownloadCache(ctx, cacheDirectory, cache, key)
+	return nil
+}
+
+// downloadDataDirToCache downloads a base backup in a new cache entry,
+// recording its files once the download is completed. The entry is
+// removed when the download fails
+func (info InitInfo) downloadDataDirToCache(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+	policy restoreRetryPolicy,
+	entryDirectory string,
+) (*downloadCacheManifest, error) {
+	if err := os.RemoveAll(entryDirectory); err != nil {
+		return nil, fmt.Errorf("while cleaning up the cache entry: %w", err)
+	}
+
+	cachedPgData := path.Join(entryDirectory, downloadCacheDataDirectory)
+	if err := os.MkdirAll(cachedPgData, 0o700); err != nil {
+		return nil, fmt.Errorf("while creating the cache entry: %w", err)
+	}
+
+	cachedInfo := info
+	cachedInfo.PgData = cachedPgData
+	manifest, err := func() (*downloadCacheManifest, error) {
+		if err := cachedInfo.ensurePgDataOwnership(
+			ctx, int(cluster.GetPostgresUID()), int(cluster.GetPostgresGID())); err != nil {
+			return nil, err
+		}
+
+		if err := cachedInfo.downloadDataDir(ctx, cluster, backup, env, policy); err != nil {
+			return nil, err
+		}
+
+		manifest, err := buildDownloadCacheManifest(backup.Status.BackupID, cachedPgData)
+		if err != nil {
+			return nil, fmt.Errorf("while recording the files of the cached base backup: %w", err)
+		}
+
+		return manifest, manifest.write(entryDirectory)
+	}()
+	if err != nil {
+		if removeErr := os.RemoveAll(entryDirectory); removeErr != nil {
+			log.FromContext(ctx).Warning("Cannot remove the incomplete cache entry",
+				"cacheEntry", entryDirectory, "error", removeErr.Error())
+		}
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// buildDownloadCacheManifest records the size and the checksum of the
+// regular files of a downloaded data directory. They are computed from
+// the downloaded files themselves, so they only allow detecting the
+// changes made to the cache after the download, and not a base backup
+// corrupted in the object store or while being downloaded
+func buildDownloadCacheManifest(backupID, pgData string) (*downloadCacheManifest, error) {
+	manifest := &downloadCacheManifest{
+		BackupID: backupID,
+		Files:    make(map[string]downloadCacheFile),
+	}
+
+	err := walkRegularFiles(pgData, func(relativeName, name string) error {
+		file, err := checksumFile(name)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files[relativeName] = file
+		manifest.Size += file.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// verify checks that a cached data directory contains exactly the files
+// recorded in the manifest, with the same size and checksum
+func (manifest *downloadCacheManifest) verify(pgData string) error {
+	found := 0
+	err := walkRegularFiles(pgData, func(relativeName, name string) error {
+		expected, ok := manifest.Files[relativeName]
+		if !ok {
+			return fmt.Errorf("%w: unexpected file %s", errModifiedCachedBackup, relativeName)
+		}
+
+		file, err := checksumFile(name)
+		if err != nil {
+			return err
+		}
+		if file != expected {
+			return fmt.Errorf("%w: file %s has been modified", errModifiedCachedBackup, relativeName)
+		}
+
+		found++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if found != len(manifest.Files) {
+		return fmt.Errorf("%w: %d files are missing", errModifiedCachedBackup, len(manifest.Files)-found)
+	}
+
+	return nil
+}
+
+// write stores the manifest in a cache entry
+func (manifest *downloadCacheManifest) write(entryDirectory string) error {
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	_, err = fileutils.WriteFileAtomic(path.Join(entryDirectory, downloadCacheManifestFile), content, 0o600)
+	return err
+}
+
+// loadDownloadCacheManifest loads the manifest of a cache entry. An error
+// wrapping os.ErrNotExist is returned when the entry is missing or
+// incomplete
+func loadDownloadCacheManifest(entryDirectory string) (*downloadCacheManifest, error) {
+	content, err := os.ReadFile(path.Join(entryDirectory, downloadCacheManifestFile)) // nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest downloadCacheManifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, fmt.Errorf("%w: unreadable manifest: %w", errModifiedCachedBackup, err)
+	}
+
+	return &manifest, nil
+}
+
+// walkRegularFiles invokes the passed function for every regular file
+// contained in a directory, passing its relative and full name
+func walkRegularFiles(directory string, fn func(relativeName, name string) error) error {
+	return filepath.WalkDir(directory, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		relativeName, err := filepath.Rel(directory, name)
+		if err != nil {
+			return err
+		}
+
+		return fn(relativeName, name)
+	})
+}
+
+// checksumFile gets the size and the SHA256 checksum of a file
+func checksumFile(name string) (downloadCacheFile, error) {
+	file, err := os.Open(name) // nolint:gosec
+	if err != nil {
+		return downloadCacheFile{}, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return downloadCacheFile{}, err
+	}
+
+	return downloadCacheFile{Size: size, Checksum: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// downloadCacheEntry is an entry found in the download cache
+type downloadCacheEntry struct {
+	key      string
+	size     int64
+	lastUsed time.Time
+}
+
+// evictDownloadCache removes the least recently used entries of the
+// download cache exceeding its limits, and the incomplete ones. The entry
+// of the base backup just restored is always kept. Eviction failures are
+// only logged, as the restore is already completed
+func evictDownloadCache(
+	ctx context.Context,
+	cacheDirectory string,
+	cache *apiv1.RecoveryDownloadCache,
+	currentKey string,
+) {
+	contextLogger := log.FromContext(ctx)
+
+	directoryEntries, err := os.ReadDir(cacheDirectory)
+	if err != nil {
+		contextLogger.Warning("Cannot read the download cache, skipping the eviction",
+			"error", err.Error())
+		return
+	}
+
+	var current *downloadCacheEntry
+	var entries []downloadCacheEntry
+	for _, directoryEntry := range directoryEntries {
+		if !directoryEntry.IsDir() {
+			continue
+		}
+
+		entryDirectory := path.Join(cacheDirectory, directoryEntry.Name())
+		manifest, err := loadDownloadCacheManifest(entryDirectory)
+		var stat os.FileInfo
+		if err == nil {
+			stat, err = os.Stat(path.Join(entryDirectory, downloadCacheManifestFile))
+		}
+		if err != nil {
+			if directoryEntry.Name() != currentKey {
+				removeDownloadCacheEntry(ctx, entryDirectory, "incomplete")
+			}
+			continue
+		}
+
+		entry := downloadCacheEntry{key: directoryEntry.Name(), size: manifest.Size, lastUsed: stat.ModTime()}
+		if entry.key == currentKey {
+			current = &entry
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// Keep the most recently used entries first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].lastUsed.After(entries[j].lastUsed)
+	})
+
+	var maxSize int64
+	if cache.MaxSize != nil {
+		maxSize = cache.MaxSize.Value()
+	}
+
+	keptEntries := 0
+	var keptSize int64
+	if current != nil {
+		keptEntries = 1
+		keptSize = current.size
+		if maxSize > 0 && keptSize > maxSize {
+			contextLogger.Warning("The cached base backup exceeds the maximum size of the download cache",
+				"size", keptSize, "maxSize", maxSize)
+		}
+	}
+
+	for _, entry := range entries {
+		if keptEntries < cache.GetMaxEntries() && (maxSize <= 0 || keptSize+entry.size <= maxSize) {
+			keptEntries++
+			keptSize += entry.size
+			continue
+		}
+
+		removeDownloadCacheEntry(ctx, path.Join(cacheDirectory, entry.key), "evicted")
+	}
+
+	contextLogger.Info("Download cache updated",
+		"entries", keptEntries,
+		"size", keptSize)
+}
+
+// removeDownloadCacheEntry removes an entry of the download cache
+func removeDownloadCacheEntry(ctx context.Context, entryDirectory string, reason string) {
+	contextLogger := log.FromContext(ctx).WithValues("cacheEntry", entryDirectory, "reason", reason)
+	if err := os.RemoveAll(entryDirectory); err != nil {
+		contextLogger.Warning("Cannot remove the entry of the download cache", "error", err.Error())
+		return
+	}
+
+	contextLogger.Info("Removed the entry of the download cache")
+}
@@ -0,0 +1,158 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore download cache", func() {
+	var (
+		cacheDirectory string
+		pgData         string
+		runner         *fakeBarmanRunner
+		info           InitInfo
+		cluster        *apiv1.Cluster
+		backup         *apiv1.Backup
+	)
+
+	newBackup := func(backupID string) *apiv1.Backup {
+		return &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "source",
+			BackupID:        backupID,
+		}}
+	}
+
+	restore := func() error {
+		return info.restoreDataDirCached(
+			context.TODO(), cluster, backup, nil, getRestoreRetryPolicy(cluster), cacheDirectory)
+	}
+
+	BeforeEach(func() {
+		cacheDirectory = GinkgoT().TempDir()
+		pgData = path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(os.MkdirAll(pgData, 0o700)).To(Succeed())
+		runner = &fakeBarmanRunner{restoredFiles: map[string]string{
+			"PG_VERSION":    "16",
+			"base/1/1259":   "pg_class",
+			"global/1262":   "pg_database",
+			"backup_label":  "START WAL LOCATION",
+			"pg_wal/.empty": "",
+		}}
+		info = InitInfo{PgData: pgData, BarmanRunner: runner}
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						DownloadCache: &apiv1.RecoveryDownloadCache{ClaimName: "restore-cache"},
+					},
+				},
+			},
+		}
+		backup = newBackup("20240101T000000")
+	})
+
+	It("downloads the base backup once and then assembles PGDATA from the cache", func() {
+		Expect(restore()).To(Succeed())
+		Expect(runner.restoreAttempts).To(Equal(1))
+		Expect(path.Join(pgData, "base", "1", "1259")).To(BeARegularFile())
+
+		Expect(os.RemoveAll(pgData)).To(Succeed())
+		Expect(os.MkdirAll(pgData, 0o700)).To(Succeed())
+		Expect(restore()).To(Succeed())
+		Expect(runner.restoreAttempts).To(Equal(1))
+		content, err := os.ReadFile(path.Join(pgData, "global", "1262"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("pg_database"))
+	})
+
+	It("downloads the base backup again when the cached copy is corrupted", func() {
+		Expect(restore()).To(Succeed())
+
+		cachedFile := path.Join(cacheDirectory, downloadCacheKey(backup), downloadCacheDataDirectory, "base", "1", "1259")
+		Expect(os.WriteFile(cachedFile, []byte("corrupted"), 0o600)).To(Succeed())
+		Expect(restore()).To(Succeed())
+		Expect(runner.restoreAttempts).To(Equal(2))
+
+		content, err := os.ReadFile(path.Join(pgData, "base", "1", "1259"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("pg_class"))
+	})
+
+	It("doesn't keep the cache entry of a failed download", func() {
+		runner.restoreErr = errors.New("access denied")
+		Expect(restore()).ToNot(Succeed())
+		Expect(path.Join(cacheDirectory, downloadCacheKey(backup))).ToNot(BeADirectory())
+	})
+
+	It("evicts the least recently used base backups", func() {
+		cluster.Spec.Bootstrap.Recovery.DownloadCache.MaxEntries = ptr.To(int32(2))
+		firstBackup := newBackup("20240101T000000")
+		secondBackup := newBackup("20240102T000000")
+		thirdBackup := newBackup("20240103T000000")
+
+		for _, current := range []*apiv1.Backup{firstBackup, secondBackup, thirdBackup} {
+			backup = current
+			Expect(restore()).To(Succeed())
+			// make the modification times of the manifests distinguishable
+			manifestFile := path.Join(cacheDirectory, downloadCacheKey(current), downloadCacheManifestFile)
+			Expect(os.Chtimes(manifestFile, time.Now(), time.Now().Add(-time.Hour))).To(Succeed())
+		}
+
+		Expect(path.Join(cacheDirectory, downloadCacheKey(firstBackup))).ToNot(BeADirectory())
+		Expect(path.Join(cacheDirectory, downloadCacheKey(secondBackup))).To(BeADirectory())
+		Expect(path.Join(cacheDirectory, downloadCacheKey(thirdBackup))).To(BeADirectory())
+	})
+
+	It("evicts the base backups exceeding the maximum size, keeping the current one", func() {
+		maxSize := resource.MustParse("1")
+		cluster.Spec.Bootstrap.Recovery.DownloadCache.MaxSize = &maxSize
+		cluster.Spec.Bootstrap.Recovery.DownloadCache.MaxEntries = ptr.To(int32(5))
+		firstBackup := newBackup("20240101T000000")
+		secondBackup := newBackup("20240102T000000")
+
+		backup = firstBackup
+		Expect(restore()).To(Succeed())
+		backup = secondBackup
+		Expect(restore()).To(Succeed())
+
+		Expect(path.Join(cacheDirectory, downloadCacheKey(firstBackup))).ToNot(BeADirectory())
+		Expect(path.Join(cacheDirectory, downloadCacheKey(secondBackup))).To(BeADirectory())
+	})
+
+	It("keys the cache entries by backup ID and location", func() {
+		otherStore := newBackup("20240101T000000")
+		otherStore.Status.DestinationPath = "s3://other-backups/"
+		Expect(downloadCacheKey(backup)).To(HavePrefix("20240101T000000-"))
+		Expect(downloadCacheKey(backup)).To(Equal(downloadCacheKey(newBackup("20240101T000000"))))
+		Expect(downloadCacheKey(backup)).ToNot(Equal(downloadCacheKey(otherStore)))
+		Expect(downloadCacheKey(newBackup("../escape"))).ToNot(ContainSubstring("/"))
+	})
+})
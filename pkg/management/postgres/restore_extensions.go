@@ -0,0 +1,209 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+// restoredExtensionsQuery lists the extensions installed in a database,
+// together with the default version provided by the image, which is
+// empty when the image doesn't provide the extension at all
+const restoredExtensionsQuery = `
+SELECT e.extname, e.extversion, COALESCE(a.default_version, '')
+FROM pg_catalog.pg_extension e
+LEFT JOIN pg_catalog.pg_available_extensions a ON a.name = e.extname
+ORDER BY 1`
+
+// restoredExtension is an extension installed in a restored database
+type restoredExtension struct {
+	name           string
+	version        string
+	defaultVersion string
+}
+
+// getRecoveryExtensions gets the extensions the user asked to
+// update after the recovery
+func getRecoveryExtensions(cluster *apiv1.Cluster) []apiv1.RecoveryExtension {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Extensions
+}
+
+// listRestoredDatabases lists the restored databases accepting connections
+func listRestoredDatabases(ctx context.Context, instance *Instance) ([]string, error) {
+	superUserDB, err := instance.GetSuperUserDB()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := superUserDB.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("while starting a transaction: %w", err)
+	}
+	databases, errs := postgresutils.GetAllAccessibleDatabases(tx, "datallowconn")
+	if err := tx.Commit(); err != nil {
+		errs = append(errs, err)
+	}
+	if errs != nil {
+		return nil, fmt.Errorf("while listing the databases: %v", errs)
+	}
+
+	return databases, nil
+}
+
+// reconcileRestoredExtensions reports, for every restored database, the
+// extensions whose version differs from the one provided by the image,
+// and updates the ones requested by the user
+func (info InitInfo) reconcileRestoredExtensions(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	instance *Instance,
+) error {
+	databases, err := listRestoredDatabases(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	targets := getRecoveryExtensions(cluster)
+	for _, databaseName := range databases {
+		db, err := instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			return fmt.Errorf("could not connect to database %s: %w", databaseName, err)
+		}
+
+		if err := reconcileDatabaseExtensions(ctx, db, databaseName, targets); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileDatabaseExtensions reports the extensions of a database whose
+// version differs from the one provided by the image, and updates the
+// requested ones to the target version via ALTER EXTENSION. The requested
+// extensions not installed in the database are skipped
+func reconcileDatabaseExtensions(
+	ctx context.Context,
+	db *sql.DB,
+	databaseName string,
+	targets []apiv1.RecoveryExtension,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("database", databaseName)
+
+	installed, err := getRestoredExtensions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("while listing the extensions of database %s: %w", databaseName, err)
+	}
+
+	requested := make(map[string]apiv1.RecoveryExtension, len(targets))
+	for _, target := range targets {
+		requested[target.Name] = target
+	}
+
+	for _, extension := range installed {
+		target, isRequested := requested[extension.name]
+		switch {
+		case isRequested:
+			if err := updateRestoredExtension(ctx, db, databaseName, extension, target.Version); err != nil {
+				return err
+			}
+
+		case extension.defaultVersion == "":
+			contextLogger.Warning("EXTENSION MISMATCH: the restored extension is not provided by the image",
+				"extension", extension.name,
+				"version", extension.version)
+
+		case extension.version != extension.defaultVersion:
+			contextLogger.Warning("EXTENSION MISMATCH: the version of the restored extension differs "+
+				"from the one provided by the image",
+				"extension", extension.name,
+				"version", extension.version,
+				"imageVersion", extension.defaultVersion)
+		}
+	}
+
+	return nil
+}
+
+// updateRestoredExtension updates an extension to the passed version,
+// defaulting to the one provided by the image
+func updateRestoredExtension(
+	ctx context.Context,
+	db *sql.DB,
+	databaseName string,
+	extension restoredExtension,
+	version string,
+) error {
+	if version == "" {
+		version = extension.defaultVersion
+	}
+	if version == "" {
+		return fmt.Errorf("cannot update extension %s of database %s: the image doesn't provide it",
+			extension.name, databaseName)
+	}
+	if version == extension.version {
+		return nil
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER EXTENSION %s UPDATE TO %s",
+		pgx.Identifier{extension.name}.Sanitize(), pq.QuoteLiteral(version))); err != nil {
+		return fmt.Errorf("while updating extension %s of database %s to version %s: %w",
+			extension.name, databaseName, version, err)
+	}
+
+	log.FromContext(ctx).Info("Updated the restored extension",
+		"database", databaseName,
+		"extension", extension.name,
+		"previousVersion", extension.version,
+		"version", version)
+	return nil
+}
+
+// getRestoredExtensions lists the extensions installed in the
+// database of the passed connection
+func getRestoredExtensions(ctx context.Context, db *sql.DB) ([]restoredExtension, error) {
+	rows, err := db.QueryContext(ctx, restoredExtensionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []restoredExtension
+	for rows.Next() {
+		var extension restoredExtension
+		if err := rows.Scan(&extension.name, &extension.version, &extension.defaultVersion); err != nil {
+			return nil, err
+		}
+		result = append(result, extension)
+	}
+
+	return result, rows.Err()
+}
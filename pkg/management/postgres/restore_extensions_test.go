@@ -0,0 +1,96 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("extensions of the restored databases", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	extensionRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"extname", "extversion", "default_version"}).
+			AddRow("pg_stat_statements", "1.9", "1.10").
+			AddRow("plpgsql", "1.0", "1.0").
+			AddRow("postgis", "3.3.2", "3.4.0").
+			AddRow("timescaledb", "2.11.0", "")
+	}
+
+	It("only reports the mismatches when no extension is requested", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_extension").WillReturnRows(extensionRows())
+
+		Expect(reconcileDatabaseExtensions(context.TODO(), db, "app", nil)).To(Succeed())
+	})
+
+	It("updates the requested extensions to the target version", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_extension").WillReturnRows(extensionRows())
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER EXTENSION "pg_stat_statements" UPDATE TO '1.10'`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER EXTENSION "postgis" UPDATE TO '3.3.4'`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(reconcileDatabaseExtensions(context.TODO(), db, "app", []apiv1.RecoveryExtension{
+			{Name: "pg_stat_statements"},
+			{Name: "plpgsql"},
+			{Name: "postgis", Version: "3.3.4"},
+			{Name: "pgvector"},
+		})).To(Succeed())
+	})
+
+	It("fails when a requested extension is not provided by the image", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_extension").WillReturnRows(extensionRows())
+
+		err := reconcileDatabaseExtensions(context.TODO(), db, "app", []apiv1.RecoveryExtension{
+			{Name: "timescaledb"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("the image doesn't provide it")))
+	})
+
+	It("reports the errors raised while updating an extension", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_extension").WillReturnRows(extensionRows())
+		mock.ExpectExec("ALTER EXTENSION").WillReturnError(errors.New("no update path"))
+
+		err := reconcileDatabaseExtensions(context.TODO(), db, "app", []apiv1.RecoveryExtension{
+			{Name: "postgis", Version: "9.9"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("no update path")))
+	})
+})
@@ -0,0 +1,219 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrNoFallbackBackup is raised when the selected base backup can't be
+// restored, and there is no older one that can be used in its place
+var ErrNoFallbackBackup = errors.New("no base backup could be restored")
+
+// getBackupFallback gets the policy used to restore an older base backup
+// when the selected one can't be restored, if any
+func getBackupFallback(cluster *apiv1.Cluster) *apiv1.RecoveryBackupFallback {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Backup != nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.BackupFallback
+}
+
+// getMaxBackupFallbacks gets the maximum number of older base backups
+// that can be tried after the selected one
+func getMaxBackupFallbacks(fallback *apiv1.RecoveryBackupFallback) int {
+	if fallback.MaxFallbacks < 1 {
+		return 1
+	}
+
+	return int(fallback.MaxFallbacks)
+}
+
+// restoreDataDirWithFallback restores the passed base backup into PGDATA.
+// When the cluster allows it, and the restore fails, the previous base
+// backups are tried in turn, up to the configured limit. The base backup
+// that has been restored is returned and, when falling back was allowed,
+// reported in the cluster status
+func (info InitInfo) restoreDataDirWithFallback(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+) (*apiv1.Backup, error) {
+	contextLogger := log.FromContext(ctx)
+	policy := getRestoreRetryPolicy(cluster)
+
+	fallback := getBackupFallback(cluster)
+	if fallback == nil {
+		return backup, info.restoreDataDir(ctx, cluster, backup, env, policy)
+	}
+	maxFallbacks := getMaxBackupFallbacks(fallback)
+
+	var failedBackupIDs []string
+	for {
+		err := info.restoreBackupCandidate(ctx, cluster, backup, env, policy, len(failedBackupIDs) > 0)
+		if err == nil {
+			break
+		}
+		// An older backup won't help when barman-cloud-restore
+		// can't even be started
+		if ctx.Err() != nil || !barman.IsRetriableRestoreError(err) {
+			return nil, err
+		}
+
+		failedBackupIDs = append(failedBackupIDs, backup.Status.BackupID)
+		if len(failedBackupIDs) > maxFallbacks {
+			return nil, fmt.Errorf("%w: the maximum number of fallbacks (%d) has been reached, "+
+				"failed backups: %v, last error: %w",
+				ErrNoFallbackBackup, maxFallbacks, failedBackupIDs, err)
+		}
+
+		previous, listErr := info.loadPreviousBackup(ctx, typedClient, cluster, backup, env)
+		if listErr != nil {
+			return nil, fmt.Errorf("while looking for a base backup older than %s: %w (restore error: %w)",
+				backup.Status.BackupID, listErr, err)
+		}
+		if previous == nil {
+			return nil, fmt.Errorf("%w: no base backup older than %s, failed backups: %v, last error: %w",
+				ErrNoFallbackBackup, backup.Status.BackupID, failedBackupIDs, err)
+		}
+
+		contextLogger.Warning("Cannot restore the base backup, falling back to the previous one",
+			"failedBackupID", backup.Status.BackupID,
+			"backupID", previous.Status.BackupID,
+			"fallback", len(failedBackupIDs),
+			"maxFallbacks", maxFallbacks,
+			"error", err.Error())
+
+		// Start again from an empty data directory, as the failed
+		// restore may have left some files behind
+		if err := fileutils.RemoveDirectoryContent(info.PgData); err != nil {
+			return nil, fmt.Errorf("while cleaning up the data directory before falling back: %w", err)
+		}
+		backup = previous
+	}
+
+	if len(failedBackupIDs) > 0 {
+		contextLogger.Info("Restored an older base backup in place of the selected one",
+			"backupID", backup.Status.BackupID,
+			"failedBackupIDs", failedBackupIDs)
+	}
+
+	report := &apiv1.RestoredBackupReport{
+		BackupID:        backup.Status.BackupID,
+		FailedBackupIDs: failedBackupIDs,
+	}
+	if err := info.reportRestoredBackup(ctx, typedClient, report); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// restoreBackupCandidate restores a base backup into PGDATA. The presence
+// in the archive of the first WAL file needed by a fallback backup is
+// checked first, as the one of the selected backup has already been
+// checked before starting the restore
+func (info InitInfo) restoreBackupCandidate(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+	policy restoreRetryPolicy,
+	isFallback bool,
+) error {
+	if isFallback {
+		if err := info.ensureArchiveContainsLastCheckpointRedoWAL(ctx, cluster, env, backup); err != nil {
+			return err
+		}
+	}
+
+	return info.restoreDataDir(ctx, cluster, backup, env, policy)
+}
+
+// loadPreviousBackup gets the latest base backup, stored in the object
+// store of the recovery source, taken before the passed one. When there
+// is none, nil is returned
+func (info InitInfo) loadPreviousBackup(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+) (*apiv1.Backup, error) {
+	sourceName := cluster.Spec.Bootstrap.Recovery.Source
+	server, found := cluster.ExternalCluster(sourceName)
+	if !found {
+		return nil, fmt.Errorf("missing external cluster: %v", sourceName)
+	}
+
+	backupCatalog, err := info.barmanRunner(cluster).ListBackups(
+		ctx, server.BarmanObjectStore, server.GetServerName(), env)
+	if err != nil {
+		return nil, err
+	}
+
+	previousBackup := backupCatalog.PreviousBackupInfo(
+		backup.Status.BackupID,
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget)
+	if previousBackup == nil {
+		return nil, nil
+	}
+
+	return newBackupFromBarmanBackup(server, previousBackup), nil
+}
+
+// reportRestoredBackup writes the base backup that has been restored
+// in the cluster status
+func (info InitInfo) reportRestoredBackup(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.RestoredBackupReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RestoredBackup = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the restored backup in the cluster status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("falling back to an older base backup", func() {
+	beginTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	newCluster := func(fallback *apiv1.RecoveryBackupFallback) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:         "origin",
+						BackupFallback: fallback,
+					},
+				},
+				ExternalClusters: []apiv1.ExternalCluster{
+					{
+						Name: "origin",
+						BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+							DestinationPath: "s3://backups/",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	newRunner := func(restoreFailures int) *fakeBarmanRunner {
+		return &fakeBarmanRunner{
+			restoreFailures: restoreFailures,
+			backupCatalog: catalog.NewCatalog([]catalog.BarmanBackup{
+				{ID: "first", BeginTime: beginTime, EndTime: beginTime.Add(time.Hour)},
+				{ID: "second", BeginTime: beginTime.Add(24 * time.Hour), EndTime: beginTime.Add(25 * time.Hour)},
+				{ID: "failed", BeginTime: beginTime.Add(36 * time.Hour), Error: "failed"},
+				{ID: "third", BeginTime: beginTime.Add(48 * time.Hour), EndTime: beginTime.Add(49 * time.Hour)},
+			}),
+		}
+	}
+
+	newTypedClient := func(cluster *apiv1.Cluster) client.Client {
+		return fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+	}
+
+	It("sets a default for the maximum number of fallbacks", func() {
+		Expect(getBackupFallback(newCluster(nil))).To(BeNil())
+		Expect(getMaxBackupFallbacks(&apiv1.RecoveryBackupFallback{})).To(Equal(1))
+		Expect(getMaxBackupFallbacks(&apiv1.RecoveryBackupFallback{MaxFallbacks: 3})).To(Equal(3))
+	})
+
+	It("finds the latest valid base backup preceding the failed one", func() {
+		runner := newRunner(0)
+		info := InitInfo{BarmanRunner: runner}
+		cluster := newCluster(&apiv1.RecoveryBackupFallback{MaxFallbacks: 1})
+
+		previous, err := info.loadPreviousBackup(context.TODO(), nil, cluster,
+			&apiv1.Backup{Status: apiv1.BackupStatus{BackupID: "third"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(runner.listedServer).To(Equal("origin"))
+		Expect(previous.Status.BackupID).To(Equal("second"))
+		Expect(previous.Status.DestinationPath).To(Equal("s3://backups/"))
+
+		previous, err = info.loadPreviousBackup(context.TODO(), nil, cluster,
+			&apiv1.Backup{Status: apiv1.BackupStatus{BackupID: "first"}}, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(previous).To(BeNil())
+	})
+
+	It("doesn't fall back when not requested", func() {
+		runner := newRunner(1)
+		info := InitInfo{PgData: GinkgoT().TempDir(), BarmanRunner: runner}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+			BackupID:        "third",
+		}}
+
+		_, err := info.restoreDataDirWithFallback(context.TODO(), nil, newCluster(nil), backup, nil)
+		Expect(err).To(MatchError("temporary failure"))
+		Expect(runner.restoreAttempts).To(Equal(1))
+	})
+
+	It("fails when there is no older base backup", func() {
+		cluster := newCluster(&apiv1.RecoveryBackupFallback{MaxFallbacks: 2})
+		runner := newRunner(1)
+		info := InitInfo{PgData: GinkgoT().TempDir(), BarmanRunner: runner, ClusterName: "clone", Namespace: "dev"}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+			BackupID:        "first",
+		}}
+
+		_, err := info.restoreDataDirWithFallback(context.TODO(), newTypedClient(cluster), cluster, backup, nil)
+		Expect(err).To(MatchError(ErrNoFallbackBackup))
+		Expect(err.Error()).To(ContainSubstring("temporary failure"))
+		Expect(runner.restoreAttempts).To(Equal(1))
+	})
+
+	It("reports the restored base backup in the cluster status", func() {
+		cluster := newCluster(&apiv1.RecoveryBackupFallback{MaxFallbacks: 1})
+		typedClient := newTypedClient(cluster)
+		info := InitInfo{PgData: GinkgoT().TempDir(), BarmanRunner: newRunner(0), ClusterName: "clone", Namespace: "dev"}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+			BackupID:        "third",
+		}}
+
+		restored, err := info.restoreDataDirWithFallback(context.TODO(), typedClient, cluster, backup, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(restored).To(Equal(backup))
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.RestoredBackup).To(Equal(&apiv1.RestoredBackupReport{BackupID: "third"}))
+	})
+})
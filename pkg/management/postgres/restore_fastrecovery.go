@@ -0,0 +1,94 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// fastRecoveryOptions are the GUCs relaxing the durability of the
+// instance while the WAL files are being replayed
+var fastRecoveryOptions = map[string]string{
+	"fsync":              "off",
+	"full_page_writes":   "off",
+	"synchronous_commit": "off",
+}
+
+// isFastRecovery checks if the user requested to replay the WAL files
+// with a reduced durability
+func isFastRecovery(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.FastRecovery
+}
+
+// writeFastRecoveryConfiguration writes, in the override.conf file, the
+// options relaxing the durability of the instance for the recovery phase.
+// They will be removed when the replication settings are written after
+// the recovery is completed
+func (info InitInfo) writeFastRecoveryConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	if !isFastRecovery(cluster) {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, fastRecoveryOptions); err != nil {
+		return fmt.Errorf("while configuring Postgres for fast recovery: %w", err)
+	}
+
+	log.FromContext(ctx).Warning(
+		"FAST RECOVERY REQUESTED: the WAL files will be replayed with fsync, full_page_writes "+
+			"and synchronous_commit turned off. A crash during the recovery can corrupt the "+
+			"data directory, which will need to be restored again",
+		"options", fastRecoveryOptions)
+
+	return nil
+}
+
+// restoreDurabilityAfterFastRecovery removes the options relaxing the
+// durability from the configuration, if still present, and flushes to disk
+// the data directory written during the recovery. The instance needs to be
+// stopped
+func (info InitInfo) restoreDurabilityAfterFastRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	if !isFastRecovery(cluster) {
+		return nil
+	}
+
+	relaxedOptions := make([]string, 0, len(fastRecoveryOptions))
+	for option := range fastRecoveryOptions {
+		relaxedOptions = append(relaxedOptions, option)
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, nil, relaxedOptions...); err != nil {
+		return fmt.Errorf("while removing the fast recovery configuration: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Fast recovery completed, flushing the data directory to disk")
+	if err := info.initdbSyncOnly(ctx); err != nil {
+		return fmt.Errorf("while flushing write cache to disk: %w", err)
+	}
+
+	return nil
+}
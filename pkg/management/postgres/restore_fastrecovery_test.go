@@ -0,0 +1,71 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("fast recovery", func() {
+	var (
+		pgData       string
+		overrideConf string
+	)
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+		overrideConf = path.Join(pgData, constants.PostgresqlOverrideConfigurationFile)
+	})
+
+	newCluster := func(fastRecovery bool) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "source", FastRecovery: fastRecovery},
+				},
+			},
+		}
+	}
+
+	It("doesn't change the configuration unless requested", func() {
+		info := InitInfo{PgData: pgData}
+		Expect(info.writeFastRecoveryConfiguration(context.TODO(), newCluster(false))).To(Succeed())
+		Expect(overrideConf).ToNot(BeAnExistingFile())
+	})
+
+	It("relaxes the durability for the recovery phase", func() {
+		Expect(os.WriteFile(overrideConf, []byte("recovery_target_action = 'promote'\n"), 0o600)).To(Succeed())
+
+		info := InitInfo{PgData: pgData}
+		Expect(info.writeFastRecoveryConfiguration(context.TODO(), newCluster(true))).To(Succeed())
+
+		content, err := os.ReadFile(overrideConf) // nolint: gosec
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("recovery_target_action = 'promote'"))
+		Expect(string(content)).To(ContainSubstring("fsync = 'off'"))
+		Expect(string(content)).To(ContainSubstring("full_page_writes = 'off'"))
+		Expect(string(content)).To(ContainSubstring("synchronous_commit = 'off'"))
+	})
+})
@@ -0,0 +1,168 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// freezeCandidatesQuery lists the permanent tables and materialized
+	// views whose relfrozenxid is older than the passed age, the oldest
+	// first, up to the passed number of them. A NULL limit means no limit
+	freezeCandidatesQuery = "SELECT c.oid, n.nspname, c.relname, pg_catalog.age(c.relfrozenxid) " +
+		"FROM pg_catalog.pg_class c " +
+		"JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace " +
+		"WHERE c.relkind IN ('r', 'm') AND c.relpersistence = 'p' " +
+		"AND pg_catalog.age(c.relfrozenxid) >= $1 " +
+		"ORDER BY 4 DESC LIMIT $2"
+
+	// freezeAgeQuery gets the age of the relfrozenxid of a table
+	freezeAgeQuery = "SELECT pg_catalog.age(relfrozenxid) FROM pg_catalog.pg_class WHERE oid = $1"
+)
+
+// freezeCandidate is a table to be frozen
+type freezeCandidate struct {
+	oid    int64
+	schema string
+	name   string
+	age    int64
+}
+
+// getRecoveryFreeze gets the freeze of the restored tables
+// requested by the user, if any
+func getRecoveryFreeze(cluster *apiv1.Cluster) *apiv1.RecoveryFreeze {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Freeze
+}
+
+// freezeRestoredTables runs `VACUUM (FREEZE)` on the tables of every
+// restored database whose relfrozenxid is older than the threshold, the
+// oldest first. The tables are frozen one at a time, logging the age of
+// their relfrozenxid before and after, and the time it took
+func freezeRestoredTables(
+	ctx context.Context,
+	freeze *apiv1.RecoveryFreeze,
+	instance *Instance,
+) error {
+	if freeze == nil {
+		return nil
+	}
+
+	databases, err := listRestoredDatabases(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	for _, databaseName := range databases {
+		db, err := instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			return fmt.Errorf("could not connect to database %s: %w", databaseName, err)
+		}
+
+		if err := freezeDatabaseTables(ctx, db, databaseName, freeze); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// freezeDatabaseTables runs `VACUUM (FREEZE)` on the oldest
+// tables of a database
+func freezeDatabaseTables(
+	ctx context.Context,
+	db *sql.DB,
+	databaseName string,
+	freeze *apiv1.RecoveryFreeze,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("database", databaseName)
+
+	candidates, err := listFreezeCandidates(ctx, db, freeze)
+	if err != nil {
+		return fmt.Errorf("while listing the tables to be frozen in database %s: %w", databaseName, err)
+	}
+	if len(candidates) == 0 {
+		contextLogger.Info("No table needs to be frozen", "minAge", freeze.GetMinAge())
+		return nil
+	}
+
+	for _, candidate := range candidates {
+		table := pgx.Identifier{candidate.schema, candidate.name}.Sanitize()
+
+		start := time.Now()
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("VACUUM (FREEZE) %s", table)); err != nil {
+			return fmt.Errorf("while freezing table %s in database %s: %w", table, databaseName, err)
+		}
+		duration := time.Since(start)
+
+		var ageAfter int64
+		if err := db.QueryRowContext(ctx, freezeAgeQuery, candidate.oid).Scan(&ageAfter); err != nil {
+			return fmt.Errorf("while checking the age of table %s in database %s: %w", table, databaseName, err)
+		}
+
+		contextLogger.Info("Froze a restored table",
+			"table", table,
+			"ageBefore", candidate.age,
+			"ageAfter", ageAfter,
+			"duration", duration.String())
+	}
+
+	return nil
+}
+
+// listFreezeCandidates lists the tables to be frozen in a database
+func listFreezeCandidates(
+	ctx context.Context,
+	db *sql.DB,
+	freeze *apiv1.RecoveryFreeze,
+) ([]freezeCandidate, error) {
+	var limit sql.NullInt32
+	if freeze.MaxTables > 0 {
+		limit = sql.NullInt32{Int32: freeze.MaxTables, Valid: true}
+	}
+
+	rows, err := db.QueryContext(ctx, freezeCandidatesQuery, freeze.GetMinAge(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []freezeCandidate
+	for rows.Next() {
+		var candidate freezeCandidate
+		if err := rows.Scan(&candidate.oid, &candidate.schema, &candidate.name, &candidate.age); err != nil {
+			return nil, err
+		}
+		result = append(result, candidate)
+	}
+
+	return result, rows.Err()
+}
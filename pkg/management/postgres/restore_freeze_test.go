@@ -0,0 +1,92 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("freeze of the restored tables", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("freezes the oldest tables, up to the requested number", func() {
+		freeze := &apiv1.RecoveryFreeze{MinAge: ptr.To(int64(1000)), MaxTables: 2}
+		mock.ExpectQuery(regexp.QuoteMeta(freezeCandidatesQuery)).
+			WithArgs(int64(1000), sql.NullInt32{Int32: 2, Valid: true}).
+			WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "age"}).
+				AddRow(16384, "public", "events", 190000000).
+				AddRow(16390, "Audit", "log", 160000000))
+		mock.ExpectExec(regexp.QuoteMeta(`VACUUM (FREEZE) "public"."events"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(freezeAgeQuery)).WithArgs(int64(16384)).
+			WillReturnRows(sqlmock.NewRows([]string{"age"}).AddRow(12))
+		mock.ExpectExec(regexp.QuoteMeta(`VACUUM (FREEZE) "Audit"."log"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(freezeAgeQuery)).WithArgs(int64(16390)).
+			WillReturnRows(sqlmock.NewRows([]string{"age"}).AddRow(12))
+
+		Expect(freezeDatabaseTables(context.TODO(), db, "app", freeze)).To(Succeed())
+	})
+
+	It("freezes every table older than the default age when no limit is set", func() {
+		mock.ExpectQuery(regexp.QuoteMeta(freezeCandidatesQuery)).
+			WithArgs(int64(150000000), sql.NullInt32{}).
+			WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "age"}))
+
+		Expect(freezeDatabaseTables(context.TODO(), db, "app", &apiv1.RecoveryFreeze{})).To(Succeed())
+	})
+
+	It("fails when a table can't be frozen", func() {
+		mock.ExpectQuery(regexp.QuoteMeta(freezeCandidatesQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"oid", "nspname", "relname", "age"}).
+				AddRow(16384, "public", "events", 190000000))
+		mock.ExpectExec(regexp.QuoteMeta(`VACUUM (FREEZE) "public"."events"`)).
+			WillReturnError(errors.New("canceling statement due to user request"))
+
+		err := freezeDatabaseTables(context.TODO(), db, "app", &apiv1.RecoveryFreeze{})
+		Expect(err).To(MatchError(ContainSubstring(`while freezing table "public"."events" in database app`)))
+	})
+
+	It("does nothing when no freeze is requested", func() {
+		Expect(getRecoveryFreeze(&apiv1.Cluster{})).To(BeNil())
+		Expect(freezeRestoredTables(context.TODO(), nil, nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,109 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// finalizeRestoreHbaConf replaces the temporary pg_hba.conf written for the
+// restore, which only allows the local connections of the operating system
+// user, with the rules of the cluster. pg_ident.conf is installed first, so
+// that every map referred by the new rules exists when they are read. Both
+// files are replaced atomically, and PostgreSQL is asked to reload them if
+// it is running. The client is used only to read the LDAP bind password,
+// when the cluster uses the search+bind mode
+func (info InitInfo) finalizeRestoreHbaConf(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	contextLogger := log.FromContext(ctx)
+	instance := info.GetInstance()
+
+	ldapBindPassword, err := info.getLDAPBindPassword(ctx, typedClient, cluster)
+	if err != nil {
+		return err
+	}
+
+	// The rules are generated before touching any file, so that
+	// the temporary ones are kept if the generation fails
+	hbaContent, err := instance.GeneratePostgresqlHBA(cluster, ldapBindPassword)
+	if err != nil {
+		return fmt.Errorf("while generating the pg_hba.conf of the cluster: %w", err)
+	}
+
+	if _, err := instance.RefreshPGIdent(cluster.Spec.PostgresConfiguration.PgIdent); err != nil {
+		return fmt.Errorf("while installing the pg_ident.conf of the cluster: %w", err)
+	}
+
+	if _, err := InstallPgDataFileContent(info.PgData, hbaContent, constants.PostgresqlHBARulesFile); err != nil {
+		return fmt.Errorf("while installing the pg_hba.conf of the cluster: %w", err)
+	}
+
+	contextLogger.Info("Replaced the temporary access rules of the restore with the ones of the cluster")
+
+	// When PostgreSQL is not running, it reads the new
+	// rules as soon as it is started
+	if err := instance.IsServerHealthy(); err != nil {
+		return nil
+	}
+
+	if err := instance.Reload(ctx); err != nil {
+		return fmt.Errorf("while reloading the access rules of the cluster: %w", err)
+	}
+
+	return nil
+}
+
+// getLDAPBindPassword gets the password used by PostgreSQL to bind to
+// the LDAP server in the search+bind mode, if configured
+func (info InitInfo) getLDAPBindPassword(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+) (string, error) {
+	secretName := cluster.GetLDAPSecretName()
+	if secretName == "" {
+		return "", nil
+	}
+
+	var secret corev1.Secret
+	if err := typedClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: info.Namespace, Name: secretName},
+		&secret,
+	); err != nil {
+		return "", fmt.Errorf("while getting the LDAP bind password secret: %w", err)
+	}
+
+	secretKey := cluster.Spec.PostgresConfiguration.LDAP.BindSearchAuth.BindPassword.Key
+	password, ok := secret.Data[secretKey]
+	if !ok {
+		return "", fmt.Errorf("missing key inside bind+search secret: %s", secretKey)
+	}
+
+	return string(password), nil
+}
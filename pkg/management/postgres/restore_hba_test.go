@@ -0,0 +1,119 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("access rules after the restore", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir(), ClusterName: "clone", Namespace: "dev"}
+		Expect(info.WriteRestoreHbaConf()).To(Succeed())
+	})
+
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					PgHBA:   []string{"hostssl app app 10.0.0.0/8 scram-sha-256"},
+					PgIdent: []string{"custom-map admin app"},
+				},
+			},
+		}
+	}
+
+	It("replaces the temporary rules with the ones of the cluster", func(ctx SpecContext) {
+		Expect(info.finalizeRestoreHbaConf(ctx, nil, newCluster())).To(Succeed())
+
+		hbaContent, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlHBARulesFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(hbaContent)).To(ContainSubstring("hostssl app app 10.0.0.0/8 scram-sha-256"))
+		Expect(string(hbaContent)).To(ContainSubstring("map=local"))
+
+		identContent, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlIdentFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(identContent)).To(ContainSubstring("custom-map admin app"))
+		Expect(string(identContent)).To(ContainSubstring(
+			fmt.Sprintf("local %s postgres", getCurrentUserOrDefaultToInsecureMapping())))
+	})
+
+	It("uses the LDAP bind password of the cluster", func(ctx SpecContext) {
+		cluster := newCluster()
+		cluster.Spec.PostgresConfiguration.LDAP = &apiv1.LDAPConfig{
+			Server: "ldap.example.com",
+			BindSearchAuth: &apiv1.LDAPBindSearchAuth{
+				BaseDN: "dc=example,dc=com",
+				BindDN: "cn=admin,dc=example,dc=com",
+				BindPassword: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"},
+					Key:                  "password",
+				},
+			},
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "ldap-bind", Namespace: "dev"},
+			Data:       map[string][]byte{"password": []byte("bind-secret")},
+		}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(secret).
+			Build()
+
+		Expect(info.finalizeRestoreHbaConf(ctx, typedClient, cluster)).To(Succeed())
+
+		hbaContent, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlHBARulesFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(hbaContent)).To(ContainSubstring("bind-secret"))
+	})
+
+	It("keeps the temporary rules when the LDAP bind password is missing", func(ctx SpecContext) {
+		cluster := newCluster()
+		cluster.Spec.PostgresConfiguration.LDAP = &apiv1.LDAPConfig{
+			Server: "ldap.example.com",
+			BindSearchAuth: &apiv1.LDAPBindSearchAuth{
+				BindPassword: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "ldap-bind"},
+					Key:                  "password",
+				},
+			},
+		}
+		typedClient := fake.NewClientBuilder().WithScheme(scheme.BuildWithAllKnownScheme()).Build()
+
+		Expect(info.finalizeRestoreHbaConf(ctx, typedClient, cluster)).ToNot(Succeed())
+
+		hbaContent, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlHBARulesFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(hbaContent)).To(Equal("local all all peer map=local\n"))
+	})
+})
@@ -0,0 +1,231 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// restoreHeartbeat reports in the cluster status when the restore last
+// made progress. The progress is observed at every interval: a new state
+// of the restore, a new LSN replayed by the recovery, or, while the base
+// backup is being restored, more data downloaded. The cluster status is
+// updated only when some progress has been made since the previous
+// report, so that a stuck restore stops updating it
+type restoreHeartbeat struct {
+	// the status of the restore, where the state and
+	// the replayed LSN are observed
+	status *RestoreStatusTracker
+
+	// measures the downloaded data while restoring the base backup
+	downloadedSize func() (int64, error)
+
+	// writes the heartbeat in the cluster status
+	report func(ctx context.Context, heartbeat *apiv1.RestoreHeartbeat) error
+
+	// the last observed progress
+	phase        apiv1.RestoreState
+	replayedLSN  string
+	size         int64
+	lastProgress time.Time
+
+	// the progress written in the cluster status, if any
+	reportedProgress time.Time
+}
+
+// newRestoreHeartbeat creates the heartbeat of a restore starting now
+func newRestoreHeartbeat(
+	status *RestoreStatusTracker,
+	downloadedSize func() (int64, error),
+	report func(ctx context.Context, heartbeat *apiv1.RestoreHeartbeat) error,
+) *restoreHeartbeat {
+	return &restoreHeartbeat{
+		status:         status,
+		downloadedSize: downloadedSize,
+		report:         report,
+		phase:          apiv1.RestoreStateLoadBackup,
+		lastProgress:   time.Now(),
+	}
+}
+
+// observe checks if the restore made progress since the previous
+// observation, recording when the last progress was made
+func (h *restoreHeartbeat) observe(ctx context.Context, now time.Time) {
+	if h.status == nil {
+		return
+	}
+
+	status := h.status.Get()
+	if status.Phase != "" && status.Phase != h.phase {
+		h.phase = status.Phase
+		h.size = 0
+		h.lastProgress = now
+	}
+	if status.ReplayedLSN != h.replayedLSN {
+		h.replayedLSN = status.ReplayedLSN
+		h.lastProgress = now
+	}
+
+	if h.phase != apiv1.RestoreStateRestoreData || h.downloadedSize == nil {
+		return
+	}
+
+	size, err := h.downloadedSize()
+	if err != nil {
+		// The files are being written while the size is measured
+		log.FromContext(ctx).Debug("Cannot measure the downloaded data", "error", err.Error())
+		return
+	}
+	// A smaller size means that a failed download is being retried
+	if size > h.size {
+		h.lastProgress = now
+	}
+	h.size = size
+}
+
+// beat observes the progress of the restore and reports it in the cluster
+// status, unless it was already reported. Errors are only logged, as they
+// don't affect the restore: the progress will be reported at the next beat
+func (h *restoreHeartbeat) beat(ctx context.Context, now time.Time) {
+	h.observe(ctx, now)
+	if !h.lastProgress.After(h.reportedProgress) {
+		return
+	}
+
+	heartbeat := &apiv1.RestoreHeartbeat{
+		Phase:            h.phase,
+		LastProgressTime: metav1.NewTime(h.lastProgress),
+	}
+	if err := h.report(ctx, heartbeat); err != nil {
+		log.FromContext(ctx).Warning("Cannot report the heartbeat of the restore",
+			"phase", h.phase,
+			"error", err.Error())
+		return
+	}
+	h.reportedProgress = h.lastProgress
+}
+
+// run beats at every interval, until the context is cancelled
+func (h *restoreHeartbeat) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	h.beat(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			h.beat(ctx, now)
+		}
+	}
+}
+
+// startHeartbeat starts the heartbeat of the restore, when requested,
+// returning the function stopping it. The last progress, like the end
+// of the restore, is reported once the heartbeat is stopped
+func (m *restoreMachine) startHeartbeat(ctx context.Context) func() {
+	if m.cluster.Spec.Bootstrap == nil || m.cluster.Spec.Bootstrap.Recovery == nil ||
+		m.cluster.Spec.Bootstrap.Recovery.Heartbeat == nil {
+		return func() {}
+	}
+
+	interval := m.cluster.Spec.Bootstrap.Recovery.Heartbeat.GetInterval()
+	heartbeat := newRestoreHeartbeat(
+		m.info.RestoreStatus,
+		func() (int64, error) {
+			return downloadedDataSize(
+				m.info.PgData,
+				postgresSpec.RecoveryStagingDirectory,
+				postgresSpec.RecoveryDownloadCacheDirectory)
+		},
+		func(ctx context.Context, heartbeat *apiv1.RestoreHeartbeat) error {
+			return m.info.reportRestoreHeartbeat(ctx, m.typedClient, heartbeat)
+		},
+	)
+
+	heartbeatCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		heartbeat.run(heartbeatCtx, interval)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		heartbeat.beat(ctx, time.Now())
+	}
+}
+
+// downloadedDataSize computes the size of the data downloaded in the
+// passed directories, ignoring the ones that don't exist
+func downloadedDataSize(directories ...string) (int64, error) {
+	var size int64
+	for _, directory := range directories {
+		if _, err := os.Stat(directory); os.IsNotExist(err) {
+			continue
+		}
+
+		directorySize, err := directorySize(directory)
+		if err != nil {
+			return 0, err
+		}
+		size += directorySize
+	}
+
+	return size, nil
+}
+
+// reportRestoreHeartbeat writes the last progress
+// of the restore in the cluster status
+func (info InitInfo) reportRestoreHeartbeat(
+	ctx context.Context,
+	typedClient client.Client,
+	heartbeat *apiv1.RestoreHeartbeat,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RestoreHeartbeat = heartbeat
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the heartbeat of the restore in the cluster status: %w", err)
+	}
+
+	return nil
+}
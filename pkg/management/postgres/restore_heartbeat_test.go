@@ -0,0 +1,159 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("heartbeat of the restore", func() {
+	var (
+		tracker  *RestoreStatusTracker
+		reported []*apiv1.RestoreHeartbeat
+		size     int64
+		start    time.Time
+	)
+
+	newHeartbeat := func() *restoreHeartbeat {
+		heartbeat := newRestoreHeartbeat(
+			tracker,
+			func() (int64, error) { return size, nil },
+			func(_ context.Context, heartbeat *apiv1.RestoreHeartbeat) error {
+				reported = append(reported, heartbeat)
+				return nil
+			},
+		)
+		heartbeat.lastProgress = start
+		return heartbeat
+	}
+
+	BeforeEach(func() {
+		tracker = NewRestoreStatusTracker()
+		reported = nil
+		size = 0
+		start = time.Now()
+	})
+
+	It("reports the progress only once", func() {
+		heartbeat := newHeartbeat()
+		heartbeat.beat(context.TODO(), start)
+		Expect(reported).To(HaveLen(1))
+		Expect(reported[0].Phase).To(Equal(apiv1.RestoreStateLoadBackup))
+		Expect(reported[0].LastProgressTime.Time).To(BeTemporally("==", start))
+
+		heartbeat.beat(context.TODO(), start.Add(time.Minute))
+		Expect(reported).To(HaveLen(1))
+	})
+
+	It("reports a new state and a new replayed LSN", func() {
+		heartbeat := newHeartbeat()
+		heartbeat.beat(context.TODO(), start)
+
+		tracker.recordPhase(apiv1.RestoreStateWaitRecovery)
+		heartbeat.beat(context.TODO(), start.Add(time.Minute))
+		Expect(reported).To(HaveLen(2))
+		Expect(reported[1].Phase).To(Equal(apiv1.RestoreStateWaitRecovery))
+
+		tracker.recordProgress(&apiv1.RecoveryProgressReport{ReplayedLSN: "0/5000100"})
+		heartbeat.beat(context.TODO(), start.Add(2*time.Minute))
+		Expect(reported).To(HaveLen(3))
+		Expect(reported[2].LastProgressTime.Time).To(BeTemporally("==", start.Add(2*time.Minute)))
+	})
+
+	It("reports the growth of the downloaded data", func() {
+		heartbeat := newHeartbeat()
+		tracker.recordPhase(apiv1.RestoreStateRestoreData)
+		size = 1024
+		heartbeat.beat(context.TODO(), start)
+		Expect(reported).To(HaveLen(1))
+
+		heartbeat.beat(context.TODO(), start.Add(time.Minute))
+		Expect(reported).To(HaveLen(1))
+
+		size = 2048
+		heartbeat.beat(context.TODO(), start.Add(2*time.Minute))
+		Expect(reported).To(HaveLen(2))
+
+		// A failed download being retried starts again from an empty directory
+		size = 0
+		heartbeat.beat(context.TODO(), start.Add(3*time.Minute))
+		Expect(reported).To(HaveLen(2))
+	})
+
+	It("reports the progress again after a failed report", func() {
+		failures := 1
+		report := func(_ context.Context, heartbeat *apiv1.RestoreHeartbeat) error {
+			if failures > 0 {
+				failures--
+				return errors.New("conflict")
+			}
+			reported = append(reported, heartbeat)
+			return nil
+		}
+		heartbeat := newRestoreHeartbeat(tracker, nil, report)
+
+		heartbeat.beat(context.TODO(), start)
+		Expect(reported).To(BeEmpty())
+		heartbeat.beat(context.TODO(), start.Add(time.Minute))
+		Expect(reported).To(HaveLen(1))
+	})
+
+	It("measures the data downloaded in the existing directories", func() {
+		directory := GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(directory, "file"), make([]byte, 100), 0o600)).To(Succeed())
+
+		size, err := downloadedDataSize(directory, path.Join(directory, "missing"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(size).To(Equal(int64(100)))
+	})
+
+	It("reports the heartbeat in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		heartbeat := &apiv1.RestoreHeartbeat{
+			Phase:            apiv1.RestoreStateRestoreData,
+			LastProgressTime: metav1.NewTime(start.Truncate(time.Second)),
+		}
+
+		Expect(info.reportRestoreHeartbeat(context.TODO(), typedClient, heartbeat)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.RestoreHeartbeat.Phase).To(Equal(apiv1.RestoreStateRestoreData))
+		Expect(result.Status.RestoreHeartbeat.LastProgressTime.Time).
+			To(BeTemporally("==", heartbeat.LastProgressTime.Time))
+	})
+})
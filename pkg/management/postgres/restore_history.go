@@ -0,0 +1,167 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// restoreHistoryLimit is the number of restores retained
+// in the status of a backup
+const restoreHistoryLimit = 10
+
+// newBackupRestoreRecord creates the record of a restore that ended now
+func newBackupRestoreRecord(
+	cluster *apiv1.Cluster,
+	backupID string,
+	startedAt time.Time,
+	result *apiv1.RestoreResult,
+	restoreErr error,
+) apiv1.BackupRestoreRecord {
+	now := time.Now()
+	record := apiv1.BackupRestoreRecord{
+		ClusterName:      cluster.Name,
+		ClusterNamespace: cluster.Namespace,
+		BackupID:         backupID,
+		CompletedAt:      metav1.NewTime(now),
+		Succeeded:        restoreErr == nil,
+	}
+
+	if !startedAt.IsZero() {
+		record.StartedAt = &metav1.Time{Time: startedAt}
+		record.Duration = &metav1.Duration{Duration: now.Sub(startedAt).Round(time.Second)}
+	}
+
+	if restoreErr != nil {
+		record.Error = restoreErr.Error()
+	}
+
+	if result != nil {
+		record.ReplayedBytes = result.ReplayedBytes
+		record.TargetReached = result.TargetReached
+	}
+
+	return record
+}
+
+// appendBackupRestoreRecord adds a record to the history of the restores,
+// discarding the oldest ones exceeding the limit
+func appendBackupRestoreRecord(
+	history []apiv1.BackupRestoreRecord,
+	record apiv1.BackupRestoreRecord,
+	limit int,
+) []apiv1.BackupRestoreRecord {
+	history = append(history, record)
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+
+	return history
+}
+
+// recordBackupRestore appends the record of a restore to the history in
+// the status of the Backup object referenced by the cluster, if any.
+// Errors are only logged, as they don't affect the restore
+func (info InitInfo) recordBackupRestore(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	record apiv1.BackupRestoreRecord,
+) {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Backup == nil {
+		return
+	}
+
+	backupObjectKey := client.ObjectKey{
+		Namespace: cluster.Spec.Bootstrap.Recovery.Backup.GetNamespace(info.Namespace),
+		Name:      cluster.Spec.Bootstrap.Recovery.Backup.Name,
+	}
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var backup apiv1.Backup
+		if err := typedClient.Get(ctx, backupObjectKey, &backup); err != nil {
+			return err
+		}
+
+		backup.Status.RestoreHistory = appendBackupRestoreRecord(
+			backup.Status.RestoreHistory, record, restoreHistoryLimit)
+		return typedClient.Status().Update(ctx, &backup)
+	})
+	if err != nil {
+		log.FromContext(ctx).Warning("Cannot record the restore in the backup status",
+			"backup", backupObjectKey.Name,
+			"namespace", backupObjectKey.Namespace,
+			"error", err.Error())
+		return
+	}
+
+	log.FromContext(ctx).Info("Recorded the restore in the backup status",
+		"backup", backupObjectKey.Name,
+		"namespace", backupObjectKey.Namespace,
+		"succeeded", record.Succeeded)
+}
+
+// recordHistory records the outcome of the restore in the status of the
+// restored Backup object. The start of a resumed restore is the one
+// written in its manifest
+func (m *restoreMachine) recordHistory(ctx context.Context, startedAt time.Time, restoreErr error) {
+	if m.manifest != nil {
+		if manifestStartedAt, err := time.Parse(time.RFC3339, m.manifest.StartedAt); err == nil {
+			startedAt = manifestStartedAt
+		}
+	}
+
+	var backupID string
+	if m.backup != nil {
+		backupID = m.backup.Status.BackupID
+	}
+
+	var result *apiv1.RestoreResult
+	if restoreErr == nil {
+		var err error
+		if result, err = m.info.restoreResult(ctx, m.typedClient); err != nil {
+			log.FromContext(ctx).Warning("Cannot get the result of the restore", "error", err.Error())
+		}
+	}
+
+	m.info.recordBackupRestore(ctx, m.typedClient, m.cluster,
+		newBackupRestoreRecord(m.cluster, backupID, startedAt, result, restoreErr))
+}
+
+// restoreResult gets the summary of the WAL replay written in the
+// cluster status, if any
+func (info InitInfo) restoreResult(ctx context.Context, typedClient client.Client) (*apiv1.RestoreResult, error) {
+	var cluster apiv1.Cluster
+	if err := typedClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+		&cluster,
+	); err != nil {
+		return nil, fmt.Errorf("while getting the result of the restore: %w", err)
+	}
+
+	return cluster.Status.RestoreResult, nil
+}
@@ -0,0 +1,98 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Restore history", func() {
+	cluster := &apiv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-restore", Namespace: "default"},
+		Spec: apiv1.ClusterSpec{
+			Bootstrap: &apiv1.BootstrapConfiguration{
+				Recovery: &apiv1.BootstrapRecovery{
+					Backup: &apiv1.BackupSource{
+						LocalObjectReference: apiv1.LocalObjectReference{Name: "backup-example"},
+					},
+				},
+			},
+		},
+	}
+
+	It("creates the record of a restore", func() {
+		startedAt := time.Now().Add(-time.Hour)
+		record := newBackupRestoreRecord(cluster, "20240101T000000", startedAt,
+			&apiv1.RestoreResult{ReplayedBytes: 1024, TargetReached: ptr.To(true)}, nil)
+		Expect(record.ClusterName).To(Equal("cluster-restore"))
+		Expect(record.ClusterNamespace).To(Equal("default"))
+		Expect(record.BackupID).To(Equal("20240101T000000"))
+		Expect(record.Succeeded).To(BeTrue())
+		Expect(record.Duration.Duration).To(BeNumerically("~", time.Hour, time.Minute))
+		Expect(record.ReplayedBytes).To(BeEquivalentTo(1024))
+		Expect(record.TargetReached).To(HaveValue(BeTrue()))
+
+		record = newBackupRestoreRecord(cluster, "", time.Time{}, nil, errors.New("restore failed"))
+		Expect(record.Succeeded).To(BeFalse())
+		Expect(record.Error).To(Equal("restore failed"))
+		Expect(record.StartedAt).To(BeNil())
+		Expect(record.Duration).To(BeNil())
+	})
+
+	It("retains only the most recent restores", func() {
+		var history []apiv1.BackupRestoreRecord
+		for i := 0; i < 5; i++ {
+			history = appendBackupRestoreRecord(history,
+				apiv1.BackupRestoreRecord{BackupID: fmt.Sprintf("backup-%d", i)}, 3)
+		}
+		Expect(history).To(HaveLen(3))
+		Expect(history[0].BackupID).To(Equal("backup-2"))
+		Expect(history[2].BackupID).To(Equal("backup-4"))
+	})
+
+	It("records the restore in the status of the backup", func() {
+		backup := &apiv1.Backup{ObjectMeta: metav1.ObjectMeta{Name: "backup-example", Namespace: "default"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(backup).
+			WithStatusSubresource(backup).
+			Build()
+
+		info := InitInfo{Namespace: "default", ClusterName: "cluster-restore"}
+		info.recordBackupRestore(context.TODO(), typedClient, cluster,
+			newBackupRestoreRecord(cluster, "20240101T000000", time.Now(), nil, nil))
+
+		var updated apiv1.Backup
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(backup), &updated)).To(Succeed())
+		Expect(updated.Status.RestoreHistory).To(HaveLen(1))
+		Expect(updated.Status.RestoreHistory[0].ClusterName).To(Equal("cluster-restore"))
+	})
+})
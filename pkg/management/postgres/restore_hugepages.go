@@ -0,0 +1,267 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+const (
+	// hugePagesParameter is the PostgreSQL parameter controlling
+	// the usage of the huge pages
+	hugePagesParameter = "huge_pages"
+
+	// hugePagesRecoveryFallback is the value of huge_pages used during
+	// the recovery when the huge pages required by the cluster aren't
+	// available. PostgreSQL uses them anyway if it can
+	hugePagesRecoveryFallback = "try"
+)
+
+// hugePagesProbe checks if the huge pages can be used by the
+// PostgreSQL instance running in the current pod
+type hugePagesProbe struct {
+	// the file reporting the memory usage of the node
+	meminfoFile string
+	// the directory of the cgroup of the pod
+	cgroupDirectory string
+}
+
+// defaultHugePagesProbe checks the huge pages of the current node and pod
+var defaultHugePagesProbe = hugePagesProbe{
+	meminfoFile:     "/proc/meminfo",
+	cgroupDirectory: "/sys/fs/cgroup",
+}
+
+// requiresHugePages checks if the cluster configuration
+// requires PostgreSQL to use the huge pages
+func requiresHugePages(cluster *apiv1.Cluster) bool {
+	value := cluster.Spec.PostgresConfiguration.Parameters[hugePagesParameter]
+	return strings.EqualFold(strings.Trim(strings.TrimSpace(value), "'"), "on")
+}
+
+// unavailabilityReason checks if the huge pages can be used by an instance
+// with the passed shared buffers, returning an empty string if they can
+// and, otherwise, the reason why they can't. When the size of the shared
+// buffers is empty, just the presence of free huge pages is checked
+func (probe hugePagesProbe) unavailabilityReason(sharedBuffers string) (string, error) {
+	freePages, pageSizeKB, err := readHugePagesMeminfo(probe.meminfoFile)
+	if err != nil {
+		return "", err
+	}
+	if freePages == 0 || pageSizeKB == 0 {
+		return "the node has no free huge pages", nil
+	}
+
+	var sharedBuffersKB int64
+	if sharedBuffers != "" {
+		if sharedBuffersKB, err = parseMemoryKB(sharedBuffers, 8); err != nil {
+			return "", fmt.Errorf("while parsing shared_buffers %q: %w", sharedBuffers, err)
+		}
+		if freePages*pageSizeKB < sharedBuffersKB {
+			return fmt.Sprintf("the node has %d free huge pages of %dkB, not enough for shared_buffers %s",
+				freePages, pageSizeKB, sharedBuffers), nil
+		}
+	}
+
+	limit, err := readHugePagesCgroupLimit(probe.cgroupDirectory, pageSizeKB)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case limit == 0:
+		return "the pod doesn't request any huge pages", nil
+	case limit > 0 && limit < sharedBuffersKB*1024:
+		return fmt.Sprintf("the pod requests %d bytes of huge pages, not enough for shared_buffers %s",
+			limit, sharedBuffers), nil
+	}
+
+	return "", nil
+}
+
+// readHugePagesMeminfo reads, from the memory usage of the
+// node, the number of free huge pages and their size in kB
+func readHugePagesMeminfo(meminfoFile string) (freePages, pageSizeKB int64, err error) {
+	file, err := os.Open(meminfoFile)
+	if err != nil {
+		return 0, 0, fmt.Errorf("while reading the huge pages of the node: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch name {
+		case "HugePages_Free":
+			freePages, err = strconv.ParseInt(fields[0], 10, 64)
+		case "Hugepagesize":
+			pageSizeKB, err = strconv.ParseInt(fields[0], 10, 64)
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("while parsing %s in %s: %w", name, meminfoFile, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, fmt.Errorf("while reading the huge pages of the node: %w", err)
+	}
+
+	return freePages, pageSizeKB, nil
+}
+
+// readHugePagesCgroupLimit reads the limit, in bytes, applied to the huge
+// pages of the passed size by the cgroup v2 of the pod. A negative value
+// is returned when no limit is applied or the limit can't be found, as with
+// cgroup v1, in which case PostgreSQL is expected to use the huge pages
+func readHugePagesCgroupLimit(cgroupDirectory string, pageSizeKB int64) (int64, error) {
+	limitFile := path.Join(cgroupDirectory, fmt.Sprintf("hugetlb.%s.max", hugePagesCgroupSize(pageSizeKB)))
+	content, err := os.ReadFile(limitFile)
+	if os.IsNotExist(err) {
+		return -1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("while reading the huge pages limit of the pod: %w", err)
+	}
+
+	value := strings.TrimSpace(string(content))
+	if value == "max" {
+		return -1, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("while parsing the huge pages limit in %s: %w", limitFile, err)
+	}
+
+	return limit, nil
+}
+
+// hugePagesCgroupSize gets the name used by the cgroup hugetlb
+// controller for the huge pages of the passed size
+func hugePagesCgroupSize(pageSizeKB int64) string {
+	switch {
+	case pageSizeKB%(1024*1024) == 0:
+		return fmt.Sprintf("%dGB", pageSizeKB/(1024*1024))
+	case pageSizeKB%1024 == 0:
+		return fmt.Sprintf("%dMB", pageSizeKB/1024)
+	default:
+		return fmt.Sprintf("%dKB", pageSizeKB)
+	}
+}
+
+// writeRecoveryHugePagesConfiguration sets, in the custom.conf file, huge_pages
+// to try for the recovery phase when the cluster requires the huge pages but
+// they aren't available in the pod running the recovery, as PostgreSQL
+// wouldn't start otherwise. The value of the cluster configuration is set
+// back once the restored instance is configured
+func (info InitInfo) writeRecoveryHugePagesConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	if !requiresHugePages(cluster) {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	reason, err := defaultHugePagesProbe.unavailabilityReason(
+		cluster.Spec.PostgresConfiguration.Parameters["shared_buffers"])
+	if err != nil {
+		contextLogger.Warning("Cannot check if the huge pages are available, keeping the cluster configuration",
+			"parameter", hugePagesParameter,
+			"error", err.Error())
+		return nil
+	}
+	if reason == "" {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		map[string]string{hugePagesParameter: hugePagesRecoveryFallback},
+	); err != nil {
+		return fmt.Errorf("while configuring the huge pages for the recovery: %w", err)
+	}
+
+	contextLogger.Warning("The huge pages required by the cluster aren't available, "+
+		"they won't be required during the recovery",
+		"parameter", hugePagesParameter,
+		"value", hugePagesRecoveryFallback,
+		"reason", reason)
+
+	return nil
+}
+
+// restoreHugePagesAfterRecovery sets huge_pages back to the value of the
+// cluster configuration, warning the user when the huge pages still aren't
+// available, as the instance will not start in this pod. The instance
+// needs to be stopped
+func (info InitInfo) restoreHugePagesAfterRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	if !requiresHugePages(cluster) {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	changed, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		map[string]string{hugePagesParameter: cluster.Spec.PostgresConfiguration.Parameters[hugePagesParameter]},
+	)
+	if err != nil {
+		return fmt.Errorf("while restoring the huge pages configuration: %w", err)
+	}
+	if !changed {
+		return nil
+	}
+
+	contextLogger.Info("Restored the huge pages configuration after the recovery",
+		"parameter", hugePagesParameter)
+
+	reason, err := defaultHugePagesProbe.unavailabilityReason(
+		cluster.Spec.PostgresConfiguration.Parameters["shared_buffers"])
+	switch {
+	case err != nil:
+		contextLogger.Warning("Cannot check if the huge pages are available",
+			"parameter", hugePagesParameter,
+			"error", err.Error())
+	case reason != "":
+		contextLogger.Warning("The huge pages required by the cluster still aren't available, "+
+			"PostgreSQL will not start until they are configured on the node and requested by the pod",
+			"parameter", hugePagesParameter,
+			"reason", reason)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("huge pages fallback during the recovery", func() {
+	var probe hugePagesProbe
+
+	writeMeminfo := func(freePages int) {
+		Expect(os.WriteFile(probe.meminfoFile, []byte(fmt.Sprintf(
+			"MemTotal:       16384000 kB\nHugePages_Total:    1024\nHugePages_Free:     %d\n"+
+				"Hugepagesize:       2048 kB\n", freePages)), 0o600)).To(Succeed())
+	}
+
+	writeCgroupLimit := func(limit string) {
+		Expect(os.WriteFile(path.Join(probe.cgroupDirectory, "hugetlb.2MB.max"), []byte(limit+"\n"), 0o600)).
+			To(Succeed())
+	}
+
+	newCluster := func(hugePages string) *apiv1.Cluster {
+		return &apiv1.Cluster{Spec: apiv1.ClusterSpec{PostgresConfiguration: apiv1.PostgresConfiguration{
+			Parameters: map[string]string{"huge_pages": hugePages, "shared_buffers": "1GB"},
+		}}}
+	}
+
+	BeforeEach(func() {
+		tempDir := GinkgoT().TempDir()
+		probe = hugePagesProbe{meminfoFile: path.Join(tempDir, "meminfo"), cgroupDirectory: tempDir}
+
+		previousProbe := defaultHugePagesProbe
+		defaultHugePagesProbe = probe
+		DeferCleanup(func() {
+			defaultHugePagesProbe = previousProbe
+		})
+	})
+
+	It("detects if the huge pages are required by the cluster", func() {
+		Expect(requiresHugePages(newCluster("on"))).To(BeTrue())
+		Expect(requiresHugePages(newCluster("'ON'"))).To(BeTrue())
+		Expect(requiresHugePages(newCluster("try"))).To(BeFalse())
+		Expect(requiresHugePages(&apiv1.Cluster{})).To(BeFalse())
+	})
+
+	It("checks the huge pages of the node and of the pod", func() {
+		writeMeminfo(0)
+		Expect(probe.unavailabilityReason("")).To(ContainSubstring("no free huge pages"))
+
+		writeMeminfo(256)
+		Expect(probe.unavailabilityReason("")).To(BeEmpty())
+		Expect(probe.unavailabilityReason("1GB")).To(ContainSubstring("not enough for shared_buffers 1GB"))
+		Expect(probe.unavailabilityReason("512MB")).To(BeEmpty())
+
+		writeCgroupLimit("max")
+		Expect(probe.unavailabilityReason("512MB")).To(BeEmpty())
+		writeCgroupLimit("0")
+		Expect(probe.unavailabilityReason("512MB")).To(ContainSubstring("doesn't request any huge pages"))
+		writeCgroupLimit("268435456")
+		Expect(probe.unavailabilityReason("512MB")).To(ContainSubstring("requests 268435456 bytes"))
+	})
+
+	It("names the cgroup files after the size of the huge pages", func() {
+		Expect(hugePagesCgroupSize(2048)).To(Equal("2MB"))
+		Expect(hugePagesCgroupSize(1024 * 1024)).To(Equal("1GB"))
+		Expect(hugePagesCgroupSize(64)).To(Equal("64KB"))
+	})
+
+	It("falls back to try during the recovery and restores the setting afterwards", func() {
+		writeMeminfo(0)
+		info := InitInfo{PgData: GinkgoT().TempDir()}
+		cluster := newCluster("on")
+		customConf := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+
+		Expect(info.writeRecoveryHugePagesConfiguration(context.TODO(), cluster)).To(Succeed())
+		content, err := os.ReadFile(customConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("huge_pages = 'try'"))
+
+		Expect(info.restoreHugePagesAfterRecovery(context.TODO(), cluster)).To(Succeed())
+		content, err = os.ReadFile(customConf)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("huge_pages = 'on'"))
+	})
+
+	It("keeps the cluster configuration when the huge pages are available", func() {
+		writeMeminfo(1024)
+		info := InitInfo{PgData: GinkgoT().TempDir()}
+
+		Expect(info.writeRecoveryHugePagesConfiguration(context.TODO(), newCluster("on"))).To(Succeed())
+		Expect(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)).ToNot(BeAnExistingFile())
+	})
+})
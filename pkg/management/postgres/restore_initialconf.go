@@ -0,0 +1,183 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// initialPostgresqlConf is the configuration of the restored instance,
+// generated in a temporary data directory and ready to be installed
+// in PGDATA
+type initialPostgresqlConf struct {
+	tempDataDir string
+	policy      apiv1.RecoveryTemporaryDirectoryPolicy
+}
+
+// prepareInitialPostgresqlConf bootstraps a temporary instance and
+// generates its configuration from the Cluster spec. Nothing is written
+// in PGDATA, so this can be done while the base backup is restored
+func (info InitInfo) prepareInitialPostgresqlConf(cluster *apiv1.Cluster) (_ *initialPostgresqlConf, err error) {
+	if err := fileutils.EnsureDirectoryExists(postgresSpec.RecoveryTemporaryDirectory); err != nil {
+		return nil, err
+	}
+
+	tempDataDir, err := newTemporaryDataDir(postgresSpec.RecoveryTemporaryDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("while creating a temporary data directory: %w", err)
+	}
+	conf := &initialPostgresqlConf{
+		tempDataDir: tempDataDir,
+		policy:      cluster.GetRecoveryTemporaryDirectoryPolicy(),
+	}
+	defer func() {
+		if err != nil {
+			conf.release(true)
+		}
+	}()
+
+	temporaryInitInfo := InitInfo{
+		PgData:    tempDataDir,
+		Temporary: true,
+	}
+
+	if err = temporaryInitInfo.CreateDataDirectory(); err != nil {
+		return nil, fmt.Errorf("while creating a temporary data directory: %w", err)
+	}
+
+	temporaryInstance := temporaryInitInfo.GetInstance()
+	temporaryInstance.Namespace = info.Namespace
+	temporaryInstance.ClusterName = info.ClusterName
+
+	_, err = temporaryInstance.RefreshPGHBA(cluster, "")
+	if err != nil {
+		return nil, fmt.Errorf("while generating pg_hba.conf: %w", err)
+	}
+	_, err = temporaryInstance.RefreshPGIdent(cluster.Spec.PostgresConfiguration.PgIdent)
+	if err != nil {
+		return nil, fmt.Errorf("while generating pg_ident.conf: %w", err)
+	}
+	_, err = temporaryInstance.RefreshConfigurationFilesFromCluster(cluster, false)
+	if err != nil {
+		return nil, fmt.Errorf("while generating Postgres configuration: %w", err)
+	}
+
+	return conf, nil
+}
+
+// install copies the generated configuration into PGDATA, and removes
+// the temporary data directory
+func (conf *initialPostgresqlConf) install(pgData string) (err error) {
+	defer func() {
+		conf.release(err != nil)
+	}()
+
+	err = fileutils.CopyFile(
+		path.Join(conf.tempDataDir, "postgresql.conf"),
+		path.Join(pgData, "postgresql.conf"))
+	if err != nil {
+		return fmt.Errorf("while installing postgresql.conf: %w", err)
+	}
+
+	err = fileutils.CopyFile(
+		path.Join(conf.tempDataDir, constants.PostgresqlCustomConfigurationFile),
+		path.Join(pgData, constants.PostgresqlCustomConfigurationFile))
+	if err != nil {
+		return fmt.Errorf("while installing %v: %w", constants.PostgresqlCustomConfigurationFile, err)
+	}
+
+	err = fileutils.CopyFile(
+		path.Join(conf.tempDataDir, constants.PostgresqlOverrideConfigurationFile),
+		path.Join(pgData, constants.PostgresqlOverrideConfigurationFile))
+	if err != nil {
+		return fmt.Errorf("while installing %v: %w", constants.PostgresqlOverrideConfigurationFile, err)
+	}
+
+	// Disable SSL as we still don't have the required certificates
+	err = fileutils.AppendStringToFile(
+		path.Join(pgData, constants.PostgresqlCustomConfigurationFile),
+		"ssl = 'off'\n")
+	if err != nil {
+		return fmt.Errorf("cannot write recovery config: %w", err)
+	}
+
+	return nil
+}
+
+// release removes the temporary data directory, unless the policy
+// requires it to be retained for inspection
+func (conf *initialPostgresqlConf) release(failed bool) {
+	releaseTemporaryDataDir(conf.tempDataDir, conf.policy, failed)
+}
+
+// pendingInitialPostgresqlConf is the preparation of the initial
+// configuration running in the background
+type pendingInitialPostgresqlConf struct {
+	done chan struct{}
+	conf *initialPostgresqlConf
+	err  error
+}
+
+// startInitialPostgresqlConfPreparation prepares the initial configuration
+// in the background, so that it overlaps with the restore of the base backup
+func (info InitInfo) startInitialPostgresqlConfPreparation(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+) *pendingInitialPostgresqlConf {
+	pending := &pendingInitialPostgresqlConf{done: make(chan struct{})}
+	go func() {
+		defer close(pending.done)
+		startedAt := time.Now()
+		pending.conf, pending.err = info.prepareInitialPostgresqlConf(cluster)
+		if pending.err == nil {
+			log.FromContext(ctx).Info("Prepared the initial configuration of the restored instance",
+				"duration", time.Since(startedAt).String())
+		}
+	}()
+
+	return pending
+}
+
+// wait waits for the preparation to be completed, and gets its outcome.
+// It can be called more than once
+func (pending *pendingInitialPostgresqlConf) wait() (*initialPostgresqlConf, error) {
+	<-pending.done
+	return pending.conf, pending.err
+}
+
+// discard waits for the preparation to be completed, and removes the
+// temporary data directory without installing the configuration. The
+// generation of the configuration didn't fail, and the directory is
+// retained only when the policy always requires it
+func (pending *pendingInitialPostgresqlConf) discard() {
+	if pending == nil {
+		return
+	}
+
+	if conf, err := pending.wait(); err == nil {
+		conf.release(false)
+	}
+}
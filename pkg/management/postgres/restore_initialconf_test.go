@@ -0,0 +1,91 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("initial configuration prepared during the restore", func() {
+	var (
+		pgData string
+		conf   *initialPostgresqlConf
+	)
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+		conf = &initialPostgresqlConf{
+			tempDataDir: path.Join(GinkgoT().TempDir(), temporaryDataDirPrefix+"test"),
+			policy:      apiv1.RecoveryTemporaryDirectoryPolicyDelete,
+		}
+		Expect(os.MkdirAll(conf.tempDataDir, 0o700)).To(Succeed())
+	})
+
+	completed := func(conf *initialPostgresqlConf, err error) *pendingInitialPostgresqlConf {
+		pending := &pendingInitialPostgresqlConf{done: make(chan struct{}), conf: conf, err: err}
+		close(pending.done)
+		return pending
+	}
+
+	It("installs the configuration in PGDATA and removes the temporary directory", func() {
+		for _, name := range []string{
+			"postgresql.conf",
+			constants.PostgresqlCustomConfigurationFile,
+			constants.PostgresqlOverrideConfigurationFile,
+		} {
+			Expect(os.WriteFile(path.Join(conf.tempDataDir, name), []byte("# "+name+"\n"), 0o600)).To(Succeed())
+		}
+
+		Expect(conf.install(pgData)).To(Succeed())
+		Expect(path.Join(pgData, "postgresql.conf")).To(BeAnExistingFile())
+		Expect(path.Join(pgData, constants.PostgresqlOverrideConfigurationFile)).To(BeAnExistingFile())
+		content, err := os.ReadFile(path.Join(pgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(HaveSuffix("ssl = 'off'\n"))
+		Expect(conf.tempDataDir).ToNot(BeAnExistingFile())
+	})
+
+	It("removes the temporary directory when the installation fails", func() {
+		Expect(conf.install(pgData)).To(MatchError(ContainSubstring("while installing postgresql.conf")))
+		Expect(conf.tempDataDir).ToNot(BeAnExistingFile())
+	})
+
+	It("reports the outcome of the preparation every time it is waited for", func() {
+		pending := completed(nil, errors.New("initdb failed"))
+		_, err := pending.wait()
+		Expect(err).To(MatchError("initdb failed"))
+		_, err = pending.wait()
+		Expect(err).To(MatchError("initdb failed"))
+	})
+
+	It("removes the temporary directory when the preparation is discarded", func() {
+		completed(conf, nil).discard()
+		Expect(conf.tempDataDir).ToNot(BeAnExistingFile())
+
+		completed(nil, errors.New("initdb failed")).discard()
+		var nothing *pendingInitialPostgresqlConf
+		nothing.discard()
+	})
+})
@@ -0,0 +1,112 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils/compatibility"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrInsufficientInodes is raised when a volume receiving the base
+// backup doesn't have enough free inodes for its files
+var ErrInsufficientInodes = errors.New("not enough free inodes to restore the base backup")
+
+// getRecoveryInodesCheck gets the check of the free inodes requested
+// by the user, if any
+func getRecoveryInodesCheck(cluster *apiv1.Cluster) *apiv1.RecoveryInodesCheck {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.InodesCheck
+}
+
+// estimateRestoredFiles gets the number of files the base backup is
+// expected to contain. Unless set by the user, it is estimated from the
+// size of the PGDATA volume, as the restored data must fit in it. Zero is
+// returned when the number can't be estimated
+func estimateRestoredFiles(cluster *apiv1.Cluster, check *apiv1.RecoveryInodesCheck) uint64 {
+	if check.ExpectedFiles != nil {
+		return uint64(*check.ExpectedFiles) // nolint:gosec
+	}
+
+	size := cluster.Spec.StorageConfiguration.GetSizeOrNil()
+	bytesPerFile := check.GetBytesPerFile()
+	if size == nil || bytesPerFile.Value() <= 0 {
+		return 0
+	}
+
+	return uint64(size.Value()/bytesPerFile.Value()) + 1 // nolint:gosec
+}
+
+// checkRestoreInodes checks, when requested by the user, that the volume
+// containing the passed directory has enough free inodes for the files
+// of the base backup. The check is skipped on the filesystems not limiting
+// the number of inodes
+func checkRestoreInodes(ctx context.Context, cluster *apiv1.Cluster, directory string) error {
+	check := getRecoveryInodesCheck(cluster)
+	if check == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx).WithValues("directory", directory)
+	required := estimateRestoredFiles(cluster, check)
+	if required == 0 {
+		contextLogger.Warning("Cannot estimate the number of files of the base backup, " +
+			"skipping the check of the free inodes")
+		return nil
+	}
+
+	free, total, err := compatibility.GetAvailableInodes(directory)
+	if err != nil {
+		return fmt.Errorf("while checking the free inodes of %s: %w", directory, err)
+	}
+
+	return evaluateRestoreInodes(ctx, directory, free, total, required, check.ExpectedFiles == nil)
+}
+
+// evaluateRestoreInodes compares the free inodes of a filesystem with
+// the ones required by the files of the base backup
+func evaluateRestoreInodes(
+	ctx context.Context,
+	directory string,
+	free, total, required uint64,
+	estimated bool,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("directory", directory)
+	if total == 0 {
+		contextLogger.Info("The filesystem doesn't limit the number of inodes, skipping the check")
+		return nil
+	}
+
+	if free < required {
+		return fmt.Errorf("%w: %d inodes available in %s, %d required (estimated: %v)",
+			ErrInsufficientInodes, free, directory, required, estimated)
+	}
+
+	contextLogger.Info("The volume has enough free inodes",
+		"availableInodes", free,
+		"totalInodes", total,
+		"requiredInodes", required,
+		"estimated", estimated)
+	return nil
+}
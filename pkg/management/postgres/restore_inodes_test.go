@@ -0,0 +1,78 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"path"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("free inodes of the volumes receiving the base backup", func() {
+	newCluster := func(size string, check *apiv1.RecoveryInodesCheck) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				StorageConfiguration: apiv1.StorageConfiguration{Size: size},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", InodesCheck: check},
+				},
+			},
+		}
+	}
+
+	It("uses the number of files set by the user", func() {
+		check := &apiv1.RecoveryInodesCheck{ExpectedFiles: ptr.To(int64(250000))}
+		Expect(estimateRestoredFiles(newCluster("10Gi", check), check)).To(BeEquivalentTo(250000))
+	})
+
+	It("estimates the number of files from the size of the PGDATA volume", func() {
+		bytesPerFile := resource.MustParse("64Ki")
+		check := &apiv1.RecoveryInodesCheck{BytesPerFile: &bytesPerFile}
+		Expect(estimateRestoredFiles(newCluster("1Gi", check), check)).To(BeEquivalentTo(16385))
+		Expect(estimateRestoredFiles(newCluster("1Gi", &apiv1.RecoveryInodesCheck{}),
+			&apiv1.RecoveryInodesCheck{})).To(BeEquivalentTo(1025))
+		Expect(estimateRestoredFiles(newCluster("", check), check)).To(BeZero())
+	})
+
+	It("fails when the free inodes are not enough", func() {
+		Expect(evaluateRestoreInodes(context.TODO(), "/var/lib/postgresql/data", 1000, 655360, 1025, true)).
+			To(MatchError(ErrInsufficientInodes))
+		Expect(evaluateRestoreInodes(context.TODO(), "/var/lib/postgresql/data", 1025, 655360, 1025, true)).
+			To(Succeed())
+	})
+
+	It("skips the check on the filesystems not limiting the number of inodes", func() {
+		Expect(evaluateRestoreInodes(context.TODO(), "/var/lib/postgresql/data", 0, 0, 1025, true)).To(Succeed())
+	})
+
+	It("checks the inodes of the filesystem only when requested", func() {
+		directory := GinkgoT().TempDir()
+		Expect(checkRestoreInodes(context.TODO(), newCluster("1Gi", nil), directory)).To(Succeed())
+		Expect(checkRestoreInodes(context.TODO(), newCluster("1Gi", &apiv1.RecoveryInodesCheck{
+			ExpectedFiles: ptr.To(int64(1)),
+		}), directory)).To(Succeed())
+		Expect(checkRestoreInodes(context.TODO(), newCluster("1Gi", &apiv1.RecoveryInodesCheck{}),
+			path.Join(directory, "missing"))).To(MatchError(ContainSubstring("while checking the free inodes")))
+	})
+})
@@ -0,0 +1,145 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/specs"
+)
+
+// PrepareInPlaceRestore makes room for the restore when the cluster
+// requested to restore the backup over the data directory contained in
+// its PVCs. Depending on the cluster definition, the existing data
+// directory is preserved, for a manual rollback, or removed
+func (info InitInfo) PrepareInPlaceRestore(ctx context.Context) error {
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+
+	cluster, err := info.loadCluster(ctx, typedClient)
+	if err != nil {
+		return err
+	}
+
+	if !cluster.IsInPlaceRestoreEnabled() {
+		return nil
+	}
+
+	return info.prepareInPlaceRestore(ctx, cluster, time.Now())
+}
+
+// inPlaceRestoreDirectories gets the directories replaced by the
+// restore in place: PGDATA, the WAL directory and the data directory
+// of every tablespace
+func (info InitInfo) inPlaceRestoreDirectories(cluster *apiv1.Cluster) []string {
+	directories := []string{info.PgData}
+	if info.PgWal != "" {
+		directories = append(directories, info.PgWal)
+	}
+	for _, tablespace := range cluster.Spec.Tablespaces {
+		directories = append(directories, specs.LocationForTablespace(tablespace.Name))
+	}
+
+	return directories
+}
+
+// inPlaceRestoreMarkerPath gets the path of the marker recording that the
+// directories replaced by the restore in place have been prepared. It is
+// stored next to PGDATA, as PGDATA itself is renamed or removed
+func (info InitInfo) inPlaceRestoreMarkerPath() string {
+	return path.Join(path.Dir(path.Clean(info.PgData)), constants.InPlaceRestoreMarker)
+}
+
+// isInPlaceRestorePrepared checks if a previous execution of the restore
+// job already prepared the directories replaced by the restore in place
+// of the passed cluster
+func (info InitInfo) isInPlaceRestorePrepared(cluster *apiv1.Cluster) (bool, error) {
+	content, err := os.ReadFile(info.inPlaceRestoreMarkerPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return string(content) == string(cluster.UID), nil
+}
+
+// prepareInPlaceRestore preserves, renaming them after the passed time,
+// or removes the directories replaced by the restore in place. This is
+// done only once per cluster, so that the retries of the restore job
+// don't replace the directories again
+func (info InitInfo) prepareInPlaceRestore(ctx context.Context, cluster *apiv1.Cluster, now time.Time) error {
+	contextLogger := log.FromContext(ctx)
+
+	prepared, err := info.isInPlaceRestorePrepared(cluster)
+	if err != nil {
+		return fmt.Errorf("while checking if the restore in place has been prepared: %w", err)
+	}
+	if prepared {
+		contextLogger.Info("The directories replaced by the restore in place have already been prepared")
+		return nil
+	}
+
+	preserve := cluster.Spec.Bootstrap.Recovery.InPlace.ShouldPreserveDataDirectory()
+	suffix := fileutils.FormatFriendlyTimestamp(now)
+
+	for _, directory := range info.inPlaceRestoreDirectories(cluster) {
+		exists, err := fileutils.FileExists(directory)
+		if err != nil {
+			return fmt.Errorf("while checking directory %s: %w", directory, err)
+		}
+		if !exists {
+			continue
+		}
+
+		if !preserve {
+			contextLogger.Warning("Restoring in place, removing the existing directory",
+				"directory", directory)
+			if err := fileutils.RemoveDirectory(directory); err != nil {
+				return fmt.Errorf("while removing directory %s: %w", directory, err)
+			}
+			continue
+		}
+
+		preservedDirectory := fmt.Sprintf("%s_%s", directory, suffix)
+		contextLogger.Warning("Restoring in place, preserving the existing directory for a manual rollback",
+			"directory", directory,
+			"preservedDirectory", preservedDirectory)
+		if err := os.Rename(directory, preservedDirectory); err != nil {
+			return fmt.Errorf("while preserving directory %s: %w", directory, err)
+		}
+	}
+
+	if _, err := fileutils.WriteStringToFile(info.inPlaceRestoreMarkerPath(), string(cluster.UID)); err != nil {
+		return fmt.Errorf("while marking the restore in place as prepared: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore in place", func() {
+	var (
+		info    InitInfo
+		cluster *apiv1.Cluster
+		now     = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	)
+
+	BeforeEach(func() {
+		parent := GinkgoT().TempDir()
+		info = InitInfo{PgData: path.Join(parent, "pgdata"), PgWal: path.Join(parent, "pg_wal")}
+		Expect(os.MkdirAll(info.PgData, 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(info.PgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						InPlace: &apiv1.RecoveryInPlace{Enabled: true},
+					},
+				},
+			},
+		}
+	})
+
+	It("preserves the existing data directory by default", func() {
+		Expect(info.prepareInPlaceRestore(context.TODO(), cluster, now)).To(Succeed())
+		Expect(info.PgData).ToNot(BeADirectory())
+		preserved := fmt.Sprintf("%s_%s", info.PgData, fileutils.FormatFriendlyTimestamp(now))
+		Expect(path.Join(preserved, "PG_VERSION")).To(BeARegularFile())
+	})
+
+	It("removes the existing data directory when asked to", func() {
+		cluster.Spec.Bootstrap.Recovery.InPlace.PreserveDataDirectory = ptr.To(false)
+		Expect(info.prepareInPlaceRestore(context.TODO(), cluster, now)).To(Succeed())
+		Expect(info.PgData).ToNot(BeADirectory())
+		Expect(fmt.Sprintf("%s_%s", info.PgData, fileutils.FormatFriendlyTimestamp(now))).ToNot(BeADirectory())
+	})
+
+	It("prepares the directories only once", func() {
+		cluster.UID = "a3c1b9d2"
+		Expect(info.prepareInPlaceRestore(context.TODO(), cluster, now)).To(Succeed())
+
+		Expect(os.MkdirAll(info.PgData, 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(info.PgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		later := now.Add(time.Minute)
+		Expect(info.prepareInPlaceRestore(context.TODO(), cluster, later)).To(Succeed())
+		Expect(path.Join(info.PgData, "PG_VERSION")).To(BeARegularFile())
+		Expect(fmt.Sprintf("%s_%s", info.PgData, fileutils.FormatFriendlyTimestamp(later))).ToNot(BeADirectory())
+
+		cluster.UID = "f7e20c4a"
+		Expect(info.prepareInPlaceRestore(context.TODO(), cluster, later)).To(Succeed())
+		Expect(info.PgData).ToNot(BeADirectory())
+		Expect(fmt.Sprintf("%s_%s", info.PgData, fileutils.FormatFriendlyTimestamp(later))).To(BeADirectory())
+	})
+})
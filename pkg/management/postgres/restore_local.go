@@ -0,0 +1,284 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// localBackupID is the backup ID written in the restore marker
+// when recovering from a local volume
+const localBackupID = "local"
+
+// ErrInvalidLocalBackup is raised when the local volume doesn't contain
+// a backup that can be restored
+var ErrInvalidLocalBackup = errors.New("the local volume doesn't contain a valid backup")
+
+// isLocalRecovery checks if the user requested to restore
+// a backup stored in a local volume
+func isLocalRecovery(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.Local != nil
+}
+
+// restoreFromLocalBackup restores PGDATA from the copy of a data directory
+// stored in a local volume, and configures PostgreSQL to replay the WAL
+// files stored in the same volume. Barman and the object stores aren't
+// involved at all
+func (info InitInfo) restoreFromLocalBackup(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	recoverySettings map[string]string,
+) error {
+	contextLogger := log.FromContext(ctx)
+	local := cluster.Spec.Bootstrap.Recovery.Local
+	dataPath := path.Join(postgresSpec.LocalBackupDirectory, local.GetDataPath())
+	walPath := path.Join(postgresSpec.LocalBackupDirectory, local.GetWALPath())
+	env := os.Environ()
+
+	interrupted, err := info.IsRecoveryInterrupted()
+	if err != nil {
+		return fmt.Errorf("while checking for an interrupted recovery: %w", err)
+	}
+	if interrupted {
+		contextLogger.Info("Resuming an interrupted recovery, skipping the copy of the local backup",
+			"pgdata", info.PgData)
+		return info.ConfigureInstanceAfterRestore(ctx, typedClient, cluster, env)
+	}
+
+	if err := validateLocalBackup(dataPath, walPath); err != nil {
+		return err
+	}
+
+	if err := checkDecompressionCommand(local.Decompression); err != nil {
+		return err
+	}
+
+	if err := info.ensurePgDataOwnership(
+		ctx, int(cluster.GetPostgresUID()), int(cluster.GetPostgresGID())); err != nil {
+		return err
+	}
+
+	if err := checkRestoreInodes(ctx, cluster, info.PgData); err != nil {
+		return err
+	}
+
+	if isLocalBackupArchive(dataPath) {
+		contextLogger.Info("Extracting the local backup archive", "source", dataPath, "pgdata", info.PgData)
+		if err := extractLocalBackupArchive(ctx, local.Decompression, dataPath, info.PgData); err != nil {
+			return fmt.Errorf("while extracting the local backup archive: %w", err)
+		}
+		if err := checkDataDirStructure(info.PgData); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidLocalBackup, dataPath, err)
+		}
+	} else {
+		if local.Decompression != nil {
+			contextLogger.Warning("The local backup is not an archive, ignoring the decompression program",
+				"source", dataPath)
+		}
+		contextLogger.Info("Copying the local backup", "source", dataPath, "pgdata", info.PgData)
+		if err := copyDataDirectory(dataPath, info.PgData); err != nil {
+			return fmt.Errorf("while copying the local backup: %w", err)
+		}
+	}
+
+	if err := fileutils.RemoveRestoreExcludedFiles(ctx, info.PgData); err != nil {
+		return fmt.Errorf("while removing the excluded files from the local backup copy: %w", err)
+	}
+
+	if err := info.decryptDataDir(ctx, typedClient, cluster, env); err != nil {
+		return err
+	}
+
+	if err := info.checkControlFile(ctx, cluster, nil); err != nil {
+		return err
+	}
+
+	if err := info.checkRestoredSource(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.checkTablespaceLinks(ctx); err != nil {
+		return err
+	}
+
+	if err := info.checkRestoredLocale(ctx); err != nil {
+		return err
+	}
+
+	if _, err := info.restoreCustomWalDir(ctx); err != nil {
+		return err
+	}
+
+	if err := info.WriteInitialPostgresqlConf(cluster); err != nil {
+		return err
+	}
+
+	if _, err := info.GetInstance().migratePostgresAutoConfFile(ctx); err != nil {
+		return err
+	}
+
+	if err := info.WriteRestoreHbaConf(); err != nil {
+		return err
+	}
+
+	if err := info.writeLocalRestoreWalConfig(cluster, walPath, recoverySettings); err != nil {
+		return err
+	}
+
+	if err := info.writeFastRecoveryConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryWorkersConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryMemoryConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryHugePagesConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryCheckpointConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoveryStandbyDelayConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if err := info.writeZeroDamagedPagesConfiguration(ctx, cluster); err != nil {
+		return err
+	}
+
+	if !isFastRecovery(cluster) {
+		if err := info.writeRestoreMarker(localBackupID); err != nil {
+			return fmt.Errorf("while writing the restore marker: %w", err)
+		}
+	}
+
+	if err := info.writeRecoverySignal(cluster); err != nil {
+		return err
+	}
+
+	return info.ConfigureInstanceAfterRestore(ctx, typedClient, cluster, env)
+}
+
+// validateLocalBackup checks that the local volume contains the copy of a
+// PostgreSQL data directory, or an archive of it, and a directory for the
+// WAL files. The content of an archive is checked once it is extracted
+func validateLocalBackup(dataPath, walPath string) error {
+	if !isLocalBackupArchive(dataPath) {
+		if err := checkDataDirStructure(dataPath); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidLocalBackup, dataPath, err)
+		}
+	}
+
+	stat, err := os.Stat(walPath)
+	if err != nil {
+		return fmt.Errorf("%w: cannot access the WAL directory: %v", ErrInvalidLocalBackup, err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", ErrInvalidLocalBackup, walPath)
+	}
+
+	return nil
+}
+
+// writeLocalRestoreWalConfig configures PostgreSQL to fetch the WAL
+// files to be replayed from a local directory
+func (info InitInfo) writeLocalRestoreWalConfig(
+	cluster *apiv1.Cluster,
+	walPath string,
+	recoverySettings map[string]string,
+) error {
+	recoveryTargetOptions, err := info.renderRecoveryTarget(cluster)
+	if err != nil {
+		return err
+	}
+
+	cmd, err := info.withReplayThrottle(
+		restoreCommand{fetch: []string{"cp"}, arguments: []string{walPath + "/%f", "%p"}}, cluster)
+	if err != nil {
+		return err
+	}
+
+	recoveryFileContents := fmt.Sprintf(
+		"recovery_target_action = %s\n"+
+			"restore_command = '%s'\n"+
+			"%s%s",
+		recoveryTargetAction(cluster),
+		cmd.render(),
+		recoveryTargetOptions,
+		renderRecoverySettings(recoverySettings, recoveryTargetOptions != ""))
+
+	return info.writeRecoveryConfiguration(cluster, recoveryFileContents)
+}
+
+// copyDataDirectory copies the content of a data directory, preserving
+// the symbolic links. The directories are created with the permissions
+// required by PostgreSQL
+func copyDataDirectory(source, destination string) error {
+	return filepath.WalkDir(source, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativeName, err := filepath.Rel(source, name)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, relativeName)
+
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, 0o700)
+
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(name)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+
+		case entry.Type().IsRegular():
+			return fileutils.CopyFile(name, target)
+
+		default:
+			// Sockets, pipes and devices aren't part of a data directory
+			return nil
+		}
+	})
+}
@@ -0,0 +1,80 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery from a local volume", func() {
+	var backupDir string
+
+	BeforeEach(func() {
+		backupDir = GinkgoT().TempDir()
+		dataDir := path.Join(backupDir, "data")
+		Expect(os.MkdirAll(path.Join(dataDir, "global"), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(dataDir, "base", "1"), 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(dataDir, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(dataDir, "global", "pg_control"), []byte("control"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(dataDir, "base", "1", "1259"), []byte("relation"), 0o600)).To(Succeed())
+		Expect(os.Mkdir(path.Join(backupDir, "wals"), 0o700)).To(Succeed())
+	})
+
+	It("accepts a volume containing a data directory and the WAL files", func() {
+		Expect(validateLocalBackup(path.Join(backupDir, "data"), path.Join(backupDir, "wals"))).To(Succeed())
+	})
+
+	It("rejects a volume without a data directory", func() {
+		Expect(os.Remove(path.Join(backupDir, "data", "global", "pg_control"))).To(Succeed())
+		err := validateLocalBackup(path.Join(backupDir, "data"), path.Join(backupDir, "wals"))
+		Expect(err).To(MatchError(ErrInvalidLocalBackup))
+	})
+
+	It("rejects a volume without a WAL directory", func() {
+		err := validateLocalBackup(path.Join(backupDir, "data"), path.Join(backupDir, "missing"))
+		Expect(err).To(MatchError(ErrInvalidLocalBackup))
+
+		Expect(os.WriteFile(path.Join(backupDir, "file"), nil, 0o600)).To(Succeed())
+		err = validateLocalBackup(path.Join(backupDir, "data"), path.Join(backupDir, "file"))
+		Expect(err).To(MatchError(ErrInvalidLocalBackup))
+	})
+
+	It("copies the data directory preserving the symbolic links", func() {
+		dataDir := path.Join(backupDir, "data")
+		Expect(os.Symlink("/var/lib/postgresql/tablespaces/tbs", path.Join(dataDir, "tbs"))).To(Succeed())
+
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(copyDataDirectory(dataDir, destination)).To(Succeed())
+		Expect(checkDataDirStructure(destination)).To(Succeed())
+
+		content, err := os.ReadFile(path.Join(destination, "base", "1", "1259"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("relation"))
+
+		link, err := os.Readlink(path.Join(destination, "tbs"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(link).To(Equal("/var/lib/postgresql/tablespaces/tbs"))
+
+		stat, err := os.Stat(path.Join(destination, "base"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+	})
+})
@@ -0,0 +1,364 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+const (
+	// localeReferenceDatabase is the database whose locale is checked
+	// before starting the restored instance. It can't be dropped and
+	// its locale can't be changed
+	localeReferenceDatabase = "template0"
+
+	// pgDatabaseRelationOID is the OID of the pg_database catalog
+	pgDatabaseRelationOID = 1262
+
+	// relationMapperFile is the file mapping the OIDs of the shared
+	// catalogs, like pg_database, to the files storing them
+	relationMapperFile = "global/pg_filenode.map"
+
+	// relationMapperMagic is the magic number of the relation mapper file
+	relationMapperMagic = 0x592717
+
+	// defaultBlockSize is the page size used when pg_controldata
+	// doesn't report it
+	defaultBlockSize = 8192
+)
+
+// ErrUnsupportedRestoredLocale is raised when the locale of the restored
+// databases isn't available in the image running the recovery
+var ErrUnsupportedRestoredLocale = errors.New("the locale of the restored databases is not available in the image")
+
+// errLocaleNotFound is raised when the locale of a database can't be
+// read from the catalog files of the data directory
+var errLocaleNotFound = errors.New("cannot find the locale of the database in pg_database")
+
+// checkRestoredLocale checks, without starting PostgreSQL, that the
+// LC_COLLATE and LC_CTYPE of the restored template0 database are
+// available in the image. PostgreSQL would otherwise refuse the
+// connections with an error that doesn't point at the restore. When
+// the locale of the restored databases or the ones of the image can't be
+// detected, the check is skipped
+func (info InitInfo) checkRestoredLocale(ctx context.Context) error {
+	contextLogger := log.FromContext(ctx)
+
+	majorVersion, err := postgresutils.GetMajorVersion(info.PgData)
+	if err != nil {
+		return fmt.Errorf("while reading the PostgreSQL version of the restored data directory: %w", err)
+	}
+
+	collate, ctype, err := readDatabaseLocale(
+		info.PgData, info.getRestoredBlockSize(), majorVersion, localeReferenceDatabase)
+	if err != nil {
+		contextLogger.Warning("Cannot read the locale of the restored databases, skipping the check",
+			"database", localeReferenceDatabase,
+			"error", err.Error())
+		return nil
+	}
+
+	output, err := exec.Command("locale", "-a").Output() // #nosec G204
+	if err != nil {
+		contextLogger.Warning("Cannot list the locales available in the image, skipping the check",
+			"error", err.Error())
+		return nil
+	}
+	available := parseAvailableLocales(string(output))
+	if available.Len() == 0 {
+		contextLogger.Warning("No locale listed as available in the image, skipping the check")
+		return nil
+	}
+
+	contextLogger.Info("Checking the locale of the restored databases",
+		"database", localeReferenceDatabase,
+		"lcCollate", collate,
+		"lcCtype", ctype)
+
+	return checkLocaleAvailability(collate, ctype, available)
+}
+
+// getRestoredBlockSize gets the size of the pages of the restored
+// data directory from pg_controldata, using the default size when
+// it's not available
+func (info InitInfo) getRestoredBlockSize() int {
+	output, err := info.GetInstance().GetPgControldata()
+	if err != nil {
+		return defaultBlockSize
+	}
+
+	blockSize, err := strconv.Atoi(utils.ParsePgControldataOutput(output)["Database block size"])
+	if err != nil || blockSize <= 0 {
+		return defaultBlockSize
+	}
+
+	return blockSize
+}
+
+// checkLocaleAvailability checks that the passed LC_COLLATE and LC_CTYPE
+// are in the list of the available locales. The C and POSIX locales
+// are always available
+func checkLocaleAvailability(collate, ctype string, available *stringset.Data) error {
+	var missing []string
+	for _, locale := range []string{collate, ctype} {
+		normalized := normalizeLocaleName(locale)
+		if normalized == "c" || normalized == "posix" || available.Has(normalized) {
+			continue
+		}
+		if !slices.Contains(missing, locale) {
+			missing = append(missing, locale)
+		}
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: the restored %s database uses LC_COLLATE %q and LC_CTYPE %q, "+
+		"but %s is missing in the image, whose default locale is %q",
+		ErrUnsupportedRestoredLocale, localeReferenceDatabase, collate, ctype,
+		strings.Join(missing, " and "), getTargetLocale())
+}
+
+// parseAvailableLocales parses the output of `locale -a`
+// into a set of normalized locale names
+func parseAvailableLocales(output string) *stringset.Data {
+	result := stringset.New()
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result.Put(normalizeLocaleName(line))
+		}
+	}
+
+	return result
+}
+
+// normalizeLocaleName normalizes a locale name the way the C library
+// does, so that `en_US.UTF-8` and `en_US.utf8` are considered the same
+// locale: the codeset is lower-cased, and its punctuation removed
+func normalizeLocaleName(name string) string {
+	name, modifier, hasModifier := strings.Cut(name, "@")
+	language, codeset, hasCodeset := strings.Cut(name, ".")
+
+	result := language
+	if language == "C" || language == "POSIX" {
+		result = strings.ToLower(language)
+	}
+	if hasCodeset {
+		var normalized strings.Builder
+		for _, char := range strings.ToLower(codeset) {
+			if (char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') {
+				normalized.WriteRune(char)
+			}
+		}
+		result += "." + normalized.String()
+	}
+	if hasModifier {
+		result += "@" + modifier
+	}
+
+	return result
+}
+
+// readDatabaseLocale reads the LC_COLLATE and LC_CTYPE of a database
+// directly from the pg_database catalog stored in a data directory,
+// without starting PostgreSQL. The locale of a database can't change,
+// so every version of its row carries the same values
+func readDatabaseLocale(pgData string, blockSize, majorVersion int, databaseName string) (string, string, error) {
+	fileNumber, err := readMappedRelationFileNumber(path.Join(pgData, relationMapperFile), pgDatabaseRelationOID)
+	if err != nil {
+		return "", "", err
+	}
+
+	content, err := os.ReadFile(path.Join(pgData, "global", strconv.FormatUint(uint64(fileNumber), 10))) // #nosec
+	if err != nil {
+		return "", "", err
+	}
+
+	for offset := 0; offset+blockSize <= len(content); offset += blockSize {
+		for _, tuple := range heapPageTuples(content[offset : offset+blockSize]) {
+			collate, ctype, ok := parsePgDatabaseLocale(tuple, majorVersion, databaseName)
+			if ok {
+				return collate, ctype, nil
+			}
+		}
+	}
+
+	return "", "", fmt.Errorf("%w: %s", errLocaleNotFound, databaseName)
+}
+
+// readMappedRelationFileNumber reads, from the relation mapper file, the
+// number of the file storing a shared catalog
+func readMappedRelationFileNumber(fileName string, relationOID uint32) (uint32, error) {
+	content, err := os.ReadFile(fileName) // #nosec
+	if err != nil {
+		return 0, err
+	}
+
+	if len(content) < 8 || binary.LittleEndian.Uint32(content[0:4]) != relationMapperMagic {
+		return 0, fmt.Errorf("%s is not a valid relation mapper file", fileName)
+	}
+
+	mappings := int(binary.LittleEndian.Uint32(content[4:8]))
+	for i := 0; i < mappings && 8+i*8+8 <= len(content); i++ {
+		entry := content[8+i*8:]
+		if binary.LittleEndian.Uint32(entry[0:4]) == relationOID {
+			return binary.LittleEndian.Uint32(entry[4:8]), nil
+		}
+	}
+
+	return 0, fmt.Errorf("relation %d not found in %s", relationOID, fileName)
+}
+
+// heapPageTuples gets the data of the tuples stored in a heap page,
+// skipping the header of each tuple. Dead tuples are included
+func heapPageTuples(page []byte) [][]byte {
+	const pageHeaderSize = 24
+	const tupleHeaderOffsetPosition = 22
+
+	lower := int(binary.LittleEndian.Uint16(page[12:14]))
+	if lower < pageHeaderSize || lower > len(page) {
+		return nil
+	}
+
+	var result [][]byte
+	for position := pageHeaderSize; position+4 <= lower; position += 4 {
+		itemID := binary.LittleEndian.Uint32(page[position : position+4])
+		itemOffset := int(itemID & 0x7FFF)
+		itemFlags := (itemID >> 15) & 0x03
+		itemLength := int(itemID >> 17)
+
+		// Only the normal line pointers reference a tuple
+		if itemFlags != 1 || itemOffset+itemLength > len(page) || itemLength <= tupleHeaderOffsetPosition {
+			continue
+		}
+
+		tuple := page[itemOffset : itemOffset+itemLength]
+		dataOffset := int(tuple[tupleHeaderOffsetPosition])
+		if dataOffset >= len(tuple) {
+			continue
+		}
+		result = append(result, tuple[dataOffset:])
+	}
+
+	return result
+}
+
+// parsePgDatabaseLocale gets LC_COLLATE and LC_CTYPE from the data of a
+// pg_database tuple, if it belongs to the passed database. The layout
+// of the columns preceding them depends on the PostgreSQL version: up
+// to PostgreSQL 14 they are fixed-length names, while starting from
+// PostgreSQL 15 they are text values following the fixed-length columns
+func parsePgDatabaseLocale(data []byte, majorVersion int, databaseName string) (string, string, bool) {
+	const nameLength = 64
+	const nameOffset = 4
+
+	if len(data) < nameOffset+nameLength || readName(data[nameOffset:nameOffset+nameLength]) != databaseName {
+		return "", "", false
+	}
+
+	if majorVersion < 15 {
+		const collateOffset = 76
+		const ctypeOffset = collateOffset + nameLength
+		if len(data) < ctypeOffset+nameLength {
+			return "", "", false
+		}
+		return readName(data[collateOffset : collateOffset+nameLength]),
+			readName(data[ctypeOffset : ctypeOffset+nameLength]), true
+	}
+
+	const collateOffset = 96
+	collate, next, ok := readText(data, collateOffset)
+	if !ok {
+		return "", "", false
+	}
+	ctype, _, ok := readText(data, next)
+	if !ok {
+		return "", "", false
+	}
+
+	return collate, ctype, true
+}
+
+// readName reads a value of the name type, which is null-terminated
+func readName(data []byte) string {
+	if end := bytes.IndexByte(data, 0); end >= 0 {
+		data = data[:end]
+	}
+
+	return string(data)
+}
+
+// readText reads an uncompressed value of the text type stored in a tuple,
+// returning it together with the offset of the following column. Values
+// with a 1-byte header aren't aligned, while the ones with a 4-byte header
+// are aligned to 4 bytes, the padding being zeroed
+func readText(data []byte, offset int) (string, int, bool) {
+	if offset >= len(data) {
+		return "", 0, false
+	}
+
+	if data[offset] == 0 {
+		offset = (offset + 3) &^ 3
+	}
+	if offset >= len(data) {
+		return "", 0, false
+	}
+
+	header := data[offset]
+	switch {
+	case header == 0x01:
+		// TOAST pointer, not used for a locale name
+		return "", 0, false
+
+	case header&0x01 == 0x01:
+		length := int(header >> 1)
+		if length < 1 || offset+length > len(data) {
+			return "", 0, false
+		}
+		return string(data[offset+1 : offset+length]), offset + length, true
+
+	case header&0x03 == 0x00:
+		if offset+4 > len(data) {
+			return "", 0, false
+		}
+		length := int(binary.LittleEndian.Uint32(data[offset:offset+4]) >> 2)
+		if length < 4 || offset+length > len(data) {
+			return "", 0, false
+		}
+		return string(data[offset+4 : offset+length]), offset + length, true
+
+	default:
+		// Compressed inline value
+		return "", 0, false
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"encoding/binary"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("locale of the restored databases", func() {
+	const blockSize = 8192
+
+	// buildHeapPage builds a heap page containing the passed tuple data,
+	// each one preceded by a tuple header
+	buildHeapPage := func(tuples ...[]byte) []byte {
+		const tupleHeaderSize = 24
+		page := make([]byte, blockSize)
+		upper := blockSize
+		for i, data := range tuples {
+			tuple := make([]byte, tupleHeaderSize+len(data))
+			tuple[22] = tupleHeaderSize
+			copy(tuple[tupleHeaderSize:], data)
+
+			upper -= len(tuple)
+			upper &^= 7
+			copy(page[upper:], tuple)
+			itemID := uint32(upper) | 1<<15 | uint32(len(tuple))<<17
+			binary.LittleEndian.PutUint32(page[24+i*4:], itemID)
+		}
+		binary.LittleEndian.PutUint16(page[12:], uint16(24+len(tuples)*4))
+		binary.LittleEndian.PutUint16(page[14:], uint16(upper))
+		return page
+	}
+
+	withName := func(data []byte, offset int, value string) []byte {
+		copy(data[offset:offset+64], value)
+		return data
+	}
+
+	// shortText encodes a text value with a 1-byte header
+	shortText := func(value string) []byte {
+		return append([]byte{byte((len(value)+1)<<1 | 1)}, value...)
+	}
+
+	pg14Tuple := func(datname, collate, ctype string) []byte {
+		data := make([]byte, 220)
+		withName(data, 4, datname)
+		withName(data, 76, collate)
+		return withName(data, 140, ctype)
+	}
+
+	pg16Tuple := func(datname, collate, ctype string) []byte {
+		data := withName(make([]byte, 96), 4, datname)
+		data = append(data, shortText(collate)...)
+		return append(data, shortText(ctype)...)
+	}
+
+	writeDataDirectory := func(pgData string, pages ...[]byte) {
+		Expect(os.MkdirAll(path.Join(pgData, "global"), 0o700)).To(Succeed())
+
+		mapper := make([]byte, 512)
+		binary.LittleEndian.PutUint32(mapper[0:], 0x592717)
+		binary.LittleEndian.PutUint32(mapper[4:], 2)
+		binary.LittleEndian.PutUint32(mapper[8:], 1260)
+		binary.LittleEndian.PutUint32(mapper[12:], 1260)
+		binary.LittleEndian.PutUint32(mapper[16:], 1262)
+		binary.LittleEndian.PutUint32(mapper[20:], 16390)
+		Expect(os.WriteFile(path.Join(pgData, "global", "pg_filenode.map"), mapper, 0o600)).To(Succeed())
+
+		var content []byte
+		for _, page := range pages {
+			content = append(content, page...)
+		}
+		Expect(os.WriteFile(path.Join(pgData, "global", "16390"), content, 0o600)).To(Succeed())
+	}
+
+	It("reads the locale of a database up to PostgreSQL 14", func() {
+		pgData := GinkgoT().TempDir()
+		writeDataDirectory(pgData,
+			buildHeapPage(pg14Tuple("template1", "C", "C")),
+			buildHeapPage(pg14Tuple("postgres", "C", "C"), pg14Tuple("template0", "en_US.UTF-8", "en_US.UTF-8")))
+
+		collate, ctype, err := readDatabaseLocale(pgData, blockSize, 14, "template0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(collate).To(Equal("en_US.UTF-8"))
+		Expect(ctype).To(Equal("en_US.UTF-8"))
+	})
+
+	It("reads the locale of a database starting from PostgreSQL 15", func() {
+		pgData := GinkgoT().TempDir()
+		writeDataDirectory(pgData, buildHeapPage(
+			pg16Tuple("template1", "C", "C"),
+			pg16Tuple("template0", "de_DE.UTF-8", "de_DE.ISO-8859-1")))
+
+		collate, ctype, err := readDatabaseLocale(pgData, blockSize, 16, "template0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(collate).To(Equal("de_DE.UTF-8"))
+		Expect(ctype).To(Equal("de_DE.ISO-8859-1"))
+	})
+
+	It("complains when the database is not there", func() {
+		pgData := GinkgoT().TempDir()
+		writeDataDirectory(pgData, buildHeapPage(pg16Tuple("template1", "C", "C")))
+
+		_, _, err := readDatabaseLocale(pgData, blockSize, 16, "template0")
+		Expect(err).To(MatchError(errLocaleNotFound))
+	})
+
+	It("normalizes the locale names like the C library", func() {
+		Expect(normalizeLocaleName("en_US.UTF-8")).To(Equal("en_US.utf8"))
+		Expect(normalizeLocaleName("en_US.utf8")).To(Equal("en_US.utf8"))
+		Expect(normalizeLocaleName("C.UTF-8")).To(Equal("c.utf8"))
+		Expect(normalizeLocaleName("de_DE.ISO-8859-1@euro")).To(Equal("de_DE.iso88591@euro"))
+		Expect(normalizeLocaleName("POSIX")).To(Equal("posix"))
+	})
+
+	It("checks the locales are available in the image", func() {
+		available := parseAvailableLocales("C\nC.utf8\nPOSIX\nen_US.utf8\n")
+
+		Expect(checkLocaleAvailability("en_US.UTF-8", "en_US.UTF-8", available)).To(Succeed())
+		Expect(checkLocaleAvailability("C", "POSIX", parseAvailableLocales(""))).To(Succeed())
+
+		err := checkLocaleAvailability("de_DE.UTF-8", "en_US.UTF-8", available)
+		Expect(err).To(MatchError(ErrUnsupportedRestoredLocale))
+		Expect(err.Error()).To(ContainSubstring(`LC_COLLATE "de_DE.UTF-8" and LC_CTYPE "en_US.UTF-8"`))
+	})
+})
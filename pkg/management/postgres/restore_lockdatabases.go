@@ -0,0 +1,79 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// connectableUserDatabasesQuery lists the user databases
+// currently accepting connections
+const connectableUserDatabasesQuery = `
+SELECT datname
+FROM pg_catalog.pg_database
+WHERE datallowconn AND NOT datistemplate AND datname <> 'postgres'
+ORDER BY 1`
+
+// getRecoveryAllowedDatabases gets the databases the user wants to be
+// connectable after the recovery. When empty, no database is locked
+func getRecoveryAllowedDatabases(cluster *apiv1.Cluster) []string {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.AllowedDatabases
+}
+
+// lockRestoredDatabases prevents the connections to every user database
+// not included in the passed allow-list. The postgres database and the
+// template databases are never locked. The locked databases are returned
+func lockRestoredDatabases(ctx context.Context, db *sql.DB, allowedDatabases []string) ([]string, error) {
+	contextLogger := log.FromContext(ctx)
+
+	databases, err := queryNames(db, connectableUserDatabasesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("while listing the user databases: %w", err)
+	}
+
+	var locked []string
+	for _, databaseName := range databases {
+		if slices.Contains(allowedDatabases, databaseName) {
+			continue
+		}
+
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER DATABASE %s WITH ALLOW_CONNECTIONS false",
+			pgx.Identifier{databaseName}.Sanitize())); err != nil {
+			return locked, fmt.Errorf("while locking database %s: %w", databaseName, err)
+		}
+		contextLogger.Info("Locked the restored database, it doesn't accept connections",
+			"database", databaseName)
+		locked = append(locked, databaseName)
+	}
+
+	contextLogger.Info("Locked the restored databases not in the allow-list",
+		"allowedDatabases", allowedDatabases,
+		"lockedDatabases", locked)
+	return locked, nil
+}
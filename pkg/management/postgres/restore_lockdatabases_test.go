@@ -0,0 +1,71 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("locking the restored databases", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("locks the user databases not in the allow-list", func() {
+		mock.ExpectQuery("WHERE datallowconn AND NOT datistemplate").
+			WillReturnRows(sqlmock.NewRows([]string{"datname"}).
+				AddRow("app").
+				AddRow("Billing").
+				AddRow("hr"))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "Billing" WITH ALLOW_CONNECTIONS false`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER DATABASE "hr" WITH ALLOW_CONNECTIONS false`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		locked, err := lockRestoredDatabases(context.TODO(), db, []string{"app"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(locked).To(Equal([]string{"Billing", "hr"}))
+	})
+
+	It("reports the errors raised while locking a database", func() {
+		mock.ExpectQuery("WHERE datallowconn AND NOT datistemplate").
+			WillReturnRows(sqlmock.NewRows([]string{"datname"}).AddRow("hr"))
+		mock.ExpectExec("ALTER DATABASE").WillReturnError(errors.New("permission denied"))
+
+		_, err := lockRestoredDatabases(context.TODO(), db, []string{"app"})
+		Expect(err).To(MatchError(ContainSubstring("while locking database hr")))
+	})
+})
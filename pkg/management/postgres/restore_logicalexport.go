@@ -0,0 +1,234 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/execlog"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// logicalExportDirectoryName is the directory, next to PGDATA, where
+	// the dumps are written before being uploaded
+	logicalExportDirectoryName = "logical-export"
+
+	// logicalExportTimeFormat is the format of the time of the export,
+	// used in the destination of the dumps
+	logicalExportTimeFormat = "20060102T150405"
+
+	// logicalExportInstanceFileName is the name of the dump of the whole
+	// instance, taken with pg_dumpall
+	logicalExportInstanceFileName = "dumpall.sql"
+)
+
+// logicalExportDump is a dump taken by the logical export
+type logicalExportDump struct {
+	// the command taking the dump and its options
+	command string
+	options []string
+
+	// the file where the dump is written
+	file string
+
+	// the location, in the object store, where the dump is uploaded
+	destination string
+}
+
+// getRecoveryLogicalExport gets the logical export of the
+// restored data requested by the user, if any
+func getRecoveryLogicalExport(cluster *apiv1.Cluster) *apiv1.RecoveryLogicalExport {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.LogicalExport
+}
+
+// exportRestoredData takes the logical export of the restored data and
+// uploads the dumps to the object store. The dumps are written next to
+// PGDATA, and removed once uploaded
+func (info InitInfo) exportRestoredData(
+	ctx context.Context,
+	export *apiv1.RecoveryLogicalExport,
+	getDsn func(dbname string) string,
+	env []string,
+) error {
+	if export == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	directory := path.Join(path.Dir(info.PgData), logicalExportDirectoryName)
+	if err := os.RemoveAll(directory); err != nil {
+		return fmt.Errorf("while cleaning the logical export directory: %w", err)
+	}
+	if err := fileutils.EnsureDirectoryExists(directory); err != nil {
+		return fmt.Errorf("while creating the logical export directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(directory); err != nil {
+			contextLogger.Warning("Cannot remove the logical export directory",
+				"directory", directory,
+				"error", err.Error())
+		}
+	}()
+
+	startTime := time.Now()
+	dumps := planLogicalExport(export, info.ClusterName, directory, startTime, getDsn)
+	contextLogger.Info("Starting the logical export of the restored data",
+		"dumps", len(dumps),
+		"destinationPath", export.DestinationPath)
+
+	var totalSize int64
+	for _, dump := range dumps {
+		size, err := runLogicalExportDump(ctx, dump, env)
+		if err != nil {
+			return err
+		}
+		totalSize += size
+
+		if err := uploadLogicalExportDump(ctx, export.UploadCommand, dump, env); err != nil {
+			return err
+		}
+
+		// Every dump is removed once uploaded, not to need the space
+		// for all the dumps at the same time
+		if err := os.Remove(dump.file); err != nil {
+			return fmt.Errorf("while removing the uploaded dump %s: %w", dump.file, err)
+		}
+	}
+
+	contextLogger.Info("Logical export completed",
+		"dumps", len(dumps),
+		"size", totalSize,
+		"duration", time.Since(startTime).String())
+	return nil
+}
+
+// planLogicalExport generates the dumps to be taken by the logical export.
+// A dump in the custom format is taken for every requested database or,
+// when there is none, a dump of the whole instance
+func planLogicalExport(
+	export *apiv1.RecoveryLogicalExport,
+	clusterName string,
+	directory string,
+	exportTime time.Time,
+	getDsn func(dbname string) string,
+) []logicalExportDump {
+	destination := fmt.Sprintf("%s/%s/%s",
+		strings.TrimSuffix(export.DestinationPath, "/"),
+		clusterName,
+		exportTime.UTC().Format(logicalExportTimeFormat))
+
+	if len(export.Databases) == 0 {
+		file := path.Join(directory, logicalExportInstanceFileName)
+		return []logicalExportDump{
+			{
+				command:     "pg_dumpall",
+				options:     []string{"-d", getDsn("postgres"), "-f", file},
+				file:        file,
+				destination: destination + "/" + logicalExportInstanceFileName,
+			},
+		}
+	}
+
+	dumps := make([]logicalExportDump, 0, len(export.Databases))
+	for _, database := range export.Databases {
+		// The names of the databases can contain characters
+		// not allowed in the name of a file
+		fileName := url.PathEscape(database) + ".dump"
+		file := path.Join(directory, fileName)
+		dumps = append(dumps, logicalExportDump{
+			command:     "pg_dump",
+			options:     []string{"-Fc", "-d", getDsn(database), "-f", file},
+			file:        file,
+			destination: destination + "/" + fileName,
+		})
+	}
+
+	return dumps
+}
+
+// runLogicalExportDump takes a dump, returning its size
+func runLogicalExportDump(ctx context.Context, dump logicalExportDump, env []string) (int64, error) {
+	contextLogger := log.FromContext(ctx)
+	startTime := time.Now()
+
+	contextLogger.Info("Taking the logical dump",
+		"command", dump.command,
+		"options", dump.options)
+	cmd := exec.CommandContext(ctx, dump.command, dump.options...) // #nosec G204
+	cmd.Env = env
+	if err := execlog.RunStreaming(cmd, dump.command); err != nil {
+		return 0, fmt.Errorf("while taking the logical dump %s: %w", path.Base(dump.file), err)
+	}
+
+	stat, err := os.Stat(dump.file)
+	if err != nil {
+		return 0, fmt.Errorf("while checking the logical dump %s: %w", path.Base(dump.file), err)
+	}
+
+	contextLogger.Info("Logical dump taken",
+		"file", dump.file,
+		"size", stat.Size(),
+		"duration", time.Since(startTime).String())
+	return stat.Size(), nil
+}
+
+// uploadLogicalExportDump uploads a dump with the command requested by the
+// user, which receives the credentials of the object store via the passed
+// environment
+func uploadLogicalExportDump(
+	ctx context.Context,
+	uploadCommand []string,
+	dump logicalExportDump,
+	env []string,
+) error {
+	if len(uploadCommand) == 0 {
+		return fmt.Errorf("missing upload command")
+	}
+
+	contextLogger := log.FromContext(ctx)
+	startTime := time.Now()
+	options := append(append([]string{}, uploadCommand[1:]...), dump.file, dump.destination)
+
+	contextLogger.Info("Uploading the logical dump",
+		"command", uploadCommand[0],
+		"options", options)
+	cmd := exec.CommandContext(ctx, uploadCommand[0], options...) // #nosec G204
+	cmd.Env = env
+	if err := execlog.RunStreaming(cmd, path.Base(uploadCommand[0])); err != nil {
+		return fmt.Errorf("while uploading the logical dump %s to %s: %w",
+			path.Base(dump.file), dump.destination, err)
+	}
+
+	contextLogger.Info("Logical dump uploaded",
+		"destination", dump.destination,
+		"duration", time.Since(startTime).String())
+	return nil
+}
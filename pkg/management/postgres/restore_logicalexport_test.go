@@ -0,0 +1,105 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("logical export of the restored data", func() {
+	exportTime := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	getDsn := func(dbname string) string {
+		return "host=/controller/run dbname=" + dbname
+	}
+
+	It("exports the whole instance when no database is requested", func() {
+		dumps := planLogicalExport(
+			&apiv1.RecoveryLogicalExport{DestinationPath: "s3://exports/", UploadCommand: []string{"upload"}},
+			"cluster-example", "/export", exportTime, getDsn)
+		Expect(dumps).To(Equal([]logicalExportDump{
+			{
+				command:     "pg_dumpall",
+				options:     []string{"-d", "host=/controller/run dbname=postgres", "-f", "/export/dumpall.sql"},
+				file:        "/export/dumpall.sql",
+				destination: "s3://exports/cluster-example/20240301T123000/dumpall.sql",
+			},
+		}))
+	})
+
+	It("exports every requested database in the custom format", func() {
+		dumps := planLogicalExport(
+			&apiv1.RecoveryLogicalExport{
+				Databases:       []string{"app", "sales/eu"},
+				DestinationPath: "s3://exports",
+				UploadCommand:   []string{"upload"},
+			},
+			"cluster-example", "/export", exportTime, getDsn)
+		Expect(dumps).To(HaveLen(2))
+		Expect(dumps[0].command).To(Equal("pg_dump"))
+		Expect(dumps[0].options).To(Equal([]string{
+			"-Fc", "-d", "host=/controller/run dbname=app", "-f", "/export/app.dump",
+		}))
+		Expect(dumps[1].file).To(Equal("/export/sales%2Feu.dump"))
+		Expect(dumps[1].destination).To(Equal("s3://exports/cluster-example/20240301T123000/sales%2Feu.dump"))
+	})
+
+	It("takes a dump and measures its size", func() {
+		file := path.Join(GinkgoT().TempDir(), "app.dump")
+		size, err := runLogicalExportDump(context.TODO(), logicalExportDump{
+			command: "sh",
+			options: []string{"-c", `printf 'dump' > "$0"`, file},
+			file:    file,
+		}, os.Environ())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(size).To(BeEquivalentTo(4))
+	})
+
+	It("uploads a dump passing the credentials via the environment", func() {
+		file := path.Join(GinkgoT().TempDir(), "app.dump")
+		Expect(os.WriteFile(file, []byte("dump"), 0o600)).To(Succeed())
+
+		Expect(uploadLogicalExportDump(
+			context.TODO(),
+			[]string{"sh", "-c", `printf '%s %s' "$1" "$AWS_ACCESS_KEY_ID" > "$0.uploaded"`},
+			logicalExportDump{file: file, destination: "s3://exports/cluster-example/app.dump"},
+			append(os.Environ(), "AWS_ACCESS_KEY_ID=access-key"),
+		)).To(Succeed())
+
+		content, err := os.ReadFile(file + ".uploaded") // #nosec
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("s3://exports/cluster-example/app.dump access-key"))
+	})
+
+	It("fails when the upload fails", func() {
+		err := uploadLogicalExportDump(
+			context.TODO(),
+			[]string{"false"},
+			logicalExportDump{file: "/export/app.dump", destination: "s3://exports/cluster-example/app.dump"},
+			os.Environ(),
+		)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("s3://exports/cluster-example/app.dump"))
+	})
+})
@@ -0,0 +1,211 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+)
+
+// ErrRecoveryLogProbe is raised when the probe of the log detects a
+// fatal error of PostgreSQL preventing the recovery
+var ErrRecoveryLogProbe = errors.New("PostgreSQL logged an error preventing the recovery")
+
+// recoveryLogPattern is a fatal error of PostgreSQL with a known cause
+type recoveryLogPattern struct {
+	name       string
+	expression *regexp.Regexp
+	hint       string
+}
+
+// builtinRecoveryLogPatterns are the most common causes
+// of a failed recovery, always detected by the probe
+var builtinRecoveryLogPatterns = []recoveryLogPattern{
+	{
+		name:       "missing-tablespace",
+		expression: regexp.MustCompile(`^could not \w+ (file|directory) "[^"]*pg_tblspc/`),
+		hint: "A tablespace of the backup is missing or not reachable: check the tablespaces " +
+			"of the cluster and their remapping",
+	},
+	{
+		name: "insufficient-parameter",
+		expression: regexp.MustCompile(
+			`insufficient parameter settings|is a lower setting than on the (primary|master) server`),
+		hint: "A parameter of the cluster is lower than on the source of the backup: raise it " +
+			"to at least the value it had on the source",
+	},
+	{
+		name: "corrupt-wal",
+		expression: regexp.MustCompile(`WAL contains references to invalid pages|` +
+			`incorrect resource manager data checksum|` +
+			`invalid (magic number|record length|resource manager ID|contrecord length|info bits)|` +
+			`invalid checkpoint record`),
+		hint: "A WAL file of the archive is corrupt: set a recovery target preceding it, " +
+			"or restore a different backup",
+	},
+	{
+		name: "missing-wal",
+		expression: regexp.MustCompile(`could not locate required checkpoint record|` +
+			`WAL ends before (end of online backup|consistent recovery point)`),
+		hint: "The archive doesn't contain every WAL file needed to make the backup consistent: " +
+			"check the archive of the source, or restore a different backup",
+	},
+	{
+		name: "timeline-mismatch",
+		expression: regexp.MustCompile(`is not a child of this server's history|` +
+			`requested timeline \d+ does not contain minimum recovery point`),
+		hint: "The requested timeline can't be reached from the backup: check targetTLI " +
+			"in the recovery target",
+	},
+}
+
+// getRecoveryLogProbe gets the probe of the log
+// requested by the user, if any
+func getRecoveryLogProbe(cluster *apiv1.Cluster) *apiv1.RecoveryLogProbe {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.LogProbe
+}
+
+// recoveryLogProbe is a log record writer matching the fatal errors logged
+// by PostgreSQL while the WAL files are being replayed against a set of
+// known patterns, while forwarding every record to another writer. The
+// errors logged after the end of the WAL replay are left to the
+// promotionFailureCollector
+type recoveryLogProbe struct {
+	writer   logpipe.RecordWriter
+	patterns []recoveryLogPattern
+
+	mu      sync.Mutex
+	stopped bool
+	match   *recoveryLogPattern
+	failure string
+}
+
+// newRecoveryLogProbe creates the probe of the log, matching the built-in
+// patterns and the ones requested by the user, and forwarding the log
+// records to the passed writer, or to the instance manager logger when it
+// is nil. No probe is created when it wasn't requested
+func newRecoveryLogProbe(
+	writer logpipe.RecordWriter,
+	logProbe *apiv1.RecoveryLogProbe,
+) (*recoveryLogProbe, error) {
+	if logProbe == nil {
+		return nil, nil
+	}
+
+	if writer == nil {
+		writer = &logpipe.LogRecordWriter{}
+	}
+
+	patterns := make([]recoveryLogPattern, 0, len(builtinRecoveryLogPatterns)+len(logProbe.Patterns))
+	patterns = append(patterns, builtinRecoveryLogPatterns...)
+	for _, pattern := range logProbe.Patterns {
+		expression, err := regexp.Compile(pattern.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression of the log probe pattern %s: %w", pattern.Name, err)
+		}
+		patterns = append(patterns, recoveryLogPattern{
+			name:       pattern.Name,
+			expression: expression,
+			hint:       pattern.Hint,
+		})
+	}
+
+	return &recoveryLogProbe{writer: writer, patterns: patterns}, nil
+}
+
+// Write implements the logpipe.RecordWriter interface
+func (probe *recoveryLogProbe) Write(record logpipe.NamedRecord) {
+	probe.writer.Write(record)
+
+	loggingRecord, ok := record.(*logpipe.LoggingRecord)
+	if !ok {
+		return
+	}
+
+	probe.mu.Lock()
+	defer probe.mu.Unlock()
+
+	if probe.stopped || probe.match != nil {
+		return
+	}
+
+	if strings.HasPrefix(loggingRecord.Message, redoDoneMessagePrefix) {
+		probe.stopped = true
+		return
+	}
+
+	if loggingRecord.ErrorSeverity != "FATAL" && loggingRecord.ErrorSeverity != "PANIC" {
+		return
+	}
+
+	for i := range probe.patterns {
+		if !probe.patterns[i].expression.MatchString(loggingRecord.Message) {
+			continue
+		}
+
+		probe.match = &probe.patterns[i]
+		probe.failure = loggingRecord.Message
+		if loggingRecord.Detail != "" {
+			probe.failure += ": " + loggingRecord.Detail
+		}
+		return
+	}
+}
+
+// probeError gets the error detected by the probe, if any
+func (probe *recoveryLogProbe) probeError() error {
+	if probe == nil {
+		return nil
+	}
+
+	probe.mu.Lock()
+	defer probe.mu.Unlock()
+
+	if probe.match == nil {
+		return nil
+	}
+
+	if probe.match.hint == "" {
+		return fmt.Errorf("%w: %s (pattern %s)", ErrRecoveryLogProbe, probe.failure, probe.match.name)
+	}
+
+	return fmt.Errorf("%w: %s (pattern %s). %s",
+		ErrRecoveryLogProbe, probe.failure, probe.match.name, probe.match.hint)
+}
+
+// stop makes the probe ignore the following log records,
+// once the recovery is completed
+func (probe *recoveryLogProbe) stop() {
+	if probe == nil {
+		return
+	}
+
+	probe.mu.Lock()
+	defer probe.mu.Unlock()
+
+	probe.stopped = true
+}
@@ -0,0 +1,108 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("probe of the log during the recovery", func() {
+	fatal := func(message string) *logpipe.LoggingRecord {
+		return &logpipe.LoggingRecord{ErrorSeverity: "FATAL", Message: message}
+	}
+
+	It("is not created unless requested", func() {
+		logProbe, err := newRecoveryLogProbe(nil, getRecoveryLogProbe(&apiv1.Cluster{}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(logProbe).To(BeNil())
+		Expect(logProbe.probeError()).To(Succeed())
+	})
+
+	DescribeTable("detects the built-in fatal errors",
+		func(message, pattern string) {
+			writer := &recordingWriter{}
+			logProbe, err := newRecoveryLogProbe(writer, &apiv1.RecoveryLogProbe{})
+			Expect(err).ToNot(HaveOccurred())
+
+			logProbe.Write(fatal(message))
+			Expect(writer.records).To(HaveLen(1))
+			Expect(logProbe.probeError()).To(MatchError(ContainSubstring("(pattern " + pattern + ")")))
+		},
+		Entry("missing tablespace",
+			`could not open directory "pg_tblspc/16385/PG_16_202307071": No such file or directory`,
+			"missing-tablespace"),
+		Entry("insufficient parameter",
+			"hot standby is not possible because max_connections = 100 is a lower setting "+
+				"than on the master server (its value was 200)",
+			"insufficient-parameter"),
+		Entry("corrupt WAL", "WAL contains references to invalid pages", "corrupt-wal"),
+		Entry("missing WAL", "WAL ends before end of online backup", "missing-wal"),
+		Entry("timeline mismatch",
+			"requested timeline 3 is not a child of this server's history", "timeline-mismatch"),
+	)
+
+	It("ignores the errors that are not fatal", func() {
+		logProbe, err := newRecoveryLogProbe(&recordingWriter{}, &apiv1.RecoveryLogProbe{})
+		Expect(err).ToNot(HaveOccurred())
+
+		// This is logged at the end of every WAL file available
+		logProbe.Write(&logpipe.LoggingRecord{
+			ErrorSeverity: "LOG",
+			Message:       "invalid record length at 0/3000148: expected at least 24, got 0",
+		})
+		logProbe.Write(fatal("terminating connection due to administrator command"))
+		Expect(logProbe.probeError()).To(Succeed())
+	})
+
+	It("matches the patterns requested by the user, reporting their hint", func() {
+		logProbe, err := newRecoveryLogProbe(&recordingWriter{}, &apiv1.RecoveryLogProbe{
+			Patterns: []apiv1.RecoveryLogProbePattern{
+				{Name: "missing-extension", Expression: `could not access file "\$libdir/`, Hint: "Use the same image"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		logProbe.Write(fatal(`could not access file "$libdir/postgis-3": No such file or directory`))
+		Expect(logProbe.probeError()).To(MatchError(HaveSuffix("(pattern missing-extension). Use the same image")))
+	})
+
+	It("rejects an invalid expression", func() {
+		_, err := newRecoveryLogProbe(nil, &apiv1.RecoveryLogProbe{
+			Patterns: []apiv1.RecoveryLogProbePattern{{Name: "broken", Expression: "("}},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("stops probing at the end of the WAL replay and once stopped", func() {
+		logProbe, err := newRecoveryLogProbe(&recordingWriter{}, &apiv1.RecoveryLogProbe{})
+		Expect(err).ToNot(HaveOccurred())
+
+		logProbe.Write(&logpipe.LoggingRecord{Message: "redo done at 0/3000148"})
+		logProbe.Write(fatal("WAL contains references to invalid pages"))
+		Expect(logProbe.probeError()).To(Succeed())
+
+		logProbe, err = newRecoveryLogProbe(&recordingWriter{}, &apiv1.RecoveryLogProbe{})
+		Expect(err).ToNot(HaveOccurred())
+		logProbe.stop()
+		logProbe.Write(fatal("WAL contains references to invalid pages"))
+		Expect(logProbe.probeError()).To(Succeed())
+	})
+})
@@ -0,0 +1,215 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/versions"
+)
+
+// RestoreManifestFile is the file, in PGDATA, where the manifest
+// of the restore is written
+const RestoreManifestFile = "cnpg_restore_manifest.json"
+
+// ErrRestoreManifestMismatch is raised when the backup to be restored
+// doesn't match the one reported in the manifest of a previous restore
+var ErrRestoreManifestMismatch = errors.New("the backup doesn't match the restore manifest")
+
+// RestoreManifest describes what has been done by a restore, and can
+// be used to repeat it
+type RestoreManifest struct {
+	// The ID of the restored backup
+	BackupID string `json:"backupID"`
+
+	// The name of the server in the object store
+	ServerName string `json:"serverName"`
+
+	// The path of the object store containing the backup
+	DestinationPath string `json:"destinationPath"`
+
+	// The endpoint of the object store, if any
+	EndpointURL string `json:"endpointURL,omitempty"`
+
+	// The cloud provider of the object store
+	Provider string `json:"provider,omitempty"`
+
+	// The recovery target, if any
+	RecoveryTarget *apiv1.RecoveryTarget `json:"recoveryTarget,omitempty"`
+
+	// The action taken by PostgreSQL once the recovery target is reached
+	RecoveryTargetAction string `json:"recoveryTargetAction"`
+
+	// The timeline of the instance once the recovery is completed
+	TimelineID string `json:"timelineID,omitempty"`
+
+	// The version of the operator that executed the restore
+	OperatorVersion string `json:"operatorVersion"`
+
+	// When the restore started
+	StartedAt string `json:"startedAt"`
+
+	// When the restore was completed
+	CompletedAt string `json:"completedAt,omitempty"`
+}
+
+// newRestoreManifest creates the manifest of a restore starting now
+func newRestoreManifest(cluster *apiv1.Cluster, backup *apiv1.Backup) *RestoreManifest {
+	manifest := &RestoreManifest{
+		BackupID:             backup.Status.BackupID,
+		ServerName:           backup.Status.ServerName,
+		DestinationPath:      backup.Status.DestinationPath,
+		EndpointURL:          backup.Status.EndpointURL,
+		Provider:             getBackupProvider(backup.Status.BarmanCredentials),
+		RecoveryTargetAction: recoveryTargetAction(cluster),
+		OperatorVersion:      versions.Version,
+		StartedAt:            time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget != nil {
+		manifest.RecoveryTarget = cluster.Spec.Bootstrap.Recovery.RecoveryTarget.DeepCopy()
+	}
+
+	return manifest
+}
+
+// getBackupProvider gets the name of the cloud provider of the
+// object store, as used by Barman Cloud
+func getBackupProvider(credentials apiv1.BarmanCredentials) string {
+	switch {
+	case credentials.AWS != nil:
+		return "aws-s3"
+	case credentials.Azure != nil:
+		return "azure-blob-storage"
+	case credentials.Google != nil:
+		return "google-cloud-storage"
+	default:
+		return ""
+	}
+}
+
+// writeRestoreManifest completes the manifest with the timeline reached by
+// the recovery and writes it in PGDATA. The manifest is logged too, as the
+// recovery job is the only one that can read the data directory before the
+// instance is started
+func (info InitInfo) writeRestoreManifest(ctx context.Context, manifest *RestoreManifest) error {
+	pgControlData, err := info.GetInstance().GetPgControldata()
+	if err != nil {
+		return err
+	}
+	manifest.TimelineID = utils.ParsePgControldataOutput(pgControlData)[utils.PgControlDataKeyLatestCheckpointTimelineID]
+	manifest.CompletedAt = time.Now().UTC().Format(time.RFC3339)
+
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("while encoding the restore manifest: %w", err)
+	}
+
+	if _, err := fileutils.WriteFileAtomic(path.Join(info.PgData, RestoreManifestFile), content, 0o600); err != nil {
+		return fmt.Errorf("while writing the restore manifest: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Restore manifest written",
+		"fileName", RestoreManifestFile,
+		"manifest", string(content))
+	return nil
+}
+
+// loadRestoreManifest fetches the manifest of a previous restore contained
+// in the ConfigMap referenced by the cluster, if any
+func loadRestoreManifest(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+) (*RestoreManifest, error) {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Manifest == nil {
+		return nil, nil
+	}
+
+	reference := cluster.Spec.Bootstrap.Recovery.Manifest
+	configMap, err := getRestoreConfigMap(ctx, typedClient, cluster, reference.Name)
+	if err != nil {
+		return nil, fmt.Errorf("while getting the restore manifest ConfigMap %s: %w", reference.Name, err)
+	}
+
+	content, ok := configMap.Data[reference.Key]
+	if !ok {
+		return nil, fmt.Errorf("missing key %s in the restore manifest ConfigMap %s", reference.Key, reference.Name)
+	}
+
+	var manifest RestoreManifest
+	if err := json.Unmarshal([]byte(content), &manifest); err != nil {
+		return nil, fmt.Errorf("while decoding the restore manifest in ConfigMap %s: %w", reference.Name, err)
+	}
+	if manifest.BackupID == "" {
+		return nil, fmt.Errorf("the restore manifest in ConfigMap %s has no backup ID", reference.Name)
+	}
+
+	log.FromContext(ctx).Info("Repeating the restore described by the manifest",
+		"configMap", reference.Name,
+		"key", reference.Key,
+		"backupID", manifest.BackupID,
+		"recoveryTarget", manifest.RecoveryTarget)
+	return &manifest, nil
+}
+
+// applyRestoreManifest makes the cluster restore the backup and reach the
+// recovery target reported in the manifest of a previous restore. The
+// cluster is changed in memory only
+func applyRestoreManifest(cluster *apiv1.Cluster, manifest *RestoreManifest) {
+	recoveryTarget := &apiv1.RecoveryTarget{}
+	if manifest.RecoveryTarget != nil {
+		recoveryTarget = manifest.RecoveryTarget.DeepCopy()
+	}
+	recoveryTarget.BackupID = manifest.BackupID
+
+	cluster.Spec.Bootstrap.Recovery.RecoveryTarget = recoveryTarget
+}
+
+// checkRestoreManifest checks that the backup to be restored is the one
+// reported in the manifest of a previous restore
+func checkRestoreManifest(manifest *RestoreManifest, backup *apiv1.Backup) error {
+	switch {
+	case backup.Status.BackupID != manifest.BackupID:
+		return fmt.Errorf("%w: found backup ID %s, expected %s",
+			ErrRestoreManifestMismatch, backup.Status.BackupID, manifest.BackupID)
+
+	case !isSameObjectStoreLocation(
+		backup.Status.EndpointURL, backup.Status.DestinationPath, backup.Status.ServerName,
+		manifest.EndpointURL, manifest.DestinationPath, manifest.ServerName,
+	):
+		return fmt.Errorf("%w: found the backup in server %s of %s, expected server %s of %s",
+			ErrRestoreManifestMismatch,
+			backup.Status.ServerName, backup.Status.DestinationPath,
+			manifest.ServerName, manifest.DestinationPath)
+	}
+
+	return nil
+}
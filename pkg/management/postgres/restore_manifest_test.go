@@ -0,0 +1,132 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore manifest", func() {
+	backup := &apiv1.Backup{
+		Status: apiv1.BackupStatus{
+			BarmanCredentials: apiv1.BarmanCredentials{AWS: &apiv1.S3Credentials{InheritFromIAMRole: true}},
+			EndpointURL:       "https://s3.example.com",
+			DestinationPath:   "s3://backups/",
+			ServerName:        "source",
+			BackupID:          "20240101T000000",
+		},
+	}
+
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source: "origin",
+						Manifest: &apiv1.ConfigMapKeySelector{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "manifest"},
+							Key:                  "manifest.json",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("describes the restored backup and the recovery target", func() {
+		cluster := newCluster()
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget = &apiv1.RecoveryTarget{TargetLSN: "0/3000000"}
+
+		manifest := newRestoreManifest(cluster, backup)
+		Expect(manifest.BackupID).To(Equal("20240101T000000"))
+		Expect(manifest.ServerName).To(Equal("source"))
+		Expect(manifest.DestinationPath).To(Equal("s3://backups/"))
+		Expect(manifest.EndpointURL).To(Equal("https://s3.example.com"))
+		Expect(manifest.Provider).To(Equal("aws-s3"))
+		Expect(manifest.RecoveryTarget).To(Equal(&apiv1.RecoveryTarget{TargetLSN: "0/3000000"}))
+		Expect(manifest.RecoveryTargetAction).To(Equal("promote"))
+		Expect(manifest.StartedAt).ToNot(BeEmpty())
+	})
+
+	It("repeats the restore described by a manifest", func() {
+		previous := newRestoreManifest(newCluster(), backup)
+		previous.RecoveryTarget = &apiv1.RecoveryTarget{TargetName: "before-migration"}
+		content, err := json.Marshal(previous)
+		Expect(err).ToNot(HaveOccurred())
+
+		cluster := newCluster()
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "manifest", Namespace: "dev"},
+				Data:       map[string]string{"manifest.json": string(content)},
+			}).
+			Build()
+
+		manifest, err := loadRestoreManifest(context.TODO(), typedClient, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest).To(Equal(previous))
+
+		applyRestoreManifest(cluster, manifest)
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget).To(Equal(&apiv1.RecoveryTarget{
+			BackupID:   "20240101T000000",
+			TargetName: "before-migration",
+		}))
+		Expect(checkRestoreManifest(manifest, backup)).To(Succeed())
+	})
+
+	It("rejects a backup not matching the manifest", func() {
+		manifest := newRestoreManifest(newCluster(), backup)
+
+		otherBackup := backup.DeepCopy()
+		otherBackup.Status.BackupID = "20240102T000000"
+		Expect(checkRestoreManifest(manifest, otherBackup)).To(MatchError(ErrRestoreManifestMismatch))
+
+		otherBackup = backup.DeepCopy()
+		otherBackup.Status.ServerName = "other"
+		Expect(checkRestoreManifest(manifest, otherBackup)).To(MatchError(ErrRestoreManifestMismatch))
+	})
+
+	It("rejects an invalid manifest", func() {
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "manifest", Namespace: "dev"},
+				Data:       map[string]string{"manifest.json": "{}"},
+			}).
+			Build()
+
+		_, err := loadRestoreManifest(context.TODO(), typedClient, newCluster())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("doesn't require a manifest", func() {
+		Expect(loadRestoreManifest(context.TODO(), fake.NewClientBuilder().Build(), &apiv1.Cluster{})).To(BeNil())
+	})
+})
@@ -0,0 +1,227 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+// ErrInvalidRecoveryMemory is raised when a memory parameter requested
+// for the recovery can't be used with the restored PostgreSQL version
+var ErrInvalidRecoveryMemory = errors.New("invalid recovery memory parameter")
+
+// recoveryMemoryOption is a memory parameter that can be tuned
+// for the recovery
+type recoveryMemoryOption struct {
+	// the name of the PostgreSQL parameter
+	name string
+	// the first PostgreSQL major version supporting the parameter
+	minMajorVersion int
+	// the size, in kB, of the unit used when the value has no unit
+	unitKB int64
+	// the range, in kB, of the values accepted by PostgreSQL
+	minKB, maxKB int64
+	// the value requested for the recovery, if any
+	value func(memory *apiv1.RecoveryMemory) string
+}
+
+// recoveryMemoryOptions are the memory parameters that can be configured
+// for the recovery phase. The ranges are the ones of the 64-bit builds
+// of the supported PostgreSQL versions
+var recoveryMemoryOptions = []recoveryMemoryOption{
+	{
+		name:            "maintenance_work_mem",
+		minMajorVersion: 10,
+		unitKB:          1,
+		minKB:           1024,
+		maxKB:           2147483647,
+		value:           func(memory *apiv1.RecoveryMemory) string { return memory.MaintenanceWorkMem },
+	},
+	{
+		name:            "effective_cache_size",
+		minMajorVersion: 10,
+		unitKB:          8,
+		minKB:           8,
+		maxKB:           2147483647 * 8,
+		value:           func(memory *apiv1.RecoveryMemory) string { return memory.EffectiveCacheSize },
+	},
+}
+
+// memoryUnitsKB are the memory units accepted by PostgreSQL, with their
+// size in kB
+var memoryUnitsKB = map[string]int64{
+	"kB": 1,
+	"MB": 1024,
+	"GB": 1024 * 1024,
+	"TB": 1024 * 1024 * 1024,
+}
+
+// getRecoveryMemory gets the memory parameters requested by the user
+// for the recovery, if any
+func getRecoveryMemory(cluster *apiv1.Cluster) *apiv1.RecoveryMemory {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Memory
+}
+
+// parseMemoryKB converts a memory size, expressed with the PostgreSQL
+// units, to kB. The passed unit is used when the value has no unit
+func parseMemoryKB(value string, unitKB int64) (int64, error) {
+	number := strings.TrimRight(value, "kMGTB")
+	unit := strings.TrimPrefix(value, number)
+
+	multiplier := unitKB
+	if unit != "" {
+		var ok bool
+		if multiplier, ok = memoryUnitsKB[unit]; !ok {
+			return 0, fmt.Errorf("unknown unit %q", unit)
+		}
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if size > (1<<62)/multiplier {
+		return 0, fmt.Errorf("value too large")
+	}
+
+	return size * multiplier, nil
+}
+
+// renderRecoveryMemoryOptions generates the memory parameters for the
+// recovery, checking that they are accepted by the passed PostgreSQL
+// major version
+func renderRecoveryMemoryOptions(memory *apiv1.RecoveryMemory, majorVersion int) (map[string]string, error) {
+	options := make(map[string]string)
+	for _, option := range recoveryMemoryOptions {
+		value := option.value(memory)
+		if value == "" {
+			continue
+		}
+
+		if majorVersion < option.minMajorVersion {
+			return nil, fmt.Errorf("%w: %s is not supported by PostgreSQL %d",
+				ErrInvalidRecoveryMemory, option.name, majorVersion)
+		}
+
+		size, err := parseMemoryKB(value, option.unitKB)
+		if err != nil {
+			return nil, fmt.Errorf("%w: cannot parse %s value %q: %v",
+				ErrInvalidRecoveryMemory, option.name, value, err)
+		}
+		if size < option.minKB || size > option.maxKB {
+			return nil, fmt.Errorf("%w: %s value %q is outside the range accepted by PostgreSQL %d, "+
+				"from %dkB to %dkB",
+				ErrInvalidRecoveryMemory, option.name, value, majorVersion, option.minKB, option.maxKB)
+		}
+
+		options[option.name] = value
+	}
+
+	return options, nil
+}
+
+// steadyStateMemoryOptions gets the values the memory parameters have
+// once the recovery is completed, which are the ones of the cluster
+// configuration
+func steadyStateMemoryOptions(cluster *apiv1.Cluster) map[string]string {
+	options := make(map[string]string)
+	for _, option := range recoveryMemoryOptions {
+		if value, ok := cluster.Spec.PostgresConfiguration.Parameters[option.name]; ok {
+			options[option.name] = value
+		}
+	}
+
+	return options
+}
+
+// writeRecoveryMemoryConfiguration writes, in the custom.conf file, the
+// memory parameters for the recovery phase. They will be set back to the
+// values of the cluster configuration once the restored instance is
+// configured
+func (info InitInfo) writeRecoveryMemoryConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	memory := getRecoveryMemory(cluster)
+	if memory == nil {
+		return nil
+	}
+
+	majorVersion, err := postgresutils.GetMajorVersion(info.PgData)
+	if err != nil {
+		return fmt.Errorf("cannot detect major version: %w", err)
+	}
+
+	options, err := renderRecoveryMemoryOptions(memory, majorVersion)
+	if err != nil {
+		return err
+	}
+	if len(options) == 0 {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, options); err != nil {
+		return fmt.Errorf("while configuring the memory for the recovery: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Configured the memory for the recovery",
+		"options", options,
+		"steadyStateOptions", steadyStateMemoryOptions(cluster))
+
+	return nil
+}
+
+// restoreMemoryAfterRecovery sets the memory parameters back to the values
+// of the cluster configuration, removing the ones the cluster doesn't
+// define. The instance needs to be stopped
+func (info InitInfo) restoreMemoryAfterRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	if getRecoveryMemory(cluster) == nil {
+		return nil
+	}
+
+	managedOptions := make([]string, 0, len(recoveryMemoryOptions))
+	for _, option := range recoveryMemoryOptions {
+		managedOptions = append(managedOptions, option.name)
+	}
+	options := steadyStateMemoryOptions(cluster)
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	changed, err := configfile.UpdatePostgresConfigurationFile(targetFile, options, managedOptions...)
+	if err != nil {
+		return fmt.Errorf("while restoring the memory configuration: %w", err)
+	}
+
+	if changed {
+		log.FromContext(ctx).Info("Restored the memory configuration after the recovery",
+			"options", options)
+	}
+
+	return nil
+}
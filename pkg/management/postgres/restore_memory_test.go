@@ -0,0 +1,104 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery memory", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		pgData := GinkgoT().TempDir()
+		info = InitInfo{PgData: pgData}
+		Expect(os.WriteFile(
+			path.Join(pgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("maintenance_work_mem = '64MB'\nshared_buffers = '128MB'\n"),
+			0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+	})
+
+	readCustomConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	newCluster := func(parameters map[string]string, memory *apiv1.RecoveryMemory) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					Parameters: parameters,
+				},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Memory: memory},
+				},
+			},
+		}
+	}
+
+	It("converts the memory sizes to kB", func() {
+		Expect(parseMemoryKB("2GB", 1)).To(Equal(int64(2 * 1024 * 1024)))
+		Expect(parseMemoryKB("1024", 1)).To(Equal(int64(1024)))
+		Expect(parseMemoryKB("16", 8)).To(Equal(int64(128)))
+		_, err := parseMemoryKB("1PB", 1)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("writes the parameters and restores the values of the cluster configuration", func() {
+		cluster := newCluster(
+			map[string]string{"maintenance_work_mem": "64MB"},
+			&apiv1.RecoveryMemory{MaintenanceWorkMem: "2GB", EffectiveCacheSize: "12GB"})
+
+		Expect(info.writeRecoveryMemoryConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal(
+			"maintenance_work_mem = '2GB'\nshared_buffers = '128MB'\neffective_cache_size = '12GB'\n"))
+
+		Expect(info.restoreMemoryAfterRecovery(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal("maintenance_work_mem = '64MB'\nshared_buffers = '128MB'\n"))
+	})
+
+	It("rejects the values outside the range accepted by PostgreSQL", func() {
+		_, err := renderRecoveryMemoryOptions(&apiv1.RecoveryMemory{MaintenanceWorkMem: "512kB"}, 16)
+		Expect(err).To(MatchError(ErrInvalidRecoveryMemory))
+
+		_, err = renderRecoveryMemoryOptions(&apiv1.RecoveryMemory{MaintenanceWorkMem: "4TB"}, 16)
+		Expect(err).To(MatchError(ErrInvalidRecoveryMemory))
+
+		_, err = renderRecoveryMemoryOptions(&apiv1.RecoveryMemory{EffectiveCacheSize: "1GB"}, 9)
+		Expect(err).To(MatchError(ErrInvalidRecoveryMemory))
+
+		cluster := newCluster(nil, &apiv1.RecoveryMemory{EffectiveCacheSize: "0"})
+		Expect(info.writeRecoveryMemoryConfiguration(context.TODO(), cluster)).To(MatchError(ErrInvalidRecoveryMemory))
+		Expect(readCustomConf()).To(Equal("maintenance_work_mem = '64MB'\nshared_buffers = '128MB'\n"))
+	})
+
+	It("leaves the configuration untouched when no memory parameters are requested", func() {
+		Expect(info.writeRecoveryMemoryConfiguration(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(info.restoreMemoryAfterRecovery(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(readCustomConf()).To(Equal("maintenance_work_mem = '64MB'\nshared_buffers = '128MB'\n"))
+	})
+})
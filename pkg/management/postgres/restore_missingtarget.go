@@ -0,0 +1,85 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrMissingRecoveryTarget is raised when the restored instance is going
+// to be promoted without a recovery target, and the user asked to fail
+var ErrMissingRecoveryTarget = errors.New("no recovery target has been set")
+
+// getMissingRecoveryTargetPolicy gets the action to be taken when
+// the restored instance is going to be promoted without a recovery target
+func getMissingRecoveryTargetPolicy(cluster *apiv1.Cluster) apiv1.MissingRecoveryTargetPolicy {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.OnMissingRecoveryTarget == "" {
+		return apiv1.MissingRecoveryTargetPolicyLatest
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.OnMissingRecoveryTarget
+}
+
+// hasRecoverySettingsTarget checks if the recovery settings
+// supplied by the user set a recovery target
+func hasRecoverySettingsTarget(settings map[string]string) bool {
+	for _, name := range exclusiveRecoveryTargetSettings {
+		if _, ok := settings[name]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkMissingRecoveryTarget makes explicit, before the restore starts,
+// that the restored instance will replay every WAL file available in the
+// archive and be promoted, as no recovery target has been set, neither in
+// the cluster nor in the recovery settings. This is reported with a
+// warning or, when the user asked so, makes the restore fail, so that a
+// forgotten or mistyped recovery target never causes a full replay
+func checkMissingRecoveryTarget(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	recoverySettings map[string]string,
+) error {
+	if cluster.GetRecoveryRole() != apiv1.RecoveryRolePrimary ||
+		getRequestedRecoveryTarget(cluster) != nil ||
+		hasRecoverySettingsTarget(recoverySettings) {
+		return nil
+	}
+
+	if getMissingRecoveryTargetPolicy(cluster) == apiv1.MissingRecoveryTargetPolicyFail {
+		return fmt.Errorf(
+			"%w: the restored instance would replay every WAL file available in the archive "+
+				"and be promoted. Set .spec.bootstrap.recovery.recoveryTarget or, to recover to the "+
+				"latest available point, set .spec.bootstrap.recovery.onMissingRecoveryTarget to %q",
+			ErrMissingRecoveryTarget, apiv1.MissingRecoveryTargetPolicyLatest)
+	}
+
+	log.FromContext(ctx).Warning("No recovery target has been set: every WAL file available in the "+
+		"archive will be replayed, and the restored instance will be promoted at the end of the archive. "+
+		"Set onMissingRecoveryTarget to fail to refuse the restores without a recovery target",
+		"option", "onMissingRecoveryTarget")
+	return nil
+}
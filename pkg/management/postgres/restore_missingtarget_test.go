@@ -0,0 +1,74 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("missing recovery target", func() {
+	var cluster *apiv1.Cluster
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						OnMissingRecoveryTarget: apiv1.MissingRecoveryTargetPolicyFail,
+					},
+				},
+			},
+		}
+	})
+
+	It("fails without a recovery target when requested", func() {
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, nil)).
+			To(MatchError(ErrMissingRecoveryTarget))
+	})
+
+	It("recovers to the latest point by default", func() {
+		cluster.Spec.Bootstrap.Recovery.OnMissingRecoveryTarget = ""
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, nil)).To(Succeed())
+
+		cluster.Spec.Bootstrap.Recovery.OnMissingRecoveryTarget = apiv1.MissingRecoveryTargetPolicyLatest
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, nil)).To(Succeed())
+	})
+
+	It("accepts a recovery target set in the cluster", func() {
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget = &apiv1.RecoveryTarget{TargetName: "before-upgrade"}
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, nil)).To(Succeed())
+	})
+
+	It("accepts a recovery target set in the recovery settings", func() {
+		settings := map[string]string{"recovery_target_lsn": "0/3000000"}
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, settings)).To(Succeed())
+
+		settings = map[string]string{"recovery_min_apply_delay": "1h"}
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, settings)).
+			To(MatchError(ErrMissingRecoveryTarget))
+	})
+
+	It("ignores the instances that won't be promoted", func() {
+		cluster.Spec.Bootstrap.Recovery.Role = apiv1.RecoveryRoleStandby
+		Expect(checkMissingRecoveryTarget(context.TODO(), cluster, nil)).To(Succeed())
+	})
+})
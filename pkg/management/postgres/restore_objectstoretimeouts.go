@@ -0,0 +1,114 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+)
+
+// getRecoveryObjectStoreTimeouts gets the timeouts of the requests
+// to the object store requested by the user, if any
+func getRecoveryObjectStoreTimeouts(cluster *apiv1.Cluster) *apiv1.RecoveryObjectStoreTimeouts {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.ObjectStoreTimeouts
+}
+
+// objectStoreTimeoutOptions generates the barman-cloud options setting the
+// timeouts of the requests to the object store. As barman-cloud accepts
+// them in seconds, they are rounded up to the second
+func objectStoreTimeoutOptions(cluster *apiv1.Cluster) []string {
+	timeouts := getRecoveryObjectStoreTimeouts(cluster)
+	if timeouts == nil || timeouts.Read == nil || timeouts.Read.Duration <= 0 {
+		return nil
+	}
+
+	seconds := int64(math.Ceil(timeouts.Read.Duration.Seconds()))
+	return []string{"--read-timeout", strconv.FormatInt(seconds, 10)}
+}
+
+const (
+	// walFetchKillDelay is the time barman-cloud-wal-restore has to exit
+	// once terminated by the timeout of the fetch, before being killed
+	walFetchKillDelay = 10 * time.Second
+
+	// walFetchTimedOutExitCodes are the exit codes of timeout when the
+	// command has been terminated, or killed after walFetchKillDelay
+	walFetchTimedOutExitCodes = "124|137"
+
+	// walFetchTimedOutFatalExitCode is the exit code of a timed out fetch
+	// aborting the recovery. PostgreSQL considers any exit code greater than
+	// 125 as a fatal error, instead of a missing WAL file
+	walFetchTimedOutFatalExitCode = "255"
+
+	// walFetchTimedOutRetryExitCode is the exit code of a timed out fetch
+	// making a standby fetch the WAL file again later
+	walFetchTimedOutRetryExitCode = "1"
+)
+
+// withWALFetchTimeout sets the timeout requested by the user to the fetch
+// of a WAL file. A continuous standby fetches the WAL file again when the
+// timeout expires, while a recovery ending with a promotion is aborted, as
+// PostgreSQL would otherwise consider the WAL file as not existing and end
+// the recovery before reaching its target
+func (cmd restoreCommand) withWALFetchTimeout(cluster *apiv1.Cluster) restoreCommand {
+	timeouts := getRecoveryObjectStoreTimeouts(cluster)
+	if timeouts == nil || timeouts.WALFetch == nil || timeouts.WALFetch.Duration <= 0 {
+		return cmd
+	}
+
+	cmd.fetchTimeout = timeouts.WALFetch.Duration
+	cmd.fetchTimedOutExitCode = walFetchTimedOutFatalExitCode
+	if cluster.GetRecoveryRole() == apiv1.RecoveryRoleStandby {
+		cmd.fetchTimedOutExitCode = walFetchTimedOutRetryExitCode
+	}
+
+	return cmd
+}
+
+// renderWALFetch renders the fetch of a WAL file, wrapped with its timeout
+// if any. The arguments of a fetch with a timeout are quoted, so that they
+// are passed as single words to the command
+func (cmd restoreCommand) renderWALFetch() []string {
+	if cmd.fetchTimeout <= 0 {
+		return append(append([]string{}, cmd.fetch...), cmd.arguments...)
+	}
+
+	seconds := int64(math.Ceil(cmd.fetchTimeout.Seconds()))
+	result := []string{
+		"{",
+		"timeout",
+		"--kill-after=" + strconv.FormatInt(int64(walFetchKillDelay.Seconds()), 10),
+		strconv.FormatInt(seconds, 10),
+	}
+	result = append(result, cmd.fetch...)
+	for _, argument := range cmd.arguments {
+		result = append(result, `"`+argument+`"`)
+	}
+	return append(result,
+		"||", "{", "rc=$?;",
+		"case", "$rc", "in",
+		walFetchTimedOutExitCodes+")", "exit", cmd.fetchTimedOutExitCode+";;",
+		"*)", "exit", "$rc;;",
+		"esac;", "};", "}")
+}
@@ -0,0 +1,115 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"os/exec"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("object store timeouts", func() {
+	newCluster := func(timeouts *apiv1.RecoveryObjectStoreTimeouts) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "restored"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", ObjectStoreTimeouts: timeouts},
+				},
+			},
+		}
+	}
+
+	It("doesn't pass any option when no timeout is set", func() {
+		Expect(objectStoreTimeoutOptions(newCluster(nil))).To(BeEmpty())
+		Expect(objectStoreTimeoutOptions(newCluster(&apiv1.RecoveryObjectStoreTimeouts{}))).To(BeEmpty())
+	})
+
+	It("rounds the read timeout up to the second", func() {
+		Expect(objectStoreTimeoutOptions(newCluster(&apiv1.RecoveryObjectStoreTimeouts{
+			Read: &metav1.Duration{Duration: 1500 * time.Millisecond},
+		}))).To(Equal([]string{"--read-timeout", "2"}))
+	})
+
+	It("passes the read timeout to barman-cloud-wal-restore", func() {
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+		}}
+		cluster := newCluster(&apiv1.RecoveryObjectStoreTimeouts{
+			Read: &metav1.Duration{Duration: 90 * time.Second},
+		})
+
+		options, err := backupWalRestoreOptions(cluster, backup)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(options).To(Equal([]string{"--read-timeout", "90", "s3://backups/", "origin"}))
+	})
+
+	It("doesn't wrap the fetch of the WAL files when no timeout is set", func() {
+		cmd := restoreCommand{fetch: []string{"barman-cloud-wal-restore", "s3://backups/", "origin"}}
+		cmd.arguments = walFetchArguments
+		Expect(cmd.withWALFetchTimeout(newCluster(nil)).render()).
+			To(Equal("barman-cloud-wal-restore s3://backups/ origin %f %p"))
+	})
+
+	// runWALFetch executes a restore_command as PostgreSQL would do,
+	// returning its exit code
+	runWALFetch := func(cmd restoreCommand) int {
+		command := renderRestoreCommand(cmd.render(), "000000010000000000000001", "pg_wal/RECOVERYXLOG")
+		err := exec.Command("sh", "-c", command).Run() // #nosec G204
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return 0
+	}
+
+	It("aborts the recovery when the fetch of a WAL file times out", func() {
+		cluster := newCluster(&apiv1.RecoveryObjectStoreTimeouts{
+			WALFetch: &metav1.Duration{Duration: 500 * time.Millisecond},
+		})
+
+		fetch := func(script string) restoreCommand {
+			return restoreCommand{fetch: []string{"sh", "-c", script, "sh"}, arguments: walFetchArguments}.
+				withWALFetchTimeout(cluster)
+		}
+
+		cmd := fetch("'sleep 30'")
+		Expect(cmd.render()).To(HavePrefix("{ timeout --kill-after=10 1 "))
+		Expect(runWALFetch(cmd)).To(Equal(255))
+		Expect(runWALFetch(fetch("'exit 1'"))).To(Equal(1))
+		Expect(runWALFetch(fetch(`'test "$1" = 000000010000000000000001'`))).To(Equal(0))
+	})
+
+	It("makes a continuous standby fetch again a WAL file whose fetch timed out", func() {
+		cluster := newCluster(&apiv1.RecoveryObjectStoreTimeouts{
+			WALFetch: &metav1.Duration{Duration: 500 * time.Millisecond},
+		})
+		cluster.Spec.Bootstrap.Recovery.Role = apiv1.RecoveryRoleStandby
+
+		cmd := restoreCommand{fetch: []string{"sh", "-c", "'sleep 30'", "sh"}, arguments: walFetchArguments}
+		Expect(runWALFetch(cmd.withWALFetchTimeout(cluster))).To(Equal(1))
+	})
+})
@@ -0,0 +1,167 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// restoreOutcomeReportTimeout is the time allowed to report the outcome
+// of the restore, which may happen after the context has been cancelled
+const restoreOutcomeReportTimeout = 30 * time.Second
+
+// RestoreOutcome is the outcome of the restore, as reported
+// by the exit code of the job and by the cluster conditions
+type RestoreOutcome struct {
+	// The exit code of the restore job
+	ExitCode int
+
+	// The reason of the RestoreCompleted condition
+	Reason apiv1.ConditionReason
+}
+
+// configurationRestoreErrors are the errors raised when the restore can't
+// work with the current configuration, and won't succeed until it is changed
+var configurationRestoreErrors = []error{
+	apiv1.ErrConflictingRecoveryTargets,
+	ErrArchiveDestinationIsRecoverySource,
+	ErrCatalogSummaryMismatch,
+	ErrCollationMismatch,
+	ErrControlFileRepairUnsafe,
+	ErrInsufficientInodes,
+	ErrInsufficientStagingSpace,
+	ErrInvalidLocalBackup,
+	ErrInvalidPostgresConfiguration,
+	ErrInvalidPromotionSlot,
+	ErrInvalidRecoveryMemory,
+	ErrInvalidRecoverySettings,
+	ErrInvalidRecoveryTargetAnnotation,
+	ErrInvalidServerName,
+	ErrPasswordResetRoleNotFound,
+	ErrPasswordResetSuperuser,
+	ErrRestoreManifestMismatch,
+	ErrUnexpectedRecoverySource,
+	ErrUnsupportedRestoredLocale,
+}
+
+// targetNotReachedRestoreErrors are the errors raised when the
+// recovery can't reach the requested recovery target
+var targetNotReachedRestoreErrors = []error{
+	ErrRecoveryTargetNotReached,
+	ErrRecoveryTargetOutsideWindow,
+}
+
+// GetRestoreOutcome classifies the error returned by the restore, nil
+// meaning that the restore succeeded. The errors not falling in any other
+// category are reported as generic failures, with exit code 1
+func GetRestoreOutcome(err error) RestoreOutcome {
+	switch {
+	case err == nil:
+		return RestoreOutcome{ExitCode: 0, Reason: apiv1.ConditionReasonRestoreSucceeded}
+
+	case errors.Is(err, ErrRestorePhaseTimedOut), errors.Is(err, context.DeadlineExceeded):
+		return RestoreOutcome{
+			ExitCode: apiv1.RestoreTimeoutExitCode,
+			Reason:   apiv1.ConditionReasonRestoreTimedOut,
+		}
+
+	case isAnyRestoreError(err, targetNotReachedRestoreErrors):
+		return RestoreOutcome{
+			ExitCode: apiv1.RestoreTargetNotReachedExitCode,
+			Reason:   apiv1.ConditionReasonRestoreTargetNotReached,
+		}
+
+	case isAnyRestoreError(err, configurationRestoreErrors):
+		return RestoreOutcome{
+			ExitCode: apiv1.RestoreConfigurationErrorExitCode,
+			Reason:   apiv1.ConditionReasonRestoreConfigurationError,
+		}
+
+	case isTransientRestoreError(err):
+		return RestoreOutcome{
+			ExitCode: apiv1.RestoreTransientFailureExitCode,
+			Reason:   apiv1.ConditionReasonRestoreTransientFailure,
+		}
+
+	default:
+		return RestoreOutcome{ExitCode: 1, Reason: apiv1.ConditionReasonRestoreFailed}
+	}
+}
+
+// isAnyRestoreError checks if the error matches one of the passed ones
+func isAnyRestoreError(err error, targets []error) bool {
+	for _, target := range targets {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTransientRestoreError checks if the error may not happen again
+// when the restore is retried, such as a network error while
+// downloading the base backup or while talking to the API server
+func isTransientRestoreError(err error) bool {
+	var barmanError *barman.CloudRestoreError
+	if errors.As(err, &barmanError) {
+		return barmanError.IsRetriable()
+	}
+
+	return errors.Is(err, ErrWALFetchProbeFailed) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
+// reportRestoreOutcome sets the RestoreCompleted condition to the outcome
+// of the restore. Errors are only logged, as they don't change the outcome
+func (info InitInfo) reportRestoreOutcome(ctx context.Context, typedClient client.Client, restoreErr error) {
+	outcome := GetRestoreOutcome(restoreErr)
+	condition := &metav1.Condition{
+		Type:    string(apiv1.ConditionRestoreCompleted),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(outcome.Reason),
+		Message: "The restore completed successfully",
+	}
+	if restoreErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Message = restoreErr.Error()
+	}
+
+	// The outcome is reported even when the restore
+	// has been interrupted by the cancellation of the context
+	reportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), restoreOutcomeReportTimeout)
+	defer cancel()
+
+	log.FromContext(ctx).Info("Reporting the outcome of the restore",
+		"reason", outcome.Reason,
+		"exitCode", outcome.ExitCode)
+	info.reportRestorePhaseCondition(reportCtx, typedClient, condition)
+}
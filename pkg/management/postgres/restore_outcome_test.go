@@ -0,0 +1,85 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("outcome of the restore", func() {
+	It("maps the errors to the exit codes of the restore job", func() {
+		Expect(GetRestoreOutcome(nil)).To(Equal(RestoreOutcome{
+			ExitCode: 0, Reason: apiv1.ConditionReasonRestoreSucceeded,
+		}))
+		Expect(GetRestoreOutcome(fmt.Errorf("in ConfigMap settings: %w", ErrInvalidRecoverySettings))).
+			To(Equal(RestoreOutcome{
+				ExitCode: apiv1.RestoreConfigurationErrorExitCode,
+				Reason:   apiv1.ConditionReasonRestoreConfigurationError,
+			}))
+		Expect(GetRestoreOutcome(&RestorePhaseTimeoutError{Phase: "recovery", Err: context.DeadlineExceeded})).
+			To(Equal(RestoreOutcome{
+				ExitCode: apiv1.RestoreTimeoutExitCode,
+				Reason:   apiv1.ConditionReasonRestoreTimedOut,
+			}))
+		Expect(GetRestoreOutcome(fmt.Errorf("%w: stopped at 0/3000000", ErrRecoveryTargetNotReached))).
+			To(Equal(RestoreOutcome{
+				ExitCode: apiv1.RestoreTargetNotReachedExitCode,
+				Reason:   apiv1.ConditionReasonRestoreTargetNotReached,
+			}))
+		Expect(GetRestoreOutcome(apierrors.NewServiceUnavailable("unavailable"))).
+			To(Equal(RestoreOutcome{
+				ExitCode: apiv1.RestoreTransientFailureExitCode,
+				Reason:   apiv1.ConditionReasonRestoreTransientFailure,
+			}))
+		Expect(GetRestoreOutcome(errors.New("unexpected"))).
+			To(Equal(RestoreOutcome{ExitCode: 1, Reason: apiv1.ConditionReasonRestoreFailed}))
+	})
+
+	It("reports the outcome in the cluster conditions", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+
+		info.reportRestoreOutcome(context.TODO(), typedClient, ErrInvalidServerName)
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		condition := meta.FindStatusCondition(result.Status.Conditions, string(apiv1.ConditionRestoreCompleted))
+		Expect(condition).ToNot(BeNil())
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonRestoreConfigurationError)))
+		Expect(condition.Message).To(Equal(ErrInvalidServerName.Error()))
+	})
+})
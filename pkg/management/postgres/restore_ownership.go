@@ -0,0 +1,116 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils/compatibility"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ensurePgDataOwnership makes sure that PGDATA, and the directory containing
+// it, are owned by the passed user and group and can be written by them.
+// When PGDATA is on a subPath of a volume, Kubernetes may have created these
+// directories owned by root, preventing barman-cloud-restore and PostgreSQL
+// from writing into them. Directories not existing yet are skipped, as they
+// will be created with the correct ownership
+func (info InitInfo) ensurePgDataOwnership(ctx context.Context, uid, gid int) error {
+	directories := []struct {
+		name string
+		// the mode the directory must have
+		mode os.FileMode
+		// when false, the mode is only required to contain the above permissions
+		exactMode bool
+	}{
+		{name: path.Dir(info.PgData), mode: 0o700},
+		{name: info.PgData, mode: 0o700, exactMode: true},
+	}
+
+	for _, directory := range directories {
+		stat, err := os.Stat(directory.name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("while checking directory %s: %w", directory.name, err)
+		}
+
+		if err := ensureDirectoryOwner(ctx, directory.name, uid, gid); err != nil {
+			return err
+		}
+
+		mode := stat.Mode().Perm()
+		targetMode := directory.mode
+		if !directory.exactMode {
+			targetMode |= mode
+		}
+		if mode == targetMode {
+			continue
+		}
+
+		log.FromContext(ctx).Info("Fixing the permissions of the PGDATA directory",
+			"directory", directory.name,
+			"mode", mode.String(),
+			"targetMode", targetMode.String())
+		if err := os.Chmod(directory.name, targetMode); err != nil {
+			return fmt.Errorf("while changing the permissions of %s: %w", directory.name, err)
+		}
+	}
+
+	return nil
+}
+
+// ensureDirectoryOwner changes the owner of a directory, unless it is
+// already the correct one. When the current process isn't allowed to
+// do it, but can write into the directory anyway, no error is raised
+func ensureDirectoryOwner(ctx context.Context, directory string, uid, gid int) error {
+	contextLogger := log.FromContext(ctx)
+
+	currentUID, currentGID, err := compatibility.GetOwnership(directory)
+	if err != nil {
+		return fmt.Errorf("while checking the owner of %s: %w", directory, err)
+	}
+	if currentUID == uid && currentGID == gid {
+		return nil
+	}
+
+	contextLogger.Info("Fixing the ownership of the PGDATA directory",
+		"directory", directory,
+		"uid", currentUID,
+		"gid", currentGID,
+		"targetUID", uid,
+		"targetGID", gid)
+
+	err = os.Chown(directory, uid, gid)
+	if err == nil {
+		return nil
+	}
+
+	if compatibility.IsWritable(directory) {
+		contextLogger.Warning("Cannot change the ownership of the PGDATA directory, "+
+			"proceeding as it is writable",
+			"directory", directory,
+			"error", err.Error())
+		return nil
+	}
+
+	return fmt.Errorf("while changing the ownership of %s to %d:%d: %w", directory, uid, gid, err)
+}
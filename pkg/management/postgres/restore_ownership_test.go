@@ -0,0 +1,67 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PGDATA ownership", func() {
+	var (
+		parent string
+		info   InitInfo
+	)
+
+	BeforeEach(func() {
+		parent = GinkgoT().TempDir()
+		info = InitInfo{PgData: path.Join(parent, "pgdata")}
+	})
+
+	It("skips a PGDATA not existing yet", func() {
+		Expect(os.Chmod(parent, 0o500)).To(Succeed())
+		DeferCleanup(func() {
+			Expect(os.Chmod(parent, 0o700)).To(Succeed())
+		})
+
+		Expect(info.ensurePgDataOwnership(context.TODO(), os.Getuid(), os.Getgid())).To(Succeed())
+		Expect(info.PgData).ToNot(BeADirectory())
+
+		stat, err := os.Stat(parent)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+	})
+
+	It("fixes the permissions of PGDATA leaving the ownership untouched when correct", func() {
+		Expect(os.Mkdir(info.PgData, 0o755)).To(Succeed())
+		Expect(os.Chmod(parent, 0o755)).To(Succeed())
+
+		Expect(info.ensurePgDataOwnership(context.TODO(), os.Getuid(), os.Getgid())).To(Succeed())
+
+		stat, err := os.Stat(info.PgData)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0o700)))
+
+		stat, err = os.Stat(parent)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0o755)))
+	})
+})
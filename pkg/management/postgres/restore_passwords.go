@@ -0,0 +1,196 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+	"github.com/sethvargo/go-password/password"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrPasswordResetRoleNotFound is raised when the password of a role
+// not existing in the restored instance is requested to be reset
+var ErrPasswordResetRoleNotFound = errors.New("the role whose password should be reset doesn't exist")
+
+// ErrPasswordResetSuperuser is raised when the password of a superuser
+// role is requested to be reset without allowing it explicitly
+var ErrPasswordResetSuperuser = errors.New("resetting the password of a superuser role is not allowed")
+
+// rolePasswordReset is the new password of a role
+// of the restored instance
+type rolePasswordReset struct {
+	name           string
+	password       string
+	randomized     bool
+	allowSuperuser bool
+}
+
+// getPasswordResets gets the password resets requested by the user,
+// if any. The passwords can't be changed in a replica cluster
+func getPasswordResets(cluster *apiv1.Cluster) []apiv1.RecoveryPasswordReset {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil || cluster.IsReplica() {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.PasswordResets
+}
+
+// loadPasswordResets gets the new passwords of the roles, reading them
+// from the referenced secrets or generating them randomly
+func (info InitInfo) loadPasswordResets(ctx context.Context, cluster *apiv1.Cluster) ([]rolePasswordReset, error) {
+	resets := getPasswordResets(cluster)
+	if len(resets) == 0 {
+		return nil, nil
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return info.resolvePasswordResets(ctx, typedClient, resets)
+}
+
+// resolvePasswordResets computes the new password of every role
+func (info InitInfo) resolvePasswordResets(
+	ctx context.Context,
+	typedClient client.Client,
+	resets []apiv1.RecoveryPasswordReset,
+) ([]rolePasswordReset, error) {
+	result := make([]rolePasswordReset, 0, len(resets))
+	for _, reset := range resets {
+		newPassword, err := info.getNewRolePassword(ctx, typedClient, reset)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, rolePasswordReset{
+			name:           reset.Name,
+			password:       newPassword,
+			randomized:     reset.Randomize,
+			allowSuperuser: reset.AllowSuperuser,
+		})
+	}
+
+	return result, nil
+}
+
+// getNewRolePassword gets the new password of a role
+func (info InitInfo) getNewRolePassword(
+	ctx context.Context,
+	typedClient client.Client,
+	reset apiv1.RecoveryPasswordReset,
+) (string, error) {
+	if reset.Randomize {
+		newPassword, err := password.Generate(64, 10, 0, false, true)
+		if err != nil {
+			return "", fmt.Errorf("while generating the new password of role %s: %w", reset.Name, err)
+		}
+		return newPassword, nil
+	}
+
+	if reset.PasswordSecret == nil {
+		return "", fmt.Errorf("missing the new password of role %s", reset.Name)
+	}
+
+	var secret corev1.Secret
+	if err := typedClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: info.Namespace, Name: reset.PasswordSecret.Name},
+		&secret,
+	); err != nil {
+		return "", fmt.Errorf("while getting the password secret %s of role %s: %w",
+			reset.PasswordSecret.Name, reset.Name, err)
+	}
+
+	newPassword, ok := secret.Data[reset.PasswordSecret.Key]
+	if !ok || len(newPassword) == 0 {
+		return "", fmt.Errorf("missing key %s, inside the password secret %s of role %s",
+			reset.PasswordSecret.Key, reset.PasswordSecret.Name, reset.Name)
+	}
+
+	return string(newPassword), nil
+}
+
+// applyPasswordResets changes the password of the roles in a single
+// transaction, so that either every password is reset or none is.
+// Only the names of the roles are logged, never their passwords
+func applyPasswordResets(ctx context.Context, db *sql.DB, resets []rolePasswordReset) error {
+	if len(resets) == 0 {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("while starting the transaction to reset the passwords: %w", err)
+	}
+	defer func() {
+		// This has no effect if the transaction
+		// is committed
+		_ = tx.Rollback()
+	}()
+
+	for _, reset := range resets {
+		var isSuperuser bool
+		err := tx.QueryRowContext(
+			ctx,
+			"SELECT rolsuper FROM pg_catalog.pg_roles WHERE rolname = $1",
+			reset.name,
+		).Scan(&isSuperuser)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %s", ErrPasswordResetRoleNotFound, reset.name)
+		}
+		if err != nil {
+			return fmt.Errorf("while looking up role %s: %w", reset.name, err)
+		}
+
+		if isSuperuser && !reset.allowSuperuser {
+			return fmt.Errorf("%w: %s", ErrPasswordResetSuperuser, reset.name)
+		}
+
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER ROLE %s WITH PASSWORD %s",
+			pgx.Identifier{reset.name}.Sanitize(),
+			pq.QuoteLiteral(reset.password))); err != nil {
+			return fmt.Errorf("while resetting the password of role %s: %w", reset.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("while committing the password resets: %w", err)
+	}
+
+	for _, reset := range resets {
+		contextLogger.Info("Password of the role has been reset",
+			"role", reset.name,
+			"randomized", reset.randomized)
+	}
+
+	return nil
+}
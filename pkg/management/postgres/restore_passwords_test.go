@@ -0,0 +1,142 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("password resets after the recovery", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	roleQuery := regexp.QuoteMeta("SELECT rolsuper FROM pg_catalog.pg_roles WHERE rolname = $1")
+
+	It("reads the new passwords from the secrets or generates them", func() {
+		info := InitInfo{Namespace: "dev"}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "new-passwords", Namespace: "dev"},
+				Data:       map[string][]byte{"app": []byte("n3w-p4ssw0rd")},
+			}).
+			Build()
+
+		resets, err := info.resolvePasswordResets(context.TODO(), typedClient, []apiv1.RecoveryPasswordReset{
+			{
+				Name: "app",
+				PasswordSecret: &apiv1.SecretKeySelector{
+					LocalObjectReference: apiv1.LocalObjectReference{Name: "new-passwords"},
+					Key:                  "app",
+				},
+			},
+			{Name: "reporting", Randomize: true},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resets).To(HaveLen(2))
+		Expect(resets[0].password).To(Equal("n3w-p4ssw0rd"))
+		Expect(resets[1].randomized).To(BeTrue())
+		Expect(resets[1].password).To(HaveLen(64))
+
+		_, err = info.resolvePasswordResets(context.TODO(), typedClient, []apiv1.RecoveryPasswordReset{
+			{
+				Name: "app",
+				PasswordSecret: &apiv1.SecretKeySelector{
+					LocalObjectReference: apiv1.LocalObjectReference{Name: "new-passwords"},
+					Key:                  "missing",
+				},
+			},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("resets the passwords quoting them", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(roleQuery).WithArgs("app").
+			WillReturnRows(sqlmock.NewRows([]string{"rolsuper"}).AddRow(false))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER ROLE "app" WITH PASSWORD 'it''s new'`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		Expect(applyPasswordResets(context.TODO(), db, []rolePasswordReset{
+			{name: "app", password: "it's new"},
+		})).To(Succeed())
+	})
+
+	It("fails when a role doesn't exist", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(roleQuery).WithArgs("missing").
+			WillReturnRows(sqlmock.NewRows([]string{"rolsuper"}))
+		mock.ExpectRollback()
+
+		Expect(applyPasswordResets(context.TODO(), db, []rolePasswordReset{
+			{name: "missing", password: "secret"},
+		})).To(MatchError(ErrPasswordResetRoleNotFound))
+	})
+
+	It("protects the superuser roles unless allowed", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(roleQuery).WithArgs("admin").
+			WillReturnRows(sqlmock.NewRows([]string{"rolsuper"}).AddRow(true))
+		mock.ExpectRollback()
+
+		Expect(applyPasswordResets(context.TODO(), db, []rolePasswordReset{
+			{name: "admin", password: "secret"},
+		})).To(MatchError(ErrPasswordResetSuperuser))
+	})
+
+	It("doesn't reset the passwords in a replica cluster", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				ReplicaCluster: &apiv1.ReplicaClusterConfiguration{Enabled: ptr.To(true), Source: "origin"},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:         "origin",
+						PasswordResets: []apiv1.RecoveryPasswordReset{{Name: "app", Randomize: true}},
+					},
+				},
+			},
+		}
+		Expect(getPasswordResets(cluster)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,180 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// recoveryPausePollInterval is the interval between two checks of
+// the status of a recovery paused at the recovery target
+const recoveryPausePollInterval = 5 * time.Second
+
+// getRecoveryPause gets the pause at the recovery target requested
+// by the user, if any
+func getRecoveryPause(cluster *apiv1.Cluster) *apiv1.RecoveryPause {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.PauseAtTarget
+}
+
+// recoveryTargetAction gets the action PostgreSQL will take once
+// the recovery target is reached
+func recoveryTargetAction(cluster *apiv1.Cluster) string {
+	if getRecoveryPause(cluster) != nil {
+		return "pause"
+	}
+
+	if isShutdownAtTarget(cluster) {
+		return "shutdown"
+	}
+
+	return "promote"
+}
+
+// recoveryPromotionChecker builds a function checking if the user
+// requested, via the cluster annotations, the promotion of an instance
+// paused at the recovery target
+func (info InitInfo) recoveryPromotionChecker() (func(ctx context.Context) (bool, error), error) {
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) (bool, error) {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return false, err
+		}
+
+		return utils.IsRecoveryPromotionRequested(&cluster.ObjectMeta), nil
+	}, nil
+}
+
+// waitForRecoveryPromotion waits for the WAL replay to be paused at the
+// recovery target and then for the promotion to be requested, promoting
+// the instance automatically when the timeout expires. If the instance is
+// promoted directly via SQL, nothing else is done. When a verification
+// is passed, it is executed once the WAL replay is paused: the instance is
+// promoted if it passes, while otherwise it stays paused until the
+// promotion is requested, regardless of the timeout
+func waitForRecoveryPromotion(
+	ctx context.Context,
+	db *sql.DB,
+	timeout time.Duration,
+	isPromotionRequested func(ctx context.Context) (bool, error),
+	verify func(ctx context.Context) error,
+	pollInterval time.Duration,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var pausedSince time.Time
+	var verificationFailed bool
+	for {
+		var inRecovery, paused bool
+		if err := db.QueryRowContext(
+			ctx,
+			"SELECT pg_catalog.pg_is_in_recovery(), "+
+				"pg_catalog.pg_is_in_recovery() AND pg_catalog.pg_is_wal_replay_paused()",
+		).Scan(&inRecovery, &paused); err != nil {
+			return fmt.Errorf("while checking if the WAL replay is paused: %w", err)
+		}
+
+		if !inRecovery {
+			contextLogger.Info("Recovery paused at the target has been ended by a manual promotion",
+				"promotion", "manual")
+			return nil
+		}
+
+		if paused && pausedSince.IsZero() {
+			pausedSince = time.Now()
+			contextLogger.Info("Recovery target reached, the WAL replay is paused waiting for the promotion",
+				"timeout", timeout.String(),
+				"annotation", utils.PromoteRecoveryAnnotationName)
+
+			if verify != nil {
+				if err := verify(ctx); err != nil {
+					verificationFailed = true
+					contextLogger.Error(err, "The instance paused at the recovery target didn't pass "+
+						"the verification, the WAL replay stays paused waiting for a manual promotion",
+						"annotation", utils.PromoteRecoveryAnnotationName)
+				} else {
+					contextLogger.Info("Verification passed, resuming the WAL replay",
+						"promotion", "verified")
+					return resumeWALReplay(ctx, db)
+				}
+			}
+		}
+
+		if !pausedSince.IsZero() {
+			requested, err := isPromotionRequested(ctx)
+			if err != nil {
+				contextLogger.Warning("Cannot check if the promotion has been requested, will retry",
+					"error", err.Error())
+			}
+
+			switch {
+			case requested:
+				contextLogger.Info("Promotion requested, resuming the WAL replay",
+					"promotion", "manual",
+					"pausedFor", time.Since(pausedSince).String())
+				return resumeWALReplay(ctx, db)
+
+			case !verificationFailed && time.Since(pausedSince) >= timeout:
+				contextLogger.Info("No promotion requested before the timeout, resuming the WAL replay",
+					"promotion", "automatic",
+					"timeout", timeout.String())
+				return resumeWALReplay(ctx, db)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// resumeWALReplay resumes a WAL replay paused at the recovery target,
+// which makes PostgreSQL promote the instance
+func resumeWALReplay(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, "SELECT pg_catalog.pg_wal_replay_resume()"); err != nil {
+		return fmt.Errorf("while resuming the WAL replay: %w", err)
+	}
+
+	return nil
+}
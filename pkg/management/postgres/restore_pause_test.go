@@ -0,0 +1,137 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("pause at the recovery target", func() {
+	const pauseQuery = "SELECT pg_catalog.pg_is_in_recovery\\(\\), " +
+		"pg_catalog.pg_is_in_recovery\\(\\) AND pg_catalog.pg_is_wal_replay_paused\\(\\)"
+
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	notRequested := func(context.Context) (bool, error) { return false, nil }
+
+	It("sets the recovery target action", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{},
+				},
+			},
+		}
+		Expect(recoveryTargetAction(cluster)).To(Equal("promote"))
+
+		cluster.Spec.Bootstrap.Recovery.PauseAtTarget = &apiv1.RecoveryPause{}
+		Expect(recoveryTargetAction(cluster)).To(Equal("pause"))
+
+		cluster.Spec.Bootstrap.Recovery.PauseAtTarget = nil
+		cluster.Spec.Bootstrap.Recovery.ShutdownAtTarget = true
+		Expect(recoveryTargetAction(cluster)).To(Equal("shutdown"))
+	})
+
+	It("resumes the WAL replay when the promotion is requested", func() {
+		mock.ExpectQuery(pauseQuery).WillReturnRows(
+			sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(true, false))
+		mock.ExpectQuery(pauseQuery).WillReturnRows(
+			sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(true, true))
+		mock.ExpectExec("SELECT pg_catalog.pg_wal_replay_resume\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		requested := func(context.Context) (bool, error) { return true, nil }
+		Expect(waitForRecoveryPromotion(context.TODO(), db, time.Hour, requested, nil, time.Millisecond)).To(Succeed())
+	})
+
+	It("resumes the WAL replay when the timeout expires", func() {
+		mock.ExpectQuery(pauseQuery).WillReturnRows(
+			sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(true, true))
+		mock.ExpectExec("SELECT pg_catalog.pg_wal_replay_resume\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(waitForRecoveryPromotion(context.TODO(), db, 0, notRequested, nil, time.Millisecond)).To(Succeed())
+	})
+
+	It("doesn't resume the WAL replay of an instance promoted manually", func() {
+		mock.ExpectQuery(pauseQuery).WillReturnRows(
+			sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(true, true))
+		mock.ExpectQuery(pauseQuery).WillReturnRows(
+			sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(false, false))
+
+		Expect(waitForRecoveryPromotion(context.TODO(), db, time.Hour, notRequested, nil, time.Millisecond)).To(Succeed())
+	})
+
+	It("resumes the WAL replay as soon as the verification passes", func() {
+		mock.ExpectQuery(pauseQuery).WillReturnRows(
+			sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(true, true))
+		mock.ExpectExec("SELECT pg_catalog.pg_wal_replay_resume\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		verifications := 0
+		verify := func(context.Context) error {
+			verifications++
+			return nil
+		}
+		Expect(waitForRecoveryPromotion(context.TODO(), db, time.Hour, notRequested, verify, time.Millisecond)).
+			To(Succeed())
+		Expect(verifications).To(Equal(1))
+	})
+
+	It("stays paused after a failed verification until the promotion is requested", func() {
+		for i := 0; i < 3; i++ {
+			mock.ExpectQuery(pauseQuery).WillReturnRows(
+				sqlmock.NewRows([]string{"in_recovery", "paused"}).AddRow(true, true))
+		}
+		mock.ExpectExec("SELECT pg_catalog.pg_wal_replay_resume\\(\\)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		checks := 0
+		requestedLater := func(context.Context) (bool, error) {
+			checks++
+			return checks == 3, nil
+		}
+		verify := func(context.Context) error {
+			return ErrRecoveryVerificationFailed
+		}
+		Expect(waitForRecoveryPromotion(context.TODO(), db, 0, requestedLater, verify, time.Millisecond)).
+			To(Succeed())
+		Expect(checks).To(Equal(3))
+	})
+})
@@ -0,0 +1,228 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils/compatibility"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// permissionSweepSummary counts the entries visited and
+// adjusted by the permission sweep of PGDATA
+type permissionSweepSummary struct {
+	entries         int
+	ownershipFixed  int
+	modeFixed       int
+	skippedSymlinks int
+}
+
+// isPermissionSweepRequested checks if the user asked for the permission
+// sweep of PGDATA at the end of the restore
+func isPermissionSweepRequested(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.PermissionSweep
+}
+
+// sweepRestoredPgData runs the permission sweep of PGDATA,
+// if requested, using the user and the group of the cluster
+func (info InitInfo) sweepRestoredPgData(ctx context.Context, cluster *apiv1.Cluster) error {
+	if !isPermissionSweepRequested(cluster) {
+		return nil
+	}
+
+	if err := info.sweepPgDataPermissions(
+		ctx, int(cluster.GetPostgresUID()), int(cluster.GetPostgresGID())); err != nil {
+		return fmt.Errorf("while sweeping the permissions of PGDATA: %w", err)
+	}
+
+	return nil
+}
+
+// getAllowedPermissions gets the permissions an entry of PGDATA may have,
+// following the group access setting of the data directory, as PostgreSQL
+// does: the group can read the data only when the data directory is
+// readable by the group
+func getAllowedPermissions(isDir, groupAccess bool) os.FileMode {
+	switch {
+	case isDir && groupAccess:
+		return 0o750
+	case isDir:
+		return 0o700
+	case groupAccess:
+		return 0o640
+	default:
+		return 0o600
+	}
+}
+
+// getSweptMode gets the mode an entry of PGDATA must have, granting the
+// owner the permissions PostgreSQL needs and removing the ones not allowed
+func getSweptMode(mode os.FileMode, isDir, groupAccess bool) os.FileMode {
+	ownerMode := os.FileMode(0o600)
+	if isDir {
+		ownerMode = 0o700
+	}
+
+	return (mode | ownerMode) & getAllowedPermissions(isDir, groupAccess)
+}
+
+// sweepPgDataPermissions walks PGDATA once every restore step has written
+// its files, before the instance is started, making every entry owned by
+// the passed user and group and giving it a mode accepted by the startup
+// checks of PostgreSQL. The symbolic links are never followed: the
+// locations of the tablespaces linked in pg_tblspc are swept on their own,
+// while the other links pointing outside PGDATA are skipped
+func (info InitInfo) sweepPgDataPermissions(ctx context.Context, uid, gid int) error {
+	contextLogger := log.FromContext(ctx)
+	startTime := time.Now()
+
+	stat, err := os.Stat(info.PgData)
+	if err != nil {
+		return fmt.Errorf("while checking the PGDATA directory: %w", err)
+	}
+	groupAccess := stat.Mode().Perm()&0o070 != 0
+
+	var summary permissionSweepSummary
+	tablespaceLocations, err := sweepDirectory(info.PgData, info.PgData, uid, gid, groupAccess, &summary)
+	if err != nil {
+		return err
+	}
+
+	for _, location := range tablespaceLocations {
+		if _, err := os.Stat(location); os.IsNotExist(err) {
+			// Dangling tablespace links are reported by checkTablespaceLinks
+			continue
+		}
+		if _, err := sweepDirectory(location, info.PgData, uid, gid, groupAccess, &summary); err != nil {
+			return err
+		}
+	}
+
+	contextLogger.Info("Swept the ownership and permissions of PGDATA",
+		"entries", summary.entries,
+		"ownershipFixed", summary.ownershipFixed,
+		"modeFixed", summary.modeFixed,
+		"skippedSymlinks", summary.skippedSymlinks,
+		"tablespaces", len(tablespaceLocations),
+		"duration", time.Since(startTime).String())
+
+	return nil
+}
+
+// sweepDirectory fixes the ownership and the mode of every entry of the
+// passed directory, including itself, returning the locations of the
+// tablespaces linked in the pg_tblspc directory of PGDATA
+func sweepDirectory(
+	root, pgData string,
+	uid, gid int,
+	groupAccess bool,
+	summary *permissionSweepSummary,
+) ([]string, error) {
+	linksDirectory := filepath.Join(pgData, tablespacesLinksDirectory)
+
+	var tablespaceLocations []string
+	err := filepath.WalkDir(root, func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("while sweeping %s: %w", name, err)
+		}
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			location, err := os.Readlink(name)
+			if err != nil {
+				return fmt.Errorf("while reading the link %s: %w", name, err)
+			}
+			if !filepath.IsAbs(location) {
+				location = filepath.Join(filepath.Dir(name), location)
+			}
+
+			switch {
+			case filepath.Dir(name) == linksDirectory:
+				tablespaceLocations = append(tablespaceLocations, location)
+			case !isPathInside(location, pgData):
+				summary.skippedSymlinks++
+			}
+			return nil
+		}
+
+		summary.entries++
+		return sweepEntry(name, entry, uid, gid, groupAccess, summary)
+	})
+
+	return tablespaceLocations, err
+}
+
+// sweepEntry fixes the ownership and the mode of a single entry. When the
+// current process isn't allowed to change the owner, but can write the
+// entry anyway, the ownership is left as it is
+func sweepEntry(
+	name string,
+	entry fs.DirEntry,
+	uid, gid int,
+	groupAccess bool,
+	summary *permissionSweepSummary,
+) error {
+	currentUID, currentGID, err := compatibility.GetOwnership(name)
+	if err != nil {
+		return fmt.Errorf("while checking the owner of %s: %w", name, err)
+	}
+	if currentUID != uid || currentGID != gid {
+		if err := os.Chown(name, uid, gid); err != nil {
+			if !compatibility.IsWritable(name) {
+				return fmt.Errorf("while changing the ownership of %s to %d:%d: %w", name, uid, gid, err)
+			}
+		} else {
+			summary.ownershipFixed++
+		}
+	}
+
+	info, err := entry.Info()
+	if err != nil {
+		return fmt.Errorf("while checking the permissions of %s: %w", name, err)
+	}
+	mode := info.Mode().Perm()
+	targetMode := getSweptMode(mode, entry.IsDir(), groupAccess)
+	if mode == targetMode {
+		return nil
+	}
+
+	if err := os.Chmod(name, targetMode); err != nil {
+		return fmt.Errorf("while changing the permissions of %s: %w", name, err)
+	}
+	summary.modeFixed++
+	return nil
+}
+
+// isPathInside checks if the passed path is the directory
+// or is contained in it
+func isPathInside(name, directory string) bool {
+	relative, err := filepath.Rel(directory, filepath.Clean(name))
+	if err != nil {
+		return false
+	}
+
+	return relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PGDATA permission sweep", func() {
+	var (
+		parent     string
+		tablespace string
+		outside    string
+		info       InitInfo
+	)
+
+	BeforeEach(func() {
+		parent = GinkgoT().TempDir()
+		tablespace = path.Join(parent, "tablespaces", "tbs", "data")
+		outside = path.Join(parent, "wal")
+		info = InitInfo{PgData: path.Join(parent, "pgdata")}
+
+		Expect(os.MkdirAll(path.Join(info.PgData, "base", "1"), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(info.PgData, tablespacesLinksDirectory), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(tablespace, 0o777)).To(Succeed())
+		Expect(os.MkdirAll(outside, 0o777)).To(Succeed())
+		Expect(os.Chmod(info.PgData, 0o700)).To(Succeed())
+		Expect(os.Chmod(tablespace, 0o777)).To(Succeed())
+		Expect(os.Chmod(outside, 0o777)).To(Succeed())
+		Expect(os.WriteFile(path.Join(info.PgData, "base", "1", "1259"), nil, 0o600)).To(Succeed())
+		Expect(os.Chmod(path.Join(info.PgData, "base", "1", "1259"), 0o666)).To(Succeed())
+		Expect(os.WriteFile(path.Join(tablespace, "PG_VERSION"), nil, 0o600)).To(Succeed())
+		Expect(os.Chmod(path.Join(tablespace, "PG_VERSION"), 0o444)).To(Succeed())
+		Expect(os.Symlink(tablespace, path.Join(info.PgData, tablespacesLinksDirectory, "16385"))).To(Succeed())
+		Expect(os.Symlink(outside, path.Join(info.PgData, "pg_wal"))).To(Succeed())
+	})
+
+	modeOf := func(name string) os.FileMode {
+		stat, err := os.Stat(name)
+		Expect(err).ToNot(HaveOccurred())
+		return stat.Mode().Perm()
+	}
+
+	It("fixes the modes of PGDATA and of the linked tablespaces", func() {
+		Expect(info.sweepPgDataPermissions(context.TODO(), os.Getuid(), os.Getgid())).To(Succeed())
+
+		Expect(modeOf(path.Join(info.PgData, "base", "1", "1259"))).To(Equal(os.FileMode(0o600)))
+		Expect(modeOf(tablespace)).To(Equal(os.FileMode(0o700)))
+		Expect(modeOf(path.Join(tablespace, "PG_VERSION"))).To(Equal(os.FileMode(0o600)))
+		Expect(modeOf(outside)).To(Equal(os.FileMode(0o777)))
+	})
+
+	It("allows the group access when the data directory grants it", func() {
+		Expect(os.Chmod(info.PgData, 0o750)).To(Succeed())
+
+		Expect(info.sweepPgDataPermissions(context.TODO(), os.Getuid(), os.Getgid())).To(Succeed())
+
+		Expect(modeOf(info.PgData)).To(Equal(os.FileMode(0o750)))
+		Expect(modeOf(path.Join(info.PgData, "base", "1", "1259"))).To(Equal(os.FileMode(0o640)))
+		Expect(modeOf(tablespace)).To(Equal(os.FileMode(0o750)))
+		Expect(modeOf(path.Join(tablespace, "PG_VERSION"))).To(Equal(os.FileMode(0o640)))
+	})
+
+	It("computes the modes accepted by PostgreSQL", func() {
+		Expect(getSweptMode(0o755, true, false)).To(Equal(os.FileMode(0o700)))
+		Expect(getSweptMode(0o500, true, true)).To(Equal(os.FileMode(0o700)))
+		Expect(getSweptMode(0o775, true, true)).To(Equal(os.FileMode(0o750)))
+		Expect(getSweptMode(0o400, false, false)).To(Equal(os.FileMode(0o600)))
+		Expect(getSweptMode(0o644, false, true)).To(Equal(os.FileMode(0o640)))
+	})
+
+	It("recognizes the paths inside a directory", func() {
+		Expect(isPathInside("/pgdata/base/1", "/pgdata")).To(BeTrue())
+		Expect(isPathInside("/pgdata", "/pgdata")).To(BeTrue())
+		Expect(isPathInside("/pgdata/../wal", "/pgdata")).To(BeFalse())
+		Expect(isPathInside("/pgdata-other", "/pgdata")).To(BeFalse())
+	})
+
+	It("is requested only through the recovery bootstrap", func() {
+		cluster := &apiv1.Cluster{}
+		Expect(isPermissionSweepRequested(cluster)).To(BeFalse())
+
+		cluster.Spec.Bootstrap = &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{PermissionSweep: true},
+		}
+		Expect(isPermissionSweepRequested(cluster)).To(BeTrue())
+	})
+})
@@ -0,0 +1,200 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/conditions"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrRestorePhaseTimedOut is raised when a phase of the restore
+// doesn't complete within its timeout
+var ErrRestorePhaseTimedOut = errors.New("restore phase timed out")
+
+// RestorePhaseTimeoutError is raised when a phase of the restore has
+// been aborted, as it didn't complete within its timeout
+type RestorePhaseTimeoutError struct {
+	// The name of the phase, as used in the cluster specification
+	Phase string
+
+	// The timeout of the phase
+	Timeout time.Duration
+
+	// The error returned by the aborted phase
+	Err error
+}
+
+// Error implements the error interface
+func (e *RestorePhaseTimeoutError) Error() string {
+	return fmt.Sprintf("the %s phase of the restore didn't complete within %s: %v",
+		e.Phase, e.Timeout, e.Err)
+}
+
+// Is makes the error match ErrRestorePhaseTimedOut
+func (e *RestorePhaseTimeoutError) Is(target error) bool {
+	return target == ErrRestorePhaseTimedOut
+}
+
+// Unwrap returns the error returned by the aborted phase
+func (e *RestorePhaseTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// restorePhase is a phase of the restore having its own timeout,
+// reported by a dedicated condition
+type restorePhase struct {
+	name      string
+	condition apiv1.ClusterConditionType
+	timeout   func(timeouts *apiv1.RecoveryPhaseTimeouts) *metav1.Duration
+}
+
+// restorePhases maps the states of the restore to the phases
+// whose timeout applies to them
+var restorePhases = map[apiv1.RestoreState]restorePhase{
+	apiv1.RestoreStateRestoreData: {
+		name:      "download",
+		condition: apiv1.ConditionRestoreDownloadTimedOut,
+		timeout: func(timeouts *apiv1.RecoveryPhaseTimeouts) *metav1.Duration {
+			return timeouts.Download
+		},
+	},
+	apiv1.RestoreStateWaitRecovery: {
+		name:      "recovery",
+		condition: apiv1.ConditionRestoreRecoveryTimedOut,
+		timeout: func(timeouts *apiv1.RecoveryPhaseTimeouts) *metav1.Duration {
+			return timeouts.Recovery
+		},
+	},
+	apiv1.RestoreStateConfigure: {
+		name:      "configuration",
+		condition: apiv1.ConditionRestoreConfigurationTimedOut,
+		timeout: func(timeouts *apiv1.RecoveryPhaseTimeouts) *metav1.Duration {
+			return timeouts.Configuration
+		},
+	},
+}
+
+// getRestorePhaseTimeout gets the timeout of the phase of the restore,
+// zero meaning that the phase can take any time
+func getRestorePhaseTimeout(cluster *apiv1.Cluster, phase restorePhase) time.Duration {
+	if cluster == nil || cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.PhaseTimeouts == nil {
+		return 0
+	}
+
+	timeout := phase.timeout(cluster.Spec.Bootstrap.Recovery.PhaseTimeouts)
+	if timeout == nil {
+		return 0
+	}
+
+	return timeout.Duration
+}
+
+// withRestorePhaseTimeouts wraps the transitions of the phases having a
+// timeout, aborting them when the timeout expires
+func withRestorePhaseTimeouts(
+	cluster *apiv1.Cluster,
+	transitions map[apiv1.RestoreState]restoreTransition,
+	reportCondition func(ctx context.Context, condition *metav1.Condition),
+) map[apiv1.RestoreState]restoreTransition {
+	for state, phase := range restorePhases {
+		transition, ok := transitions[state]
+		timeout := getRestorePhaseTimeout(cluster, phase)
+		if !ok || timeout <= 0 {
+			continue
+		}
+
+		transitions[state] = withRestorePhaseTimeout(phase, timeout, transition, reportCondition)
+	}
+
+	return transitions
+}
+
+// withRestorePhaseTimeout wraps a transition, aborting it via its context
+// when the timeout expires. The outcome is reported in the condition of the
+// phase, and an interrupted phase is identified by a RestorePhaseTimeoutError
+func withRestorePhaseTimeout(
+	phase restorePhase,
+	timeout time.Duration,
+	transition restoreTransition,
+	reportCondition func(ctx context.Context, condition *metav1.Condition),
+) restoreTransition {
+	return func(ctx context.Context) (apiv1.RestoreState, error) {
+		phaseCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		nextState, err := transition(phaseCtx)
+		if err != nil && ctx.Err() == nil && errors.Is(phaseCtx.Err(), context.DeadlineExceeded) {
+			err = &RestorePhaseTimeoutError{Phase: phase.name, Timeout: timeout, Err: err}
+			log.FromContext(ctx).Error(err, "Restore phase timed out",
+				"phase", phase.name,
+				"timeout", timeout.String())
+			reportCondition(ctx, &metav1.Condition{
+				Type:    string(phase.condition),
+				Status:  metav1.ConditionTrue,
+				Reason:  string(apiv1.ConditionReasonRestorePhaseTimedOut),
+				Message: err.Error(),
+			})
+			return "", err
+		}
+		if err != nil {
+			return "", err
+		}
+
+		reportCondition(ctx, &metav1.Condition{
+			Type:    string(phase.condition),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(apiv1.ConditionReasonRestorePhaseCompleted),
+			Message: fmt.Sprintf("The %s phase of the restore completed within %s", phase.name, timeout),
+		})
+		return nextState, nil
+	}
+}
+
+// reportRestorePhaseCondition updates the condition reporting the outcome
+// of a phase of the restore. Errors are only logged, as they don't affect
+// the restore
+func (info InitInfo) reportRestorePhaseCondition(
+	ctx context.Context,
+	typedClient client.Client,
+	condition *metav1.Condition,
+) {
+	var cluster apiv1.Cluster
+	err := typedClient.Get(
+		ctx,
+		client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+		&cluster,
+	)
+	if err == nil {
+		err = conditions.Patch(ctx, typedClient, &cluster, condition)
+	}
+	if err != nil {
+		log.FromContext(ctx).Warning("Cannot report the outcome of the restore phase",
+			"condition", condition.Type,
+			"reason", condition.Reason,
+			"error", err.Error())
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore phase timeouts", func() {
+	var reported []metav1.Condition
+
+	report := func(_ context.Context, condition *metav1.Condition) {
+		reported = append(reported, *condition)
+	}
+
+	newCluster := func(timeouts *apiv1.RecoveryPhaseTimeouts) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", PhaseTimeouts: timeouts},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		reported = nil
+	})
+
+	It("aborts a phase exceeding its timeout, reporting it", func() {
+		transitions := withRestorePhaseTimeouts(
+			newCluster(&apiv1.RecoveryPhaseTimeouts{Download: &metav1.Duration{Duration: 10 * time.Millisecond}}),
+			map[apiv1.RestoreState]restoreTransition{
+				apiv1.RestoreStateRestoreData: func(ctx context.Context) (apiv1.RestoreState, error) {
+					<-ctx.Done()
+					return "", ctx.Err()
+				},
+			},
+			report)
+
+		_, err := transitions[apiv1.RestoreStateRestoreData](context.TODO())
+		Expect(err).To(MatchError(ErrRestorePhaseTimedOut))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+		var timeoutError *RestorePhaseTimeoutError
+		Expect(errors.As(err, &timeoutError)).To(BeTrue())
+		Expect(timeoutError.Phase).To(Equal("download"))
+
+		Expect(reported).To(HaveLen(1))
+		Expect(reported[0].Type).To(Equal(string(apiv1.ConditionRestoreDownloadTimedOut)))
+		Expect(reported[0].Status).To(Equal(metav1.ConditionTrue))
+		Expect(reported[0].Reason).To(Equal(string(apiv1.ConditionReasonRestorePhaseTimedOut)))
+	})
+
+	It("reports the phases completed within their timeout", func() {
+		transitions := withRestorePhaseTimeouts(
+			newCluster(&apiv1.RecoveryPhaseTimeouts{Configuration: &metav1.Duration{Duration: time.Minute}}),
+			map[apiv1.RestoreState]restoreTransition{
+				apiv1.RestoreStateConfigure: func(ctx context.Context) (apiv1.RestoreState, error) {
+					_, hasDeadline := ctx.Deadline()
+					Expect(hasDeadline).To(BeTrue())
+					return apiv1.RestoreStateDone, nil
+				},
+			},
+			report)
+
+		Expect(transitions[apiv1.RestoreStateConfigure](context.TODO())).To(Equal(apiv1.RestoreStateDone))
+		Expect(reported).To(HaveLen(1))
+		Expect(reported[0].Type).To(Equal(string(apiv1.ConditionRestoreConfigurationTimedOut)))
+		Expect(reported[0].Status).To(Equal(metav1.ConditionFalse))
+		Expect(reported[0].Reason).To(Equal(string(apiv1.ConditionReasonRestorePhaseCompleted)))
+	})
+
+	It("leaves the phases without a timeout untouched", func() {
+		failure := errors.New("failure")
+		transitions := withRestorePhaseTimeouts(
+			newCluster(&apiv1.RecoveryPhaseTimeouts{Download: &metav1.Duration{Duration: time.Minute}}),
+			map[apiv1.RestoreState]restoreTransition{
+				apiv1.RestoreStateWaitRecovery: func(ctx context.Context) (apiv1.RestoreState, error) {
+					_, hasDeadline := ctx.Deadline()
+					Expect(hasDeadline).To(BeFalse())
+					return "", failure
+				},
+			},
+			report)
+
+		_, err := transitions[apiv1.RestoreStateWaitRecovery](context.TODO())
+		Expect(err).To(Equal(failure))
+		Expect(reported).To(BeEmpty())
+	})
+})
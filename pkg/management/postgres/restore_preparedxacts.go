@@ -0,0 +1,195 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// preparedTransactionsQuery lists the prepared transactions
+// of every database, the oldest first
+const preparedTransactionsQuery = "SELECT gid, database, owner, prepared " +
+	"FROM pg_catalog.pg_prepared_xacts ORDER BY prepared"
+
+// getRecoveryPreparedTransactions gets the handling of the prepared
+// transactions requested by the user, if any
+func getRecoveryPreparedTransactions(cluster *apiv1.Cluster) *apiv1.RecoveryPreparedTransactions {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.PreparedTransactions
+}
+
+// handleRestoredPreparedTransactions detects the prepared transactions of
+// the restored instance, reporting every one of them with a warning and in
+// the cluster status. They are resolved only when explicitly requested, and
+// never in a replica cluster, which is read-only
+func (info InitInfo) handleRestoredPreparedTransactions(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	preparedTransactions *apiv1.RecoveryPreparedTransactions,
+	db *sql.DB,
+	connect func(databaseName string) (*sql.DB, error),
+) error {
+	if preparedTransactions == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	action := preparedTransactions.GetAction()
+	if cluster.IsReplica() {
+		action = apiv1.RecoveryPreparedTransactionsWarn
+	}
+
+	transactions, err := listPreparedTransactions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("while listing the prepared transactions: %w", err)
+	}
+
+	if len(transactions) == 0 {
+		contextLogger.Info("No prepared transaction found in the restored instance")
+	}
+	for _, transaction := range transactions {
+		contextLogger.Warning("Found a prepared transaction in the restored instance, "+
+			"it holds its locks until it's committed or rolled back",
+			"gid", transaction.GID,
+			"database", transaction.Database,
+			"owner", transaction.Owner,
+			"prepared", transaction.Prepared,
+			"action", action)
+	}
+
+	if action != apiv1.RecoveryPreparedTransactionsWarn {
+		if err := resolvePreparedTransactions(ctx, connect, transactions, action); err != nil {
+			return err
+		}
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+
+	return info.reportPreparedTransactions(ctx, typedClient, &apiv1.PreparedTransactionsReport{
+		Action:       action,
+		Transactions: transactions,
+	})
+}
+
+// listPreparedTransactions lists the prepared transactions
+// of the restored instance, the oldest first
+func listPreparedTransactions(ctx context.Context, db *sql.DB) ([]apiv1.PreparedTransactionReport, error) {
+	rows, err := db.QueryContext(ctx, preparedTransactionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []apiv1.PreparedTransactionReport
+	for rows.Next() {
+		var transaction apiv1.PreparedTransactionReport
+		var prepared time.Time
+		if err := rows.Scan(&transaction.GID, &transaction.Database, &transaction.Owner, &prepared); err != nil {
+			return nil, err
+		}
+		transaction.Prepared = prepared.UTC().Format(time.RFC3339)
+		result = append(result, transaction)
+	}
+
+	return result, rows.Err()
+}
+
+// resolvePreparedTransactions commits or rolls back the passed prepared
+// transactions, each one from the database it has been prepared in, as
+// required by PostgreSQL
+func resolvePreparedTransactions(
+	ctx context.Context,
+	connect func(databaseName string) (*sql.DB, error),
+	transactions []apiv1.PreparedTransactionReport,
+	action apiv1.RecoveryPreparedTransactionsAction,
+) error {
+	statement := "ROLLBACK PREPARED"
+	if action == apiv1.RecoveryPreparedTransactionsCommit {
+		statement = "COMMIT PREPARED"
+	}
+
+	for _, transaction := range transactions {
+		db, err := connect(transaction.Database)
+		if err != nil {
+			return fmt.Errorf("could not connect to database %s: %w", transaction.Database, err)
+		}
+
+		if err := retryPostRecoveryWrite(ctx, "preparedTransactions", func() error {
+			_, err := db.ExecContext(ctx, fmt.Sprintf("%s %s", statement, pq.QuoteLiteral(transaction.GID)))
+			return err
+		}); err != nil {
+			return fmt.Errorf("while executing %s on transaction %s in database %s: %w",
+				strings.ToLower(statement), transaction.GID, transaction.Database, err)
+		}
+
+		log.FromContext(ctx).Info("Resolved a prepared transaction of the restored instance",
+			"gid", transaction.GID,
+			"database", transaction.Database,
+			"action", action)
+	}
+
+	return nil
+}
+
+// reportPreparedTransactions writes the prepared transactions of the
+// restored instance, and the action taken on them, in the cluster status
+func (info InitInfo) reportPreparedTransactions(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.PreparedTransactionsReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.PreparedTransactions = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the prepared transactions in the cluster status: %w", err)
+	}
+
+	return nil
+}
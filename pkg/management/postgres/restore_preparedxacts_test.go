@@ -0,0 +1,98 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("prepared transactions of the restored instance", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	connect := func(databaseName string) (*sql.DB, error) {
+		Expect(databaseName).To(Equal("app"))
+		return db, nil
+	}
+
+	transactions := []apiv1.PreparedTransactionReport{
+		{GID: "transfer-1", Database: "app", Owner: "app", Prepared: "2024-01-01T10:00:00Z"},
+		{GID: "o'brien", Database: "app", Owner: "app", Prepared: "2024-01-01T11:00:00Z"},
+	}
+
+	It("lists the prepared transactions, the oldest first", func() {
+		mock.ExpectQuery(regexp.QuoteMeta(preparedTransactionsQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"gid", "database", "owner", "prepared"}).
+				AddRow("transfer-1", "app", "app", time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)).
+				AddRow("o'brien", "app", "app", time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))))
+
+		Expect(listPreparedTransactions(context.TODO(), db)).To(Equal(transactions))
+	})
+
+	It("rolls back the prepared transactions in their database", func() {
+		mock.ExpectExec(regexp.QuoteMeta("ROLLBACK PREPARED 'transfer-1'")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("ROLLBACK PREPARED 'o''brien'")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(resolvePreparedTransactions(
+			context.TODO(), connect, transactions, apiv1.RecoveryPreparedTransactionsRollback)).To(Succeed())
+	})
+
+	It("commits the prepared transactions when requested", func() {
+		mock.ExpectExec(regexp.QuoteMeta("COMMIT PREPARED 'transfer-1'")).
+			WillReturnError(errors.New("permission denied"))
+
+		err := resolvePreparedTransactions(
+			context.TODO(), connect, transactions[:1], apiv1.RecoveryPreparedTransactionsCommit)
+		Expect(err).To(MatchError(ContainSubstring("commit prepared on transaction transfer-1 in database app")))
+	})
+
+	It("does nothing when the detection is not requested", func() {
+		cluster := &apiv1.Cluster{}
+		Expect(getRecoveryPreparedTransactions(cluster)).To(BeNil())
+		Expect(InitInfo{}.handleRestoredPreparedTransactions(
+			context.TODO(), cluster, nil, db, connect)).To(Succeed())
+	})
+
+	It("defaults to reporting the prepared transactions", func() {
+		Expect((&apiv1.RecoveryPreparedTransactions{}).GetAction()).To(Equal(apiv1.RecoveryPreparedTransactionsWarn))
+	})
+})
@@ -0,0 +1,294 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+const (
+	// replayedLSNQuery gets the last LSN replayed by the recovery
+	replayedLSNQuery = "SELECT pg_catalog.pg_last_wal_replay_lsn()"
+
+	// restoreResultQuery gets the last LSN replayed by the recovery
+	// and the size of the WAL segments, in bytes
+	restoreResultQuery = "SELECT pg_catalog.pg_last_wal_replay_lsn(), " +
+		"(SELECT setting::bigint FROM pg_catalog.pg_settings WHERE name = 'wal_segment_size')"
+)
+
+// replayProgress estimates how much of the WAL to be replayed by the
+// recovery has already been replayed. The percentage can be computed only
+// when the recovery target is an LSN, otherwise the raw LSN progress
+// is reported
+type replayProgress struct {
+	// the LSN from which the progress is measured
+	startLSN postgresSpec.LSN
+
+	// the LSN of the recovery target, if known
+	targetLSN postgresSpec.LSN
+
+	// writes the progress in the cluster status
+	report func(ctx context.Context, report *apiv1.RecoveryProgressReport) error
+}
+
+// newReplayProgress creates the estimation of the progress of a WAL replay
+// starting from the end of a base backup and ending at the recovery target.
+// When the end of the base backup is not known, the progress is measured
+// from the first LSN observed as replayed
+func newReplayProgress(
+	backupEndLSN string,
+	recoveryTarget *apiv1.RecoveryTarget,
+	report func(ctx context.Context, report *apiv1.RecoveryProgressReport) error,
+) *replayProgress {
+	progress := &replayProgress{report: report}
+
+	if _, err := postgresSpec.LSN(backupEndLSN).Parse(); err == nil {
+		progress.startLSN = postgresSpec.LSN(backupEndLSN)
+	}
+
+	if recoveryTarget != nil && recoveryTarget.TargetLSN != "" {
+		if _, err := postgresSpec.LSN(recoveryTarget.TargetLSN).Parse(); err == nil {
+			progress.targetLSN = postgresSpec.LSN(recoveryTarget.TargetLSN)
+		}
+	}
+
+	return progress
+}
+
+// newRecoveryReplayProgress creates the estimation of the progress of the
+// WAL replay of the recovery, reported in the cluster status. Nothing is
+// reported when the cluster is not being recovered from a backup
+func (info InitInfo) newRecoveryReplayProgress(cluster *apiv1.Cluster) (*replayProgress, error) {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil, nil
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return newReplayProgress(
+		info.BackupEndLSN,
+		getRequestedRecoveryTarget(cluster),
+		func(ctx context.Context, report *apiv1.RecoveryProgressReport) error {
+			info.RestoreStatus.recordProgress(report)
+			return info.reportRecoveryProgress(ctx, typedClient, report)
+		},
+	), nil
+}
+
+// newReport computes the progress of the WAL replay given the last
+// replayed LSN
+func (p *replayProgress) newReport(replayedLSN postgresSpec.LSN) *apiv1.RecoveryProgressReport {
+	if p.startLSN == "" {
+		p.startLSN = replayedLSN
+	}
+
+	report := &apiv1.RecoveryProgressReport{
+		StartLSN:    string(p.startLSN),
+		TargetLSN:   string(p.targetLSN),
+		ReplayedLSN: string(replayedLSN),
+	}
+
+	// Both the LSNs have been validated already
+	start, _ := p.startLSN.Parse()
+	replayed, _ := replayedLSN.Parse()
+	if replayed > start {
+		report.ReplayedBytes = replayed - start
+	}
+
+	if p.targetLSN != "" {
+		target, _ := p.targetLSN.Parse()
+		report.PercentReplayed = ptr.To(computeReplayPercentage(start, target, replayed))
+	}
+
+	return report
+}
+
+// computeReplayPercentage computes the percentage of the WAL between the
+// start and the target LSNs that has been replayed, rounded down
+func computeReplayPercentage(start, target, replayed int64) int32 {
+	switch {
+	case replayed >= target:
+		return 100
+	case replayed <= start:
+		return 0
+	}
+
+	return int32((replayed - start) * 100 / (target - start))
+}
+
+// update reads the last replayed LSN and reports the progress of the
+// WAL replay. Errors are only logged, as they don't affect the recovery
+func (p *replayProgress) update(ctx context.Context, db *sql.DB) {
+	contextLogger := log.FromContext(ctx)
+
+	var replayedLSN sql.NullString
+	if err := db.QueryRowContext(ctx, replayedLSNQuery).Scan(&replayedLSN); err != nil {
+		contextLogger.Warning("Cannot get the last LSN replayed by the recovery", "error", err.Error())
+		return
+	}
+	if !replayedLSN.Valid {
+		return
+	}
+	if _, err := postgresSpec.LSN(replayedLSN.String).Parse(); err != nil {
+		contextLogger.Warning("Cannot parse the last LSN replayed by the recovery", "error", err.Error())
+		return
+	}
+
+	report := p.newReport(postgresSpec.LSN(replayedLSN.String))
+	contextLogger.Info("WAL replay progress",
+		"startLSN", report.StartLSN,
+		"targetLSN", report.TargetLSN,
+		"replayedLSN", report.ReplayedLSN,
+		"replayedBytes", report.ReplayedBytes,
+		"percentReplayed", report.PercentReplayed)
+
+	if err := p.report(ctx, report); err != nil {
+		contextLogger.Warning("Cannot report the progress of the WAL replay", "error", err.Error())
+	}
+}
+
+// newResult summarizes the WAL replay given the last replayed LSN and
+// the size of the WAL segments. Every segment containing a replayed
+// WAL record is counted, including a partially replayed one
+func (p *replayProgress) newResult(replayedLSN postgresSpec.LSN, walSegmentSize int64) *apiv1.RestoreResult {
+	report := p.newReport(replayedLSN)
+	result := &apiv1.RestoreResult{
+		WALReplayed:   report.ReplayedBytes > 0,
+		ReplayedBytes: report.ReplayedBytes,
+		StartLSN:      report.StartLSN,
+		EndLSN:        report.ReplayedLSN,
+	}
+
+	if result.WALReplayed && walSegmentSize > 0 {
+		// Both the LSNs have been validated already
+		start, _ := p.startLSN.Parse()
+		replayed, _ := replayedLSN.Parse()
+		result.ReplayedWALSegments = replayed/walSegmentSize - start/walSegmentSize + 1
+	}
+
+	return result
+}
+
+// complete reads the last LSN replayed by the recovery, once it is
+// completed, and summarizes the WAL replay
+func (p *replayProgress) complete(ctx context.Context, db *sql.DB) (*apiv1.RestoreResult, error) {
+	var replayedLSN sql.NullString
+	var walSegmentSize int64
+	if err := db.QueryRowContext(ctx, restoreResultQuery).Scan(&replayedLSN, &walSegmentSize); err != nil {
+		return nil, fmt.Errorf("while getting the last LSN replayed by the recovery: %w", err)
+	}
+
+	// No WAL record has been replayed at all
+	if !replayedLSN.Valid {
+		return &apiv1.RestoreResult{StartLSN: string(p.startLSN)}, nil
+	}
+
+	if _, err := postgresSpec.LSN(replayedLSN.String).Parse(); err != nil {
+		return nil, fmt.Errorf("while parsing the last LSN replayed by the recovery: %w", err)
+	}
+
+	return p.newResult(postgresSpec.LSN(replayedLSN.String), walSegmentSize), nil
+}
+
+// completeRestoreResult logs the summary of the WAL replay and
+// reports it in the cluster status
+func (info InitInfo) completeRestoreResult(ctx context.Context, result *apiv1.RestoreResult) error {
+	log.FromContext(ctx).Info("WAL replay completed",
+		"walReplayed", result.WALReplayed,
+		"replayedWALSegments", result.ReplayedWALSegments,
+		"replayedBytes", result.ReplayedBytes,
+		"startLSN", result.StartLSN,
+		"endLSN", result.EndLSN,
+		"targetReached", result.TargetReached)
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+
+	return info.reportRestoreResult(ctx, typedClient, result)
+}
+
+// reportRestoreResult writes the summary of the WAL replay
+// in the cluster status
+func (info InitInfo) reportRestoreResult(
+	ctx context.Context,
+	typedClient client.Client,
+	result *apiv1.RestoreResult,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RestoreResult = result
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the result of the restore in the cluster status: %w", err)
+	}
+
+	return nil
+}
+
+// reportRecoveryProgress writes the progress of the WAL replay
+// in the cluster status
+func (info InitInfo) reportRecoveryProgress(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.RecoveryProgressReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RecoveryProgress = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the progress of the WAL replay in the cluster status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("estimating the progress of the WAL replay", func() {
+	var reports []*apiv1.RecoveryProgressReport
+	recordReport := func(_ context.Context, report *apiv1.RecoveryProgressReport) error {
+		reports = append(reports, report)
+		return nil
+	}
+
+	BeforeEach(func() {
+		reports = nil
+	})
+
+	It("computes the percentage of the replayed WAL", func() {
+		parse := func(lsn string) int64 {
+			result, err := postgresSpec.LSN(lsn).Parse()
+			Expect(err).ToNot(HaveOccurred())
+			return result
+		}
+		start := parse("0/3000100")
+		target := parse("0/7000100")
+
+		Expect(computeReplayPercentage(start, target, parse("0/3000100"))).To(BeEquivalentTo(0))
+		Expect(computeReplayPercentage(start, target, parse("0/4000100"))).To(BeEquivalentTo(25))
+		Expect(computeReplayPercentage(start, target, parse("0/5000100"))).To(BeEquivalentTo(50))
+		Expect(computeReplayPercentage(start, target, parse("0/6FFFFFF"))).To(BeEquivalentTo(99))
+		Expect(computeReplayPercentage(start, target, parse("0/7000100"))).To(BeEquivalentTo(100))
+		Expect(computeReplayPercentage(start, target, parse("0/8000000"))).To(BeEquivalentTo(100))
+		Expect(computeReplayPercentage(start, target, parse("0/2000000"))).To(BeEquivalentTo(0))
+
+		// Crossing the boundary of the first half of the LSN
+		Expect(computeReplayPercentage(parse("1/C0000000"), parse("2/40000000"), parse("2/0"))).
+			To(BeEquivalentTo(50))
+	})
+
+	It("reports the percentage when the recovery target is an LSN", func() {
+		progress := newReplayProgress("0/3000100", &apiv1.RecoveryTarget{TargetLSN: "0/7000100"}, recordReport)
+
+		Expect(progress.newReport("0/5000100")).To(Equal(&apiv1.RecoveryProgressReport{
+			StartLSN:        "0/3000100",
+			TargetLSN:       "0/7000100",
+			ReplayedLSN:     "0/5000100",
+			ReplayedBytes:   0x2000000,
+			PercentReplayed: ptr.To(int32(50)),
+		}))
+	})
+
+	It("reports the raw LSN progress when the target is not known in advance", func() {
+		progress := newReplayProgress("", &apiv1.RecoveryTarget{TargetTime: "2024-01-01 10:00:00"}, recordReport)
+
+		Expect(progress.newReport("0/3000000")).To(Equal(&apiv1.RecoveryProgressReport{
+			StartLSN:    "0/3000000",
+			ReplayedLSN: "0/3000000",
+		}))
+		Expect(progress.newReport("0/3800000")).To(Equal(&apiv1.RecoveryProgressReport{
+			StartLSN:      "0/3000000",
+			ReplayedLSN:   "0/3800000",
+			ReplayedBytes: 0x800000,
+		}))
+	})
+
+	It("updates the progress while waiting for the end of the recovery", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+		policy := restoreRetryPolicy{initialBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+		progress := newReplayProgress("0/3000100", &apiv1.RecoveryTarget{TargetLSN: "0/7000100"}, recordReport)
+
+		mock.ExpectQuery(`SELECT pg_is_in_recovery\(\)$`).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta(replayedLSNQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_last_wal_replay_lsn"}).AddRow(nil))
+		mock.ExpectQuery(`SELECT pg_is_in_recovery\(\)$`).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(true))
+		mock.ExpectQuery(regexp.QuoteMeta(replayedLSNQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_last_wal_replay_lsn"}).AddRow("0/4000100"))
+		mock.ExpectQuery(`SELECT pg_is_in_recovery\(\)$`).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(false))
+		mock.ExpectQuery(`SELECT pg_is_in_recovery\(\), current_setting`).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery", "current_setting"}).AddRow(false, "off"))
+
+		Expect(waitUntilRecoveryFinishes(context.TODO(), db, policy, progress, nil, nil, nil)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+		Expect(reports).To(HaveLen(1))
+		Expect(reports[0].PercentReplayed).To(Equal(ptr.To(int32(25))))
+	})
+
+	It("reports the progress in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		report := &apiv1.RecoveryProgressReport{StartLSN: "0/3000000", ReplayedLSN: "0/3800000", ReplayedBytes: 0x800000}
+
+		Expect(info.reportRecoveryProgress(context.TODO(), typedClient, report)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.RecoveryProgress).To(Equal(report))
+	})
+
+	It("summarizes the WAL replayed after the end of the base backup", func() {
+		progress := newReplayProgress("0/3000100", nil, recordReport)
+
+		Expect(progress.newResult("0/5000100", 16*1024*1024)).To(Equal(&apiv1.RestoreResult{
+			WALReplayed:         true,
+			ReplayedWALSegments: 3,
+			ReplayedBytes:       0x2000000,
+			StartLSN:            "0/3000100",
+			EndLSN:              "0/5000100",
+		}))
+		Expect(progress.newResult("0/3000100", 16*1024*1024)).To(Equal(&apiv1.RestoreResult{
+			StartLSN: "0/3000100",
+			EndLSN:   "0/3000100",
+		}))
+	})
+
+	It("reads the summary of the WAL replay once the recovery is completed", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+		progress := newReplayProgress("0/3000100", nil, recordReport)
+
+		mock.ExpectQuery(regexp.QuoteMeta(restoreResultQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_last_wal_replay_lsn", "setting"}).
+				AddRow("0/3800000", 16*1024*1024))
+		result, err := progress.complete(context.TODO(), db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.WALReplayed).To(BeTrue())
+		Expect(result.ReplayedWALSegments).To(BeEquivalentTo(1))
+
+		mock.ExpectQuery(regexp.QuoteMeta(restoreResultQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_last_wal_replay_lsn", "setting"}).
+				AddRow(nil, 16*1024*1024))
+		result, err = progress.complete(context.TODO(), db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result).To(Equal(&apiv1.RestoreResult{StartLSN: "0/3000100"}))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
@@ -0,0 +1,184 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+)
+
+// redoDoneMessagePrefix is the prefix of the message logged by
+// PostgreSQL once every WAL record has been replayed, before the
+// instance is promoted
+const redoDoneMessagePrefix = "redo done at "
+
+// ErrPromotionFailed is raised when the WAL replay is completed, but
+// PostgreSQL couldn't promote the instance
+var ErrPromotionFailed = errors.New("the WAL replay is completed, but the instance couldn't be promoted")
+
+// promotionFailureCollector is a log record writer detecting the errors
+// reported by PostgreSQL after the end of the WAL replay, which prevent
+// the instance from being promoted, while forwarding every record to
+// another writer
+type promotionFailureCollector struct {
+	writer logpipe.RecordWriter
+
+	mu       sync.Mutex
+	redoDone bool
+	failure  string
+}
+
+// newPromotionFailureCollector creates a collector forwarding the log
+// records to the passed writer, or to the instance manager logger
+// when it is nil
+func newPromotionFailureCollector(writer logpipe.RecordWriter) *promotionFailureCollector {
+	if writer == nil {
+		writer = &logpipe.LogRecordWriter{}
+	}
+
+	return &promotionFailureCollector{writer: writer}
+}
+
+// Write implements the logpipe.RecordWriter interface
+func (collector *promotionFailureCollector) Write(record logpipe.NamedRecord) {
+	collector.writer.Write(record)
+
+	loggingRecord, ok := record.(*logpipe.LoggingRecord)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(loggingRecord.Message, redoDoneMessagePrefix):
+		collector.redoDone = true
+
+	case collector.redoDone && collector.failure == "" &&
+		(loggingRecord.ErrorSeverity == "FATAL" || loggingRecord.ErrorSeverity == "PANIC"):
+		collector.failure = loggingRecord.Message
+		if loggingRecord.Detail != "" {
+			collector.failure += ": " + loggingRecord.Detail
+		}
+	}
+}
+
+// isRedoDone checks if PostgreSQL reported that every WAL
+// record has been replayed
+func (collector *promotionFailureCollector) isRedoDone() bool {
+	if collector == nil {
+		return false
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	return collector.redoDone
+}
+
+// promotionError gets the error preventing the promotion reported by
+// PostgreSQL, if any
+func (collector *promotionFailureCollector) promotionError() error {
+	if collector == nil {
+		return nil
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	if collector.failure == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", ErrPromotionFailed, collector.failure)
+}
+
+// reset forgets the WAL replay observed until now, before PostgreSQL
+// is started again
+func (collector *promotionFailureCollector) reset() {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.redoDone = false
+	collector.failure = ""
+}
+
+// getRecoveryPromotionRetry gets how the failed promotions are
+// retried, as requested by the user, if any
+func getRecoveryPromotionRetry(cluster *apiv1.Cluster) *apiv1.RecoveryPromotionRetry {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.PromotionRetry
+}
+
+// retryFailedPromotion executes the passed function, which starts
+// PostgreSQL and waits for the end of the recovery, until the instance
+// is promoted. When the promotion fails, it is retried by executing the
+// function again after the requested interval, which gives the time to
+// fix the cause of the failure, such as an archive that can't be written.
+// PostgreSQL, restarted on the same data directory, completes the recovery
+// and tries to promote the instance again
+func retryFailedPromotion(
+	ctx context.Context,
+	promotionRetry *apiv1.RecoveryPromotionRetry,
+	promotion *promotionFailureCollector,
+	fn func() error,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		// PostgreSQL stops once the promotion fails, so the error
+		// raised while waiting is usually a connection error
+		if promotionErr := promotion.promotionError(); promotionErr != nil && !errors.Is(err, ErrPromotionFailed) {
+			err = fmt.Errorf("%w (%v)", promotionErr, err)
+		}
+		if !errors.Is(err, ErrPromotionFailed) || promotionRetry == nil ||
+			attempt > int(promotionRetry.MaxRetries) {
+			return err
+		}
+
+		interval := promotionRetry.GetInterval()
+		contextLogger.Warning("The promotion of the restored instance failed, will retry",
+			"error", err.Error(),
+			"retry", attempt,
+			"maxRetries", promotionRetry.MaxRetries,
+			"interval", interval.String())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		promotion.reset()
+	}
+}
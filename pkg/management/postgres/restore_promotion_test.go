@@ -0,0 +1,103 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Promotion failures", func() {
+	It("detects only the errors following the end of the WAL replay", func() {
+		writer := &recordingWriter{}
+		collector := newPromotionFailureCollector(writer)
+
+		collector.Write(&logpipe.LoggingRecord{ErrorSeverity: "FATAL", Message: "the database system is starting up"})
+		Expect(collector.isRedoDone()).To(BeFalse())
+		Expect(collector.promotionError()).ToNot(HaveOccurred())
+
+		collector.Write(&logpipe.LoggingRecord{ErrorSeverity: "LOG", Message: "redo done at 0/3000148"})
+		Expect(collector.isRedoDone()).To(BeTrue())
+		Expect(collector.promotionError()).ToNot(HaveOccurred())
+
+		collector.Write(&logpipe.LoggingRecord{
+			ErrorSeverity: "PANIC",
+			Message:       "could not create file \"pg_wal/xlogtemp.42\"",
+			Detail:        "No space left on device",
+		})
+		Expect(collector.promotionError()).To(MatchError(ErrPromotionFailed))
+		Expect(collector.promotionError().Error()).To(ContainSubstring("No space left on device"))
+		Expect(writer.records).To(HaveLen(3))
+
+		collector.reset()
+		Expect(collector.isRedoDone()).To(BeFalse())
+		Expect(collector.promotionError()).ToNot(HaveOccurred())
+	})
+
+	It("retries a failed promotion the requested number of times", func() {
+		collector := newPromotionFailureCollector(&recordingWriter{})
+		promotionRetry := &apiv1.RecoveryPromotionRetry{
+			MaxRetries: 2,
+			Interval:   &metav1.Duration{Duration: time.Millisecond},
+		}
+
+		var attempts int
+		failPromotion := func() error {
+			attempts++
+			collector.Write(&logpipe.LoggingRecord{Message: "redo done at 0/3000148"})
+			collector.Write(&logpipe.LoggingRecord{ErrorSeverity: "FATAL", Message: "archive is read-only"})
+			return errors.New("connection refused")
+		}
+		Expect(retryFailedPromotion(context.TODO(), promotionRetry, collector, failPromotion)).
+			To(MatchError(ErrPromotionFailed))
+		Expect(attempts).To(Equal(3))
+
+		attempts = 0
+		Expect(retryFailedPromotion(context.TODO(), nil, collector, failPromotion)).
+			To(MatchError(ErrPromotionFailed))
+		Expect(attempts).To(Equal(1))
+
+		attempts = 0
+		Expect(retryFailedPromotion(context.TODO(), promotionRetry, collector, func() error {
+			attempts++
+			if attempts == 1 {
+				return failPromotion()
+			}
+			return nil
+		})).To(Succeed())
+	})
+
+	It("doesn't retry the restore failing for other reasons", func() {
+		collector := newPromotionFailureCollector(&recordingWriter{})
+		var attempts int
+		Expect(retryFailedPromotion(context.TODO(), &apiv1.RecoveryPromotionRetry{MaxRetries: 3}, collector,
+			func() error {
+				attempts++
+				return ErrInstanceReadOnly
+			})).To(MatchError(ErrInstanceReadOnly))
+		Expect(attempts).To(Equal(1))
+	})
+})
@@ -0,0 +1,84 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrInvalidPromotionSlot is raised when the restored instance already
+// contains a replication slot with the name requested by the user,
+// which can't be used by the physical standbys
+var ErrInvalidPromotionSlot = errors.New("cannot use the promotion replication slot")
+
+// getRecoveryPromotionSlot gets the name of the physical replication
+// slot to be created in the restored instance, if any
+func getRecoveryPromotionSlot(cluster *apiv1.Cluster) string {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return ""
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.PromotionSlot
+}
+
+// createPromotionSlot creates a physical replication slot, reserving the
+// WAL immediately. This works on a standby too, so that the slot is ready
+// when the instance is promoted. An existing physical slot with the same
+// name is left untouched
+func createPromotionSlot(ctx context.Context, db *sql.DB, slotName string) error {
+	if slotName == "" {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx).WithValues("slot", slotName)
+
+	var slotType string
+	err := db.QueryRowContext(
+		ctx,
+		"SELECT slot_type FROM pg_catalog.pg_replication_slots WHERE slot_name = $1",
+		slotName,
+	).Scan(&slotType)
+	switch {
+	case err == nil && slotType == "physical":
+		contextLogger.Info("The promotion replication slot already exists")
+		return nil
+
+	case err == nil:
+		return fmt.Errorf("%w: slot %s already exists and is a %s slot",
+			ErrInvalidPromotionSlot, slotName, slotType)
+
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("while checking the promotion replication slot %s: %w", slotName, err)
+	}
+
+	if _, err := db.ExecContext(
+		ctx,
+		"SELECT pg_catalog.pg_create_physical_replication_slot(slot_name => $1, immediately_reserve => true)",
+		slotName,
+	); err != nil {
+		return fmt.Errorf("while creating the promotion replication slot %s: %w", slotName, err)
+	}
+
+	contextLogger.Info("Created the promotion replication slot")
+	return nil
+}
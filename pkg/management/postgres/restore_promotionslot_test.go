@@ -0,0 +1,91 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("promotion replication slot", func() {
+	const (
+		slotTypeQuery   = `SELECT slot_type FROM pg_catalog.pg_replication_slots WHERE slot_name = \$1`
+		createSlotQuery = `SELECT pg_catalog.pg_create_physical_replication_slot\(` +
+			`slot_name => \$1, immediately_reserve => true\)`
+	)
+
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+	})
+
+	It("gets the slot requested by the user", func() {
+		Expect(getRecoveryPromotionSlot(&apiv1.Cluster{})).To(BeEmpty())
+		Expect(getRecoveryPromotionSlot(&apiv1.Cluster{Spec: apiv1.ClusterSpec{
+			Bootstrap: &apiv1.BootstrapConfiguration{
+				Recovery: &apiv1.BootstrapRecovery{PromotionSlot: "downstream"},
+			},
+		}})).To(Equal("downstream"))
+	})
+
+	It("does nothing when no slot is requested", func() {
+		Expect(createPromotionSlot(context.TODO(), db, "")).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("creates the slot reserving the WAL", func() {
+		mock.ExpectQuery(slotTypeQuery).WithArgs("downstream").
+			WillReturnRows(sqlmock.NewRows([]string{"slot_type"}))
+		mock.ExpectExec(createSlotQuery).WithArgs("downstream").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		Expect(createPromotionSlot(context.TODO(), db, "downstream")).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("leaves an existing physical slot untouched", func() {
+		mock.ExpectQuery(slotTypeQuery).WithArgs("downstream").
+			WillReturnRows(sqlmock.NewRows([]string{"slot_type"}).AddRow("physical"))
+
+		Expect(createPromotionSlot(context.TODO(), db, "downstream")).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("fails when a logical slot with the same name exists", func() {
+		mock.ExpectQuery(slotTypeQuery).WithArgs("downstream").
+			WillReturnRows(sqlmock.NewRows([]string{"slot_type"}).AddRow("logical"))
+
+		Expect(createPromotionSlot(context.TODO(), db, "downstream")).To(MatchError(ErrInvalidPromotionSlot))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
@@ -0,0 +1,74 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"net/url"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// withRecoveryProxy sets, in the environment used by the barman-cloud
+// commands of the restore, the proxy requested by the user. The variables
+// are set both in upper and lower case, as some HTTP clients prefer the
+// latter, and replace the ones inherited from the instance manager
+// environment. The variables not set in the cluster are inherited
+func withRecoveryProxy(cluster *apiv1.Cluster, env []string) []string {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Proxy == nil {
+		return env
+	}
+
+	proxy := cluster.Spec.Bootstrap.Recovery.Proxy
+	log.Info("Using a proxy to reach the object store",
+		"httpsProxy", redactProxyURL(proxy.HTTPSProxy),
+		"httpProxy", redactProxyURL(proxy.HTTPProxy),
+		"noProxy", proxy.NoProxy)
+
+	variables := []struct {
+		name  string
+		value string
+	}{
+		{name: "HTTPS_PROXY", value: proxy.HTTPSProxy},
+		{name: "HTTP_PROXY", value: proxy.HTTPProxy},
+		{name: "NO_PROXY", value: strings.Join(proxy.NoProxy, ",")},
+	}
+
+	result := env
+	for _, variable := range variables {
+		if variable.value == "" {
+			continue
+		}
+		result = setEnvValue(result, variable.name, variable.value)
+		result = setEnvValue(result, strings.ToLower(variable.name), variable.value)
+	}
+
+	return result
+}
+
+// redactProxyURL hides the password contained in the URL of a proxy,
+// so that it can be logged
+func redactProxyURL(value string) string {
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+
+	return proxyURL.Redacted()
+}
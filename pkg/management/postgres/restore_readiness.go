@@ -0,0 +1,141 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrRestoreNotReady is raised when the readiness query didn't
+// return true before its timeout expired
+var ErrRestoreNotReady = errors.New("the restored instance didn't pass the readiness check")
+
+// errRestoreReadinessPending is raised when the readiness
+// query didn't return true yet
+var errRestoreReadinessPending = errors.New("the readiness query didn't return true")
+
+// getRecoveryReadinessCheck gets the readiness query requested
+// by the user, if any
+func getRecoveryReadinessCheck(cluster *apiv1.Cluster) *apiv1.RecoveryReadinessCheck {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.ReadinessCheck
+}
+
+// readinessCheckDatabase gets the database where the readiness query is executed
+func (info InitInfo) readinessCheckDatabase(check *apiv1.RecoveryReadinessCheck) string {
+	switch {
+	case check.Database != "":
+		return check.Database
+	case info.ApplicationDatabase != "":
+		return info.ApplicationDatabase
+	default:
+		return "postgres"
+	}
+}
+
+// waitUntilRestoreReady executes the readiness query on the restored
+// instance until it returns true, using the backoff of the restore retry
+// policy. Any failure of the query is retried, as the objects it checks
+// may not exist yet, and the restore fails when the timeout expires
+func (info InitInfo) waitUntilRestoreReady(
+	ctx context.Context,
+	check *apiv1.RecoveryReadinessCheck,
+	policy restoreRetryPolicy,
+	connect func(dbname string) (*sql.DB, error),
+) error {
+	if check == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	database := info.readinessCheckDatabase(check)
+	timeout := check.GetTimeout()
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// The error reported is the last one not caused by
+	// the expiration of the timeout, if any
+	attempts := 0
+	var lastErr error
+	isRetriable := func(error) bool {
+		return true
+	}
+	err := policy.withUnlimitedAttempts().retry(checkCtx, "wait for the readiness query", isRetriable, func() error {
+		attempts++
+		err := checkRestoreReadiness(checkCtx, check, database, connect)
+		if err != nil && checkCtx.Err() == nil {
+			lastErr = err
+		}
+		return err
+	})
+	if err == nil {
+		contextLogger.Info("The restored instance passed the readiness check",
+			"database", database,
+			"attempts", attempts)
+		return nil
+	}
+
+	if ctx.Err() != nil {
+		return err
+	}
+	if lastErr != nil {
+		err = lastErr
+	}
+
+	return fmt.Errorf("%w: the query on database %s didn't return true within %s, after %d attempts: %v",
+		ErrRestoreNotReady, database, timeout, attempts, err)
+}
+
+// checkRestoreReadiness executes the readiness query once, returning
+// errRestoreReadinessPending when it doesn't return true
+func checkRestoreReadiness(
+	ctx context.Context,
+	check *apiv1.RecoveryReadinessCheck,
+	database string,
+	connect func(dbname string) (*sql.DB, error),
+) error {
+	db, err := connect(database)
+	if err != nil {
+		return fmt.Errorf("while connecting to database %s: %w", database, err)
+	}
+
+	var ready sql.NullBool
+	if err := db.QueryRowContext(ctx, check.Query).Scan(&ready); err != nil {
+		return fmt.Errorf("while executing the readiness query: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Checking if the restored instance is ready",
+		"database", database,
+		"ready", ready.Bool,
+		"null", !ready.Valid)
+
+	if !ready.Valid || !ready.Bool {
+		return errRestoreReadinessPending
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("readiness check of the restored instance", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	const readinessQuery = "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = '20240101')"
+
+	policy := restoreRetryPolicy{initialBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("waits until the query returns true", func() {
+		info := InitInfo{ApplicationDatabase: "app"}
+		var databases []string
+		connect := func(dbname string) (*sql.DB, error) {
+			databases = append(databases, dbname)
+			return db, nil
+		}
+
+		mock.ExpectQuery(regexp.QuoteMeta(readinessQuery)).
+			WillReturnError(errors.New(`relation "schema_migrations" does not exist`))
+		mock.ExpectQuery(regexp.QuoteMeta(readinessQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(regexp.QuoteMeta(readinessQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		Expect(info.waitUntilRestoreReady(context.TODO(), &apiv1.RecoveryReadinessCheck{Query: readinessQuery},
+			policy, connect)).To(Succeed())
+		Expect(databases).To(Equal([]string{"app", "app", "app"}))
+	})
+
+	It("fails when the query doesn't return true within the timeout", func() {
+		info := InitInfo{}
+		connect := func(string) (*sql.DB, error) {
+			return nil, errors.New("connection refused")
+		}
+
+		err := info.waitUntilRestoreReady(context.TODO(), &apiv1.RecoveryReadinessCheck{
+			Query:   readinessQuery,
+			Timeout: &metav1.Duration{Duration: 50 * time.Millisecond},
+		}, policy, connect)
+		Expect(err).To(MatchError(ErrRestoreNotReady))
+		Expect(err.Error()).To(ContainSubstring("database postgres"))
+		Expect(err.Error()).To(ContainSubstring("connection refused"))
+	})
+
+	It("treats a NULL result as not ready", func() {
+		mock.ExpectQuery(regexp.QuoteMeta(readinessQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(nil))
+		Expect(checkRestoreReadiness(context.TODO(), &apiv1.RecoveryReadinessCheck{Query: readinessQuery}, "app",
+			func(string) (*sql.DB, error) { return db, nil })).To(MatchError(errRestoreReadinessPending))
+	})
+
+	It("does nothing when no readiness check is requested", func() {
+		Expect(InitInfo{}.waitUntilRestoreReady(context.TODO(), nil, policy, nil)).To(Succeed())
+	})
+})
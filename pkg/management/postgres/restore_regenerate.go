@@ -0,0 +1,124 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+var (
+	// ErrDataDirectoryInUse is raised when the recovery configuration is
+	// regenerated while a PostgreSQL server is running on the data directory
+	ErrDataDirectoryInUse = errors.New("a PostgreSQL server is running on the data directory")
+
+	// ErrInvalidDataDirectory is raised when the recovery configuration is
+	// regenerated in a directory not containing a PostgreSQL data directory
+	ErrInvalidDataDirectory = errors.New("the directory doesn't contain a valid PostgreSQL data directory")
+)
+
+// RegenerateRecoveryConfig writes the configuration needed to recover the
+// passed backup in an existing PGDATA, for example one copied manually,
+// without downloading the base backup. When requested, the initial
+// PostgreSQL configuration is written again too. PGDATA is never touched
+// while a PostgreSQL server is running on it
+func (info InitInfo) RegenerateRecoveryConfig(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	writeInitialConf bool,
+) error {
+	if err := info.checkRegenerationDataDir(postgresName); err != nil {
+		return err
+	}
+
+	recoverySettings, err := loadRecoverySettings(ctx, typedClient, cluster)
+	if err != nil {
+		return err
+	}
+
+	walSource, err := findWALSource(cluster)
+	if err != nil {
+		return err
+	}
+
+	return info.regenerateRecoveryConfig(
+		ctx, cluster, withWALSource(backup, walSource), recoverySettings, writeInitialConf)
+}
+
+// checkRegenerationDataDir checks that PGDATA contains a PostgreSQL data
+// directory, and that no server, whose executable is one of the passed
+// ones, is running on it. A stale PID file is removed
+func (info InitInfo) checkRegenerationDataDir(postgresExecutables ...string) error {
+	if err := checkDataDirStructure(info.PgData); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidDataDirectory, info.PgData, err)
+	}
+
+	process, err := info.GetInstance().CheckForExistingPostmaster(postgresExecutables...)
+	if err != nil {
+		return fmt.Errorf("while checking for a PostgreSQL server running on %s: %w", info.PgData, err)
+	}
+	if process != nil {
+		return fmt.Errorf("%w: %s, PID %d", ErrDataDirectoryInUse, info.PgData, process.Pid)
+	}
+
+	return nil
+}
+
+// regenerateRecoveryConfig writes the recovery configuration in PGDATA,
+// which has already been checked
+func (info InitInfo) regenerateRecoveryConfig(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	recoverySettings map[string]string,
+	writeInitialConf bool,
+) error {
+	contextLogger := log.FromContext(ctx)
+	contextLogger.Info("Regenerating the recovery configuration",
+		"pgdata", info.PgData,
+		"backupID", backup.Status.BackupID,
+		"initialConfiguration", writeInitialConf)
+
+	if writeInitialConf {
+		if err := info.WriteInitialPostgresqlConf(cluster); err != nil {
+			return err
+		}
+
+		if _, err := info.GetInstance().migratePostgresAutoConfFile(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := info.writeRestoreWalConfig(backup, cluster, recoverySettings); err != nil {
+		return err
+	}
+
+	if err := info.writeRecoverySignal(cluster); err != nil {
+		return err
+	}
+
+	contextLogger.Info("Recovery configuration regenerated", "pgdata", info.PgData)
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/mitchellh/go-ps"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("regeneration of the recovery configuration", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+		Expect(os.MkdirAll(path.Join(info.PgData, "global"), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(info.PgData, "base"), 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(info.PgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(info.PgData, "global", "pg_control"), []byte("control"), 0o600)).To(Succeed())
+	})
+
+	writePidFile := func(pid int) {
+		Expect(os.WriteFile(
+			path.Join(info.PgData, PostgresqlPidFile),
+			[]byte(fmt.Sprintf("%d\n%s\n", pid, info.PgData)),
+			0o600)).To(Succeed())
+	}
+
+	It("accepts a data directory no server is running on", func() {
+		Expect(info.checkRegenerationDataDir(postgresName)).To(Succeed())
+	})
+
+	It("rejects a directory not containing a data directory", func() {
+		Expect(os.Remove(path.Join(info.PgData, "global", "pg_control"))).To(Succeed())
+		Expect(info.checkRegenerationDataDir(postgresName)).To(MatchError(ErrInvalidDataDirectory))
+	})
+
+	It("rejects a data directory with a running server", func() {
+		process, err := ps.FindProcess(os.Getpid())
+		Expect(err).ToNot(HaveOccurred())
+		writePidFile(os.Getpid())
+
+		Expect(info.checkRegenerationDataDir(process.Executable())).To(MatchError(ErrDataDirectoryInUse))
+		Expect(path.Join(info.PgData, PostgresqlPidFile)).To(BeAnExistingFile())
+	})
+
+	It("removes a stale PID file", func() {
+		writePidFile(os.Getpid())
+
+		Expect(info.checkRegenerationDataDir(postgresName)).To(Succeed())
+		Expect(path.Join(info.PgData, PostgresqlPidFile)).ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,82 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"strconv"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// getRecoveryReplayThrottle gets the limit to the rate of the
+// WAL replay requested by the user, if any
+func getRecoveryReplayThrottle(cluster *apiv1.Cluster) *apiv1.RecoveryReplayThrottle {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.ReplayThrottle
+}
+
+// withReplayThrottle makes the restore_command pause after having fetched
+// a WAL file, limiting the rate of the WAL replay. As the pause is part of
+// the restore_command of the recovery, it is removed together with it
+// once the recovery is completed
+func (info InitInfo) withReplayThrottle(cmd restoreCommand, cluster *apiv1.Cluster) (restoreCommand, error) {
+	throttle := getRecoveryReplayThrottle(cluster)
+	if throttle == nil || throttle.MaxRate.Sign() <= 0 {
+		return cmd, nil
+	}
+
+	walSegmentSize, err := info.getWALSegmentSize()
+	if err != nil {
+		return restoreCommand{}, err
+	}
+
+	delay := replayThrottleDelay(walSegmentSize, throttle.MaxRate.Value())
+	log.Info("Throttling the WAL replay",
+		"maxRate", throttle.MaxRate.String(),
+		"walSegmentSize", walSegmentSize,
+		"delay", delay.String())
+
+	cmd.throttleDelay = delay
+	return cmd, nil
+}
+
+// replayThrottleDelay computes the pause to be made after every WAL
+// file not to replay more than maxRate bytes per second
+func replayThrottleDelay(walSegmentSize, maxRate int64) time.Duration {
+	if maxRate <= 0 {
+		return 0
+	}
+
+	return time.Duration(float64(walSegmentSize) / float64(maxRate) * float64(time.Second))
+}
+
+// renderReplayThrottle appends the pause to the passed fetch of a WAL
+// file. The pause is skipped when the WAL file can't be fetched, not to
+// delay the end of the recovery, and when it is shorter than a millisecond
+func (cmd restoreCommand) renderReplayThrottle(fetch []string) []string {
+	if cmd.throttleDelay < time.Millisecond {
+		return fetch
+	}
+
+	return append(append([]string{}, fetch...),
+		"&&", "sleep", strconv.FormatFloat(cmd.throttleDelay.Seconds(), 'f', 3, 64))
+}
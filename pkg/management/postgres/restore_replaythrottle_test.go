@@ -0,0 +1,60 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAL replay throttle", func() {
+	const walSegmentSize = 16 * 1024 * 1024
+
+	It("computes the pause needed not to exceed the maximum rate", func() {
+		Expect(replayThrottleDelay(walSegmentSize, 32*1024*1024)).To(Equal(500 * time.Millisecond))
+		Expect(replayThrottleDelay(walSegmentSize, 8*1024*1024)).To(Equal(2 * time.Second))
+		Expect(replayThrottleDelay(walSegmentSize, 0)).To(BeZero())
+	})
+
+	It("pauses the restore_command only after having fetched a WAL file", func() {
+		cmd := restoreCommand{fetch: []string{"cp"}, arguments: []string{"/archive/%f", "%p"}}
+		cmd.throttleDelay = 500 * time.Millisecond
+		Expect(cmd.render()).To(Equal("cp /archive/%f %p && sleep 0.500"))
+	})
+
+	It("doesn't pause for less than a millisecond", func() {
+		cmd := restoreCommand{fetch: []string{"cp"}, arguments: []string{"/archive/%f", "%p"}}
+		cmd.throttleDelay = time.Microsecond
+		Expect(cmd.render()).To(Equal("cp /archive/%f %p"))
+	})
+
+	It("doesn't throttle the WAL replay by default", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{Recovery: &apiv1.BootstrapRecovery{Source: "origin"}},
+			},
+		}
+		cmd, err := InitInfo{}.withReplayThrottle(
+			restoreCommand{fetch: []string{"cp"}, arguments: []string{"/archive/%f", "%p"}}, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmd.render()).To(Equal("cp /archive/%f %p"))
+	})
+})
@@ -0,0 +1,73 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// getRecoveryReplicationSettings gets the replication settings
+// requested by the user, if any
+func getRecoveryReplicationSettings(cluster *apiv1.Cluster) *apiv1.RecoveryReplicationSettings {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.ReplicationSettings
+}
+
+// configureRestoredReplication writes the replication settings in the
+// override.conf file of the restored instance, which uses them once it
+// becomes a replica. The values supplied by the user take precedence over
+// the generated ones, and nothing is written when the user manages the
+// replication settings: the restored instance, being a primary, doesn't
+// need them anyway
+func (info InitInfo) configureRestoredReplication(ctx context.Context, cluster *apiv1.Cluster) error {
+	contextLogger := log.FromContext(ctx)
+
+	primaryConnInfo := info.GetPrimaryConnInfo()
+	slotName := cluster.GetSlotNameFromInstanceName(info.PodName)
+
+	if settings := getRecoveryReplicationSettings(cluster); settings != nil {
+		if settings.Skip {
+			contextLogger.Info("Skipping the replication settings, as they are managed by the user",
+				"filename", constants.PostgresqlOverrideConfigurationFile)
+			return nil
+		}
+
+		if settings.PrimaryConnInfo != "" {
+			primaryConnInfo = settings.PrimaryConnInfo
+		}
+		if settings.PrimarySlotName != "" {
+			slotName = settings.PrimarySlotName
+		}
+		contextLogger.Info("Using the replication settings supplied by the user",
+			"customPrimaryConnInfo", settings.PrimaryConnInfo != "",
+			"primarySlotName", slotName)
+	}
+
+	if _, err := configurePostgresOverrideConfFile(info.PgData, primaryConnInfo, slotName); err != nil {
+		return fmt.Errorf("while configuring replica: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("replication settings of the restored instance", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir(), ClusterName: "clone", PodName: "clone-1"}
+		Expect(os.WriteFile(path.Join(info.PgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+	})
+
+	newCluster := func(settings *apiv1.RecoveryReplicationSettings) *apiv1.Cluster {
+		return &apiv1.Cluster{Spec: apiv1.ClusterSpec{Bootstrap: &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{Source: "origin", ReplicationSettings: settings},
+		}}}
+	}
+
+	readOverrideConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	It("writes the replication settings generated by the operator", func() {
+		Expect(info.configureRestoredReplication(context.TODO(), newCluster(nil))).To(Succeed())
+		Expect(readOverrideConf()).To(ContainSubstring("primary_conninfo = '" + info.GetPrimaryConnInfo() + "'"))
+	})
+
+	It("prefers the replication settings supplied by the user", func() {
+		Expect(info.configureRestoredReplication(context.TODO(), newCluster(&apiv1.RecoveryReplicationSettings{
+			PrimaryConnInfo: "host=upstream user=replicator",
+			PrimarySlotName: "clone_slot",
+		}))).To(Succeed())
+
+		content := readOverrideConf()
+		Expect(content).To(ContainSubstring("primary_conninfo = 'host=upstream user=replicator'"))
+		Expect(content).To(ContainSubstring("primary_slot_name = 'clone_slot'"))
+	})
+
+	It("doesn't touch the replication settings when they are managed by the user", func() {
+		overrideConf := path.Join(info.PgData, constants.PostgresqlOverrideConfigurationFile)
+		Expect(os.WriteFile(overrideConf, []byte("primary_conninfo = 'host=mine'\n"), 0o600)).To(Succeed())
+
+		Expect(info.configureRestoredReplication(context.TODO(),
+			newCluster(&apiv1.RecoveryReplicationSettings{Skip: true}))).To(Succeed())
+		Expect(readOverrideConf()).To(Equal("primary_conninfo = 'host=mine'\n"))
+	})
+})
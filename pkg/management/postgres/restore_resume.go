@@ -0,0 +1,135 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// recoveryArtifacts are the files that PostgreSQL removes from PGDATA
+// when the recovery is completed
+var recoveryArtifacts = []string{
+	"recovery.signal",
+	recoveryConfFile,
+	constants.BackupLabelFile,
+}
+
+// IsRecoveryInterrupted checks if PGDATA contains a base backup that has
+// been restored by a previous execution of the restore job, whose recovery
+// was started and not completed. In this case, the recovery can be resumed
+// without restoring the base backup again
+func (info InitInfo) IsRecoveryInterrupted() (bool, error) {
+	markerExists, err := fileutils.FileExists(path.Join(info.PgData, constants.RestoreMarker))
+	if err != nil || !markerExists {
+		return false, err
+	}
+
+	for _, name := range recoveryArtifacts {
+		exists, err := fileutils.FileExists(path.Join(info.PgData, name))
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// IsRestoreResumable checks if PGDATA contains the result of an interrupted
+// restore that can be resumed, either because the recovery was interrupted
+// or because the configuration of the recovered instance, recorded in the
+// cluster status, was interrupted. In this case the data directory must
+// be left untouched
+func (info InitInfo) IsRestoreResumable(ctx context.Context) (bool, error) {
+	interrupted, err := info.IsRecoveryInterrupted()
+	if err != nil || interrupted {
+		return interrupted, err
+	}
+
+	markerExists, err := fileutils.FileExists(path.Join(info.PgData, constants.RestoreMarker))
+	if err != nil || !markerExists {
+		return false, err
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return false, err
+	}
+
+	cluster, err := info.loadCluster(ctx, typedClient)
+	if err != nil {
+		return false, err
+	}
+
+	resumeState, err := info.getRestoreResumeState(cluster.Status.RestoreState)
+	if err != nil {
+		return false, err
+	}
+
+	return resumeState != apiv1.RestoreStateRestoreData, nil
+}
+
+// getRestoreResumeState gets the state from which the restore must be
+// continued once the backup is loaded, given the content of PGDATA and
+// the last state recorded in the cluster status. An interrupted recovery
+// is always resumed, while the configuration of the recovered instance is
+// resumed only if it was the last state entered. Otherwise, the base
+// backup is restored from scratch
+func (info InitInfo) getRestoreResumeState(lastState apiv1.RestoreState) (apiv1.RestoreState, error) {
+	interrupted, err := info.IsRecoveryInterrupted()
+	if err != nil {
+		return "", fmt.Errorf("while checking for an interrupted recovery: %w", err)
+	}
+	if interrupted {
+		return apiv1.RestoreStateWaitRecovery, nil
+	}
+
+	if lastState != apiv1.RestoreStateConfigure {
+		return apiv1.RestoreStateRestoreData, nil
+	}
+
+	markerExists, err := fileutils.FileExists(path.Join(info.PgData, constants.RestoreMarker))
+	if err != nil {
+		return "", fmt.Errorf("while checking for an interrupted restore: %w", err)
+	}
+	if markerExists {
+		return apiv1.RestoreStateConfigure, nil
+	}
+
+	return apiv1.RestoreStateRestoreData, nil
+}
+
+// writeRestoreMarker marks PGDATA as containing a restored base backup
+// whose recovery is ready to be started
+func (info InitInfo) writeRestoreMarker(backupID string) error {
+	_, err := fileutils.WriteStringToFile(path.Join(info.PgData, constants.RestoreMarker), backupID)
+	return err
+}
+
+// removeRestoreMarker marks the restore of PGDATA as completed
+func (info InitInfo) removeRestoreMarker() error {
+	return fileutils.RemoveFile(path.Join(info.PgData, constants.RestoreMarker))
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("interrupted recovery detection", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+	})
+
+	touch := func(name string) {
+		Expect(os.WriteFile(path.Join(info.PgData, name), nil, 0o600)).To(Succeed())
+	}
+
+	It("doesn't detect a recovery that never started", func() {
+		touch(constants.BackupLabelFile)
+		touch("recovery.signal")
+		Expect(info.IsRecoveryInterrupted()).To(BeFalse())
+	})
+
+	It("doesn't detect a recovery that has been completed", func() {
+		Expect(info.writeRestoreMarker("20240520T101010")).To(Succeed())
+		Expect(info.IsRecoveryInterrupted()).To(BeFalse())
+	})
+
+	It("detects a recovery that has been interrupted", func() {
+		Expect(info.writeRestoreMarker("20240520T101010")).To(Succeed())
+		touch("recovery.signal")
+		Expect(info.IsRecoveryInterrupted()).To(BeTrue())
+
+		Expect(info.removeRestoreMarker()).To(Succeed())
+		Expect(info.IsRecoveryInterrupted()).To(BeFalse())
+	})
+
+	It("resumes an interrupted recovery whatever the recorded state", func() {
+		Expect(info.writeRestoreMarker("20240520T101010")).To(Succeed())
+		touch("recovery.signal")
+		Expect(info.getRestoreResumeState("")).To(Equal(apiv1.RestoreStateWaitRecovery))
+		Expect(info.getRestoreResumeState(apiv1.RestoreStateWriteConfig)).To(Equal(apiv1.RestoreStateWaitRecovery))
+	})
+
+	It("resumes an interrupted configuration of the recovered instance", func() {
+		Expect(info.writeRestoreMarker("20240520T101010")).To(Succeed())
+		Expect(info.getRestoreResumeState(apiv1.RestoreStateConfigure)).To(Equal(apiv1.RestoreStateConfigure))
+		Expect(info.getRestoreResumeState(apiv1.RestoreStateWaitRecovery)).To(Equal(apiv1.RestoreStateRestoreData))
+
+		Expect(info.removeRestoreMarker()).To(Succeed())
+		Expect(info.getRestoreResumeState(apiv1.RestoreStateConfigure)).To(Equal(apiv1.RestoreStateRestoreData))
+	})
+
+	It("restores the base backup when there is nothing to be resumed", func() {
+		Expect(info.getRestoreResumeState("")).To(Equal(apiv1.RestoreStateRestoreData))
+		Expect(info.getRestoreResumeState(apiv1.RestoreStateDone)).To(Equal(apiv1.RestoreStateRestoreData))
+	})
+})
@@ -0,0 +1,169 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/resources"
+)
+
+const (
+	// defaultRestoreRetryMaxAttempts is the default number of attempts of
+	// the restore operations, which are not retried
+	defaultRestoreRetryMaxAttempts = 1
+
+	// defaultRestoreRetryInitialBackoff is the default time waited before
+	// retrying a restore operation, and the default interval between two
+	// checks of the end of the recovery
+	defaultRestoreRetryInitialBackoff = 5 * time.Second
+)
+
+// restoreRetryPolicy is the policy used to retry the restore
+// operations that can fail temporarily
+type restoreRetryPolicy struct {
+	// the maximum number of attempts, zero meaning unlimited
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	// the fraction of random time added to every backoff
+	jitter float64
+}
+
+// getRestoreRetryPolicy gets the restore retry policy requested by the
+// user, applying the defaults to the missing settings
+func getRestoreRetryPolicy(cluster *apiv1.Cluster) restoreRetryPolicy {
+	policy := restoreRetryPolicy{
+		maxAttempts:    defaultRestoreRetryMaxAttempts,
+		initialBackoff: defaultRestoreRetryInitialBackoff,
+	}
+
+	var configuration *apiv1.RestoreRetryPolicy
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil {
+		configuration = cluster.Spec.Bootstrap.Recovery.RetryPolicy
+	}
+	if configuration == nil {
+		policy.maxBackoff = policy.initialBackoff
+		return policy
+	}
+
+	if configuration.MaxAttempts != nil {
+		policy.maxAttempts = int(*configuration.MaxAttempts)
+	}
+	if configuration.InitialBackoff != nil {
+		policy.initialBackoff = configuration.InitialBackoff.Duration
+	}
+	policy.maxBackoff = policy.initialBackoff
+	if configuration.MaxBackoff != nil {
+		policy.maxBackoff = configuration.MaxBackoff.Duration
+	}
+	if configuration.JitterPercent != nil {
+		policy.jitter = float64(*configuration.JitterPercent) / 100
+	}
+
+	return policy
+}
+
+// withUnlimitedAttempts returns a copy of the policy retrying the operation
+// until it succeeds, used to wait for operations which can legitimately
+// take a long time
+func (policy restoreRetryPolicy) withUnlimitedAttempts() restoreRetryPolicy {
+	policy.maxAttempts = 0
+	return policy
+}
+
+// backoff computes the time to wait after the passed failed
+// attempt, starting from 1
+func (policy restoreRetryPolicy) backoff(attempt int) time.Duration {
+	backoff := policy.initialBackoff
+	for i := 1; i < attempt && backoff < policy.maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.maxBackoff {
+		backoff = policy.maxBackoff
+	}
+
+	if policy.jitter > 0 {
+		// #nosec G404 the jitter doesn't need a secure random number generator
+		backoff += time.Duration(rand.Float64() * policy.jitter * float64(backoff))
+	}
+
+	return backoff
+}
+
+// retry executes the passed function until it succeeds, it returns an
+// error which is not retriable, or the maximum number of attempts is
+// reached. The last error is returned
+func (policy restoreRetryPolicy) retry(
+	ctx context.Context,
+	operation string,
+	isRetriable func(error) bool,
+	fn func() error,
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isRetriable(err) {
+			return err
+		}
+		if policy.maxAttempts > 0 && attempt >= policy.maxAttempts {
+			return err
+		}
+
+		backoff := policy.backoff(attempt)
+		contextLogger.Debug("Retrying a restore operation",
+			"operation", operation,
+			"attempt", attempt,
+			"backoff", backoff.String(),
+			"error", err.Error())
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// getRestoreConfigMap reads a ConfigMap used by the recovery, retrying
+// as requested by the restore retry policy
+func getRestoreConfigMap(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	name string,
+) (*corev1.ConfigMap, error) {
+	var configMap corev1.ConfigMap
+	err := getRestoreRetryPolicy(cluster).retry(ctx, "read the ConfigMap "+name, resources.RetryAlways, func() error {
+		return typedClient.Get(ctx, client.ObjectKey{Namespace: cluster.Namespace, Name: name}, &configMap)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &configMap, nil
+}
@@ -0,0 +1,196 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore retry policy", func() {
+	newCluster := func(policy *apiv1.RestoreRetryPolicy) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:      "origin",
+						RetryPolicy: policy,
+						RecoverySettings: &apiv1.ConfigMapKeySelector{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "recovery-settings"},
+							Key:                  "recovery.conf",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	fastPolicy := &apiv1.RestoreRetryPolicy{
+		MaxAttempts:    ptr.To(int32(3)),
+		InitialBackoff: &metav1.Duration{Duration: time.Millisecond},
+	}
+
+	It("matches the previous behavior by default", func() {
+		policy := getRestoreRetryPolicy(&apiv1.Cluster{})
+		Expect(policy).To(Equal(restoreRetryPolicy{
+			maxAttempts:    1,
+			initialBackoff: 5 * time.Second,
+			maxBackoff:     5 * time.Second,
+		}))
+		Expect(policy.backoff(1)).To(Equal(5 * time.Second))
+		Expect(policy.backoff(10)).To(Equal(5 * time.Second))
+	})
+
+	It("doubles the backoff up to the maximum one", func() {
+		policy := getRestoreRetryPolicy(newCluster(&apiv1.RestoreRetryPolicy{
+			MaxAttempts:    ptr.To(int32(10)),
+			InitialBackoff: &metav1.Duration{Duration: time.Second},
+			MaxBackoff:     &metav1.Duration{Duration: 5 * time.Second},
+		}))
+		Expect(policy.maxAttempts).To(Equal(10))
+		Expect(policy.backoff(1)).To(Equal(time.Second))
+		Expect(policy.backoff(2)).To(Equal(2 * time.Second))
+		Expect(policy.backoff(3)).To(Equal(4 * time.Second))
+		Expect(policy.backoff(4)).To(Equal(5 * time.Second))
+		Expect(policy.withUnlimitedAttempts().maxAttempts).To(BeZero())
+	})
+
+	It("adds the jitter to the backoff", func() {
+		policy := getRestoreRetryPolicy(newCluster(&apiv1.RestoreRetryPolicy{
+			InitialBackoff: &metav1.Duration{Duration: time.Second},
+			JitterPercent:  ptr.To(int32(50)),
+		}))
+		for i := 0; i < 10; i++ {
+			Expect(policy.backoff(1)).To(BeNumerically(">=", time.Second))
+			Expect(policy.backoff(1)).To(BeNumerically("<=", 1500*time.Millisecond))
+		}
+	})
+
+	It("retries the download of the base backup starting from an empty data directory", func() {
+		pgData := GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		cluster := newCluster(fastPolicy)
+		runner := &fakeBarmanRunner{restoreFailures: 2}
+		info := InitInfo{PgData: pgData, BarmanRunner: runner}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+			BackupID:        "20240101T000000",
+		}}
+
+		Expect(info.restoreDataDir(context.TODO(), cluster, backup, nil, getRestoreRetryPolicy(cluster))).
+			To(Succeed())
+		Expect(runner.restoreAttempts).To(Equal(3))
+		Expect(path.Join(pgData, "PG_VERSION")).ToNot(BeAnExistingFile())
+
+		runner = &fakeBarmanRunner{restoreFailures: 3}
+		info.BarmanRunner = runner
+		Expect(info.restoreDataDir(context.TODO(), cluster, backup, nil, getRestoreRetryPolicy(cluster))).
+			To(MatchError("temporary failure"))
+		Expect(runner.restoreAttempts).To(Equal(3))
+	})
+
+	It("doesn't retry when barman-cloud-restore can't be started", func() {
+		cluster := newCluster(fastPolicy)
+		runner := &fakeBarmanRunner{restoreErr: &barman.CloudRestoreStartError{Err: exec.ErrNotFound}}
+		info := InitInfo{PgData: GinkgoT().TempDir(), BarmanRunner: runner}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+			BackupID:        "20240101T000000",
+		}}
+
+		err := info.restoreDataDir(context.TODO(), cluster, backup, nil, getRestoreRetryPolicy(cluster))
+		Expect(errors.Is(err, exec.ErrNotFound)).To(BeTrue())
+		Expect(runner.restoreAttempts).To(Equal(1))
+	})
+
+	It("retries the read of the ConfigMaps used by the recovery", func() {
+		failures := 2
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "recovery-settings", Namespace: "dev"},
+				Data:       map[string]string{"recovery.conf": "recovery_prefetch = on\n"},
+			}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(
+					ctx context.Context,
+					c client.WithWatch,
+					key client.ObjectKey,
+					obj client.Object,
+					opts ...client.GetOption,
+				) error {
+					if failures > 0 {
+						failures--
+						return errors.New("connection refused")
+					}
+					return c.Get(ctx, key, obj, opts...)
+				},
+			}).
+			Build()
+
+		_, err := loadRecoverySettings(context.TODO(), typedClient, newCluster(nil))
+		Expect(err).To(HaveOccurred())
+		Expect(failures).To(Equal(1))
+
+		settings, err := loadRecoverySettings(context.TODO(), typedClient, newCluster(fastPolicy))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(settings).To(Equal(map[string]string{"recovery_prefetch": "on"}))
+		Expect(failures).To(BeZero())
+	})
+
+	It("waits for the end of the recovery regardless of the maximum attempts", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		for i := 0; i < 5; i++ {
+			mock.ExpectQuery(`SELECT pg_is_in_recovery\(\)$`).
+				WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(true))
+		}
+		mock.ExpectQuery(`SELECT pg_is_in_recovery\(\)$`).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(false))
+		mock.ExpectQuery(`SELECT pg_is_in_recovery\(\), current_setting\('transaction_read_only'\)`).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery", "current_setting"}).AddRow(false, "off"))
+
+		policy := getRestoreRetryPolicy(newCluster(&apiv1.RestoreRetryPolicy{
+			MaxAttempts:    ptr.To(int32(1)),
+			InitialBackoff: &metav1.Duration{Duration: time.Millisecond},
+		}))
+		Expect(waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, nil, nil, nil)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
@@ -0,0 +1,183 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// schemaOnlyDatabasesQuery lists the databases whose content
+	// needs to be removed in a schema-only recovery
+	schemaOnlyDatabasesQuery = `SELECT datname FROM pg_catalog.pg_database
+WHERE datallowconn AND NOT datistemplate
+ORDER BY datname`
+
+	// schemaOnlyTablesQuery lists the user tables of the current database.
+	// Partitions are covered by their parent table, and the tables that
+	// are part of an extension are left untouched
+	schemaOnlyTablesQuery = `SELECT pg_catalog.format('%I.%I', n.nspname, c.relname)
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind IN ('r', 'p')
+AND NOT c.relispartition
+AND n.nspname <> 'information_schema'
+AND n.nspname NOT LIKE 'pg\_%'
+AND NOT EXISTS (
+  SELECT 1 FROM pg_catalog.pg_depend d
+  WHERE d.classid = 'pg_catalog.pg_class'::pg_catalog.regclass
+  AND d.objid = c.oid AND d.deptype = 'e'
+)
+ORDER BY 1`
+
+	// schemaOnlySequencesQuery lists the user sequences of the current
+	// database, excluding the ones that are part of an extension
+	schemaOnlySequencesQuery = `SELECT pg_catalog.format('%I.%I', n.nspname, c.relname)
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'S'
+AND n.nspname <> 'information_schema'
+AND n.nspname NOT LIKE 'pg\_%'
+AND NOT EXISTS (
+  SELECT 1 FROM pg_catalog.pg_depend d
+  WHERE d.classid = 'pg_catalog.pg_class'::pg_catalog.regclass
+  AND d.objid = c.oid AND d.deptype = 'e'
+)
+ORDER BY 1`
+)
+
+// isSchemaOnlyRecovery checks if the user requested the removal of the
+// content of the user tables after the recovery
+func isSchemaOnlyRecovery(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.SchemaOnly
+}
+
+// removeUserData truncates every user table and restarts every user
+// sequence in every database of the instance, preserving the DDL and
+// the grants
+func removeUserData(ctx context.Context, connect func(dbname string) (*sql.DB, error)) error {
+	contextLogger := log.FromContext(ctx)
+
+	superUserDB, err := connect("postgres")
+	if err != nil {
+		return fmt.Errorf("while getting superuser database: %w", err)
+	}
+
+	databases, err := queryNames(superUserDB, schemaOnlyDatabasesQuery)
+	if err != nil {
+		return fmt.Errorf("while listing databases: %w", err)
+	}
+
+	for _, dbname := range databases {
+		db, err := connect(dbname)
+		if err != nil {
+			return fmt.Errorf("while connecting to database %s: %w", dbname, err)
+		}
+
+		tables, err := removeDatabaseUserData(db)
+		if err != nil {
+			return fmt.Errorf("while removing user data from database %s: %w", dbname, err)
+		}
+
+		contextLogger.Info("Removed user data for schema-only recovery",
+			"database", dbname,
+			"truncatedTables", tables)
+	}
+
+	return nil
+}
+
+// removeDatabaseUserData truncates the user tables and restarts the
+// user sequences of a single database, in a transaction. It returns
+// the list of the truncated tables
+func removeDatabaseUserData(db *sql.DB) (tables []string, err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	tables, err = queryNames(tx, schemaOnlyTablesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	sequences, err := queryNames(tx, schemaOnlySequencesQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tables) > 0 {
+		if _, err = tx.Exec(fmt.Sprintf(
+			"TRUNCATE TABLE %s RESTART IDENTITY CASCADE",
+			strings.Join(tables, ", "))); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, sequence := range sequences {
+		if _, err = tx.Exec(fmt.Sprintf("ALTER SEQUENCE %s RESTART", sequence)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+// querier is the subset of the sql.DB and sql.Tx methods used by queryNames
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// queryNames runs a query returning a single text column and collects
+// the results
+func queryNames(db querier, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
@@ -0,0 +1,103 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("schema-only recovery", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+	})
+
+	connect := func(_ string) (*sql.DB, error) {
+		return db, nil
+	}
+
+	It("is enabled only with an explicit opt-in", func() {
+		cluster := &apiv1.Cluster{}
+		Expect(isSchemaOnlyRecovery(cluster)).To(BeFalse())
+
+		cluster.Spec.Bootstrap = &apiv1.BootstrapConfiguration{Recovery: &apiv1.BootstrapRecovery{}}
+		Expect(isSchemaOnlyRecovery(cluster)).To(BeFalse())
+
+		cluster.Spec.Bootstrap.Recovery.SchemaOnly = true
+		Expect(isSchemaOnlyRecovery(cluster)).To(BeTrue())
+	})
+
+	It("truncates the user tables and restarts the sequences of every database", func() {
+		mock.ExpectQuery(schemaOnlyDatabasesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"datname"}).AddRow("app").AddRow("postgres"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(schemaOnlyTablesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"format"}).AddRow("public.orders").AddRow(`public."Users"`))
+		mock.ExpectQuery(schemaOnlySequencesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"format"}).AddRow("public.orders_id_seq"))
+		mock.ExpectExec(`TRUNCATE TABLE public.orders, public."Users" RESTART IDENTITY CASCADE`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER SEQUENCE public.orders_id_seq RESTART").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(schemaOnlyTablesQuery).WillReturnRows(sqlmock.NewRows([]string{"format"}))
+		mock.ExpectQuery(schemaOnlySequencesQuery).WillReturnRows(sqlmock.NewRows([]string{"format"}))
+		mock.ExpectCommit()
+
+		Expect(removeUserData(context.TODO(), connect)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("rolls back when the truncation fails", func() {
+		mock.ExpectQuery(schemaOnlyDatabasesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"datname"}).AddRow("app"))
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(schemaOnlyTablesQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"format"}).AddRow("public.orders"))
+		mock.ExpectQuery(schemaOnlySequencesQuery).WillReturnRows(sqlmock.NewRows([]string{"format"}))
+		mock.ExpectExec("TRUNCATE TABLE public.orders RESTART IDENTITY CASCADE").
+			WillReturnError(errors.New("lock timeout"))
+		mock.ExpectRollback()
+
+		err := removeUserData(context.TODO(), connect)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("app"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
@@ -0,0 +1,247 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// restoredSequencesQuery lists the sequences of a database, the ones
+// of the system schemas excluded, together with their bounds
+const restoredSequencesQuery = `
+SELECT n.nspname, c.relname, s.seqincrement, s.seqmin, s.seqmax
+FROM pg_catalog.pg_sequence s
+JOIN pg_catalog.pg_class c ON c.oid = s.seqrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE n.nspname NOT IN ('pg_catalog', 'information_schema')
+AND n.nspname NOT LIKE 'pg\_%'
+ORDER BY 1, 2`
+
+// ErrSequenceOutOfBounds is raised when advancing a sequence would
+// move it beyond its minimum or maximum value
+var ErrSequenceOutOfBounds = errors.New("the advanced sequence would be out of bounds")
+
+// restoredSequence is a sequence of a restored database
+type restoredSequence struct {
+	schema    string
+	name      string
+	increment int64
+	minValue  int64
+	maxValue  int64
+}
+
+// qualifiedName gets the name of the sequence in the schema.sequence format
+func (s restoredSequence) qualifiedName() string {
+	return s.schema + "." + s.name
+}
+
+// getRecoverySequenceAdvance gets the advancement of the sequences
+// requested by the user, if any
+func getRecoverySequenceAdvance(cluster *apiv1.Cluster) *apiv1.RecoverySequenceAdvance {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.SequenceAdvance
+}
+
+// advanceRestoredSequences advances the sequences of the restored
+// databases requested by the user, so that the values they generate
+// don't collide with the ones generated by the source afterward
+func advanceRestoredSequences(
+	ctx context.Context,
+	advance *apiv1.RecoverySequenceAdvance,
+	instance *Instance,
+) error {
+	if advance == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	databases, err := listRestoredDatabases(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	for _, databaseName := range advance.Databases {
+		if !slices.Contains(databases, databaseName) {
+			contextLogger.Warning("The database whose sequences should be advanced "+
+				"doesn't exist or doesn't accept connections",
+				"database", databaseName)
+		}
+	}
+
+	for _, databaseName := range databases {
+		if len(advance.Databases) > 0 && !slices.Contains(advance.Databases, databaseName) {
+			continue
+		}
+
+		db, err := instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			return fmt.Errorf("could not connect to database %s: %w", databaseName, err)
+		}
+
+		if err := advanceDatabaseSequences(ctx, db, databaseName, advance); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// advanceDatabaseSequences advances, via setval, the sequences of a
+// database by the requested offset, logging the values before and after
+// the advancement of the audited ones. A sequence that has never been
+// used stays unused, so that its next value is the advanced one
+func advanceDatabaseSequences(
+	ctx context.Context,
+	db *sql.DB,
+	databaseName string,
+	advance *apiv1.RecoverySequenceAdvance,
+) error {
+	contextLogger := log.FromContext(ctx).WithValues("database", databaseName)
+
+	sequences, err := getRestoredSequences(ctx, db)
+	if err != nil {
+		return fmt.Errorf("while listing the sequences of database %s: %w", databaseName, err)
+	}
+
+	var advanced []string
+	for _, sequence := range sequences {
+		name := sequence.qualifiedName()
+		if len(advance.Sequences) > 0 && !slices.Contains(advance.Sequences, name) {
+			continue
+		}
+
+		before, after, err := advanceSequence(ctx, db, sequence, advance.Offset)
+		if err != nil {
+			return fmt.Errorf("while advancing sequence %s of database %s: %w", name, databaseName, err)
+		}
+		advanced = append(advanced, name)
+
+		if slices.Contains(advance.Audit, name) {
+			contextLogger.Info("Advanced the restored sequence",
+				"sequence", name,
+				"before", before,
+				"after", after)
+		}
+	}
+
+	for _, name := range advance.Sequences {
+		if !slices.Contains(advanced, name) {
+			contextLogger.Debug("The sequence to be advanced doesn't exist in the database",
+				"sequence", name)
+		}
+	}
+
+	contextLogger.Info("Advanced the restored sequences",
+		"offset", advance.Offset,
+		"sequences", len(advanced))
+	return nil
+}
+
+// getRestoredSequences lists the sequences of a restored database
+func getRestoredSequences(ctx context.Context, db *sql.DB) ([]restoredSequence, error) {
+	rows, err := db.QueryContext(ctx, restoredSequencesQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var sequences []restoredSequence
+	for rows.Next() {
+		var sequence restoredSequence
+		if err := rows.Scan(
+			&sequence.schema,
+			&sequence.name,
+			&sequence.increment,
+			&sequence.minValue,
+			&sequence.maxValue,
+		); err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, sequence)
+	}
+
+	return sequences, rows.Err()
+}
+
+// advanceSequence moves a sequence by the passed offset, in the direction
+// of its increment, returning its last value before and after the change
+func advanceSequence(
+	ctx context.Context,
+	db *sql.DB,
+	sequence restoredSequence,
+	offset int64,
+) (int64, int64, error) {
+	identifier := pgx.Identifier{sequence.schema, sequence.name}.Sanitize()
+
+	var lastValue int64
+	var isCalled bool
+	if err := db.QueryRowContext(
+		ctx,
+		fmt.Sprintf("SELECT last_value, is_called FROM %s", identifier),
+	).Scan(&lastValue, &isCalled); err != nil {
+		return 0, 0, err
+	}
+
+	newValue, err := advancedSequenceValue(sequence, lastValue, offset)
+	if err != nil {
+		return lastValue, 0, err
+	}
+
+	if _, err := db.ExecContext(
+		ctx,
+		"SELECT pg_catalog.setval($1::regclass, $2, $3)",
+		identifier, newValue, isCalled,
+	); err != nil {
+		return lastValue, 0, err
+	}
+
+	return lastValue, newValue, nil
+}
+
+// advancedSequenceValue computes the value of a sequence moved by the
+// passed offset, checking that it is still within the sequence bounds
+func advancedSequenceValue(sequence restoredSequence, lastValue, offset int64) (int64, error) {
+	if sequence.increment < 0 {
+		if lastValue < math.MinInt64+offset || lastValue-offset < sequence.minValue {
+			return 0, fmt.Errorf("%w: %d minus %d is lower than the minimum value %d",
+				ErrSequenceOutOfBounds, lastValue, offset, sequence.minValue)
+		}
+		return lastValue - offset, nil
+	}
+
+	if lastValue > math.MaxInt64-offset || lastValue+offset > sequence.maxValue {
+		return 0, fmt.Errorf("%w: %d plus %d is greater than the maximum value %d",
+			ErrSequenceOutOfBounds, lastValue, offset, sequence.maxValue)
+	}
+	return lastValue + offset, nil
+}
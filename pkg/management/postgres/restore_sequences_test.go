@@ -0,0 +1,116 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("advancement of the restored sequences", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	sequenceRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"nspname", "relname", "seqincrement", "seqmin", "seqmax"}).
+			AddRow("billing", "invoices_id_seq", 1, 1, int64(math.MaxInt64)).
+			AddRow("public", "countdown_seq", -1, int64(math.MinInt64), -1).
+			AddRow("public", "orders_id_seq", 1, 1, 2147483647)
+	}
+
+	It("advances every sequence in the direction of its increment", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_sequence").WillReturnRows(sequenceRows())
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT last_value, is_called FROM "billing"."invoices_id_seq"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_value", "is_called"}).AddRow(41, true))
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_catalog.setval($1::regclass, $2, $3)")).
+			WithArgs(`"billing"."invoices_id_seq"`, 1041, true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT last_value, is_called FROM "public"."countdown_seq"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_value", "is_called"}).AddRow(-10, true))
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_catalog.setval($1::regclass, $2, $3)")).
+			WithArgs(`"public"."countdown_seq"`, -1010, true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT last_value, is_called FROM "public"."orders_id_seq"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_value", "is_called"}).AddRow(1, false))
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_catalog.setval($1::regclass, $2, $3)")).
+			WithArgs(`"public"."orders_id_seq"`, 1001, false).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(advanceDatabaseSequences(context.TODO(), db, "app", &apiv1.RecoverySequenceAdvance{
+			Offset: 1000,
+			Audit:  []string{"public.orders_id_seq"},
+		})).To(Succeed())
+	})
+
+	It("advances only the requested sequences", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_sequence").WillReturnRows(sequenceRows())
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT last_value, is_called FROM "public"."orders_id_seq"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_value", "is_called"}).AddRow(500, true))
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_catalog.setval($1::regclass, $2, $3)")).
+			WithArgs(`"public"."orders_id_seq"`, 1500, true).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(advanceDatabaseSequences(context.TODO(), db, "app", &apiv1.RecoverySequenceAdvance{
+			Offset:    1000,
+			Sequences: []string{"public.orders_id_seq", "public.missing_seq"},
+		})).To(Succeed())
+	})
+
+	It("fails when a sequence would be advanced beyond its bounds", func() {
+		mock.ExpectQuery("FROM pg_catalog.pg_sequence").WillReturnRows(sequenceRows())
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT last_value, is_called FROM "public"."orders_id_seq"`)).
+			WillReturnRows(sqlmock.NewRows([]string{"last_value", "is_called"}).AddRow(2147483000, true))
+
+		err := advanceDatabaseSequences(context.TODO(), db, "app", &apiv1.RecoverySequenceAdvance{
+			Offset:    1000,
+			Sequences: []string{"public.orders_id_seq"},
+		})
+		Expect(errors.Is(err, ErrSequenceOutOfBounds)).To(BeTrue())
+	})
+
+	It("detects the overflow of the sequence values", func() {
+		_, err := advancedSequenceValue(
+			restoredSequence{increment: 1, maxValue: math.MaxInt64}, math.MaxInt64-10, 1000)
+		Expect(errors.Is(err, ErrSequenceOutOfBounds)).To(BeTrue())
+
+		_, err = advancedSequenceValue(
+			restoredSequence{increment: -1, minValue: math.MinInt64}, math.MinInt64+10, 1000)
+		Expect(errors.Is(err, ErrSequenceOutOfBounds)).To(BeTrue())
+	})
+})
@@ -0,0 +1,57 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// serverNameMaxLength is the maximum length of a server name, which is
+// a component of the path of every object stored in the object store
+const serverNameMaxLength = 255
+
+// serverNamePattern matches the server names that can be safely used as
+// a component of the object keys: they can't contain slashes or spaces,
+// and can't start with a dot
+var serverNamePattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._-]*$`)
+
+// ErrInvalidServerName is raised when the server name of the backup can't
+// be used to build the path of the objects in the object store
+var ErrInvalidServerName = errors.New("invalid server name")
+
+// validateServerName checks that the server name of a backup can be
+// passed to the barman-cloud commands. A wrong server name would make
+// them look for the backup in a nonexistent path of the object store
+func validateServerName(serverName string) error {
+	switch {
+	case serverName == "":
+		return fmt.Errorf("%w: the server name of the backup is empty", ErrInvalidServerName)
+
+	case len(serverName) > serverNameMaxLength:
+		return fmt.Errorf("%w: the server name of the backup is longer than %d characters",
+			ErrInvalidServerName, serverNameMaxLength)
+
+	case !serverNamePattern.MatchString(serverName):
+		return fmt.Errorf("%w: %q must contain only letters, digits, '.', '_' and '-', "+
+			"and it can't start with a dot or a dash",
+			ErrInvalidServerName, serverName)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("server name of the backup", func() {
+	It("accepts the valid server names", func() {
+		Expect(validateServerName("cluster-example")).To(Succeed())
+		Expect(validateServerName("Cluster_Example.v2")).To(Succeed())
+		Expect(validateServerName("_old")).To(Succeed())
+	})
+
+	It("rejects an empty server name", func() {
+		Expect(validateServerName("")).To(MatchError(ErrInvalidServerName))
+	})
+
+	It("rejects the malformed server names", func() {
+		for _, serverName := range []string{
+			"cluster/example",
+			"cluster example",
+			".hidden",
+			"..",
+			"-cluster",
+			"cluster\n",
+			strings.Repeat("a", serverNameMaxLength+1),
+		} {
+			Expect(validateServerName(serverName)).To(MatchError(ErrInvalidServerName), serverName)
+		}
+	})
+
+	It("doesn't launch barman-cloud-restore with a malformed server name", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{PgData: GinkgoT().TempDir(), BarmanRunner: runner}
+		cluster := &apiv1.Cluster{}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "cluster/example",
+			BackupID:        "20240101T000000",
+		}}
+
+		Expect(info.restoreDataDir(context.TODO(), cluster, backup, nil, getRestoreRetryPolicy(cluster))).
+			To(MatchError(ErrInvalidServerName))
+		Expect(runner.restoreAttempts).To(BeZero())
+	})
+
+	It("doesn't generate a restore_command with an empty server name", func() {
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{DestinationPath: "s3://backups/"}}
+		_, err := walFetchCommand(backup, &apiv1.Cluster{})
+		Expect(err).To(MatchError(ErrInvalidServerName))
+	})
+})
@@ -0,0 +1,177 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+)
+
+// ErrInvalidRecoverySettings is raised when the recovery settings
+// referenced by the cluster can't be used
+var ErrInvalidRecoverySettings = errors.New("invalid recovery settings")
+
+// allowedRecoverySettings are the parameters that can be specified in
+// the recovery settings ConfigMap
+var allowedRecoverySettings = stringset.From([]string{
+	"recovery_end_command",
+	"recovery_init_sync_method",
+	"recovery_prefetch",
+	"recovery_target",
+	"recovery_target_action",
+	"recovery_target_inclusive",
+	"recovery_target_lsn",
+	"recovery_target_name",
+	"recovery_target_time",
+	"recovery_target_timeline",
+	"recovery_target_xid",
+	"restore_command",
+	"wal_decode_buffer_size",
+})
+
+// operatorRecoverySettings are the parameters that are always generated
+// by the operator, as the recovery can't work without them
+var operatorRecoverySettings = stringset.From([]string{
+	"recovery_target_action",
+	"restore_command",
+})
+
+// recoveryTargetSettings are the parameters defining the recovery target.
+// When the cluster defines a recovery target, they are generated by the
+// operator
+var recoveryTargetSettings = stringset.From([]string{
+	"recovery_target",
+	"recovery_target_inclusive",
+	"recovery_target_lsn",
+	"recovery_target_name",
+	"recovery_target_time",
+	"recovery_target_timeline",
+	"recovery_target_xid",
+})
+
+// exclusiveRecoveryTargetSettings are the parameters of which PostgreSQL
+// accepts only one
+var exclusiveRecoveryTargetSettings = []string{
+	"recovery_target",
+	"recovery_target_lsn",
+	"recovery_target_name",
+	"recovery_target_time",
+	"recovery_target_xid",
+}
+
+// loadRecoverySettings fetches and validates the recovery settings
+// contained in the ConfigMap referenced by the cluster, if any
+func loadRecoverySettings(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+) (map[string]string, error) {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.RecoverySettings == nil {
+		return nil, nil
+	}
+
+	reference := cluster.Spec.Bootstrap.Recovery.RecoverySettings
+	configMap, err := getRestoreConfigMap(ctx, typedClient, cluster, reference.Name)
+	if err != nil {
+		return nil, fmt.Errorf("while getting the recovery settings ConfigMap %s: %w", reference.Name, err)
+	}
+
+	content, ok := configMap.Data[reference.Key]
+	if !ok {
+		return nil, fmt.Errorf("%w: missing key %s in ConfigMap %s",
+			ErrInvalidRecoverySettings, reference.Key, reference.Name)
+	}
+
+	settings, err := parseRecoverySettings(content)
+	if err != nil {
+		return nil, fmt.Errorf("in ConfigMap %s: %w", reference.Name, err)
+	}
+
+	log.FromContext(ctx).Info("Loaded the recovery settings",
+		"configMap", reference.Name,
+		"key", reference.Key,
+		"settings", settings)
+	return settings, nil
+}
+
+// parseRecoverySettings parses a block of recovery settings, written
+// with the syntax of the PostgreSQL configuration files, and checks
+// that it contains only recovery-related parameters
+func parseRecoverySettings(content string) (map[string]string, error) {
+	settings := make(map[string]string)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if !found || name == "" || value == "" {
+			return nil, fmt.Errorf("%w: cannot parse line %q", ErrInvalidRecoverySettings, line)
+		}
+
+		if !allowedRecoverySettings.Has(name) {
+			return nil, fmt.Errorf("%w: parameter %s is not allowed", ErrInvalidRecoverySettings, name)
+		}
+
+		settings[name] = value
+	}
+
+	var targets []string
+	for _, name := range exclusiveRecoveryTargetSettings {
+		if _, ok := settings[name]; ok {
+			targets = append(targets, name)
+		}
+	}
+	if len(targets) > 1 {
+		return nil, fmt.Errorf("%w: only one recovery target can be specified, found %s",
+			ErrInvalidRecoverySettings, strings.Join(targets, ", "))
+	}
+
+	return settings, nil
+}
+
+// renderRecoverySettings generates the configuration lines for the
+// recovery settings supplied by the user, leaving out the ones conflicting
+// with the configuration generated by the operator, which takes precedence
+func renderRecoverySettings(settings map[string]string, hasRecoveryTarget bool) string {
+	var result strings.Builder
+	for _, name := range stringset.FromKeys(settings).ToSortedList() {
+		if operatorRecoverySettings.Has(name) ||
+			(hasRecoveryTarget && recoveryTargetSettings.Has(name)) {
+			log.Warning("Ignoring a recovery setting conflicting with the ones generated by the operator",
+				"parameter", name,
+				"value", settings[name])
+			continue
+		}
+
+		result.WriteString(fmt.Sprintf("%s = %s\n", name, settings[name]))
+	}
+
+	return result.String()
+}
@@ -0,0 +1,119 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery settings", func() {
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source: "origin",
+						RecoverySettings: &apiv1.ConfigMapKeySelector{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "recovery-settings"},
+							Key:                  "recovery.conf",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	newConfigMap := func(content string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "recovery-settings", Namespace: "default"},
+			Data:       map[string]string{"recovery.conf": content},
+		}
+	}
+
+	It("loads the settings from the referenced ConfigMap", func() {
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(newConfigMap("# prefetch the blocks\n" +
+				"recovery_prefetch = on\n" +
+				"recovery_target_name = 'before-upgrade'\n")).
+			Build()
+
+		settings, err := loadRecoverySettings(context.TODO(), typedClient, newCluster())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(settings).To(Equal(map[string]string{
+			"recovery_prefetch":    "on",
+			"recovery_target_name": "'before-upgrade'",
+		}))
+	})
+
+	It("doesn't load anything when no ConfigMap is referenced", func() {
+		cluster := newCluster()
+		cluster.Spec.Bootstrap.Recovery.RecoverySettings = nil
+		Expect(loadRecoverySettings(context.TODO(), fake.NewClientBuilder().Build(), cluster)).To(BeNil())
+	})
+
+	It("fails when the key is missing from the ConfigMap", func() {
+		configMap := newConfigMap("")
+		configMap.Data = map[string]string{"other": "recovery_prefetch = on"}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(configMap).
+			Build()
+
+		_, err := loadRecoverySettings(context.TODO(), typedClient, newCluster())
+		Expect(err).To(MatchError(ErrInvalidRecoverySettings))
+	})
+
+	It("rejects parameters not related to the recovery", func() {
+		_, err := parseRecoverySettings("recovery_prefetch = on\nfsync = off\n")
+		Expect(err).To(MatchError(ErrInvalidRecoverySettings))
+	})
+
+	It("rejects malformed lines and multiple recovery targets", func() {
+		_, err := parseRecoverySettings("recovery_prefetch\n")
+		Expect(err).To(MatchError(ErrInvalidRecoverySettings))
+
+		_, err = parseRecoverySettings("recovery_target_name = 'a'\nrecovery_target_xid = '1234'\n")
+		Expect(err).To(MatchError(ErrInvalidRecoverySettings))
+	})
+
+	It("gives precedence to the settings generated by the operator", func() {
+		settings := map[string]string{
+			"restore_command":        "'cp /archive/%f %p'",
+			"recovery_target_action": "shutdown",
+			"recovery_target_name":   "'before-upgrade'",
+			"recovery_prefetch":      "on",
+		}
+
+		Expect(renderRecoverySettings(settings, false)).To(Equal(
+			"recovery_prefetch = on\n" +
+				"recovery_target_name = 'before-upgrade'\n"))
+		Expect(renderRecoverySettings(settings, true)).To(Equal("recovery_prefetch = on\n"))
+		Expect(renderRecoverySettings(nil, true)).To(BeEmpty())
+	})
+})
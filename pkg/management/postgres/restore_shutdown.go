@@ -0,0 +1,140 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+)
+
+// shutdownAtRecoveryTargetMessage is the message logged by PostgreSQL
+// when it shuts down once the recovery target is reached, as requested
+// by `recovery_target_action = shutdown`
+const shutdownAtRecoveryTargetMessage = "shutdown at recovery target"
+
+// recoveryShutdownWaitTimeout is the time allowed for PostgreSQL to
+// report the shutdown at the recovery target once the connection to the
+// instance is lost, as the log records are collected asynchronously
+const recoveryShutdownWaitTimeout = 10 * time.Second
+
+// errShutdownAtRecoveryTarget is raised while waiting for the end of the
+// recovery when PostgreSQL shut down at the recovery target, as requested.
+// It is not a failure, and it is never returned by the restore
+var errShutdownAtRecoveryTarget = errors.New("PostgreSQL shut down at the recovery target")
+
+// isShutdownAtTarget checks if the user requested PostgreSQL to be shut
+// down once the recovery target is reached
+func isShutdownAtTarget(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.ShutdownAtTarget
+}
+
+// recoveryShutdownCollector is a log record writer detecting whether
+// PostgreSQL shut down at the recovery target, while forwarding every
+// record to another writer
+type recoveryShutdownCollector struct {
+	writer logpipe.RecordWriter
+
+	once     sync.Once
+	shutDown chan struct{}
+}
+
+// newRecoveryShutdownCollector creates a collector forwarding the log
+// records to the passed writer, or to the instance manager logger
+// when it is nil
+func newRecoveryShutdownCollector(writer logpipe.RecordWriter) *recoveryShutdownCollector {
+	if writer == nil {
+		writer = &logpipe.LogRecordWriter{}
+	}
+
+	return &recoveryShutdownCollector{writer: writer, shutDown: make(chan struct{})}
+}
+
+// Write implements the logpipe.RecordWriter interface
+func (collector *recoveryShutdownCollector) Write(record logpipe.NamedRecord) {
+	collector.writer.Write(record)
+
+	loggingRecord, ok := record.(*logpipe.LoggingRecord)
+	if !ok || loggingRecord.Message != shutdownAtRecoveryTargetMessage {
+		return
+	}
+
+	collector.once.Do(func() {
+		close(collector.shutDown)
+	})
+}
+
+// hasShutDown checks if PostgreSQL reported to have shut down
+// at the recovery target
+func (collector *recoveryShutdownCollector) hasShutDown() bool {
+	if collector == nil {
+		return false
+	}
+
+	select {
+	case <-collector.shutDown:
+		return true
+	default:
+		return false
+	}
+}
+
+// waitForShutdown waits, for a limited amount of time, for PostgreSQL
+// to report the shutdown at the recovery target, returning whether
+// it happened
+func (collector *recoveryShutdownCollector) waitForShutdown(ctx context.Context, timeout time.Duration) bool {
+	if collector == nil {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-collector.shutDown:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
+// configureInstanceShutDownAtTarget prepares the restored instance, left
+// shut down at the recovery target, to be started by the instance manager.
+// Its configuration, which needs the instance to be running, is skipped,
+// but the durability relaxed by the fast recovery and the parameters
+// changed for the recovery are restored, as they would be after the
+// promotion
+func (info InitInfo) configureInstanceShutDownAtTarget(ctx context.Context, cluster *apiv1.Cluster) error {
+	log.FromContext(ctx).Info("The instance has been left shut down at the recovery target, "+
+		"skipping its configuration",
+		"skippedPasswordResets", len(getPasswordResets(cluster)))
+
+	if err := info.restoreDurabilityAfterFastRecovery(ctx, cluster); err != nil {
+		return err
+	}
+
+	return info.restoreParametersAfterRecovery(ctx, cluster)
+}
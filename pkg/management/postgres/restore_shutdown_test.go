@@ -0,0 +1,61 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("instance left shut down at the recovery target", func() {
+	It("restores the parameters changed for the recovery", func() {
+		pgData := GinkgoT().TempDir()
+		info := InitInfo{PgData: pgData}
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(
+			path.Join(pgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("max_parallel_maintenance_workers = '2'\n"),
+			0o600)).To(Succeed())
+
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{
+			PostgresConfiguration: apiv1.PostgresConfiguration{
+				Parameters: map[string]string{"max_parallel_maintenance_workers": "2"},
+			},
+			Bootstrap: &apiv1.BootstrapConfiguration{
+				Recovery: &apiv1.BootstrapRecovery{
+					Workers: &apiv1.RecoveryWorkers{MaintenanceWorkers: ptr.To(int32(8))},
+				},
+			},
+		}}
+		Expect(info.writeRecoveryWorkersConfiguration(context.TODO(), cluster)).To(Succeed())
+
+		Expect(info.configureInstanceShutDownAtTarget(context.TODO(), cluster)).To(Succeed())
+
+		content, err := os.ReadFile(path.Join(pgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal("max_parallel_maintenance_workers = '2'\n"))
+	})
+})
@@ -0,0 +1,98 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrRestoredSizeMismatch is raised when the size of the restored
+// data is outside the tolerance around the expected one
+var ErrRestoredSizeMismatch = errors.New("the size of the restored data is not the expected one")
+
+// isSizeWithinTolerance checks if the passed size differs from the
+// expected one by at most the passed percentage of the expected size
+func isSizeWithinTolerance(size, expected int64, tolerancePercent int32) bool {
+	difference := size - expected
+	if difference < 0 {
+		difference = -difference
+	}
+
+	return difference*100 <= expected*int64(tolerancePercent)
+}
+
+// getSizeDeviationPercent gets the difference between the passed size
+// and the expected one, as a percentage of the expected size
+func getSizeDeviationPercent(size, expected int64) float64 {
+	if expected == 0 {
+		return 0
+	}
+
+	return float64(size-expected) * 100 / float64(expected)
+}
+
+// checkRestoredSize compares, when requested by the user, the size of the
+// restored data, made of PGDATA and the tablespaces, with the expected one,
+// to detect a truncated or wrong base backup before the WAL replay starts.
+// A size outside the tolerance makes the restore fail or, when the user
+// asked so, is only reported with a warning
+func (info InitInfo) checkRestoredSize(ctx context.Context, cluster *apiv1.Cluster) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.SizeCheck == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	sizeCheck := cluster.Spec.Bootstrap.Recovery.SizeCheck
+
+	size, err := restoredDataSize(info.PgData)
+	if err != nil {
+		return fmt.Errorf("while computing the size of the restored data: %w", err)
+	}
+
+	expected := sizeCheck.ExpectedSize.Value()
+	tolerancePercent := sizeCheck.GetTolerancePercent()
+	deviationPercent := fmt.Sprintf("%.2f", getSizeDeviationPercent(size, expected))
+	if isSizeWithinTolerance(size, expected, tolerancePercent) {
+		contextLogger.Info("The size of the restored data is the expected one",
+			"restoredBytes", size,
+			"expectedBytes", expected,
+			"deviationPercent", deviationPercent,
+			"tolerancePercent", tolerancePercent)
+		return nil
+	}
+
+	if sizeCheck.OnSizeMismatch == apiv1.SizeMismatchPolicyWarn {
+		contextLogger.Warning("The size of the restored data is outside the tolerance, "+
+			"the base backup may be truncated or not the expected one",
+			"restoredBytes", size,
+			"expectedBytes", expected,
+			"deviationPercent", deviationPercent,
+			"tolerancePercent", tolerancePercent)
+		return nil
+	}
+
+	return fmt.Errorf(
+		"%w: %d bytes have been restored, while %d bytes were expected (%s%%, tolerance %d%%). "+
+			"The base backup may be truncated or not the expected one",
+		ErrRestoredSizeMismatch, size, expected, deviationPercent, tolerancePercent)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restored data size check", func() {
+	var (
+		info    InitInfo
+		cluster *apiv1.Cluster
+	)
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+		Expect(os.WriteFile(path.Join(info.PgData, "base"), make([]byte, 1000), 0o600)).To(Succeed())
+		cluster = &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						SizeCheck: &apiv1.RecoverySizeCheck{ExpectedSize: resource.MustParse("1050")},
+					},
+				},
+			},
+		}
+	})
+
+	It("accepts a size within the tolerance", func() {
+		Expect(info.checkRestoredSize(context.TODO(), cluster)).To(Succeed())
+	})
+
+	It("fails when the size is outside the tolerance", func() {
+		cluster.Spec.Bootstrap.Recovery.SizeCheck.TolerancePercent = ptr.To(int32(1))
+		err := info.checkRestoredSize(context.TODO(), cluster)
+		Expect(err).To(MatchError(ErrRestoredSizeMismatch))
+	})
+
+	It("only warns when requested", func() {
+		cluster.Spec.Bootstrap.Recovery.SizeCheck.TolerancePercent = ptr.To(int32(1))
+		cluster.Spec.Bootstrap.Recovery.SizeCheck.OnSizeMismatch = apiv1.SizeMismatchPolicyWarn
+		Expect(info.checkRestoredSize(context.TODO(), cluster)).To(Succeed())
+	})
+
+	It("is skipped when not requested", func() {
+		cluster.Spec.Bootstrap.Recovery.SizeCheck = nil
+		Expect(info.checkRestoredSize(context.TODO(), cluster)).To(Succeed())
+	})
+
+	It("compares the sizes with the tolerance", func() {
+		Expect(isSizeWithinTolerance(900, 1000, 10)).To(BeTrue())
+		Expect(isSizeWithinTolerance(1100, 1000, 10)).To(BeTrue())
+		Expect(isSizeWithinTolerance(899, 1000, 10)).To(BeFalse())
+		Expect(isSizeWithinTolerance(1000, 1000, 0)).To(BeTrue())
+		Expect(getSizeDeviationPercent(900, 1000)).To(BeNumerically("==", -10))
+	})
+})
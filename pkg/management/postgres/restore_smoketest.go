@@ -0,0 +1,126 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrSmokeTestFailed is raised when the post-recovery smoke test
+// doesn't pass
+var ErrSmokeTestFailed = errors.New("post-recovery smoke test failed")
+
+// getRecoverySmokeTest gets the smoke test requested by the user, if any
+func getRecoverySmokeTest(cluster *apiv1.Cluster) *apiv1.RecoverySmokeTest {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.SmokeTest
+}
+
+// smokeTestDatabase gets the database where the smoke test is executed
+func (info InitInfo) smokeTestDatabase(smokeTest *apiv1.RecoverySmokeTest) string {
+	switch {
+	case smokeTest.Database != "":
+		return smokeTest.Database
+	case info.ApplicationDatabase != "":
+		return info.ApplicationDatabase
+	default:
+		return "postgres"
+	}
+}
+
+// runRecoverySmokeTest executes the smoke test query on the restored
+// instance, and checks its result against the expected minimum. Depending
+// on the failure policy, a failure will make the recovery fail or will
+// just be reported
+func (info InitInfo) runRecoverySmokeTest(
+	ctx context.Context,
+	smokeTest *apiv1.RecoverySmokeTest,
+	connect func(dbname string) (*sql.DB, error),
+) error {
+	if smokeTest == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	database := info.smokeTestDatabase(smokeTest)
+
+	err := checkSmokeTestQuery(contextLogger, smokeTest, database, connect)
+	if err == nil {
+		return nil
+	}
+
+	if smokeTest.OnFailure == apiv1.SmokeTestFailurePolicyWarn {
+		contextLogger.Warning(
+			"POST-RECOVERY SMOKE TEST FAILED: the restored data may not be the expected one, "+
+				"proceeding as requested by the failure policy",
+			"database", database,
+			"query", smokeTest.Query,
+			"error", err.Error())
+		return nil
+	}
+
+	contextLogger.Error(err, "Post-recovery smoke test failed",
+		"database", database,
+		"query", smokeTest.Query)
+	return err
+}
+
+// checkSmokeTestQuery executes the smoke test query and checks its result
+func checkSmokeTestQuery(
+	contextLogger log.Logger,
+	smokeTest *apiv1.RecoverySmokeTest,
+	database string,
+	connect func(dbname string) (*sql.DB, error),
+) error {
+	db, err := connect(database)
+	if err != nil {
+		return fmt.Errorf("%w: while connecting to database %s: %v", ErrSmokeTestFailed, database, err)
+	}
+
+	var value sql.NullFloat64
+	if err := db.QueryRow(smokeTest.Query).Scan(&value); err != nil {
+		return fmt.Errorf("%w: while executing the query: %v", ErrSmokeTestFailed, err)
+	}
+
+	minValue := smokeTest.GetMinValue()
+	contextLogger.Info("Post-recovery smoke test executed",
+		"database", database,
+		"query", smokeTest.Query,
+		"result", value.Float64,
+		"null", !value.Valid,
+		"minValue", minValue)
+
+	if !value.Valid {
+		return fmt.Errorf("%w: the query returned NULL", ErrSmokeTestFailed)
+	}
+
+	if value.Float64 < float64(minValue) {
+		return fmt.Errorf("%w: the query returned %v, while the expected minimum is %d",
+			ErrSmokeTestFailed, value.Float64, minValue)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("post-recovery smoke test", func() {
+	const query = "SELECT count(*) FROM billing.invoices"
+
+	var (
+		db       *sql.DB
+		mock     sqlmock.Sqlmock
+		database string
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+		database = ""
+	})
+
+	connect := func(dbname string) (*sql.DB, error) {
+		database = dbname
+		return db, nil
+	}
+
+	It("does nothing when not configured", func() {
+		Expect(getRecoverySmokeTest(&apiv1.Cluster{})).To(BeNil())
+		Expect(InitInfo{}.runRecoverySmokeTest(context.TODO(), nil, connect)).To(Succeed())
+	})
+
+	It("passes when the query returns at least the expected minimum", func() {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1500))
+
+		info := InitInfo{ApplicationDatabase: "app"}
+		smokeTest := &apiv1.RecoverySmokeTest{Query: query, MinValue: ptr.To(int64(1000))}
+		Expect(info.runRecoverySmokeTest(context.TODO(), smokeTest, connect)).To(Succeed())
+		Expect(database).To(Equal("app"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("fails when the query returns less than the expected minimum", func() {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		smokeTest := &apiv1.RecoverySmokeTest{Query: query, Database: "billing"}
+		err := InitInfo{ApplicationDatabase: "app"}.runRecoverySmokeTest(context.TODO(), smokeTest, connect)
+		Expect(err).To(MatchError(ErrSmokeTestFailed))
+		Expect(database).To(Equal("billing"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("fails when the query returns NULL or can't be executed", func() {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+		mock.ExpectQuery(query).WillReturnError(errors.New(`relation "billing.invoices" does not exist`))
+
+		smokeTest := &apiv1.RecoverySmokeTest{Query: query}
+		Expect(InitInfo{}.runRecoverySmokeTest(context.TODO(), smokeTest, connect)).To(MatchError(ErrSmokeTestFailed))
+		Expect(InitInfo{}.runRecoverySmokeTest(context.TODO(), smokeTest, connect)).To(MatchError(ErrSmokeTestFailed))
+		Expect(database).To(Equal("postgres"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("only warns when requested by the failure policy", func() {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		smokeTest := &apiv1.RecoverySmokeTest{Query: query, OnFailure: apiv1.SmokeTestFailurePolicyWarn}
+		Expect(InitInfo{}.runRecoverySmokeTest(context.TODO(), smokeTest, connect)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
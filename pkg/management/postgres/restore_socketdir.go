@@ -0,0 +1,116 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrSocketDirectoryUnavailable is raised when the Unix socket
+// of the restored instance can't be found
+var ErrSocketDirectoryUnavailable = errors.New("the Unix socket of the restored instance is not available")
+
+const (
+	// postmasterPidPortLine is the line of the PID file containing
+	// the port of the postmaster, counting from zero
+	postmasterPidPortLine = 3
+
+	// postmasterPidSocketDirectoryLine is the line of the PID file
+	// containing the first socket directory of the postmaster,
+	// counting from zero
+	postmasterPidSocketDirectoryLine = 4
+)
+
+// readPostmasterSocket reads, from the PID file of the running postmaster,
+// its port and the first directory where it created its Unix socket. The
+// directory is empty when PostgreSQL isn't listening on any Unix socket
+func readPostmasterSocket(pgData string) (string, int, error) {
+	content, err := fileutils.ReadFile(path.Join(pgData, PostgresqlPidFile))
+	if err != nil {
+		return "", 0, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) <= postmasterPidSocketDirectoryLine {
+		return "", 0, fmt.Errorf("the PID file has %d lines, the socket directory is missing", len(lines))
+	}
+
+	port, err := strconv.Atoi(strings.TrimSpace(lines[postmasterPidPortLine]))
+	if err != nil {
+		return "", 0, fmt.Errorf("while parsing the port in the PID file: %w", err)
+	}
+
+	return strings.TrimSpace(lines[postmasterPidSocketDirectoryLine]), port, nil
+}
+
+// checkSocketDirectory checks that the socket directory contains
+// the Unix socket of PostgreSQL for the passed port
+func checkSocketDirectory(socketDir string, port int) error {
+	socketFile := path.Join(socketDir, fmt.Sprintf(".s.PGSQL.%d", port))
+	stat, err := os.Stat(socketFile)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSocketDirectoryUnavailable, err)
+	}
+	if stat.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%w: %s is not a socket", ErrSocketDirectoryUnavailable, socketFile)
+	}
+
+	return nil
+}
+
+// useRestoredSocketDirectory makes the superuser connections to the
+// restored instance go through the socket directory PostgreSQL is
+// actually listening on, as recorded in its PID file, rather than
+// assuming the default one. The socket is checked before connecting,
+// so that a wrong directory is reported clearly
+func useRestoredSocketDirectory(ctx context.Context, instance *Instance) error {
+	socketDir, port, err := readPostmasterSocket(instance.PgData)
+	if err != nil {
+		return fmt.Errorf("while reading the socket directory of the restored instance: %w", err)
+	}
+	if socketDir == "" {
+		return fmt.Errorf("%w: PostgreSQL isn't listening on any Unix socket, check unix_socket_directories",
+			ErrSocketDirectoryUnavailable)
+	}
+
+	if err := checkSocketDirectory(socketDir, port); err != nil {
+		return err
+	}
+
+	defaultSocketDir := GetSocketDir()
+	if socketDir == defaultSocketDir {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Connecting to the restored instance through its socket directory",
+		"socketDirectory", socketDir,
+		"defaultSocketDirectory", defaultSocketDir)
+	if instance.pool != nil {
+		instance.pool.ShutdownConnections()
+	}
+	instance.pool = newSuperUserConnectionPool(socketDir)
+	return nil
+}
@@ -0,0 +1,92 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("socket directory of the restored instance", func() {
+	var (
+		pgData    string
+		socketDir string
+		instance  *Instance
+	)
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+		// The path of a Unix socket has a short length limit
+		var err error
+		socketDir, err = os.MkdirTemp("", "sock")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			Expect(os.RemoveAll(socketDir)).To(Succeed())
+		})
+		instance = &Instance{PgData: pgData}
+	})
+
+	writePidFile := func(socketDir string) {
+		content := fmt.Sprintf("42\n%s\n1700000000\n5432\n%s\n*\n  5432001    3\nready   \n", pgData, socketDir)
+		Expect(os.WriteFile(path.Join(pgData, PostgresqlPidFile), []byte(content), 0o600)).To(Succeed())
+	}
+
+	listen := func() {
+		listener, err := net.Listen("unix", path.Join(socketDir, ".s.PGSQL.5432"))
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(listener.Close)
+	}
+
+	It("connects through a non-default socket directory", func() {
+		writePidFile(socketDir)
+		listen()
+
+		Expect(useRestoredSocketDirectory(context.TODO(), instance)).To(Succeed())
+		Expect(instance.ConnectionPool().GetDsn("postgres")).To(ContainSubstring("host=" + socketDir + " "))
+	})
+
+	It("keeps the default socket directory", func() {
+		GinkgoT().Setenv("PGHOST", socketDir)
+		writePidFile(socketDir)
+		listen()
+
+		Expect(useRestoredSocketDirectory(context.TODO(), instance)).To(Succeed())
+		Expect(instance.pool).To(BeNil())
+	})
+
+	It("fails when the socket doesn't exist", func() {
+		writePidFile(socketDir)
+		Expect(useRestoredSocketDirectory(context.TODO(), instance)).To(MatchError(ErrSocketDirectoryUnavailable))
+	})
+
+	It("fails when PostgreSQL isn't listening on a Unix socket", func() {
+		writePidFile("")
+		Expect(useRestoredSocketDirectory(context.TODO(), instance)).To(MatchError(ErrSocketDirectoryUnavailable))
+	})
+
+	It("fails when the socket is a regular file", func() {
+		writePidFile(socketDir)
+		Expect(os.WriteFile(path.Join(socketDir, ".s.PGSQL.5432"), nil, 0o600)).To(Succeed())
+		Expect(checkSocketDirectory(socketDir, 5432)).To(MatchError(ContainSubstring("is not a socket")))
+	})
+})
@@ -0,0 +1,166 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// backupLabelTimelinePrefix is the line of the backup_label file
+// reporting the timeline on which the backup has been taken
+const backupLabelTimelinePrefix = "START TIMELINE:"
+
+// ErrUnexpectedRecoverySource is raised when the restored backup doesn't
+// come from the source the user expects
+var ErrUnexpectedRecoverySource = errors.New("the backup doesn't come from the expected source")
+
+// restoredSource is the origin of a restored backup
+type restoredSource struct {
+	systemID string
+	timeline int
+}
+
+// getRecoveryExpectedSource gets the origin the user expects the
+// restored backup to have, if any
+func getRecoveryExpectedSource(cluster *apiv1.Cluster) *apiv1.RecoveryExpectedSource {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.ExpectedSource
+}
+
+// checkBackupTimeline checks, before the base backup is restored, that
+// the timeline of its first WAL file is the expected one. The check is
+// skipped when the first WAL file of the backup is not known
+func checkBackupTimeline(cluster *apiv1.Cluster, backup *apiv1.Backup) error {
+	expected := getRecoveryExpectedSource(cluster)
+	if expected == nil || expected.Timeline == nil || len(backup.Status.BeginWal) < 8 {
+		return nil
+	}
+
+	timeline, err := strconv.ParseUint(backup.Status.BeginWal[:8], 16, 32)
+	if err != nil {
+		return nil
+	}
+
+	if int(timeline) != int(*expected.Timeline) {
+		return fmt.Errorf("%w: backup %s has been taken on timeline %d, while timeline %d is expected",
+			ErrUnexpectedRecoverySource, backup.Status.BackupID, timeline, *expected.Timeline)
+	}
+
+	return nil
+}
+
+// checkRestoredSource checks that the restored data directory comes from
+// the source the user expects, reading the system identifier from
+// pg_control and the timeline from the backup_label file
+func (info InitInfo) checkRestoredSource(ctx context.Context, cluster *apiv1.Cluster) error {
+	expected := getRecoveryExpectedSource(cluster)
+	if expected == nil {
+		return nil
+	}
+
+	output, err := info.GetInstance().GetPgControldata()
+	if err != nil {
+		return fmt.Errorf("while running pg_controldata to check the source of the backup: %w", err)
+	}
+
+	backupLabel := info.BackupLabelFile
+	if len(backupLabel) == 0 {
+		backupLabel, err = os.ReadFile(path.Join(info.PgData, constants.BackupLabelFile))
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("while reading the backup_label file: %w", err)
+		}
+	}
+
+	source, err := parseRestoredSource(utils.ParsePgControldataOutput(output), backupLabel)
+	if err != nil {
+		return err
+	}
+
+	if err := checkExpectedSource(expected, source); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("The restored backup comes from the expected source",
+		"systemID", source.systemID,
+		"timeline", source.timeline)
+	return nil
+}
+
+// parseRestoredSource gets the origin of a restored backup. The timeline
+// is the one where the backup started, as reported by the backup_label
+// file, and otherwise the one of the last checkpoint
+func parseRestoredSource(controlData map[string]string, backupLabel []byte) (restoredSource, error) {
+	source := restoredSource{systemID: controlData["Database system identifier"]}
+	if source.systemID == "" {
+		return source, fmt.Errorf("no 'Database system identifier' section in the pg_controldata output")
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(backupLabel))
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), backupLabelTimelinePrefix)
+		if !found {
+			continue
+		}
+
+		timeline, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return source, fmt.Errorf("wrong timeline in the backup_label file: %q: %w", value, err)
+		}
+		source.timeline = timeline
+		return source, nil
+	}
+
+	timeline, err := strconv.Atoi(controlData["Latest checkpoint's TimeLineID"])
+	if err != nil {
+		return source, fmt.Errorf("wrong 'Latest checkpoint's TimeLineID' pg_controldata value: %w", err)
+	}
+	source.timeline = timeline
+
+	return source, nil
+}
+
+// checkExpectedSource compares the origin of a restored backup
+// with the expected one
+func checkExpectedSource(expected *apiv1.RecoveryExpectedSource, source restoredSource) error {
+	if expected.SystemID != "" && expected.SystemID != source.systemID {
+		return fmt.Errorf("%w: the system identifier of the backup is %s, while %s is expected",
+			ErrUnexpectedRecoverySource, source.systemID, expected.SystemID)
+	}
+
+	if expected.Timeline != nil && int(*expected.Timeline) != source.timeline {
+		return fmt.Errorf("%w: the backup has been taken on timeline %d, while timeline %d is expected",
+			ErrUnexpectedRecoverySource, source.timeline, *expected.Timeline)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("check of the source of the restored backup", func() {
+	controlData := map[string]string{
+		"Database system identifier":     "7400712938129768473",
+		"Latest checkpoint's TimeLineID": "4",
+	}
+
+	It("reads the timeline from the backup_label file", func() {
+		backupLabel := []byte("START WAL LOCATION: 0/3000028 (file 000000030000000000000003)\n" +
+			"CHECKPOINT LOCATION: 0/3000060\n" +
+			"BACKUP METHOD: streamed\n" +
+			"START TIMELINE: 3\n")
+
+		source, err := parseRestoredSource(controlData, backupLabel)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source).To(Equal(restoredSource{systemID: "7400712938129768473", timeline: 3}))
+	})
+
+	It("reads the timeline from pg_control without a backup_label file", func() {
+		source, err := parseRestoredSource(controlData, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source).To(Equal(restoredSource{systemID: "7400712938129768473", timeline: 4}))
+	})
+
+	It("fails when the system identifier or the timeline differ from the expected ones", func() {
+		source := restoredSource{systemID: "7400712938129768473", timeline: 3}
+
+		Expect(checkExpectedSource(&apiv1.RecoveryExpectedSource{
+			SystemID: "7400712938129768473",
+			Timeline: ptr.To(int32(3)),
+		}, source)).To(Succeed())
+		Expect(checkExpectedSource(&apiv1.RecoveryExpectedSource{
+			SystemID: "7300000000000000001",
+		}, source)).To(MatchError(ErrUnexpectedRecoverySource))
+		Expect(checkExpectedSource(&apiv1.RecoveryExpectedSource{
+			Timeline: ptr.To(int32(2)),
+		}, source)).To(MatchError(ErrUnexpectedRecoverySource))
+	})
+
+	It("checks the timeline of the backup before restoring it", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:         "origin",
+						ExpectedSource: &apiv1.RecoveryExpectedSource{Timeline: ptr.To(int32(3))},
+					},
+				},
+			},
+		}
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{BeginWal: "000000030000000000000003"}}
+		Expect(checkBackupTimeline(cluster, backup)).To(Succeed())
+
+		backup.Status.BeginWal = "000000020000000000000003"
+		Expect(checkBackupTimeline(cluster, backup)).To(MatchError(ErrUnexpectedRecoverySource))
+
+		backup.Status.BeginWal = ""
+		Expect(checkBackupTimeline(cluster, backup)).To(Succeed())
+	})
+})
@@ -0,0 +1,205 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils/compatibility"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// stagingDataDirectory is the directory of the staging volume
+// where the base backup is restored
+const stagingDataDirectory = "pgdata"
+
+// ErrInsufficientStagingSpace is raised when the staging volume
+// doesn't have enough free space to contain the base backup
+var ErrInsufficientStagingSpace = errors.New("not enough free space in the staging volume")
+
+// getRecoveryStaging gets the volume where the base backup is restored
+// before being moved to PGDATA, if any
+func getRecoveryStaging(cluster *apiv1.Cluster) *apiv1.RecoveryStaging {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Staging
+}
+
+// getStagingRequiredSpace gets the free space the staging volume must
+// have, which defaults to the size of the PGDATA volume, as the restored
+// base backup must fit in it anyway. Nil is returned when the size isn't
+// known
+func getStagingRequiredSpace(cluster *apiv1.Cluster, staging *apiv1.RecoveryStaging) *resource.Quantity {
+	if staging.RequiredSpace != nil {
+		return staging.RequiredSpace
+	}
+
+	return cluster.Spec.StorageConfiguration.GetSizeOrNil()
+}
+
+// restoreDataDirStaged restores the base backup into PGDATA. When the
+// cluster defines a staging volume, the base backup is restored there,
+// and the resulting data directory is then moved to PGDATA
+func (info InitInfo) restoreDataDirStaged(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+) (*apiv1.Backup, error) {
+	staging := getRecoveryStaging(cluster)
+	if staging == nil {
+		return info.restoreDataDirWithFallback(ctx, typedClient, cluster, backup, env)
+	}
+
+	contextLogger := log.FromContext(ctx)
+	stagingPgData := path.Join(postgresSpec.RecoveryStagingDirectory, stagingDataDirectory)
+
+	// Start from an empty staging area, as an interrupted restore
+	// may have left some files behind
+	if err := os.RemoveAll(stagingPgData); err != nil {
+		return nil, fmt.Errorf("while cleaning up the staging volume: %w", err)
+	}
+
+	if err := checkStagingSpace(
+		ctx, postgresSpec.RecoveryStagingDirectory, getStagingRequiredSpace(cluster, staging)); err != nil {
+		return nil, err
+	}
+
+	if err := checkRestoreInodes(ctx, cluster, postgresSpec.RecoveryStagingDirectory); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(stagingPgData, 0o700); err != nil {
+		return nil, fmt.Errorf("while creating the staging directory: %w", err)
+	}
+
+	stagedInfo := info
+	stagedInfo.PgData = stagingPgData
+	if err := stagedInfo.ensurePgDataOwnership(
+		ctx, int(cluster.GetPostgresUID()), int(cluster.GetPostgresGID())); err != nil {
+		return nil, err
+	}
+
+	contextLogger.Info("Restoring the base backup in the staging volume",
+		"staging", stagingPgData,
+		"claimName", staging.ClaimName)
+	stagingStart := time.Now()
+	restoredBackup, err := stagedInfo.restoreDataDirWithFallback(ctx, typedClient, cluster, backup, env)
+	if err != nil {
+		return nil, err
+	}
+	stagingDuration := time.Since(stagingStart)
+
+	contextLogger.Info("Moving the restored data directory from the staging volume",
+		"staging", stagingPgData,
+		"pgdata", info.PgData)
+	moveStart := time.Now()
+	copied, err := moveDataDirectory(stagingPgData, info.PgData)
+	if err != nil {
+		return nil, fmt.Errorf("while moving the data directory from the staging volume: %w", err)
+	}
+
+	contextLogger.Info("Staged restore completed",
+		"stagingDuration", stagingDuration.String(),
+		"moveDuration", time.Since(moveStart).String(),
+		"totalDuration", time.Since(stagingStart).String(),
+		"crossFilesystemCopy", copied)
+	return restoredBackup, nil
+}
+
+// checkStagingSpace checks that the staging volume has at least the
+// required free space. When the required space isn't known, the check
+// is skipped
+func checkStagingSpace(ctx context.Context, directory string, required *resource.Quantity) error {
+	contextLogger := log.FromContext(ctx)
+	if required == nil {
+		contextLogger.Warning("Cannot determine the space required in the staging volume, skipping the check",
+			"directory", directory)
+		return nil
+	}
+
+	available, err := compatibility.GetAvailableSpace(directory)
+	if err != nil {
+		return fmt.Errorf("while checking the free space of the staging volume: %w", err)
+	}
+
+	requiredBytes := required.Value()
+	if requiredBytes > 0 && available < uint64(requiredBytes) {
+		return fmt.Errorf("%w: %d bytes available, %s (%d bytes) required",
+			ErrInsufficientStagingSpace, available, required.String(), requiredBytes)
+	}
+
+	contextLogger.Info("The staging volume has enough free space",
+		"availableBytes", available,
+		"requiredBytes", requiredBytes)
+	return nil
+}
+
+// moveDataDirectory moves the content of a data directory into another
+// one, removing what is already there. When the two directories are on
+// the same filesystem the entries are renamed, while otherwise the
+// content is copied and then removed from the source. The returned
+// value tells if a copy was needed
+func moveDataDirectory(source, destination string) (bool, error) {
+	if err := os.MkdirAll(destination, 0o700); err != nil {
+		return false, err
+	}
+	if err := fileutils.RemoveDirectoryContent(destination); err != nil {
+		return false, err
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return false, err
+	}
+
+	for _, entry := range entries {
+		err := os.Rename(filepath.Join(source, entry.Name()), filepath.Join(destination, entry.Name()))
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, syscall.EXDEV) {
+			return false, err
+		}
+
+		// Renaming across filesystems isn't possible. As every entry
+		// is on the same filesystem, this happens with the first one,
+		// before anything has been moved
+		if err := copyDataDirectory(source, destination); err != nil {
+			return true, err
+		}
+		return true, fileutils.RemoveDirectoryContent(source)
+	}
+
+	return false, nil
+}
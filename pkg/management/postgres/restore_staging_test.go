@@ -0,0 +1,76 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore through a staging volume", func() {
+	It("defaults the required space to the size of the PGDATA volume", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{StorageConfiguration: apiv1.StorageConfiguration{Size: "10Gi"}},
+		}
+		staging := &apiv1.RecoveryStaging{ClaimName: "nvme-scratch"}
+		Expect(getStagingRequiredSpace(cluster, staging).String()).To(Equal("10Gi"))
+
+		requiredSpace := resource.MustParse("2Gi")
+		staging.RequiredSpace = &requiredSpace
+		Expect(getStagingRequiredSpace(cluster, staging).String()).To(Equal("2Gi"))
+	})
+
+	It("checks the free space of the staging volume", func() {
+		directory := GinkgoT().TempDir()
+		small := resource.MustParse("1Ki")
+		huge := resource.MustParse("1Ei")
+
+		Expect(checkStagingSpace(context.TODO(), directory, &small)).To(Succeed())
+		Expect(checkStagingSpace(context.TODO(), directory, &huge)).To(MatchError(ErrInsufficientStagingSpace))
+		Expect(checkStagingSpace(context.TODO(), directory, nil)).To(Succeed())
+	})
+
+	It("moves the data directory, replacing the content of the destination", func() {
+		source := GinkgoT().TempDir()
+		destination := path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(os.MkdirAll(path.Join(source, "base", "1"), 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(source, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(source, "base", "1", "1259"), []byte("data"), 0o600)).To(Succeed())
+		Expect(os.Symlink("/tablespaces/tbs1", path.Join(source, "16385"))).To(Succeed())
+		Expect(os.MkdirAll(destination, 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(destination, "leftover"), []byte("old"), 0o600)).To(Succeed())
+
+		copied, err := moveDataDirectory(source, destination)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(copied).To(BeFalse())
+
+		Expect(path.Join(destination, "leftover")).ToNot(BeAnExistingFile())
+		Expect(os.ReadFile(path.Join(destination, "base", "1", "1259"))).To(Equal([]byte("data")))
+		Expect(os.Readlink(path.Join(destination, "16385"))).To(Equal("/tablespaces/tbs1"))
+		entries, err := os.ReadDir(source)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+})
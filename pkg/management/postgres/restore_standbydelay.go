@@ -0,0 +1,106 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+)
+
+// recoveryStandbyDelayParameters are the parameters controlling how long
+// the WAL replay waits for the conflicting read-only queries
+var recoveryStandbyDelayParameters = []string{
+	"max_standby_archive_delay",
+	"max_standby_streaming_delay",
+}
+
+// renderRecoveryStandbyDelayOptions generates the parameters controlling
+// how long the WAL replay waits for the conflicting read-only queries
+// while paused at the recovery target or approaching it
+func renderRecoveryStandbyDelayOptions(pause *apiv1.RecoveryPause) map[string]string {
+	options := make(map[string]string)
+	if pause.MaxStandbyArchiveDelay != "" {
+		options["max_standby_archive_delay"] = pause.MaxStandbyArchiveDelay
+	}
+	if pause.MaxStandbyStreamingDelay != "" {
+		options["max_standby_streaming_delay"] = pause.MaxStandbyStreamingDelay
+	}
+
+	return options
+}
+
+// writeRecoveryStandbyDelayConfiguration writes, in the custom.conf file,
+// the delays the WAL replay waits for the conflicting read-only queries
+// during the recovery. They will be set back to the values of the cluster
+// configuration once the restored instance is configured
+func (info InitInfo) writeRecoveryStandbyDelayConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	pause := getRecoveryPause(cluster)
+	if pause == nil {
+		return nil
+	}
+
+	options := renderRecoveryStandbyDelayOptions(pause)
+	if len(options) == 0 {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, options); err != nil {
+		return fmt.Errorf("while configuring the standby delays for the recovery: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Configured the standby delays for the recovery", "options", options)
+
+	return nil
+}
+
+// restoreStandbyDelayAfterRecovery sets the standby delays back to the
+// values of the cluster configuration, removing the ones the cluster
+// doesn't define. The instance needs to be stopped
+func (info InitInfo) restoreStandbyDelayAfterRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	pause := getRecoveryPause(cluster)
+	if pause == nil || len(renderRecoveryStandbyDelayOptions(pause)) == 0 {
+		return nil
+	}
+
+	options := make(map[string]string)
+	for _, name := range recoveryStandbyDelayParameters {
+		if value, ok := cluster.Spec.PostgresConfiguration.Parameters[name]; ok {
+			options[name] = value
+		}
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	changed, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile, options, recoveryStandbyDelayParameters...)
+	if err != nil {
+		return fmt.Errorf("while restoring the standby delays: %w", err)
+	}
+
+	if changed {
+		log.FromContext(ctx).Info("Restored the standby delays after the recovery",
+			"options", options)
+	}
+
+	return nil
+}
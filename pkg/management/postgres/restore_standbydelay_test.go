@@ -0,0 +1,82 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("standby delays during the recovery", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		info = InitInfo{PgData: GinkgoT().TempDir()}
+		Expect(os.WriteFile(
+			path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("max_standby_streaming_delay = '30s'\nshared_buffers = '128MB'\n"),
+			0o600)).To(Succeed())
+	})
+
+	readCustomConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	newCluster := func(pause *apiv1.RecoveryPause) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					Parameters: map[string]string{"max_standby_streaming_delay": "30s"},
+				},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{PauseAtTarget: pause},
+				},
+			},
+		}
+	}
+
+	It("sets the standby delays during the recovery and restores the cluster configuration", func() {
+		cluster := newCluster(&apiv1.RecoveryPause{
+			MaxStandbyArchiveDelay:   "-1",
+			MaxStandbyStreamingDelay: "10min",
+		})
+
+		Expect(info.writeRecoveryStandbyDelayConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal(
+			"max_standby_streaming_delay = '10min'\nshared_buffers = '128MB'\nmax_standby_archive_delay = '-1'\n"))
+
+		Expect(info.restoreStandbyDelayAfterRecovery(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal("max_standby_streaming_delay = '30s'\nshared_buffers = '128MB'\n"))
+	})
+
+	It("leaves the configuration untouched when no delay is requested", func() {
+		for _, cluster := range []*apiv1.Cluster{newCluster(nil), newCluster(&apiv1.RecoveryPause{})} {
+			Expect(info.writeRecoveryStandbyDelayConfiguration(context.TODO(), cluster)).To(Succeed())
+			Expect(info.restoreStandbyDelayAfterRecovery(context.TODO(), cluster)).To(Succeed())
+			Expect(readCustomConf()).To(Equal("max_standby_streaming_delay = '30s'\nshared_buffers = '128MB'\n"))
+		}
+	})
+})
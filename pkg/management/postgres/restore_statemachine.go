@@ -0,0 +1,517 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/external"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// restoreTransition executes the operations of a state of the restore,
+// returning the next state to be entered
+type restoreTransition func(ctx context.Context) (apiv1.RestoreState, error)
+
+// restoreMachine is the state machine restoring a cluster from
+// a backup stored in an object store
+type restoreMachine struct {
+	info             InitInfo
+	typedClient      client.Client
+	cluster          *apiv1.Cluster
+	recoverySettings map[string]string
+
+	// the following fields are filled by the transitions
+	backup      *apiv1.Backup
+	env         []string
+	walSource   *apiv1.ExternalCluster
+	walEnv      []string
+	manifest    *RestoreManifest
+	resumeState apiv1.RestoreState
+	initialConf *pendingInitialPostgresqlConf
+}
+
+// walBackup gets the backup pointing to the object store from
+// which the WAL files are fetched
+func (m *restoreMachine) walBackup() *apiv1.Backup {
+	return withWALSource(m.backup, m.walSource)
+}
+
+// transitions gets the transition to be executed in every state. The
+// transitions of the phases having a timeout are aborted when it expires
+func (m *restoreMachine) transitions() map[apiv1.RestoreState]restoreTransition {
+	return withRestorePhaseTimeouts(m.cluster, map[apiv1.RestoreState]restoreTransition{
+		apiv1.RestoreStateLoadBackup:   m.loadBackup,
+		apiv1.RestoreStateRestoreData:  m.restoreData,
+		apiv1.RestoreStateWriteConfig:  m.writeConfig,
+		apiv1.RestoreStateWaitRecovery: m.waitRecovery,
+		apiv1.RestoreStateConfigure:    m.configure,
+	}, m.reportPhaseCondition)
+}
+
+// run executes the restore, starting by loading the backup. An interrupted
+// restore is resumed from the state chosen while loading the backup.
+// The progress is reported by the heartbeat of the restore, if requested
+func (m *restoreMachine) run(ctx context.Context) error {
+	startedAt := time.Now()
+	stopHeartbeat := m.startHeartbeat(ctx)
+	err := runRestoreStateMachine(ctx, apiv1.RestoreStateLoadBackup, m.transitions(), m.recordState)
+	stopHeartbeat()
+	m.info.RestoreStatus.recordError(err)
+	m.recordHistory(ctx, startedAt, err)
+	return err
+}
+
+// runRestoreStateMachine executes the transitions starting from the passed
+// state, until the restore is done. Every state is recorded before its
+// transition is executed, and the first error stops the restore
+func runRestoreStateMachine(
+	ctx context.Context,
+	initialState apiv1.RestoreState,
+	transitions map[apiv1.RestoreState]restoreTransition,
+	recordState func(ctx context.Context, state apiv1.RestoreState),
+) error {
+	contextLogger := log.FromContext(ctx)
+
+	state := initialState
+	for state != apiv1.RestoreStateDone {
+		transition, ok := transitions[state]
+		if !ok {
+			return fmt.Errorf("unknown restore state: %s", state)
+		}
+
+		contextLogger.Info("Entering restore state", "state", state)
+		recordState(ctx, state)
+
+		nextState, err := transition(ctx)
+		if err != nil {
+			return err
+		}
+		state = nextState
+	}
+
+	contextLogger.Info("Restore completed", "state", state)
+	recordState(ctx, state)
+	return nil
+}
+
+// recordState writes the current state of the restore in the cluster
+// status, and in the status served over HTTP. Errors are only logged, as they don't affect the restore: the
+// restore will just be resumed from an earlier state
+func (m *restoreMachine) recordState(ctx context.Context, state apiv1.RestoreState) {
+	m.info.RestoreStatus.recordPhase(state)
+	if err := m.info.reportRestoreState(ctx, m.typedClient, state); err != nil {
+		log.FromContext(ctx).Warning("Cannot record the state of the restore",
+			"state", state,
+			"error", err.Error())
+	}
+}
+
+// reportPhaseCondition writes the outcome of a phase of the
+// restore in the cluster conditions
+func (m *restoreMachine) reportPhaseCondition(ctx context.Context, condition *metav1.Condition) {
+	m.info.reportRestorePhaseCondition(ctx, m.typedClient, condition)
+}
+
+// loadBackup chooses the backup to be restored, and checks the object store
+// containing it. The next state is the one from which an interrupted
+// restore can be resumed, if any
+func (m *restoreMachine) loadBackup(ctx context.Context) (apiv1.RestoreState, error) {
+	previousManifest, err := loadRestoreManifest(ctx, m.typedClient, m.cluster)
+	if err != nil {
+		return "", err
+	}
+	if previousManifest != nil {
+		applyRestoreManifest(m.cluster, previousManifest)
+	}
+
+	// If we need to download data from a backup, we do it
+	backup, env, err := m.info.loadBackup(ctx, m.typedClient, m.cluster)
+	if err != nil {
+		return "", err
+	}
+
+	if previousManifest != nil {
+		if err := checkRestoreManifest(previousManifest, backup); err != nil {
+			return "", err
+		}
+	}
+	m.manifest = newRestoreManifest(m.cluster, backup)
+
+	if env, err = withBarmanHome(m.cluster, backup, env); err != nil {
+		return "", err
+	}
+	env = withRecoveryProxy(m.cluster, env)
+	m.backup = backup
+	m.env = env
+	m.info.BackupEndLSN = backup.Status.EndLSN
+
+	if err := checkArchiveDestinationIsNotRecoverySource(ctx, m.cluster, backup); err != nil {
+		return "", err
+	}
+
+	if m.walSource, m.walEnv, err = m.info.loadWALSource(ctx, m.typedClient, m.cluster, backup, env); err != nil {
+		return "", err
+	}
+	if m.walSource != nil {
+		if err := checkArchiveDestinationIsNotRecoverySource(ctx, m.cluster, m.walBackup()); err != nil {
+			return "", err
+		}
+	}
+
+	resumeState, err := m.info.getRestoreResumeState(m.cluster.Status.RestoreState)
+	if err != nil {
+		return "", err
+	}
+
+	switch resumeState {
+	case apiv1.RestoreStateWaitRecovery:
+		log.Info("Resuming an interrupted recovery, skipping the restore of the base backup",
+			"pgdata", m.info.PgData)
+	case apiv1.RestoreStateConfigure:
+		log.Info("Resuming an interrupted restore after the end of the recovery, "+
+			"skipping the restore of the base backup and the recovery",
+			"pgdata", m.info.PgData)
+	}
+
+	return resumeState, nil
+}
+
+// restoreData restores the base backup into PGDATA. The initial
+// configuration of the instance is prepared at the same time, and is
+// discarded if the restore of the data fails
+func (m *restoreMachine) restoreData(ctx context.Context) (_ apiv1.RestoreState, err error) {
+	if err := checkBackupTimeline(m.cluster, m.backup); err != nil {
+		return "", err
+	}
+
+	if err := validateRecoveryTargetLSN(m.cluster, m.backup); err != nil {
+		return "", err
+	}
+
+	if err := checkRecoveryWALGap(ctx, m.cluster, m.backup); err != nil {
+		return "", err
+	}
+
+	if err := m.info.checkRecoveryWindow(ctx, m.cluster, m.env, m.walEnv, m.walBackup()); err != nil {
+		return "", err
+	}
+
+	if err := m.info.ensureArchiveContainsLastCheckpointRedoWAL(ctx, m.cluster, m.walEnv, m.walBackup()); err != nil {
+		return "", err
+	}
+
+	if err := m.info.probeWALFetch(ctx, m.typedClient, m.cluster, m.walEnv, m.walBackup()); err != nil {
+		return "", err
+	}
+
+	if err := m.info.ensurePgDataOwnership(
+		ctx, int(m.cluster.GetPostgresUID()), int(m.cluster.GetPostgresGID())); err != nil {
+		return "", err
+	}
+
+	if err := checkRestoreInodes(ctx, m.cluster, m.info.PgData); err != nil {
+		return "", err
+	}
+
+	// The initial configuration doesn't depend on the restored data,
+	// and is prepared while the base backup is downloaded
+	m.initialConf = m.info.startInitialPostgresqlConfPreparation(ctx, m.cluster)
+	defer func() {
+		if err != nil {
+			m.initialConf.discard()
+			m.initialConf = nil
+		}
+	}()
+
+	releaseRestoreSlot, err := m.info.acquireRestoreSlot(ctx, m.typedClient)
+	if err != nil {
+		return "", fmt.Errorf("while acquiring a restore slot: %w", err)
+	}
+	backup, err := m.info.restoreDataDirStaged(ctx, m.typedClient, m.cluster, m.backup, m.env)
+	releaseRestoreSlot()
+	if err != nil {
+		return "", err
+	}
+	if _, err := m.initialConf.wait(); err != nil {
+		return "", err
+	}
+	m.backup = backup
+	m.manifest.BackupID = backup.Status.BackupID
+	m.info.BackupEndLSN = backup.Status.EndLSN
+
+	if err := m.info.decryptDataDir(ctx, m.typedClient, m.cluster, m.env); err != nil {
+		return "", err
+	}
+
+	if err := m.info.checkControlFile(ctx, m.cluster, m.backup); err != nil {
+		return "", err
+	}
+
+	if err := m.info.checkRestoredSize(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.verifyBackupManifest(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.checkRestoredSource(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.removeBundledWAL(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.checkTablespaceLinks(ctx); err != nil {
+		return "", err
+	}
+
+	if err := m.info.checkRestoredLocale(ctx); err != nil {
+		return "", err
+	}
+
+	if err := m.info.verifyWALArchiveContiguity(ctx, m.cluster, m.walEnv, m.walBackup()); err != nil {
+		return "", err
+	}
+
+	if _, err := m.info.restoreCustomWalDir(ctx); err != nil {
+		return "", err
+	}
+
+	return apiv1.RestoreStateWriteConfig, nil
+}
+
+// writeConfig writes the configuration of the recovery into PGDATA. The
+// restore of a replica cluster is done once it is configured to follow
+// its source
+func (m *restoreMachine) writeConfig(ctx context.Context) (apiv1.RestoreState, error) {
+	if err := m.writeInitialPostgresqlConf(); err != nil {
+		return "", err
+	}
+	// we need a migration here, otherwise the server will not start up if
+	// we recover from a base which has postgresql.auto.conf
+	// the override.conf and include statement is present, what we need to do is to
+	// migrate the content
+	if _, err := m.info.GetInstance().migratePostgresAutoConfFile(ctx); err != nil {
+		return "", err
+	}
+	switch m.cluster.GetRecoveryRole() {
+	case apiv1.RecoveryRoleReplicaCluster:
+		server, ok := m.cluster.ExternalCluster(m.cluster.Spec.ReplicaCluster.Source)
+		if !ok {
+			return "", fmt.Errorf("missing external cluster: %v", m.cluster.Spec.ReplicaCluster.Source)
+		}
+
+		connectionString, err := external.ConfigureConnectionToServer(
+			ctx, m.typedClient, m.info.Namespace, &server)
+		if err != nil {
+			return "", err
+		}
+
+		// TODO: Using a replication slot on replica cluster is not supported (yet?)
+		if _, err := UpdateReplicaConfiguration(m.info.PgData, connectionString, ""); err != nil {
+			return "", err
+		}
+		return apiv1.RestoreStateDone, nil
+
+	case apiv1.RecoveryRoleStandby:
+		// The continuous standby replays the WAL files archived with
+		// the backup, and is started by the instance manager
+		if err := m.info.writeRestoreWalConfig(m.walBackup(), m.cluster, m.recoverySettings); err != nil {
+			return "", err
+		}
+		if err := m.info.writeRecoverySignal(m.cluster); err != nil {
+			return "", err
+		}
+		return apiv1.RestoreStateDone, nil
+	}
+
+	if err := m.info.WriteRestoreHbaConf(); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRestoreWalConfig(m.walBackup(), m.cluster, m.recoverySettings); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeFastRecoveryConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRecoveryWorkersConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRecoveryMemoryConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRecoveryHugePagesConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRecoveryCheckpointConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRecoveryStandbyDelayConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeZeroDamagedPagesConfiguration(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	// A recovery executed with a relaxed durability can't be safely
+	// resumed, and will be started from scratch
+	if !isFastRecovery(m.cluster) {
+		if err := m.info.writeRestoreMarker(m.backup.Status.BackupID); err != nil {
+			return "", fmt.Errorf("while writing the restore marker: %w", err)
+		}
+	}
+
+	// The signal is created last, once every recovery setting is written
+	if err := m.info.writeRecoverySignal(m.cluster); err != nil {
+		return "", err
+	}
+
+	return apiv1.RestoreStateWaitRecovery, nil
+}
+
+// writeInitialPostgresqlConf installs the initial configuration prepared
+// while the base backup was restored. It is generated now when the restore
+// has been resumed after the base backup was restored
+func (m *restoreMachine) writeInitialPostgresqlConf() error {
+	pending := m.initialConf
+	m.initialConf = nil
+	if pending == nil {
+		return m.info.WriteInitialPostgresqlConf(m.cluster)
+	}
+
+	conf, err := pending.wait()
+	if err != nil {
+		return err
+	}
+
+	return conf.install(m.info.PgData)
+}
+
+// waitRecovery starts PostgreSQL and waits for the end of the recovery,
+// once the permissions of PGDATA have been swept, if requested.
+// The key used to decrypt the WAL files is only passed to PostgreSQL,
+// as barman-cloud-restore doesn't need it. When PostgreSQL has been left
+// shut down at the recovery target the instance can't be configured, and
+// the restore is completed once the parameters changed for the recovery
+// are restored and the access rules of the cluster replace the temporary
+// ones
+func (m *restoreMachine) waitRecovery(ctx context.Context) (apiv1.RestoreState, error) {
+	if err := m.info.sweepRestoredPgData(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	env, err := m.info.withWALDecryptionKey(ctx, m.typedClient, m.cluster, m.walEnv)
+	if err != nil {
+		return "", err
+	}
+
+	shutDown, err := m.info.waitForRestoredInstanceRecovery(ctx, m.cluster, env)
+	if err != nil {
+		return "", err
+	}
+	if !shutDown {
+		return apiv1.RestoreStateConfigure, nil
+	}
+
+	if err := m.info.configureInstanceShutDownAtTarget(ctx, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.finalizeRestoreHbaConf(ctx, m.typedClient, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRestoreManifest(ctx, m.manifest); err != nil {
+		return "", err
+	}
+
+	if err := m.info.removeRestoreMarker(); err != nil {
+		return "", fmt.Errorf("while removing the restore marker: %w", err)
+	}
+
+	return apiv1.RestoreStateDone, nil
+}
+
+// configure configures the recovered instance, installs the access rules
+// of the cluster and writes the manifest of the restore. The restore marker
+// is removed only at the end, so that an interrupted configuration can be
+// resumed
+func (m *restoreMachine) configure(ctx context.Context) (apiv1.RestoreState, error) {
+	if err := m.info.configureRestoredInstance(ctx, m.cluster, m.env); err != nil {
+		return "", err
+	}
+
+	if err := m.info.finalizeRestoreHbaConf(ctx, m.typedClient, m.cluster); err != nil {
+		return "", err
+	}
+
+	if err := m.info.writeRestoreManifest(ctx, m.manifest); err != nil {
+		return "", err
+	}
+
+	if err := m.info.removeRestoreMarker(); err != nil {
+		return "", fmt.Errorf("while removing the restore marker: %w", err)
+	}
+
+	return apiv1.RestoreStateDone, nil
+}
+
+// reportRestoreState writes the current state of the restore
+// in the cluster status
+func (info InitInfo) reportRestoreState(
+	ctx context.Context,
+	typedClient client.Client,
+	state apiv1.RestoreState,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RestoreState = state
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the state of the restore in the cluster status: %w", err)
+	}
+
+	return nil
+}
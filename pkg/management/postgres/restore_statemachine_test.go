@@ -0,0 +1,155 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore state machine", func() {
+	var recorded []apiv1.RestoreState
+	recordState := func(_ context.Context, state apiv1.RestoreState) {
+		recorded = append(recorded, state)
+	}
+
+	BeforeEach(func() {
+		recorded = nil
+	})
+
+	goTo := func(state apiv1.RestoreState) restoreTransition {
+		return func(context.Context) (apiv1.RestoreState, error) {
+			return state, nil
+		}
+	}
+
+	It("records every state entered until the restore is done", func() {
+		transitions := map[apiv1.RestoreState]restoreTransition{
+			apiv1.RestoreStateLoadBackup:   goTo(apiv1.RestoreStateRestoreData),
+			apiv1.RestoreStateRestoreData:  goTo(apiv1.RestoreStateWriteConfig),
+			apiv1.RestoreStateWriteConfig:  goTo(apiv1.RestoreStateWaitRecovery),
+			apiv1.RestoreStateWaitRecovery: goTo(apiv1.RestoreStateConfigure),
+			apiv1.RestoreStateConfigure:    goTo(apiv1.RestoreStateDone),
+		}
+
+		Expect(runRestoreStateMachine(
+			context.TODO(), apiv1.RestoreStateLoadBackup, transitions, recordState)).To(Succeed())
+		Expect(recorded).To(Equal([]apiv1.RestoreState{
+			apiv1.RestoreStateLoadBackup,
+			apiv1.RestoreStateRestoreData,
+			apiv1.RestoreStateWriteConfig,
+			apiv1.RestoreStateWaitRecovery,
+			apiv1.RestoreStateConfigure,
+			apiv1.RestoreStateDone,
+		}))
+	})
+
+	It("skips the states already completed when resuming", func() {
+		transitions := map[apiv1.RestoreState]restoreTransition{
+			apiv1.RestoreStateLoadBackup: goTo(apiv1.RestoreStateConfigure),
+			apiv1.RestoreStateConfigure:  goTo(apiv1.RestoreStateDone),
+		}
+
+		Expect(runRestoreStateMachine(
+			context.TODO(), apiv1.RestoreStateLoadBackup, transitions, recordState)).To(Succeed())
+		Expect(recorded).To(Equal([]apiv1.RestoreState{
+			apiv1.RestoreStateLoadBackup,
+			apiv1.RestoreStateConfigure,
+			apiv1.RestoreStateDone,
+		}))
+	})
+
+	It("stops at the first failing transition", func() {
+		transitions := map[apiv1.RestoreState]restoreTransition{
+			apiv1.RestoreStateLoadBackup: goTo(apiv1.RestoreStateRestoreData),
+			apiv1.RestoreStateRestoreData: func(context.Context) (apiv1.RestoreState, error) {
+				return "", errors.New("restore failed")
+			},
+		}
+
+		Expect(runRestoreStateMachine(
+			context.TODO(), apiv1.RestoreStateLoadBackup, transitions, recordState)).To(MatchError("restore failed"))
+		Expect(recorded).To(Equal([]apiv1.RestoreState{
+			apiv1.RestoreStateLoadBackup,
+			apiv1.RestoreStateRestoreData,
+		}))
+	})
+
+	It("rejects an unknown state", func() {
+		Expect(runRestoreStateMachine(
+			context.TODO(), apiv1.RestoreStateLoadBackup, nil, recordState)).To(HaveOccurred())
+		Expect(recorded).To(BeEmpty())
+	})
+
+	It("defines a transition for every state but the final one", func() {
+		transitions := (&restoreMachine{}).transitions()
+		Expect(transitions).To(HaveLen(5))
+		Expect(transitions).ToNot(HaveKey(apiv1.RestoreStateDone))
+	})
+
+	It("sweeps the permissions of PGDATA before starting the recovery", func() {
+		pgData := path.Join(GinkgoT().TempDir(), "pgdata")
+		Expect(os.MkdirAll(pgData, 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		Expect(os.Chmod(path.Join(pgData, "PG_VERSION"), 0o666)).To(Succeed())
+
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{
+			PostgresUID: int64(os.Getuid()),
+			PostgresGID: int64(os.Getgid()),
+			Bootstrap: &apiv1.BootstrapConfiguration{
+				Recovery: &apiv1.BootstrapRecovery{PermissionSweep: true},
+			},
+		}}
+		machine := &restoreMachine{info: InitInfo{PgData: pgData}, cluster: cluster}
+
+		// PostgreSQL can't be started here, but PGDATA is swept before
+		_, err := machine.waitRecovery(context.TODO())
+		Expect(err).To(HaveOccurred())
+
+		stat, err := os.Stat(path.Join(pgData, "PG_VERSION"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+	})
+
+	It("records the state in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+
+		Expect(info.reportRestoreState(context.TODO(), typedClient, apiv1.RestoreStateWaitRecovery)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.RestoreState).To(Equal(apiv1.RestoreStateWaitRecovery))
+	})
+})
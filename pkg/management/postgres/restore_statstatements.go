@@ -0,0 +1,222 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+const (
+	// statStatementsExtension is the name of the extension collecting
+	// the statistics of the executed statements
+	statStatementsExtension = "pg_stat_statements"
+
+	// statStatementsSchemaQuery gets the schema where the
+	// pg_stat_statements extension is installed
+	statStatementsSchemaQuery = "SELECT n.nspname FROM pg_catalog.pg_extension e " +
+		"JOIN pg_catalog.pg_namespace n ON n.oid = e.extnamespace " +
+		"WHERE e.extname = 'pg_stat_statements'"
+)
+
+// getRecoveryStatStatementsReset gets the reset of the statistics of
+// pg_stat_statements requested by the user, if any
+func getRecoveryStatStatementsReset(cluster *apiv1.Cluster) *apiv1.RecoveryStatStatementsReset {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.StatStatementsReset
+}
+
+// resetRestoredStatStatements resets the statistics collected by
+// pg_stat_statements, inherited from the source of the restore, saving
+// them in the baseline table first when requested. Nothing is done when
+// the extension is not installed in any database, or its library is not
+// loaded, and the outcome is logged in every case
+func resetRestoredStatStatements(
+	ctx context.Context,
+	reset *apiv1.RecoveryStatStatementsReset,
+	instance *Instance,
+) error {
+	if reset == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	superUserDB, err := instance.GetSuperUserDB()
+	if err != nil {
+		return err
+	}
+	loaded, err := isStatStatementsLoaded(ctx, superUserDB)
+	if err != nil {
+		return err
+	}
+	if !loaded {
+		contextLogger.Info("The pg_stat_statements library is not loaded, no statistics have been reset",
+			"reset", false)
+		return nil
+	}
+
+	databases, err := listRestoredDatabases(ctx, instance)
+	if err != nil {
+		return err
+	}
+
+	databaseName, db, schema, err := findStatStatementsDatabase(ctx, instance, databases)
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		contextLogger.Info("The pg_stat_statements extension is not installed, no statistics have been reset",
+			"reset", false)
+		return nil
+	}
+
+	contextLogger = contextLogger.WithValues("database", databaseName)
+	if reset.BaselineTable != "" {
+		if err := captureStatStatementsBaseline(ctx, db, schema, reset.BaselineTable); err != nil {
+			return fmt.Errorf("while saving the pg_stat_statements baseline in database %s: %w", databaseName, err)
+		}
+	}
+
+	if _, err := db.ExecContext(
+		ctx,
+		fmt.Sprintf("SELECT %s.pg_stat_statements_reset()", pgx.Identifier{schema}.Sanitize()),
+	); err != nil {
+		return fmt.Errorf("while resetting the statistics of pg_stat_statements: %w", err)
+	}
+
+	contextLogger.Info("Reset the statistics of pg_stat_statements",
+		"reset", true,
+		"baselineTable", reset.BaselineTable)
+	return nil
+}
+
+// isStatStatementsLoaded checks if the pg_stat_statements library
+// is loaded, as its functions and views can't be used otherwise
+func isStatStatementsLoaded(ctx context.Context, db *sql.DB) (bool, error) {
+	var libraries string
+	if err := db.QueryRowContext(
+		ctx,
+		"SELECT pg_catalog.current_setting('shared_preload_libraries')",
+	).Scan(&libraries); err != nil {
+		return false, fmt.Errorf("while checking the loaded libraries: %w", err)
+	}
+
+	for _, library := range strings.Split(libraries, ",") {
+		if strings.Trim(strings.TrimSpace(library), `"`) == statStatementsExtension {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// findStatStatementsDatabase finds a database, the postgres one being
+// preferred, where the pg_stat_statements extension is installed, together
+// with the schema of the extension. A nil connection is returned when
+// the extension is not installed in any database
+func findStatStatementsDatabase(
+	ctx context.Context,
+	instance *Instance,
+	databases []string,
+) (string, *sql.DB, string, error) {
+	candidates := slices.Clone(databases)
+	slices.SortStableFunc(candidates, func(a, b string) int {
+		switch {
+		case a == "postgres" && b != "postgres":
+			return -1
+		case b == "postgres" && a != "postgres":
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for _, databaseName := range candidates {
+		db, err := instance.ConnectionPool().Connection(databaseName)
+		if err != nil {
+			return "", nil, "", fmt.Errorf("could not connect to database %s: %w", databaseName, err)
+		}
+
+		var schema string
+		err = db.QueryRowContext(ctx, statStatementsSchemaQuery).Scan(&schema)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return "", nil, "", fmt.Errorf("while looking for the pg_stat_statements extension in database %s: %w",
+				databaseName, err)
+		}
+
+		return databaseName, db, schema, nil
+	}
+
+	return "", nil, "", nil
+}
+
+// captureStatStatementsBaseline saves the statistics collected by
+// pg_stat_statements in the baseline table, together with the time they
+// have been captured. An existing table is kept untouched, as it may
+// contain the baseline saved by an interrupted restore
+func captureStatStatementsBaseline(ctx context.Context, db *sql.DB, extensionSchema, baselineTable string) error {
+	contextLogger := log.FromContext(ctx)
+
+	schema, table, _ := strings.Cut(baselineTable, ".")
+	identifier := pgx.Identifier{schema, table}.Sanitize()
+
+	var exists bool
+	if err := db.QueryRowContext(
+		ctx,
+		"SELECT pg_catalog.to_regclass($1) IS NOT NULL",
+		identifier,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		contextLogger.Warning("The pg_stat_statements baseline table already exists, keeping it as it is",
+			"baselineTable", baselineTable)
+		return nil
+	}
+
+	result, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE %s AS SELECT pg_catalog.now() AS captured_at, s.* FROM %s.pg_stat_statements s",
+		identifier, pgx.Identifier{extensionSchema}.Sanitize()))
+	if err != nil {
+		return err
+	}
+
+	statements, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	contextLogger.Info("Saved the pg_stat_statements baseline",
+		"baselineTable", baselineTable,
+		"statements", statements)
+	return nil
+}
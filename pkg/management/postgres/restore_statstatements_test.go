@@ -0,0 +1,80 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("reset of the pg_stat_statements statistics", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("detects if the pg_stat_statements library is loaded", func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_catalog.current_setting('shared_preload_libraries')")).
+			WillReturnRows(sqlmock.NewRows([]string{"current_setting"}).AddRow(`auto_explain, "pg_stat_statements"`))
+		Expect(isStatStatementsLoaded(context.TODO(), db)).To(BeTrue())
+
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_catalog.current_setting('shared_preload_libraries')")).
+			WillReturnRows(sqlmock.NewRows([]string{"current_setting"}).AddRow(""))
+		Expect(isStatStatementsLoaded(context.TODO(), db)).To(BeFalse())
+	})
+
+	It("saves the baseline in a new table", func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_catalog.to_regclass($1) IS NOT NULL")).
+			WithArgs(`"monitoring"."restore_baseline"`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TABLE "monitoring"."restore_baseline" AS ` +
+			`SELECT pg_catalog.now() AS captured_at, s.* FROM "public".pg_stat_statements s`)).
+			WillReturnResult(sqlmock.NewResult(0, 42))
+
+		Expect(captureStatStatementsBaseline(context.TODO(), db, "public", "monitoring.restore_baseline")).
+			To(Succeed())
+	})
+
+	It("keeps an existing baseline table untouched", func() {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_catalog.to_regclass($1) IS NOT NULL")).
+			WithArgs(`"monitoring"."restore_baseline"`).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		Expect(captureStatStatementsBaseline(context.TODO(), db, "public", "monitoring.restore_baseline")).
+			To(Succeed())
+	})
+
+	It("does nothing when the reset is not requested", func() {
+		Expect(resetRestoredStatStatements(context.TODO(), nil, nil)).To(Succeed())
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"sync"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+)
+
+// RestoreStatus is the current state of a running restore
+type RestoreStatus struct {
+	// The last state entered by the restore
+	Phase apiv1.RestoreState `json:"phase,omitempty"`
+
+	// The last LSN replayed by the recovery
+	ReplayedLSN string `json:"replayedLSN,omitempty"`
+
+	// The LSN of the recovery target, when known in advance
+	TargetLSN string `json:"targetLSN,omitempty"`
+
+	// The percentage of the WAL to be replayed that has been
+	// replayed, available only when the target LSN is known
+	PercentReplayed *int32 `json:"percentReplayed,omitempty"`
+
+	// The last error encountered by the restore, including the
+	// ones of the operations that have been retried
+	Error string `json:"error,omitempty"`
+
+	// When the restore started
+	StartedAt time.Time `json:"startedAt"`
+
+	// When the status was last changed
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// RestoreStatusTracker keeps track of the status of a running restore.
+// It is safe for concurrent use, and a nil tracker ignores every update
+type RestoreStatusTracker struct {
+	mu     sync.Mutex
+	status RestoreStatus
+}
+
+// NewRestoreStatusTracker creates the tracker of a restore starting now
+func NewRestoreStatusTracker() *RestoreStatusTracker {
+	now := time.Now()
+	return &RestoreStatusTracker{
+		status: RestoreStatus{StartedAt: now, UpdatedAt: now},
+	}
+}
+
+// Get gets a copy of the current status of the restore
+func (t *RestoreStatusTracker) Get() RestoreStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status := t.status
+	if status.PercentReplayed != nil {
+		percent := *status.PercentReplayed
+		status.PercentReplayed = &percent
+	}
+	return status
+}
+
+// update changes the status of the restore, if tracked
+func (t *RestoreStatusTracker) update(change func(status *RestoreStatus)) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	change(&t.status)
+	t.status.UpdatedAt = time.Now()
+}
+
+// recordPhase records the state entered by the restore. The error of
+// the previous state, if any, has been overcome and is cleared
+func (t *RestoreStatusTracker) recordPhase(phase apiv1.RestoreState) {
+	t.update(func(status *RestoreStatus) {
+		status.Phase = phase
+		status.Error = ""
+	})
+}
+
+// recordProgress records the progress of the WAL replay
+func (t *RestoreStatusTracker) recordProgress(report *apiv1.RecoveryProgressReport) {
+	t.update(func(status *RestoreStatus) {
+		status.ReplayedLSN = report.ReplayedLSN
+		status.TargetLSN = report.TargetLSN
+		status.PercentReplayed = nil
+		if report.PercentReplayed != nil {
+			percent := *report.PercentReplayed
+			status.PercentReplayed = &percent
+		}
+	})
+}
+
+// recordError records an error encountered by the restore
+func (t *RestoreStatusTracker) recordError(err error) {
+	if err == nil {
+		return
+	}
+
+	t.update(func(status *RestoreStatus) {
+		status.Error = err.Error()
+	})
+}
@@ -0,0 +1,77 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("status of the restore", func() {
+	It("tracks the phase, the progress and the last error of the restore", func() {
+		tracker := NewRestoreStatusTracker()
+		tracker.recordPhase(apiv1.RestoreStateRestoreData)
+		tracker.recordError(errors.New("temporary failure"))
+		Expect(tracker.Get().Phase).To(Equal(apiv1.RestoreStateRestoreData))
+		Expect(tracker.Get().Error).To(Equal("temporary failure"))
+
+		tracker.recordPhase(apiv1.RestoreStateWaitRecovery)
+		tracker.recordProgress(&apiv1.RecoveryProgressReport{
+			StartLSN:        "0/3000100",
+			TargetLSN:       "0/7000100",
+			ReplayedLSN:     "0/5000100",
+			PercentReplayed: ptr.To(int32(50)),
+		})
+
+		status := tracker.Get()
+		Expect(status.Phase).To(Equal(apiv1.RestoreStateWaitRecovery))
+		Expect(status.Error).To(BeEmpty())
+		Expect(status.ReplayedLSN).To(Equal("0/5000100"))
+		Expect(status.TargetLSN).To(Equal("0/7000100"))
+		Expect(status.PercentReplayed).To(HaveValue(Equal(int32(50))))
+		Expect(status.UpdatedAt).ToNot(BeTemporally("<", status.StartedAt))
+	})
+
+	It("ignores the updates when the restore is not tracked", func() {
+		var tracker *RestoreStatusTracker
+		Expect(func() {
+			tracker.recordPhase(apiv1.RestoreStateLoadBackup)
+			tracker.recordError(errors.New("failure"))
+		}).ToNot(Panic())
+	})
+
+	It("tracks the states entered by the state machine", func() {
+		tracker := NewRestoreStatusTracker()
+		machine := &restoreMachine{
+			info:        InitInfo{RestoreStatus: tracker},
+			typedClient: fake.NewClientBuilder().WithScheme(scheme.BuildWithAllKnownScheme()).Build(),
+			cluster:     &apiv1.Cluster{},
+		}
+
+		machine.recordState(context.TODO(), apiv1.RestoreStateWriteConfig)
+		Expect(tracker.Get().Phase).To(Equal(apiv1.RestoreStateWriteConfig))
+	})
+})
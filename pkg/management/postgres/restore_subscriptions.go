@@ -0,0 +1,157 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// subscriptionsQuery lists the logical replication subscriptions
+// of every database, together with their state
+const subscriptionsQuery = "SELECT s.subname, d.datname, s.subenabled " +
+	"FROM pg_catalog.pg_subscription s " +
+	"JOIN pg_catalog.pg_database d ON d.oid = s.subdbid " +
+	"ORDER BY d.datname, s.subname"
+
+// restoredSubscription is a logical replication subscription
+// inherited from the source of the restore
+type restoredSubscription struct {
+	name     string
+	database string
+	enabled  bool
+}
+
+// getRecoverySubscriptionsPolicy gets the action to be taken on the
+// subscriptions inherited from the source of the restore
+func getRecoverySubscriptionsPolicy(cluster *apiv1.Cluster) apiv1.RecoverySubscriptionsPolicy {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.Subscriptions == "" {
+		return apiv1.RecoverySubscriptionsPolicyKeep
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Subscriptions
+}
+
+// handleRestoredSubscriptions disables or drops, as requested by the user,
+// the logical replication subscriptions inherited from the source of the
+// restore, which would otherwise connect to their publishers and consume
+// the changes meant for the source. Each statement is executed from the
+// database of the subscription, as required by PostgreSQL
+func handleRestoredSubscriptions(
+	ctx context.Context,
+	policy apiv1.RecoverySubscriptionsPolicy,
+	db *sql.DB,
+	connect func(databaseName string) (*sql.DB, error),
+) error {
+	if policy == apiv1.RecoverySubscriptionsPolicyKeep {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+
+	subscriptions, err := listRestoredSubscriptions(ctx, db)
+	if err != nil {
+		return fmt.Errorf("while listing the subscriptions: %w", err)
+	}
+
+	if len(subscriptions) == 0 {
+		contextLogger.Info("No logical replication subscription found in the restored instance")
+		return nil
+	}
+
+	for _, subscription := range subscriptions {
+		subscriptionDB, err := connect(subscription.database)
+		if err != nil {
+			return fmt.Errorf("could not connect to database %s: %w", subscription.database, err)
+		}
+
+		if err := retryPostRecoveryWrite(ctx, "subscriptions", func() error {
+			return applySubscriptionsPolicy(ctx, subscriptionDB, subscription, policy)
+		}); err != nil {
+			return fmt.Errorf("while applying the %s action on subscription %s in database %s: %w",
+				policy, subscription.name, subscription.database, err)
+		}
+
+		contextLogger.Info("Acted on a logical replication subscription inherited by the restored instance",
+			"subscription", subscription.name,
+			"database", subscription.database,
+			"wasEnabled", subscription.enabled,
+			"action", policy)
+	}
+
+	return nil
+}
+
+// listRestoredSubscriptions lists the logical replication
+// subscriptions of the restored instance
+func listRestoredSubscriptions(ctx context.Context, db *sql.DB) ([]restoredSubscription, error) {
+	rows, err := db.QueryContext(ctx, subscriptionsQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []restoredSubscription
+	for rows.Next() {
+		var subscription restoredSubscription
+		if err := rows.Scan(&subscription.name, &subscription.database, &subscription.enabled); err != nil {
+			return nil, err
+		}
+		result = append(result, subscription)
+	}
+
+	return result, rows.Err()
+}
+
+// applySubscriptionsPolicy disables or drops a subscription. Before being
+// dropped, a subscription is detached from its replication slot, so that
+// PostgreSQL doesn't connect to the publisher to drop the slot, which is
+// still used by the source of the restore
+func applySubscriptionsPolicy(
+	ctx context.Context,
+	db *sql.DB,
+	subscription restoredSubscription,
+	policy apiv1.RecoverySubscriptionsPolicy,
+) error {
+	name := pgx.Identifier{subscription.name}.Sanitize()
+
+	if subscription.enabled {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER SUBSCRIPTION %s DISABLE", name)); err != nil {
+			return err
+		}
+	}
+
+	if policy != apiv1.RecoverySubscriptionsPolicyDrop {
+		return nil
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("ALTER SUBSCRIPTION %s SET (slot_name = NONE)", name)); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, fmt.Sprintf("DROP SUBSCRIPTION %s", name))
+	return err
+}
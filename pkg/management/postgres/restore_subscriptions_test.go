@@ -0,0 +1,101 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("logical replication subscriptions of the restored instance", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	connect := func(databaseName string) (*sql.DB, error) {
+		Expect(databaseName).To(Equal("app"))
+		return db, nil
+	}
+
+	expectSubscriptions := func() {
+		mock.ExpectQuery(regexp.QuoteMeta(subscriptionsQuery)).
+			WillReturnRows(sqlmock.NewRows([]string{"subname", "datname", "subenabled"}).
+				AddRow("orders", "app", true).
+				AddRow("Audit", "app", false))
+	}
+
+	It("keeps the subscriptions by default", func() {
+		Expect(getRecoverySubscriptionsPolicy(&apiv1.Cluster{})).To(Equal(apiv1.RecoverySubscriptionsPolicyKeep))
+		Expect(handleRestoredSubscriptions(
+			context.TODO(), apiv1.RecoverySubscriptionsPolicyKeep, db, connect)).To(Succeed())
+	})
+
+	It("disables the enabled subscriptions", func() {
+		expectSubscriptions()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER SUBSCRIPTION "orders" DISABLE`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(handleRestoredSubscriptions(
+			context.TODO(), apiv1.RecoverySubscriptionsPolicyDisable, db, connect)).To(Succeed())
+	})
+
+	It("drops the subscriptions, detaching them from their slots", func() {
+		expectSubscriptions()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER SUBSCRIPTION "orders" DISABLE`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER SUBSCRIPTION "orders" SET (slot_name = NONE)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DROP SUBSCRIPTION "orders"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER SUBSCRIPTION "Audit" SET (slot_name = NONE)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`DROP SUBSCRIPTION "Audit"`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		Expect(handleRestoredSubscriptions(
+			context.TODO(), apiv1.RecoverySubscriptionsPolicyDrop, db, connect)).To(Succeed())
+	})
+
+	It("fails when a subscription can't be disabled", func() {
+		expectSubscriptions()
+		mock.ExpectExec(regexp.QuoteMeta(`ALTER SUBSCRIPTION "orders" DISABLE`)).
+			WillReturnError(errors.New("permission denied"))
+
+		err := handleRestoredSubscriptions(context.TODO(), apiv1.RecoverySubscriptionsPolicyDisable, db, connect)
+		Expect(err).To(MatchError(ContainSubstring("disable action on subscription orders in database app")))
+	})
+})
@@ -0,0 +1,61 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres/replication"
+)
+
+// relaxSynchronousReplication disables the synchronous replication requested
+// by the cluster while the restored instance is configured. No standby can
+// exist at this stage, and every commit, including the ones needed to set
+// the passwords, would otherwise wait forever for a synchronous standby.
+// With an empty synchronous_standby_names, synchronous_commit doesn't wait
+// for any standby, whatever its value, and can be left untouched.
+// The custom.conf file is generated again, with the synchronous replication
+// settings of the cluster, once the instance is started by its Pod and the
+// standbys can join
+func (info InitInfo) relaxSynchronousReplication(ctx context.Context, cluster *apiv1.Cluster) error {
+	synchronousStandbyNames := replication.GetSynchronousStandbyNames(cluster)
+	if synchronousStandbyNames == "" {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		map[string]string{postgres.SynchronousStandbyNames: ""},
+	); err != nil {
+		return fmt.Errorf("while relaxing the synchronous replication for the restore: %w", err)
+	}
+
+	log.FromContext(ctx).Info(
+		"Synchronous replication temporarily disabled while configuring the restored instance, "+
+			"as no synchronous standby exists yet. It will be enabled again when the instance starts",
+		"synchronousStandbyNames", synchronousStandbyNames)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("synchronous replication during the restore", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		pgData, err := os.MkdirTemp("", "restore-synchronous")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() { _ = os.RemoveAll(pgData) })
+
+		info = InitInfo{PgData: pgData}
+		Expect(os.WriteFile(
+			path.Join(pgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("synchronous_standby_names = 'ANY 1 (\"cluster-2\")'\nshared_buffers = '128MB'\n"),
+			0o600)).To(Succeed())
+	})
+
+	readCustomConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	It("disables the synchronous replication required by the cluster", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					Synchronous: &apiv1.SynchronousReplicaConfiguration{
+						Method:          apiv1.SynchronousReplicaConfigurationMethodAny,
+						Number:          1,
+						StandbyNamesPre: []string{"cluster-2"},
+					},
+				},
+			},
+		}
+
+		Expect(info.relaxSynchronousReplication(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal("synchronous_standby_names = ''\nshared_buffers = '128MB'\n"))
+	})
+
+	It("leaves the configuration untouched without synchronous replication", func() {
+		Expect(info.relaxSynchronousReplication(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(readCustomConf()).To(ContainSubstring("synchronous_standby_names = 'ANY 1 (\"cluster-2\")'"))
+	})
+})
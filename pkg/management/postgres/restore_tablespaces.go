@@ -0,0 +1,96 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrDanglingTablespaceLinks is raised when the restored data directory
+// contains links to tablespace locations that don't exist
+var ErrDanglingTablespaceLinks = errors.New("the restored data directory links to missing tablespace locations")
+
+// checkTablespaceLinks verifies, before the instance is started, that the
+// location of every tablespace linked in pg_tblspc exists. A missing
+// location is created when the directory containing it exists, as it
+// happens when the volume of the tablespace is mounted but empty. The
+// locations that can't be created are reported all together, as PostgreSQL
+// would fail to start with a confusing error
+func (info InitInfo) checkTablespaceLinks(ctx context.Context) error {
+	contextLogger := log.FromContext(ctx)
+
+	linksDirectory := filepath.Join(info.PgData, tablespacesLinksDirectory)
+	entries, err := os.ReadDir(linksDirectory)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("while listing the tablespace links: %w", err)
+	}
+
+	var missing []string
+	for _, entry := range entries {
+		// In-place tablespaces are directories inside pg_tblspc
+		if entry.Type()&fs.ModeSymlink == 0 {
+			continue
+		}
+
+		link := filepath.Join(linksDirectory, entry.Name())
+		location, err := os.Readlink(link)
+		if err != nil {
+			return fmt.Errorf("while reading the tablespace link %s: %w", link, err)
+		}
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(linksDirectory, location)
+		}
+
+		_, err = os.Stat(location)
+		if err == nil {
+			continue
+		}
+		if !os.IsNotExist(err) {
+			return fmt.Errorf("while checking the location %s of tablespace %s: %w", location, entry.Name(), err)
+		}
+
+		if _, err := os.Stat(filepath.Dir(location)); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (tablespace %s)", location, entry.Name()))
+			continue
+		}
+
+		contextLogger.Warning("Creating the missing location of a restored tablespace, which will be empty",
+			"tablespace", entry.Name(),
+			"location", location)
+		if err := os.Mkdir(location, 0o700); err != nil {
+			return fmt.Errorf("while creating the location %s of tablespace %s: %w", location, entry.Name(), err)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s. Declare the tablespaces in the cluster or restore them elsewhere "+
+			"with the tablespaceRemap option", ErrDanglingTablespaceLinks, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restored tablespace links", func() {
+	var (
+		pgData string
+		root   string
+		info   InitInfo
+	)
+
+	BeforeEach(func() {
+		root = GinkgoT().TempDir()
+		pgData = path.Join(root, "pgdata")
+		Expect(os.MkdirAll(path.Join(pgData, tablespacesLinksDirectory), 0o700)).To(Succeed())
+		info = InitInfo{PgData: pgData}
+	})
+
+	link := func(oid, location string) {
+		Expect(os.Symlink(location, path.Join(pgData, tablespacesLinksDirectory, oid))).To(Succeed())
+	}
+
+	It("accepts a data directory without tablespaces", func() {
+		Expect(info.checkTablespaceLinks(context.TODO())).To(Succeed())
+		Expect(InitInfo{PgData: path.Join(root, "missing")}.checkTablespaceLinks(context.TODO())).To(Succeed())
+	})
+
+	It("creates the missing locations inside an existing directory", func() {
+		Expect(os.MkdirAll(path.Join(root, "tablespaces", "reports", "data"), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(root, "tablespaces", "archive"), 0o700)).To(Succeed())
+		link("16385", path.Join(root, "tablespaces", "reports", "data"))
+		link("16386", path.Join(root, "tablespaces", "archive", "data"))
+
+		Expect(info.checkTablespaceLinks(context.TODO())).To(Succeed())
+		Expect(path.Join(root, "tablespaces", "archive", "data")).To(BeADirectory())
+	})
+
+	It("reports every dangling link", func() {
+		link("16385", path.Join(root, "old", "reports", "data"))
+		link("16386", path.Join(root, "old", "archive", "data"))
+
+		err := info.checkTablespaceLinks(context.TODO())
+		Expect(err).To(MatchError(ErrDanglingTablespaceLinks))
+		Expect(err.Error()).To(ContainSubstring(path.Join(root, "old", "reports", "data") + " (tablespace 16385)"))
+		Expect(err.Error()).To(ContainSubstring(path.Join(root, "old", "archive", "data") + " (tablespace 16386)"))
+	})
+
+	It("passes the remapped tablespaces to barman-cloud-restore", func() {
+		runner := &fakeBarmanRunner{}
+		info := InitInfo{
+			PgData:       "/var/lib/postgresql/data/pgdata",
+			BarmanRunner: runner,
+			TablespaceRemap: []apiv1.RecoveryTablespaceRemap{
+				{Name: "reports", Location: "/var/lib/postgresql/tablespaces/analytics/data"},
+			},
+		}
+		backup := &apiv1.Backup{
+			Status: apiv1.BackupStatus{DestinationPath: "s3://backups/", ServerName: "source", BackupID: "20240101T000000"},
+		}
+
+		Expect(info.restoreDataDir(
+			context.TODO(), &apiv1.Cluster{}, backup, nil, getRestoreRetryPolicy(&apiv1.Cluster{}))).To(Succeed())
+		Expect(runner.restoreOptions).To(Equal([]string{
+			"s3://backups/",
+			"source",
+			"20240101T000000",
+			"--tablespace", "reports:/var/lib/postgresql/tablespaces/analytics/data",
+			"/var/lib/postgresql/data/pgdata",
+		}))
+	})
+})
@@ -0,0 +1,170 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrInvalidRecoveryTargetAnnotation is raised when the recovery target
+// set in the annotation of the cluster can't be used
+var ErrInvalidRecoveryTargetAnnotation = errors.New("invalid recovery target annotation")
+
+// recoveryTargetSource is where the recovery target used
+// for the restore comes from
+type recoveryTargetSource string
+
+const (
+	// recoveryTargetSourceSpec is used when the recovery target
+	// is the one defined in the cluster spec
+	recoveryTargetSourceSpec recoveryTargetSource = "spec"
+
+	// recoveryTargetSourceAnnotation is used when the recovery
+	// target is the one defined in the cluster annotation
+	recoveryTargetSourceAnnotation recoveryTargetSource = "annotation"
+
+	// recoveryTargetSourceMerged is used when the recovery target
+	// defined in the cluster annotation overrides some of the
+	// settings of the one defined in the cluster spec
+	recoveryTargetSourceMerged recoveryTargetSource = "annotation merged with spec"
+)
+
+// applyRecoveryTargetAnnotation makes the cluster use the recovery target
+// defined in its annotation, if any. The settings in the annotation take
+// precedence over the ones in the spec, and when the annotation sets one
+// of the mutually exclusive targets, the ones in the spec are discarded.
+// The cluster is changed in memory only, and the source of the resulting
+// recovery target is returned
+func applyRecoveryTargetAnnotation(cluster *apiv1.Cluster) (recoveryTargetSource, error) {
+	content, ok := cluster.Annotations[utils.RecoveryTargetAnnotationName]
+	if !ok || cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return recoveryTargetSourceSpec, nil
+	}
+
+	var annotationTarget apiv1.RecoveryTarget
+	decoder := json.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&annotationTarget); err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidRecoveryTargetAnnotation, content, err)
+	}
+	if err := annotationTarget.Validate(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRecoveryTargetAnnotation, err)
+	}
+
+	specTarget := cluster.Spec.Bootstrap.Recovery.RecoveryTarget
+	if specTarget == nil {
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget = &annotationTarget
+		return recoveryTargetSourceAnnotation, nil
+	}
+
+	merged := mergeRecoveryTargets(specTarget, &annotationTarget)
+	if err := merged.Validate(); err != nil {
+		return "", fmt.Errorf("%w: once merged with the recovery target of the spec: %v",
+			ErrInvalidRecoveryTargetAnnotation, err)
+	}
+
+	cluster.Spec.Bootstrap.Recovery.RecoveryTarget = merged
+	return recoveryTargetSourceMerged, nil
+}
+
+// mergeRecoveryTargets overrides the settings of a recovery target with
+// the ones set in another one. When the latter sets one of the mutually
+// exclusive targets, the ones of the former are discarded
+func mergeRecoveryTargets(base, overrides *apiv1.RecoveryTarget) *apiv1.RecoveryTarget {
+	result := base.DeepCopy()
+	if overrides.HasTarget() {
+		result.TargetImmediate = nil
+		result.TargetLSN = ""
+		result.TargetName = ""
+		result.TargetXID = ""
+		result.TargetTime = ""
+		result.TargetWAL = ""
+		result.TargetBackupEnd = nil
+	}
+
+	if overrides.BackupID != "" {
+		result.BackupID = overrides.BackupID
+	}
+	if overrides.TargetTLI != "" {
+		result.TargetTLI = overrides.TargetTLI
+	}
+	if overrides.TargetXID != "" {
+		result.TargetXID = overrides.TargetXID
+	}
+	if overrides.TargetName != "" {
+		result.TargetName = overrides.TargetName
+	}
+	if overrides.TargetLSN != "" {
+		result.TargetLSN = overrides.TargetLSN
+	}
+	if overrides.TargetWAL != "" {
+		result.TargetWAL = overrides.TargetWAL
+	}
+	if overrides.TargetTime != "" {
+		result.TargetTime = overrides.TargetTime
+	}
+	if overrides.TargetImmediate != nil {
+		result.TargetImmediate = overrides.TargetImmediate
+	}
+	if overrides.TargetBackupEnd != nil {
+		result.TargetBackupEnd = overrides.TargetBackupEnd
+	}
+	if overrides.Exclusive != nil {
+		result.Exclusive = overrides.Exclusive
+	}
+
+	return result
+}
+
+// recordRecoveryTargetSource logs, and reports with an event on the
+// cluster, where the recovery target used for the restore comes from
+func recordRecoveryTargetSource(ctx context.Context, cluster *apiv1.Cluster, source recoveryTargetSource) {
+	contextLogger := log.FromContext(ctx)
+
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget == nil {
+		return
+	}
+
+	target, err := json.Marshal(cluster.Spec.Bootstrap.Recovery.RecoveryTarget)
+	if err != nil {
+		contextLogger.Warning("Cannot encode the recovery target", "error", err.Error())
+		return
+	}
+
+	contextLogger.Info("Using the recovery target",
+		"source", source,
+		"recoveryTarget", string(target))
+
+	recorder, err := management.NewEventRecorder()
+	if err != nil {
+		contextLogger.Warning("Cannot create the event recorder, the source of the recovery target won't be reported",
+			"error", err.Error())
+		return
+	}
+	recorder.Eventf(cluster, "Normal", "RecoveryTarget",
+		"Recovery target from the %s: %s", source, string(target))
+}
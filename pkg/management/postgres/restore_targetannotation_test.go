@@ -0,0 +1,102 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery target from the cluster annotation", func() {
+	newCluster := func(annotation string, target *apiv1.RecoveryTarget) *apiv1.Cluster {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", RecoveryTarget: target},
+				},
+			},
+		}
+		if annotation != "" {
+			cluster.Annotations = map[string]string{utils.RecoveryTargetAnnotationName: annotation}
+		}
+		return cluster
+	}
+
+	It("uses the recovery target of the spec without the annotation", func() {
+		cluster := newCluster("", &apiv1.RecoveryTarget{TargetLSN: "0/3000060"})
+
+		source, err := applyRecoveryTargetAnnotation(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source).To(Equal(recoveryTargetSourceSpec))
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget).To(Equal(&apiv1.RecoveryTarget{TargetLSN: "0/3000060"}))
+	})
+
+	It("uses the recovery target of the annotation when the spec doesn't have one", func() {
+		cluster := newCluster(`{"targetName": "before-migration"}`, nil)
+
+		source, err := applyRecoveryTargetAnnotation(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source).To(Equal(recoveryTargetSourceAnnotation))
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget).To(Equal(
+			&apiv1.RecoveryTarget{TargetName: "before-migration"}))
+	})
+
+	It("replaces the target of the spec, keeping its other settings", func() {
+		cluster := newCluster(`{"targetTime": "2026-10-01 10:00:00+00"}`, &apiv1.RecoveryTarget{
+			BackupID:  "20261001T000000",
+			TargetTLI: "2",
+			TargetLSN: "0/3000060",
+		})
+
+		source, err := applyRecoveryTargetAnnotation(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(source).To(Equal(recoveryTargetSourceMerged))
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget).To(Equal(&apiv1.RecoveryTarget{
+			BackupID:   "20261001T000000",
+			TargetTLI:  "2",
+			TargetTime: "2026-10-01 10:00:00+00",
+		}))
+	})
+
+	It("keeps the target of the spec when the annotation doesn't set one", func() {
+		cluster := newCluster(`{"exclusive": true}`, &apiv1.RecoveryTarget{TargetLSN: "0/3000060"})
+
+		_, err := applyRecoveryTargetAnnotation(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget).To(Equal(&apiv1.RecoveryTarget{
+			TargetLSN: "0/3000060",
+			Exclusive: ptr.To(true),
+		}))
+	})
+
+	It("rejects an invalid annotation", func() {
+		for _, annotation := range []string{
+			`{"targetLSN": `,
+			`{"recoveryTargetLSN": "0/3000060"}`,
+			`{"targetLSN": "0/3000060", "targetName": "before-migration"}`,
+			`{"targetLSN": "not-an-lsn"}`,
+		} {
+			_, err := applyRecoveryTargetAnnotation(newCluster(annotation, nil))
+			Expect(err).To(MatchError(ErrInvalidRecoveryTargetAnnotation), annotation)
+		}
+	})
+})
@@ -0,0 +1,409 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+const (
+	// recoveryStoppingMessagePrefix is the prefix of the messages logged
+	// by PostgreSQL when the recovery stops at the recovery target
+	recoveryStoppingMessagePrefix = "recovery stopping "
+
+	// recoveryEndedBeforeTargetMessage is the message logged by PostgreSQL,
+	// starting from version 13, when the WAL files end before the recovery
+	// target is reached
+	recoveryEndedBeforeTargetMessage = "recovery ended before configured recovery target was reached"
+)
+
+// ErrRecoveryTargetNotReached is raised when the recovery ended before
+// reaching the requested recovery target, and the user asked to fail
+var ErrRecoveryTargetNotReached = errors.New("the recovery ended before reaching the recovery target")
+
+// getRequestedRecoveryTarget gets the recovery target requested by the
+// user, if any. A recovery target selecting only a backup or a timeline
+// is not considered, as PostgreSQL replays every WAL file it finds
+func getRequestedRecoveryTarget(cluster *apiv1.Cluster) *apiv1.RecoveryTarget {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		!cluster.Spec.Bootstrap.Recovery.RecoveryTarget.HasTarget() {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.RecoveryTarget
+}
+
+// ErrRecoveryTargetTimeMismatch is raised when the commit time of the last
+// replayed transaction is not consistent with the recovery target time,
+// and the user asked to fail
+var ErrRecoveryTargetTimeMismatch = errors.New(
+	"the last replayed transaction is not consistent with the recovery target time")
+
+// getTargetTimeCheck gets the verification of the recovery target
+// time requested by the user, if any
+func getTargetTimeCheck(cluster *apiv1.Cluster) *apiv1.RecoveryTargetTimeCheck {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.TargetTimeCheck
+}
+
+// isStrictRecoveryTarget checks if the recovery should fail when
+// the recovery target is not reached
+func isStrictRecoveryTarget(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.StrictRecoveryTarget
+}
+
+// recoveryTargetCollector is a log record writer detecting whether
+// PostgreSQL stopped the recovery at the recovery target, while
+// forwarding every record to another writer
+type recoveryTargetCollector struct {
+	writer logpipe.RecordWriter
+
+	mu                sync.Mutex
+	stopped           bool
+	endedBeforeTarget bool
+}
+
+// newRecoveryTargetCollector creates a collector forwarding the log
+// records to the passed writer, or to the instance manager logger
+// when it is nil
+func newRecoveryTargetCollector(writer logpipe.RecordWriter) *recoveryTargetCollector {
+	if writer == nil {
+		writer = &logpipe.LogRecordWriter{}
+	}
+
+	return &recoveryTargetCollector{writer: writer}
+}
+
+// Write implements the logpipe.RecordWriter interface
+func (collector *recoveryTargetCollector) Write(record logpipe.NamedRecord) {
+	collector.writer.Write(record)
+
+	loggingRecord, ok := record.(*logpipe.LoggingRecord)
+	if !ok {
+		return
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(loggingRecord.Message, recoveryStoppingMessagePrefix):
+		collector.stopped = true
+	case loggingRecord.Message == recoveryEndedBeforeTargetMessage:
+		collector.endedBeforeTarget = true
+	}
+}
+
+// hasStoppedAtTarget checks if PostgreSQL reported to have stopped
+// the recovery at the recovery target
+func (collector *recoveryTargetCollector) hasStoppedAtTarget() bool {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	return collector.stopped && !collector.endedBeforeTarget
+}
+
+// hasEndedBeforeTarget checks if PostgreSQL reported that the WAL files
+// ended before the recovery target was reached
+func (collector *recoveryTargetCollector) hasEndedBeforeTarget() bool {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	return collector.endedBeforeTarget
+}
+
+// getRecoveryReachedPoint gets the LSN of the last WAL record, and the
+// commit time of the last transaction, replayed during the recovery.
+// PostgreSQL keeps them available once the recovery is completed
+func getRecoveryReachedPoint(ctx context.Context, db *sql.DB) (string, string, error) {
+	var reachedLSN, reachedTime sql.NullString
+	if err := db.QueryRowContext(
+		ctx,
+		"SELECT pg_catalog.pg_last_wal_replay_lsn()::text, pg_catalog.pg_last_xact_replay_timestamp()::text",
+	).Scan(&reachedLSN, &reachedTime); err != nil {
+		return "", "", fmt.Errorf("while getting the point reached by the recovery: %w", err)
+	}
+
+	return reachedLSN.String, reachedTime.String, nil
+}
+
+// newRecoveryTargetReport creates the report of the point reached
+// by the recovery. When PostgreSQL didn't report to have stopped at the
+// target, an LSN target is considered reached if the last replayed LSN
+// is not before it
+func newRecoveryTargetReport(
+	target *apiv1.RecoveryTarget,
+	collector *recoveryTargetCollector,
+	reachedLSN, reachedTime string,
+) *apiv1.RecoveryTargetReport {
+	reached := collector.hasStoppedAtTarget()
+	if !reached && !collector.hasEndedBeforeTarget() && target.TargetLSN != "" && reachedLSN != "" {
+		shortfall, measured := computeRecoveryTargetShortfall(target, reachedLSN, reachedTime)
+		reached = measured && shortfall.bytes == 0
+	}
+
+	return &apiv1.RecoveryTargetReport{
+		Requested:   *target.DeepCopy(),
+		ReachedLSN:  reachedLSN,
+		ReachedTime: reachedTime,
+		Reached:     reached,
+	}
+}
+
+// recoveryTargetShortfall is the distance between the point reached
+// by the recovery and the requested recovery target
+type recoveryTargetShortfall struct {
+	bytes    int64
+	duration time.Duration
+}
+
+// computeRecoveryTargetShortfall computes how far the point reached by the
+// recovery is from the recovery target. Only the distance from LSN and time
+// targets can be measured, and false is returned for the other ones
+func computeRecoveryTargetShortfall(
+	target *apiv1.RecoveryTarget,
+	reachedLSN, reachedTime string,
+) (recoveryTargetShortfall, bool) {
+	var result recoveryTargetShortfall
+
+	switch {
+	case target.TargetLSN != "":
+		targetPosition, err := postgresSpec.LSN(target.TargetLSN).Parse()
+		if err != nil {
+			return result, false
+		}
+		reachedPosition, err := postgresSpec.LSN(reachedLSN).Parse()
+		if err != nil {
+			return result, false
+		}
+		result.bytes = max(targetPosition-reachedPosition, 0)
+
+	case target.TargetTime != "":
+		targetTime, err := utils.ParseTargetTime(nil, target.TargetTime)
+		if err != nil {
+			return result, false
+		}
+		lastTransactionTime, err := utils.ParseTargetTime(nil, reachedTime)
+		if err != nil {
+			return result, false
+		}
+		result.duration = max(targetTime.Sub(lastTransactionTime), 0)
+
+	default:
+		return result, false
+	}
+
+	return result, true
+}
+
+// setRestoreResultTarget records in the result of the restore whether
+// the recovery target has been reached and, if not, how far from it the
+// recovery ended
+func setRestoreResultTarget(result *apiv1.RestoreResult, report *apiv1.RecoveryTargetReport) {
+	result.TargetReached = ptr.To(report.Reached)
+	if report.Reached {
+		return
+	}
+
+	shortfall, measured := computeRecoveryTargetShortfall(&report.Requested, report.ReachedLSN, report.ReachedTime)
+	if !measured {
+		return
+	}
+
+	result.TargetShortfallBytes = shortfall.bytes
+	if shortfall.duration > 0 {
+		result.TargetShortfallTime = &metav1.Duration{Duration: shortfall.duration}
+	}
+}
+
+// checkRecoveryTargetReport logs the point reached by the recovery,
+// and raises an error when the recovery target has not been reached
+// and the user asked for it
+func checkRecoveryTargetReport(ctx context.Context, report *apiv1.RecoveryTargetReport, strict bool) error {
+	contextLogger := log.FromContext(ctx)
+	if report.Reached {
+		contextLogger.Info("The recovery stopped at the requested recovery target",
+			"requested", report.Requested,
+			"reachedLSN", report.ReachedLSN,
+			"reachedTime", report.ReachedTime)
+		return nil
+	}
+
+	shortfall, measured := computeRecoveryTargetShortfall(&report.Requested, report.ReachedLSN, report.ReachedTime)
+	if strict {
+		err := fmt.Errorf("%w: requested %+v, reached LSN %q and time %q",
+			ErrRecoveryTargetNotReached, report.Requested, report.ReachedLSN, report.ReachedTime)
+		if measured {
+			err = fmt.Errorf("%w, short by %d bytes and %s", err, shortfall.bytes, shortfall.duration)
+		}
+		return err
+	}
+
+	contextLogger.Warning(
+		"RECOVERY TARGET NOT REACHED: the recovery ended before reaching the requested recovery "+
+			"target, probably because the WAL files ended earlier. The restored data may not contain "+
+			"every change you expected",
+		"requested", report.Requested,
+		"reachedLSN", report.ReachedLSN,
+		"reachedTime", report.ReachedTime,
+		"shortfallBytes", shortfall.bytes,
+		"shortfallTime", shortfall.duration.String())
+	return nil
+}
+
+// checkRecoveryTargetTime compares the commit time of the last replayed
+// transaction with the recovery target time, recording the result in the
+// report. The transaction must not have committed after the target, or at
+// the target when it is exclusive, nor too long before it. An error is
+// raised only when the comparison fails and the check is strict
+func checkRecoveryTargetTime(
+	ctx context.Context,
+	check *apiv1.RecoveryTargetTimeCheck,
+	report *apiv1.RecoveryTargetReport,
+) error {
+	if check == nil || report.Requested.TargetTime == "" {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	if report.ReachedTime == "" {
+		contextLogger.Warning("No transaction has been replayed, the recovery target time cannot be verified",
+			"targetTime", report.Requested.TargetTime)
+		return nil
+	}
+
+	targetTime, err := utils.ParseTargetTime(nil, report.Requested.TargetTime)
+	if err != nil {
+		return fmt.Errorf("while parsing the recovery target time %q: %w", report.Requested.TargetTime, err)
+	}
+	lastTransactionTime, err := utils.ParseTargetTime(nil, report.ReachedTime)
+	if err != nil {
+		return fmt.Errorf("while parsing the commit time of the last replayed transaction %q: %w",
+			report.ReachedTime, err)
+	}
+
+	gap := targetTime.Sub(lastTransactionTime)
+	exclusive := report.Requested.Exclusive != nil && *report.Requested.Exclusive
+	var problem string
+	switch {
+	case gap < 0 || (gap == 0 && exclusive):
+		problem = "the last replayed transaction committed after the recovery target time"
+	case gap > check.GetMaxGap():
+		problem = "the last replayed transaction committed too long before the recovery target time"
+	}
+
+	report.TimeGap = &metav1.Duration{Duration: gap}
+	report.TimeVerified = ptr.To(problem == "")
+
+	if problem == "" {
+		contextLogger.Info("The last replayed transaction is consistent with the recovery target time",
+			"targetTime", report.Requested.TargetTime,
+			"reachedTime", report.ReachedTime,
+			"gap", gap.String())
+		return nil
+	}
+
+	if check.Strict {
+		return fmt.Errorf("%w: %s: requested %q, reached %q, gap %s, maximum gap %s",
+			ErrRecoveryTargetTimeMismatch, problem, report.Requested.TargetTime, report.ReachedTime,
+			gap, check.GetMaxGap())
+	}
+
+	contextLogger.Warning(
+		"RECOVERY TARGET TIME MISMATCH: "+problem+". The recovery may not have stopped where intended",
+		"targetTime", report.Requested.TargetTime,
+		"reachedTime", report.ReachedTime,
+		"gap", gap.String(),
+		"maxGap", check.GetMaxGap().String())
+	return nil
+}
+
+// completeRecoveryTargetCheck reports the point reached by the recovery
+// in the cluster status and checks it against the recovery target
+func (info InitInfo) completeRecoveryTargetCheck(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	report *apiv1.RecoveryTargetReport,
+) error {
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+
+	timeErr := checkRecoveryTargetTime(ctx, getTargetTimeCheck(cluster), report)
+
+	if err := info.reportRecoveryTarget(ctx, typedClient, report); err != nil {
+		return err
+	}
+
+	if err := checkRecoveryTargetReport(ctx, report, isStrictRecoveryTarget(cluster)); err != nil {
+		return err
+	}
+
+	return timeErr
+}
+
+// reportRecoveryTarget writes the point reached by the recovery
+// in the cluster status
+func (info InitInfo) reportRecoveryTarget(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.RecoveryTargetReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.RecoveryTarget = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the reached recovery target in the cluster status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("checking the reached recovery target", func() {
+	target := &apiv1.RecoveryTarget{TargetTime: "2024-01-01 12:00:00+00"}
+
+	It("rejects conflicting recovery targets before starting the restore", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						RecoveryTarget: &apiv1.RecoveryTarget{TargetName: "before-migration", TargetTime: "2024-01-01"},
+					},
+				},
+			},
+		}
+		Expect(validateRecoveryTarget(cluster)).To(MatchError(apiv1.ErrConflictingRecoveryTargets))
+
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget.TargetName = ""
+		Expect(validateRecoveryTarget(cluster)).To(Succeed())
+		Expect(validateRecoveryTarget(&apiv1.Cluster{})).To(Succeed())
+	})
+
+	It("considers only the recovery targets", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						RecoveryTarget: &apiv1.RecoveryTarget{BackupID: "20240101T000000"},
+					},
+				},
+			},
+		}
+		Expect(getRequestedRecoveryTarget(cluster)).To(BeNil())
+		Expect(getRequestedRecoveryTarget(&apiv1.Cluster{})).To(BeNil())
+
+		cluster.Spec.Bootstrap.Recovery.RecoveryTarget = target
+		Expect(getRequestedRecoveryTarget(cluster)).To(Equal(target))
+	})
+
+	It("detects when the recovery stops at the target, forwarding every record", func() {
+		writer := &recordingWriter{}
+		collector := newRecoveryTargetCollector(writer)
+
+		collector.Write(&logpipe.LoggingRecord{Message: "redo starts at 0/2000028"})
+		Expect(collector.hasStoppedAtTarget()).To(BeFalse())
+
+		collector.Write(&logpipe.LoggingRecord{
+			Message: "recovery stopping before commit of transaction 742, time 2024-01-01 12:00:03.1+00",
+		})
+		Expect(collector.hasStoppedAtTarget()).To(BeTrue())
+		Expect(collector.hasEndedBeforeTarget()).To(BeFalse())
+		Expect(writer.records).To(HaveLen(2))
+	})
+
+	It("detects when the recovery ends before the target", func() {
+		collector := newRecoveryTargetCollector(&recordingWriter{})
+		collector.Write(&logpipe.LoggingRecord{
+			ErrorSeverity: "FATAL",
+			Message:       "recovery ended before configured recovery target was reached",
+		})
+		Expect(collector.hasStoppedAtTarget()).To(BeFalse())
+		Expect(collector.hasEndedBeforeTarget()).To(BeTrue())
+	})
+
+	It("gets the point reached by the recovery", func() {
+		db, mock, err := sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		mock.ExpectQuery("pg_last_wal_replay_lsn").
+			WillReturnRows(sqlmock.NewRows([]string{"lsn", "time"}).AddRow("0/3000148", nil))
+
+		reachedLSN, reachedTime, err := getRecoveryReachedPoint(context.TODO(), db)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reachedLSN).To(Equal("0/3000148"))
+		Expect(reachedTime).To(BeEmpty())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("fails only in strict mode when the target is not reached", func() {
+		collector := newRecoveryTargetCollector(&recordingWriter{})
+		report := newRecoveryTargetReport(target, collector, "0/3000148", "2024-01-01 11:58:00+00")
+		Expect(report.Reached).To(BeFalse())
+		Expect(report.Requested).To(Equal(*target))
+
+		Expect(checkRecoveryTargetReport(context.TODO(), report, false)).To(Succeed())
+		Expect(checkRecoveryTargetReport(context.TODO(), report, true)).To(MatchError(ErrRecoveryTargetNotReached))
+
+		report.Reached = true
+		Expect(checkRecoveryTargetReport(context.TODO(), report, true)).To(Succeed())
+	})
+
+	It("records in the restore result how far the recovery ended from a time target", func() {
+		collector := newRecoveryTargetCollector(&recordingWriter{})
+		report := newRecoveryTargetReport(target, collector, "0/3000148", "2024-01-01 10:00:00+00")
+		Expect(checkRecoveryTargetReport(context.TODO(), report, true)).
+			To(MatchError(ContainSubstring("short by 0 bytes and 2h0m0s")))
+
+		result := &apiv1.RestoreResult{WALReplayed: true}
+		setRestoreResultTarget(result, report)
+		Expect(result.TargetReached).To(Equal(ptr.To(false)))
+		Expect(result.TargetShortfallTime).To(Equal(&metav1.Duration{Duration: 2 * time.Hour}))
+		Expect(result.TargetShortfallBytes).To(BeZero())
+	})
+
+	It("compares the last replayed LSN with an LSN target", func() {
+		lsnTarget := &apiv1.RecoveryTarget{TargetLSN: "0/3000000"}
+
+		collector := newRecoveryTargetCollector(&recordingWriter{})
+		report := newRecoveryTargetReport(lsnTarget, collector, "0/2FFF000", "")
+		Expect(report.Reached).To(BeFalse())
+
+		result := &apiv1.RestoreResult{}
+		setRestoreResultTarget(result, report)
+		Expect(result.TargetReached).To(Equal(ptr.To(false)))
+		Expect(result.TargetShortfallBytes).To(Equal(int64(0x1000)))
+		Expect(result.TargetShortfallTime).To(BeNil())
+
+		report = newRecoveryTargetReport(lsnTarget, collector, "0/3000148", "")
+		Expect(report.Reached).To(BeTrue())
+
+		result = &apiv1.RestoreResult{}
+		setRestoreResultTarget(result, report)
+		Expect(result.TargetReached).To(Equal(ptr.To(true)))
+		Expect(result.TargetShortfallBytes).To(BeZero())
+	})
+
+	It("doesn't trust the LSN when PostgreSQL reported the end of the WAL files", func() {
+		collector := newRecoveryTargetCollector(&recordingWriter{})
+		collector.Write(&logpipe.LoggingRecord{Message: "recovery ended before configured recovery target was reached"})
+
+		report := newRecoveryTargetReport(&apiv1.RecoveryTarget{TargetLSN: "0/3000000"}, collector, "0/3000148", "")
+		Expect(report.Reached).To(BeFalse())
+	})
+
+	It("reports the reached point in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		report := &apiv1.RecoveryTargetReport{
+			Requested:  *target,
+			ReachedLSN: "0/3000148",
+			Reached:    true,
+		}
+
+		Expect(info.reportRecoveryTarget(context.TODO(), typedClient, report)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.RecoveryTarget).To(Equal(report))
+	})
+	It("verifies the commit time of the last replayed transaction", func() {
+		check := &apiv1.RecoveryTargetTimeCheck{}
+		report := &apiv1.RecoveryTargetReport{Requested: *target, ReachedTime: "2024-01-01 11:58:00.123456+00"}
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).To(Succeed())
+		Expect(report.TimeVerified).To(Equal(ptr.To(true)))
+		Expect(report.TimeGap.Duration).To(Equal(2*time.Minute - 123456*time.Microsecond))
+
+		Expect(checkRecoveryTargetTime(context.TODO(), nil, report)).To(Succeed())
+	})
+
+	It("respects the inclusive flag of the recovery target", func() {
+		check := &apiv1.RecoveryTargetTimeCheck{Strict: true}
+		report := &apiv1.RecoveryTargetReport{Requested: *target, ReachedTime: "2024-01-01 12:00:00+00"}
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).To(Succeed())
+		Expect(report.TimeVerified).To(Equal(ptr.To(true)))
+
+		report.Requested.Exclusive = ptr.To(true)
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).
+			To(MatchError(ErrRecoveryTargetTimeMismatch))
+		Expect(report.TimeVerified).To(Equal(ptr.To(false)))
+	})
+
+	It("fails only in strict mode when the gap is too large", func() {
+		check := &apiv1.RecoveryTargetTimeCheck{MaxGap: &metav1.Duration{Duration: 5 * time.Minute}}
+		report := &apiv1.RecoveryTargetReport{Requested: *target, ReachedTime: "2024-01-01 11:00:00+00"}
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).To(Succeed())
+		Expect(report.TimeVerified).To(Equal(ptr.To(false)))
+		Expect(report.TimeGap.Duration).To(Equal(time.Hour))
+
+		check.Strict = true
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).
+			To(MatchError(ErrRecoveryTargetTimeMismatch))
+	})
+
+	It("fails when a transaction after the target has been replayed", func() {
+		check := &apiv1.RecoveryTargetTimeCheck{Strict: true}
+		report := &apiv1.RecoveryTargetReport{Requested: *target, ReachedTime: "2024-01-01 12:00:01+00"}
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).
+			To(MatchError(ErrRecoveryTargetTimeMismatch))
+		Expect(report.TimeGap.Duration).To(Equal(-time.Second))
+	})
+
+	It("doesn't verify the target time when no transaction has been replayed", func() {
+		check := &apiv1.RecoveryTargetTimeCheck{Strict: true}
+		report := &apiv1.RecoveryTargetReport{Requested: *target}
+		Expect(checkRecoveryTargetTime(context.TODO(), check, report)).To(Succeed())
+		Expect(report.TimeVerified).To(BeNil())
+	})
+})
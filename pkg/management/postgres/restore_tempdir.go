@@ -0,0 +1,75 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// temporaryDataDirPrefix is the prefix of the name of the temporary data
+// directories used to generate the configuration of the restored instance
+const temporaryDataDirPrefix = "datadir_"
+
+// newTemporaryDataDir creates a temporary data directory inside the passed
+// one. Its name contains the time of its creation, to tell apart the
+// directories retained by the different runs. The directories retained by
+// the previous runs are removed, not to accumulate them
+func newTemporaryDataDir(baseDirectory string) (string, error) {
+	previous, err := filepath.Glob(filepath.Join(baseDirectory, temporaryDataDirPrefix+"*"))
+	if err != nil {
+		return "", err
+	}
+	for _, directory := range previous {
+		log.Info("Removing the temporary data directory retained by a previous run", "path", directory)
+		if err := os.RemoveAll(directory); err != nil {
+			return "", fmt.Errorf("while removing the temporary data directory %s: %w", directory, err)
+		}
+	}
+
+	return os.MkdirTemp(
+		baseDirectory,
+		temporaryDataDirPrefix+time.Now().UTC().Format("20060102T150405Z")+"_")
+}
+
+// releaseTemporaryDataDir removes a temporary data directory, unless the
+// passed policy requires it to be retained for inspection
+func releaseTemporaryDataDir(
+	directory string,
+	policy apiv1.RecoveryTemporaryDirectoryPolicy,
+	failed bool,
+) {
+	if policy == apiv1.RecoveryTemporaryDirectoryPolicyRetain ||
+		(policy == apiv1.RecoveryTemporaryDirectoryPolicyRetainOnFailure && failed) {
+		log.Info("Retaining the temporary data directory for inspection",
+			"path", directory,
+			"policy", policy,
+			"failed", failed)
+		return
+	}
+
+	if err := os.RemoveAll(directory); err != nil {
+		log.Error(
+			err,
+			"skipping error while deleting temporary data directory")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("temporary data directory policy", func() {
+	It("removes the directories retained by the previous runs", func() {
+		baseDirectory := GinkgoT().TempDir()
+		retained := path.Join(baseDirectory, temporaryDataDirPrefix+"20260101T000000Z_1")
+		other := path.Join(baseDirectory, "verify.wal")
+		Expect(os.Mkdir(retained, 0o700)).To(Succeed())
+		Expect(os.WriteFile(other, []byte("wal"), 0o600)).To(Succeed())
+
+		directory, err := newTemporaryDataDir(baseDirectory)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(directory).To(BeADirectory())
+		Expect(path.Base(directory)).To(MatchRegexp(`^datadir_\d{8}T\d{6}Z_`))
+		Expect(retained).ToNot(BeAnExistingFile())
+		Expect(other).To(BeAnExistingFile())
+	})
+
+	It("retains the directory according to the policy", func() {
+		baseDirectory := GinkgoT().TempDir()
+		newDirectory := func() string {
+			directory := path.Join(baseDirectory, temporaryDataDirPrefix+"test")
+			Expect(os.MkdirAll(directory, 0o700)).To(Succeed())
+			return directory
+		}
+
+		directory := newDirectory()
+		releaseTemporaryDataDir(directory, apiv1.RecoveryTemporaryDirectoryPolicyDelete, true)
+		Expect(directory).ToNot(BeAnExistingFile())
+
+		directory = newDirectory()
+		releaseTemporaryDataDir(directory, apiv1.RecoveryTemporaryDirectoryPolicyRetainOnFailure, false)
+		Expect(directory).ToNot(BeAnExistingFile())
+
+		directory = newDirectory()
+		releaseTemporaryDataDir(directory, apiv1.RecoveryTemporaryDirectoryPolicyRetainOnFailure, true)
+		Expect(directory).To(BeADirectory())
+
+		releaseTemporaryDataDir(directory, apiv1.RecoveryTemporaryDirectoryPolicyRetain, false)
+		Expect(directory).To(BeADirectory())
+	})
+})
@@ -18,14 +18,32 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/thoas/go-funk"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/utils/ptr"
 	"k8s.io/utils/strings/slices"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -205,3 +223,429 @@ var _ = Describe("testing restore InitInfo methods", func() {
 		Expect(enforcedParamsInPGData["max_connections"]).To(Equal(200))
 	})
 })
+
+var _ = Describe("waiting for the restored instance to be writable", func() {
+	const (
+		recoveryQuery = `SELECT pg_is_in_recovery\(\)$`
+		writableQuery = `SELECT pg_is_in_recovery\(\), current_setting\('transaction_read_only'\)`
+	)
+
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	policy := restoreRetryPolicy{initialBackoff: time.Millisecond, maxBackoff: time.Millisecond}
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+
+		retryUntilWritesAccepted := RetryUntilWritesAccepted
+		RetryUntilWritesAccepted = wait.Backoff{Duration: time.Millisecond, Steps: 3}
+		DeferCleanup(func() {
+			RetryUntilWritesAccepted = retryUntilWritesAccepted
+		})
+	})
+
+	It("waits for the read-only window after the promotion to be over", func() {
+		mock.ExpectQuery(recoveryQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(true))
+		mock.ExpectQuery(recoveryQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(false))
+		mock.ExpectQuery(writableQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery", "current_setting"}).AddRow(false, "on"))
+		mock.ExpectQuery(writableQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery", "current_setting"}).AddRow(false, "off"))
+
+		Expect(waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, nil, nil, nil)).To(Succeed())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("gives up if the instance doesn't accept writes in time", func() {
+		mock.ExpectQuery(recoveryQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery"}).AddRow(false))
+		for i := 0; i < RetryUntilWritesAccepted.Steps; i++ {
+			mock.ExpectQuery(writableQuery).
+				WillReturnRows(sqlmock.NewRows([]string{"pg_is_in_recovery", "current_setting"}).AddRow(false, "on"))
+		}
+
+		Expect(waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, nil, nil, nil)).
+			To(MatchError(ErrInstanceReadOnly))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("stops waiting when PostgreSQL couldn't promote the instance", func() {
+		promotion := newPromotionFailureCollector(&recordingWriter{})
+		promotion.Write(&logpipe.LoggingRecord{Message: "redo done at 0/3000148"})
+		promotion.Write(&logpipe.LoggingRecord{
+			ErrorSeverity: "FATAL",
+			Message:       `could not write to file "pg_wal/00000002.history": Read-only file system`,
+		})
+
+		err := waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, promotion, nil, nil)
+		Expect(err).To(MatchError(ErrPromotionFailed))
+		Expect(err.Error()).To(ContainSubstring("00000002.history"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("stops waiting when the probe of the log detected a fatal error", func() {
+		logProbe, err := newRecoveryLogProbe(&recordingWriter{}, &apiv1.RecoveryLogProbe{})
+		Expect(err).ToNot(HaveOccurred())
+		logProbe.Write(&logpipe.LoggingRecord{
+			ErrorSeverity: "FATAL",
+			Message:       "recovery aborted because of insufficient parameter settings",
+			Detail:        "max_connections = 100 is a lower setting than on the primary server",
+		})
+
+		err = waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, nil, nil, logProbe)
+		Expect(err).To(MatchError(ErrRecoveryLogProbe))
+		Expect(err.Error()).To(ContainSubstring("max_connections = 100"))
+		Expect(err.Error()).To(ContainSubstring("insufficient-parameter"))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("recognizes the shutdown at the recovery target as the end of the recovery", func() {
+		// PostgreSQL reports the shutdown while the connection is being lost
+		shutdown := newRecoveryShutdownCollector(&recordingWriter{})
+		mock.ExpectQuery(recoveryQuery).WillReturnError(errors.New("unexpected EOF")).
+			WillDelayFor(200 * time.Millisecond)
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(50 * time.Millisecond)
+			shutdown.Write(&logpipe.LoggingRecord{Message: "recovery stopping before commit of transaction 1234"})
+			shutdown.Write(&logpipe.LoggingRecord{Message: shutdownAtRecoveryTargetMessage})
+		}()
+
+		err := waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, nil, shutdown, nil)
+		Expect(err).To(MatchError(errShutdownAtRecoveryTarget))
+		Expect(shutdown.hasShutDown()).To(BeTrue())
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("stops waiting once PostgreSQL reported the shutdown at the recovery target", func() {
+		shutdown := newRecoveryShutdownCollector(&recordingWriter{})
+		shutdown.Write(&logpipe.LoggingRecord{Message: shutdownAtRecoveryTargetMessage})
+
+		err := waitUntilRecoveryFinishes(context.TODO(), db, policy, nil, nil, shutdown, nil)
+		Expect(err).To(MatchError(errShutdownAtRecoveryTarget))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("doesn't consider a lost connection as a shutdown when not reported", func() {
+		shutdown := newRecoveryShutdownCollector(&recordingWriter{})
+		Expect(shutdown.waitForShutdown(context.TODO(), time.Millisecond)).To(BeFalse())
+		Expect(shutdown.hasShutDown()).To(BeFalse())
+
+		var missing *recoveryShutdownCollector
+		Expect(missing.hasShutDown()).To(BeFalse())
+		Expect(missing.waitForShutdown(context.TODO(), time.Millisecond)).To(BeFalse())
+	})
+
+	It("doesn't retry on unexpected errors", func() {
+		mock.ExpectQuery(writableQuery).WillReturnError(errors.New("connection refused"))
+
+		err := waitUntilInstanceAcceptsWrites(db)
+		Expect(err).To(HaveOccurred())
+		Expect(err).ToNot(MatchError(ErrInstanceReadOnly))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+})
+
+var _ = Describe("recovery target LSN validation", func() {
+	newCluster := func(targetLSN string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						RecoveryTarget: &apiv1.RecoveryTarget{TargetLSN: targetLSN},
+					},
+				},
+			},
+		}
+	}
+
+	backup := &apiv1.Backup{
+		Status: apiv1.BackupStatus{
+			BackupID: "20240101T000000",
+			BeginLSN: "0/5000028",
+			EndLSN:   "0/6000100",
+		},
+	}
+
+	It("accepts a target at or after the consistency point of the backup", func() {
+		Expect(validateRecoveryTargetLSN(newCluster("0/6000100"), backup)).To(Succeed())
+		Expect(validateRecoveryTargetLSN(newCluster("1/0"), backup)).To(Succeed())
+	})
+
+	It("rejects a target preceding the consistency point of the backup", func() {
+		err := validateRecoveryTargetLSN(newCluster("0/5800000"), backup)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("20240101T000000"))
+	})
+
+	It("rejects a syntactically invalid target", func() {
+		Expect(validateRecoveryTargetLSN(newCluster("14:32"), backup)).ToNot(Succeed())
+	})
+
+	It("accepts any valid target when the end of the backup is not known", func() {
+		Expect(validateRecoveryTargetLSN(newCluster("0/1"), &apiv1.Backup{})).To(Succeed())
+	})
+
+	It("does nothing without a target LSN", func() {
+		Expect(validateRecoveryTargetLSN(&apiv1.Cluster{}, backup)).To(Succeed())
+	})
+})
+
+var _ = Describe("collecting the stderr of barman-cloud-restore", func() {
+	It("keeps the lines written to stderr", func() {
+		var stderr stderrCollector
+		cmd := exec.Command("sh", "-c", "echo out; echo 'ERROR: No backups found' >&2; exit 1")
+		err := runStreamingCollectingStderr(cmd, "test", &stderr)
+
+		var exitError *exec.ExitError
+		Expect(errors.As(err, &exitError)).To(BeTrue())
+		Expect(stderr.String()).To(Equal("ERROR: No backups found"))
+	})
+
+	It("distinguishes a command that can't be started", func() {
+		var stderr stderrCollector
+		cmd := exec.Command(path.Join(GinkgoT().TempDir(), "barman-cloud-restore"))
+		err := runStreamingCollectingStderr(cmd, "test", &stderr)
+
+		var startError *commandStartError
+		Expect(errors.As(err, &startError)).To(BeTrue())
+		Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+	})
+
+	It("keeps just the last lines", func() {
+		var stderr stderrCollector
+		for i := 0; i < stderrCollectorMaxLines+10; i++ {
+			_, _ = stderr.Write([]byte("line"))
+		}
+		_, _ = stderr.Write([]byte("last"))
+		Expect(stderr.lines).To(HaveLen(stderrCollectorMaxLines))
+		Expect(stderr.lines[len(stderr.lines)-1]).To(Equal("last"))
+	})
+})
+
+var _ = Describe("recovery configuration persistence", func() {
+	const recoverySettings = "recovery_target_action = promote\nrestore_command = 'true'\n"
+
+	var pgData string
+
+	BeforeEach(func() {
+		pgData = GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(pgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("archive_command = 'false'\n"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(pgData, constants.PostgresqlOverrideConfigurationFile),
+			[]byte("work_mem = '1GB'\n"), 0o600)).To(Succeed())
+	})
+
+	It("creates the signal file only once every recovery setting is written", func() {
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		info := InitInfo{PgData: pgData}
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{Bootstrap: &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{
+				Workers:    &apiv1.RecoveryWorkers{MaintenanceWorkers: ptr.To(int32(8))},
+				Checkpoint: &apiv1.RecoveryCheckpoint{MaxWALSize: "16GB"},
+			},
+		}}}
+
+		Expect(persistRecoveryConfiguration(pgData, "recovery.signal", recoverySettings)).To(Succeed())
+		Expect(path.Join(pgData, "recovery.signal")).ToNot(BeAnExistingFile())
+
+		Expect(info.writeRecoveryWorkersConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(info.writeRecoveryCheckpointConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(path.Join(pgData, "recovery.signal")).ToNot(BeAnExistingFile())
+
+		Expect(info.writeRecoverySignal(cluster)).To(Succeed())
+		Expect(path.Join(pgData, "recovery.signal")).To(BeAnExistingFile())
+		customConf, err := os.ReadFile(path.Join(pgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(customConf)).To(ContainSubstring(recoverySettings))
+		Expect(string(customConf)).To(ContainSubstring("max_parallel_maintenance_workers = '8'"))
+		Expect(string(customConf)).To(ContainSubstring("max_wal_size = '16GB'"))
+		autoConf, err := os.ReadFile(path.Join(pgData, constants.PostgresqlOverrideConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(autoConf).To(BeEmpty())
+	})
+
+	It("creates the signal of a continuous standby", func() {
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{Bootstrap: &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{Role: apiv1.RecoveryRoleStandby},
+		}}}
+
+		Expect(InitInfo{PgData: pgData}.writeRecoverySignal(cluster)).To(Succeed())
+		Expect(path.Join(pgData, "standby.signal")).To(BeAnExistingFile())
+		Expect(path.Join(pgData, "recovery.signal")).ToNot(BeAnExistingFile())
+	})
+
+	It("installs the staged recovery.conf before PostgreSQL 12", func() {
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("11\n"), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(pgData, stagedRecoveryConfFile), []byte(recoverySettings), 0o600)).To(Succeed())
+
+		Expect(InitInfo{PgData: pgData}.writeRecoverySignal(&apiv1.Cluster{})).To(Succeed())
+		Expect(path.Join(pgData, stagedRecoveryConfFile)).ToNot(BeAnExistingFile())
+		content, err := os.ReadFile(path.Join(pgData, recoveryConfFile))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(content)).To(Equal(recoverySettings))
+	})
+
+	It("never leaves the signal file without the complete recovery configuration", func() {
+		// A signal file left by a previous attempt must not survive
+		// a failure while writing the new configuration
+		Expect(os.WriteFile(path.Join(pgData, "recovery.signal"), nil, 0o600)).To(Succeed())
+		Expect(os.Remove(path.Join(pgData, constants.PostgresqlOverrideConfigurationFile))).To(Succeed())
+		Expect(os.Mkdir(path.Join(pgData, constants.PostgresqlOverrideConfigurationFile), 0o700)).To(Succeed())
+
+		err := persistRecoveryConfiguration(pgData, "recovery.signal", recoverySettings)
+		Expect(err).To(MatchError(ContainSubstring("cannot erase auto config")))
+		Expect(path.Join(pgData, "recovery.signal")).ToNot(BeAnExistingFile())
+	})
+
+	It("doesn't create the signal file when the settings can't be written", func() {
+		Expect(os.Remove(path.Join(pgData, constants.PostgresqlCustomConfigurationFile))).To(Succeed())
+
+		err := persistRecoveryConfiguration(pgData, "standby.signal", recoverySettings)
+		Expect(err).To(MatchError(ContainSubstring("cannot write recovery config")))
+		Expect(path.Join(pgData, "standby.signal")).ToNot(BeAnExistingFile())
+	})
+})
+
+var _ = Describe("loading a backup from another namespace", func() {
+	newCluster := func() *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Backup: &apiv1.BackupSource{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "nightly"},
+							Namespace:            "prod",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("reads the backup from its namespace", func() {
+		backup := &apiv1.Backup{
+			ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "prod"},
+			Status: apiv1.BackupStatus{
+				DestinationPath: "s3://backups/",
+				BarmanCredentials: apiv1.BarmanCredentials{
+					AWS: &apiv1.S3Credentials{InheritFromIAMRole: true},
+				},
+			},
+		}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(backup).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+
+		result, _, err := info.loadBackupFromReference(context.TODO(), typedClient, newCluster())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Namespace).To(Equal("prod"))
+		Expect(result.Status.DestinationPath).To(Equal("s3://backups/"))
+	})
+
+	It("explains how to grant the access when reading the backup is forbidden", func() {
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(
+					_ context.Context,
+					_ client.WithWatch,
+					key client.ObjectKey,
+					_ client.Object,
+					_ ...client.GetOption,
+				) error {
+					return apierrors.NewForbidden(
+						schema.GroupResource{Group: apiv1.GroupVersion.Group, Resource: "backups"},
+						key.Name, errors.New("forbidden"))
+				},
+			}).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+
+		_, _, err := info.loadBackupFromReference(context.TODO(), typedClient, newCluster())
+		Expect(apierrors.IsForbidden(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("a Role and a RoleBinding granting it are needed"))
+	})
+})
+
+type recordingWriter struct {
+	records []logpipe.NamedRecord
+}
+
+func (writer *recordingWriter) Write(record logpipe.NamedRecord) {
+	writer.records = append(writer.records, record)
+}
+
+var _ = Describe("Recovery signal file", func() {
+	It("starts a continuous standby in standby mode", func() {
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+				},
+			},
+		}
+		Expect(recoverySignalFile(cluster)).To(Equal("recovery.signal"))
+
+		cluster.Spec.Bootstrap.Recovery.Role = apiv1.RecoveryRolePrimary
+		Expect(recoverySignalFile(cluster)).To(Equal("recovery.signal"))
+
+		cluster.Spec.Bootstrap.Recovery.Role = apiv1.RecoveryRoleStandby
+		Expect(recoverySignalFile(cluster)).To(Equal("standby.signal"))
+	})
+})
+
+var _ = Describe("recovery target at the end of the backup", func() {
+	newCluster := func(target *apiv1.RecoveryTarget) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", RecoveryTarget: target},
+				},
+			},
+		}
+	}
+
+	It("stops the recovery at the end LSN of the restored backup", func() {
+		cluster := newCluster(&apiv1.RecoveryTarget{BackupID: "20261001T000000", TargetBackupEnd: ptr.To(true)})
+		info := InitInfo{BackupEndLSN: "0/5000138"}
+
+		Expect(info.renderRecoveryTarget(cluster)).To(Equal(
+			"recovery_target_lsn = '0/5000138'\n" +
+				"recovery_target_inclusive = true\n"))
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget.TargetBackupEnd).To(HaveValue(BeTrue()))
+	})
+
+	It("fails when the end LSN of the backup is unknown", func() {
+		cluster := newCluster(&apiv1.RecoveryTarget{BackupID: "20261001T000000", TargetBackupEnd: ptr.To(true)})
+
+		_, err := InitInfo{}.renderRecoveryTarget(cluster)
+		Expect(err).To(MatchError(ContainSubstring("the end LSN of the backup is unknown")))
+	})
+
+	It("replaces the end of the backup with the target of the annotation", func() {
+		cluster := newCluster(&apiv1.RecoveryTarget{BackupID: "20261001T000000", TargetBackupEnd: ptr.To(true)})
+		cluster.Annotations = map[string]string{utils.RecoveryTargetAnnotationName: `{"targetLSN": "0/6000000"}`}
+
+		_, err := applyRecoveryTargetAnnotation(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cluster.Spec.Bootstrap.Recovery.RecoveryTarget).To(Equal(&apiv1.RecoveryTarget{
+			BackupID:  "20261001T000000",
+			TargetLSN: "0/6000000",
+		}))
+	})
+})
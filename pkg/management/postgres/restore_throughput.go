@@ -0,0 +1,113 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// tablespacesLinksDirectory is the directory of PGDATA containing a
+// symbolic link to the location of every tablespace
+const tablespacesLinksDirectory = "pg_tblspc"
+
+// logRestoreThroughput logs the duration of the download of the base
+// backup, together with the amount of data restored in PGDATA and in
+// the tablespaces, and the aggregate throughput
+func (info InitInfo) logRestoreThroughput(ctx context.Context, duration time.Duration) {
+	contextLogger := log.FromContext(ctx)
+
+	size, err := restoredDataSize(info.PgData)
+	if err != nil {
+		contextLogger.Warning("Cannot compute the size of the restored data",
+			"duration", duration.String(),
+			"error", err.Error())
+		return
+	}
+
+	var throughput float64
+	if duration > 0 {
+		throughput = float64(size) / 1024 / 1024 / duration.Seconds()
+	}
+
+	contextLogger.Info("Restore completed",
+		"duration", duration.String(),
+		"restoredBytes", size,
+		"throughputMBps", fmt.Sprintf("%.2f", throughput))
+}
+
+// restoredDataSize computes the size of the regular files contained in
+// PGDATA and in the tablespaces, which are reached following the symbolic
+// links in pg_tblspc
+func restoredDataSize(pgData string) (int64, error) {
+	size, err := directorySize(pgData)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(pgData, tablespacesLinksDirectory))
+	if os.IsNotExist(err) {
+		return size, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		location, err := filepath.EvalSymlinks(filepath.Join(pgData, tablespacesLinksDirectory, entry.Name()))
+		if err != nil {
+			return 0, err
+		}
+
+		tablespaceSize, err := directorySize(location)
+		if err != nil {
+			return 0, err
+		}
+		size += tablespaceSize
+	}
+
+	return size, nil
+}
+
+// directorySize computes the size of the regular files contained
+// in a directory, without following the symbolic links
+func directorySize(directory string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(directory, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		fileInfo, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		size += fileInfo.Size()
+		return nil
+	})
+
+	return size, err
+}
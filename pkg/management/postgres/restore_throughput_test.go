@@ -0,0 +1,51 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restored data size", func() {
+	It("includes the tablespaces linked in pg_tblspc", func() {
+		pgData := path.Join(GinkgoT().TempDir(), "pgdata")
+		tablespace := GinkgoT().TempDir()
+		Expect(os.MkdirAll(path.Join(pgData, "base", "1"), 0o700)).To(Succeed())
+		Expect(os.MkdirAll(path.Join(pgData, tablespacesLinksDirectory), 0o700)).To(Succeed())
+		Expect(os.WriteFile(path.Join(pgData, "base", "1", "1259"), make([]byte, 1000), 0o600)).To(Succeed())
+		Expect(os.WriteFile(path.Join(tablespace, "16390"), make([]byte, 500), 0o600)).To(Succeed())
+		Expect(os.Symlink(tablespace, path.Join(pgData, tablespacesLinksDirectory, "16389"))).To(Succeed())
+
+		Expect(restoredDataSize(pgData)).To(BeEquivalentTo(1500))
+	})
+
+	It("works without tablespaces", func() {
+		pgData := GinkgoT().TempDir()
+		Expect(os.WriteFile(path.Join(pgData, "PG_VERSION"), []byte("16\n"), 0o600)).To(Succeed())
+
+		Expect(restoredDataSize(pgData)).To(BeEquivalentTo(3))
+	})
+
+	It("fails when PGDATA doesn't exist", func() {
+		_, err := restoredDataSize(path.Join(GinkgoT().TempDir(), "missing"))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,271 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/lib/pq"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// ErrRecoveryVerificationFailed is raised when the instance paused at
+// the recovery target doesn't pass the verification
+var ErrRecoveryVerificationFailed = errors.New("recovery verification failed")
+
+// foreignKeysQuery lists the foreign keys of the current database, with
+// the columns of the referencing and of the referenced table. The
+// foreign keys of the partitions are checked via the ones of the
+// partitioned tables, while the rows of the tables inheriting from
+// a regular one are not covered by its foreign keys
+const foreignKeysQuery = `
+SELECT c.conname,
+  c.conrelid::pg_catalog.regclass::text,
+  c.confrelid::pg_catalog.regclass::text,
+  t.relkind <> 'p',
+  rt.relkind <> 'p',
+  ARRAY(
+    SELECT a.attname FROM pg_catalog.unnest(c.conkey) WITH ORDINALITY AS k(attnum, n)
+    JOIN pg_catalog.pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+    ORDER BY k.n),
+  ARRAY(
+    SELECT a.attname FROM pg_catalog.unnest(c.confkey) WITH ORDINALITY AS k(attnum, n)
+    JOIN pg_catalog.pg_attribute a ON a.attrelid = c.confrelid AND a.attnum = k.attnum
+    ORDER BY k.n)
+FROM pg_catalog.pg_constraint c
+JOIN pg_catalog.pg_class t ON t.oid = c.conrelid
+JOIN pg_catalog.pg_class rt ON rt.oid = c.confrelid
+WHERE c.contype = 'f' AND c.convalidated AND c.conparentid = 0
+ORDER BY 2, 1`
+
+// foreignKey is a foreign key to be checked
+type foreignKey struct {
+	name              string
+	table             string
+	columns           []string
+	referencedTable   string
+	referencedColumns []string
+	// when true, the tables inheriting from the table are excluded
+	onlyTable           bool
+	onlyReferencedTable bool
+}
+
+// getRecoveryVerification gets the verification of the instance paused
+// at the recovery target requested by the user, if any
+func getRecoveryVerification(cluster *apiv1.Cluster) *apiv1.RecoveryVerification {
+	pause := getRecoveryPause(cluster)
+	if pause == nil {
+		return nil
+	}
+
+	return pause.Verification
+}
+
+// recoveryVerifier builds the function verifying the instance paused at
+// the recovery target, which reports the outcome in the cluster status.
+// Nil is returned when no verification is requested
+func (info InitInfo) recoveryVerifier(
+	cluster *apiv1.Cluster,
+	instance *Instance,
+) (func(ctx context.Context) error, error) {
+	verification := getRecoveryVerification(cluster)
+	if verification == nil {
+		return nil, nil
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	smokeTest := getRecoverySmokeTest(cluster)
+	return func(ctx context.Context) error {
+		err := info.runRecoveryVerification(ctx, verification, smokeTest,
+			instance.ConnectionPool().Connection,
+			func(ctx context.Context) ([]string, error) {
+				return listRestoredDatabases(ctx, instance)
+			})
+		info.reportRestorePhaseCondition(ctx, typedClient, newRecoveryVerificationCondition(err))
+		return err
+	}, nil
+}
+
+// newRecoveryVerificationCondition builds the condition reporting the
+// outcome of the verification of the instance paused at the target
+func newRecoveryVerificationCondition(err error) *metav1.Condition {
+	if err != nil {
+		return &metav1.Condition{
+			Type:    string(apiv1.ConditionRecoveryVerification),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(apiv1.ConditionReasonRecoveryVerificationFailed),
+			Message: err.Error(),
+		}
+	}
+
+	return &metav1.Condition{
+		Type:    string(apiv1.ConditionRecoveryVerification),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(apiv1.ConditionReasonRecoveryVerificationPassed),
+		Message: "The instance paused at the recovery target passed the verification",
+	}
+}
+
+// runRecoveryVerification executes the read-only checks requested by the
+// user on the instance paused at the recovery target: the queries, the
+// smoke test and the check of the foreign keys
+func (info InitInfo) runRecoveryVerification(
+	ctx context.Context,
+	verification *apiv1.RecoveryVerification,
+	smokeTest *apiv1.RecoverySmokeTest,
+	connect func(dbname string) (*sql.DB, error),
+	listDatabases func(ctx context.Context) ([]string, error),
+) error {
+	contextLogger := log.FromContext(ctx)
+	contextLogger.Info("Verifying the instance paused at the recovery target")
+
+	queries := verification.Queries
+	if verification.SmokeTest && smokeTest != nil {
+		queries = append(slices.Clone(queries), *smokeTest)
+	}
+	for idx := range queries {
+		if err := info.runRecoverySmokeTest(ctx, &queries[idx], connect); err != nil {
+			return fmt.Errorf("%w: %v", ErrRecoveryVerificationFailed, err)
+		}
+	}
+
+	if verification.ForeignKeys {
+		databases, err := listDatabases(ctx)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrRecoveryVerificationFailed, err)
+		}
+
+		for _, database := range databases {
+			db, err := connect(database)
+			if err != nil {
+				return fmt.Errorf("%w: while connecting to database %s: %v",
+					ErrRecoveryVerificationFailed, database, err)
+			}
+
+			if err := checkForeignKeys(ctx, db, database); err != nil {
+				return fmt.Errorf("%w: %v", ErrRecoveryVerificationFailed, err)
+			}
+		}
+	}
+
+	contextLogger.Info("The instance paused at the recovery target passed the verification")
+	return nil
+}
+
+// checkForeignKeys checks that every row of the tables of a database
+// referencing another table matches one of its rows
+func checkForeignKeys(ctx context.Context, db *sql.DB, database string) error {
+	contextLogger := log.FromContext(ctx).WithValues("database", database)
+
+	foreignKeys, err := listForeignKeys(ctx, db)
+	if err != nil {
+		return fmt.Errorf("while listing the foreign keys of database %s: %w", database, err)
+	}
+
+	for _, key := range foreignKeys {
+		var violated bool
+		if err := db.QueryRowContext(ctx, foreignKeyViolationQuery(key)).Scan(&violated); err != nil {
+			return fmt.Errorf("while checking foreign key %s of table %s in database %s: %w",
+				key.name, key.table, database, err)
+		}
+		if violated {
+			return fmt.Errorf("foreign key %s of table %s in database %s is violated",
+				key.name, key.table, database)
+		}
+	}
+
+	contextLogger.Info("Foreign keys checked", "foreignKeys", len(foreignKeys))
+	return nil
+}
+
+// listForeignKeys lists the foreign keys of the database
+func listForeignKeys(ctx context.Context, db *sql.DB) ([]foreignKey, error) {
+	rows, err := db.QueryContext(ctx, foreignKeysQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	var result []foreignKey
+	for rows.Next() {
+		var key foreignKey
+		var columns, referencedColumns pq.StringArray
+		if err := rows.Scan(
+			&key.name,
+			&key.table,
+			&key.referencedTable,
+			&key.onlyTable,
+			&key.onlyReferencedTable,
+			&columns,
+			&referencedColumns,
+		); err != nil {
+			return nil, err
+		}
+		key.columns = columns
+		key.referencedColumns = referencedColumns
+		result = append(result, key)
+	}
+
+	return result, rows.Err()
+}
+
+// foreignKeyViolationQuery builds the query checking if any row of the
+// referencing table doesn't match a row of the referenced one. As with
+// the default `MATCH SIMPLE`, the rows having a NULL in any of the
+// referencing columns are skipped. The table names are already quoted
+func foreignKeyViolationQuery(key foreignKey) string {
+	qualify := func(alias string, columns []string) string {
+		qualified := make([]string, len(columns))
+		for idx, column := range columns {
+			qualified[idx] = fmt.Sprintf("%s.%s", alias, pgx.Identifier{column}.Sanitize())
+		}
+		return strings.Join(qualified, ", ")
+	}
+
+	relation := func(table string, only bool) string {
+		if only {
+			return "ONLY " + table
+		}
+		return table
+	}
+
+	columns := qualify("r", key.columns)
+	return fmt.Sprintf(
+		"SELECT EXISTS (SELECT 1 FROM %s AS r WHERE ROW(%s) IS NOT NULL "+
+			"AND NOT EXISTS (SELECT 1 FROM %s AS p WHERE ROW(%s) = ROW(%s)))",
+		relation(key.table, key.onlyTable),
+		columns,
+		relation(key.referencedTable, key.onlyReferencedTable),
+		qualify("p", key.referencedColumns),
+		columns)
+}
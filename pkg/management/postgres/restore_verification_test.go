@@ -0,0 +1,119 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("verification of the instance paused at the recovery target", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() {
+			_ = db.Close()
+		})
+	})
+
+	connect := func(string) (*sql.DB, error) { return db, nil }
+	listDatabases := func(context.Context) ([]string, error) { return []string{"app"}, nil }
+
+	It("gets the verification requested by the user", func() {
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{
+			Bootstrap: &apiv1.BootstrapConfiguration{Recovery: &apiv1.BootstrapRecovery{}},
+		}}
+		Expect(getRecoveryVerification(cluster)).To(BeNil())
+
+		verification := &apiv1.RecoveryVerification{ForeignKeys: true}
+		cluster.Spec.Bootstrap.Recovery.PauseAtTarget = &apiv1.RecoveryPause{Verification: verification}
+		Expect(getRecoveryVerification(cluster)).To(Equal(verification))
+	})
+
+	It("reports the outcome of the verification", func() {
+		condition := newRecoveryVerificationCondition(nil)
+		Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonRecoveryVerificationPassed)))
+
+		condition = newRecoveryVerificationCondition(ErrRecoveryVerificationFailed)
+		Expect(condition.Status).To(Equal(metav1.ConditionFalse))
+		Expect(condition.Reason).To(Equal(string(apiv1.ConditionReasonRecoveryVerificationFailed)))
+		Expect(condition.Message).To(Equal(ErrRecoveryVerificationFailed.Error()))
+	})
+
+	It("executes the queries and the smoke test", func() {
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM invoices").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+		mock.ExpectQuery("SELECT count\\(\\*\\) FROM customers").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		err := InitInfo{}.runRecoveryVerification(context.TODO(),
+			&apiv1.RecoveryVerification{
+				Queries:   []apiv1.RecoverySmokeTest{{Query: "SELECT count(*) FROM invoices"}},
+				SmokeTest: true,
+			},
+			&apiv1.RecoverySmokeTest{Query: "SELECT count(*) FROM customers"},
+			connect, listDatabases)
+		Expect(err).To(MatchError(ErrRecoveryVerificationFailed))
+		Expect(err).To(MatchError(ContainSubstring("the expected minimum is 1")))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("checks the foreign keys of every database", func() {
+		mock.ExpectQuery("SELECT c.conname").WillReturnRows(
+			sqlmock.NewRows([]string{"conname", "table", "referenced", "only", "referenced_only", "columns", "ref"}).
+				AddRow("invoices_customer_fkey", "invoices", "customers", true, true, "{customer_id}", "{id}"))
+		mock.ExpectQuery(regexp.QuoteMeta(
+			`SELECT EXISTS (SELECT 1 FROM ONLY invoices AS r WHERE ROW(r."customer_id") IS NOT NULL ` +
+				`AND NOT EXISTS (SELECT 1 FROM ONLY customers AS p WHERE ROW(p."id") = ROW(r."customer_id")))`)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		err := InitInfo{}.runRecoveryVerification(context.TODO(),
+			&apiv1.RecoveryVerification{ForeignKeys: true}, nil, connect, listDatabases)
+		Expect(err).To(MatchError(ErrRecoveryVerificationFailed))
+		Expect(err).To(MatchError(ContainSubstring("invoices_customer_fkey")))
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("builds the query checking a foreign key on a partitioned table", func() {
+		Expect(foreignKeyViolationQuery(foreignKey{
+			table:               `sales."Orders"`,
+			columns:             []string{"region", "customer id"},
+			referencedTable:     "customers",
+			referencedColumns:   []string{"region", "id"},
+			onlyReferencedTable: true,
+		})).To(Equal(`SELECT EXISTS (SELECT 1 FROM sales."Orders" AS r ` +
+			`WHERE ROW(r."region", r."customer id") IS NOT NULL ` +
+			`AND NOT EXISTS (SELECT 1 FROM ONLY customers AS p ` +
+			`WHERE ROW(p."region", p."id") = ROW(r."region", r."customer id")))`))
+	})
+})
@@ -0,0 +1,320 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/restorer"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrWALArchiveGap is raised when a WAL file needed to reach the
+// recovery target is missing from the WAL archive
+var ErrWALArchiveGap = errors.New("gap in the WAL archive")
+
+// verifyWALArchiveContiguity checks, when requested by the user, that
+// every WAL file between the end of the backup and the recovery target
+// is available in the WAL archive
+func (info InitInfo) verifyWALArchiveContiguity(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	env []string,
+	backup *apiv1.Backup,
+) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		!cluster.Spec.Bootstrap.Recovery.VerifyWALArchive {
+		return nil
+	}
+
+	// it's the full path of the file that will temporarily contain each WAL file
+	const testWALPath = postgresSpec.RecoveryTemporaryDirectory + "/verify.wal"
+	contextLogger := log.FromContext(ctx)
+
+	recoveryTarget := cluster.Spec.Bootstrap.Recovery.RecoveryTarget
+	if recoveryTarget == nil || recoveryTarget.TargetLSN == "" {
+		return fmt.Errorf("the verification of the WAL archive requires a targetLSN")
+	}
+
+	walSegmentSize, err := info.getWALSegmentSize()
+	if err != nil {
+		return err
+	}
+
+	first, err := postgresSpec.SegmentFromName(backup.Status.EndWal)
+	if err != nil {
+		return fmt.Errorf("while parsing the end WAL of the backup %q: %w", backup.Status.EndWal, err)
+	}
+
+	if err := fileutils.EnsureParentDirectoryExists(testWALPath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := fileutils.RemoveFile(testWALPath); err != nil {
+			contextLogger.Error(err, "while deleting the temporary wal file")
+		}
+	}()
+
+	opts, err := backupWalRestoreOptions(cluster, backup)
+	if err != nil {
+		return err
+	}
+
+	// The history files are fetched to find the
+	// timeline the recovery will follow
+	fetchFile := func(name string) ([]byte, error) {
+		if err := info.barmanRunner(cluster).WALRestore(ctx, cluster, env, name, testWALPath, opts); err != nil {
+			return nil, err
+		}
+
+		content, err := os.ReadFile(testWALPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return content, fileutils.RemoveFile(testWALPath)
+	}
+
+	targetTLI, history, err := loadTargetTimelineHistory(fetchFile, first.Tli, recoveryTarget.TargetTLI)
+	if err != nil {
+		return err
+	}
+
+	walNames, err := walFilesBetween(
+		backup.Status.EndWal, postgresSpec.LSN(recoveryTarget.TargetLSN), walSegmentSize, targetTLI, history)
+	if err != nil {
+		return err
+	}
+
+	contextLogger.Info("Verifying the contiguity of the WAL archive",
+		"firstWAL", walNames[0],
+		"lastWAL", walNames[len(walNames)-1],
+		"walFiles", len(walNames),
+		"targetTLI", targetTLI)
+
+	for _, walName := range walNames {
+		if err := info.barmanRunner(cluster).WALRestore(ctx, cluster, env, walName, testWALPath, opts); err != nil {
+			if errors.Is(err, restorer.ErrWALNotFound) {
+				return fmt.Errorf("%w: missing WAL file %s", ErrWALArchiveGap, walName)
+			}
+			return fmt.Errorf("while verifying the presence of WAL file %s in the archive: %w", walName, err)
+		}
+
+		if err := fileutils.RemoveFile(testWALPath); err != nil {
+			return err
+		}
+	}
+
+	contextLogger.Info("The WAL archive contains every WAL file needed to reach the recovery target")
+	return nil
+}
+
+// getWALSegmentSize reads the WAL segment size of the restored data directory
+func (info InitInfo) getWALSegmentSize() (int64, error) {
+	pgControlDataString, err := info.GetInstance().GetPgControldata()
+	if err != nil {
+		return 0, fmt.Errorf("while running pg_controldata to detect WAL segment size: %w", err)
+	}
+
+	pgControlData := utils.ParsePgControldataOutput(pgControlDataString)
+	walSegmentSizeString, ok := pgControlData["Bytes per WAL segment"]
+	if !ok {
+		return 0, fmt.Errorf("no 'Bytes per WAL segment' section into pg_controldata output")
+	}
+
+	walSegmentSize, err := strconv.ParseInt(walSegmentSizeString, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf(
+			"wrong 'Bytes per WAL segment' pg_controldata value (not an integer): '%s' %w",
+			walSegmentSizeString, err)
+	}
+
+	return walSegmentSize, nil
+}
+
+// timelineSwitch is an entry of a timeline history file: the
+// timeline has been left for the following one at the passed LSN
+type timelineSwitch struct {
+	tli int32
+	lsn int64
+}
+
+// loadTargetTimelineHistory gets the timeline the recovery will follow,
+// and the switches leading to it, as PostgreSQL does: the history file of
+// the requested timeline is fetched from the archive, while the latest
+// timeline is found looking for the history files following the one of
+// the backup
+func loadTargetTimelineHistory(
+	fetchFile func(name string) ([]byte, error),
+	backupTLI int32,
+	targetTLI string,
+) (int32, []timelineSwitch, error) {
+	var content []byte
+	var tli int32
+
+	switch targetTLI {
+	case "", "latest":
+		tli = backupTLI
+		for {
+			newContent, err := fetchFile(timelineHistoryFileName(tli + 1))
+			if errors.Is(err, restorer.ErrWALNotFound) {
+				break
+			}
+			if err != nil {
+				return 0, nil, fmt.Errorf("while looking for the latest timeline in the archive: %w", err)
+			}
+			tli++
+			content = newContent
+		}
+
+	default:
+		requested, err := strconv.ParseInt(targetTLI, 10, 32)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid target timeline %q: %w", targetTLI, err)
+		}
+		tli = int32(requested)
+		if tli <= backupTLI {
+			return backupTLI, nil, nil
+		}
+
+		content, err = fetchFile(timelineHistoryFileName(tli))
+		if errors.Is(err, restorer.ErrWALNotFound) {
+			return 0, nil, fmt.Errorf("%w: missing history file %s",
+				ErrWALArchiveGap, timelineHistoryFileName(tli))
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("while fetching the history of the target timeline: %w", err)
+		}
+	}
+
+	if tli == backupTLI {
+		return tli, nil, nil
+	}
+
+	history, err := parseTimelineHistory(content)
+	if err != nil {
+		return 0, nil, fmt.Errorf("while parsing %s: %w", timelineHistoryFileName(tli), err)
+	}
+
+	return tli, history, nil
+}
+
+// timelineHistoryFileName gets the name of the history file of a timeline
+func timelineHistoryFileName(tli int32) string {
+	return fmt.Sprintf("%08X.history", tli)
+}
+
+// parseTimelineHistory parses the content of a timeline history file,
+// made of a line for each ancestor timeline, with its number, the LSN
+// where it was left and the reason
+func parseTimelineHistory(content []byte) ([]timelineSwitch, error) {
+	var result []timelineSwitch
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+
+		tli, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeline in line %q: %w", line, err)
+		}
+
+		lsn, err := postgresSpec.LSN(fields[1]).Parse()
+		if err != nil {
+			return nil, fmt.Errorf("invalid switch point in line %q: %w", line, err)
+		}
+
+		result = append(result, timelineSwitch{tli: int32(tli), lsn: lsn})
+	}
+
+	return result, nil
+}
+
+// walFilesBetween generates the names of the WAL files from the first one
+// to the one containing the passed LSN, both included. Each file is taken
+// from the timeline PostgreSQL reads it from, following the switches
+// leading to the target timeline: the newest timeline which started at or
+// before the file
+func walFilesBetween(
+	firstWAL string,
+	targetLSN postgresSpec.LSN,
+	walSegmentSize int64,
+	targetTLI int32,
+	history []timelineSwitch,
+) ([]string, error) {
+	first, err := postgresSpec.SegmentFromName(firstWAL)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing the end WAL of the backup %q: %w", firstWAL, err)
+	}
+
+	lsn, err := targetLSN.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	last := segmentContaining(lsn, walSegmentSize)
+	if segmentPrecedes(last, first) {
+		return nil, fmt.Errorf("the recovery target LSN %s precedes the end WAL of the backup %s",
+			targetLSN, firstWAL)
+	}
+
+	var result []string
+	for current := first; ; current = current.NextSegments(2, nil, &walSegmentSize)[1] {
+		current.Tli = targetTLI
+		for _, entry := range history {
+			if segmentPrecedes(current, segmentContaining(entry.lsn, walSegmentSize)) {
+				current.Tli = entry.tli
+				break
+			}
+		}
+
+		result = append(result, current.Name())
+		if current.Log == last.Log && current.Seg == last.Seg {
+			return result, nil
+		}
+	}
+}
+
+// segmentContaining gets the position of the WAL segment containing
+// the passed LSN, without a timeline
+func segmentContaining(lsn int64, walSegmentSize int64) postgresSpec.Segment {
+	return postgresSpec.Segment{
+		Log: int32(lsn >> 32),
+		Seg: int32((lsn & 0xFFFFFFFF) / walSegmentSize),
+	}
+}
+
+// segmentPrecedes checks if the position of a WAL segment
+// precedes the one of another segment, ignoring the timeline
+func segmentPrecedes(segment, other postgresSpec.Segment) bool {
+	return segment.Log < other.Log || (segment.Log == other.Log && segment.Seg < other.Seg)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/restorer"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAL archive contiguity verification", func() {
+	const walSegmentSize = int64(16 * 1024 * 1024)
+
+	It("lists the WAL files from the end of the backup to the target", func() {
+		Expect(walFilesBetween("000000010000000000000003", "0/5000100", walSegmentSize, 1, nil)).To(Equal([]string{
+			"000000010000000000000003",
+			"000000010000000000000004",
+			"000000010000000000000005",
+		}))
+	})
+
+	It("crosses log file boundaries", func() {
+		Expect(walFilesBetween("0000000200000000000000FE", "1/1000000", walSegmentSize, 2, nil)).To(Equal([]string{
+			"0000000200000000000000FE",
+			"0000000200000000000000FF",
+			"000000020000000100000000",
+			"000000020000000100000001",
+		}))
+	})
+
+	It("handles non-default WAL segment sizes", func() {
+		Expect(walFilesBetween("000000010000000000000001", "0/8000000", 64*1024*1024, 1, nil)).To(Equal([]string{
+			"000000010000000000000001",
+			"000000010000000000000002",
+		}))
+	})
+
+	It("returns the backup end WAL when it contains the target", func() {
+		Expect(walFilesBetween("000000010000000000000003", "0/3000060", walSegmentSize, 1, nil)).To(Equal([]string{
+			"000000010000000000000003",
+		}))
+	})
+
+	It("fails when the target precedes the end of the backup", func() {
+		_, err := walFilesBetween("000000010000000000000003", "0/2000000", walSegmentSize, 1, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("fails with invalid input", func() {
+		_, err := walFilesBetween("", "0/2000000", walSegmentSize, 1, nil)
+		Expect(err).To(HaveOccurred())
+		_, err = walFilesBetween("000000010000000000000003", "14:32", walSegmentSize, 1, nil)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("follows the timeline switches leading to the target timeline", func() {
+		history := []timelineSwitch{{tli: 1, lsn: 0x4000100}, {tli: 2, lsn: 0x6000000}}
+		Expect(walFilesBetween("000000010000000000000003", "0/7000100", walSegmentSize, 3, history)).To(Equal([]string{
+			"000000010000000000000003",
+			"000000020000000000000004",
+			"000000020000000000000005",
+			"000000030000000000000006",
+			"000000030000000000000007",
+		}))
+	})
+
+	It("ignores the timeline switches preceding the end of the backup", func() {
+		history := []timelineSwitch{{tli: 1, lsn: 0x2000000}}
+		Expect(walFilesBetween("000000020000000000000003", "0/4000000", walSegmentSize, 2, history)).To(Equal([]string{
+			"000000020000000000000003",
+			"000000020000000000000004",
+		}))
+	})
+
+	It("parses the timeline history files", func() {
+		content := "1\t0/4000100\tno recovery target specified\n\n" +
+			"2\t0/6000000\tbefore 2024-01-01 00:00:00+00\n"
+		Expect(parseTimelineHistory([]byte(content))).To(Equal([]timelineSwitch{
+			{tli: 1, lsn: 0x4000100},
+			{tli: 2, lsn: 0x6000000},
+		}))
+
+		_, err := parseTimelineHistory([]byte("1\tnot-an-lsn\n"))
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("finding the target timeline", func() {
+		var (
+			archive map[string]string
+			fetched []string
+		)
+
+		fetchFile := func(name string) ([]byte, error) {
+			fetched = append(fetched, name)
+			content, ok := archive[name]
+			if !ok {
+				return nil, restorer.ErrWALNotFound
+			}
+			return []byte(content), nil
+		}
+
+		BeforeEach(func() {
+			fetched = nil
+			archive = map[string]string{
+				"00000002.history": "1\t0/4000100\tno recovery target specified\n",
+				"00000003.history": "1\t0/4000100\tno recovery target specified\n" +
+					"2\t0/6000000\tno recovery target specified\n",
+			}
+		})
+
+		It("looks for the latest timeline in the archive", func() {
+			tli, history, err := loadTargetTimelineHistory(fetchFile, 1, "latest")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tli).To(BeEquivalentTo(3))
+			Expect(history).To(HaveLen(2))
+			Expect(fetched).To(Equal([]string{"00000002.history", "00000003.history", "00000004.history"}))
+		})
+
+		It("stays on the timeline of the backup when there are no newer ones", func() {
+			archive = nil
+			tli, history, err := loadTargetTimelineHistory(fetchFile, 1, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tli).To(BeEquivalentTo(1))
+			Expect(history).To(BeEmpty())
+		})
+
+		It("fetches the history of the requested timeline", func() {
+			tli, history, err := loadTargetTimelineHistory(fetchFile, 1, "2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tli).To(BeEquivalentTo(2))
+			Expect(history).To(Equal([]timelineSwitch{{tli: 1, lsn: 0x4000100}}))
+			Expect(fetched).To(Equal([]string{"00000002.history"}))
+		})
+
+		It("reports a gap when the history of the requested timeline is missing", func() {
+			_, _, err := loadTargetTimelineHistory(fetchFile, 1, "5")
+			Expect(err).To(MatchError(ErrWALArchiveGap))
+		})
+
+		It("doesn't fetch anything when the timeline of the backup is requested", func() {
+			tli, history, err := loadTargetTimelineHistory(fetchFile, 3, "3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tli).To(BeEquivalentTo(3))
+			Expect(history).To(BeEmpty())
+			Expect(fetched).To(BeEmpty())
+		})
+	})
+
+	It("does nothing unless explicitly requested", func() {
+		info := InitInfo{}
+		Expect(info.verifyWALArchiveContiguity(context.TODO(), &apiv1.Cluster{}, nil, &apiv1.Backup{})).To(Succeed())
+	})
+})
@@ -0,0 +1,77 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"strconv"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+)
+
+// walFetchTransientExitCodes are the exit codes of a fetch of a WAL file
+// which can succeed when retried: the connectivity and generic errors of
+// barman-cloud-wal-restore, and a fetch aborted by its timeout. Any other
+// exit code, like the one of a missing WAL file, is returned immediately
+// to PostgreSQL, which relies on it to detect the end of the recovery
+const walFetchTransientExitCodes = "2|4|" + walFetchTimedOutFatalExitCode
+
+// getWALFetchRetries gets the number of times a failed fetch of a WAL
+// file is retried by the restore_command, as requested by the user
+func getWALFetchRetries(cluster *apiv1.Cluster) int32 {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return 0
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.WALFetchRetries
+}
+
+// withWALFetchRetries makes the restore_command retry the fetch of a WAL
+// file failing with a transient error, waiting for the initial backoff of
+// the restore retry policy between two attempts
+func (cmd restoreCommand) withWALFetchRetries(cluster *apiv1.Cluster) restoreCommand {
+	retries := getWALFetchRetries(cluster)
+	if retries <= 0 {
+		return cmd
+	}
+
+	cmd.fetchRetries = retries
+	cmd.fetchRetryDelay = getRestoreRetryPolicy(cluster).initialBackoff
+	return cmd
+}
+
+// renderWALFetchRetries wraps the passed fetch of a WAL file with its
+// retries, if any. The fetch is executed in a subshell, as the timeout
+// wrapping it exits on failures
+func (cmd restoreCommand) renderWALFetchRetries(fetch []string) []string {
+	if cmd.fetchRetries <= 0 {
+		return fetch
+	}
+
+	result := []string{"{", "attempt=0;", "until", "("}
+	result = append(result, fetch...)
+	return append(result,
+		");", "do", "rc=$?;",
+		"case", "$rc", "in",
+		walFetchTransientExitCodes+")", ";;",
+		"*)", "exit", "$rc;;",
+		"esac;",
+		"attempt=$((attempt+1));",
+		"if", "[", "$attempt", "-gt", strconv.FormatInt(int64(cmd.fetchRetries), 10), "];",
+		"then", "exit", "$rc;", "fi;",
+		"sleep", strconv.FormatFloat(cmd.fetchRetryDelay.Seconds(), 'f', 3, 64)+";",
+		"done;", "}")
+}
@@ -0,0 +1,123 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("retries of the fetch of the WAL files", func() {
+	newCluster := func(retries int32) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "restored"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:          "origin",
+						WALFetchRetries: retries,
+						RetryPolicy: &apiv1.RestoreRetryPolicy{
+							InitialBackoff: &metav1.Duration{Duration: 10 * time.Millisecond},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// runWALFetch executes a restore_command as PostgreSQL would do,
+	// returning its exit code
+	runWALFetch := func(cmd restoreCommand) int {
+		command := renderRestoreCommand(cmd.render(), "000000010000000000000001", "pg_wal/RECOVERYXLOG")
+		err := exec.Command("sh", "-c", command).Run() // #nosec G204
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		Expect(err).ToNot(HaveOccurred())
+		return 0
+	}
+
+	// failingFetch is a fetch failing with the passed exit code until it
+	// has been executed the passed number of times, counting the attempts
+	// in the passed file
+	failingFetch := func(counter string, exitCode int, failures int) restoreCommand {
+		script := fmt.Sprintf(
+			`'echo >> %s; test $(wc -l < %s) -gt %d || exit %d'`,
+			counter, counter, failures, exitCode)
+		return restoreCommand{fetch: []string{"sh", "-c", script, "sh"}, arguments: walFetchArguments}
+	}
+
+	attempts := func(counter string) int {
+		content, err := os.ReadFile(counter) // #nosec G304
+		Expect(err).ToNot(HaveOccurred())
+		return strings.Count(string(content), "\n")
+	}
+
+	var counter string
+
+	BeforeEach(func() {
+		counter = path.Join(GinkgoT().TempDir(), "attempts")
+	})
+
+	It("doesn't wrap the fetch of the WAL files when no retry is requested", func() {
+		cmd := restoreCommand{fetch: []string{"barman-cloud-wal-restore", "s3://backups/", "origin"}}
+		cmd.arguments = walFetchArguments
+		Expect(cmd.withWALFetchRetries(newCluster(0)).render()).
+			To(Equal("barman-cloud-wal-restore s3://backups/ origin %f %p"))
+	})
+
+	It("retries a fetch failing with a transient error", func() {
+		cmd := failingFetch(counter, 2, 2).withWALFetchRetries(newCluster(3))
+		Expect(runWALFetch(cmd)).To(Equal(0))
+		Expect(attempts(counter)).To(Equal(3))
+	})
+
+	It("fails once the retries are exhausted", func() {
+		cmd := failingFetch(counter, 4, 10).withWALFetchRetries(newCluster(2))
+		Expect(runWALFetch(cmd)).To(Equal(4))
+		Expect(attempts(counter)).To(Equal(3))
+	})
+
+	It("reports a missing WAL file immediately", func() {
+		cmd := failingFetch(counter, 1, 10).withWALFetchRetries(newCluster(3))
+		Expect(runWALFetch(cmd)).To(Equal(1))
+		Expect(attempts(counter)).To(Equal(1))
+	})
+
+	It("retries a fetch aborted by its timeout", func() {
+		cluster := newCluster(1)
+		cluster.Spec.Bootstrap.Recovery.ObjectStoreTimeouts = &apiv1.RecoveryObjectStoreTimeouts{
+			WALFetch: &metav1.Duration{Duration: 500 * time.Millisecond},
+		}
+
+		cmd := restoreCommand{fetch: []string{"sh", "-c", "'sleep 30'", "sh"}, arguments: walFetchArguments}
+		Expect(runWALFetch(cmd.withWALFetchTimeout(cluster).withWALFetchRetries(cluster))).To(Equal(255))
+	})
+})
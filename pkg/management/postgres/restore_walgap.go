@@ -0,0 +1,140 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrWALGapTooLarge is raised when the distance between the end of the
+// base backup and the recovery target exceeds the allowed one, and the
+// user asked to fail
+var ErrWALGapTooLarge = errors.New("the recovery target is too far from the end of the base backup")
+
+// recoveryWALGap is the distance between the end of a base backup and
+// the recovery target. Only the distances that can be computed, given
+// the kind of recovery target, are set
+type recoveryWALGap struct {
+	walSize  *int64
+	timeSpan *time.Duration
+}
+
+// getWALGapCheck gets the check of the distance between the base
+// backup and the recovery target requested by the user, if any
+func getWALGapCheck(cluster *apiv1.Cluster) *apiv1.RecoveryWALGapCheck {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.WALGapCheck
+}
+
+// computeRecoveryWALGap computes the distance between the end of the
+// base backup and the recovery target, as an amount of WAL when the
+// target is an LSN and as a time span when the target is a time
+func computeRecoveryWALGap(backup *apiv1.Backup, target *apiv1.RecoveryTarget) (recoveryWALGap, error) {
+	var gap recoveryWALGap
+
+	if target.TargetLSN != "" && backup.Status.EndLSN != "" {
+		endLSN, err := postgresSpec.LSN(backup.Status.EndLSN).Parse()
+		if err != nil {
+			return gap, fmt.Errorf("while parsing the end LSN of the backup %q: %w", backup.Status.EndLSN, err)
+		}
+		targetLSN, err := postgresSpec.LSN(target.TargetLSN).Parse()
+		if err != nil {
+			return gap, fmt.Errorf("while parsing the recovery target LSN %q: %w", target.TargetLSN, err)
+		}
+		walSize := max(targetLSN-endLSN, 0)
+		gap.walSize = &walSize
+	}
+
+	if target.TargetTime != "" && backup.Status.StoppedAt != nil {
+		targetTime, err := utils.ParseTargetTime(nil, target.TargetTime)
+		if err != nil {
+			return gap, fmt.Errorf("while parsing the recovery target time %q: %w", target.TargetTime, err)
+		}
+		timeSpan := max(targetTime.Sub(backup.Status.StoppedAt.Time), 0)
+		gap.timeSpan = &timeSpan
+	}
+
+	return gap, nil
+}
+
+// exceededThresholds lists the thresholds of the check exceeded by the
+// distance between the base backup and the recovery target
+func (gap recoveryWALGap) exceededThresholds(check *apiv1.RecoveryWALGapCheck) []string {
+	var result []string
+	if gap.walSize != nil && check.MaxWALSize != nil && *gap.walSize > check.MaxWALSize.Value() {
+		result = append(result, fmt.Sprintf("%d bytes of WAL exceed maxWALSize %s",
+			*gap.walSize, check.MaxWALSize.String()))
+	}
+	if gap.timeSpan != nil && check.MaxTimeGap != nil && *gap.timeSpan > check.MaxTimeGap.Duration {
+		result = append(result, fmt.Sprintf("%s of changes exceed maxTimeGap %s",
+			gap.timeSpan.String(), check.MaxTimeGap.Duration.String()))
+	}
+
+	return result
+}
+
+// checkRecoveryWALGap checks, before the base backup is restored, that
+// the recovery target is not too far from the end of the base backup,
+// as replaying a large amount of WAL files can take a long time. When
+// the distance exceeds the thresholds chosen by the user, a newer base
+// backup is suggested and, in strict mode, the recovery fails
+func checkRecoveryWALGap(ctx context.Context, cluster *apiv1.Cluster, backup *apiv1.Backup) error {
+	check := getWALGapCheck(cluster)
+	target := getRequestedRecoveryTarget(cluster)
+	if check == nil || target == nil {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	gap, err := computeRecoveryWALGap(backup, target)
+	if err != nil {
+		return err
+	}
+
+	exceeded := gap.exceededThresholds(check)
+	if len(exceeded) == 0 {
+		contextLogger.Info("The recovery target is within the allowed distance from the end of the base backup",
+			"backupID", backup.Status.BackupID,
+			"walSize", gap.walSize,
+			"timeSpan", gap.timeSpan)
+		return nil
+	}
+
+	if check.Strict {
+		return fmt.Errorf("%w: base backup %s: %s, please choose a newer base backup",
+			ErrWALGapTooLarge, backup.Status.BackupID, strings.Join(exceeded, ", "))
+	}
+
+	contextLogger.Warning(
+		"The recovery target is far from the end of the base backup, and replaying the WAL files "+
+			"may take a long time. Consider restoring a newer base backup",
+		"backupID", backup.Status.BackupID,
+		"exceeded", exceeded)
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("distance between the base backup and the recovery target", func() {
+	backup := &apiv1.Backup{
+		Status: apiv1.BackupStatus{
+			BackupID:  "20240101T000000",
+			EndLSN:    "0/5000000",
+			StoppedAt: &metav1.Time{Time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	newCluster := func(target *apiv1.RecoveryTarget, check *apiv1.RecoveryWALGapCheck) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						RecoveryTarget: target,
+						WALGapCheck:    check,
+					},
+				},
+			},
+		}
+	}
+
+	It("computes the amount of WAL and the time span to be replayed", func() {
+		gap, err := computeRecoveryWALGap(backup, &apiv1.RecoveryTarget{TargetLSN: "1/5000000"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gap.walSize).To(HaveValue(Equal(int64(1) << 32)))
+		Expect(gap.timeSpan).To(BeNil())
+
+		gap, err = computeRecoveryWALGap(backup, &apiv1.RecoveryTarget{TargetTime: "2024-01-01T02:00:00Z"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(gap.walSize).To(BeNil())
+		Expect(gap.timeSpan).To(HaveValue(Equal(2 * time.Hour)))
+
+		_, err = computeRecoveryWALGap(backup, &apiv1.RecoveryTarget{TargetLSN: "invalid"})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("only warns when the distance exceeds the thresholds", func() {
+		check := &apiv1.RecoveryWALGapCheck{MaxTimeGap: &metav1.Duration{Duration: time.Hour}}
+		cluster := newCluster(&apiv1.RecoveryTarget{TargetTime: "2024-01-01T02:00:00Z"}, check)
+		Expect(checkRecoveryWALGap(context.TODO(), cluster, backup)).To(Succeed())
+	})
+
+	It("fails in strict mode when the distance exceeds the thresholds", func() {
+		check := &apiv1.RecoveryWALGapCheck{MaxWALSize: ptr.To(resource.MustParse("1Gi")), Strict: true}
+		err := checkRecoveryWALGap(context.TODO(),
+			newCluster(&apiv1.RecoveryTarget{TargetLSN: "1/5000000"}, check), backup)
+		Expect(err).To(MatchError(ErrWALGapTooLarge))
+
+		Expect(checkRecoveryWALGap(context.TODO(),
+			newCluster(&apiv1.RecoveryTarget{TargetLSN: "0/6000000"}, check), backup)).To(Succeed())
+		Expect(checkRecoveryWALGap(context.TODO(), newCluster(nil, check), backup)).To(Succeed())
+	})
+})
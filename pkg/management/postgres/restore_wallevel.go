@@ -0,0 +1,169 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// ErrWALLevelMismatch is raised when the effective wal_level of the
+// restored cluster doesn't support its intended downstream use
+var ErrWALLevelMismatch = errors.New("the wal_level doesn't support the intended downstream use")
+
+// walLevelRanks orders the values of wal_level by the
+// information written in the WAL
+var walLevelRanks = map[postgres.WalLevelValue]int{
+	postgres.WalLevelValueMinimal: 0,
+	postgres.WalLevelValueReplica: 1,
+	postgres.WalLevelValueLogical: 2,
+}
+
+// getRecoveryDownstreamUse gets the downstream use intended
+// for the restored cluster, if any
+func getRecoveryDownstreamUse(cluster *apiv1.Cluster) *apiv1.RecoveryDownstreamUse {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.DownstreamUse
+}
+
+// getRequiredWALLevel gets the wal_level required by the intended
+// downstream use, empty when there's no requirement
+func getRequiredWALLevel(downstreamUse *apiv1.RecoveryDownstreamUse) postgres.WalLevelValue {
+	if downstreamUse == nil || !downstreamUse.LogicalReplication {
+		return ""
+	}
+
+	return postgres.WalLevelValueLogical
+}
+
+// getConfiguredWALLevel gets the wal_level set in the parameters of the
+// cluster or, when not set, the default one of the operator
+func getConfiguredWALLevel(cluster *apiv1.Cluster) postgres.WalLevelValue {
+	if value, ok := cluster.Spec.PostgresConfiguration.Parameters[postgres.ParameterWalLevel]; ok {
+		return postgres.WalLevelValue(value)
+	}
+
+	return postgres.WalLevelValue(postgres.CnpgConfigurationSettings.GlobalDefaultSettings[postgres.ParameterWalLevel])
+}
+
+// getAdjustedWALLevel gets the wal_level to be set in the configuration
+// of the restored instances to support their intended downstream use,
+// empty when no adjustment is needed or allowed. A minimal wal_level is
+// never adjusted, as it requires the WAL senders to be disabled
+func getAdjustedWALLevel(cluster *apiv1.Cluster) postgres.WalLevelValue {
+	downstreamUse := getRecoveryDownstreamUse(cluster)
+	required := getRequiredWALLevel(downstreamUse)
+	if required == "" || downstreamUse.OnWALLevelMismatch == apiv1.WALLevelMismatchPolicyFail {
+		return ""
+	}
+
+	configured := getConfiguredWALLevel(cluster)
+	if configured == postgres.WalLevelValueMinimal || walLevelRanks[configured] >= walLevelRanks[required] {
+		return ""
+	}
+
+	return required
+}
+
+// checkRecoveryWALLevel checks, before the restore starts, that the
+// effective wal_level of the cluster supports its intended downstream use,
+// and reports the outcome in the cluster status. A mismatch is fixed in
+// the configuration of the restored instances or, when the user asked so
+// or it can't be fixed, makes the restore fail with the required change
+func (info InitInfo) checkRecoveryWALLevel(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+) error {
+	required := getRequiredWALLevel(getRecoveryDownstreamUse(cluster))
+	if required == "" {
+		return nil
+	}
+
+	contextLogger := log.FromContext(ctx)
+	configured := getConfiguredWALLevel(cluster)
+	report := &apiv1.WALLevelReport{
+		Detected: string(configured),
+		Required: string(required),
+	}
+
+	var mismatchErr error
+	switch {
+	case walLevelRanks[configured] >= walLevelRanks[required]:
+		contextLogger.Info("The wal_level supports the intended downstream use of the restored cluster",
+			"walLevel", configured,
+			"requiredWALLevel", required)
+
+	case getAdjustedWALLevel(cluster) != "":
+		report.Adjusted = true
+		contextLogger.Warning("The wal_level doesn't support the intended downstream use of the restored "+
+			"cluster, the required one will be set in the configuration of the restored instances",
+			"walLevel", configured,
+			"requiredWALLevel", required)
+
+	default:
+		mismatchErr = fmt.Errorf(
+			"%w: the logical replication requires wal_level %q, while the cluster is configured with %q. "+
+				"Set wal_level to %q in .spec.postgresql.parameters and restore the cluster again",
+			ErrWALLevelMismatch, required, configured, required)
+	}
+
+	if err := info.reportWALLevel(ctx, typedClient, report); err != nil {
+		return err
+	}
+
+	return mismatchErr
+}
+
+// reportWALLevel writes the wal_level of the restored cluster,
+// compared with the required one, in the cluster status
+func (info InitInfo) reportWALLevel(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.WALLevelReport,
+) error {
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.WALLevel = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the wal_level in the cluster status: %w", err)
+	}
+
+	return nil
+}
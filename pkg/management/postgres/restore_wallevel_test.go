@@ -0,0 +1,98 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("wal_level check of the restored cluster", func() {
+	var (
+		cluster     *apiv1.Cluster
+		typedClient client.Client
+		info        InitInfo
+	)
+
+	BeforeEach(func() {
+		cluster = &apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:        "origin",
+						DownstreamUse: &apiv1.RecoveryDownstreamUse{LogicalReplication: true},
+					},
+				},
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					Parameters: map[string]string{"wal_level": "replica"},
+				},
+			},
+		}
+		typedClient = fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info = InitInfo{ClusterName: "clone", Namespace: "dev"}
+	})
+
+	getReport := func() *apiv1.WALLevelReport {
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		return result.Status.WALLevel
+	}
+
+	It("reports the adjustment of the wal_level", func() {
+		Expect(info.checkRecoveryWALLevel(context.TODO(), typedClient, cluster)).To(Succeed())
+		Expect(getReport()).To(Equal(&apiv1.WALLevelReport{Detected: "replica", Required: "logical", Adjusted: true}))
+	})
+
+	It("fails with the required change when requested", func() {
+		cluster.Spec.Bootstrap.Recovery.DownstreamUse.OnWALLevelMismatch = apiv1.WALLevelMismatchPolicyFail
+		err := info.checkRecoveryWALLevel(context.TODO(), typedClient, cluster)
+		Expect(err).To(MatchError(ErrWALLevelMismatch))
+		Expect(err.Error()).To(ContainSubstring(`Set wal_level to "logical" in .spec.postgresql.parameters`))
+		Expect(getReport()).To(Equal(&apiv1.WALLevelReport{Detected: "replica", Required: "logical"}))
+	})
+
+	It("fails when the wal_level is minimal", func() {
+		cluster.Spec.PostgresConfiguration.Parameters["wal_level"] = "minimal"
+		Expect(info.checkRecoveryWALLevel(context.TODO(), typedClient, cluster)).To(MatchError(ErrWALLevelMismatch))
+	})
+
+	It("accepts the default wal_level", func() {
+		delete(cluster.Spec.PostgresConfiguration.Parameters, "wal_level")
+		Expect(info.checkRecoveryWALLevel(context.TODO(), typedClient, cluster)).To(Succeed())
+		Expect(getReport()).To(Equal(&apiv1.WALLevelReport{Detected: "logical", Required: "logical"}))
+	})
+
+	It("does nothing without a downstream use", func() {
+		cluster.Spec.Bootstrap.Recovery.DownstreamUse = nil
+		Expect(info.checkRecoveryWALLevel(context.TODO(), typedClient, cluster)).To(Succeed())
+		Expect(getReport()).To(BeNil())
+	})
+})
@@ -0,0 +1,117 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/execlog"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+)
+
+// ErrWALFetchProbeFailed is raised when the WAL files can't be fetched
+// with the restore_command PostgreSQL will use during the recovery
+var ErrWALFetchProbeFailed = errors.New("the WAL files can't be fetched from the archive")
+
+// probeWALFetch checks, when requested by the user, that the WAL files can
+// be fetched from the archive before restoring the base backup. The first
+// WAL file of the backup is fetched into a temporary location, using the
+// same command, and the same environment, of the restore_command
+func (info InitInfo) probeWALFetch(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	env []string,
+	backup *apiv1.Backup,
+) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		!cluster.Spec.Bootstrap.Recovery.ProbeWALFetch {
+		return nil
+	}
+
+	// it's the full path of the file that will temporarily contain the fetched WAL file
+	const probeWALPath = postgresSpec.RecoveryTemporaryDirectory + "/probe.wal"
+	contextLogger := log.FromContext(ctx)
+
+	cmd, err := walFetchCommand(backup, cluster)
+	if err != nil {
+		return err
+	}
+
+	env, err = info.withWALDecryptionKey(ctx, typedClient, cluster, env)
+	if err != nil {
+		return err
+	}
+
+	if err := fileutils.EnsureParentDirectoryExists(probeWALPath); err != nil {
+		return err
+	}
+	defer func() {
+		if err := fileutils.RemoveFile(probeWALPath); err != nil {
+			contextLogger.Error(err, "while deleting the temporary wal file")
+		}
+	}()
+
+	contextLogger.Info("Probing the fetch of the WAL files", "walName", backup.Status.BeginWal)
+	if err := runWALFetchProbe(ctx, cmd, env, backup.Status.BeginWal, probeWALPath); err != nil {
+		contextLogger.Error(err, "Cannot fetch the WAL files, the recovery would stall",
+			"walName", backup.Status.BeginWal)
+		return err
+	}
+
+	contextLogger.Info("The WAL files can be fetched from the archive")
+	return nil
+}
+
+// runWALFetchProbe executes, via the shell as PostgreSQL does, the passed
+// restore_command to fetch a WAL file into the passed path, and checks
+// that the file has been written
+func runWALFetchProbe(ctx context.Context, cmd restoreCommand, env []string, walName, walPath string) error {
+	command := renderRestoreCommand(cmd.render(), walName, walPath)
+
+	probeCmd := exec.CommandContext(ctx, "sh", "-c", command) // #nosec G204
+	probeCmd.Env = env
+	if err := execlog.RunBuffering(probeCmd, "restore_command"); err != nil {
+		return fmt.Errorf("%w: cannot fetch WAL file %s, which is part of the backup, "+
+			"check the credentials, the provider and the decryption settings of the WAL archive: %v",
+			ErrWALFetchProbeFailed, walName, err)
+	}
+
+	if _, err := os.Stat(walPath); err != nil {
+		return fmt.Errorf("%w: WAL file %s has not been written by the restore_command: %v",
+			ErrWALFetchProbeFailed, walName, err)
+	}
+
+	return nil
+}
+
+// renderRestoreCommand replaces the placeholders of a restore_command
+// as PostgreSQL does, with the name of the WAL file to be fetched and
+// the path where it must be written
+func renderRestoreCommand(command, walName, walPath string) string {
+	return strings.NewReplacer("%%", "%", "%f", walName, "%p", walPath).Replace(command)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("probe of the fetch of the WAL files", func() {
+	It("replaces the placeholders of the restore_command", func() {
+		Expect(renderRestoreCommand(`cp "/archive/%f" "%p" && echo 100%%`, "000000010000000000000001", "/tmp/probe.wal")).
+			To(Equal(`cp "/archive/000000010000000000000001" "/tmp/probe.wal" && echo 100%`))
+	})
+
+	It("builds the WAL fetch command of the restore_command", func() {
+		backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "origin",
+		}}
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{Bootstrap: &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+		}}}
+
+		cmd, err := walFetchCommand(backup, cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmd.render()).To(Equal("barman-cloud-wal-restore s3://backups/ origin %f %p"))
+	})
+
+	It("fetches a WAL file with the restore_command", func() {
+		archiveDir := GinkgoT().TempDir()
+		walPath := path.Join(GinkgoT().TempDir(), "probe.wal")
+		Expect(os.WriteFile(path.Join(archiveDir, "000000010000000000000001"), []byte("wal"), 0o600)).To(Succeed())
+
+		cmd := restoreCommand{fetch: []string{"cp"}, arguments: []string{archiveDir + "/%f", "%p"}}
+		Expect(runWALFetchProbe(context.TODO(), cmd, nil, "000000010000000000000001", walPath)).To(Succeed())
+		Expect(walPath).To(BeAnExistingFile())
+	})
+
+	It("fails fast when the WAL file can't be fetched", func() {
+		archiveDir := GinkgoT().TempDir()
+		walPath := path.Join(GinkgoT().TempDir(), "probe.wal")
+
+		cmd := restoreCommand{fetch: []string{"cp"}, arguments: []string{archiveDir + "/%f", "%p"}}
+		Expect(runWALFetchProbe(context.TODO(), cmd, nil, "000000010000000000000001", walPath)).
+			To(MatchError(ErrWALFetchProbeFailed))
+
+		cmd = restoreCommand{fetch: []string{"true"}}
+		Expect(runWALFetchProbe(context.TODO(), cmd, nil, "000000010000000000000001", walPath)).
+			To(MatchError(ErrWALFetchProbeFailed))
+	})
+})
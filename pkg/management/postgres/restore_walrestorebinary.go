@@ -0,0 +1,42 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// ErrWALRestoreUnavailable is raised when the program fetching the WAL
+// files, referenced by the restore_command, cannot be executed
+var ErrWALRestoreUnavailable = errors.New("the program fetching the WAL files is not available")
+
+// checkWALRestoreBinary checks that the program fetching the WAL files
+// exists and is executable. PostgreSQL inherits the PATH of the instance
+// manager, and would otherwise fail to fetch every WAL file, making the
+// recovery stall instead of failing
+func checkWALRestoreBinary(binary string) error {
+	if _, err := exec.LookPath(binary); err != nil {
+		return fmt.Errorf(
+			"%w: %v. The restore_command would fail for every WAL file: check that "+
+				"the operand image contains Barman Cloud",
+			ErrWALRestoreUnavailable, err)
+	}
+
+	return nil
+}
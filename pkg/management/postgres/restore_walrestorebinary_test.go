@@ -0,0 +1,41 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"os"
+	"path"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("availability of the program fetching the WAL files", func() {
+	It("accepts an executable program", func() {
+		Expect(checkWALRestoreBinary("sh")).To(Succeed())
+	})
+
+	It("rejects a missing program", func() {
+		Expect(checkWALRestoreBinary("cnpg-missing-wal-restore")).To(MatchError(ErrWALRestoreUnavailable))
+	})
+
+	It("rejects a program which is not executable", func() {
+		binary := path.Join(GinkgoT().TempDir(), "barman-cloud-wal-restore")
+		Expect(os.WriteFile(binary, []byte("#!/bin/sh\n"), 0o600)).To(Succeed())
+		Expect(checkWALRestoreBinary(binary)).To(MatchError(ErrWALRestoreUnavailable))
+	})
+})
@@ -0,0 +1,113 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+)
+
+// getRecoveryWALSource gets the name of the external cluster whose
+// object store contains the WAL files to be replayed, if different
+// from the one of the base backup
+func getRecoveryWALSource(cluster *apiv1.Cluster) string {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return ""
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.WALSource
+}
+
+// loadWALSource loads the external cluster whose object store contains
+// the WAL files to be replayed, together with the environment needed to
+// access it. When the WAL files are stored with the base backup, no
+// external cluster is returned and the environment of the base backup
+// is used
+func (info InitInfo) loadWALSource(
+	ctx context.Context,
+	typedClient client.Client,
+	cluster *apiv1.Cluster,
+	backup *apiv1.Backup,
+	env []string,
+) (*apiv1.ExternalCluster, []string, error) {
+	server, err := findWALSource(cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+	if server == nil {
+		return nil, env, nil
+	}
+
+	log.FromContext(ctx).Info("Fetching the WAL files from a different object store than the base backup",
+		"walSource", server.Name,
+		"endpointURL", server.BarmanObjectStore.EndpointURL,
+		"destinationPath", server.BarmanObjectStore.DestinationPath,
+		"serverName", server.GetServerName())
+
+	walEnv, err := objectStoreEnvironment(ctx, typedClient, cluster, server.BarmanObjectStore)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if walEnv, err = withBarmanHome(cluster, withWALSource(backup, server), walEnv); err != nil {
+		return nil, nil, err
+	}
+
+	return server, withRecoveryProxy(cluster, walEnv), nil
+}
+
+// findWALSource finds the external cluster whose object store contains
+// the WAL files to be replayed, if different from the one of the base
+// backup
+func findWALSource(cluster *apiv1.Cluster) (*apiv1.ExternalCluster, error) {
+	sourceName := getRecoveryWALSource(cluster)
+	if sourceName == "" {
+		return nil, nil
+	}
+
+	server, found := cluster.ExternalCluster(sourceName)
+	if !found {
+		return nil, fmt.Errorf("missing external cluster: %v", sourceName)
+	}
+	if server.BarmanObjectStore == nil {
+		return nil, fmt.Errorf("external cluster %v has no object store", sourceName)
+	}
+
+	return &server, nil
+}
+
+// withWALSource gets a copy of the backup pointing to the object store
+// containing the WAL files, which is the one used to fetch them. Without
+// a WAL source, the backup is returned as is
+func withWALSource(backup *apiv1.Backup, server *apiv1.ExternalCluster) *apiv1.Backup {
+	if server == nil {
+		return backup
+	}
+
+	result := backup.DeepCopy()
+	result.Status.BarmanCredentials = server.BarmanObjectStore.BarmanCredentials
+	result.Status.EndpointCA = server.BarmanObjectStore.EndpointCA
+	result.Status.EndpointURL = server.BarmanObjectStore.EndpointURL
+	result.Status.DestinationPath = server.BarmanObjectStore.DestinationPath
+	result.Status.ServerName = server.GetServerName()
+	return result
+}
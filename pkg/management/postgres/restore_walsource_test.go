@@ -0,0 +1,84 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WAL files stored in a different object store", func() {
+	backup := &apiv1.Backup{Status: apiv1.BackupStatus{
+		EndpointURL:     "https://backups.example.com",
+		DestinationPath: "s3://backups/",
+		ServerName:      "origin",
+		BackupID:        "20241014T000000",
+		EndWal:          "000000010000000000000002",
+	}}
+	walServer := &apiv1.ExternalCluster{
+		Name: "wals",
+		BarmanObjectStore: &apiv1.BarmanObjectStoreConfiguration{
+			EndpointURL:     "https://wals.example.com",
+			DestinationPath: "s3://wals/",
+			ServerName:      "origin-wals",
+		},
+	}
+
+	It("uses the backup as is without a WAL source", func() {
+		Expect(withWALSource(backup, nil)).To(BeIdenticalTo(backup))
+
+		server, env, err := InitInfo{}.loadWALSource(context.TODO(), nil, &apiv1.Cluster{}, backup, []string{"A=1"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server).To(BeNil())
+		Expect(env).To(Equal([]string{"A=1"}))
+	})
+
+	It("points the restore_command to the object store of the WAL files", func() {
+		walBackup := withWALSource(backup, walServer)
+		Expect(walBackup.Status.BackupID).To(Equal(backup.Status.BackupID))
+		Expect(walBackup.Status.EndWal).To(Equal(backup.Status.EndWal))
+		Expect(backup.Status.DestinationPath).To(Equal("s3://backups/"))
+
+		cmd, err := walFetchCommand(walBackup, &apiv1.Cluster{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cmd.render()).To(Equal("barman-cloud-wal-restore --endpoint-url https://wals.example.com " +
+			"s3://wals/ origin-wals %f %p"))
+	})
+
+	It("fails when the WAL source is not an external cluster with an object store", func() {
+		cluster := &apiv1.Cluster{Spec: apiv1.ClusterSpec{
+			Bootstrap: &apiv1.BootstrapConfiguration{
+				Recovery: &apiv1.BootstrapRecovery{Source: "origin", WALSource: "wals"},
+			},
+		}}
+		_, err := findWALSource(cluster)
+		Expect(err).To(HaveOccurred())
+
+		cluster.Spec.ExternalClusters = []apiv1.ExternalCluster{{Name: "wals"}}
+		_, err = findWALSource(cluster)
+		Expect(err).To(HaveOccurred())
+
+		cluster.Spec.ExternalClusters = []apiv1.ExternalCluster{*walServer}
+		server, err := findWALSource(cluster)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(server.Name).To(Equal("wals"))
+	})
+})
@@ -0,0 +1,347 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/fileutils"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/barman/restorer"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
+)
+
+// ErrRecoveryTargetOutsideWindow is raised when the recovery target
+// can't be reached restoring the selected base backup
+var ErrRecoveryTargetOutsideWindow = errors.New("the recovery target is outside the recovery window")
+
+// ErrRecoveryWindowUnavailable is raised when the WAL archive doesn't
+// contain the WAL files needed to make the base backup consistent
+var ErrRecoveryWindowUnavailable = errors.New("the recovery window is not available")
+
+// RecoveryWindow is the range of the recovery targets that can be reached
+// restoring a base backup and replaying the WAL files of the archive
+type RecoveryWindow struct {
+	// The earliest recoverable point, which is the end of the base backup
+	EarliestTime time.Time
+	EarliestLSN  postgresSpec.LSN
+
+	// The latest recoverable LSN, which is the end of the last WAL file
+	// of the archive following the base backup on its timeline
+	LatestLSN postgresSpec.LSN
+
+	// The latest recoverable time known, which is the end of the most
+	// recent base backup covered by the WAL files of the archive. The
+	// archive doesn't contain the time of the WAL records, and the
+	// latest recoverable time can be later than this one
+	LatestTime time.Time
+
+	// The last WAL file of the archive following the base backup
+	LastWAL string
+}
+
+// ComputeRecoveryWindow computes the recovery window of a base backup,
+// looking for the last WAL file of the archive following it. The search
+// fetches a number of WAL files logarithmic in the size of the archive,
+// and assumes that the archive doesn't contain gaps. The catalog of the
+// backups, if passed, is used to find the latest recoverable time
+func ComputeRecoveryWindow(
+	ctx context.Context,
+	runner BarmanRunner,
+	cluster *apiv1.Cluster,
+	env []string,
+	backup *apiv1.Backup,
+	backupCatalog *catalog.Catalog,
+) (*RecoveryWindow, error) {
+	// it's the full path of the file that will temporarily contain each WAL file
+	const probeWALPath = postgresSpec.RecoveryTemporaryDirectory + "/window.wal"
+
+	return computeRecoveryWindow(ctx, runner, cluster, env, backup, backupCatalog, probeWALPath)
+}
+
+// computeRecoveryWindow computes the recovery window of a base backup,
+// fetching the WAL files of the archive into the passed path
+func computeRecoveryWindow(
+	ctx context.Context,
+	runner BarmanRunner,
+	cluster *apiv1.Cluster,
+	env []string,
+	backup *apiv1.Backup,
+	backupCatalog *catalog.Catalog,
+	probeWALPath string,
+) (*RecoveryWindow, error) {
+	contextLogger := log.FromContext(ctx)
+
+	first, err := postgresSpec.SegmentFromName(backup.Status.EndWal)
+	if err != nil {
+		return nil, fmt.Errorf("while parsing the end WAL of the backup %q: %w", backup.Status.EndWal, err)
+	}
+
+	options, err := backupWalRestoreOptions(cluster, backup)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fileutils.EnsureParentDirectoryExists(probeWALPath); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := fileutils.RemoveFile(probeWALPath); err != nil {
+			contextLogger.Error(err, "while deleting the temporary wal file")
+		}
+	}()
+
+	walSegmentSize := inferWALSegmentSize(backup)
+	isArchived := func(offset int64) (bool, error) {
+		walName := segmentAfter(first, offset, walSegmentSize).Name()
+		err := runner.WALRestore(ctx, cluster, env, walName, probeWALPath, options)
+		if errors.Is(err, restorer.ErrWALNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("while checking the presence of WAL file %s in the archive: %w", walName, err)
+		}
+		return true, fileutils.RemoveFile(probeWALPath)
+	}
+
+	lastOffset, err := findLastArchivedOffset(isArchived)
+	if err != nil {
+		return nil, err
+	}
+	if lastOffset < 0 {
+		return nil, fmt.Errorf("%w: missing WAL file %s, containing the end of the backup",
+			ErrRecoveryWindowUnavailable, first.Name())
+	}
+
+	last := segmentAfter(first, lastOffset, walSegmentSize)
+	latestLSN, err := last.EndLSN(walSegmentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	window := &RecoveryWindow{
+		EarliestLSN: postgresSpec.LSN(backup.Status.EndLSN),
+		LatestLSN:   latestLSN,
+		LastWAL:     last.Name(),
+	}
+	if backup.Status.StoppedAt != nil {
+		window.EarliestTime = backup.Status.StoppedAt.Time
+	}
+	window.LatestTime = latestCoveredBackupTime(backupCatalog, int(first.Tli), latestLSN, window.EarliestTime)
+
+	contextLogger.Info("Computed the recovery window",
+		"backupID", backup.Status.BackupID,
+		"earliestTime", window.EarliestTime,
+		"earliestLSN", window.EarliestLSN,
+		"latestTime", window.LatestTime,
+		"latestLSN", window.LatestLSN,
+		"lastWAL", window.LastWAL)
+	return window, nil
+}
+
+// findLastArchivedOffset finds the offset of the last WAL file of the
+// archive, doubling the offset until a missing WAL file is found, and then
+// bisecting. The result is -1 when the first WAL file is missing
+func findLastArchivedOffset(isArchived func(offset int64) (bool, error)) (int64, error) {
+	found, err := isArchived(0)
+	if err != nil || !found {
+		return -1, err
+	}
+
+	// The WAL file at low is archived, the one at high is missing
+	low, high := int64(0), int64(1)
+	for {
+		found, err := isArchived(high)
+		if err != nil {
+			return -1, err
+		}
+		if !found {
+			break
+		}
+		low, high = high, high*2
+	}
+
+	for high-low > 1 {
+		middle := low + (high-low)/2
+		found, err := isArchived(middle)
+		if err != nil {
+			return -1, err
+		}
+		if found {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+
+	return low, nil
+}
+
+// segmentAfter gets the WAL segment following the passed one by
+// offset segments, on the same timeline
+func segmentAfter(segment postgresSpec.Segment, offset int64, walSegmentSize int64) postgresSpec.Segment {
+	segmentsPerLog := (int64(1) << 32) / walSegmentSize
+	position := int64(segment.Log)*segmentsPerLog + int64(segment.Seg) + offset
+	return postgresSpec.Segment{
+		Tli: segment.Tli,
+		Log: int32(position / segmentsPerLog),
+		Seg: int32(position % segmentsPerLog),
+	}
+}
+
+// inferWALSegmentSize infers the WAL segment size used by the source
+// cluster from the beginning of the backup, as the WAL file name and the
+// LSN are consistent only for the correct size. The default size is
+// used when it can't be inferred
+func inferWALSegmentSize(backup *apiv1.Backup) int64 {
+	segment, err := postgresSpec.SegmentFromName(backup.Status.BeginWal)
+	if err != nil {
+		return postgresSpec.DefaultWALSegmentSize
+	}
+	lsn, err := postgresSpec.LSN(backup.Status.BeginLSN).Parse()
+	if err != nil || int64(segment.Log) != lsn>>32 {
+		return postgresSpec.DefaultWALSegmentSize
+	}
+
+	matches := func(size int64) bool {
+		return int64(segment.Seg) == (lsn&0xFFFFFFFF)/size
+	}
+	if matches(postgresSpec.DefaultWALSegmentSize) {
+		return postgresSpec.DefaultWALSegmentSize
+	}
+
+	// PostgreSQL supports WAL segment sizes from 1MB to 1GB
+	for size := int64(1 << 20); size <= 1<<30; size *= 2 {
+		if matches(size) {
+			return size
+		}
+	}
+
+	return postgresSpec.DefaultWALSegmentSize
+}
+
+// latestCoveredBackupTime gets the end time of the most recent backup of
+// the catalog, on the passed timeline, ending before the passed LSN. When
+// there is none, the passed time is returned
+func latestCoveredBackupTime(
+	backupCatalog *catalog.Catalog,
+	timeline int,
+	latestLSN postgresSpec.LSN,
+	earliestTime time.Time,
+) time.Time {
+	result := earliestTime
+	if backupCatalog == nil {
+		return result
+	}
+
+	for _, barmanBackup := range backupCatalog.List {
+		if barmanBackup.TimeLine != timeline || barmanBackup.EndLSN == "" ||
+			latestLSN.Less(postgresSpec.LSN(barmanBackup.EndLSN)) {
+			continue
+		}
+		if barmanBackup.EndTime.After(result) {
+			result = barmanBackup.EndTime
+		}
+	}
+
+	return result
+}
+
+// checkRecoveryWindow checks, when requested by the user, that the
+// recovery target falls within the recovery window of the base backup.
+// The catalog of the base backups is listed with the environment of the
+// recovery source, while the WAL files are looked for with the passed
+// environment and backup, which may point to a different object store
+func (info InitInfo) checkRecoveryWindow(
+	ctx context.Context,
+	cluster *apiv1.Cluster,
+	catalogEnv []string,
+	env []string,
+	backup *apiv1.Backup,
+) error {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil ||
+		!cluster.Spec.Bootstrap.Recovery.VerifyRecoveryWindow {
+		return nil
+	}
+
+	target := cluster.Spec.Bootstrap.Recovery.RecoveryTarget
+	if target == nil || (target.TargetLSN == "" && target.TargetTime == "") {
+		return nil
+	}
+
+	// The catalog is only needed to find the latest recoverable time,
+	// which is not checked, and the check can go on without it
+	var backupCatalog *catalog.Catalog
+	server, found := cluster.ExternalCluster(cluster.Spec.Bootstrap.Recovery.Source)
+	if found && server.BarmanObjectStore != nil {
+		var err error
+		backupCatalog, err = info.barmanRunner(cluster).ListBackups(
+			ctx, server.BarmanObjectStore, server.GetServerName(), catalogEnv)
+		if err != nil {
+			log.FromContext(ctx).Warning("Cannot list the backups to compute the recovery window",
+				"error", err.Error())
+		}
+	}
+
+	window, err := ComputeRecoveryWindow(ctx, info.barmanRunner(cluster), cluster, env, backup, backupCatalog)
+	if err != nil {
+		return err
+	}
+
+	return window.CheckTarget(target)
+}
+
+// CheckTarget checks that a recovery target can be reached within the
+// recovery window. As the latest recoverable time is only known to be
+// not earlier than the one of the window, only the LSN targets are
+// checked against the end of the window
+func (window *RecoveryWindow) CheckTarget(target *apiv1.RecoveryTarget) error {
+	if target == nil {
+		return nil
+	}
+
+	if target.TargetLSN != "" {
+		targetLSN := postgresSpec.LSN(target.TargetLSN)
+		if _, err := targetLSN.Parse(); err != nil {
+			return fmt.Errorf("while parsing the recovery target LSN %q: %w", target.TargetLSN, err)
+		}
+		if targetLSN.Less(window.EarliestLSN) || window.LatestLSN.Less(targetLSN) {
+			return fmt.Errorf("%w: target LSN %s, recovery window from %s to %s",
+				ErrRecoveryTargetOutsideWindow, targetLSN, window.EarliestLSN, window.LatestLSN)
+		}
+	}
+
+	if target.TargetTime != "" {
+		targetTime, err := utils.ParseTargetTime(nil, target.TargetTime)
+		if err != nil {
+			return fmt.Errorf("while parsing the recovery target time %q: %w", target.TargetTime, err)
+		}
+		if !window.EarliestTime.IsZero() && targetTime.Before(window.EarliestTime) {
+			return fmt.Errorf("%w: target time %s, recovery window starting from %s",
+				ErrRecoveryTargetOutsideWindow,
+				targetTime.Format(time.RFC3339), window.EarliestTime.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
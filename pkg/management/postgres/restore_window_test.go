@@ -0,0 +1,121 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/catalog"
+	postgresSpec "github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery window of a base backup", func() {
+	stoppedAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	backup := &apiv1.Backup{
+		Status: apiv1.BackupStatus{
+			DestinationPath: "s3://backups/",
+			ServerName:      "source",
+			BackupID:        "20240301T115500",
+			BeginWal:        "000000010000000000000002",
+			BeginLSN:        "0/2000028",
+			EndWal:          "000000010000000000000004",
+			EndLSN:          "0/4000100",
+			StoppedAt:       ptr.To(metav1.NewTime(stoppedAt)),
+		},
+	}
+	cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "cluster-restore"}}
+
+	archived := func(first, last int) []string {
+		var result []string
+		for i := first; i <= last; i++ {
+			result = append(result, fmt.Sprintf("0000000100000000%08X", i))
+		}
+		return result
+	}
+
+	It("finds the last WAL file of the archive with few fetches", func() {
+		runner := &fakeBarmanRunner{archivedWALs: archived(4, 0x0B)}
+		backupCatalog := catalog.NewCatalog([]catalog.BarmanBackup{
+			{ID: "covered", TimeLine: 1, EndLSN: "0/8000000", EndTime: stoppedAt.Add(time.Hour)},
+			{ID: "not-covered", TimeLine: 1, EndLSN: "0/D000000", EndTime: stoppedAt.Add(2 * time.Hour)},
+			{ID: "other-timeline", TimeLine: 2, EndLSN: "0/6000000", EndTime: stoppedAt.Add(3 * time.Hour)},
+		})
+
+		window, err := computeRecoveryWindow(context.TODO(), runner, cluster, nil, backup, backupCatalog,
+			path.Join(GinkgoT().TempDir(), "window.wal"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*window).To(Equal(RecoveryWindow{
+			EarliestTime: stoppedAt,
+			EarliestLSN:  "0/4000100",
+			LatestLSN:    "0/C000000",
+			LatestTime:   stoppedAt.Add(time.Hour),
+			LastWAL:      "00000001000000000000000B",
+		}))
+		Expect(len(runner.restoredWALs)).To(BeNumerically("<", 8))
+	})
+
+	It("fails when the archive doesn't contain the end of the backup", func() {
+		runner := &fakeBarmanRunner{archivedWALs: []string{}}
+		_, err := computeRecoveryWindow(context.TODO(), runner, cluster, nil, backup, nil,
+			path.Join(GinkgoT().TempDir(), "window.wal"))
+		Expect(err).To(MatchError(ErrRecoveryWindowUnavailable))
+	})
+
+	It("moves to the following log file after its last segment", func() {
+		segment := postgresSpec.MustSegmentFromName("0000000100000000000000FF")
+		Expect(segmentAfter(segment, 1, postgresSpec.DefaultWALSegmentSize).Name()).
+			To(Equal("000000010000000100000000"))
+	})
+
+	It("infers the WAL segment size from the beginning of the backup", func() {
+		Expect(inferWALSegmentSize(backup)).To(Equal(postgresSpec.DefaultWALSegmentSize))
+		Expect(inferWALSegmentSize(&apiv1.Backup{Status: apiv1.BackupStatus{
+			BeginWal: "000000010000000000000002",
+			BeginLSN: "0/4000000",
+		}})).To(BeEquivalentTo(32 * 1024 * 1024))
+		Expect(inferWALSegmentSize(&apiv1.Backup{})).To(Equal(postgresSpec.DefaultWALSegmentSize))
+	})
+
+	It("checks the recovery targets against the window", func() {
+		window := &RecoveryWindow{
+			EarliestTime: stoppedAt,
+			EarliestLSN:  "0/4000100",
+			LatestLSN:    "0/C000000",
+			LatestTime:   stoppedAt.Add(time.Hour),
+		}
+		Expect(window.CheckTarget(nil)).To(Succeed())
+		Expect(window.CheckTarget(&apiv1.RecoveryTarget{TargetLSN: "0/8000000"})).To(Succeed())
+		Expect(window.CheckTarget(&apiv1.RecoveryTarget{TargetLSN: "0/D000000"})).
+			To(MatchError(ErrRecoveryTargetOutsideWindow))
+		Expect(window.CheckTarget(&apiv1.RecoveryTarget{TargetLSN: "0/3000000"})).
+			To(MatchError(ErrRecoveryTargetOutsideWindow))
+		Expect(window.CheckTarget(&apiv1.RecoveryTarget{TargetTime: "2024-03-01 11:00:00Z"})).
+			To(MatchError(ErrRecoveryTargetOutsideWindow))
+		// The latest recoverable time is only a lower bound
+		Expect(window.CheckTarget(&apiv1.RecoveryTarget{TargetTime: "2024-03-02 11:00:00Z"})).To(Succeed())
+	})
+})
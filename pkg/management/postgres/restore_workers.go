@@ -0,0 +1,157 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	postgresutils "github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/utils"
+)
+
+// recoveryWorkersOption is a parameter configuring the worker processes
+// used during the recovery
+type recoveryWorkersOption struct {
+	// the name of the PostgreSQL parameter
+	name string
+	// the first PostgreSQL major version supporting the parameter
+	minMajorVersion int
+	// the value requested for the recovery, if any
+	value func(workers *apiv1.RecoveryWorkers) *int32
+}
+
+// recoveryWorkersOptions are the parameters that can be configured
+// for the recovery phase
+var recoveryWorkersOptions = []recoveryWorkersOption{
+	{
+		name:            "max_parallel_maintenance_workers",
+		minMajorVersion: 11,
+		value:           func(workers *apiv1.RecoveryWorkers) *int32 { return workers.MaintenanceWorkers },
+	},
+	{
+		name:            "maintenance_io_concurrency",
+		minMajorVersion: 13,
+		value:           func(workers *apiv1.RecoveryWorkers) *int32 { return workers.IOConcurrency },
+	},
+}
+
+// getRecoveryWorkers gets the worker processes requested by the user
+// for the recovery, if any
+func getRecoveryWorkers(cluster *apiv1.Cluster) *apiv1.RecoveryWorkers {
+	if cluster.Spec.Bootstrap == nil || cluster.Spec.Bootstrap.Recovery == nil {
+		return nil
+	}
+
+	return cluster.Spec.Bootstrap.Recovery.Workers
+}
+
+// renderRecoveryWorkersOptions generates the parameters configuring the
+// worker processes for the recovery, leaving out the ones not supported
+// by the passed PostgreSQL major version
+func renderRecoveryWorkersOptions(
+	ctx context.Context,
+	workers *apiv1.RecoveryWorkers,
+	majorVersion int,
+) map[string]string {
+	options := make(map[string]string)
+	for _, option := range recoveryWorkersOptions {
+		value := option.value(workers)
+		if value == nil {
+			continue
+		}
+
+		if majorVersion < option.minMajorVersion {
+			log.FromContext(ctx).Warning(
+				"Ignoring a recovery workers parameter not supported by this PostgreSQL version",
+				"parameter", option.name,
+				"majorVersion", majorVersion,
+				"minMajorVersion", option.minMajorVersion)
+			continue
+		}
+
+		options[option.name] = strconv.Itoa(int(*value))
+	}
+
+	return options
+}
+
+// writeRecoveryWorkersConfiguration writes, in the custom.conf file, the
+// parameters configuring the worker processes for the recovery phase.
+// They will be set back to the values of the cluster configuration once
+// the restored instance is configured
+func (info InitInfo) writeRecoveryWorkersConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	workers := getRecoveryWorkers(cluster)
+	if workers == nil {
+		return nil
+	}
+
+	majorVersion, err := postgresutils.GetMajorVersion(info.PgData)
+	if err != nil {
+		return fmt.Errorf("cannot detect major version: %w", err)
+	}
+
+	options := renderRecoveryWorkersOptions(ctx, workers, majorVersion)
+	if len(options) == 0 {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, options); err != nil {
+		return fmt.Errorf("while configuring the recovery workers: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Configured the worker processes for the recovery", "options", options)
+
+	return nil
+}
+
+// restoreWorkersAfterRecovery sets the parameters configuring the worker
+// processes back to the values of the cluster configuration, removing the
+// ones the cluster doesn't define. The instance needs to be stopped
+func (info InitInfo) restoreWorkersAfterRecovery(ctx context.Context, cluster *apiv1.Cluster) error {
+	if getRecoveryWorkers(cluster) == nil {
+		return nil
+	}
+
+	managedOptions := make([]string, 0, len(recoveryWorkersOptions))
+	options := make(map[string]string)
+	for _, option := range recoveryWorkersOptions {
+		managedOptions = append(managedOptions, option.name)
+		if value, ok := cluster.Spec.PostgresConfiguration.Parameters[option.name]; ok {
+			options[option.name] = value
+		}
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	changed, err := configfile.UpdatePostgresConfigurationFile(targetFile, options, managedOptions...)
+	if err != nil {
+		return fmt.Errorf("while restoring the worker processes configuration: %w", err)
+	}
+
+	if changed {
+		log.FromContext(ctx).Info("Restored the worker processes configuration after the recovery",
+			"options", options)
+	}
+
+	return nil
+}
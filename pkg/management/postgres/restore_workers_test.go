@@ -0,0 +1,106 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"os"
+	"path"
+
+	"k8s.io/utils/ptr"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("recovery workers", func() {
+	var info InitInfo
+
+	BeforeEach(func() {
+		pgData, err := os.MkdirTemp("", "restore-workers")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() { _ = os.RemoveAll(pgData) })
+
+		info = InitInfo{PgData: pgData}
+		Expect(os.WriteFile(
+			path.Join(pgData, constants.PostgresqlCustomConfigurationFile),
+			[]byte("max_parallel_maintenance_workers = '2'\nshared_buffers = '128MB'\n"),
+			0o600)).To(Succeed())
+	})
+
+	writePgVersion := func(version string) {
+		Expect(os.WriteFile(path.Join(info.PgData, "PG_VERSION"), []byte(version+"\n"), 0o600)).To(Succeed())
+	}
+
+	readCustomConf := func() string {
+		content, err := os.ReadFile(path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	newCluster := func(parameters map[string]string) *apiv1.Cluster {
+		return &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				PostgresConfiguration: apiv1.PostgresConfiguration{
+					Parameters: parameters,
+				},
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Workers: &apiv1.RecoveryWorkers{
+							MaintenanceWorkers: ptr.To(int32(8)),
+							IOConcurrency:      ptr.To(int32(64)),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("writes the parameters supported by the major version", func() {
+		writePgVersion("16")
+		Expect(info.writeRecoveryWorkersConfiguration(context.TODO(), newCluster(nil))).To(Succeed())
+		Expect(readCustomConf()).To(Equal(
+			"max_parallel_maintenance_workers = '8'\nshared_buffers = '128MB'\n" +
+				"maintenance_io_concurrency = '64'\n"))
+	})
+
+	It("skips the parameters not supported by the major version", func() {
+		options := renderRecoveryWorkersOptions(context.TODO(), newCluster(nil).Spec.Bootstrap.Recovery.Workers, 12)
+		Expect(options).To(Equal(map[string]string{"max_parallel_maintenance_workers": "8"}))
+
+		options = renderRecoveryWorkersOptions(context.TODO(), newCluster(nil).Spec.Bootstrap.Recovery.Workers, 10)
+		Expect(options).To(BeEmpty())
+	})
+
+	It("restores the values of the cluster configuration after the recovery", func() {
+		writePgVersion("16")
+		cluster := newCluster(map[string]string{"max_parallel_maintenance_workers": "2"})
+		Expect(info.writeRecoveryWorkersConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(info.restoreWorkersAfterRecovery(context.TODO(), cluster)).To(Succeed())
+		Expect(readCustomConf()).To(Equal("max_parallel_maintenance_workers = '2'\nshared_buffers = '128MB'\n"))
+	})
+
+	It("leaves the configuration untouched when no workers are requested", func() {
+		writePgVersion("16")
+		Expect(info.writeRecoveryWorkersConfiguration(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(info.restoreWorkersAfterRecovery(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(readCustomConf()).To(Equal("max_parallel_maintenance_workers = '2'\nshared_buffers = '128MB'\n"))
+	})
+})
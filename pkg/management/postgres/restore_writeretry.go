@@ -0,0 +1,76 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/stringset"
+)
+
+// RetryPostRecoveryWrites is the retry configuration that is used when
+// the writes executed after the recovery fail because the promoted
+// instance is still read-only or starting up
+var RetryPostRecoveryWrites = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Steps:    6,
+	Cap:      5 * time.Second,
+}
+
+// transientPostRecoveryErrorCodes are the SQLSTATE codes of the errors
+// raised by an instance that is not ready to accept writes yet. For
+// PostgreSQL codes see https://www.postgresql.org/docs/current/errcodes-appendix.html
+var transientPostRecoveryErrorCodes = stringset.From([]string{
+	"25006", // read_only_sql_transaction
+	"57P03", // cannot_connect_now, like "the database system is starting up"
+})
+
+// isTransientPostRecoveryError checks if an error has been raised because
+// the instance is not ready to accept writes yet
+func isTransientPostRecoveryError(err error) bool {
+	var errPGX *pgconn.PgError
+	return errors.As(err, &errPGX) && transientPostRecoveryErrorCodes.Has(errPGX.Code)
+}
+
+// retryPostRecoveryWrite executes a write on the restored instance, repeating
+// it while it fails because the instance is still read-only or starting up.
+// Any other error, like a syntax error or a missing privilege, is returned
+// immediately. The failures being retried happen before anything is written,
+// so the write is safe to be repeated
+func retryPostRecoveryWrite(ctx context.Context, step string, write func() error) error {
+	contextLogger := log.FromContext(ctx).WithValues("step", step)
+
+	attempt := 0
+	return retry.OnError(RetryPostRecoveryWrites, isTransientPostRecoveryError, func() error {
+		attempt++
+		err := write()
+		if err != nil && isTransientPostRecoveryError(err) {
+			contextLogger.Info("The restored instance is not accepting writes yet, retrying",
+				"attempt", attempt,
+				"error", err.Error())
+		}
+		return err
+	})
+}
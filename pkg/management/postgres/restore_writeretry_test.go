@@ -0,0 +1,115 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("retry of the writes executed after the recovery", func() {
+	var (
+		db   *sql.DB
+		mock sqlmock.Sqlmock
+	)
+
+	roleQuery := regexp.QuoteMeta("SELECT rolsuper FROM pg_catalog.pg_roles WHERE rolname = $1")
+	alterRole := regexp.QuoteMeta(`ALTER ROLE "app" WITH PASSWORD 'secret'`)
+	resets := []rolePasswordReset{{name: "app", password: "secret"}}
+
+	BeforeEach(func() {
+		var err error
+		db, mock, err = sqlmock.New()
+		Expect(err).ToNot(HaveOccurred())
+
+		retryPostRecoveryWrites := RetryPostRecoveryWrites
+		RetryPostRecoveryWrites = wait.Backoff{Duration: time.Millisecond, Steps: 3}
+		DeferCleanup(func() {
+			RetryPostRecoveryWrites = retryPostRecoveryWrites
+		})
+	})
+
+	AfterEach(func() {
+		Expect(mock.ExpectationsWereMet()).To(Succeed())
+	})
+
+	It("retries the writes refused by an instance that is still read-only", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(roleQuery).WithArgs("app").
+			WillReturnRows(sqlmock.NewRows([]string{"rolsuper"}).AddRow(false))
+		mock.ExpectExec(alterRole).WillReturnError(&pgconn.PgError{
+			Code:    "25006",
+			Message: "cannot execute ALTER ROLE in a read-only transaction",
+		})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectQuery(roleQuery).WithArgs("app").
+			WillReturnRows(sqlmock.NewRows([]string{"rolsuper"}).AddRow(false))
+		mock.ExpectExec(alterRole).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		Expect(retryPostRecoveryWrite(context.TODO(), "passwordResets", func() error {
+			return applyPasswordResets(context.TODO(), db, resets)
+		})).To(Succeed())
+	})
+
+	It("fails immediately on the other errors", func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(roleQuery).WithArgs("app").
+			WillReturnRows(sqlmock.NewRows([]string{"rolsuper"}).AddRow(false))
+		mock.ExpectExec(alterRole).WillReturnError(&pgconn.PgError{
+			Code:    "42501",
+			Message: "permission denied to alter role",
+		})
+		mock.ExpectRollback()
+
+		err := retryPostRecoveryWrite(context.TODO(), "passwordResets", func() error {
+			return applyPasswordResets(context.TODO(), db, resets)
+		})
+		Expect(err).To(MatchError(ContainSubstring("permission denied to alter role")))
+	})
+
+	It("gives up when the instance keeps refusing the writes", func() {
+		attempts := 0
+		err := retryPostRecoveryWrite(context.TODO(), "configureNewInstance", func() error {
+			attempts++
+			return fmt.Errorf("while connecting: %w", &pgconn.PgError{
+				Code:    "57P03",
+				Message: "the database system is starting up",
+			})
+		})
+		Expect(isTransientPostRecoveryError(err)).To(BeTrue())
+		Expect(attempts).To(Equal(RetryPostRecoveryWrites.Steps))
+	})
+
+	It("recognizes only the errors raised by an instance not ready for writes", func() {
+		Expect(isTransientPostRecoveryError(&pgconn.PgError{Code: "25006"})).To(BeTrue())
+		Expect(isTransientPostRecoveryError(&pgconn.PgError{Code: "42601"})).To(BeFalse())
+		Expect(isTransientPostRecoveryError(errors.New("read-only"))).To(BeFalse())
+	})
+})
@@ -0,0 +1,220 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/configfile"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+)
+
+const (
+	// zeroDamagedPagesOption is the GUC making PostgreSQL zero out
+	// the damaged pages instead of raising an error
+	zeroDamagedPagesOption = "zero_damaged_pages"
+
+	// maxReportedZeroedPages is the maximum number of zeroed pages
+	// listed in the cluster status
+	maxReportedZeroedPages = 100
+)
+
+// zeroedPageMessageRe matches the warning raised by PostgreSQL when
+// a damaged page is zeroed out
+var zeroedPageMessageRe = regexp.MustCompile(
+	`^invalid page in block (\d+) of relation (.+); zeroing out page$`)
+
+// isZeroDamagedPagesRecovery checks if the user requested to zero
+// out the damaged pages found during the recovery
+func isZeroDamagedPagesRecovery(cluster *apiv1.Cluster) bool {
+	return cluster.Spec.Bootstrap != nil &&
+		cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.ZeroDamagedPages
+}
+
+// writeZeroDamagedPagesConfiguration enables, in the custom.conf file,
+// the zeroing of the damaged pages for the recovery phase. It will be
+// disabled as soon as the recovery is completed
+func (info InitInfo) writeZeroDamagedPagesConfiguration(ctx context.Context, cluster *apiv1.Cluster) error {
+	if !isZeroDamagedPagesRecovery(cluster) {
+		return nil
+	}
+
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(
+		targetFile,
+		map[string]string{zeroDamagedPagesOption: "on"},
+	); err != nil {
+		return fmt.Errorf("while enabling the zeroing of the damaged pages: %w", err)
+	}
+
+	log.FromContext(ctx).Warning(
+		"ZERO DAMAGED PAGES REQUESTED: the damaged pages found during the recovery will be " +
+			"zeroed out, and the data they contain will be lost. The zeroed pages will be " +
+			"reported in the cluster status")
+
+	return nil
+}
+
+// completeZeroDamagedPagesRecovery removes the zeroing of the damaged
+// pages from the configuration, and reports the pages zeroed out during
+// the recovery. The instance needs to be stopped
+func (info InitInfo) completeZeroDamagedPagesRecovery(
+	ctx context.Context,
+	collector *zeroedPagesCollector,
+) error {
+	if err := info.disableZeroDamagedPages(); err != nil {
+		return err
+	}
+
+	typedClient, err := management.NewControllerRuntimeClient()
+	if err != nil {
+		return err
+	}
+
+	return info.reportZeroedPages(ctx, typedClient, collector.getReport())
+}
+
+// disableZeroDamagedPages removes the zeroing of the damaged pages
+// from the configuration
+func (info InitInfo) disableZeroDamagedPages() error {
+	targetFile := path.Join(info.PgData, constants.PostgresqlCustomConfigurationFile)
+	if _, err := configfile.UpdatePostgresConfigurationFile(targetFile, nil, zeroDamagedPagesOption); err != nil {
+		return fmt.Errorf("while disabling the zeroing of the damaged pages: %w", err)
+	}
+
+	return nil
+}
+
+// zeroedPagesCollector is a log record writer collecting the pages zeroed
+// out by PostgreSQL, while forwarding every record to another writer
+type zeroedPagesCollector struct {
+	writer logpipe.RecordWriter
+
+	mu     sync.Mutex
+	report apiv1.ZeroedPagesReport
+}
+
+// newZeroedPagesCollector creates a collector forwarding the log
+// records to the instance manager logger
+func newZeroedPagesCollector() *zeroedPagesCollector {
+	return &zeroedPagesCollector{writer: &logpipe.LogRecordWriter{}}
+}
+
+// Write implements the logpipe.RecordWriter interface
+func (collector *zeroedPagesCollector) Write(record logpipe.NamedRecord) {
+	collector.writer.Write(record)
+
+	loggingRecord, ok := record.(*logpipe.LoggingRecord)
+	if !ok {
+		return
+	}
+
+	matches := zeroedPageMessageRe.FindStringSubmatch(loggingRecord.Message)
+	if matches == nil {
+		return
+	}
+
+	block, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return
+	}
+
+	collector.addPage(matches[2], block)
+}
+
+// addPage records a zeroed page
+func (collector *zeroedPagesCollector) addPage(relation string, block int64) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	report := &collector.report
+	report.Count++
+	if report.Count > maxReportedZeroedPages {
+		report.Truncated = true
+		return
+	}
+
+	for i := range report.Relations {
+		if report.Relations[i].Relation == relation {
+			report.Relations[i].Blocks = append(report.Relations[i].Blocks, block)
+			return
+		}
+	}
+	report.Relations = append(report.Relations, apiv1.ZeroedPagesRelation{
+		Relation: relation,
+		Blocks:   []int64{block},
+	})
+}
+
+// getReport gets a copy of the zeroed pages collected so far
+func (collector *zeroedPagesCollector) getReport() *apiv1.ZeroedPagesReport {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	return collector.report.DeepCopy()
+}
+
+// reportZeroedPages writes the pages zeroed out during the recovery in
+// the cluster status, and in the logs of the recovery job
+func (info InitInfo) reportZeroedPages(
+	ctx context.Context,
+	typedClient client.Client,
+	report *apiv1.ZeroedPagesReport,
+) error {
+	contextLogger := log.FromContext(ctx)
+	if report.Count > 0 {
+		contextLogger.Warning("Damaged pages have been zeroed out during the recovery, their data is lost",
+			"count", report.Count,
+			"relations", report.Relations,
+			"truncated", report.Truncated)
+	} else {
+		contextLogger.Info("No damaged page has been zeroed out during the recovery")
+	}
+
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		var cluster apiv1.Cluster
+		if err := typedClient.Get(
+			ctx,
+			client.ObjectKey{Namespace: info.Namespace, Name: info.ClusterName},
+			&cluster,
+		); err != nil {
+			return err
+		}
+
+		origCluster := cluster.DeepCopy()
+		cluster.Status.ZeroedPages = report
+		return typedClient.Status().Patch(ctx, &cluster, client.MergeFrom(origCluster))
+	})
+	if err != nil {
+		return fmt.Errorf("while reporting the zeroed pages in the cluster status: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/scheme"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/constants"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres/logpipe"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("zeroing the damaged pages", func() {
+	zeroedPage := func(block int, relation string) *logpipe.LoggingRecord {
+		return &logpipe.LoggingRecord{
+			ErrorSeverity: "WARNING",
+			Message:       fmt.Sprintf("invalid page in block %d of relation %s; zeroing out page", block, relation),
+		}
+	}
+
+	It("enables the zeroing of the damaged pages only when requested", func() {
+		pgData, err := os.MkdirTemp("", "restore-zeroed-pages")
+		Expect(err).ToNot(HaveOccurred())
+		DeferCleanup(func() { _ = os.RemoveAll(pgData) })
+		customConf := path.Join(pgData, constants.PostgresqlCustomConfigurationFile)
+		Expect(os.WriteFile(customConf, []byte("shared_buffers = '128MB'\n"), 0o600)).To(Succeed())
+		info := InitInfo{PgData: pgData}
+
+		Expect(info.writeZeroDamagedPagesConfiguration(context.TODO(), &apiv1.Cluster{})).To(Succeed())
+		Expect(os.ReadFile(customConf)).To(BeEquivalentTo("shared_buffers = '128MB'\n"))
+
+		cluster := &apiv1.Cluster{
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{ZeroDamagedPages: true},
+				},
+			},
+		}
+		Expect(info.writeZeroDamagedPagesConfiguration(context.TODO(), cluster)).To(Succeed())
+		Expect(os.ReadFile(customConf)).To(BeEquivalentTo("shared_buffers = '128MB'\nzero_damaged_pages = 'on'\n"))
+
+		Expect(info.disableZeroDamagedPages()).To(Succeed())
+		Expect(os.ReadFile(customConf)).To(BeEquivalentTo("shared_buffers = '128MB'\n"))
+	})
+
+	It("collects the zeroed pages while forwarding every record", func() {
+		writer := &recordingWriter{}
+		collector := &zeroedPagesCollector{writer: writer}
+
+		collector.Write(zeroedPage(12, "base/16384/16385"))
+		collector.Write(&logpipe.LoggingRecord{Message: "redo done at 0/3000148"})
+		collector.Write(zeroedPage(40, "base/16384/16385"))
+		collector.Write(zeroedPage(3, "base/16384/16390"))
+
+		Expect(writer.records).To(HaveLen(4))
+		Expect(collector.getReport()).To(Equal(&apiv1.ZeroedPagesReport{
+			Count: 3,
+			Relations: []apiv1.ZeroedPagesRelation{
+				{Relation: "base/16384/16385", Blocks: []int64{12, 40}},
+				{Relation: "base/16384/16390", Blocks: []int64{3}},
+			},
+		}))
+	})
+
+	It("truncates the list of the zeroed pages", func() {
+		collector := &zeroedPagesCollector{writer: &recordingWriter{}}
+		for i := 0; i < maxReportedZeroedPages+5; i++ {
+			collector.Write(zeroedPage(i, "base/16384/16385"))
+		}
+
+		report := collector.getReport()
+		Expect(report.Count).To(Equal(maxReportedZeroedPages + 5))
+		Expect(report.Truncated).To(BeTrue())
+		Expect(report.Relations).To(HaveLen(1))
+		Expect(report.Relations[0].Blocks).To(HaveLen(maxReportedZeroedPages))
+	})
+
+	It("reports the zeroed pages in the cluster status", func() {
+		cluster := &apiv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "clone", Namespace: "dev"}}
+		typedClient := fake.NewClientBuilder().
+			WithScheme(scheme.BuildWithAllKnownScheme()).
+			WithObjects(cluster).
+			WithStatusSubresource(cluster).
+			Build()
+		info := InitInfo{ClusterName: "clone", Namespace: "dev"}
+		report := &apiv1.ZeroedPagesReport{
+			Count:     1,
+			Relations: []apiv1.ZeroedPagesRelation{{Relation: "base/16384/16385", Blocks: []int64{12}}},
+		}
+
+		Expect(info.reportZeroedPages(context.TODO(), typedClient, report)).To(Succeed())
+
+		var result apiv1.Cluster
+		Expect(typedClient.Get(context.TODO(), client.ObjectKeyFromObject(cluster), &result)).To(Succeed())
+		Expect(result.Status.ZeroedPages).To(Equal(report))
+	})
+})
@@ -0,0 +1,67 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/url"
+)
+
+type restoreWebserverEndpoints struct {
+	status *postgres.RestoreStatusTracker
+}
+
+// NewRestoreWebServer returns a webserver serving the status of a running
+// restore. It allows connections only from localhost, as the restore job
+// doesn't have the certificates needed to authenticate the clients
+func NewRestoreWebServer(status *postgres.RestoreStatusTracker) *Webserver {
+	endpoints := restoreWebserverEndpoints{
+		status: status,
+	}
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf("localhost:%d", url.LocalPort),
+		Handler:           endpoints.serveMux(),
+		ReadHeaderTimeout: DefaultReadHeaderTimeout,
+		ReadTimeout:       DefaultReadTimeout,
+	}
+
+	return NewWebServer(server)
+}
+
+// serveMux routes the requests to the endpoints of the restore webserver
+func (ws *restoreWebserverEndpoints) serveMux() *http.ServeMux {
+	serveMux := http.NewServeMux()
+	serveMux.HandleFunc(url.PathRestoreStatus, ws.serveRestoreStatus)
+	return serveMux
+}
+
+// serveRestoreStatus reports the phase of the restore, the progress
+// of the WAL replay and the last error encountered, if any
+func (ws *restoreWebserverEndpoints) serveRestoreStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	log.Trace("Restore status request received")
+	sendJSONResponseWithData(w, http.StatusOK, ws.status.Get())
+}
@@ -0,0 +1,53 @@
+/*
+Copyright The CloudNativePG Contributors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/postgres"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("restore webserver", func() {
+	It("serves the status of the restore", func() {
+		endpoints := restoreWebserverEndpoints{status: postgres.NewRestoreStatusTracker()}
+		recorder := httptest.NewRecorder()
+
+		endpoints.serveMux().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, url.PathRestoreStatus, nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+
+		var response Response[postgres.RestoreStatus]
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.EnsureDataIsPresent()).To(Succeed())
+		Expect(response.Data.StartedAt).ToNot(BeZero())
+	})
+
+	It("accepts only GET requests", func() {
+		endpoints := restoreWebserverEndpoints{status: postgres.NewRestoreStatusTracker()}
+		recorder := httptest.NewRecorder()
+
+		endpoints.serveMux().ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, url.PathRestoreStatus, nil))
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
@@ -58,6 +58,9 @@ const (
 	// PathUpdate is the URL path for the instance manager update function
 	PathUpdate string = "/update"
 
+	// PathRestoreStatus is the URL path for the status of a running restore
+	PathRestoreStatus string = "/restore/status"
+
 	// PathCache is the URL path for cached resources
 	PathCache string = "/cache/"
 
@@ -120,7 +120,7 @@ host all all all {{.DefaultAuthenticationMethod}}
 #
 
 # Grant local access ('local' user map)
-local {{.Username}} postgres
+local {{.Username}} {{.SuperUser}}
 
 #
 # USER-DEFINED RULES
@@ -207,6 +207,22 @@ local {{.Username}} postgres
 	// used by the PostgreSQL server
 	SocketDirectory = ScratchDataDirectory + "/run"
 
+	// LocalBackupDirectory is where the volume containing the backup
+	// is mounted, when recovering from a local volume
+	LocalBackupDirectory = "/var/lib/postgresql/local-backup"
+
+	// RecoveryStagingDirectory is where the staging volume is mounted,
+	// when the base backup is restored there before being moved to PGDATA
+	RecoveryStagingDirectory = "/var/lib/postgresql/staging"
+
+	// RecoveryDownloadCacheDirectory is where the volume keeping the
+	// downloaded base backups is mounted, when the download cache is used
+	RecoveryDownloadCacheDirectory = "/var/lib/postgresql/download-cache"
+
+	// LogicalDumpDirectory is where the volume containing the logical
+	// dumps is mounted, when importing them
+	LogicalDumpDirectory = "/var/lib/postgresql/logical-dump"
+
 	// ServerPort is the port where the postmaster process will be listening.
 	// It's also used in the naming of the Unix socket
 	ServerPort = 5432
@@ -543,16 +559,19 @@ func CreateHBARules(hba []string,
 }
 
 // CreateIdentRules will create the content of pg_ident.conf file given
-// the rules set by the cluster spec
-func CreateIdentRules(ident []string, username string) (string, error) {
+// the rules set by the cluster spec. The 'local' map grants the operating
+// system user running PostgreSQL access as the database superuser
+func CreateIdentRules(ident []string, username string, superUser string) (string, error) {
 	var identContent bytes.Buffer
 
 	templateData := struct {
-		Mappings []string
-		Username string
+		Mappings  []string
+		Username  string
+		SuperUser string
 	}{
-		Mappings: ident,
-		Username: username,
+		Mappings:  ident,
+		Username:  username,
+		SuperUser: superUser,
 	}
 
 	if err := identTemplate.Execute(&identContent, templateData); err != nil {
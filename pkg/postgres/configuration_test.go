@@ -269,15 +269,20 @@ var _ = Describe("pg_ident.conf generation", func() {
 	}
 
 	It("contains the default map when no mappings are added", func() {
-		Expect(CreateIdentRules(make([]string, 0), "someone")).To(
+		Expect(CreateIdentRules(make([]string, 0), "someone", "postgres")).To(
 			ContainSubstring("\nlocal someone postgres\n"))
 	})
 
 	It("contains the default map and additional mappings when added", func() {
-		rules, _ := CreateIdentRules(specRules, "someone")
+		rules, _ := CreateIdentRules(specRules, "someone", "postgres")
 		Expect(rules).To(ContainSubstring("\nlocal someone postgres\n"))
 		Expect(rules).To(ContainSubstring("\ntest someone else\n"))
 	})
+
+	It("maps an operating system user different from postgres to the superuser", func() {
+		Expect(CreateIdentRules(nil, "enterprisedb", "postgres")).To(
+			ContainSubstring("\nlocal enterprisedb postgres\n"))
+	})
 })
 
 var _ = Describe("pgaudit", func() {
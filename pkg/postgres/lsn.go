@@ -42,10 +42,11 @@ func (lsn LSN) Less(other LSN) bool {
 	return p1 < p2
 }
 
-// Parse an LSN in its components
+// Parse an LSN in its components. Both of them must be composed of
+// one to eight hexadecimal digits, as PostgreSQL does
 func (lsn LSN) Parse() (int64, error) {
 	components := strings.Split(string(lsn), "/")
-	if len(components) != 2 {
+	if len(components) != 2 || !isLSNComponent(components[0]) || !isLSNComponent(components[1]) {
 		return 0, fmt.Errorf("error parsing LSN %s", lsn)
 	}
 
@@ -63,3 +64,18 @@ func (lsn LSN) Parse() (int64, error) {
 
 	return (segment << 32) + displacement, nil
 }
+
+// isLSNComponent checks if the passed string is a valid half of an LSN
+func isLSNComponent(component string) bool {
+	if len(component) == 0 || len(component) > 8 {
+		return false
+	}
+
+	for _, c := range component {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+
+	return true
+}
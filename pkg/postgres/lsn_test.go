@@ -30,6 +30,16 @@ var _ = Describe("LSN handling functions", func() {
 			Expect(err).To(HaveOccurred())
 			_, err = LSN("28734982739847293874823974928738423/987429837498273498723984723").Parse()
 			Expect(err).To(HaveOccurred())
+			_, err = LSN("-1/10").Parse()
+			Expect(err).To(HaveOccurred())
+			_, err = LSN("+1/10").Parse()
+			Expect(err).To(HaveOccurred())
+			_, err = LSN("1/").Parse()
+			Expect(err).To(HaveOccurred())
+			_, err = LSN("123456789/0").Parse()
+			Expect(err).To(HaveOccurred())
+			_, err = LSN("0/3000000 ").Parse()
+			Expect(err).To(HaveOccurred())
 		})
 
 		It("works for good LSNs", func() {
@@ -149,6 +149,23 @@ func (segment Segment) Name() string {
 	return fmt.Sprintf("%08X%08X%08X", segment.Tli, segment.Log, segment.Seg)
 }
 
+// EndLSN gets the LSN where the segment ends, which is the first position
+// of the following one. The result depends on the WAL segment size that
+// was used to generate the segment name
+func (segment Segment) EndLSN(walSegmentSize int64) (LSN, error) {
+	if walSegmentSize <= 0 || walSegmentSize&(walSegmentSize-1) != 0 {
+		return "", fmt.Errorf("invalid WAL segment size: %d", walSegmentSize)
+	}
+
+	if segment.Seg < 0 || int64(segment.Seg) >= (1<<32)/walSegmentSize {
+		return "", fmt.Errorf("%w: %s with a segment size of %d bytes",
+			ErrorBadWALSegmentName, segment.Name(), walSegmentSize)
+	}
+
+	end := (int64(segment.Log) << 32) + (int64(segment.Seg)+1)*walSegmentSize
+	return LSN(fmt.Sprintf("%X/%X", end>>32, end&0xFFFFFFFF)), nil
+}
+
 // WalSegmentsPerFile is the number of WAL Segments in a WAL File
 func WalSegmentsPerFile(walSegmentSize int64) int32 {
 	// Given that segment section is represented by 8 hex characters,
@@ -244,3 +244,33 @@ var _ = Describe("WAL files checking", func() {
 		}
 	})
 })
+
+var _ = Describe("Segment end LSN", func() {
+	It("computes the end of a segment with the default segment size", func() {
+		Expect(MustSegmentFromName("000000010000000000000001").EndLSN(DefaultWALSegmentSize)).
+			To(Equal(LSN("0/2000000")))
+		Expect(MustSegmentFromName("0000000100000ABC00000010").EndLSN(DefaultWALSegmentSize)).
+			To(Equal(LSN("ABC/11000000")))
+	})
+
+	It("computes the end of the last segment of a log", func() {
+		Expect(MustSegmentFromName("0000000100000ABC000000FF").EndLSN(DefaultWALSegmentSize)).
+			To(Equal(LSN("ABD/0")))
+	})
+
+	It("computes the end of a segment with a custom segment size", func() {
+		segmentSize := int64(1 << 30)
+		Expect(MustSegmentFromName("000000010000000500000002").EndLSN(segmentSize)).
+			To(Equal(LSN("5/C0000000")))
+		Expect(MustSegmentFromName("000000010000000500000003").EndLSN(segmentSize)).
+			To(Equal(LSN("6/0")))
+	})
+
+	It("rejects a segment that can't exist with the segment size", func() {
+		_, err := MustSegmentFromName("000000010000000500000004").EndLSN(1 << 30)
+		Expect(err).To(MatchError(ErrorBadWALSegmentName))
+
+		_, err = MustSegmentFromName("000000010000000500000001").EndLSN(1000)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -65,7 +65,16 @@ func RegisterPhaseWithOrigCluster(
 		Message: "Cluster Is Not Ready",
 	}
 
-	if modifiedCluster.Status.Phase == apiv1.PhaseHealthy {
+	switch {
+	case modifiedCluster.Status.Phase == apiv1.PhaseHealthy && modifiedCluster.IsPostRestoreMaintenancePending():
+		condition = metav1.Condition{
+			Type:    string(apiv1.ConditionClusterReady),
+			Status:  metav1.ConditionFalse,
+			Reason:  string(apiv1.ClusterPostRestoreMaintenancePending),
+			Message: "Cluster is waiting for the post-restore maintenance to be completed",
+		}
+
+	case modifiedCluster.Status.Phase == apiv1.PhaseHealthy:
 		condition = metav1.Condition{
 			Type:    string(apiv1.ConditionClusterReady),
 			Status:  metav1.ConditionTrue,
@@ -43,16 +43,16 @@ func createBootstrapContainer(cluster apiv1.Cluster) corev1.Container {
 		SecurityContext: CreateContainerSecurityContext(cluster.GetSeccompProfile()),
 	}
 
-	addManagerLoggingOptions(cluster, &container)
+	addManagerLoggingOptions(cluster.Spec.LogLevel, &container)
 
 	return container
 }
 
 // addManagerLoggingOptions propagate the logging configuration
 // to the manager inside the generated pod.
-func addManagerLoggingOptions(cluster apiv1.Cluster, container *corev1.Container) {
-	if cluster.Spec.LogLevel != "" {
-		container.Command = append(container.Command, fmt.Sprintf("--log-level=%s", cluster.Spec.LogLevel))
+func addManagerLoggingOptions(logLevel string, container *corev1.Container) {
+	if logLevel != "" {
+		container.Command = append(container.Command, fmt.Sprintf("--log-level=%s", logLevel))
 	}
 	container.Command = append(container.Command, log.GetFieldsRemapFlags()...)
 }
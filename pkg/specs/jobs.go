@@ -18,6 +18,7 @@ package specs
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/kballard/go-shellquote"
 	batchv1 "k8s.io/api/batch/v1"
@@ -27,6 +28,7 @@ import (
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
 	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/management/log"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 	"github.com/cloudnative-pg/cloudnative-pg/pkg/utils"
 )
 
@@ -86,7 +88,9 @@ func CreatePrimaryJobViaInitdb(cluster apiv1.Cluster, nodeSerial int) *batchv1.J
 	initCommand = append(initCommand, buildCommonInitJobFlags(cluster)...)
 
 	if cluster.Spec.Bootstrap.InitDB.Import != nil {
-		return createPrimaryJob(cluster, nodeSerial, jobRoleImport, initCommand)
+		job := createPrimaryJob(cluster, nodeSerial, jobRoleImport, initCommand)
+		addLogicalDumpVolumeToJob(cluster, job)
+		return job
 	}
 
 	if cluster.ShouldInitDBRunPostInitApplicationSQLRefs() {
@@ -194,14 +198,122 @@ func CreatePrimaryJobViaRecovery(cluster apiv1.Cluster, nodeSerial int, backup *
 	}
 
 	initCommand = append(initCommand, buildCommonInitJobFlags(cluster)...)
+	initCommand = append(initCommand, buildRestoreConcurrencyFlags()...)
 
 	job := createPrimaryJob(cluster, nodeSerial, jobRoleFullRecovery, initCommand)
 
 	addBarmanEndpointCAToJobFromCluster(cluster, backup, job)
+	addLocalBackupVolumeToJob(cluster, job)
+	addRecoveryStagingVolumeToJob(cluster, job)
+	addRecoveryDownloadCacheVolumeToJob(cluster, job)
 
 	return job
 }
 
+// addLocalBackupVolumeToJob mounts, in read-only mode, the volume
+// containing the backup to be restored, when recovering from a local volume
+func addLocalBackupVolumeToJob(cluster apiv1.Cluster, job *batchv1.Job) {
+	local := cluster.Spec.Bootstrap.Recovery.Local
+	if local == nil {
+		return
+	}
+
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "local-backup",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: local.ClaimName,
+				ReadOnly:  true,
+			},
+		},
+	})
+	job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+		job.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "local-backup",
+			MountPath: postgres.LocalBackupDirectory,
+			ReadOnly:  true,
+		},
+	)
+}
+
+// addRecoveryStagingVolumeToJob mounts the volume where the base backup
+// is restored before being moved to PGDATA, if any
+func addRecoveryStagingVolumeToJob(cluster apiv1.Cluster, job *batchv1.Job) {
+	staging := cluster.Spec.Bootstrap.Recovery.Staging
+	if staging == nil {
+		return
+	}
+
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "recovery-staging",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: staging.ClaimName,
+			},
+		},
+	})
+	job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+		job.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "recovery-staging",
+			MountPath: postgres.RecoveryStagingDirectory,
+		},
+	)
+}
+
+// addRecoveryDownloadCacheVolumeToJob mounts the volume where the
+// downloaded base backups are kept, if any
+func addRecoveryDownloadCacheVolumeToJob(cluster apiv1.Cluster, job *batchv1.Job) {
+	downloadCache := cluster.Spec.Bootstrap.Recovery.DownloadCache
+	if downloadCache == nil {
+		return
+	}
+
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "recovery-download-cache",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: downloadCache.ClaimName,
+			},
+		},
+	})
+	job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+		job.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "recovery-download-cache",
+			MountPath: postgres.RecoveryDownloadCacheDirectory,
+		},
+	)
+}
+
+// addLogicalDumpVolumeToJob mounts, in read-only mode, the volume
+// containing the logical dumps to be imported, if any
+func addLogicalDumpVolumeToJob(cluster apiv1.Cluster, job *batchv1.Job) {
+	dump := cluster.Spec.Bootstrap.InitDB.Import.Source.Dump
+	if dump == nil {
+		return
+	}
+
+	job.Spec.Template.Spec.Volumes = append(job.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: "logical-dump",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: dump.ClaimName,
+				ReadOnly:  true,
+			},
+		},
+	})
+	job.Spec.Template.Spec.Containers[0].VolumeMounts = append(
+		job.Spec.Template.Spec.Containers[0].VolumeMounts,
+		corev1.VolumeMount{
+			Name:      "logical-dump",
+			MountPath: postgres.LogicalDumpDirectory,
+			ReadOnly:  true,
+		},
+	)
+}
+
 func addBarmanEndpointCAToJobFromCluster(cluster apiv1.Cluster, backup *apiv1.Backup, job *batchv1.Job) {
 	var credentials apiv1.BarmanCredentials
 	var endpointCA *apiv1.SecretKeySelector
@@ -278,6 +390,19 @@ func buildCommonInitJobFlags(cluster apiv1.Cluster) []string {
 	return flags
 }
 
+// buildRestoreConcurrencyFlags builds the flags limiting the number of
+// restores running at the same time, as configured in the operator
+func buildRestoreConcurrencyFlags() []string {
+	if configuration.Current.RestoreConcurrencyLimit <= 0 {
+		return nil
+	}
+
+	return []string{
+		"--restore-concurrency-limit", strconv.Itoa(configuration.Current.RestoreConcurrencyLimit),
+		"--restore-concurrency-namespace", configuration.Current.GetRestoreConcurrencyNamespace(),
+	}
+}
+
 // jobRole describe a possible type of job
 type jobRole string
 
@@ -297,6 +422,18 @@ func (role jobRole) getJobName(instanceName string) string {
 	return fmt.Sprintf("%s-%s", instanceName, role)
 }
 
+// getLogLevel returns the log level of the instance manager running
+// the job. The recovery jobs can use a log level different from the
+// one of the cluster
+func (role jobRole) getLogLevel(cluster apiv1.Cluster) string {
+	switch role {
+	case jobRoleFullRecovery, jobRoleSnapshotRecovery:
+		return cluster.GetRecoveryLogLevel()
+	default:
+		return cluster.Spec.LogLevel
+	}
+}
+
 // GetPossibleJobNames get all the possible job names for a given instance
 func GetPossibleJobNames(instanceName string) []string {
 	res := make([]string, len(jobRoleList))
@@ -372,7 +509,7 @@ func createPrimaryJob(cluster apiv1.Cluster, nodeSerial int, role jobRole, initC
 	}
 
 	cluster.SetInheritedDataAndOwnership(&job.ObjectMeta)
-	addManagerLoggingOptions(cluster, &job.Spec.Template.Spec.Containers[0])
+	addManagerLoggingOptions(role.getLogLevel(cluster), &job.Spec.Template.Spec.Containers[0])
 	if utils.IsAnnotationAppArmorPresent(&job.Spec.Template.Spec, cluster.Annotations) {
 		utils.AnnotateAppArmor(&job.ObjectMeta, &job.Spec.Template.Spec, cluster.Annotations)
 	}
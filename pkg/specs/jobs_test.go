@@ -22,6 +22,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	apiv1 "github.com/cloudnative-pg/cloudnative-pg/api/v1"
+	"github.com/cloudnative-pg/cloudnative-pg/internal/configuration"
+	"github.com/cloudnative-pg/cloudnative-pg/pkg/postgres"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -166,3 +168,220 @@ var _ = Describe("Job created via InitDB", func() {
 			postInitApplicationSQLRefsFolder.toString()))
 	})
 })
+
+var _ = Describe("Job created via logical import", func() {
+	newCluster := func(source apiv1.ImportSource) apiv1.Cluster {
+		return apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					InitDB: &apiv1.BootstrapInitDB{
+						Import: &apiv1.Import{
+							Source:    source,
+							Type:      apiv1.MonolithSnapshotType,
+							Databases: []string{"*"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	It("mounts the volume containing the logical dumps", func() {
+		job := CreatePrimaryJobViaInitdb(newCluster(apiv1.ImportSource{
+			Dump: &apiv1.LogicalDumpSource{ClaimName: "dumps"},
+		}), 1)
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "logical-dump",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "dumps",
+					ReadOnly:  true,
+				},
+			},
+		}))
+		Expect(job.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "logical-dump",
+			MountPath: postgres.LogicalDumpDirectory,
+			ReadOnly:  true,
+		}))
+	})
+
+	It("doesn't mount any volume when importing from an external cluster", func() {
+		job := CreatePrimaryJobViaInitdb(newCluster(apiv1.ImportSource{ExternalCluster: "origin"}), 1)
+		for _, volume := range job.Spec.Template.Spec.Volumes {
+			Expect(volume.Name).ToNot(Equal("logical-dump"))
+		}
+	})
+})
+
+var _ = Describe("Job created via recovery", func() {
+	It("mounts the volume containing a local backup", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Local: &apiv1.LocalBackupSource{ClaimName: "lab-backup"},
+					},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "local-backup",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "lab-backup",
+					ReadOnly:  true,
+				},
+			},
+		}))
+		Expect(job.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "local-backup",
+			MountPath: postgres.LocalBackupDirectory,
+			ReadOnly:  true,
+		}))
+	})
+
+	It("doesn't mount any local backup volume by default", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		for _, volume := range job.Spec.Template.Spec.Volumes {
+			Expect(volume.Name).ToNot(Equal("local-backup"))
+		}
+	})
+
+	It("mounts the staging volume", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:  "origin",
+						Staging: &apiv1.RecoveryStaging{ClaimName: "nvme-scratch"},
+					},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "recovery-staging",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "nvme-scratch",
+				},
+			},
+		}))
+		Expect(job.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "recovery-staging",
+			MountPath: postgres.RecoveryStagingDirectory,
+		}))
+	})
+
+	It("mounts the download cache volume", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source:        "origin",
+						DownloadCache: &apiv1.RecoveryDownloadCache{ClaimName: "restore-cache"},
+					},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Volumes).To(ContainElement(corev1.Volume{
+			Name: "recovery-download-cache",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "restore-cache",
+				},
+			},
+		}))
+		Expect(job.Spec.Template.Spec.Containers[0].VolumeMounts).To(ContainElement(corev1.VolumeMount{
+			Name:      "recovery-download-cache",
+			MountPath: postgres.RecoveryDownloadCacheDirectory,
+		}))
+	})
+
+	It("uses the log level requested for the recovery", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				LogLevel: "info",
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin", LogLevel: "trace"},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement("--log-level=trace"))
+		Expect(job.Spec.Template.Spec.Containers[0].Command).ToNot(ContainElement("--log-level=info"))
+
+		job = CreatePrimaryJobViaRestoreSnapshot(cluster, 1, &metav1.ObjectMeta{}, nil)
+		Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement("--log-level=trace"))
+
+		pod := PodWithExistingStorage(cluster, 1)
+		Expect(pod.Spec.Containers[0].Command).To(ContainElement("--log-level=info"))
+	})
+
+	It("uses the log level of the cluster by default", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+			Spec: apiv1.ClusterSpec{
+				LogLevel: "debug",
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+				},
+			},
+		}
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElement("--log-level=debug"))
+	})
+})
+
+var _ = Describe("Restore concurrency limit", func() {
+	cluster := apiv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-example", Namespace: "default"},
+		Spec: apiv1.ClusterSpec{
+			Bootstrap: &apiv1.BootstrapConfiguration{
+				Recovery: &apiv1.BootstrapRecovery{Source: "origin"},
+			},
+		},
+	}
+
+	AfterEach(func() {
+		configuration.Current = configuration.NewConfiguration()
+	})
+
+	It("doesn't limit the restores by default", func() {
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Containers[0].Command).ToNot(ContainElement("--restore-concurrency-limit"))
+	})
+
+	It("passes the limit configured in the operator to the restore job", func() {
+		configuration.Current.RestoreConcurrencyLimit = 10
+		configuration.Current.OperatorNamespace = "cnpg-system"
+
+		job := CreatePrimaryJobViaRecovery(cluster, 1, nil)
+		Expect(job.Spec.Template.Spec.Containers[0].Command).To(ContainElements(
+			"--restore-concurrency-limit", "10",
+			"--restore-concurrency-namespace", "cnpg-system",
+		))
+	})
+})
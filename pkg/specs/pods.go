@@ -258,7 +258,7 @@ func createPostgresContainers(cluster apiv1.Cluster, envConfig EnvConfig, enable
 		containers[0].Command = append(containers[0].Command, "--metrics-port-tls")
 	}
 
-	addManagerLoggingOptions(cluster, &containers[0])
+	addManagerLoggingOptions(cluster.Spec.LogLevel, &containers[0])
 
 	// if user customizes the liveness probe timeout, we need to adjust the failure threshold
 	addLivenessProbeFailureThreshold(cluster, &containers[0])
@@ -154,6 +154,15 @@ func getInvolvedSecretNames(cluster apiv1.Cluster, backupOrigin *apiv1.Backup) [
 		}
 	}
 
+	// The recovery job needs to read the new passwords of the roles, if any
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil {
+		for _, reset := range cluster.Spec.Bootstrap.Recovery.PasswordResets {
+			if reset.PasswordSecret != nil {
+				involvedSecretNames = append(involvedSecretNames, reset.PasswordSecret.Name)
+			}
+		}
+	}
+
 	involvedSecretNames = append(involvedSecretNames, backupSecrets(cluster, backupOrigin)...)
 	involvedSecretNames = append(involvedSecretNames, externalClusterSecrets(cluster)...)
 	involvedSecretNames = append(involvedSecretNames, managedRolesSecrets(cluster)...)
@@ -174,6 +183,13 @@ func getInvolvedConfigMapNames(cluster apiv1.Cluster) []string {
 		}
 	}
 
+	// The recovery job needs to read the recovery settings, if any
+	if cluster.Spec.Bootstrap != nil && cluster.Spec.Bootstrap.Recovery != nil &&
+		cluster.Spec.Bootstrap.Recovery.RecoverySettings != nil {
+		involvedConfigMapNames = append(involvedConfigMapNames,
+			cluster.Spec.Bootstrap.Recovery.RecoverySettings.Name)
+	}
+
 	return cleanupResourceList(involvedConfigMapNames)
 }
 
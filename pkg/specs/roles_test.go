@@ -197,6 +197,27 @@ var _ = Describe("Roles", func() {
 	})
 })
 
+var _ = Describe("ConfigMaps", func() {
+	It("include the recovery settings ConfigMap", func() {
+		cluster := apiv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-example"},
+			Spec: apiv1.ClusterSpec{
+				Bootstrap: &apiv1.BootstrapConfiguration{
+					Recovery: &apiv1.BootstrapRecovery{
+						Source: "origin",
+						RecoverySettings: &apiv1.ConfigMapKeySelector{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "recovery-settings"},
+							Key:                  "recovery.conf",
+						},
+					},
+				},
+			},
+		}
+
+		Expect(getInvolvedConfigMapNames(cluster)).To(ConsistOf("cluster-example", "recovery-settings"))
+	})
+})
+
 var _ = Describe("Secrets", func() {
 	var (
 		cluster apiv1.Cluster
@@ -277,6 +298,25 @@ var _ = Describe("Secrets", func() {
 		Expect(secrets).To(ConsistOf("test-secret", "test-access", "test-region", "test-session", "test-endpoint-ca-name"))
 	})
 
+	It("include the new passwords of the roles reset after the recovery", func() {
+		cluster.Spec.Bootstrap = &apiv1.BootstrapConfiguration{
+			Recovery: &apiv1.BootstrapRecovery{
+				Source: "origin",
+				PasswordResets: []apiv1.RecoveryPasswordReset{
+					{
+						Name: "app",
+						PasswordSecret: &apiv1.SecretKeySelector{
+							LocalObjectReference: apiv1.LocalObjectReference{Name: "new-passwords"},
+							Key:                  "app",
+						},
+					},
+					{Name: "reporting", Randomize: true},
+				},
+			},
+		}
+		Expect(getInvolvedSecretNames(cluster, nil)).To(ContainElement("new-passwords"))
+	})
+
 	It("should contain default secrets only", func() {
 		Expect(getInvolvedSecretNames(cluster, nil)).To(Equal([]string{
 			"thisTest-app",
@@ -148,6 +148,11 @@ const (
 	// archive is empty before writing data
 	skipEmptyWalArchiveCheck = MetadataNamespace + "/skipEmptyWalArchiveCheck"
 
+	// allowArchivingToRecoverySource is the name of the annotation which allows a
+	// cluster restored from an object store to archive its WAL files in the same
+	// place the backup has been read from
+	allowArchivingToRecoverySource = MetadataNamespace + "/allowArchivingToRecoverySource"
+
 	// ClusterSerialAnnotationName is the name of the annotation containing the
 	// serial number of the node
 	ClusterSerialAnnotationName = MetadataNamespace + "/nodeSerial"
@@ -229,6 +234,16 @@ const (
 	// PluginPortAnnotationName is the name of the annotation containing the
 	// port the plugin is listening to
 	PluginPortAnnotationName = MetadataNamespace + "/pluginPort"
+
+	// PromoteRecoveryAnnotationName is the name of the annotation used to
+	// request the promotion of an instance whose recovery is paused at
+	// the recovery target
+	PromoteRecoveryAnnotationName = MetadataNamespace + "/promoteRecovery"
+
+	// RecoveryTargetAnnotationName is the name of the annotation containing,
+	// in JSON format, the recovery target to be used when the cluster is
+	// bootstrapped from a backup, taking precedence over the one in the spec
+	RecoveryTargetAnnotationName = MetadataNamespace + "/recoveryTarget"
 )
 
 type annotationStatus string
@@ -433,6 +448,19 @@ func IsWalArchivingDisabled(object *metav1.ObjectMeta) bool {
 	return object.Annotations[SkipWalArchiving] == string(annotationStatusEnabled)
 }
 
+// IsRecoveryPromotionRequested returns a boolean indicating if the promotion
+// of an instance paused at the recovery target has been requested
+func IsRecoveryPromotionRequested(object *metav1.ObjectMeta) bool {
+	return object.Annotations[PromoteRecoveryAnnotationName] == string(annotationStatusEnabled)
+}
+
+// IsArchivingToRecoverySourceAllowed returns a boolean indicating if a restored
+// cluster is allowed to archive its WAL files in the object store and server
+// name its backup has been read from
+func IsArchivingToRecoverySourceAllowed(object *metav1.ObjectMeta) bool {
+	return object.Annotations[allowArchivingToRecoverySource] == string(annotationStatusEnabled)
+}
+
 func mergeMap(receiver, giver map[string]string) map[string]string {
 	for key, value := range giver {
 		receiver[key] = value